@@ -111,6 +111,56 @@ func main() {
 			label:             "UpdateTransaction",
 			interfaceInstance: &models.UpdateTransaction{}})
 
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "UpdateHook",
+			interfaceInstance: &models.UpdateHook{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "UpdatePolicy",
+			interfaceInstance: &models.UpdatePolicy{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "UpdatePolicyRun",
+			interfaceInstance: &models.UpdatePolicyRun{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "DeviceGroupDesiredState",
+			interfaceInstance: &models.DeviceGroupDesiredState{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "UpdateRollback",
+			interfaceInstance: &models.UpdateRollback{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "DeviceEvent",
+			interfaceInstance: &models.DeviceEvent{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "DeviceStalenessSettings",
+			interfaceInstance: &models.DeviceStalenessSettings{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "UpdateApprovalSettings",
+			interfaceInstance: &models.UpdateApprovalSettings{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "DeviceAction",
+			interfaceInstance: &models.DeviceAction{}})
+
+	modelsInterfaces = append(modelsInterfaces,
+		ModelInterface{
+			label:             "DeviceActionPlaybook",
+			interfaceInstance: &models.DeviceActionPlaybook{}})
+
 	for modelsIndex, modelsInterface := range modelsInterfaces {
 		log.Debugf("Migrating Model %d: %s", modelsIndex, modelsInterface.label)
 
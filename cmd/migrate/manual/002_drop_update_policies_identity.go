@@ -0,0 +1,59 @@
+package manual
+
+import (
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	registerMigration("drop update policies identity (002)", dropUpdatePoliciesIdentity002)
+}
+
+func dropUpdatePoliciesIdentity002() error {
+	return replaceIdentityWithPrincipal(&models.UpdatePolicy{}, "update_policies")
+}
+
+// replaceIdentityWithPrincipal keeps only the principal name of the raw identities stored in a table, then drops them
+func replaceIdentityWithPrincipal(model interface{}, table string) error {
+	migrator := db.DB.Migrator()
+	if !migrator.HasColumn(model, "identity") {
+		log.Infof("Column identity does not exist in %s", table)
+		return nil
+	}
+	if !migrator.HasColumn(model, "principal") {
+		log.Infof("Adding principal column to %s", table)
+		if err := migrator.AddColumn(model, "Principal"); err != nil {
+			return err
+		}
+	}
+
+	var rows []struct {
+		ID       uint
+		Identity string
+	}
+	if err := db.DB.Table(table).Select("id", "identity").Where("identity <> ''").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		id, err := identity.DecodeIdentity(row.Identity)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err.Error(), "id": row.ID}).Warningf("Failed decoding identity of %s", table)
+			continue
+		}
+		var principal string
+		switch {
+		case id.Identity.User != nil:
+			principal = id.Identity.User.Username
+		case id.Identity.ServiceAccount != nil:
+			principal = id.Identity.ServiceAccount.Username
+		}
+		if err := db.DB.Table(table).Where("id = ?", row.ID).Update("principal", principal).Error; err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Dropping identity column from %s", table)
+	return migrator.DropColumn(model, "identity")
+}
@@ -0,0 +1,13 @@
+package manual
+
+import (
+	"github.com/redhatinsights/edge-api/pkg/models"
+)
+
+func init() {
+	registerMigration("drop device groups identity (003)", dropDeviceGroupsIdentity003)
+}
+
+func dropDeviceGroupsIdentity003() error {
+	return replaceIdentityWithPrincipal(&models.DeviceGroup{}, "device_groups")
+}
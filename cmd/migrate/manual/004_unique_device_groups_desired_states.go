@@ -0,0 +1,44 @@
+package manual
+
+import (
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	registerMigration("unique device groups desired states (004)", uniqueDeviceGroupsDesiredStates004)
+}
+
+// uniqueDeviceGroupsDesiredStates004 keeps the latest desired state of each device group and drops the non unique
+// device group index, the auto migration creates the unique one
+func uniqueDeviceGroupsDesiredStates004() error {
+	model := &models.DeviceGroupDesiredState{}
+	indexName := "idx_device_group_desired_states_device_group_id"
+	migrator := db.DB.Migrator()
+	if !migrator.HasIndex(model, indexName) {
+		log.Infof("Index %s does not exist", indexName)
+		return nil
+	}
+	indexes, err := migrator.GetIndexes(model)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if unique, _ := index.Unique(); index.Name() == indexName && unique {
+			log.Infof("Index %s is already unique", indexName)
+			return nil
+		}
+	}
+
+	log.Info("Deleting the deleted and the previous desired states of the device groups")
+	if err := db.DB.Unscoped().Where("deleted_at IS NOT NULL").Delete(model).Error; err != nil {
+		return err
+	}
+	latestIDs := db.DB.Model(model).Select("MAX(id)").Group("device_group_id")
+	if err := db.DB.Unscoped().Where("id NOT IN (?)", latestIDs).Delete(model).Error; err != nil {
+		return err
+	}
+	log.Infof("Dropping index %s", indexName)
+	return migrator.DropIndex(model, indexName)
+}
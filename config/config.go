@@ -93,6 +93,26 @@ type EdgeConfig struct {
 	PulpGuardSubjectDN         string                    `json:"pulp_guard_subject_dn,omitempty"`
 	Pulp                       Pulp                      `json:"pulp"`
 	CleanupBatchSize           int                       `json:"cleanup_batch_size,omitempty"`
+	EventsStreamInterval       int                       `json:"events_stream_interval,omitempty"`
+	EventsStreamTimeout        int                       `json:"events_stream_timeout,omitempty"`
+	DispatchRecordTimeout      int                       `json:"dispatch_record_timeout,omitempty"`
+	DispatchRecordInterval     int                       `json:"dispatch_record_interval,omitempty"`
+	PlaybookSigningKey         string                    `json:"-"`
+	PlaybookSigningKeyPath     string                    `json:"playbook_signing_key_path,omitempty"`
+	PlaybookSigningKeyID       string                    `json:"playbook_signing_key_id,omitempty"`
+	PlaybookSigningPassphrase  string                    `json:"-"`
+	StaticDeltaPregenCount     int                       `json:"static_delta_pregen_count,omitempty"`
+	GCInterval                 int                       `json:"gc_interval,omitempty"`
+	GCDryRun                   bool                      `json:"gc_dry_run,omitempty"`
+	GCKeepVersions             int                       `json:"gc_keep_versions,omitempty"`
+	GCKeepDays                 int                       `json:"gc_keep_days,omitempty"`
+	UpdatePolicyInterval       int                       `json:"update_policy_interval,omitempty"`
+	DynamicGroupsInterval      int                       `json:"dynamic_groups_interval,omitempty"`
+	InventoryProfileFields     []string                  `json:"inventory_profile_fields,omitempty"`
+	DeviceStaleHours           int                       `json:"device_stale_hours,omitempty"`
+	DeviceCulledHours          int                       `json:"device_culled_hours,omitempty"`
+	DeviceStalenessInterval    int                       `json:"device_staleness_interval,omitempty"`
+	DisconnectedAlertPercent   int                       `json:"disconnected_alert_percent,omitempty"`
 }
 
 type dbConfig struct {
@@ -242,6 +262,26 @@ func CreateEdgeAPIConfig() (*EdgeConfig, error) {
 	options.SetDefault("RBAC_BASE_URL", "http://rbac-service:8080")
 	options.SetDefault("RbacTimeout", 30)
 	options.SetDefault("CleanupBatchSize", "500")
+	options.SetDefault("EventsStreamInterval", 2)
+	options.SetDefault("EventsStreamTimeout", 600)
+	options.SetDefault("DispatchRecordTimeout", 240)
+	options.SetDefault("DispatchRecordInterval", 15)
+	options.SetDefault("PlaybookSigningKey", "")
+	options.SetDefault("PlaybookSigningKeyPath", "")
+	options.SetDefault("PlaybookSigningKeyID", "")
+	options.SetDefault("PlaybookSigningPassphrase", "")
+	options.SetDefault("StaticDeltaPregenCount", 3)
+	options.SetDefault("GCInterval", 1440)
+	options.SetDefault("GCDryRun", false)
+	options.SetDefault("GCKeepVersions", 3)
+	options.SetDefault("GCKeepDays", 30)
+	options.SetDefault("UpdatePolicyInterval", 5)
+	options.SetDefault("DynamicGroupsInterval", 10)
+	options.SetDefault("InventoryProfileFields", "arch,os_release,os_kernel_version,cpu,memory,host_type,network_interfaces,tags,rpm_ostree_deployments")
+	options.SetDefault("DeviceStaleHours", 26)
+	options.SetDefault("DeviceCulledHours", 336)
+	options.SetDefault("DeviceStalenessInterval", 60)
+	options.SetDefault("DisconnectedAlertPercent", 50)
 	options.AutomaticEnv()
 
 	if options.GetBool("Debug") {
@@ -350,6 +390,26 @@ func CreateEdgeAPIConfig() (*EdgeConfig, error) {
 		PulpS3AccessKey:            pulpConfig.S3AccessKey,
 		PulpGuardSubjectDN:         pulpConfig.GuardSubjectDN,
 		CleanupBatchSize:           options.GetInt("CleanupBatchSize"),
+		EventsStreamInterval:       options.GetInt("EventsStreamInterval"),
+		EventsStreamTimeout:        options.GetInt("EventsStreamTimeout"),
+		DispatchRecordTimeout:      options.GetInt("DispatchRecordTimeout"),
+		DispatchRecordInterval:     options.GetInt("DispatchRecordInterval"),
+		PlaybookSigningKey:         options.GetString("PlaybookSigningKey"),
+		PlaybookSigningKeyPath:     options.GetString("PlaybookSigningKeyPath"),
+		PlaybookSigningKeyID:       options.GetString("PlaybookSigningKeyID"),
+		PlaybookSigningPassphrase:  options.GetString("PlaybookSigningPassphrase"),
+		StaticDeltaPregenCount:     options.GetInt("StaticDeltaPregenCount"),
+		GCInterval:                 options.GetInt("GCInterval"),
+		GCDryRun:                   options.GetBool("GCDryRun"),
+		GCKeepVersions:             options.GetInt("GCKeepVersions"),
+		GCKeepDays:                 options.GetInt("GCKeepDays"),
+		UpdatePolicyInterval:       options.GetInt("UpdatePolicyInterval"),
+		DynamicGroupsInterval:      options.GetInt("DynamicGroupsInterval"),
+		InventoryProfileFields:     splitConfigList(options.GetString("InventoryProfileFields")),
+		DeviceStaleHours:           options.GetInt("DeviceStaleHours"),
+		DeviceCulledHours:          options.GetInt("DeviceCulledHours"),
+		DeviceStalenessInterval:    options.GetInt("DeviceStalenessInterval"),
+		DisconnectedAlertPercent:   options.GetInt("DisconnectedAlertPercent"),
 	}
 
 	// this allows dot notation to be used before a full config refactor
@@ -511,6 +571,17 @@ func Cleanup() {
 	config = nil
 }
 
+// splitConfigList returns the values of a comma separated config list
+func splitConfigList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
 // GetConfigValues return all configuration values that may be used for logging
 func GetConfigValues() (map[string]interface{}, error) {
 	var configValues map[string]interface{}
@@ -568,6 +639,24 @@ func LogConfigAtStartup(cfg *EdgeConfig) {
 		"PulpURL":                  cfg.PulpURL,
 		"PulpContentURL":           cfg.PulpContentURL,
 		"PulpGuardSubjectDN":       cfg.PulpGuardSubjectDN,
+		"EventsStreamInterval":     cfg.EventsStreamInterval,
+		"EventsStreamTimeout":      cfg.EventsStreamTimeout,
+		"DispatchRecordTimeout":    cfg.DispatchRecordTimeout,
+		"DispatchRecordInterval":   cfg.DispatchRecordInterval,
+		"PlaybookSigningKeyPath":   cfg.PlaybookSigningKeyPath,
+		"PlaybookSigningKeyID":     cfg.PlaybookSigningKeyID,
+		"StaticDeltaPregenCount":   cfg.StaticDeltaPregenCount,
+		"GCInterval":               cfg.GCInterval,
+		"GCDryRun":                 cfg.GCDryRun,
+		"GCKeepVersions":           cfg.GCKeepVersions,
+		"GCKeepDays":               cfg.GCKeepDays,
+		"UpdatePolicyInterval":     cfg.UpdatePolicyInterval,
+		"DynamicGroupsInterval":    cfg.DynamicGroupsInterval,
+		"InventoryProfileFields":   cfg.InventoryProfileFields,
+		"DeviceStaleHours":         cfg.DeviceStaleHours,
+		"DeviceCulledHours":        cfg.DeviceCulledHours,
+		"DeviceStalenessInterval":  cfg.DeviceStalenessInterval,
+		"DisconnectedAlertPercent": cfg.DisconnectedAlertPercent,
 	}
 
 	// loop through the key/value pairs
@@ -22,6 +22,14 @@ func WithCustomIdentityType(ctx context.Context, orgID string, identityType stri
 	return rhidentity.WithIdentity(ctx, id)
 }
 
+// WithSystemIdentity returns a context with the system identity of a device certificate of common name cn
+func WithSystemIdentity(ctx context.Context, orgID string, cn string) context.Context {
+	id := newIdentity(orgID, pointy.Pointer(common.DefaultAccount), common.IdentityTypeSystem)
+	id.Identity.User = nil
+	id.Identity.System = &rhidentity.System{CommonName: cn, CertType: "system"}
+	return rhidentity.WithIdentity(ctx, id)
+}
+
 func WithRawIdentity(ctx context.Context, orgID string) context.Context {
 	id := newIdentity(orgID, pointy.Pointer(common.DefaultAccount), "User")
 	rawID, err := json.Marshal(id)
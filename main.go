@@ -141,6 +141,9 @@ func webRoutes(cfg *config.EdgeConfig) *chi.Mux {
 			s.Route("/devices", routes.MakeDevicesRouter)
 			s.Route("/thirdpartyrepo", routes.MakeThirdPartyRepoRouter)
 			s.Route("/device-groups", routes.MakeDeviceGroupsRouter)
+			s.Route("/device-actions", routes.MakeDeviceActionsRouter)
+			// the device routes are requested by the devices with their certificate on the cert API
+			s.Route("/device", routes.MakeDeviceRouter)
 
 			// this is meant for testing the job queue
 			s.Post("/ops/jobs/noop", services.CreateNoopJob)
@@ -262,6 +265,12 @@ func main() {
 
 	defer routes.UpdateTransCache.Stop()
 
+	go services.ScheduleUnresponsiveDispatchRecordsJob(ctx)
+	go services.ScheduleGarbageCollectionJob(ctx)
+	go services.ScheduleUpdatePoliciesJob(ctx)
+	go services.ScheduleDynamicDeviceGroupsJob(ctx)
+	go services.ScheduleDeviceStalenessJob(ctx)
+
 	consumers := []services.ConsumerService{
 		services.NewKafkaConsumerService(cfg.KafkaConfig, kafkacommon.TopicPlaybookDispatcherRuns),
 		services.NewKafkaConsumerService(cfg.KafkaConfig, kafkacommon.TopicInventoryEvents),
@@ -65,9 +65,11 @@ type Group struct {
 
 // SystemProfile represents the struct of a SystemProfile on Inventory API
 type SystemProfile struct {
-	RHCClientID          string   `json:"rhc_client_id"`
-	RpmOstreeDeployments []OSTree `json:"rpm_ostree_deployments"`
-	HostType             string   `json:"host_type"`
+	RHCClientID               string   `json:"rhc_client_id"`
+	RpmOstreeDeployments      []OSTree `json:"rpm_ostree_deployments"`
+	HostType                  string   `json:"host_type"`
+	GreenbootStatus           string   `json:"greenboot_status,omitempty"`
+	GreenbootFallbackDetected bool     `json:"greenboot_fallback_detected,omitempty"`
 }
 
 // OSTree represents the struct of a SystemProfile on Inventory API
@@ -80,6 +82,8 @@ const (
 	inventoryAPI = "api/inventory/v1/hosts"
 	orderBy      = "updated"
 	orderHow     = "DESC"
+	// tagsPageSize is the number of hosts requested per page when filtering by tag
+	tagsPageSize = 100
 	// Fields represents field we get from inventory
 	Fields = "host_type,operating_system,greenboot_status,greenboot_fallback_detected,rpm_ostree_deployments,rhc_client_id,rhc_config_state"
 	// FilterParams represents params to retrieve data from inventory
@@ -268,10 +272,28 @@ func (c *Client) ReturnDeviceListByID(deviceIDs []string) (Response, error) {
 
 }
 
-// ReturnDevicesByTag will return the list of devices by tag
+// ReturnDevicesByTag will return the list of devices by tag, reading all the result pages
 func (c *Client) ReturnDevicesByTag(tag string) (Response, error) {
-	tagsParam := "?tags=" + tag
-	url := fmt.Sprintf("%s/%s%s%s", config.Get().InventoryConfig.URL, inventoryAPI, FilterParams, tagsParam)
+	var inventory Response
+	for page := 1; ; page++ {
+		response, err := c.returnDevicesByTagPage(tag, page)
+		if err != nil {
+			return Response{}, err
+		}
+		inventory.Total = response.Total
+		inventory.Result = append(inventory.Result, response.Result...)
+		inventory.Count = len(inventory.Result)
+		if len(response.Result) == 0 || inventory.Count >= response.Total {
+			break
+		}
+	}
+	return inventory, nil
+}
+
+// returnDevicesByTagPage returns a page of the devices by tag
+func (c *Client) returnDevicesByTagPage(tag string, page int) (Response, error) {
+	url := fmt.Sprintf("%s/%s%s&tags=%s&per_page=%d&page=%d",
+		config.Get().InventoryConfig.URL, inventoryAPI, FilterParams, tag, tagsPageSize, page)
 	c.log.WithFields(log.Fields{
 		"url": url,
 	}).Info("Inventory ReturnDevicesByTag Request Started")
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 
 	"github.com/redhatinsights/edge-api/config"
 	"github.com/redhatinsights/edge-api/pkg/clients/inventory"
@@ -61,4 +62,43 @@ var _ = Describe("Inventory Client Tests", func() {
 			Expect(result.Result[0].ID).To(Equal(deviceUUID))
 		})
 	})
+	Context("ReturnDevicesByTag", func() {
+
+		It("should filter the inventory hosts by tag and read all the pages", func() {
+			tag := "site/city=paris"
+			devicesUUIDs := []string{faker.UUIDHyphenated(), faker.UUIDHyphenated(), faker.UUIDHyphenated()}
+			requestedPages := []string{}
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				urlQueryValues := r.URL.Query()
+				Expect(urlQueryValues.Get("filter[system_profile][host_type]")).To(Equal("edge"))
+				Expect(urlQueryValues.Get("tags")).To(Equal(tag))
+				Expect(urlQueryValues.Get("per_page")).To(Equal("100"))
+				page := urlQueryValues.Get("page")
+				requestedPages = append(requestedPages, page)
+				response := inventory.Response{Total: len(devicesUUIDs)}
+				if page == "1" {
+					response.Result = []inventory.Device{{ID: devicesUUIDs[0]}, {ID: devicesUUIDs[1]}}
+				} else {
+					response.Result = []inventory.Device{{ID: devicesUUIDs[2]}}
+				}
+				response.Count = len(response.Result)
+				w.WriteHeader(http.StatusOK)
+				err := json.NewEncoder(w).Encode(&response)
+				Expect(err).ToNot(HaveOccurred())
+			}))
+			defer ts.Close()
+			config.Get().InventoryConfig.URL = ts.URL
+			result, err := client.ReturnDevicesByTag(url.QueryEscape(tag))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestedPages).To(Equal([]string{"1", "2"}))
+			Expect(result.Total).To(Equal(3))
+			Expect(result.Count).To(Equal(3))
+			Expect(len(result.Result)).To(Equal(3))
+			for index, device := range result.Result {
+				Expect(device.ID).To(Equal(devicesUUIDs[index]))
+			}
+		})
+	})
 })
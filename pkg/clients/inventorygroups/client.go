@@ -10,6 +10,7 @@ import (
 	"net/http"
 	url2 "net/url"
 	"strconv"
+	"strings"
 
 	"github.com/redhatinsights/edge-api/config"
 	"github.com/redhatinsights/edge-api/pkg/clients"
@@ -79,6 +80,7 @@ type ClientInterface interface {
 	GetGroupByUUID(groupUUID string) (*Group, error)
 	CreateGroup(groupName string, hostIDS []string) (*Group, error) // nolint:revive
 	AddHostsToGroup(groupUUID string, hosts []string) (*Group, error)
+	RemoveHostsFromGroup(groupUUID string, hosts []string) error
 	ListGroups(requestParams ListGroupsParams) (*Response, error)
 }
 
@@ -354,3 +356,45 @@ func (c *Client) AddHostsToGroup(groupUUID string, hosts []string) (*Group, erro
 
 	return &group, nil
 }
+
+// RemoveHostsFromGroup removes hosts from an inventory group, the hosts are left without inventory group
+func (c *Client) RemoveHostsFromGroup(groupUUID string, hosts []string) error {
+	if groupUUID == "" {
+		c.log.Error("inventory group uuid is mandatory")
+		return ErrGroupUUIDIsMandatory
+	}
+
+	if len(hosts) == 0 {
+		c.log.Error("inventory group hosts are mandatory")
+		return ErrGroupHostsAreMandatory
+	}
+
+	groupsURL, err := c.GetBaseURL()
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf("%s/%s/hosts/%s", groupsURL.String(), groupUUID, strings.Join(hosts, ","))
+	c.log.WithField("url", requestURL).Info("inventory remove group hosts request started")
+
+	req, _ := http.NewRequest(http.MethodDelete, requestURL, nil)
+	headers := clients.GetOutgoingHeaders(c.ctx)
+	for key, value := range headers {
+		req.Header.Add(key, value)
+	}
+	client := clients.ConfigureClientWithTLS(&http.Client{})
+	res, err := client.Do(req)
+	if err != nil {
+		c.log.WithField("error", err.Error()).Error("inventory remove group hosts request error")
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		body, _ := IOReadAll(res.Body)
+		c.log.WithFields(log.Fields{"statusCode": res.StatusCode, "responseBody": string(body)}).Error("inventory remove group hosts error response")
+		return ErrGroupsRequestResponse
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/redhatinsights/edge-api/config"
@@ -762,3 +763,87 @@ func TestAddHostsToGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveHostsFromGroup(t *testing.T) {
+	initialInventoryURL := config.Get().InventoryConfig.URL
+
+	// restore the initial inventory url
+	defer func(inventoryURL string) {
+		config.Get().InventoryConfig.URL = inventoryURL
+	}(initialInventoryURL)
+
+	groupUUID := faker.UUIDHyphenated()
+	hostsToRemove := []string{faker.UUIDHyphenated(), faker.UUIDHyphenated()}
+
+	testCases := []struct {
+		Name          string
+		InventoryURL  string
+		GroupUUID     string
+		HostsToRemove []string
+		HTTPStatus    int
+		ExpectedError error
+	}{
+		{
+			Name:          "should remove hosts from group successfully",
+			GroupUUID:     groupUUID,
+			HostsToRemove: hostsToRemove,
+			HTTPStatus:    http.StatusNoContent,
+		},
+		{
+			Name:          "should return error when group uuid is empty",
+			GroupUUID:     "",
+			HostsToRemove: hostsToRemove,
+			HTTPStatus:    http.StatusNoContent,
+			ExpectedError: inventorygroups.ErrGroupUUIDIsMandatory,
+		},
+		{
+			Name:          "should return error when no hosts supplied",
+			GroupUUID:     groupUUID,
+			HostsToRemove: []string{},
+			HTTPStatus:    http.StatusNoContent,
+			ExpectedError: inventorygroups.ErrGroupHostsAreMandatory,
+		},
+		{
+			Name:          "should return error when http status is not 204",
+			GroupUUID:     groupUUID,
+			HostsToRemove: hostsToRemove,
+			HTTPStatus:    http.StatusNotFound,
+			ExpectedError: inventorygroups.ErrGroupsRequestResponse,
+		},
+		{
+			Name:          "should return error when parsing base url fails",
+			GroupUUID:     groupUUID,
+			HostsToRemove: hostsToRemove,
+			InventoryURL:  "\t",
+			HTTPStatus:    http.StatusNoContent,
+			ExpectedError: inventorygroups.ErrParsingURL,
+		},
+	}
+
+	for _, testCase := range testCases {
+		// avoid Implicit memory aliasing
+		testCase := testCase
+		t.Run(testCase.Name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodDelete, r.Method)
+				assert.Equal(t, fmt.Sprintf("/%s/%s/hosts/%s", inventorygroups.BasePath, groupUUID, strings.Join(hostsToRemove, ",")), r.URL.Path)
+				w.WriteHeader(testCase.HTTPStatus)
+			}))
+			defer ts.Close()
+
+			if testCase.InventoryURL == "" {
+				config.Get().InventoryConfig.URL = ts.URL
+			} else {
+				config.Get().InventoryConfig.URL = testCase.InventoryURL
+			}
+
+			client := inventorygroups.InitClient(context.Background(), log.NewEntry(log.StandardLogger()))
+			err := client.RemoveHostsFromGroup(testCase.GroupUUID, testCase.HostsToRemove)
+			if testCase.ExpectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, testCase.ExpectedError.Error())
+			}
+		})
+	}
+}
@@ -124,3 +124,17 @@ func (mr *MockClientInterfaceMockRecorder) ListGroups(requestParams interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockClientInterface)(nil).ListGroups), requestParams)
 }
+
+// RemoveHostsFromGroup mocks base method.
+func (m *MockClientInterface) RemoveHostsFromGroup(groupUUID string, hosts []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveHostsFromGroup", groupUUID, hosts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveHostsFromGroup indicates an expected call of RemoveHostsFromGroup.
+func (mr *MockClientInterfaceMockRecorder) RemoveHostsFromGroup(groupUUID, hosts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveHostsFromGroup", reflect.TypeOf((*MockClientInterface)(nil).RemoveHostsFromGroup), groupUUID, hosts)
+}
@@ -41,6 +41,18 @@ func (a Access) AccessType() string {
 	return permIndex(a.Permission, 2)
 }
 
+// IsAllowed returns whether the access list allows the accessType access to the application resource, the resource
+// definitions are not considered
+func (l AccessList) IsAllowed(application Application, resource ResourceType, accessType AccessType) bool {
+	for _, access := range l {
+		if access.Application() == string(application) && ResourceMatch(ResourceType(access.Resource()), resource) &&
+			AccessMatch(AccessType(access.AccessType()), accessType) {
+			return true
+		}
+	}
+	return false
+}
+
 // permIndex return the permission item value at index when splitting by permission delimiter
 // the permission looks like "inventory:hosts:read" where:
 // inventory is the application name and locate at index 0
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	url2 "net/url"
+	"strconv"
 	"time"
 
 	"github.com/redhatinsights/edge-api/config"
@@ -20,6 +21,7 @@ var ErrCreatingRbacURL = errors.New("error occurred when creating rbac url")
 var ErrInvalidAttributeFilterKey = errors.New("invalid value for attributeFilter.key in RBAC response")
 var ErrInvalidAttributeFilterOperation = errors.New("invalid value for attributeFilter.operation in RBAC response")
 var ErrInvalidAttributeFilterValue = errors.New("received invalid UUIDs for attributeFilter.value in RBAC response")
+var ErrInvalidDeviceGroupsFilterValue = errors.New("received invalid device group ids for attributeFilter.value in RBAC response")
 var ErrFailedToBuildAccessRequest = errors.New("failed to build access request")
 var ErrRbacRequestResponse = errors.New("rbac response error")
 
@@ -37,17 +39,29 @@ type AccessType string
 type Application string
 
 const (
-	AccessTypeAny  AccessType = "*"
-	AccessTypeRead AccessType = "read"
+	AccessTypeAny   AccessType = "*"
+	AccessTypeRead  AccessType = "read"
+	AccessTypeWrite AccessType = "write"
+	// AccessTypeExecute is the access type needed to run a device action playbook
+	AccessTypeExecute AccessType = "execute"
 )
 
-const ApplicationInventory Application = "inventory"
+const (
+	ApplicationEdge      Application = "edge"
+	ApplicationInventory Application = "inventory"
+)
 
 const (
-	ResourceTypeAny   ResourceType = "*"
-	ResourceTypeHOSTS ResourceType = "hosts"
+	ResourceTypeAny          ResourceType = "*"
+	ResourceTypeHOSTS        ResourceType = "hosts"
+	ResourceTypeDeviceGroups ResourceType = "device-groups"
+	// ResourceTypeDeviceActionPlaybooks is the resource of the playbooks uploaded by the org to run on its devices
+	ResourceTypeDeviceActionPlaybooks ResourceType = "device-action-playbooks"
 )
 
+// DeviceGroupsFilterKey is the resource definition filter key of the device groups ids
+const DeviceGroupsFilterKey = "device_group.id"
+
 const DefaultTimeDuration = 1 * time.Second
 
 // PaginationLimit to get a maximum of 1000 records
@@ -79,6 +93,7 @@ type PaginationLinks struct {
 type ClientInterface interface {
 	GetAccessList(application Application) (AccessList, error)
 	GetInventoryGroupsAccess(acl AccessList, resource ResourceType, accessType AccessType) (bool, []string, bool, error)
+	GetDeviceGroupsAccess(acl AccessList, resource ResourceType, accessType AccessType) (bool, []uint, error)
 }
 
 // Client is the implementation of an ClientInterface
@@ -236,6 +251,67 @@ func (c *Client) GetInventoryGroupsAccess(acl AccessList, resource ResourceType,
 	return allowedAccess, overallGroupIDs, globalUnGroupedHosts, nil
 }
 
+// getDeviceGroupsFromResourceDefinition validate and return the device groups ids of the resource definition
+func (c *Client) getDeviceGroupsFromResourceDefinition(resourceDefinition ResourceDefinition) ([]uint, error) {
+	if resourceDefinition.Filter.Key != DeviceGroupsFilterKey {
+		c.log.WithField("filter-key", resourceDefinition.Filter.Key).Error("received an unexpected resource filter key value")
+		return nil, ErrInvalidAttributeFilterKey
+	}
+	if resourceDefinition.Filter.Operation != "in" {
+		c.log.WithField("filter-operation", resourceDefinition.Filter.Operation).Error("received an unexpected resource filter operation value")
+		return nil, ErrInvalidAttributeFilterOperation
+	}
+	groupsIDs := make([]uint, 0, len(resourceDefinition.Filter.Value))
+	for _, value := range resourceDefinition.Filter.Value {
+		if value == nil {
+			c.log.Error("received an empty device group id")
+			return nil, ErrInvalidDeviceGroupsFilterValue
+		}
+		groupID, err := strconv.ParseUint(*value, 10, 0)
+		if err != nil || groupID == 0 {
+			c.log.WithField("filter-value", *value).Error("error occurred while parsing device group id value")
+			return nil, ErrInvalidDeviceGroupsFilterValue
+		}
+		groupsIDs = append(groupsIDs, uint(groupID))
+	}
+	return groupsIDs, nil
+}
+
+// GetDeviceGroupsAccess return whether access is allowed and the device groups ids the access is restricted to,
+// the device groups ids are nil when the access is granted to all the device groups
+func (c *Client) GetDeviceGroupsAccess(acl AccessList, resource ResourceType, accessType AccessType) (bool, []uint, error) {
+	var overallGroupIDs []uint
+	var overallGroupIDSMap = make(map[uint]bool)
+	var allowedAccess bool
+	for _, ac := range acl {
+		if ac.Application() == string(ApplicationEdge) && ResourceMatch(ResourceType(ac.Resource()), resource) && AccessMatch(AccessType(ac.AccessType()), accessType) {
+			allowedAccess = true
+			if len(ac.ResourceDefinitions) == 0 {
+				// global access to all the device groups in the context of this access type
+				overallGroupIDs = nil
+				break
+			}
+			for _, resourceDef := range ac.ResourceDefinitions {
+				groupsIDs, err := c.getDeviceGroupsFromResourceDefinition(resourceDef)
+				if err != nil {
+					return false, nil, err
+				}
+				for _, groupID := range groupsIDs {
+					if _, ok := overallGroupIDSMap[groupID]; !ok {
+						overallGroupIDSMap[groupID] = true
+						overallGroupIDs = append(overallGroupIDs, groupID)
+					}
+				}
+			}
+			if overallGroupIDs == nil {
+				// the resource definitions restrict the access to no device group
+				overallGroupIDs = []uint{}
+			}
+		}
+	}
+	return allowedAccess, overallGroupIDs, nil
+}
+
 // AccessMatch return whether the access type matches the required resource type
 func AccessMatch(access1, access2 AccessType) bool {
 	return access1 == access2 || access1 == AccessTypeAny
@@ -456,4 +456,172 @@ var _ = Describe("Rbac Client", func() {
 			Expect(allowedAccess).To(BeFalse())
 		})
 	})
+
+	Context("GetDeviceGroupsAccess", func() {
+
+		It("it should return the expected device groups successfully", func() {
+			acl := rbac.AccessList{
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{
+						{
+							Filter: rbac.ResourceDefinitionFilter{
+								Key:       rbac.DeviceGroupsFilterKey,
+								Operation: "in",
+								Value:     []*string{StringPointer("1"), StringPointer("2")},
+							},
+						},
+					},
+					Permission: "edge:device-groups:write",
+				},
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{
+						{
+							Filter: rbac.ResourceDefinitionFilter{
+								Key:       rbac.DeviceGroupsFilterKey,
+								Operation: "in",
+								Value:     []*string{StringPointer("2"), StringPointer("3")},
+							},
+						},
+					},
+					Permission: "edge:*:*",
+				},
+				// should not be taken into account
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{
+						{
+							Filter: rbac.ResourceDefinitionFilter{
+								Key:       rbac.DeviceGroupsFilterKey,
+								Operation: "in",
+								Value:     []*string{StringPointer("4")},
+							},
+						},
+					},
+					Permission: "edge:device-groups:read",
+				},
+			}
+			allowedAccess, groupsIDs, err := client.GetDeviceGroupsAccess(acl, rbac.ResourceTypeDeviceGroups, rbac.AccessTypeWrite)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowedAccess).To(BeTrue())
+			Expect(groupsIDs).To(Equal([]uint{1, 2, 3}))
+		})
+
+		It("it should allow access to all device groups when resources is empty", func() {
+			acl := rbac.AccessList{
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{
+						{
+							Filter: rbac.ResourceDefinitionFilter{
+								Key:       rbac.DeviceGroupsFilterKey,
+								Operation: "in",
+								Value:     []*string{StringPointer("1")},
+							},
+						},
+					},
+					Permission: "edge:device-groups:read",
+				},
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{},
+					Permission:          "edge:device-groups:*",
+				},
+			}
+			allowedAccess, groupsIDs, err := client.GetDeviceGroupsAccess(acl, rbac.ResourceTypeDeviceGroups, rbac.AccessTypeRead)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowedAccess).To(BeTrue())
+			Expect(groupsIDs).To(BeNil())
+		})
+
+		It("it should not allow access when no permission match", func() {
+			acl := rbac.AccessList{
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{},
+					Permission:          "edge:device-groups:read",
+				},
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{},
+					Permission:          "inventory:*:*",
+				},
+			}
+			allowedAccess, groupsIDs, err := client.GetDeviceGroupsAccess(acl, rbac.ResourceTypeDeviceGroups, rbac.AccessTypeWrite)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowedAccess).To(BeFalse())
+			Expect(groupsIDs).To(BeNil())
+		})
+
+		It("it should return an empty device groups list when the filter value is empty", func() {
+			acl := rbac.AccessList{
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{
+						{
+							Filter: rbac.ResourceDefinitionFilter{
+								Key:       rbac.DeviceGroupsFilterKey,
+								Operation: "in",
+								Value:     []*string{},
+							},
+						},
+					},
+					Permission: "edge:device-groups:read",
+				},
+			}
+			allowedAccess, groupsIDs, err := client.GetDeviceGroupsAccess(acl, rbac.ResourceTypeDeviceGroups, rbac.AccessTypeRead)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowedAccess).To(BeTrue())
+			Expect(groupsIDs).ToNot(BeNil())
+			Expect(groupsIDs).To(BeEmpty())
+		})
+
+		It("it should return error when filter key is invalid", func() {
+			acl := rbac.AccessList{
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{
+						{
+							Filter: rbac.ResourceDefinitionFilter{
+								Key:       "group.id",
+								Operation: "in",
+								Value:     []*string{StringPointer("1")},
+							},
+						},
+					},
+					Permission: "edge:device-groups:read",
+				},
+			}
+			_, _, err := client.GetDeviceGroupsAccess(acl, rbac.ResourceTypeDeviceGroups, rbac.AccessTypeRead)
+			Expect(err).To(MatchError(rbac.ErrInvalidAttributeFilterKey))
+		})
+
+		It("it should return error when filter value is not a device group id", func() {
+			acl := rbac.AccessList{
+				rbac.Access{
+					ResourceDefinitions: []rbac.ResourceDefinition{
+						{
+							Filter: rbac.ResourceDefinitionFilter{
+								Key:       rbac.DeviceGroupsFilterKey,
+								Operation: "in",
+								Value:     []*string{StringPointer(faker.UUIDHyphenated())},
+							},
+						},
+					},
+					Permission: "edge:device-groups:read",
+				},
+			}
+			_, _, err := client.GetDeviceGroupsAccess(acl, rbac.ResourceTypeDeviceGroups, rbac.AccessTypeRead)
+			Expect(err).To(MatchError(rbac.ErrInvalidDeviceGroupsFilterValue))
+		})
+	})
+
+	Context("AccessList IsAllowed", func() {
+		acl := rbac.AccessList{
+			rbac.Access{Permission: "edge:device-groups:*"},
+			rbac.Access{Permission: "edge:device-action-playbooks:write"},
+		}
+
+		It("should allow the access matching a permission", func() {
+			Expect(acl.IsAllowed(rbac.ApplicationEdge, rbac.ResourceTypeDeviceActionPlaybooks, rbac.AccessTypeWrite)).To(BeTrue())
+			Expect(acl.IsAllowed(rbac.ApplicationEdge, rbac.ResourceTypeDeviceGroups, rbac.AccessTypeExecute)).To(BeTrue())
+		})
+
+		It("should not allow the access matching no permission", func() {
+			Expect(acl.IsAllowed(rbac.ApplicationEdge, rbac.ResourceTypeDeviceActionPlaybooks, rbac.AccessTypeExecute)).To(BeFalse())
+			Expect(acl.IsAllowed(rbac.ApplicationInventory, rbac.ResourceTypeDeviceActionPlaybooks, rbac.AccessTypeWrite)).To(BeFalse())
+		})
+	})
 })
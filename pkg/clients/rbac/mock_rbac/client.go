@@ -49,6 +49,22 @@ func (mr *MockClientInterfaceMockRecorder) GetAccessList(application interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessList", reflect.TypeOf((*MockClientInterface)(nil).GetAccessList), application)
 }
 
+// GetDeviceGroupsAccess mocks base method.
+func (m *MockClientInterface) GetDeviceGroupsAccess(acl rbac.AccessList, resource rbac.ResourceType, accessType rbac.AccessType) (bool, []uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupsAccess", acl, resource, accessType)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].([]uint)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDeviceGroupsAccess indicates an expected call of GetDeviceGroupsAccess.
+func (mr *MockClientInterfaceMockRecorder) GetDeviceGroupsAccess(acl, resource, accessType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupsAccess", reflect.TypeOf((*MockClientInterface)(nil).GetDeviceGroupsAccess), acl, resource, accessType)
+}
+
 // GetInventoryGroupsAccess mocks base method.
 func (m *MockClientInterface) GetInventoryGroupsAccess(acl rbac.AccessList, resource rbac.ResourceType, accessType rbac.AccessType) (bool, []string, bool, error) {
 	m.ctrl.T.Helper()
@@ -154,3 +154,26 @@ func OrgDB(orgID string, gormDB *gorm.DB, table string) *gorm.DB {
 func OrgDBx(ctx context.Context, orgID string, gormDB *gorm.DB, table string) *gorm.DB {
 	return OrgDB(orgID, gormDB, table).WithContext(ctx)
 }
+
+// WithAdvisoryLock runs fn only when the postgres session advisory lock of key is free, so that a job started on every
+// replica does not run concurrently. It returns whether fn was run. Other databases have no advisory locks and always run fn.
+func WithAdvisoryLock(ctx context.Context, key int64, fn func()) (bool, error) {
+	if DB.Dialector.Name() != "postgres" {
+		fn()
+		return true, nil
+	}
+	var locked bool
+	err := DB.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if result := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&locked); result.Error != nil {
+			return result.Error
+		}
+		if !locked {
+			return nil
+		}
+		// unlock with a fresh context, the job context may be done by now
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", key)
+		fn()
+		return nil
+	})
+	return locked, err
+}
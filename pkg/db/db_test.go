@@ -3,6 +3,7 @@
 package db_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -84,4 +85,13 @@ var _ = Describe("Db", func() {
 			localDB = db.DB
 		})
 	})
+	Context("WithAdvisoryLock", func() {
+		It("should run the function when the database has no advisory locks", func() {
+			var run bool
+			locked, err := db.WithAdvisoryLock(context.Background(), 1, func() { run = true })
+			Expect(err).ToNot(HaveOccurred())
+			Expect(locked).To(BeTrue())
+			Expect(run).To(BeTrue())
+		})
+	})
 })
@@ -29,6 +29,14 @@ type EdgeAPIServices struct {
 	ThirdPartyRepoService  services.ThirdPartyRepoServiceInterface
 	DeviceGroupsService    services.DeviceGroupsServiceInterface
 	FilesService           services.FilesService
+	StatusEventsService    services.StatusEventsServiceInterface
+	UpdateHooksService     services.UpdateHooksServiceInterface
+	DeviceHistoryService   services.DeviceHistoryServiceInterface
+	StaticDeltaService     services.StaticDeltaServiceInterface
+	UpdatePolicyService    services.UpdatePolicyServiceInterface
+	DesiredStateService    services.DesiredStateServiceInterface
+	DeviceStalenessService services.DeviceStalenessServiceInterface
+	DeviceActionsService   services.DeviceActionsServiceInterface
 	ProducerService        kafkacommon.ProducerServiceInterface
 	ConsumerService        kafkacommon.ConsumerServiceInterface
 	InventoryGroupsService inventorygroups.ClientInterface
@@ -57,6 +65,14 @@ func Init(ctx context.Context) *EdgeAPIServices {
 		DeviceService:          services.NewDeviceService(ctx, log),
 		DeviceGroupsService:    services.NewDeviceGroupsService(ctx, log),
 		FilesService:           services.NewFilesService(log),
+		StatusEventsService:    services.NewStatusEventsService(ctx, log),
+		UpdateHooksService:     services.NewUpdateHooksService(ctx, log),
+		DeviceHistoryService:   services.NewDeviceHistoryService(ctx, log),
+		StaticDeltaService:     services.NewStaticDeltaService(ctx, log),
+		UpdatePolicyService:    services.NewUpdatePolicyService(ctx, log),
+		DesiredStateService:    services.NewDesiredStateService(ctx, log),
+		DeviceStalenessService: services.NewDeviceStalenessService(ctx, log),
+		DeviceActionsService:   services.NewDeviceActionsService(ctx, log),
 		ProducerService:        kafkacommon.NewProducerService(),
 		ConsumerService:        kafkacommon.NewConsumerService(ctx, log),
 		InventoryGroupsService: inventorygroups.InitClient(ctx, log),
@@ -97,3 +97,21 @@ var MemoryCacheHitCount = prometheus.NewCounterVec(
 	},
 	[]string{"op"},
 )
+
+var GarbageCollectedCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name:        "edge_garbage_collected_count",
+		Help:        "garbage collected static deltas and update repos count by type and result (deleted/dry_run/failed)",
+		ConstLabels: prometheus.Labels{"service": ApplicationName, "component": BinaryName},
+	},
+	[]string{"type", "result"},
+)
+
+var GarbageCollectedBytes = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name:        "edge_garbage_collected_bytes",
+		Help:        "bytes of the garbage collected static deltas by result (deleted/dry_run/failed)",
+		ConstLabels: prometheus.Labels{"service": ApplicationName, "component": BinaryName},
+	},
+	[]string{"result"},
+)
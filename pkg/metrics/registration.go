@@ -14,5 +14,7 @@ func RegisterAPIMetrics() {
 		StorageTransferCount,
 		StorageTransferDuration,
 		MemoryCacheHitCount,
+		GarbageCollectedCount,
+		GarbageCollectedBytes,
 	)
 }
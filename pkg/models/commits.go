@@ -29,6 +29,8 @@ const (
 	RepoStatusPending = "PENDING"
 	// RepoStatusSkipped is for when a Repo is available to the user (post commit build)
 	RepoStatusSkipped = "SKIPPED"
+	// RepoStatusStorageCleaned is for when the storage of a Repo was cleaned
+	RepoStatusStorageCleaned = "STORAGE_CLEANED"
 	// RepoStatusSuccess is for when a Repo is available to the user
 	RepoStatusSuccess = "SUCCESS"
 )
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// DeviceAction is a signed playbook dispatched to devices to run a curated action or a playbook uploaded by the org.
+// The run of the playbook on each device is tracked by a dispatch record.
+type DeviceAction struct {
+	Model
+	OrgID           string           `json:"org_id" gorm:"index;<-:create"`
+	Type            string           `json:"type"`
+	Unit            string           `json:"unit,omitempty"`                         // the systemd unit restarted by a restart_service action
+	PlaybookID      *uint            `json:"playbook_id,omitempty" gorm:"index"`     // the org playbook run by a run_playbook action
+	DeviceGroupID   *uint            `json:"device_group_id,omitempty" gorm:"index"` // the device group the action was sent to, if any
+	RequestedBy     string           `json:"requested_by,omitempty"`                 // the principal that created the action
+	Status          string           `json:"status"`
+	DispatchRecords []DispatchRecord `json:"dispatch_records" gorm:"many2many:deviceaction_dispatchrecords;"`
+}
+
+// DeviceActionPlaybook is a playbook uploaded by an org to be run on its devices by a run_playbook device action,
+// it is signed when dispatched
+type DeviceActionPlaybook struct {
+	Model
+	OrgID       string `json:"org_id" gorm:"index;<-:create"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Playbook    string `json:"playbook"`
+}
+
+// DeviceActionRequest is the request to run an action on devices or on all the devices of a device group
+type DeviceActionRequest struct {
+	Type          string   `json:"type"`
+	Unit          string   `json:"unit,omitempty"`
+	PlaybookID    *uint    `json:"playbook_id,omitempty"`
+	DevicesUUID   []string `json:"devices_uuid,omitempty"`
+	DeviceGroupID *uint    `json:"device_group_id,omitempty"`
+}
+
+const (
+	// DeviceActionTypeReboot reboots the devices
+	DeviceActionTypeReboot = "reboot"
+	// DeviceActionTypeRestartService restarts a systemd unit of the devices
+	DeviceActionTypeRestartService = "restart_service"
+	// DeviceActionTypeCollectLogs collects the journal of the current boot of the devices in the playbook run output
+	DeviceActionTypeCollectLogs = "collect_logs"
+	// DeviceActionTypeRefreshSubscription refreshes the subscription of the devices
+	DeviceActionTypeRefreshSubscription = "refresh_subscription"
+	// DeviceActionTypeRunPlaybook runs a playbook uploaded by the org on the devices
+	DeviceActionTypeRunPlaybook = "run_playbook"
+
+	// DeviceActionStatusCreated is for when the device action is created, before its playbook is dispatched
+	DeviceActionStatusCreated = "CREATED"
+	// DeviceActionStatusRunning is for when the device action playbook is dispatched and still runs on some devices
+	DeviceActionStatusRunning = "RUNNING"
+	// DeviceActionStatusSuccess is for when the device action playbook ran successfully on all the devices
+	DeviceActionStatusSuccess = "SUCCESS"
+	// DeviceActionStatusError is for when the device action playbook failed on some devices
+	DeviceActionStatusError = "ERROR"
+
+	// DeviceActionReasonNotConnected is for when the device action playbook can not be dispatched to a device without rhc
+	DeviceActionReasonNotConnected = "The device is not connected with rhc."
+
+	// DeviceActionPlaybookMaxSize is the maximum size in bytes of an org device action playbook
+	DeviceActionPlaybookMaxSize = 256 * 1024
+
+	// DeviceActionTypeInvalidErrorMessage is the error message returned when the device action type is unknown
+	DeviceActionTypeInvalidErrorMessage = "action type must be reboot, restart_service, collect_logs, refresh_subscription or run_playbook"
+	// DeviceActionUnitInvalidErrorMessage is the error message returned when the restarted systemd unit name is invalid
+	DeviceActionUnitInvalidErrorMessage = "restart_service action requires a valid systemd unit name"
+	// DeviceActionUnitNotAllowedErrorMessage is the error message returned when a unit is set on an action other than restart_service
+	DeviceActionUnitNotAllowedErrorMessage = "unit is only allowed on restart_service actions"
+	// DeviceActionPlaybookRequiredErrorMessage is the error message returned when a run_playbook action has no playbook
+	DeviceActionPlaybookRequiredErrorMessage = "run_playbook action requires a playbook_id"
+	// DeviceActionPlaybookNotAllowedErrorMessage is the error message returned when a playbook is set on an action other than run_playbook
+	DeviceActionPlaybookNotAllowedErrorMessage = "playbook_id is only allowed on run_playbook actions"
+	// DeviceActionTargetErrorMessage is the error message returned when the action is not sent to either devices or a device group
+	DeviceActionTargetErrorMessage = "action must be sent to devices_uuid or to a device_group_id"
+
+	// DeviceActionPlaybookNameEmptyErrorMessage is the error message returned when the playbook name is empty
+	DeviceActionPlaybookNameEmptyErrorMessage = "playbook name cannot be empty"
+	// DeviceActionPlaybookNameInvalidErrorMessage is the error message returned when the playbook name is invalid
+	DeviceActionPlaybookNameInvalidErrorMessage = "playbook name must start with alphanumeric characters and can contain underscore and hyphen characters"
+	// DeviceActionPlaybookEmptyErrorMessage is the error message returned when the playbook content is empty
+	DeviceActionPlaybookEmptyErrorMessage = "playbook cannot be empty"
+	// DeviceActionPlaybookTooLargeErrorMessage is the error message returned when the playbook content is too large
+	DeviceActionPlaybookTooLargeErrorMessage = "playbook must not be larger than 256KiB"
+	// DeviceActionPlaybookInvalidErrorMessage is the error message returned when the playbook can not be signed
+	DeviceActionPlaybookInvalidErrorMessage = "playbook must be a list of plays, each play with hosts, tasks and vars to hold its signature"
+)
+
+var (
+	validDeviceActionPlaybookName = regexp.MustCompile(`^[A-Za-z0-9]+[A-Za-z0-9\s_-]*$`)
+	// the unit is rendered in the playbook, it is restricted to the systemd unit name characters
+	validDeviceActionUnit = regexp.MustCompile(`^[A-Za-z0-9:_.@-]{1,255}$`)
+)
+
+// ValidateRequest validates the DeviceActionRequest
+func (a *DeviceActionRequest) ValidateRequest() error {
+	switch a.Type {
+	case DeviceActionTypeReboot, DeviceActionTypeRestartService, DeviceActionTypeCollectLogs,
+		DeviceActionTypeRefreshSubscription, DeviceActionTypeRunPlaybook:
+	default:
+		return errors.New(DeviceActionTypeInvalidErrorMessage)
+	}
+	if a.Type == DeviceActionTypeRestartService {
+		if !validDeviceActionUnit.MatchString(a.Unit) || strings.HasPrefix(a.Unit, "-") {
+			return errors.New(DeviceActionUnitInvalidErrorMessage)
+		}
+	} else if a.Unit != "" {
+		return errors.New(DeviceActionUnitNotAllowedErrorMessage)
+	}
+	if a.Type == DeviceActionTypeRunPlaybook {
+		if a.PlaybookID == nil {
+			return errors.New(DeviceActionPlaybookRequiredErrorMessage)
+		}
+	} else if a.PlaybookID != nil {
+		return errors.New(DeviceActionPlaybookNotAllowedErrorMessage)
+	}
+	if (len(a.DevicesUUID) == 0) == (a.DeviceGroupID == nil) {
+		return errors.New(DeviceActionTargetErrorMessage)
+	}
+	return nil
+}
+
+// ValidateRequest validates the DeviceActionPlaybook request
+func (p *DeviceActionPlaybook) ValidateRequest() error {
+	if p.Name == "" {
+		return errors.New(DeviceActionPlaybookNameEmptyErrorMessage)
+	}
+	if !validDeviceActionPlaybookName.MatchString(p.Name) {
+		return errors.New(DeviceActionPlaybookNameInvalidErrorMessage)
+	}
+	if strings.TrimSpace(p.Playbook) == "" {
+		return errors.New(DeviceActionPlaybookEmptyErrorMessage)
+	}
+	if len(p.Playbook) > DeviceActionPlaybookMaxSize {
+		return errors.New(DeviceActionPlaybookTooLargeErrorMessage)
+	}
+	// the playbook is signed when dispatched, the signature is held by the vars of each play
+	var plays []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(p.Playbook), &plays); err != nil || len(plays) == 0 {
+		return errors.New(DeviceActionPlaybookInvalidErrorMessage)
+	}
+	for _, play := range plays {
+		if _, ok := play["hosts"]; !ok {
+			return errors.New(DeviceActionPlaybookInvalidErrorMessage)
+		}
+		if _, ok := play["tasks"]; !ok {
+			return errors.New(DeviceActionPlaybookInvalidErrorMessage)
+		}
+		if _, ok := play["vars"].(map[string]interface{}); !ok {
+			return errors.New(DeviceActionPlaybookInvalidErrorMessage)
+		}
+	}
+	return nil
+}
+
+// BeforeCreate method is called before creating a device action, it make sure org_id is not empty
+func (a *DeviceAction) BeforeCreate(tx *gorm.DB) error {
+	if a.OrgID == "" {
+		log.Error("device-action do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
+
+// BeforeCreate method is called before creating a device action playbook, it make sure org_id is not empty
+func (p *DeviceActionPlaybook) BeforeCreate(tx *gorm.DB) error {
+	if p.OrgID == "" {
+		log.Error("device-action-playbook do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package models
+
+// CreateDeviceActionAPI is the device actions POST endpoint struct for openapi.json auto-gen
+type CreateDeviceActionAPI struct {
+	Type          string   `json:"type" example:"restart_service"`                                        // the action, reboot, restart_service, collect_logs, refresh_subscription or run_playbook
+	Unit          string   `json:"unit,omitempty" example:"podman-auto-update.service"`                   // the systemd unit restarted by a restart_service action
+	PlaybookID    *uint    `json:"playbook_id,omitempty" example:"1024"`                                  // the org playbook run by a run_playbook action
+	DevicesUUID   []string `json:"devices_uuid,omitempty" example:"b579a578-1a6f-48d5-8a45-21f2a656a5d4"` // the devices to run the action on
+	DeviceGroupID *uint    `json:"device_group_id,omitempty" example:"1024"`                              // the device group to run the action on all the devices of
+} // DeviceActionRequest
+
+// DeviceActionAPI is the device actions endpoints return struct for openapi.json auto-gen
+type DeviceActionAPI struct {
+	ID              uint                `json:"ID" example:"1024"`                                   // the action id
+	OrgID           string              `json:"org_id" example:"2000"`                               // orgId that the action belongs to
+	Type            string              `json:"type" example:"restart_service"`                      // the action
+	Unit            string              `json:"unit,omitempty" example:"podman-auto-update.service"` // the systemd unit restarted by a restart_service action
+	PlaybookID      *uint               `json:"playbook_id,omitempty" example:"1024"`                // the org playbook run by a run_playbook action
+	DeviceGroupID   *uint               `json:"device_group_id,omitempty" example:"1024"`            // the device group the action was sent to
+	RequestedBy     string              `json:"requested_by,omitempty" example:"user@example.com"`   // the principal that created the action
+	Status          string              `json:"status" example:"RUNNING"`                            // the action status, CREATED, RUNNING, SUCCESS or ERROR
+	DispatchRecords []DispatchRecordAPI `json:"dispatch_records"`                                    // the run of the action playbook on each device
+} // DeviceAction
+
+// CreateDeviceActionPlaybookAPI is the device action playbooks POST endpoint struct for openapi.json auto-gen
+type CreateDeviceActionPlaybookAPI struct {
+	Name        string `json:"name" example:"prune-images"`                                        // the playbook name
+	Description string `json:"description,omitempty" example:"remove the unused container images"` // the playbook description
+	Playbook    string `json:"playbook" example:"- hosts: localhost\n  vars: {}\n  tasks: []\n"`   // the playbook yaml, each play has vars to hold its signature
+} // DeviceActionPlaybook
+
+// DeviceActionPlaybookAPI is the device action playbooks endpoints return struct for openapi.json auto-gen
+type DeviceActionPlaybookAPI struct {
+	ID          uint   `json:"ID" example:"1024"`                                                  // the playbook id
+	OrgID       string `json:"org_id" example:"2000"`                                              // orgId that the playbook belongs to
+	Name        string `json:"name" example:"prune-images"`                                        // the playbook name
+	Description string `json:"description,omitempty" example:"remove the unused container images"` // the playbook description
+	Playbook    string `json:"playbook"`                                                           // the playbook yaml
+} // DeviceActionPlaybook
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeviceActionRequestValidateRequest(t *testing.T) {
+	playbookID := uint(1)
+	deviceGroupID := uint(2)
+	devicesUUID := []string{"b579a578-1a6f-48d5-8a45-21f2a656a5d4"}
+	testScenarios := []struct {
+		name     string
+		request  *DeviceActionRequest
+		expected error
+	}{
+		{name: "Invalid type", request: &DeviceActionRequest{Type: "shutdown", DevicesUUID: devicesUUID}, expected: errors.New(DeviceActionTypeInvalidErrorMessage)},
+		{name: "Restart without unit", request: &DeviceActionRequest{Type: DeviceActionTypeRestartService, DevicesUUID: devicesUUID}, expected: errors.New(DeviceActionUnitInvalidErrorMessage)},
+		{name: "Restart with injected unit", request: &DeviceActionRequest{Type: DeviceActionTypeRestartService, Unit: "sshd; rm -rf /", DevicesUUID: devicesUUID}, expected: errors.New(DeviceActionUnitInvalidErrorMessage)},
+		{name: "Restart with option unit", request: &DeviceActionRequest{Type: DeviceActionTypeRestartService, Unit: "--force", DevicesUUID: devicesUUID}, expected: errors.New(DeviceActionUnitInvalidErrorMessage)},
+		{name: "Unit on reboot", request: &DeviceActionRequest{Type: DeviceActionTypeReboot, Unit: "sshd.service", DevicesUUID: devicesUUID}, expected: errors.New(DeviceActionUnitNotAllowedErrorMessage)},
+		{name: "Run playbook without playbook", request: &DeviceActionRequest{Type: DeviceActionTypeRunPlaybook, DevicesUUID: devicesUUID}, expected: errors.New(DeviceActionPlaybookRequiredErrorMessage)},
+		{name: "Playbook on collect logs", request: &DeviceActionRequest{Type: DeviceActionTypeCollectLogs, PlaybookID: &playbookID, DevicesUUID: devicesUUID}, expected: errors.New(DeviceActionPlaybookNotAllowedErrorMessage)},
+		{name: "No target", request: &DeviceActionRequest{Type: DeviceActionTypeReboot}, expected: errors.New(DeviceActionTargetErrorMessage)},
+		{name: "Two targets", request: &DeviceActionRequest{Type: DeviceActionTypeReboot, DevicesUUID: devicesUUID, DeviceGroupID: &deviceGroupID}, expected: errors.New(DeviceActionTargetErrorMessage)},
+		{name: "Valid restart", request: &DeviceActionRequest{Type: DeviceActionTypeRestartService, Unit: "container-app@web.service", DevicesUUID: devicesUUID}, expected: nil},
+		{name: "Valid device group playbook", request: &DeviceActionRequest{Type: DeviceActionTypeRunPlaybook, PlaybookID: &playbookID, DeviceGroupID: &deviceGroupID}, expected: nil},
+	}
+
+	for _, testScenario := range testScenarios {
+		err := testScenario.request.ValidateRequest()
+		if err == nil && testScenario.expected != nil {
+			t.Errorf("Test %q was supposed to fail but passed successfully", testScenario.name)
+		}
+		if err != nil && testScenario.expected == nil {
+			t.Errorf("Test %q was supposed to pass but failed: %s", testScenario.name, err)
+		}
+		if err != nil && testScenario.expected != nil && err.Error() != testScenario.expected.Error() {
+			t.Errorf("Test %q: expected to fail on %q but got %q", testScenario.name, testScenario.expected, err)
+		}
+	}
+}
+
+func TestDeviceActionPlaybookValidateRequest(t *testing.T) {
+	validPlaybook := "- hosts: localhost\n  vars: {}\n  tasks:\n    - command: podman image prune -f\n"
+	testScenarios := []struct {
+		name     string
+		playbook *DeviceActionPlaybook
+		expected error
+	}{
+		{name: "Empty name", playbook: &DeviceActionPlaybook{Playbook: validPlaybook}, expected: errors.New(DeviceActionPlaybookNameEmptyErrorMessage)},
+		{name: "Invalid name", playbook: &DeviceActionPlaybook{Name: "** prune", Playbook: validPlaybook}, expected: errors.New(DeviceActionPlaybookNameInvalidErrorMessage)},
+		{name: "Empty playbook", playbook: &DeviceActionPlaybook{Name: "prune", Playbook: " \n"}, expected: errors.New(DeviceActionPlaybookEmptyErrorMessage)},
+		{name: "Too large playbook", playbook: &DeviceActionPlaybook{Name: "prune", Playbook: validPlaybook + strings.Repeat("#", DeviceActionPlaybookMaxSize)}, expected: errors.New(DeviceActionPlaybookTooLargeErrorMessage)},
+		{name: "Not a list of plays", playbook: &DeviceActionPlaybook{Name: "prune", Playbook: "hosts: localhost\n"}, expected: errors.New(DeviceActionPlaybookInvalidErrorMessage)},
+		{name: "Play without vars", playbook: &DeviceActionPlaybook{Name: "prune", Playbook: "- hosts: localhost\n  tasks: []\n"}, expected: errors.New(DeviceActionPlaybookInvalidErrorMessage)},
+		{name: "Play without tasks", playbook: &DeviceActionPlaybook{Name: "prune", Playbook: "- hosts: localhost\n  vars: {}\n"}, expected: errors.New(DeviceActionPlaybookInvalidErrorMessage)},
+		{name: "Valid playbook", playbook: &DeviceActionPlaybook{Name: "prune-images", Playbook: validPlaybook}, expected: nil},
+	}
+
+	for _, testScenario := range testScenarios {
+		err := testScenario.playbook.ValidateRequest()
+		if err == nil && testScenario.expected != nil {
+			t.Errorf("Test %q was supposed to fail but passed successfully", testScenario.name)
+		}
+		if err != nil && testScenario.expected == nil {
+			t.Errorf("Test %q was supposed to pass but failed: %s", testScenario.name, err)
+		}
+		if err != nil && testScenario.expected != nil && err.Error() != testScenario.expected.Error() {
+			t.Errorf("Test %q: expected to fail on %q but got %q", testScenario.name, testScenario.expected, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// DeviceConnectivity is the rhc connectivity of a device over a period, computed from its CONNECTED and DISCONNECTED
+// history events
+type DeviceConnectivity struct {
+	DeviceUUID          string      `json:"device_uuid"`
+	DeviceName          string      `json:"device_name"`
+	Connected           bool        `json:"connected"`
+	Since               EdgeAPITime `json:"since"`                // when the device got its current connectivity
+	ConnectedSeconds    int64       `json:"connected_seconds"`    // the time the device was connected during the period
+	ConnectedPercentage float64     `json:"connected_percentage"` // the part of the period the device was connected
+	Disconnections      int         `json:"disconnections"`       // the number of times the device was disconnected during the period
+}
+
+// DeviceGroupConnectivity is the rhc connectivity of the devices of a device group over a period
+type DeviceGroupConnectivity struct {
+	DeviceGroupID       uint                 `json:"device_group_id"`
+	DeviceGroupName     string               `json:"device_group_name"`
+	DevicesCount        int                  `json:"devices_count"`
+	ConnectedCount      int                  `json:"connected_count"`
+	DisconnectedCount   int                  `json:"disconnected_count"`
+	ConnectedPercentage float64              `json:"connected_percentage"` // the average connected part of the period of the devices
+	Devices             []DeviceConnectivity `json:"devices"`
+}
+
+const (
+	// DeviceConnectivityDefaultDays is the default period in days of the devices connectivity
+	DeviceConnectivityDefaultDays = 30
+	// DeviceConnectivityMaxDays is the maximum period in days of the devices connectivity
+	DeviceConnectivityMaxDays = 365
+
+	// DeviceConnectivityDaysInvalidErrorMessage is the error message returned when the connectivity period is invalid
+	DeviceConnectivityDaysInvalidErrorMessage = "days must be an integer between 1 and 365"
+)
+
+// DevicesDisconnectedQuery is the query of the devices disconnected before a time, the devices without connectivity
+// events are disconnected since their creation
+const DevicesDisconnectedQuery = "devices.connected = ? AND COALESCE((SELECT MAX(device_events.created_at) FROM device_events " +
+	"WHERE device_events.device_id = devices.id AND device_events.type IN ? AND device_events.deleted_at IS NULL), devices.created_at) < ?"
+
+// DeviceConnectivityEventTypes are the history event types of the device connectivity changes
+var DeviceConnectivityEventTypes = []string{DeviceEventTypeConnected, DeviceEventTypeDisconnected}
+
+// ValidateDeviceConnectivityDays validates the period in days of the devices connectivity
+func ValidateDeviceConnectivityDays(days int) error {
+	if days < 1 || days > DeviceConnectivityMaxDays {
+		return errors.New(DeviceConnectivityDaysInvalidErrorMessage)
+	}
+	return nil
+}
+
+// NewDeviceConnectivity computes the connectivity of a device from the period start to now. The last connectivity event
+// before the period start gives the connectivity at the start of the period, it is nil when there is none.
+// The period events must be ordered from the oldest.
+func NewDeviceConnectivity(device *Device, start time.Time, now time.Time, previous *DeviceEvent, events []DeviceEvent) DeviceConnectivity {
+	connectivity := DeviceConnectivity{
+		DeviceUUID: device.UUID,
+		DeviceName: device.Name,
+		Connected:  device.Connected,
+		Since:      EdgeAPITime{Time: device.CreatedAt.Time, Valid: !device.CreatedAt.Time.IsZero()},
+	}
+	// the device is only measured since it is known
+	if device.CreatedAt.Time.After(start) {
+		start = device.CreatedAt.Time
+	}
+	if !now.After(start) {
+		return connectivity
+	}
+
+	// without an event before the period the device starts with the state the first event changes,
+	// without any event it kept its current state
+	connected := device.Connected
+	switch {
+	case previous != nil:
+		connected = previous.Type == DeviceEventTypeConnected
+		connectivity.Since = EdgeAPITime{Time: previous.CreatedAt.Time, Valid: true}
+	case len(events) > 0:
+		connected = events[0].Type != DeviceEventTypeConnected
+	}
+	var connectedDuration time.Duration
+	from := start
+	for _, event := range events {
+		at := event.CreatedAt.Time
+		if at.Before(from) {
+			at = from
+		}
+		if connected {
+			connectedDuration += at.Sub(from)
+		}
+		connected = event.Type == DeviceEventTypeConnected
+		if !connected {
+			connectivity.Disconnections++
+		}
+		from = at
+		connectivity.Since = EdgeAPITime{Time: event.CreatedAt.Time, Valid: true}
+	}
+	if connected {
+		connectedDuration += now.Sub(from)
+	}
+	connectivity.ConnectedSeconds = int64(connectedDuration.Seconds())
+	connectivity.ConnectedPercentage = float64(connectedDuration) * 100 / float64(now.Sub(start))
+	return connectivity
+}
+
+// DeviceGroupDisconnectedAlert returns whether the newly disconnected devices of a device group made its disconnected
+// devices reach the alert percentage, a device group is alerted once until enough of its devices connect again
+func DeviceGroupDisconnectedAlert(devicesCount int, disconnectedCount int, newlyDisconnectedCount int, alertPercent int) bool {
+	if alertPercent <= 0 || devicesCount == 0 {
+		return false
+	}
+	reached := func(count int) bool {
+		return count*100 >= alertPercent*devicesCount
+	}
+	return reached(disconnectedCount) && !reached(disconnectedCount-newlyDisconnectedCount)
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewDeviceConnectivity(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-10 * time.Hour)
+	at := func(hours int) EdgeAPITime {
+		return EdgeAPITime{Time: now.Add(time.Duration(hours) * time.Hour), Valid: true}
+	}
+	event := func(hours int, eventType string) DeviceEvent {
+		return DeviceEvent{CreatedAt: at(hours), Type: eventType}
+	}
+	previous := event(-20, DeviceEventTypeConnected)
+
+	testScenarios := []struct {
+		name                string
+		device              Device
+		previous            *DeviceEvent
+		events              []DeviceEvent
+		expectedPercentage  float64
+		expectedSince       EdgeAPITime
+		expectedDisconnects int
+	}{
+		{
+			name:               "Always connected",
+			device:             Device{Model: Model{CreatedAt: at(-100)}, Connected: true},
+			expectedPercentage: 100,
+			expectedSince:      at(-100),
+		},
+		{
+			name:               "Always disconnected",
+			device:             Device{Model: Model{CreatedAt: at(-100)}},
+			expectedPercentage: 0,
+			expectedSince:      at(-100),
+		},
+		{
+			name:                "Disconnected during the period",
+			device:              Device{Model: Model{CreatedAt: at(-100)}, Connected: true},
+			previous:            &previous,
+			events:              []DeviceEvent{event(-8, DeviceEventTypeDisconnected), event(-6, DeviceEventTypeConnected)},
+			expectedPercentage:  80,
+			expectedSince:       at(-6),
+			expectedDisconnects: 1,
+		},
+		{
+			name:                "Without event before the period",
+			device:              Device{Model: Model{CreatedAt: at(-100)}},
+			events:              []DeviceEvent{event(-5, DeviceEventTypeDisconnected)},
+			expectedPercentage:  50,
+			expectedSince:       at(-5),
+			expectedDisconnects: 1,
+		},
+		{
+			name:               "Created during the period",
+			device:             Device{Model: Model{CreatedAt: at(-4)}},
+			events:             []DeviceEvent{event(-2, DeviceEventTypeConnected), event(-1, DeviceEventTypeDisconnected)},
+			expectedPercentage: 25,
+			expectedSince:      at(-1),
+			// the device was created disconnected
+			expectedDisconnects: 1,
+		},
+	}
+
+	for _, testScenario := range testScenarios {
+		connectivity := NewDeviceConnectivity(&testScenario.device, start, now, testScenario.previous, testScenario.events)
+		if connectivity.ConnectedPercentage < testScenario.expectedPercentage-0.01 || connectivity.ConnectedPercentage > testScenario.expectedPercentage+0.01 {
+			t.Errorf("Test %q: expected %v connected percentage but got %v", testScenario.name, testScenario.expectedPercentage, connectivity.ConnectedPercentage)
+		}
+		if !connectivity.Since.Time.Equal(testScenario.expectedSince.Time) {
+			t.Errorf("Test %q: expected connectivity since %v but got %v", testScenario.name, testScenario.expectedSince.Time, connectivity.Since.Time)
+		}
+		if connectivity.Disconnections != testScenario.expectedDisconnects {
+			t.Errorf("Test %q: expected %d disconnections but got %d", testScenario.name, testScenario.expectedDisconnects, connectivity.Disconnections)
+		}
+	}
+}
+
+func TestDeviceGroupDisconnectedAlert(t *testing.T) {
+	testScenarios := []struct {
+		name              string
+		devicesCount      int
+		disconnectedCount int
+		newlyDisconnected int
+		alertPercent      int
+		expected          bool
+	}{
+		{name: "Below the percentage", devicesCount: 10, disconnectedCount: 4, newlyDisconnected: 1, alertPercent: 50, expected: false},
+		{name: "Reaching the percentage", devicesCount: 10, disconnectedCount: 5, newlyDisconnected: 1, alertPercent: 50, expected: true},
+		{name: "Crossing the percentage at once", devicesCount: 10, disconnectedCount: 8, newlyDisconnected: 6, alertPercent: 50, expected: true},
+		{name: "Already above the percentage", devicesCount: 10, disconnectedCount: 6, newlyDisconnected: 1, alertPercent: 50, expected: false},
+		{name: "Alert disabled", devicesCount: 10, disconnectedCount: 10, newlyDisconnected: 10, alertPercent: 0, expected: false},
+		{name: "Empty group", devicesCount: 0, disconnectedCount: 0, newlyDisconnected: 0, alertPercent: 50, expected: false},
+	}
+
+	for _, testScenario := range testScenarios {
+		alert := DeviceGroupDisconnectedAlert(testScenario.devicesCount, testScenario.disconnectedCount,
+			testScenario.newlyDisconnected, testScenario.alertPercent)
+		if alert != testScenario.expected {
+			t.Errorf("Test %q: expected alert %v but got %v", testScenario.name, testScenario.expected, alert)
+		}
+	}
+}
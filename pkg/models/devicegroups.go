@@ -13,6 +13,9 @@ import (
 // DeviceGroup is a record of Edge Devices Groups
 // Account is the account associated with the device group
 // Type is the device group type and must be "static" or "dynamic"
+// Rule is the expression selecting the devices of a dynamic device group, see ParseDeviceGroupRule
+// ParentID is the parent device group of a nested device group, e.g. a site group under its region group
+// CascadeRBAC is whether the RBAC permissions on the device group cover its descendant groups
 type DeviceGroup struct {
 	Model
 	Account     string   `json:"Account" gorm:"index"`
@@ -22,12 +25,18 @@ type DeviceGroup struct {
 	Devices     []Device `faker:"-" json:"Devices" gorm:"many2many:device_groups_devices;"`
 	ValidUpdate bool     `json:"ValidUpdate" gorm:"-:all"`
 	UUID        string   `json:"uuid,omitempty" gorm:"index"`
+	ParentID    *uint    `json:"ParentID,omitempty" gorm:"index"`
+	CascadeRBAC bool     `json:"CascadeRBAC"`
+	HasChildren bool     `json:"HasChildren" gorm:"-:all"`
+	Rule        string   `json:"Rule,omitempty"`
+	Principal   string   `json:"-"` // the principal that set the rule, the inventory tags are queried on its behalf
 }
 
 // DeviceGroupListDetail is a record of Edge Devices Groups with images and status information
 type DeviceGroupListDetail struct {
 	DeviceGroup     DeviceGroup        `json:"DeviceGroup"`
 	DeviceImageInfo *[]DeviceImageInfo `json:"DevicesImageInfo"`
+	Drift           *DeviceDriftCounts `json:"Drift,omitempty"` // the drift counts of the devices when the group has a desired state
 }
 
 // DeviceImageInfo is a record of group with the current images running on the device
@@ -87,6 +96,14 @@ func (group *DeviceGroup) ValidateRequest() error {
 	if group.Type != DeviceGroupTypeStatic && group.Type != DeviceGroupTypeDynamic {
 		return errors.New(DeviceGroupTypeInvalidErrorMessage)
 	}
+	if group.Type == DeviceGroupTypeStatic && group.Rule != "" {
+		return errors.New(DeviceGroupRuleStaticErrorMessage)
+	}
+	if group.Type == DeviceGroupTypeDynamic {
+		if _, err := ParseDeviceGroupRule(group.Rule); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -2,9 +2,10 @@ package models
 
 // CreateDeviceGroupAPI is the /device-group POST endpoint struct for openapi.json auto-gen
 type CreateDeviceGroupAPI struct {
-	Name    string                    `json:"name" example:"my-device-group"` // the device group name
-	Type    string                    `json:"type" example:"static"`          // the device group type
-	Devices []DeviceForDeviceGroupAPI `json:"DevicesAPI,omitempty"`           // Devices of group
+	Name    string                    `json:"name" example:"my-device-group"`                               // the device group name
+	Type    string                    `json:"type" example:"static"`                                        // the device group type
+	Devices []DeviceForDeviceGroupAPI `json:"DevicesAPI,omitempty"`                                         // Devices of group
+	Rule    string                    `json:"rule,omitempty" example:"image_set = 12 and connected = true"` // the rule selecting the devices of a dynamic group
 
 } // CreateDeviceGroup
 
@@ -38,8 +39,15 @@ type CheckGroupNameParamAPI struct {
 
 // PutGroupNameParamAPI is the parameter to check update device group
 type PutGroupNameParamAPI struct {
-	Name string `json:"Name" example:"my-device-group"` // device group name
-	Type string `json:"Type" example:"static"`          // device group type
+	Name string `json:"Name" example:"my-device-group"`                               // device group name
+	Type string `json:"Type" example:"static"`                                        // device group type
+	Rule string `json:"Rule,omitempty" example:"image_set = 12 and connected = true"` // the rule selecting the devices of a dynamic group
+}
+
+// DeviceGroupHierarchyAPI is the expected values to nest a device group under a parent group
+type DeviceGroupHierarchyAPI struct {
+	ParentID    *uint `json:"ParentID" example:"1080"`    // the parent device group, null to make the group a root group
+	CascadeRBAC bool  `json:"CascadeRBAC" example:"true"` // whether the RBAC permissions on the group cover its descendant groups
 }
 
 // DeviceGroupViewResponseAPI is the detail return of /view endpoint
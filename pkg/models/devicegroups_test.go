@@ -22,6 +22,9 @@ func TestGroupValidateRequest(t *testing.T) {
 		{name: "Invalid name", group: &DeviceGroup{Name: "** test group", OrgID: "111111", Type: DeviceGroupTypeDefault}, expected: errors.New(DeviceGroupNameInvalidErrorMessage)},
 		{name: "Empty orgID", group: &DeviceGroup{Name: "test_group", OrgID: "", Type: "static"}, expected: errors.New(DeviceGroupOrgIDEmptyErrorMessage)},
 		{name: "Valid DeviceGroup", group: &DeviceGroup{Name: "test_group", OrgID: "111111", Type: DeviceGroupTypeDefault}, expected: nil},
+		{name: "Static with rule", group: &DeviceGroup{Name: "test_group", OrgID: "111111", Type: DeviceGroupTypeStatic, Rule: "connected = true"}, expected: errors.New(DeviceGroupRuleStaticErrorMessage)},
+		{name: "Dynamic without rule", group: &DeviceGroup{Name: "test_group", OrgID: "111111", Type: DeviceGroupTypeDynamic}, expected: errors.New(DeviceGroupRuleEmptyErrorMessage)},
+		{name: "Valid dynamic DeviceGroup", group: &DeviceGroup{Name: "test_group", OrgID: "111111", Type: DeviceGroupTypeDynamic, Rule: "connected = true"}, expected: nil},
 	}
 
 	for _, testScenario := range testScenarios {
@@ -47,7 +50,7 @@ func TestGroupCreateUpdateConstraint(t *testing.T) {
 
 	groupNewType := DeviceGroupTypeStatic
 	groupNewName := "new_test_group"
-	group := DeviceGroup{Name: groupInitialName, Account: groupInitialAccount, OrgID: groupInitialOrgID, Type: groupInitialType}
+	group := DeviceGroup{Name: groupInitialName, Account: groupInitialAccount, OrgID: groupInitialOrgID, Type: groupInitialType, Rule: "connected = true"}
 	err := group.ValidateRequest()
 	if err != nil {
 		t.Errorf("Failed to pass validation, Error: %q", err)
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+)
+
+// DeviceGroupDesiredState pins the devices of a device group to a version of an image set.
+// The devices not running the version drift from the desired state until they are reconciled.
+type DeviceGroupDesiredState struct {
+	Model
+	OrgID         string `json:"org_id" gorm:"index;<-:create"`
+	DeviceGroupID uint   `json:"device_group_id" gorm:"uniqueIndex"`
+	ImageSetID    uint   `json:"image_set_id"`
+	Version       int    `json:"version"`
+	ImageID       uint   `json:"image_id"` // the image of the version, its commit is the desired commit of the devices
+}
+
+// DeviceDrift is the drift of a device from the desired state of its group
+type DeviceDrift struct {
+	DeviceID     uint   `json:"device_id"`
+	DeviceUUID   string `json:"device_uuid"`
+	DeviceName   string `json:"device_name"`
+	ImageID      uint   `json:"image_id"`
+	ImageVersion int    `json:"image_version,omitempty"`
+	CurrentHash  string `json:"current_hash,omitempty"`
+	Status       string `json:"status"`
+}
+
+// DeviceDriftCounts is the number of devices of a device group for each drift status
+type DeviceDriftCounts struct {
+	InSync   int `json:"in_sync"`
+	Drifting int `json:"drifting"`
+	Ahead    int `json:"ahead"`
+	Unknown  int `json:"unknown"`
+}
+
+// DeviceGroupDrift is the drift of the devices of a device group from its desired state
+type DeviceGroupDrift struct {
+	DesiredState *DeviceGroupDesiredState `json:"desired_state"`
+	CommitHash   string                   `json:"commit_hash"` // the desired commit of the devices
+	Counts       DeviceDriftCounts        `json:"counts"`
+	Devices      []DeviceDrift            `json:"devices"`
+}
+
+const (
+	// DeviceDriftStatusInSync is for when the device runs the desired commit
+	DeviceDriftStatusInSync = "IN_SYNC"
+	// DeviceDriftStatusDrifting is for when the device runs an older version or another image than the desired one
+	DeviceDriftStatusDrifting = "DRIFTING"
+	// DeviceDriftStatusAhead is for when the device runs a newer version of the desired image set
+	DeviceDriftStatusAhead = "AHEAD"
+	// DeviceDriftStatusUnknown is for when the image the device runs is unknown
+	DeviceDriftStatusUnknown = "UNKNOWN"
+
+	// DeviceGroupDesiredStateImageSetEmptyErrorMessage is the error message returned when the desired image set is not defined
+	DeviceGroupDesiredStateImageSetEmptyErrorMessage = "desired image set must be defined"
+	// DeviceGroupDesiredStateVersionInvalidErrorMessage is the error message returned when the desired version is invalid
+	DeviceGroupDesiredStateVersionInvalidErrorMessage = "desired version must be a positive integer"
+)
+
+// ValidateRequest validates the DeviceGroupDesiredState request
+func (s *DeviceGroupDesiredState) ValidateRequest() error {
+	if s.ImageSetID == 0 {
+		return errors.New(DeviceGroupDesiredStateImageSetEmptyErrorMessage)
+	}
+	if s.Version <= 0 {
+		return errors.New(DeviceGroupDesiredStateVersionInvalidErrorMessage)
+	}
+	return nil
+}
+
+// DeviceDriftStatus returns the drift status of a device from the desired image, deviceImage is nil when the image
+// the device runs is not known. The current hash reported by the device prevails over its image.
+func DeviceDriftStatus(device *Device, deviceImage *Image, desiredImage *Image) string {
+	if device.CurrentHash != "" && desiredImage.Commit != nil && device.CurrentHash == desiredImage.Commit.OSTreeCommit {
+		return DeviceDriftStatusInSync
+	}
+	if deviceImage == nil {
+		return DeviceDriftStatusUnknown
+	}
+	if deviceImage.ID == desiredImage.ID {
+		if device.CurrentHash != "" && deviceImage.Commit != nil && device.CurrentHash != deviceImage.Commit.OSTreeCommit {
+			// the device image is outdated, e.g. the device was rolled back
+			return DeviceDriftStatusDrifting
+		}
+		return DeviceDriftStatusInSync
+	}
+	if deviceImage.ImageSetID != nil && desiredImage.ImageSetID != nil && *deviceImage.ImageSetID == *desiredImage.ImageSetID &&
+		deviceImage.Version > desiredImage.Version {
+		return DeviceDriftStatusAhead
+	}
+	return DeviceDriftStatusDrifting
+}
+
+// Add counts a device drift status
+func (c *DeviceDriftCounts) Add(status string) {
+	switch status {
+	case DeviceDriftStatusInSync:
+		c.InSync++
+	case DeviceDriftStatusDrifting:
+		c.Drifting++
+	case DeviceDriftStatusAhead:
+		c.Ahead++
+	default:
+		c.Unknown++
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+// SetDeviceGroupDesiredStateAPI is the device group desired state PUT endpoint struct for openapi.json auto-gen
+type SetDeviceGroupDesiredStateAPI struct {
+	ImageSetID uint `json:"image_set_id" example:"1024"` // the image set the devices are pinned to a version of
+	Version    int  `json:"version" example:"3"`         // the successful image set version the devices are pinned to
+} // SetDeviceGroupDesiredState
+
+// DeviceGroupDesiredStateAPI is the device group desired state endpoints return struct for openapi.json auto-gen
+type DeviceGroupDesiredStateAPI struct {
+	ID            uint   `json:"ID" example:"1024"`              // the desired state id
+	OrgID         string `json:"org_id" example:"2000"`          // orgId that the desired state belongs to
+	DeviceGroupID uint   `json:"device_group_id" example:"1024"` // the device group whose devices are pinned
+	ImageSetID    uint   `json:"image_set_id" example:"1024"`    // the image set the devices are pinned to a version of
+	Version       int    `json:"version" example:"3"`            // the image set version the devices are pinned to
+	ImageID       uint   `json:"image_id" example:"2048"`        // the image of the version
+} // DeviceGroupDesiredState
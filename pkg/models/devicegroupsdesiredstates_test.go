@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeviceGroupDesiredStateValidateRequest(t *testing.T) {
+	testScenarios := []struct {
+		name     string
+		state    *DeviceGroupDesiredState
+		expected error
+	}{
+		{name: "No image set", state: &DeviceGroupDesiredState{Version: 1}, expected: errors.New(DeviceGroupDesiredStateImageSetEmptyErrorMessage)},
+		{name: "No version", state: &DeviceGroupDesiredState{ImageSetID: 1}, expected: errors.New(DeviceGroupDesiredStateVersionInvalidErrorMessage)},
+		{name: "Negative version", state: &DeviceGroupDesiredState{ImageSetID: 1, Version: -2}, expected: errors.New(DeviceGroupDesiredStateVersionInvalidErrorMessage)},
+		{name: "Valid desired state", state: &DeviceGroupDesiredState{ImageSetID: 1, Version: 3}, expected: nil},
+	}
+
+	for _, testScenario := range testScenarios {
+		err := testScenario.state.ValidateRequest()
+		if err == nil && testScenario.expected != nil {
+			t.Errorf("Test %q was supposed to fail but passed successfully", testScenario.name)
+		}
+		if err != nil && testScenario.expected == nil {
+			t.Errorf("Test %q was supposed to pass but failed: %s", testScenario.name, err)
+		}
+		if err != nil && testScenario.expected != nil && err.Error() != testScenario.expected.Error() {
+			t.Errorf("Test %q: expected to fail on %q but got %q", testScenario.name, testScenario.expected, err)
+		}
+	}
+}
+
+func TestDeviceDriftStatus(t *testing.T) {
+	imageSetID := uint(1)
+	otherImageSetID := uint(2)
+	oldImage := &Image{Model: Model{ID: 1}, ImageSetID: &imageSetID, Version: 1, Commit: &Commit{OSTreeCommit: "old-hash"}}
+	desiredImage := &Image{Model: Model{ID: 2}, ImageSetID: &imageSetID, Version: 2, Commit: &Commit{OSTreeCommit: "desired-hash"}}
+	newImage := &Image{Model: Model{ID: 3}, ImageSetID: &imageSetID, Version: 3, Commit: &Commit{OSTreeCommit: "new-hash"}}
+	otherImage := &Image{Model: Model{ID: 4}, ImageSetID: &otherImageSetID, Version: 5, Commit: &Commit{OSTreeCommit: "other-hash"}}
+
+	testScenarios := []struct {
+		name        string
+		device      *Device
+		deviceImage *Image
+		expected    string
+	}{
+		{name: "Desired image", device: &Device{ImageID: 2}, deviceImage: desiredImage, expected: DeviceDriftStatusInSync},
+		{name: "Desired commit", device: &Device{CurrentHash: "desired-hash"}, deviceImage: nil, expected: DeviceDriftStatusInSync},
+		{name: "Desired image with a rolled back commit", device: &Device{ImageID: 2, CurrentHash: "old-hash"}, deviceImage: desiredImage, expected: DeviceDriftStatusDrifting},
+		{name: "Older version", device: &Device{ImageID: 1}, deviceImage: oldImage, expected: DeviceDriftStatusDrifting},
+		{name: "Newer version", device: &Device{ImageID: 3}, deviceImage: newImage, expected: DeviceDriftStatusAhead},
+		{name: "Other image set", device: &Device{ImageID: 4}, deviceImage: otherImage, expected: DeviceDriftStatusDrifting},
+		{name: "Unknown image", device: &Device{CurrentHash: "unknown-hash"}, deviceImage: nil, expected: DeviceDriftStatusUnknown},
+	}
+
+	for _, testScenario := range testScenarios {
+		status := DeviceDriftStatus(testScenario.device, testScenario.deviceImage, desiredImage)
+		if status != testScenario.expected {
+			t.Errorf("Test %q: expected %q but got %q", testScenario.name, testScenario.expected, status)
+		}
+	}
+}
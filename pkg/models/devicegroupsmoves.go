@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+)
+
+// DeviceGroupsMove is a request to move devices from a device group to another
+type DeviceGroupsMove struct {
+	FromGroupID uint     `json:"from_group_id" example:"1024"`                                // the device group the devices are moved from
+	ToGroupID   uint     `json:"to_group_id" example:"2048"`                                  // the device group the devices are moved to
+	DevicesUUID []string `json:"devices_uuid" example:"b579a578-1a6f-48d5-8a45-21f2a656a5d4"` // the devices to move
+}
+
+// DeviceMoveResult is the outcome of the move of a device
+type DeviceMoveResult struct {
+	DeviceUUID string `json:"device_uuid"`
+	DeviceID   uint   `json:"device_id,omitempty"`
+	Status     string `json:"status"`
+}
+
+// DeviceGroupsMoveResult is the outcome of a devices move between device groups
+type DeviceGroupsMoveResult struct {
+	Moved   int                `json:"moved"`   // the number of devices moved
+	Devices []DeviceMoveResult `json:"devices"` // the outcome for each requested device
+}
+
+const (
+	// DeviceMoveStatusMoved is for when the device moved to the target group
+	DeviceMoveStatusMoved = "MOVED"
+	// DeviceMoveStatusNotFound is for when the device does not exist
+	DeviceMoveStatusNotFound = "NOT_FOUND"
+	// DeviceMoveStatusNotInSourceGroup is for when the device does not belong to the source group
+	DeviceMoveStatusNotInSourceGroup = "NOT_IN_SOURCE_GROUP"
+
+	// DeviceGroupsMoveGroupsErrorMessage is the error message returned when the move source or target group is invalid
+	DeviceGroupsMoveGroupsErrorMessage = "source and target groups must be defined and different"
+	// DeviceGroupsMoveDevicesEmptyErrorMessage is the error message returned when the move has no devices
+	DeviceGroupsMoveDevicesEmptyErrorMessage = "devices to move must be supplied"
+)
+
+// ValidateRequest validates the DeviceGroupsMove request
+func (m *DeviceGroupsMove) ValidateRequest() error {
+	if m.FromGroupID == 0 || m.ToGroupID == 0 || m.FromGroupID == m.ToGroupID {
+		return errors.New(DeviceGroupsMoveGroupsErrorMessage)
+	}
+	if len(m.DevicesUUID) == 0 {
+		return errors.New(DeviceGroupsMoveDevicesEmptyErrorMessage)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DeviceGroupRule is the parsed rule expression of a dynamic device group, a device is a member of the group
+// when it matches all the rule conditions
+type DeviceGroupRule struct {
+	Conditions []DeviceGroupRuleCondition
+}
+
+// DeviceGroupRuleCondition is a single condition of a dynamic device group rule, e.g. image_version < 3
+type DeviceGroupRuleCondition struct {
+	Attribute string
+	Operator  string
+	Value     string
+}
+
+const (
+	// DeviceGroupRuleAttributeImageSet is the id of the image set of the device image
+	DeviceGroupRuleAttributeImageSet = "image_set"
+	// DeviceGroupRuleAttributeImageVersion is the version of the device image
+	DeviceGroupRuleAttributeImageVersion = "image_version"
+	// DeviceGroupRuleAttributeName is the device name, the ~ operator matches a pattern where * is any sequence of characters
+	DeviceGroupRuleAttributeName = "name"
+	// DeviceGroupRuleAttributeTag is an inventory tag of the device in the namespace/key=value format
+	DeviceGroupRuleAttributeTag = "tag"
+	// DeviceGroupRuleAttributeConnected is whether the device is connected
+	DeviceGroupRuleAttributeConnected = "connected"
+	// DeviceGroupRuleAttributeLastSeenHours is the number of hours since the device was last seen
+	DeviceGroupRuleAttributeLastSeenHours = "last_seen_hours"
+	// DeviceGroupRuleAttributeUpdateAvailable is whether an update is available for the device
+	DeviceGroupRuleAttributeUpdateAvailable = "update_available"
+
+	// DeviceGroupRuleConditionsSeparator is the keyword joining the conditions of a rule
+	DeviceGroupRuleConditionsSeparator = "and"
+
+	// DeviceGroupRuleEmptyErrorMessage is the error message returned when a dynamic device group has no rule
+	DeviceGroupRuleEmptyErrorMessage = "dynamic group rule cannot be empty"
+	// DeviceGroupRuleStaticErrorMessage is the error message returned when a static device group has a rule
+	DeviceGroupRuleStaticErrorMessage = "static group cannot have a rule"
+	// DeviceGroupRuleInvalidErrorMessage is the error message returned when the rule expression can not be parsed
+	DeviceGroupRuleInvalidErrorMessage = "group rule is invalid"
+)
+
+type deviceGroupRuleValueKind int
+
+const (
+	deviceGroupRuleValueInt deviceGroupRuleValueKind = iota
+	deviceGroupRuleValueBool
+	deviceGroupRuleValueString
+)
+
+type deviceGroupRuleAttribute struct {
+	kind      deviceGroupRuleValueKind
+	operators []string
+}
+
+var deviceGroupRuleAttributes = map[string]deviceGroupRuleAttribute{
+	DeviceGroupRuleAttributeImageSet:        {kind: deviceGroupRuleValueInt, operators: []string{"=", "!="}},
+	DeviceGroupRuleAttributeImageVersion:    {kind: deviceGroupRuleValueInt, operators: []string{"=", "!=", "<", "<=", ">", ">="}},
+	DeviceGroupRuleAttributeName:            {kind: deviceGroupRuleValueString, operators: []string{"=", "!=", "~"}},
+	DeviceGroupRuleAttributeTag:             {kind: deviceGroupRuleValueString, operators: []string{"="}},
+	DeviceGroupRuleAttributeConnected:       {kind: deviceGroupRuleValueBool, operators: []string{"="}},
+	DeviceGroupRuleAttributeLastSeenHours:   {kind: deviceGroupRuleValueInt, operators: []string{"<", "<=", ">", ">="}},
+	DeviceGroupRuleAttributeUpdateAvailable: {kind: deviceGroupRuleValueBool, operators: []string{"="}},
+}
+
+// ParseDeviceGroupRule parses a dynamic device group rule expression, the conditions are joined by "and", e.g.
+// image_set = 12 and image_version < 3 and name ~ "store-*" and tag = "site/city=paris" and connected = true
+func ParseDeviceGroupRule(expression string) (*DeviceGroupRule, error) {
+	tokens, err := tokenizeDeviceGroupRule(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New(DeviceGroupRuleEmptyErrorMessage)
+	}
+	rule := &DeviceGroupRule{}
+	for i := 0; i < len(tokens); i += 4 {
+		if i+3 > len(tokens) {
+			return nil, fmt.Errorf("%s: incomplete condition at the end of the rule", DeviceGroupRuleInvalidErrorMessage)
+		}
+		condition := DeviceGroupRuleCondition{Attribute: strings.ToLower(tokens[i]), Operator: tokens[i+1], Value: tokens[i+2]}
+		if err := condition.validate(); err != nil {
+			return nil, err
+		}
+		rule.Conditions = append(rule.Conditions, condition)
+		if i+3 < len(tokens) && strings.ToLower(tokens[i+3]) != DeviceGroupRuleConditionsSeparator {
+			return nil, fmt.Errorf("%s: expected %q after %s but got %q",
+				DeviceGroupRuleInvalidErrorMessage, DeviceGroupRuleConditionsSeparator, condition.Attribute, tokens[i+3])
+		}
+		if i+3 == len(tokens)-1 {
+			return nil, fmt.Errorf("%s: missing condition after %q", DeviceGroupRuleInvalidErrorMessage, DeviceGroupRuleConditionsSeparator)
+		}
+	}
+	return rule, nil
+}
+
+func (c *DeviceGroupRuleCondition) validate() error {
+	attribute, ok := deviceGroupRuleAttributes[c.Attribute]
+	if !ok {
+		return fmt.Errorf("%s: unknown attribute %q", DeviceGroupRuleInvalidErrorMessage, c.Attribute)
+	}
+	validOperator := false
+	for _, operator := range attribute.operators {
+		if operator == c.Operator {
+			validOperator = true
+			break
+		}
+	}
+	if !validOperator {
+		return fmt.Errorf("%s: operator %q is not supported by %s, use one of %s",
+			DeviceGroupRuleInvalidErrorMessage, c.Operator, c.Attribute, strings.Join(attribute.operators, " "))
+	}
+	switch attribute.kind {
+	case deviceGroupRuleValueInt:
+		if value, err := strconv.Atoi(c.Value); err != nil || value < 0 {
+			return fmt.Errorf("%s: %s value must be a positive integer", DeviceGroupRuleInvalidErrorMessage, c.Attribute)
+		}
+	case deviceGroupRuleValueBool:
+		if _, err := strconv.ParseBool(c.Value); err != nil {
+			return fmt.Errorf("%s: %s value must be true or false", DeviceGroupRuleInvalidErrorMessage, c.Attribute)
+		}
+	default:
+		if c.Value == "" {
+			return fmt.Errorf("%s: %s value cannot be empty", DeviceGroupRuleInvalidErrorMessage, c.Attribute)
+		}
+		if c.Attribute == DeviceGroupRuleAttributeTag && !strings.Contains(c.Value, "=") {
+			return fmt.Errorf("%s: tag value must be in the namespace/key=value format", DeviceGroupRuleInvalidErrorMessage)
+		}
+	}
+	return nil
+}
+
+// IntValue returns the condition value of an integer attribute
+func (c *DeviceGroupRuleCondition) IntValue() int {
+	value, _ := strconv.Atoi(c.Value)
+	return value
+}
+
+// BoolValue returns the condition value of a boolean attribute
+func (c *DeviceGroupRuleCondition) BoolValue() bool {
+	value, _ := strconv.ParseBool(c.Value)
+	return value
+}
+
+// tokenizeDeviceGroupRule splits the rule expression in attributes, operators, values and separators,
+// double-quoted values can contain spaces
+func tokenizeDeviceGroupRule(expression string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expression); {
+		char := expression[i]
+		switch {
+		case char == ' ' || char == '\t' || char == '\n':
+			i++
+		case char == '"':
+			end := strings.IndexByte(expression[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%s: unterminated quoted value", DeviceGroupRuleInvalidErrorMessage)
+			}
+			tokens = append(tokens, expression[i+1:i+1+end])
+			i += end + 2
+		case strings.IndexByte("=!<>~", char) >= 0:
+			operator := string(char)
+			if i+1 < len(expression) && expression[i+1] == '=' && char != '=' && char != '~' {
+				operator += "="
+			}
+			tokens = append(tokens, operator)
+			i += len(operator)
+		default:
+			start := i
+			for i < len(expression) && strings.IndexByte(" \t\n\"=!<>~", expression[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, expression[start:i])
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDeviceGroupRule(t *testing.T) {
+	testScenarios := []struct {
+		name       string
+		expression string
+		expected   []DeviceGroupRuleCondition
+		errorText  string
+	}{
+		{
+			name:       "All the attributes",
+			expression: `image_set = 12 AND image_version < 3 and name ~ "store *" and tag = "site/city=paris" and connected = true and last_seen_hours >= 24 and update_available != false`,
+			errorText:  "operator \"!=\" is not supported by update_available",
+		},
+		{
+			name:       "Valid rule",
+			expression: `image_set = 12 AND image_version<=3 and name ~ "store *" and tag = "site/city=paris" and connected = true and last_seen_hours >= 24`,
+			expected: []DeviceGroupRuleCondition{
+				{Attribute: DeviceGroupRuleAttributeImageSet, Operator: "=", Value: "12"},
+				{Attribute: DeviceGroupRuleAttributeImageVersion, Operator: "<=", Value: "3"},
+				{Attribute: DeviceGroupRuleAttributeName, Operator: "~", Value: "store *"},
+				{Attribute: DeviceGroupRuleAttributeTag, Operator: "=", Value: "site/city=paris"},
+				{Attribute: DeviceGroupRuleAttributeConnected, Operator: "=", Value: "true"},
+				{Attribute: DeviceGroupRuleAttributeLastSeenHours, Operator: ">=", Value: "24"},
+			},
+		},
+		{name: "Empty rule", expression: "  ", errorText: DeviceGroupRuleEmptyErrorMessage},
+		{name: "Unknown attribute", expression: "os = rhel", errorText: "unknown attribute \"os\""},
+		{name: "Invalid integer", expression: "image_version > latest", errorText: "image_version value must be a positive integer"},
+		{name: "Invalid boolean", expression: "connected = maybe", errorText: "connected value must be true or false"},
+		{name: "Invalid tag", expression: `tag = "site"`, errorText: "tag value must be in the namespace/key=value format"},
+		{name: "Missing separator", expression: "connected = true update_available = true", errorText: "expected \"and\" after connected"},
+		{name: "Missing condition", expression: "connected = true and", errorText: "missing condition after \"and\""},
+		{name: "Incomplete condition", expression: "connected =", errorText: "incomplete condition"},
+		{name: "Unterminated quote", expression: `name = "store`, errorText: "unterminated quoted value"},
+	}
+
+	for _, testScenario := range testScenarios {
+		rule, err := ParseDeviceGroupRule(testScenario.expression)
+		if testScenario.errorText != "" {
+			if err == nil || !strings.Contains(err.Error(), testScenario.errorText) {
+				t.Errorf("Test %q: expected to fail on %q but got %v", testScenario.name, testScenario.errorText, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %q was supposed to pass but failed: %s", testScenario.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rule.Conditions, testScenario.expected) {
+			t.Errorf("Test %q: expected the conditions %v but got %v", testScenario.name, testScenario.expected, rule.Conditions)
+		}
+	}
+}
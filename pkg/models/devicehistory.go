@@ -0,0 +1,70 @@
+package models
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DeviceEvent records a change of a device seen by the service, such as an image change reported by inventory,
+// a group membership change or a connectivity change.
+// The Model fields are declared explicitly for created_at to be part of the connectivity index, used by DevicesDisconnectedQuery
+type DeviceEvent struct {
+	ID        uint           `gorm:"primarykey" json:"ID,omitempty"`
+	CreatedAt EdgeAPITime    `gorm:"index;index:idx_device_events_connectivity,priority:3" json:"CreatedAt,omitempty"`
+	UpdatedAt EdgeAPITime    `gorm:"index" json:"UpdatedAt,omitempty"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"DeletedAt,omitempty"`
+	OrgID     string         `json:"org_id" gorm:"index;<-:create"`
+	DeviceID  uint           `json:"device_id" gorm:"index;index:idx_device_events_connectivity,priority:1"`
+	Type      string         `json:"type" gorm:"index:idx_device_events_connectivity,priority:2"`
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+}
+
+const (
+	// DeviceEventTypeUpdate is the history event type of an update attempt of the device
+	DeviceEventTypeUpdate = "UPDATE"
+	// DeviceEventTypeImageChanged is for when inventory reports a device running another image, From and To are the ostree commits
+	DeviceEventTypeImageChanged = "IMAGE_CHANGED"
+	// DeviceEventTypeGroupAdded is for when the device is added to a device group, To is the group name
+	DeviceEventTypeGroupAdded = "GROUP_ADDED"
+	// DeviceEventTypeGroupRemoved is for when the device is removed from a device group, From is the group name
+	DeviceEventTypeGroupRemoved = "GROUP_REMOVED"
+	// DeviceEventTypeInventoryGroupChanged is for when inventory reports the device in another inventory group
+	DeviceEventTypeInventoryGroupChanged = "INVENTORY_GROUP_CHANGED"
+	// DeviceEventTypeConnected is for when the device became reachable through cloud connector
+	DeviceEventTypeConnected = "CONNECTED"
+	// DeviceEventTypeDisconnected is for when the device became unreachable through cloud connector
+	DeviceEventTypeDisconnected = "DISCONNECTED"
+	// DeviceEventTypeStalenessChanged is for when the device became fresh, stale or culled, From and To are the staleness
+	DeviceEventTypeStalenessChanged = "STALENESS_CHANGED"
+)
+
+// DeviceHistoryEvent is an event of the device history timeline
+type DeviceHistoryEvent struct {
+	Type          string      `json:"type"`
+	Time          EdgeAPITime `json:"time"`
+	UpdateID      uint        `json:"update_id,omitempty"`
+	FromCommit    string      `json:"from_commit,omitempty"`
+	ToCommit      string      `json:"to_commit,omitempty"`
+	Status        string      `json:"status,omitempty"`
+	Reason        string      `json:"reason,omitempty"`
+	PlaybookRunID string      `json:"playbook_run_id,omitempty"`
+	From          string      `json:"from,omitempty"`
+	To            string      `json:"to,omitempty"`
+}
+
+// DeviceHistory is a page of the device history timeline, the events are ordered from the most recent
+type DeviceHistory struct {
+	Count int64                `json:"count"`
+	Data  []DeviceHistoryEvent `json:"data"`
+}
+
+// BeforeCreate method is called before creating a device event, it makes sure org_id is not empty
+func (e *DeviceEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.OrgID == "" {
+		log.Error("device event do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DeviceHistoryEventAPI is an event of the device history timeline for openapi.json auto-gen
+type DeviceHistoryEventAPI struct {
+	Type          string    `json:"type" example:"UPDATE"`                                    // the event type, UPDATE, IMAGE_CHANGED, GROUP_ADDED, GROUP_REMOVED, INVENTORY_GROUP_CHANGED, CONNECTED or DISCONNECTED
+	Time          time.Time `json:"time" example:"2023-02-21T09:48:42.371Z"`                  // the time of the event
+	UpdateID      uint      `json:"update_id,omitempty" example:"1026"`                       // the update of an UPDATE event
+	FromCommit    string    `json:"from_commit,omitempty" example:"2478a5a4c6f0e8d8b1d6a16a"` // the ostree commit the device ran before the event
+	ToCommit      string    `json:"to_commit,omitempty" example:"9bd8dfe9856aa5bb1683e85f"`   // the ostree commit the device runs after the event
+	Status        string    `json:"status,omitempty" example:"COMPLETE"`                      // the dispatch status of an UPDATE event
+	Reason        string    `json:"reason,omitempty" example:""`                              // the dispatch reason of an UPDATE event
+	PlaybookRunID string    `json:"playbook_run_id,omitempty" example:"c0e6d4f1-58a4-4c1c"`   // the playbook dispatcher run id of an UPDATE event
+	From          string    `json:"from,omitempty" example:"group-1"`                         // the previous group of a group event
+	To            string    `json:"to,omitempty" example:"group-2"`                           // the new group of a group event
+} // DeviceHistoryEvent
+
+// DeviceHistoryAPI is the device history endpoint return struct for openapi.json auto-gen
+type DeviceHistoryAPI struct {
+	Count int64                   `json:"count" example:"40"` // the overall number of events of the device
+	Data  []DeviceHistoryEventAPI `json:"data"`               // the events of the page, from the most recent
+} // DeviceHistory
@@ -52,6 +52,7 @@ type DeviceView struct {
 	DeviceUUID       string              `json:"DeviceUUID"`
 	ImageID          uint                `json:"ImageID"`
 	ImageName        string              `json:"ImageName"`
+	ImageVersion     int                 `json:"ImageVersion"`
 	LastSeen         EdgeAPITime         `json:"LastSeen"`
 	UpdateAvailable  bool                `json:"UpdateAvailable"`
 	Status           string              `json:"Status"`
@@ -61,6 +62,10 @@ type DeviceView struct {
 	DispatcherReason string              `json:"DispatcherReason"`
 	GroupName        string              `json:"GroupName"` // the inventory group name
 	GroupUUID        string              `json:"GroupUUID"` // the inventory group id
+	// the drift from the desired state of the device group, when the device belongs to a group with a desired state
+	DriftStatus string `json:"DriftStatus,omitempty"`
+	// the inventory tags of the device
+	Tags []DeviceTag `json:"Tags"`
 }
 
 // DeviceDeviceGroup is a struct of device group name and id needed for DeviceView
@@ -76,6 +81,8 @@ const (
 	DeviceViewStatusUpdating = "UPDATING"
 	// DeviceViewStatusUpdateAvail is for when a update available for a device
 	DeviceViewStatusUpdateAvail = "UPDATE AVAILABLE"
+	// DeviceViewStatusStale is for when a device was not seen within the org stale threshold
+	DeviceViewStatusStale = "STALE"
 )
 
 // Device is a record of Edge Devices referenced by their UUID as per the
@@ -102,6 +109,18 @@ type Device struct {
 	UpdateTransaction *[]UpdateTransaction `faker:"-" gorm:"many2many:updatetransaction_devices;" json:"UpdateTransaction"`
 	GroupName         string               `json:"group_name"` // the inventory group name
 	GroupUUID         string               `json:"group_uuid"` // the inventory group id
+	SystemProfile     DeviceSystemProfile  `gorm:"embedded" json:"SystemProfile"`
+
+	// the staleness of the device, FRESH, STALE or CULLED, from the time it was last seen
+	Staleness string `gorm:"index;default:FRESH" json:"Staleness"`
+	// the device groups the device was removed from when culled, it is added back to them when it is seen again
+	CulledDeviceGroupsIDs []uint `gorm:"type:text;serializer:json" json:"-"`
+
+	// the subscription manager id of the device, the common name of its certificate
+	SubscriptionManagerID string `gorm:"index" json:"SubscriptionManagerID,omitempty"`
+	// the greenboot status and agent version reported by the device last check-in
+	GreenbootStatus string `json:"GreenbootStatus,omitempty"`
+	AgentVersion    string `json:"AgentVersion,omitempty"`
 }
 
 // BeforeCreate method is called before creating devices, it make sure org_id is not empty
@@ -101,6 +101,7 @@ type DeviceViewAPI struct {
 	DeviceUUID       string           `json:"DeviceUUID"      example:"a-8bdf-a21accb24925"` // UUID of Device
 	ImageID          uint             `json:"ImageID"         example:"323241"`              // ID of image
 	ImageName        string           `json:"ImageName"       example:"image_name"`          // Name of image
+	ImageVersion     int              `json:"ImageVersion"    example:"2"`                   // Version of image
 	LastSeen         EdgeAPITime      `json:"LastSeen"`                                      // Last datetime that device updated
 	UpdateAvailable  bool             `json:"UpdateAvailable" example:"true"`                // indicate if there is update to device
 	Status           string           `json:"Status"          example:"SUCCESS"`             // Status of device
@@ -110,6 +111,8 @@ type DeviceViewAPI struct {
 	DispatcherReason string           `json:"DispatcherReason"`                              // Reason of Dispatch
 	GroupName        string           `json:"GroupName"`                                     // the inventory group name
 	GroupUUID        string           `json:"GroupUUID"`                                     // the inventory group id
+	// the inventory tags of the device
+	Tags []DeviceTag `json:"Tags"`
 }
 
 // DeviceViewListAPI is the list of devices for a given account, formatted for the UI
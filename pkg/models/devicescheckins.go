@@ -0,0 +1,41 @@
+package models
+
+import "errors"
+
+// DeviceCheckIn is the state reported by a device checking in with its certificate, it updates the device without
+// waiting for the inventory events
+type DeviceCheckIn struct {
+	BootedCommit    string `json:"booted_commit"`    // the ostree commit the device booted
+	StagedCommit    string `json:"staged_commit"`    // the ostree commit staged for the next boot, if any
+	GreenbootStatus string `json:"greenboot_status"` // the greenboot status of the booted deployment, green or red
+	AgentVersion    string `json:"agent_version"`    // the version of the agent checking in
+}
+
+// DeviceCheckInResult is the state returned to a device checking in
+type DeviceCheckInResult struct {
+	DeviceUUID      string `json:"device_uuid"`
+	UpdateAvailable bool   `json:"update_available"`
+}
+
+const (
+	// DeviceGreenbootStatusGreen is for when the booted deployment passed the greenboot health checks
+	DeviceGreenbootStatusGreen = "green"
+	// DeviceGreenbootStatusRed is for when the booted deployment failed the greenboot health checks
+	DeviceGreenbootStatusRed = "red"
+
+	// DeviceCheckInBootedCommitRequiredErrorMessage is the error message returned when the booted commit is missing
+	DeviceCheckInBootedCommitRequiredErrorMessage = "booted commit is required"
+	// DeviceCheckInGreenbootStatusInvalidErrorMessage is the error message returned when the greenboot status is unknown
+	DeviceCheckInGreenbootStatusInvalidErrorMessage = "greenboot status must be green or red"
+)
+
+// ValidateRequest validates the DeviceCheckIn request
+func (c *DeviceCheckIn) ValidateRequest() error {
+	if c.BootedCommit == "" {
+		return errors.New(DeviceCheckInBootedCommitRequiredErrorMessage)
+	}
+	if c.GreenbootStatus != "" && c.GreenbootStatus != DeviceGreenbootStatusGreen && c.GreenbootStatus != DeviceGreenbootStatusRed {
+		return errors.New(DeviceCheckInGreenbootStatusInvalidErrorMessage)
+	}
+	return nil
+}
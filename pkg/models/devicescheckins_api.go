@@ -0,0 +1,15 @@
+package models
+
+// DeviceCheckInAPI is the device check-in POST endpoint struct for openapi.json auto-gen
+type DeviceCheckInAPI struct {
+	BootedCommit    string `json:"booted_commit" example:"9bd8dfe9856aa5bb1683e85f123bfe7785d45fbdb6f10372ff2c80e703400999"` // the ostree commit the device booted
+	StagedCommit    string `json:"staged_commit" example:"c7fcb0a8b9e2b1cd0d0b3b4bbd5b39e5b0bdf1a7b51a0a07b8d3b1b6dd9e2f6c"` // the ostree commit staged for the next boot, if any
+	GreenbootStatus string `json:"greenboot_status" example:"green"`                                                         // the greenboot status of the booted deployment, green or red
+	AgentVersion    string `json:"agent_version" example:"0.2.8"`                                                            // the version of the agent checking in
+} // DeviceCheckIn
+
+// DeviceCheckInResultAPI is the device check-in endpoint return struct for openapi.json auto-gen
+type DeviceCheckInResultAPI struct {
+	DeviceUUID      string `json:"device_uuid" example:"b579a578-1a6f-48d5-8a45-21f2a656a5d4"` // the inventory id of the device
+	UpdateAvailable bool   `json:"update_available" example:"true"`                            // whether an update of the device image is available
+} // DeviceCheckInResult
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// DeviceSystemProfile is the subset of the inventory system profile of a device persisted on Edge API,
+// it allows to filter and sort the devices without requesting inventory
+type DeviceSystemProfile struct {
+	Arch                 string                      `json:"arch,omitempty"`
+	OSRelease            string                      `json:"os_release,omitempty"`
+	OSKernelVersion      string                      `json:"os_kernel_version,omitempty"`
+	CPUModel             string                      `json:"cpu_model,omitempty" gorm:"column:cpu_model"`
+	NumberOfCPUs         int                         `json:"number_of_cpus,omitempty" gorm:"column:number_of_cpus"`
+	SystemMemoryBytes    int64                       `json:"system_memory_bytes,omitempty"`
+	HostType             string                      `json:"host_type,omitempty"`
+	NetworkInterfaces    []DeviceNetworkInterface    `json:"network_interfaces,omitempty" gorm:"type:text;serializer:json"`
+	Tags                 []string                    `json:"tags,omitempty" gorm:"type:text;serializer:json"` // the inventory tags in the namespace/key=value format
+	RpmOSTreeDeployments []DeviceRpmOSTreeDeployment `json:"rpm_ostree_deployments,omitempty" gorm:"column:rpm_ostree_deployments;type:text;serializer:json"`
+}
+
+// DeviceNetworkInterface is a network interface of the device system profile
+type DeviceNetworkInterface struct {
+	Name          string   `json:"name"`
+	MACAddress    string   `json:"mac_address,omitempty"`
+	IPv4Addresses []string `json:"ipv4_addresses,omitempty"`
+	IPv6Addresses []string `json:"ipv6_addresses,omitempty"`
+	State         string   `json:"state,omitempty"`
+	Type          string   `json:"type,omitempty"`
+}
+
+// DeviceRpmOSTreeDeployment is a rpm-ostree deployment of the device system profile
+type DeviceRpmOSTreeDeployment struct {
+	Checksum string `json:"checksum"`
+	Origin   string `json:"origin,omitempty"`
+	OSName   string `json:"osname,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Booted   bool   `json:"booted"`
+	Pinned   bool   `json:"pinned"`
+}
+
+const (
+	// DeviceSystemProfileFieldArch is the system profile field of the device architecture
+	DeviceSystemProfileFieldArch = "arch"
+	// DeviceSystemProfileFieldOSRelease is the system profile field of the device operating system release
+	DeviceSystemProfileFieldOSRelease = "os_release"
+	// DeviceSystemProfileFieldKernel is the system profile field of the device kernel version
+	DeviceSystemProfileFieldKernel = "os_kernel_version"
+	// DeviceSystemProfileFieldCPU is the system profile fields of the device cpu model and number of cpus
+	DeviceSystemProfileFieldCPU = "cpu"
+	// DeviceSystemProfileFieldMemory is the system profile field of the device memory size
+	DeviceSystemProfileFieldMemory = "memory"
+	// DeviceSystemProfileFieldHostType is the system profile field of the device host type
+	DeviceSystemProfileFieldHostType = "host_type"
+	// DeviceSystemProfileFieldNetworkInterfaces is the system profile field of the device network interfaces
+	DeviceSystemProfileFieldNetworkInterfaces = "network_interfaces"
+	// DeviceSystemProfileFieldTags is the field of the device inventory tags
+	DeviceSystemProfileFieldTags = "tags"
+	// DeviceSystemProfileFieldRpmOSTreeDeployments is the system profile field of the device rpm-ostree deployments
+	DeviceSystemProfileFieldRpmOSTreeDeployments = "rpm_ostree_deployments"
+)
+
+// Subset returns the system profile with only the values of the fields, the other values are reset
+func (p DeviceSystemProfile) Subset(fields []string) DeviceSystemProfile {
+	var subset DeviceSystemProfile
+	for _, field := range fields {
+		switch field {
+		case DeviceSystemProfileFieldArch:
+			subset.Arch = p.Arch
+		case DeviceSystemProfileFieldOSRelease:
+			subset.OSRelease = p.OSRelease
+		case DeviceSystemProfileFieldKernel:
+			subset.OSKernelVersion = p.OSKernelVersion
+		case DeviceSystemProfileFieldCPU:
+			subset.CPUModel = p.CPUModel
+			subset.NumberOfCPUs = p.NumberOfCPUs
+		case DeviceSystemProfileFieldMemory:
+			subset.SystemMemoryBytes = p.SystemMemoryBytes
+		case DeviceSystemProfileFieldHostType:
+			subset.HostType = p.HostType
+		case DeviceSystemProfileFieldNetworkInterfaces:
+			subset.NetworkInterfaces = p.NetworkInterfaces
+		case DeviceSystemProfileFieldTags:
+			subset.Tags = p.Tags
+		case DeviceSystemProfileFieldRpmOSTreeDeployments:
+			subset.RpmOSTreeDeployments = p.RpmOSTreeDeployments
+		}
+	}
+	return subset
+}
+
+// DeviceTag is an inventory tag of a device
+type DeviceTag struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+// String returns the tag in the namespace/key=value format, the namespace and value are omitted when empty
+func (t DeviceTag) String() string {
+	tag := t.Key
+	if t.Namespace != "" {
+		tag = t.Namespace + "/" + tag
+	}
+	if t.Value != "" {
+		tag = tag + "=" + t.Value
+	}
+	return tag
+}
+
+// ParseDeviceTag returns the DeviceTag of a tag in the namespace/key=value format
+func ParseDeviceTag(tag string) DeviceTag {
+	var deviceTag DeviceTag
+	tag, deviceTag.Value, _ = strings.Cut(tag, "=")
+	if namespace, key, found := strings.Cut(tag, "/"); found {
+		deviceTag.Namespace = namespace
+		deviceTag.Key = key
+	} else {
+		deviceTag.Key = tag
+	}
+	return deviceTag
+}
+
+// DeviceTags returns the parsed inventory tags of the device system profile
+func (p DeviceSystemProfile) DeviceTags() []DeviceTag {
+	tags := make([]DeviceTag, 0, len(p.Tags))
+	for _, tag := range p.Tags {
+		tags = append(tags, ParseDeviceTag(tag))
+	}
+	return tags
+}
+
+// DevicesTagQuery is the query of the devices having a tag, the argument is returned by DevicesTagQueryValue
+const DevicesTagQuery = "devices.tags LIKE ? ESCAPE '!'"
+
+// DevicesTagQueryValue returns the DevicesTagQuery argument matching exactly the tag among the persisted devices tags
+func DevicesTagQueryValue(tag string) string {
+	// the tags are persisted as a json list of strings, match the tag json string with its quotes
+	jsonTag, _ := json.Marshal(ParseDeviceTag(tag).String())
+	escaper := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return "%" + escaper.Replace(string(jsonTag)) + "%"
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeviceSystemProfileSubset(t *testing.T) {
+	profile := DeviceSystemProfile{
+		Arch:                 "x86_64",
+		OSRelease:            "9.2",
+		OSKernelVersion:      "5.14.0",
+		CPUModel:             "Intel(R) Xeon(R)",
+		NumberOfCPUs:         4,
+		SystemMemoryBytes:    8589934592,
+		HostType:             "edge",
+		NetworkInterfaces:    []DeviceNetworkInterface{{Name: "eth0", IPv4Addresses: []string{"10.0.0.42"}}},
+		Tags:                 []string{"site/store=042"},
+		RpmOSTreeDeployments: []DeviceRpmOSTreeDeployment{{Checksum: "checksum", Booted: true}},
+	}
+
+	testScenarios := []struct {
+		name     string
+		fields   []string
+		expected DeviceSystemProfile
+	}{
+		{name: "No fields", fields: nil, expected: DeviceSystemProfile{}},
+		{name: "Unknown field", fields: []string{"bios_vendor"}, expected: DeviceSystemProfile{}},
+		{
+			name:     "Cpu and memory",
+			fields:   []string{DeviceSystemProfileFieldCPU, DeviceSystemProfileFieldMemory},
+			expected: DeviceSystemProfile{CPUModel: "Intel(R) Xeon(R)", NumberOfCPUs: 4, SystemMemoryBytes: 8589934592},
+		},
+		{
+			name:     "Tags and network interfaces",
+			fields:   []string{DeviceSystemProfileFieldTags, DeviceSystemProfileFieldNetworkInterfaces},
+			expected: DeviceSystemProfile{Tags: profile.Tags, NetworkInterfaces: profile.NetworkInterfaces},
+		},
+		{
+			name: "All fields",
+			fields: []string{
+				DeviceSystemProfileFieldArch, DeviceSystemProfileFieldOSRelease, DeviceSystemProfileFieldKernel,
+				DeviceSystemProfileFieldCPU, DeviceSystemProfileFieldMemory, DeviceSystemProfileFieldHostType,
+				DeviceSystemProfileFieldNetworkInterfaces, DeviceSystemProfileFieldTags, DeviceSystemProfileFieldRpmOSTreeDeployments,
+			},
+			expected: profile,
+		},
+	}
+
+	for _, testScenario := range testScenarios {
+		subset := profile.Subset(testScenario.fields)
+		if !reflect.DeepEqual(subset, testScenario.expected) {
+			t.Errorf("Test %q: expected %+v but got %+v", testScenario.name, testScenario.expected, subset)
+		}
+	}
+}
+
+func TestParseDeviceTag(t *testing.T) {
+	testScenarios := []struct {
+		tag      string
+		expected DeviceTag
+	}{
+		{tag: "site/store=042", expected: DeviceTag{Namespace: "site", Key: "store", Value: "042"}},
+		{tag: "site/store", expected: DeviceTag{Namespace: "site", Key: "store"}},
+		{tag: "store=042", expected: DeviceTag{Key: "store", Value: "042"}},
+		{tag: "site/path=/opt/a=b", expected: DeviceTag{Namespace: "site", Key: "path", Value: "/opt/a=b"}},
+	}
+
+	for _, testScenario := range testScenarios {
+		deviceTag := ParseDeviceTag(testScenario.tag)
+		if deviceTag != testScenario.expected {
+			t.Errorf("Test %q: expected %+v but got %+v", testScenario.tag, testScenario.expected, deviceTag)
+		}
+		if deviceTag.String() != testScenario.tag {
+			t.Errorf("Test %q: expected string %q but got %q", testScenario.tag, testScenario.tag, deviceTag.String())
+		}
+	}
+}
+
+func TestDevicesTagQueryValue(t *testing.T) {
+	testScenarios := []struct {
+		tag      string
+		expected string
+	}{
+		{tag: "site/store=042", expected: `%"site/store=042"%`},
+		{tag: "site/store_id=10%", expected: `%"site/store!_id=10!%"%`},
+		{tag: "site/alert=!", expected: `%"site/alert=!!"%`},
+	}
+
+	for _, testScenario := range testScenarios {
+		if value := DevicesTagQueryValue(testScenario.tag); value != testScenario.expected {
+			t.Errorf("Test %q: expected %q but got %q", testScenario.tag, testScenario.expected, value)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DeviceStalenessSettings are the thresholds of an org after which a device not seen becomes stale, then culled.
+// A stale device is excluded from the updates by default, a culled device is removed from its groups and deleted.
+type DeviceStalenessSettings struct {
+	Model
+	OrgID       string `json:"org_id" gorm:"uniqueIndex;<-:create"`
+	StaleHours  int    `json:"stale_hours"`
+	CulledHours int    `json:"culled_hours"`
+}
+
+const (
+	// DeviceStalenessFresh is for when the device was seen within the stale threshold
+	DeviceStalenessFresh = "FRESH"
+	// DeviceStalenessStale is for when the device was not seen within the stale threshold
+	DeviceStalenessStale = "STALE"
+	// DeviceStalenessCulled is for when the device was not seen within the culled threshold, the device is deleted
+	DeviceStalenessCulled = "CULLED"
+
+	// DeviceStalenessSettingsInvalidErrorMessage is the error message returned when the staleness thresholds are invalid
+	DeviceStalenessSettingsInvalidErrorMessage = "stale hours must be positive and lower than culled hours"
+)
+
+// ValidateRequest validates the DeviceStalenessSettings request
+func (s *DeviceStalenessSettings) ValidateRequest() error {
+	if s.StaleHours <= 0 || s.CulledHours <= s.StaleHours {
+		return errors.New(DeviceStalenessSettingsInvalidErrorMessage)
+	}
+	return nil
+}
+
+// Staleness returns the staleness of a device last seen at the given time
+func (s *DeviceStalenessSettings) Staleness(lastSeen time.Time, now time.Time) string {
+	switch {
+	case lastSeen.Before(now.Add(-time.Duration(s.CulledHours) * time.Hour)):
+		return DeviceStalenessCulled
+	case lastSeen.Before(now.Add(-time.Duration(s.StaleHours) * time.Hour)):
+		return DeviceStalenessStale
+	default:
+		return DeviceStalenessFresh
+	}
+}
+
+// BeforeCreate method is called before creating device staleness settings, it make sure org_id is not empty
+func (s *DeviceStalenessSettings) BeforeCreate(tx *gorm.DB) error {
+	if s.OrgID == "" {
+		log.Error("device-staleness-settings do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package models
+
+// SetDeviceStalenessSettingsAPI is the device staleness settings PUT endpoint struct for openapi.json auto-gen
+type SetDeviceStalenessSettingsAPI struct {
+	StaleHours  int `json:"stale_hours" example:"26"`   // the hours after which a device not seen becomes stale
+	CulledHours int `json:"culled_hours" example:"336"` // the hours after which a device not seen is culled
+} // SetDeviceStalenessSettings
+
+// DeviceStalenessSettingsAPI is the device staleness settings endpoints return struct for openapi.json auto-gen
+type DeviceStalenessSettingsAPI struct {
+	OrgID       string `json:"org_id" example:"2000"`      // orgId that the settings belong to
+	StaleHours  int    `json:"stale_hours" example:"26"`   // the hours after which a device not seen becomes stale
+	CulledHours int    `json:"culled_hours" example:"336"` // the hours after which a device not seen is culled
+} // DeviceStalenessSettings
@@ -0,0 +1,59 @@
+package models
+
+import "errors"
+
+// DeviceUpdateManifest is the desired state of a device pulling its updates, the device deploys the commit
+// of the ref from the remote when it is not up to date, in its maintenance window if any
+type DeviceUpdateManifest struct {
+	DeviceUUID string `json:"device_uuid"`
+	UpToDate   bool   `json:"up_to_date"` // the device runs its desired commit, the other fields are empty
+
+	// the update transaction driving the desired state, zero when driven by the desired image of the device group
+	UpdateTransactionID uint                     `json:"update_transaction_id,omitempty"`
+	OSTreeRef           string                   `json:"ostree_ref,omitempty"`
+	CommitHash          string                   `json:"commit_hash,omitempty"`
+	ChangesRefs         bool                     `json:"changes_refs,omitempty"` // the device rebases on another ref
+	RemoteName          string                   `json:"remote_name,omitempty"`
+	RemoteURL           string                   `json:"remote_url,omitempty"`
+	GpgVerify           bool                     `json:"gpg_verify"`
+	MaintenanceWindow   *DeviceMaintenanceWindow `json:"maintenance_window,omitempty"`
+}
+
+// DeviceMaintenanceWindow is the time of day window in which a device applies its updates
+type DeviceMaintenanceWindow struct {
+	Start string `json:"start"` // HH:MM UTC
+	End   string `json:"end"`   // HH:MM UTC
+}
+
+// DeviceUpdateResult is the result of an update transaction applied by a device pulling its updates
+type DeviceUpdateResult struct {
+	UpdateTransactionID uint   `json:"update_transaction_id"`
+	Status              string `json:"status"`
+	Reason              string `json:"reason,omitempty"`
+}
+
+const (
+	// DeviceUpdateManifestRemoteName is the name of the ostree remote the devices pull their updates from
+	DeviceUpdateManifestRemoteName = "rhel-edge"
+
+	// DeviceUpdateResultStatusSuccess is for when the device deployed the update commit
+	DeviceUpdateResultStatusSuccess = "SUCCESS"
+	// DeviceUpdateResultStatusFailure is for when the device failed to deploy the update commit
+	DeviceUpdateResultStatusFailure = "FAILURE"
+
+	// DeviceUpdateResultUpdateRequiredErrorMessage is the error message returned when the update transaction is missing
+	DeviceUpdateResultUpdateRequiredErrorMessage = "update transaction id is required"
+	// DeviceUpdateResultStatusInvalidErrorMessage is the error message returned when the update result status is unknown
+	DeviceUpdateResultStatusInvalidErrorMessage = "update result status must be \"SUCCESS\" or \"FAILURE\""
+)
+
+// ValidateRequest validates the DeviceUpdateResult request
+func (r *DeviceUpdateResult) ValidateRequest() error {
+	if r.UpdateTransactionID == 0 {
+		return errors.New(DeviceUpdateResultUpdateRequiredErrorMessage)
+	}
+	if r.Status != DeviceUpdateResultStatusSuccess && r.Status != DeviceUpdateResultStatusFailure {
+		return errors.New(DeviceUpdateResultStatusInvalidErrorMessage)
+	}
+	return nil
+}
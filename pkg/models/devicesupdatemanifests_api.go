@@ -0,0 +1,28 @@
+package models
+
+// DeviceUpdateManifestAPI is the device desired state endpoint return struct for openapi.json auto-gen
+type DeviceUpdateManifestAPI struct {
+	DeviceUUID          string                      `json:"device_uuid" example:"b579a578-1a6f-48d5-8a45-21f2a656a5d4"`                                           // the inventory id of the device
+	UpToDate            bool                        `json:"up_to_date" example:"false"`                                                                           // whether the device runs its desired commit
+	UpdateTransactionID uint                        `json:"update_transaction_id,omitempty" example:"1026"`                                                       // the update transaction driving the desired state, if any
+	OSTreeRef           string                      `json:"ostree_ref,omitempty" example:"rhel/9/x86_64/edge"`                                                    // the desired ostree ref
+	CommitHash          string                      `json:"commit_hash,omitempty" example:"9bd8dfe9856aa5bb1683e85f123bfe7785d45fbdb6f10372ff2c80e703400999"`     // the desired ostree commit
+	ChangesRefs         bool                        `json:"changes_refs,omitempty" example:"false"`                                                               // whether the device rebases on another ref
+	RemoteName          string                      `json:"remote_name,omitempty" example:"rhel-edge"`                                                            // the ostree remote name
+	RemoteURL           string                      `json:"remote_url,omitempty" example:"https://cert.console.redhat.com/api/edge/v1/storage/update-repos/1026"` // the ostree remote url
+	GpgVerify           bool                        `json:"gpg_verify" example:"false"`                                                                           // whether the remote commits signatures are verified
+	MaintenanceWindow   *DeviceMaintenanceWindowAPI `json:"maintenance_window,omitempty"`                                                                         // the window in which the device applies the update
+} // DeviceUpdateManifest
+
+// DeviceMaintenanceWindowAPI is the maintenance window of a device for openapi.json auto-gen
+type DeviceMaintenanceWindowAPI struct {
+	Start string `json:"start" example:"22:00"` // the window start time of day, HH:MM UTC
+	End   string `json:"end" example:"04:00"`   // the window end time of day, HH:MM UTC
+} // DeviceMaintenanceWindow
+
+// DeviceUpdateResultAPI is the device update result POST endpoint struct for openapi.json auto-gen
+type DeviceUpdateResultAPI struct {
+	UpdateTransactionID uint   `json:"update_transaction_id" example:"1026"`                 // the update transaction applied by the device
+	Status              string `json:"status" example:"FAILURE"`                             // the update result, SUCCESS or FAILURE
+	Reason              string `json:"reason,omitempty" example:"rpm-ostree upgrade failed"` // the failure reason reported by the device
+} // DeviceUpdateResult
@@ -30,10 +30,13 @@ type StaticDeltaCommit struct {
 // StaticDeltaState models the state of a static delta database record
 type StaticDeltaState struct {
 	Model
-	Name   string `json:"name" gorm:"index;<-:create"` // the fromcommit-tocommit static delta name
-	OrgID  string `json:"org_id"`                      // the owner of the static delta
-	Status string `json:"status"`                      // the status of the generation process
-	URL    string `json:"url"`                         // url for the repo where the static delta is stored
+	Name       string `json:"name" gorm:"index;<-:create"` // the fromcommit-tocommit static delta name
+	OrgID      string `json:"org_id"`                      // the owner of the static delta
+	Status     string `json:"status"`                      // the status of the generation process
+	URL        string `json:"url"`                         // url for the repo where the static delta is stored
+	FromCommit string `json:"from_commit"`                 // the ostree commit the static delta updates from
+	ToCommit   string `json:"to_commit" gorm:"index"`      // the ostree commit the static delta updates to
+	Size       int64  `json:"size"`                        // the size in bytes of the generated static delta
 }
 
 // values for the StaticDeltaState Status field
@@ -0,0 +1,11 @@
+package models
+
+// StaticDeltaStateAPI is the image static deltas endpoint return struct for openapi.json auto-gen
+type StaticDeltaStateAPI struct {
+	Name       string `json:"name" example:"2478a5a4c6f0e8d8-9bd8dfe9856aa5bb"` // the fromcommit-tocommit static delta name
+	FromCommit string `json:"from_commit" example:"2478a5a4c6f0e8d8"`           // the ostree commit the static delta updates from
+	ToCommit   string `json:"to_commit" example:"9bd8dfe9856aa5bb"`             // the ostree commit the static delta updates to
+	Status     string `json:"status" example:"READY"`                           // the status of the generation process
+	Size       int64  `json:"size" example:"52428800"`                          // the size in bytes of the generated static delta
+	URL        string `json:"url" example:"https://repos.example.com/deltas"`   // url for the repo where the static delta is stored
+} // StaticDeltaState
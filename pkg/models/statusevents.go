@@ -0,0 +1,37 @@
+package models
+
+import "fmt"
+
+const (
+	// StatusEventTypeImage is the status event of an image
+	StatusEventTypeImage = "image"
+	// StatusEventTypeCommit is the status event of an image commit
+	StatusEventTypeCommit = "commit"
+	// StatusEventTypeInstaller is the status event of an image installer
+	StatusEventTypeInstaller = "installer"
+	// StatusEventTypeUpdate is the status event of an update transaction
+	StatusEventTypeUpdate = "update"
+	// StatusEventTypeDispatchRecord is the status event of an update device dispatch record
+	StatusEventTypeDispatchRecord = "dispatch_record"
+)
+
+// StatusEvent is the status of an image or update stage pushed to the events stream clients
+type StatusEvent struct {
+	Type       string `json:"type"`
+	ID         uint   `json:"id"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+	DeviceUUID string `json:"device_uuid,omitempty"`
+}
+
+// Key returns the key identifying the stage the status event belongs to
+func (e StatusEvent) Key() string {
+	return fmt.Sprintf("%s:%d", e.Type, e.ID)
+}
+
+// StatusEvents is the current status of all the stages of an image or update
+type StatusEvents struct {
+	Events []StatusEvent
+	// Completed is true when no more status changes are expected
+	Completed bool
+}
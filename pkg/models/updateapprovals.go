@@ -0,0 +1,24 @@
+package models
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UpdateApprovalSettings are the update approval settings of an org. When the org requires approval, its new updates
+// await the approval of a second principal before being built and dispatched.
+type UpdateApprovalSettings struct {
+	Model
+	OrgID           string `json:"org_id" gorm:"uniqueIndex;<-:create"`
+	RequireApproval bool   `json:"require_approval"`
+}
+
+// BeforeCreate method is called before creating update approval settings, it make sure org_id is not empty
+func (s *UpdateApprovalSettings) BeforeCreate(tx *gorm.DB) error {
+	if s.OrgID == "" {
+		log.Error("update-approval-settings do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
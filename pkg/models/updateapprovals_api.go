@@ -0,0 +1,12 @@
+package models
+
+// SetUpdateApprovalSettingsAPI is the update approval settings PUT endpoint struct for openapi.json auto-gen
+type SetUpdateApprovalSettingsAPI struct {
+	RequireApproval bool `json:"require_approval" example:"true"` // whether the updates await the approval of a second principal
+} // SetUpdateApprovalSettings
+
+// UpdateApprovalSettingsAPI is the update approval settings endpoints return struct for openapi.json auto-gen
+type UpdateApprovalSettingsAPI struct {
+	OrgID           string `json:"org_id" example:"2000"`           // orgId that the settings belong to
+	RequireApproval bool   `json:"require_approval" example:"true"` // whether the updates await the approval of a second principal
+} // UpdateApprovalSettings
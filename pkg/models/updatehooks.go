@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UpdateHook is a script defined by an org that runs on its devices before or after an update.
+// A hook belongs to an image set or to a device group, and is injected in the update playbook of their devices.
+type UpdateHook struct {
+	Model
+	OrgID         string `json:"org_id" gorm:"index;<-:create"`
+	Name          string `json:"name"`
+	Stage         string `json:"stage"`
+	Script        string `json:"script"`
+	Position      int    `json:"position"`
+	ImageSetID    *uint  `json:"image_set_id,omitempty" gorm:"index"`
+	DeviceGroupID *uint  `json:"device_group_id,omitempty" gorm:"index"`
+}
+
+const (
+	// UpdateHookStagePre is the stage of the hooks that run before the device update
+	UpdateHookStagePre = "pre"
+	// UpdateHookStagePost is the stage of the hooks that run after the device rebooted on the update
+	UpdateHookStagePost = "post"
+	// UpdateHookScriptMaxSize is the maximum size in bytes of an update hook script
+	UpdateHookScriptMaxSize = 64 * 1024
+
+	// UpdateHookNameEmptyErrorMessage is the error message returned when the update hook name is empty
+	UpdateHookNameEmptyErrorMessage = "hook name cannot be empty"
+	// UpdateHookNameInvalidErrorMessage is the error message returned when the update hook name is invalid
+	UpdateHookNameInvalidErrorMessage = "hook name must start with alphanumeric characters and can contain underscore and hyphen characters"
+	// UpdateHookStageInvalidErrorMessage is the error message returned when the update hook stage is invalid
+	UpdateHookStageInvalidErrorMessage = "hook stage must be \"pre\" or \"post\""
+	// UpdateHookScriptEmptyErrorMessage is the error message returned when the update hook script is empty
+	UpdateHookScriptEmptyErrorMessage = "hook script cannot be empty"
+	// UpdateHookScriptTooLargeErrorMessage is the error message returned when the update hook script is too large
+	UpdateHookScriptTooLargeErrorMessage = "hook script must not be larger than 64KiB"
+	// UpdateHookScriptTemplateErrorMessage is the error message returned when the update hook script has templating delimiters
+	UpdateHookScriptTemplateErrorMessage = "hook script cannot contain the templating delimiters \"{{\", \"{%\" or \"{#\""
+	// UpdateHookPositionInvalidErrorMessage is the error message returned when the update hook position is negative
+	UpdateHookPositionInvalidErrorMessage = "hook position cannot be negative"
+	// UpdateHookOwnerErrorMessage is the error message returned when the update hook does not belong to exactly one image set or device group
+	UpdateHookOwnerErrorMessage = "hook must belong to an image set or to a device group"
+)
+
+var (
+	validUpdateHookName = regexp.MustCompile(`^[A-Za-z0-9]+[A-Za-z0-9\s_-]*$`)
+	// the scripts are rendered in the playbook variables, ansible would evaluate any templating expression
+	updateHookTemplateDelimiters = []string{"{{", "{%", "{#"}
+)
+
+// ValidateRequest validates the UpdateHook request
+func (h *UpdateHook) ValidateRequest() error {
+	if h.Name == "" {
+		return errors.New(UpdateHookNameEmptyErrorMessage)
+	}
+	if !validUpdateHookName.MatchString(h.Name) {
+		return errors.New(UpdateHookNameInvalidErrorMessage)
+	}
+	if h.Stage != UpdateHookStagePre && h.Stage != UpdateHookStagePost {
+		return errors.New(UpdateHookStageInvalidErrorMessage)
+	}
+	if strings.TrimSpace(h.Script) == "" {
+		return errors.New(UpdateHookScriptEmptyErrorMessage)
+	}
+	if len(h.Script) > UpdateHookScriptMaxSize {
+		return errors.New(UpdateHookScriptTooLargeErrorMessage)
+	}
+	for _, delimiter := range updateHookTemplateDelimiters {
+		if strings.Contains(h.Script, delimiter) {
+			return errors.New(UpdateHookScriptTemplateErrorMessage)
+		}
+	}
+	if h.Position < 0 {
+		return errors.New(UpdateHookPositionInvalidErrorMessage)
+	}
+	if (h.ImageSetID == nil) == (h.DeviceGroupID == nil) {
+		return errors.New(UpdateHookOwnerErrorMessage)
+	}
+	return nil
+}
+
+// BeforeCreate method is called before creating an update hook, it make sure org_id is not empty
+func (h *UpdateHook) BeforeCreate(tx *gorm.DB) error {
+	if h.OrgID == "" {
+		log.Error("update-hook do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package models
+
+// CreateUpdateHookAPI is the image set and device group hooks POST endpoint struct for openapi.json auto-gen
+type CreateUpdateHookAPI struct {
+	Name     string `json:"name" example:"drain-workloads"`               // the hook name
+	Stage    string `json:"stage" example:"pre"`                          // the hook stage, "pre" runs before the update, "post" after reboot
+	Script   string `json:"script" example:"podman stop --all --time 30"` // the hook shell script
+	Position int    `json:"position" example:"0"`                         // the hook order among the hooks of the same stage
+} // CreateUpdateHook
+
+// UpdateHookAPI is the image set and device group hooks endpoints return struct for openapi.json auto-gen
+type UpdateHookAPI struct {
+	ID            uint   `json:"ID" example:"1024"`                            // the hook id
+	OrgID         string `json:"org_id" example:"2000"`                        // orgId that the hook belongs to
+	Name          string `json:"name" example:"drain-workloads"`               // the hook name
+	Stage         string `json:"stage" example:"pre"`                          // the hook stage
+	Script        string `json:"script" example:"podman stop --all --time 30"` // the hook shell script
+	Position      int    `json:"position" example:"0"`                         // the hook order among the hooks of the same stage
+	ImageSetID    *uint  `json:"image_set_id,omitempty" example:"1024"`        // the image set the hook belongs to
+	DeviceGroupID *uint  `json:"device_group_id,omitempty" example:"1024"`     // the device group the hook belongs to
+} // UpdateHook
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUpdateHookValidateRequest(t *testing.T) {
+	imageSetID := uint(1)
+	deviceGroupID := uint(2)
+	testScenarios := []struct {
+		name     string
+		hook     *UpdateHook
+		expected error
+	}{
+		{name: "Empty name", hook: &UpdateHook{Stage: UpdateHookStagePre, Script: "true", ImageSetID: &imageSetID}, expected: errors.New(UpdateHookNameEmptyErrorMessage)},
+		{name: "Invalid name", hook: &UpdateHook{Name: "** hook", Stage: UpdateHookStagePre, Script: "true", ImageSetID: &imageSetID}, expected: errors.New(UpdateHookNameInvalidErrorMessage)},
+		{name: "Invalid stage", hook: &UpdateHook{Name: "hook", Stage: "during", Script: "true", ImageSetID: &imageSetID}, expected: errors.New(UpdateHookStageInvalidErrorMessage)},
+		{name: "Empty script", hook: &UpdateHook{Name: "hook", Stage: UpdateHookStagePre, Script: " \n", ImageSetID: &imageSetID}, expected: errors.New(UpdateHookScriptEmptyErrorMessage)},
+		{name: "Too large script", hook: &UpdateHook{Name: "hook", Stage: UpdateHookStagePre, Script: strings.Repeat("a", UpdateHookScriptMaxSize+1), ImageSetID: &imageSetID}, expected: errors.New(UpdateHookScriptTooLargeErrorMessage)},
+		{name: "Script with template expression", hook: &UpdateHook{Name: "hook", Stage: UpdateHookStagePost, Script: "echo {{ lookup('env', 'HOME') }}", ImageSetID: &imageSetID}, expected: errors.New(UpdateHookScriptTemplateErrorMessage)},
+		{name: "Script with template statement", hook: &UpdateHook{Name: "hook", Stage: UpdateHookStagePost, Script: "{% raw %}", ImageSetID: &imageSetID}, expected: errors.New(UpdateHookScriptTemplateErrorMessage)},
+		{name: "Negative position", hook: &UpdateHook{Name: "hook", Stage: UpdateHookStagePre, Script: "true", Position: -1, ImageSetID: &imageSetID}, expected: errors.New(UpdateHookPositionInvalidErrorMessage)},
+		{name: "No owner", hook: &UpdateHook{Name: "hook", Stage: UpdateHookStagePre, Script: "true"}, expected: errors.New(UpdateHookOwnerErrorMessage)},
+		{name: "Two owners", hook: &UpdateHook{Name: "hook", Stage: UpdateHookStagePre, Script: "true", ImageSetID: &imageSetID, DeviceGroupID: &deviceGroupID}, expected: errors.New(UpdateHookOwnerErrorMessage)},
+		{name: "Valid image set hook", hook: &UpdateHook{Name: "drain workloads", Stage: UpdateHookStagePre, Script: "podman stop --all", ImageSetID: &imageSetID}, expected: nil},
+		{name: "Valid device group hook", hook: &UpdateHook{Name: "smoke-test", Stage: UpdateHookStagePost, Script: "curl -f http://localhost/health", DeviceGroupID: &deviceGroupID}, expected: nil},
+	}
+
+	for _, testScenario := range testScenarios {
+		err := testScenario.hook.ValidateRequest()
+		if err == nil && testScenario.expected != nil {
+			t.Errorf("Test %q was supposed to fail but passed successfully", testScenario.name)
+		}
+		if err != nil && testScenario.expected == nil {
+			t.Errorf("Test %q was supposed to pass but failed: %s", testScenario.name, err)
+		}
+		if err != nil && testScenario.expected != nil && err.Error() != testScenario.expected.Error() {
+			t.Errorf("Test %q: expected to fail on %q but got %q", testScenario.name, testScenario.expected, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UpdatePolicy makes the devices of a device group update automatically to the latest successful version of an image set.
+// The update runs as soon as the version succeeds, in the policy maintenance window or after a soak delay.
+type UpdatePolicy struct {
+	Model
+	OrgID         string `json:"org_id" gorm:"index;<-:create"`
+	DeviceGroupID uint   `json:"device_group_id" gorm:"index"`
+	ImageSetID    uint   `json:"image_set_id" gorm:"index"`
+	Schedule      string `json:"schedule"`
+	WindowStart   string `json:"window_start,omitempty"` // the maintenance window start time of day, HH:MM UTC
+	WindowEnd     string `json:"window_end,omitempty"`   // the maintenance window end time of day, HH:MM UTC
+	SoakHours     int    `json:"soak_hours,omitempty"`
+	Principal     string `json:"-"` // the principal that set the policy, the updates are created on its behalf
+}
+
+// UpdatePolicyRun is an automatic update of the devices of a device group to a new image set version
+type UpdatePolicyRun struct {
+	Model
+	OrgID       string    `json:"org_id" gorm:"index;<-:create"`
+	PolicyID    uint      `json:"policy_id" gorm:"index"`
+	ImageID     uint      `json:"image_id"`
+	Status      string    `json:"status" gorm:"index"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+const (
+	// UpdatePolicyScheduleImmediate updates the devices as soon as the image set version succeeds
+	UpdatePolicyScheduleImmediate = "IMMEDIATE"
+	// UpdatePolicyScheduleWindow updates the devices in the next maintenance window of the policy
+	UpdatePolicyScheduleWindow = "WINDOW"
+	// UpdatePolicyScheduleSoak updates the devices once the image set version succeeded for the soak delay
+	UpdatePolicyScheduleSoak = "SOAK"
+
+	// UpdatePolicyRunStatusScheduled is for when the run waits for its schedule
+	UpdatePolicyRunStatusScheduled = "SCHEDULED"
+	// UpdatePolicyRunStatusRunning is for when the run is creating the update transactions
+	UpdatePolicyRunStatusRunning = "RUNNING"
+	// UpdatePolicyRunStatusDone is for when the run created the update transactions
+	UpdatePolicyRunStatusDone = "DONE"
+	// UpdatePolicyRunStatusSuperseded is for when a newer image set version succeeded before the run
+	UpdatePolicyRunStatusSuperseded = "SUPERSEDED"
+	// UpdatePolicyRunStatusError is for when the run failed to create the update transactions
+	UpdatePolicyRunStatusError = "ERROR"
+
+	// UpdatePolicyScheduleInvalidErrorMessage is the error message returned when the update policy schedule is invalid
+	UpdatePolicyScheduleInvalidErrorMessage = "policy schedule must be \"IMMEDIATE\", \"WINDOW\" or \"SOAK\""
+	// UpdatePolicyImageSetEmptyErrorMessage is the error message returned when the update policy image set is not defined
+	UpdatePolicyImageSetEmptyErrorMessage = "policy image set must be defined"
+	// UpdatePolicyWindowInvalidErrorMessage is the error message returned when the update policy maintenance window is invalid
+	UpdatePolicyWindowInvalidErrorMessage = "policy window start and end must be different times of day in the HH:MM format"
+	// UpdatePolicySoakInvalidErrorMessage is the error message returned when the update policy soak delay is invalid
+	UpdatePolicySoakInvalidErrorMessage = "policy soak hours must be positive"
+
+	updatePolicyWindowLayout = "15:04"
+)
+
+// ValidateRequest validates the UpdatePolicy request
+func (p *UpdatePolicy) ValidateRequest() error {
+	if p.ImageSetID == 0 {
+		return errors.New(UpdatePolicyImageSetEmptyErrorMessage)
+	}
+	switch p.Schedule {
+	case UpdatePolicyScheduleImmediate:
+	case UpdatePolicyScheduleWindow:
+		start, errStart := time.Parse(updatePolicyWindowLayout, p.WindowStart)
+		end, errEnd := time.Parse(updatePolicyWindowLayout, p.WindowEnd)
+		if errStart != nil || errEnd != nil || start.Equal(end) {
+			return errors.New(UpdatePolicyWindowInvalidErrorMessage)
+		}
+	case UpdatePolicyScheduleSoak:
+		if p.SoakHours <= 0 {
+			return errors.New(UpdatePolicySoakInvalidErrorMessage)
+		}
+	default:
+		return errors.New(UpdatePolicyScheduleInvalidErrorMessage)
+	}
+	return nil
+}
+
+// NextRun returns when the policy runs the update to a version that succeeded at the given time
+func (p *UpdatePolicy) NextRun(succeededAt time.Time) time.Time {
+	switch p.Schedule {
+	case UpdatePolicyScheduleWindow:
+		return p.NextWindowStart(succeededAt)
+	case UpdatePolicyScheduleSoak:
+		return succeededAt.Add(time.Duration(p.SoakHours) * time.Hour)
+	default:
+		return succeededAt
+	}
+}
+
+// NextWindowStart returns the given time when it is in the maintenance window, otherwise the next start of the window
+func (p *UpdatePolicy) NextWindowStart(t time.Time) time.Time {
+	t = t.UTC()
+	start, errStart := time.Parse(updatePolicyWindowLayout, p.WindowStart)
+	end, errEnd := time.Parse(updatePolicyWindowLayout, p.WindowEnd)
+	if errStart != nil || errEnd != nil {
+		return t
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	startOffset := time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+	endOffset := time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute
+	now := t.Sub(day)
+	if startOffset < endOffset {
+		if now >= startOffset && now < endOffset {
+			return t
+		}
+		if now < startOffset {
+			return day.Add(startOffset)
+		}
+		return day.AddDate(0, 0, 1).Add(startOffset)
+	}
+	// the window spans midnight
+	if now >= startOffset || now < endOffset {
+		return t
+	}
+	return day.Add(startOffset)
+}
+
+// BeforeCreate method is called before creating an update policy, it make sure org_id is not empty
+func (p *UpdatePolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.OrgID == "" {
+		log.Error("update-policy do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
+
+// BeforeCreate method is called before creating an update policy run, it make sure org_id is not empty
+func (r *UpdatePolicyRun) BeforeCreate(tx *gorm.DB) error {
+	if r.OrgID == "" {
+		log.Error("update-policy-run do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package models
+
+// SetUpdatePolicyAPI is the device group update policy PUT endpoint struct for openapi.json auto-gen
+type SetUpdatePolicyAPI struct {
+	ImageSetID  uint   `json:"image_set_id" example:"1024"`            // the image set the devices update to the latest version of
+	Schedule    string `json:"schedule" example:"WINDOW"`              // when the devices update, "IMMEDIATE", "WINDOW" or "SOAK"
+	WindowStart string `json:"window_start,omitempty" example:"22:00"` // the maintenance window start, HH:MM UTC
+	WindowEnd   string `json:"window_end,omitempty" example:"04:00"`   // the maintenance window end, HH:MM UTC
+	SoakHours   int    `json:"soak_hours,omitempty" example:"24"`      // the hours the version must have succeeded for before the update
+} // SetUpdatePolicy
+
+// UpdatePolicyAPI is the device group update policy endpoints return struct for openapi.json auto-gen
+type UpdatePolicyAPI struct {
+	ID            uint   `json:"ID" example:"1024"`                      // the policy id
+	OrgID         string `json:"org_id" example:"2000"`                  // orgId that the policy belongs to
+	DeviceGroupID uint   `json:"device_group_id" example:"1024"`         // the device group the policy updates the devices of
+	ImageSetID    uint   `json:"image_set_id" example:"1024"`            // the image set the devices update to the latest version of
+	Schedule      string `json:"schedule" example:"WINDOW"`              // when the devices update
+	WindowStart   string `json:"window_start,omitempty" example:"22:00"` // the maintenance window start, HH:MM UTC
+	WindowEnd     string `json:"window_end,omitempty" example:"04:00"`   // the maintenance window end, HH:MM UTC
+	SoakHours     int    `json:"soak_hours,omitempty" example:"24"`      // the hours the version must have succeeded for before the update
+} // UpdatePolicy
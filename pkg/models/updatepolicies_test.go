@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpdatePolicyValidateRequest(t *testing.T) {
+	testScenarios := []struct {
+		name     string
+		policy   *UpdatePolicy
+		expected error
+	}{
+		{name: "No image set", policy: &UpdatePolicy{Schedule: UpdatePolicyScheduleImmediate}, expected: errors.New(UpdatePolicyImageSetEmptyErrorMessage)},
+		{name: "Invalid schedule", policy: &UpdatePolicy{ImageSetID: 1, Schedule: "NIGHTLY"}, expected: errors.New(UpdatePolicyScheduleInvalidErrorMessage)},
+		{name: "Window without end", policy: &UpdatePolicy{ImageSetID: 1, Schedule: UpdatePolicyScheduleWindow, WindowStart: "22:00"}, expected: errors.New(UpdatePolicyWindowInvalidErrorMessage)},
+		{name: "Invalid window time", policy: &UpdatePolicy{ImageSetID: 1, Schedule: UpdatePolicyScheduleWindow, WindowStart: "25:00", WindowEnd: "04:00"}, expected: errors.New(UpdatePolicyWindowInvalidErrorMessage)},
+		{name: "Empty window", policy: &UpdatePolicy{ImageSetID: 1, Schedule: UpdatePolicyScheduleWindow, WindowStart: "04:00", WindowEnd: "04:00"}, expected: errors.New(UpdatePolicyWindowInvalidErrorMessage)},
+		{name: "Soak without delay", policy: &UpdatePolicy{ImageSetID: 1, Schedule: UpdatePolicyScheduleSoak}, expected: errors.New(UpdatePolicySoakInvalidErrorMessage)},
+		{name: "Valid immediate policy", policy: &UpdatePolicy{ImageSetID: 1, Schedule: UpdatePolicyScheduleImmediate}, expected: nil},
+		{name: "Valid window policy", policy: &UpdatePolicy{ImageSetID: 1, Schedule: UpdatePolicyScheduleWindow, WindowStart: "22:00", WindowEnd: "04:00"}, expected: nil},
+		{name: "Valid soak policy", policy: &UpdatePolicy{ImageSetID: 1, Schedule: UpdatePolicyScheduleSoak, SoakHours: 24}, expected: nil},
+	}
+
+	for _, testScenario := range testScenarios {
+		err := testScenario.policy.ValidateRequest()
+		if err == nil && testScenario.expected != nil {
+			t.Errorf("Test %q was supposed to fail but passed successfully", testScenario.name)
+		}
+		if err != nil && testScenario.expected == nil {
+			t.Errorf("Test %q was supposed to pass but failed: %s", testScenario.name, err)
+		}
+		if err != nil && testScenario.expected != nil && err.Error() != testScenario.expected.Error() {
+			t.Errorf("Test %q: expected to fail on %q but got %q", testScenario.name, testScenario.expected, err)
+		}
+	}
+}
+
+func TestUpdatePolicyNextRun(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2023, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	dayWindow := &UpdatePolicy{Schedule: UpdatePolicyScheduleWindow, WindowStart: "02:00", WindowEnd: "05:00"}
+	nightWindow := &UpdatePolicy{Schedule: UpdatePolicyScheduleWindow, WindowStart: "22:00", WindowEnd: "04:00"}
+	testScenarios := []struct {
+		name        string
+		policy      *UpdatePolicy
+		succeededAt time.Time
+		expected    time.Time
+	}{
+		{name: "Immediate", policy: &UpdatePolicy{Schedule: UpdatePolicyScheduleImmediate}, succeededAt: at(10, 12, 30), expected: at(10, 12, 30)},
+		{name: "Soak", policy: &UpdatePolicy{Schedule: UpdatePolicyScheduleSoak, SoakHours: 36}, succeededAt: at(10, 12, 30), expected: at(12, 0, 30)},
+		{name: "Before the window", policy: dayWindow, succeededAt: at(10, 1, 0), expected: at(10, 2, 0)},
+		{name: "In the window", policy: dayWindow, succeededAt: at(10, 3, 0), expected: at(10, 3, 0)},
+		{name: "After the window", policy: dayWindow, succeededAt: at(10, 5, 0), expected: at(11, 2, 0)},
+		{name: "Before the night window", policy: nightWindow, succeededAt: at(10, 12, 0), expected: at(10, 22, 0)},
+		{name: "In the night window before midnight", policy: nightWindow, succeededAt: at(10, 23, 0), expected: at(10, 23, 0)},
+		{name: "In the night window after midnight", policy: nightWindow, succeededAt: at(10, 3, 59), expected: at(10, 3, 59)},
+	}
+
+	for _, testScenario := range testScenarios {
+		nextRun := testScenario.policy.NextRun(testScenario.succeededAt)
+		if !nextRun.Equal(testScenario.expected) {
+			t.Errorf("Test %q: expected the next run at %s but got %s", testScenario.name, testScenario.expected, nextRun)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UpdateRollback records a device that booted back into its previous deployment after an update completed,
+// usually because the update deployment failed the greenboot health checks
+type UpdateRollback struct {
+	Model
+	OrgID                     string `json:"org_id" gorm:"index;<-:create"`
+	UpdateTransactionID       uint   `json:"update_id" gorm:"index"`
+	DeviceID                  uint   `json:"device_id" gorm:"index"`
+	DeviceUUID                string `json:"device_uuid"`
+	TargetCommit              string `json:"target_commit"`
+	BootedCommit              string `json:"booted_commit"`
+	GreenbootStatus           string `json:"greenboot_status"`
+	GreenbootFallbackDetected bool   `json:"greenboot_fallback_detected"`
+}
+
+// BeforeCreate method is called before creating an update rollback, it makes sure org_id is not empty
+func (r *UpdateRollback) BeforeCreate(tx *gorm.DB) error {
+	if r.OrgID == "" {
+		log.Error("update rollback do not have an org_id")
+		return ErrOrgIDIsMandatory
+	}
+
+	return nil
+}
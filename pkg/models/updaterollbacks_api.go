@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UpdateRollbackAPI is the update and device rollbacks endpoints return struct for openapi.json auto-gen
+type UpdateRollbackAPI struct {
+	ID                        uint      `json:"ID" example:"1024"`                                                                        // the rollback id
+	CreatedAt                 time.Time `json:"CreatedAt" example:"2023-02-21T09:48:42.371Z"`                                             // the time the rollback was detected
+	OrgID                     string    `json:"org_id" example:"2000"`                                                                    // orgId that the rollback belongs to
+	UpdateTransactionID       uint      `json:"update_id" example:"1026"`                                                                 // the update the device rolled back
+	DeviceID                  uint      `json:"device_id" example:"1096"`                                                                 // the device id
+	DeviceUUID                string    `json:"device_uuid" example:"54880418-b7c2-402e-93e5-287e168de7a6"`                               // the device inventory uuid
+	TargetCommit              string    `json:"target_commit" example:"9bd8dfe9856aa5bb1683e85f123bfe7785d45fbdb6f10372ff2c80e703400999"` // the ostree commit the update deployed
+	BootedCommit              string    `json:"booted_commit" example:"2478a5a4c6f0e8d8b1d6a16a2c5c8a7d5d2e0f0b8f6e1e4b2b7c6f1d0a9c8b7e"` // the ostree commit the device booted instead
+	GreenbootStatus           string    `json:"greenboot_status" example:"red"`                                                           // the greenboot status reported by the device
+	GreenbootFallbackDetected bool      `json:"greenboot_fallback_detected" example:"true"`                                               // whether greenboot reported a fallback boot
+} // UpdateRollback
@@ -30,6 +30,10 @@ type UpdateTransaction struct {
 	Repo            *Repo            `json:"Repo"`
 	ChangesRefs     bool             `gorm:"default:false" json:"ChangesRefs"`
 	DispatchRecords []DispatchRecord `gorm:"many2many:updatetransaction_dispatchrecords;save_association:false" json:"DispatchRecords"`
+	ParentID        *uint            `json:"ParentID,omitempty" gorm:"index"` // the update this update retries the failed devices of
+	RequestedBy     string           `json:"RequestedBy,omitempty"`           // the principal that created the update
+	ReviewedBy      string           `json:"ReviewedBy,omitempty"`            // the principal that approved or rejected the update
+	UpdatePolicyID  *uint            `json:"UpdatePolicyID,omitempty"`        // the device group update policy that created the update
 }
 
 // DispatchRecord represents the combination of a Playbook Dispatcher (https://github.com/RedHatInsights/playbook-dispatcher),
@@ -49,8 +53,10 @@ type DispatchRecord struct {
 type DevicesUpdate struct {
 	CommitID    uint     `json:"CommitID,omitempty"`
 	DevicesUUID []string `json:"DevicesUUID"`
-	// TODO: Implement updates by tag
-	// Tag        string `json:"Tag"`
+	// the inventory tags in the namespace/key=value format, the devices having all the tags are updated
+	Tags []string `json:"Tags,omitempty"`
+	// whether the stale devices are updated, they are excluded by default
+	IncludeStale bool `json:"IncludeStale,omitempty"`
 }
 
 const (
@@ -68,6 +74,12 @@ const (
 	UpdateStatusDeviceDisconnected = "DISCONNECTED"
 	// UpdateStatusDeviceUnresponsive is for when an update is UpdateStatusDeviceUnresponsive
 	UpdateStatusDeviceUnresponsive = "UNRESPONSIVE"
+	// UpdateStatusRolledBack is for when a device rolled back the update to its previous deployment
+	UpdateStatusRolledBack = "ROLLED_BACK"
+	// UpdateStatusAwaitingApproval is for when an update waits for a second person to approve it before it is built and dispatched
+	UpdateStatusAwaitingApproval = "AWAITING_APPROVAL"
+	// UpdateStatusRejected is for when an update awaiting approval was rejected
+	UpdateStatusRejected = "REJECTED"
 	// UpdateStatusStorageCleaned is for when an update-transaction repo content has storage cleaned
 	// this happen when an update-transaction is going to be deleted forever
 	UpdateStatusStorageCleaned = "STORAGE_CLEANED"
@@ -85,6 +97,10 @@ const (
 	DispatchRecordStatusError = "ERROR"
 	// DispatchRecordStatusComplete is for when a playbook dispatcher job is complete
 	DispatchRecordStatusComplete = "COMPLETE"
+	// DispatchRecordStatusUnresponsive is for when a playbook dispatcher job never reported back before the deadline
+	DispatchRecordStatusUnresponsive = UpdateStatusDeviceUnresponsive
+	// DispatchRecordStatusRolledBack is for when the device booted back into its previous deployment after the playbook completed
+	DispatchRecordStatusRolledBack = UpdateStatusRolledBack
 )
 
 const (
@@ -92,6 +108,10 @@ const (
 	UpdateReasonFailure = "The playbook failed to run."
 	// UpdateReasonTimeout is for when the device took more time than expected to update
 	UpdateReasonTimeout = "The service timed out during the last update."
+	// UpdateReasonRolledBack is for when the device rolled back the update, usually after failing its greenboot health checks
+	UpdateReasonRolledBack = "The device rolled back to its previous deployment."
+	// UpdateReasonDeviceFailure is for when a device pulling the update reported it failed to apply it
+	UpdateReasonDeviceFailure = "The device failed to apply the update."
 )
 
 // ValidateRequest validates a Update Record Request
@@ -134,3 +154,46 @@ type DeviceUpdateImagesFilters struct {
 	AllPackages        *int
 	SystemsRunning     *int
 }
+
+const (
+	// UpdatePreviewReasonImageUndefined is for when the device current image is unknown
+	UpdatePreviewReasonImageUndefined = "IMAGE_UNDEFINED"
+	// UpdatePreviewReasonDifferentImageSet is for when the device image does not belong to the target image set
+	UpdatePreviewReasonDifferentImageSet = "DIFFERENT_IMAGE_SET"
+	// UpdatePreviewReasonAlreadyOnTarget is for when the device is already running the target commit
+	UpdatePreviewReasonAlreadyOnTarget = "ALREADY_ON_TARGET"
+	// UpdatePreviewReasonDisconnected is for when the device is disconnected and cannot receive the update
+	UpdatePreviewReasonDisconnected = "DISCONNECTED"
+	// UpdatePreviewReasonStale is for when the device is stale and the stale devices are not included
+	UpdatePreviewReasonStale = "STALE"
+)
+
+// UpdatePreview is the dry-run result of a devices update, nothing is created or dispatched
+type UpdatePreview struct {
+	CommitID              uint                  `json:"CommitID"`
+	ImageID               uint                  `json:"ImageID"`
+	IncludedDevices       []UpdatePreviewDevice `json:"IncludedDevices"`
+	ExcludedDevices       []UpdatePreviewDevice `json:"ExcludedDevices"`
+	Images                []UpdatePreviewImage  `json:"Images"`
+	EstimatedDownloadSize int64                 `json:"EstimatedDownloadSize"`
+}
+
+// UpdatePreviewDevice is a device that would be included or excluded from an update
+type UpdatePreviewDevice struct {
+	UUID    string `json:"UUID"`
+	Name    string `json:"Name"`
+	ImageID uint   `json:"ImageID"`
+	Reason  string `json:"Reason,omitempty"`
+}
+
+// UpdatePreviewImage is the update preview from a distinct device current image to the target image
+type UpdatePreviewImage struct {
+	ImageID               uint        `json:"ImageID"`
+	Version               int         `json:"Version"`
+	OSTreeCommit          string      `json:"OSTreeCommit"`
+	DevicesCount          int         `json:"DevicesCount"`
+	PackageDiff           PackageDiff `json:"PackageDiff"`
+	StaticDeltaStatus     string      `json:"StaticDeltaStatus"`
+	StaticDeltaExists     bool        `json:"StaticDeltaExists"`
+	EstimatedDownloadSize int64       `json:"EstimatedDownloadSize"`
+}
@@ -35,20 +35,28 @@ type UpdateRepoAPI struct {
 
 // UpdateAPI The structure of a device update
 type UpdateAPI struct {
-	ID              uint                      `json:"ID" example:"1026"`           // The unique ID of device update
-	Commit          UpdateCommitAPI           `json:"Commit"`                      // The device Update target commit
-	OldCommits      []UpdateCommitAPI         `json:"OldCommits"`                  // The device alternate commits from current device commit to target commit
-	Devices         []UpdateDeviceAPI         `json:"Devices"`                     // The current devices to update
-	Status          string                    `json:"Status" example:"BUILDING"`   // the current devices update status
-	Repo            *UpdateRepoAPI            `json:"Repo"`                        // The current repository built from this update
-	ChangesRefs     bool                      `json:"ChangesRefs" example:"false"` // Whether this update is changing device ostree ref
-	DispatchRecords []UpdateDispatchRecordAPI `json:"DispatchRecords"`             // The current update dispatcher records
+	ID              uint                      `json:"ID" example:"1026"`                 // The unique ID of device update
+	Commit          UpdateCommitAPI           `json:"Commit"`                            // The device Update target commit
+	OldCommits      []UpdateCommitAPI         `json:"OldCommits"`                        // The device alternate commits from current device commit to target commit
+	Devices         []UpdateDeviceAPI         `json:"Devices"`                           // The current devices to update
+	Status          string                    `json:"Status" example:"BUILDING"`         // the current devices update status
+	Repo            *UpdateRepoAPI            `json:"Repo"`                              // The current repository built from this update
+	ChangesRefs     bool                      `json:"ChangesRefs" example:"false"`       // Whether this update is changing device ostree ref
+	DispatchRecords []UpdateDispatchRecordAPI `json:"DispatchRecords"`                   // The current update dispatcher records
+	ParentID        *uint                     `json:"ParentID,omitempty" example:"1025"` // The unique ID of the update this update retries the failed devices of
+	RequestedBy     string                    `json:"RequestedBy,omitempty"`             // The principal that created the update
+	ReviewedBy      string                    `json:"ReviewedBy,omitempty"`              // The principal that approved or rejected the update
+	UpdatePolicyID  *uint                     `json:"UpdatePolicyID,omitempty"`          // The unique ID of the device group update policy that created the update
 } // @name Update
 
 // DevicesUpdateAPI the structure for creating device updates
 type DevicesUpdateAPI struct {
 	CommitID    uint     `json:"CommitID,omitempty" example:"1026"`                                                               // Optional: The unique ID of the target commit
 	DevicesUUID []string `json:"DevicesUUID" example:"b579a578-1a6f-48d5-8a45-21f2a656a5d4,1abb288d-6d88-4e2d-bdeb-fcc536be58ec"` // List of devices uuids to update
+	// Optional: the inventory tags, the devices having all the tags are updated when DevicesUUID is not supplied
+	Tags []string `json:"Tags,omitempty" example:"site/store=042"`
+	// Optional: whether the stale devices are updated, they are excluded by default
+	IncludeStale bool `json:"IncludeStale,omitempty" example:"false"`
 } // @name DevicesUpdate
 
 // ImageValidationRequestAPI is the structure for validating images for device updates
@@ -95,3 +103,33 @@ type InventoryGroupDevicesUpdateInfoResponseAPI struct {
 	DevicesCount   int      `json:"devices_count" example:"25"`                                                                               // the overall count of all devices that belongs to inventory group
 	DevicesUUID    []string `json:"update_devices_uuids" example:"b579a578-1a6f-48d5-8a45-21f2a656a5d4,1abb288d-6d88-4e2d-bdeb-fcc536be58ec"` // the list of devices uuids that belongs to inventory group that are available to update
 }
+
+// UpdatePreviewDeviceAPI is a device that would be included or excluded from an update
+type UpdatePreviewDeviceAPI struct {
+	UUID    string `json:"UUID" example:"b579a578-1a6f-48d5-8a45-21f2a656a5d4"` // the device inventory uuid
+	Name    string `json:"Name" example:"test-host.example.com"`                // the device inventory name
+	ImageID uint   `json:"ImageID" example:"10778"`                             // the device current image ID
+	Reason  string `json:"Reason,omitempty" example:"DIFFERENT_IMAGE_SET"`      // why the device is excluded, one of IMAGE_UNDEFINED, DIFFERENT_IMAGE_SET, ALREADY_ON_TARGET, DISCONNECTED
+} // @name UpdatePreviewDevice
+
+// UpdatePreviewImageAPI is the update preview from a distinct device current image to the target image
+type UpdatePreviewImageAPI struct {
+	ImageID               uint        `json:"ImageID" example:"10778"`                                                                 // the devices current image ID
+	Version               int         `json:"Version" example:"2"`                                                                     // the devices current image version
+	OSTreeCommit          string      `json:"OSTreeCommit" example:"9bd8dfe9856aa5bb1683e85f123bfe7785d45fbdb6f10372ff2c80e703400999"` // the devices current ostree commit hash
+	DevicesCount          int         `json:"DevicesCount" example:"25"`                                                               // the count of included devices running this image
+	PackageDiff           PackageDiff `json:"PackageDiff"`                                                                             // the packages difference from this image to the target image
+	StaticDeltaStatus     string      `json:"StaticDeltaStatus" example:"NOTFOUND"`                                                    // the static delta state from this image commit to the target commit
+	StaticDeltaExists     bool        `json:"StaticDeltaExists" example:"false"`                                                       // whether a ready static delta exists, when false it would be generated
+	EstimatedDownloadSize int64       `json:"EstimatedDownloadSize" example:"104857600"`                                               // the estimated download size in bytes for one device
+} // @name UpdatePreviewImage
+
+// UpdatePreviewAPI is the dry-run result of a devices update
+type UpdatePreviewAPI struct {
+	CommitID              uint                     `json:"CommitID" example:"1056"`                    // the target commit ID
+	ImageID               uint                     `json:"ImageID" example:"10779"`                    // the target image ID
+	IncludedDevices       []UpdatePreviewDeviceAPI `json:"IncludedDevices"`                            // the devices that would be updated
+	ExcludedDevices       []UpdatePreviewDeviceAPI `json:"ExcludedDevices"`                            // the devices that would not be updated and why
+	Images                []UpdatePreviewImageAPI  `json:"Images"`                                     // the preview per distinct current image of included devices
+	EstimatedDownloadSize int64                    `json:"EstimatedDownloadSize" example:"2621440000"` // the estimated download size in bytes for all included devices
+} // @name UpdatePreview
@@ -15,6 +15,9 @@ import (
 // IdentityTypeUser represent the user identity type
 const IdentityTypeUser = "User"
 
+// IdentityTypeSystem represent the system identity type of the requests authenticated with a certificate
+const IdentityTypeSystem = "System"
+
 // GetOriginalIdentity get the original identity data from context
 func GetOriginalIdentity(ctx context.Context) (string, error) {
 	ident := identity.GetRawIdentity(ctx)
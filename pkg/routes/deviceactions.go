@@ -0,0 +1,410 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MakeDeviceActionsRouter adds support for the actions dispatched to the devices
+func MakeDeviceActionsRouter(sub chi.Router) {
+	sub.With(ValidateQueryParams("device-actions")).With(common.Paginate).Get("/", GetDeviceActions)
+	sub.Post("/", CreateDeviceAction)
+	sub.Get("/playbooks", GetDeviceActionPlaybooks)
+	sub.Post("/playbooks", CreateDeviceActionPlaybook)
+	sub.Delete("/playbooks/{playbookID}", DeleteDeviceActionPlaybook)
+	sub.Route("/{actionID}", func(r chi.Router) {
+		r.Get("/", GetDeviceAction)
+		r.Get("/playbook.yml", GetDeviceActionPlaybook)
+	})
+}
+
+// GetDeviceActions returns the device actions of the org
+// @Summary      Returns the device actions
+// @ID           GetDeviceActions
+// @Description  Returns the actions dispatched to the devices, the latest first, with the run of the action playbook on each device. When the device groups access is restricted, only the actions whose devices all belong to the allowed device groups are returned.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        limit   query  int  false  "field: return number of actions until limit is reached. Default is 30."
+// @Param        offset  query  int  false  "field: return number of actions beginning at the offset."
+// @Success      200 {object} []models.DeviceActionAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The access to the device groups is forbidden."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-actions [get]
+func GetDeviceActions(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, rbac.AccessTypeRead)
+	if err != nil {
+		// logs and response handled by handleDeviceGroupsRbac
+		return
+	}
+	var tx *gorm.DB
+	if allowedGroupsIDs != nil {
+		tx = db.DB.Where(deviceGroupsRbacDeviceActionsFilter(allowedGroupsIDs))
+	}
+	pagination := common.GetPagination(r)
+	actions, err := ctxServices.DeviceActionsService.GetDeviceActions(orgID, pagination.Limit, pagination.Offset, tx)
+	if err != nil {
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed getting device actions")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, actions)
+}
+
+// CreateDeviceAction dispatches an action to devices or to all the devices of a device group
+// @Summary      Runs an action on devices
+// @ID           CreateDeviceAction
+// @Description  Dispatches a signed playbook that reboots the devices, restarts a systemd unit, collects the journal of the current boot, refreshes the subscription or runs a playbook uploaded by the org. The action is sent to devices or to all the devices of a device group and of its descendant groups, as an update of a device group, its run on each device is tracked by a dispatch record. Running an org playbook requires the execute access to the device action playbooks.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        body	body	models.CreateDeviceActionAPI	true	"request body"
+// @Success      200 {object} models.DeviceActionAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The access to the devices or to the playbooks is forbidden."
+// @Failure      404 {object} errors.NotFound "The devices, device group or playbook were not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-actions [post]
+func CreateDeviceAction(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	var request models.DeviceActionRequest
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &request); err != nil {
+		return
+	}
+	if err := request.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	if request.Type == models.DeviceActionTypeRunPlaybook && !validateDeviceActionPlaybooksRbac(w, r, rbac.AccessTypeExecute) {
+		// logs and response handled by validateDeviceActionPlaybooksRbac
+		return
+	}
+	if request.DeviceGroupID != nil {
+		if !validateDeviceGroupsRbac(w, r, rbac.AccessTypeWrite, *request.DeviceGroupID) {
+			// logs and response handled by validateDeviceGroupsRbac
+			return
+		}
+		// the access to the device group does not extend to the devices of its descendants
+		devices, err := ctxServices.DeviceGroupsService.GetDeviceGroupDevices(orgID, *request.DeviceGroupID, true)
+		if err != nil {
+			ctxServices.Log.WithField("error", err.Error()).Error("Error getting the device action devices")
+			respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+			return
+		}
+		devicesUUID := make([]string, 0, len(devices))
+		for _, device := range devices {
+			devicesUUID = append(devicesUUID, device.UUID)
+		}
+		if !validateDevicesDeviceGroupsRbac(w, r, orgID, rbac.AccessTypeWrite, devicesUUID) {
+			// logs and response handled by validateDevicesDeviceGroupsRbac
+			return
+		}
+	} else if !validateDevicesDeviceGroupsRbac(w, r, orgID, rbac.AccessTypeWrite, request.DevicesUUID) {
+		// logs and response handled by validateDevicesDeviceGroupsRbac
+		return
+	}
+	action, err := ctxServices.DeviceActionsService.CreateDeviceAction(orgID, &request)
+	if err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("Error creating device action")
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceNotFoundError, *services.DeviceGroupNotFound, *services.DeviceActionPlaybookNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		case *services.DeviceActionHasNoDevices:
+			apiError = errors.NewBadRequest(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed creating device action")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, action)
+}
+
+// GetDeviceAction returns a device action
+// @Summary      Returns a device action
+// @ID           GetDeviceAction
+// @Description  Returns a device action with the run of its playbook on each device
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        actionID	path	int	true	"Identifier of the device action"
+// @Success      200 {object} models.DeviceActionAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The access to the device action devices is forbidden."
+// @Failure      404 {object} errors.NotFound "device action was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-actions/{actionID} [get]
+func GetDeviceAction(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	action := getDeviceAction(w, r, ctxServices)
+	if action == nil {
+		// logs and response handled by getDeviceAction
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, action)
+}
+
+// GetDeviceActionPlaybook returns the signed playbook of a device action
+// @Summary      Returns the playbook of a device action
+// @ID           GetDeviceActionPlaybook
+// @Description  Returns the signed playbook dispatched to the devices by a device action
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      plain
+// @Param        actionID	path	int	true	"Identifier of the device action"
+// @Success      200 {string} string "the playbook file content of the device action"
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The access to the device action devices is forbidden."
+// @Failure      404 {object} errors.NotFound "device action was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-actions/{actionID}/playbook.yml [get]
+func GetDeviceActionPlaybook(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	action := getDeviceAction(w, r, ctxServices)
+	if action == nil {
+		// logs and response handled by getDeviceAction
+		return
+	}
+	playbook, err := ctxServices.DeviceActionsService.GetDeviceActionPlaybook(action)
+	if err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("Error getting device action playbook")
+		respondWithAPIError(w, ctxServices.Log, errors.NewNotFound("file was not found on the S3 bucket"))
+		return
+	}
+	defer playbook.Close()
+	if _, err := io.Copy(w, playbook); err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("Error reading the device action playbook")
+		respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+		return
+	}
+}
+
+// getDeviceAction returns the device action of the request path, it responds with the error when not found or when
+// the user has no read access to the device groups of all the action devices
+func getDeviceAction(w http.ResponseWriter, r *http.Request, ctxServices *dependencies.EdgeAPIServices) *models.DeviceAction {
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return nil
+	}
+	actionID := readPathID(w, r, ctxServices.Log, "actionID", "device action ID must be a positive integer")
+	if actionID == 0 {
+		return nil
+	}
+	action, err := ctxServices.DeviceActionsService.GetDeviceAction(orgID, actionID)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceActionNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed getting device action")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return nil
+	}
+	allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, rbac.AccessTypeRead)
+	if err != nil {
+		// logs and response handled by handleDeviceGroupsRbac
+		return nil
+	}
+	if allowedGroupsIDs == nil {
+		return action
+	}
+	var allowedCount int64
+	if res := db.DB.Model(&models.DeviceAction{}).Where("device_actions.id = ?", action.ID).
+		Where(deviceGroupsRbacDeviceActionsFilter(allowedGroupsIDs)).Count(&allowedCount); res.Error != nil {
+		ctxServices.Log.WithField("error", res.Error.Error()).Error("failed to get the device action access")
+		respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+		return nil
+	}
+	if allowedCount == 0 {
+		respondWithAPIError(w, ctxServices.Log, errors.NewForbidden("access to the device action devices is forbidden"))
+		return nil
+	}
+	return action
+}
+
+// GetDeviceActionPlaybooks returns the playbooks uploaded by the org
+// @Summary      Returns the device action playbooks
+// @ID           GetDeviceActionPlaybooks
+// @Description  Returns the playbooks uploaded by the org to run on its devices with run_playbook device actions
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} []models.DeviceActionPlaybookAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-actions/playbooks [get]
+func GetDeviceActionPlaybooks(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	playbooks, err := ctxServices.DeviceActionsService.GetPlaybooks(orgID)
+	if err != nil {
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed getting device action playbooks")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, playbooks)
+}
+
+// CreateDeviceActionPlaybook uploads a playbook of the org
+// @Summary      Uploads a device action playbook
+// @ID           CreateDeviceActionPlaybook
+// @Description  Uploads a playbook to run on the org devices with run_playbook device actions. Each play must have vars, the playbook is signed when dispatched.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        body	body	models.CreateDeviceActionPlaybookAPI	true	"request body"
+// @Success      200 {object} models.DeviceActionPlaybookAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The write access to the device action playbooks is forbidden."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-actions/playbooks [post]
+func CreateDeviceActionPlaybook(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	if !validateDeviceActionPlaybooksRbac(w, r, rbac.AccessTypeWrite) {
+		// logs and response handled by validateDeviceActionPlaybooksRbac
+		return
+	}
+	var playbook models.DeviceActionPlaybook
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &playbook); err != nil {
+		return
+	}
+	playbook.ID = 0
+	playbook.OrgID = orgID
+	if err := playbook.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	result, err := ctxServices.DeviceActionsService.CreatePlaybook(orgID, &playbook)
+	if err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("Error creating device action playbook")
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed creating device action playbook")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, result)
+}
+
+// DeleteDeviceActionPlaybook deletes a playbook of the org
+// @Summary      Deletes a device action playbook
+// @ID           DeleteDeviceActionPlaybook
+// @Description  Deletes a playbook of the org, the device actions that ran it are kept
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        playbookID	path	int	true	"Identifier of the device action playbook"
+// @Success      200
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The write access to the device action playbooks is forbidden."
+// @Failure      404 {object} errors.NotFound "device action playbook was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-actions/playbooks/{playbookID} [delete]
+func DeleteDeviceActionPlaybook(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	playbookID := readPathID(w, r, ctxServices.Log, "playbookID", "playbook ID must be a positive integer")
+	if playbookID == 0 {
+		return
+	}
+	if !validateDeviceActionPlaybooksRbac(w, r, rbac.AccessTypeWrite) {
+		// logs and response handled by validateDeviceActionPlaybooksRbac
+		return
+	}
+	if err := ctxServices.DeviceActionsService.DeletePlaybook(orgID, playbookID); err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceActionPlaybookNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed deleting device action playbook")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// validateDeviceActionPlaybooksRbac responds with a forbidden error and returns false when the user has no accessType
+// access to the device action playbooks. Uploading and running org playbooks is not covered by the device groups access.
+func validateDeviceActionPlaybooksRbac(w http.ResponseWriter, r *http.Request, accessType rbac.AccessType) bool {
+	if !feature.DeviceGroupsRbac.IsEnabled() {
+		return true
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	if userIdentity, err := common.GetIdentityFromContext(r.Context()); err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("error occurred when retrieving identity from context")
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest("error retrieving identity"))
+		return false
+	} else if userIdentity.Identity.Type != common.IdentityTypeUser {
+		return true
+	}
+	acl, err := ctxServices.RbacService.GetAccessList(rbac.ApplicationEdge)
+	if err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("error occurred when getting rbac access list")
+		respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+		return false
+	}
+	if !acl.IsAllowed(rbac.ApplicationEdge, rbac.ResourceTypeDeviceActionPlaybooks, accessType) {
+		ctxServices.Log.WithField("accessType", accessType).Info("access to device action playbooks is forbidden")
+		respondWithAPIError(w, ctxServices.Log, errors.NewForbidden("access to device action playbooks is forbidden"))
+		return false
+	}
+	return true
+}
+
+// readPathID returns the positive integer id of a path param, it responds with a bad request and returns 0 otherwise
+func readPathID(w http.ResponseWriter, r *http.Request, logger log.FieldLogger, param string, message string) uint {
+	id, err := strconv.Atoi(chi.URLParam(r, param))
+	if err != nil || id <= 0 {
+		respondWithAPIError(w, logger, errors.NewBadRequest(message))
+		return 0
+	}
+	return uint(id)
+}
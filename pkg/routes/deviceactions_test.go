@@ -0,0 +1,296 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	testHelpers "github.com/redhatinsights/edge-api/internal/testing"
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac"
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac/mock_rbac"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+)
+
+func TestCreateDeviceAction(t *testing.T) {
+	deviceGroupID := uint(2048)
+
+	tt := []struct {
+		name               string
+		request            models.DeviceActionRequest
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should create the device action",
+			request:            models.DeviceActionRequest{Type: models.DeviceActionTypeReboot, DevicesUUID: []string{"device-1"}},
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when the unit is invalid",
+			request:            models.DeviceActionRequest{Type: models.DeviceActionTypeRestartService, Unit: "sshd; reboot", DevicesUUID: []string{"device-1"}},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return not found when the device group does not exist",
+			request:            models.DeviceActionRequest{Type: models.DeviceActionTypeCollectLogs, DeviceGroupID: &deviceGroupID},
+			callService:        true,
+			returnError:        new(services.DeviceGroupNotFound),
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+		{
+			name:               "should return bad request when the device group has no devices",
+			request:            models.DeviceActionRequest{Type: models.DeviceActionTypeCollectLogs, DeviceGroupID: &deviceGroupID},
+			callService:        true,
+			returnError:        new(services.DeviceActionHasNoDevices),
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return internal server error",
+			request:            models.DeviceActionRequest{Type: models.DeviceActionTypeReboot, DevicesUUID: []string{"device-1"}},
+			callService:        true,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		body, err := json.Marshal(te.request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceActionsService := mock_services.NewMockDeviceActionsServiceInterface(ctrl)
+		if te.callService {
+			mockDeviceActionsService.EXPECT().CreateDeviceAction(common.DefaultOrgID, gomock.Any()).DoAndReturn(
+				func(orgID string, request *models.DeviceActionRequest) (*models.DeviceAction, error) {
+					if te.returnError != nil {
+						return nil, te.returnError
+					}
+					return &models.DeviceAction{OrgID: orgID, Type: request.Type, Status: models.DeviceActionStatusRunning}, nil
+				})
+		}
+		mockDeviceGroupsService := mock_services.NewMockDeviceGroupsServiceInterface(ctrl)
+		if te.request.DeviceGroupID != nil {
+			mockDeviceGroupsService.EXPECT().GetDeviceGroupDevices(common.DefaultOrgID, *te.request.DeviceGroupID, true).Return(nil, nil)
+		}
+		ctx := dependencies.ContextWithServices(req.Context(), &dependencies.EdgeAPIServices{
+			DeviceActionsService: mockDeviceActionsService,
+			DeviceGroupsService:  mockDeviceGroupsService,
+			Log:                  log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(CreateDeviceAction)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
+func TestGetDeviceAction(t *testing.T) {
+	tt := []struct {
+		name               string
+		actionID           string
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should return the device action", actionID: "10", callService: true, expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found", actionID: "10", callService: true, returnError: new(services.DeviceActionNotFound), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return bad request when action id is invalid", actionID: "abc", expectedHTTPStatus: http.StatusBadRequest},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceActionsService := mock_services.NewMockDeviceActionsServiceInterface(ctrl)
+		if te.callService {
+			action := &models.DeviceAction{OrgID: common.DefaultOrgID, Type: models.DeviceActionTypeReboot}
+			if te.returnError != nil {
+				action = nil
+			}
+			mockDeviceActionsService.EXPECT().GetDeviceAction(common.DefaultOrgID, uint(10)).Return(action, te.returnError)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("actionID", te.actionID)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceActionsService: mockDeviceActionsService,
+			Log:                  log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(GetDeviceAction)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
+func TestDeleteDeviceActionPlaybook(t *testing.T) {
+	tt := []struct {
+		name               string
+		playbookID         string
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should delete the playbook", playbookID: "10", callService: true, expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found", playbookID: "10", callService: true, returnError: new(services.DeviceActionPlaybookNotFound), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return bad request when playbook id is invalid", playbookID: "-1", expectedHTTPStatus: http.StatusBadRequest},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodDelete, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceActionsService := mock_services.NewMockDeviceActionsServiceInterface(ctrl)
+		if te.callService {
+			mockDeviceActionsService.EXPECT().DeletePlaybook(common.DefaultOrgID, uint(10)).Return(te.returnError)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("playbookID", te.playbookID)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceActionsService: mockDeviceActionsService,
+			Log:                  log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(DeleteDeviceActionPlaybook)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
+func TestDeviceActionPlaybooksRbac(t *testing.T) {
+	defer func() {
+		_ = os.Unsetenv(feature.DeviceGroupsRbac.EnvVar)
+		config.Get().Auth = false
+	}()
+	// enable authentication in config to use the identity type
+	config.Get().Auth = true
+	if err := os.Setenv(feature.DeviceGroupsRbac.EnvVar, "true"); err != nil {
+		t.Fatal(err)
+	}
+	playbookID := uint(10)
+	playbook := models.DeviceActionPlaybook{
+		Name:     "prune-images",
+		Playbook: "- hosts: localhost\n  vars: {}\n  tasks:\n    - name: prune\n      command: podman image prune -f\n",
+	}
+
+	tt := []struct {
+		name               string
+		method             string
+		body               interface{}
+		handler            http.HandlerFunc
+		acl                rbac.AccessList
+		callService        bool
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should not upload a playbook without the playbooks write access",
+			method:             http.MethodPost,
+			body:               playbook,
+			handler:            CreateDeviceActionPlaybook,
+			acl:                rbac.AccessList{{Permission: "edge:device-groups:*"}},
+			expectedHTTPStatus: http.StatusForbidden,
+		},
+		{
+			name:               "should upload a playbook with the playbooks write access",
+			method:             http.MethodPost,
+			body:               playbook,
+			handler:            CreateDeviceActionPlaybook,
+			acl:                rbac.AccessList{{Permission: "edge:device-action-playbooks:write"}},
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should not delete a playbook without the playbooks write access",
+			method:             http.MethodDelete,
+			handler:            DeleteDeviceActionPlaybook,
+			acl:                rbac.AccessList{{Permission: "edge:device-action-playbooks:read"}},
+			expectedHTTPStatus: http.StatusForbidden,
+		},
+		{
+			name:               "should not run a playbook without the playbooks execute access",
+			method:             http.MethodPost,
+			body:               models.DeviceActionRequest{Type: models.DeviceActionTypeRunPlaybook, PlaybookID: &playbookID, DevicesUUID: []string{"device-1"}},
+			handler:            CreateDeviceAction,
+			acl:                rbac.AccessList{{Permission: "edge:device-action-playbooks:write"}},
+			expectedHTTPStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, te := range tt {
+		var body io.Reader
+		if te.body != nil {
+			data, err := json.Marshal(te.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = bytes.NewBuffer(data)
+		}
+		req, err := http.NewRequest(te.method, "/", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRbacClient := mock_rbac.NewMockClientInterface(ctrl)
+		mockRbacClient.EXPECT().GetAccessList(rbac.ApplicationEdge).Return(te.acl, nil)
+		mockDeviceActionsService := mock_services.NewMockDeviceActionsServiceInterface(ctrl)
+		if te.callService {
+			mockDeviceActionsService.EXPECT().CreatePlaybook(common.DefaultOrgID, gomock.Any()).Return(&models.DeviceActionPlaybook{}, nil)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("playbookID", "10")
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = testHelpers.WithCustomIdentityType(ctx, common.DefaultOrgID, common.IdentityTypeUser)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceActionsService: mockDeviceActionsService,
+			RbacService:          mockRbacClient,
+			Log:                  log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		te.handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
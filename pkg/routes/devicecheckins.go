@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+// MakeDeviceRouter adds the routes of the devices requesting with their certificate on the cert API
+func MakeDeviceRouter(sub chi.Router) {
+	sub.Post("/checkin", DeviceCheckIn)
+	sub.Get("/desired-state", GetDeviceDesiredState)
+	sub.Post("/update-result", SetDeviceUpdateResult)
+}
+
+// readDeviceSubscriptionManagerID returns the subscription manager id of the device certificate identity,
+// it responds with a forbidden error and returns an empty string when the request is not from a device
+func readDeviceSubscriptionManagerID(w http.ResponseWriter, r *http.Request) string {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	ident, err := common.GetIdentityFromContext(r.Context())
+	if err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("error occurred when retrieving identity from context")
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest("error retrieving identity"))
+		return ""
+	}
+	if ident.Identity.Type != common.IdentityTypeSystem || ident.Identity.System == nil || ident.Identity.System.CommonName == "" {
+		respondWithAPIError(w, ctxServices.Log, errors.NewForbidden("a device certificate identity is required"))
+		return ""
+	}
+	return ident.Identity.System.CommonName
+}
+
+// DeviceCheckIn updates the device with the state it reports
+// @Summary      Checks in a device
+// @ID           DeviceCheckIn
+// @Description  Reports the booted and staged commits, greenboot status and agent version of the device authenticated with its certificate. The device last seen time, current and available hashes are updated without waiting for the inventory events.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        body	body	models.DeviceCheckInAPI	true	"request body"
+// @Success      200 {object} models.DeviceCheckInResultAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The request is not authenticated with a device certificate."
+// @Failure      404 {object} errors.NotFound "The device was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device/checkin [post]
+func DeviceCheckIn(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	subscriptionManagerID := readDeviceSubscriptionManagerID(w, r)
+	if subscriptionManagerID == "" {
+		// logs and response handled by readDeviceSubscriptionManagerID
+		return
+	}
+	var checkIn models.DeviceCheckIn
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &checkIn); err != nil {
+		return
+	}
+	if err := checkIn.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	result, err := ctxServices.DeviceService.CheckInDevice(orgID, subscriptionManagerID, &checkIn)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceNotFoundError:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed checking in device")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, result)
+}
@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	testHelpers "github.com/redhatinsights/edge-api/internal/testing"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestDeviceCheckIn(t *testing.T) {
+	orgID := faker.UUIDHyphenated()
+	subscriptionManagerID := faker.UUIDHyphenated()
+	checkIn := models.DeviceCheckIn{BootedCommit: faker.UUIDHyphenated(), GreenbootStatus: models.DeviceGreenbootStatusGreen}
+
+	initialAuth := config.Get().Auth
+	defer func() {
+		config.Get().Auth = initialAuth
+	}()
+	// enable authentication in config to use the identity type
+	config.Get().Auth = true
+
+	tt := []struct {
+		name               string
+		identity           func(ctx context.Context) context.Context
+		checkIn            models.DeviceCheckIn
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name: "should check in the device",
+			identity: func(ctx context.Context) context.Context {
+				return testHelpers.WithSystemIdentity(ctx, orgID, subscriptionManagerID)
+			},
+			checkIn:            checkIn,
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name: "should return forbidden when the request is not from a device",
+			identity: func(ctx context.Context) context.Context {
+				return testHelpers.WithCustomIdentity(ctx, orgID)
+			},
+			checkIn:            checkIn,
+			expectedHTTPStatus: http.StatusForbidden,
+		},
+		{
+			name: "should return bad request when the booted commit is missing",
+			identity: func(ctx context.Context) context.Context {
+				return testHelpers.WithSystemIdentity(ctx, orgID, subscriptionManagerID)
+			},
+			checkIn:            models.DeviceCheckIn{GreenbootStatus: models.DeviceGreenbootStatusGreen},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name: "should return bad request when the greenboot status is unknown",
+			identity: func(ctx context.Context) context.Context {
+				return testHelpers.WithSystemIdentity(ctx, orgID, subscriptionManagerID)
+			},
+			checkIn:            models.DeviceCheckIn{BootedCommit: checkIn.BootedCommit, GreenbootStatus: "yellow"},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name: "should return not found when the device does not exist",
+			identity: func(ctx context.Context) context.Context {
+				return testHelpers.WithSystemIdentity(ctx, orgID, subscriptionManagerID)
+			},
+			checkIn:            checkIn,
+			callService:        true,
+			returnError:        new(services.DeviceNotFoundError),
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+		{
+			name: "should return internal server error",
+			identity: func(ctx context.Context) context.Context {
+				return testHelpers.WithSystemIdentity(ctx, orgID, subscriptionManagerID)
+			},
+			checkIn:            checkIn,
+			callService:        true,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		body, err := json.Marshal(te.checkIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "/checkin", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceService := mock_services.NewMockDeviceServiceInterface(ctrl)
+		if te.callService {
+			mockDeviceService.EXPECT().CheckInDevice(orgID, subscriptionManagerID, &te.checkIn).DoAndReturn(
+				func(orgID string, subscriptionManagerID string, checkIn *models.DeviceCheckIn) (*models.DeviceCheckInResult, error) {
+					if te.returnError != nil {
+						return nil, te.returnError
+					}
+					return &models.DeviceCheckInResult{DeviceUUID: faker.UUIDHyphenated()}, nil
+				})
+		}
+		ctx := te.identity(req.Context())
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceService: mockDeviceService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(DeviceCheckIn)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
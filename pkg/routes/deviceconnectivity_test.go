@@ -0,0 +1,155 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestGetDeviceConnectivity(t *testing.T) {
+	deviceUUID := faker.UUIDHyphenated()
+	tt := []struct {
+		name               string
+		query              string
+		callService        bool
+		expectedDays       int
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should return the device connectivity over the default period",
+			callService:        true,
+			expectedDays:       models.DeviceConnectivityDefaultDays,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return the device connectivity over the requested period",
+			query:              "?days=7",
+			callService:        true,
+			expectedDays:       7,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when days is not an integer",
+			query:              "?days=week",
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return bad request when days is out of range",
+			query:              "?days=366",
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return not found when the device does not exist",
+			callService:        true,
+			expectedDays:       models.DeviceConnectivityDefaultDays,
+			returnError:        new(services.DeviceNotFoundError),
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+		{
+			name:               "should return internal server error",
+			callService:        true,
+			expectedDays:       models.DeviceConnectivityDefaultDays,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		t.Run(te.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/"+te.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockDeviceHistoryService := mock_services.NewMockDeviceHistoryServiceInterface(ctrl)
+			if te.callService {
+				mockDeviceHistoryService.EXPECT().GetDeviceConnectivity(common.DefaultOrgID, deviceUUID, te.expectedDays).
+					Return(&models.DeviceConnectivity{DeviceUUID: deviceUUID}, te.returnError)
+			}
+			ctx := dependencies.ContextWithServices(req.Context(), &dependencies.EdgeAPIServices{
+				DeviceHistoryService: mockDeviceHistoryService,
+				Log:                  log.NewEntry(log.StandardLogger()),
+			})
+			ctx = context.WithValue(ctx, deviceContextKey, DeviceContext{DeviceUUID: deviceUUID})
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(GetDeviceConnectivity)
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			if status := rr.Code; status != te.expectedHTTPStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, te.expectedHTTPStatus)
+			}
+		})
+	}
+}
+
+func TestGetDeviceGroupConnectivity(t *testing.T) {
+	deviceGroup := &models.DeviceGroup{Model: models.Model{ID: 1}, OrgID: common.DefaultOrgID, Name: faker.Name()}
+	tt := []struct {
+		name               string
+		query              string
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should return the device group connectivity",
+			query:              "?days=1",
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when days is invalid",
+			query:              "?days=0",
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return internal server error",
+			query:              "?days=1",
+			callService:        true,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		t.Run(te.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/"+te.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockDeviceHistoryService := mock_services.NewMockDeviceHistoryServiceInterface(ctrl)
+			if te.callService {
+				mockDeviceHistoryService.EXPECT().GetDeviceGroupConnectivity(deviceGroup.OrgID, deviceGroup.ID, 1).
+					Return(&models.DeviceGroupConnectivity{DeviceGroupID: deviceGroup.ID}, te.returnError)
+			}
+			ctx := dependencies.ContextWithServices(req.Context(), &dependencies.EdgeAPIServices{
+				DeviceHistoryService: mockDeviceHistoryService,
+				Log:                  log.NewEntry(log.StandardLogger()),
+			})
+			ctx = context.WithValue(ctx, deviceGroupKey, deviceGroup)
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(GetDeviceGroupConnectivity)
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			if status := rr.Code; status != te.expectedHTTPStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, te.expectedHTTPStatus)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac"
 	"github.com/redhatinsights/edge-api/pkg/db"
 	"github.com/redhatinsights/edge-api/pkg/models"
 	"github.com/redhatinsights/edge-api/pkg/services"
@@ -47,6 +48,7 @@ func setContextDeviceGroupDevice(ctx context.Context, deviceGroupDevice *models.
 func MakeDeviceGroupsRouter(sub chi.Router) {
 	sub.With(ValidateAccessPermission).With(ValidateQueryParams("device-groups")).With(ValidateGetAllDeviceGroupsFilterParams).With(common.Paginate).Get("/", GetAllDeviceGroups)
 	sub.With(ValidateAccessPermission).Post("/", CreateDeviceGroup)
+	sub.With(ValidateAccessPermission).Post("/move", MoveDeviceGroupsDevices)
 	sub.Get("/checkName/{name}", CheckGroupName)
 	sub.Get("/enforce-edge-groups", GetEnforceEdgeGroups)
 	sub.Route("/{ID}", func(r chi.Router) {
@@ -57,6 +59,20 @@ func MakeDeviceGroupsRouter(sub chi.Router) {
 		r.Delete("/", DeleteDeviceGroupByID)
 		r.Post("/devices", AddDeviceGroupDevices)
 		r.Delete("/devices", DeleteDeviceGroupManyDevices)
+		r.Get("/hooks", GetDeviceGroupUpdateHooks)
+		r.Post("/hooks", CreateDeviceGroupUpdateHook)
+		r.Delete("/hooks/{hookID}", DeleteDeviceGroupUpdateHook)
+		r.Get("/update-policy", GetDeviceGroupUpdatePolicy)
+		r.Put("/update-policy", SetDeviceGroupUpdatePolicy)
+		r.Delete("/update-policy", DeleteDeviceGroupUpdatePolicy)
+		r.Get("/desired-state", GetDeviceGroupDesiredState)
+		r.Put("/desired-state", SetDeviceGroupDesiredState)
+		r.Delete("/desired-state", DeleteDeviceGroupDesiredState)
+		r.Get("/drift", GetDeviceGroupDrift)
+		r.Post("/reconcile", ReconcileDeviceGroup)
+		r.Put("/hierarchy", SetDeviceGroupHierarchy)
+		r.Get("/descendants", GetDeviceGroupDescendants)
+		r.Get("/connectivity", GetDeviceGroupConnectivity)
 		r.Route("/details", func(d chi.Router) {
 			d.Use(DeviceGroupDetailsCtx)
 			d.Get("/", GetDeviceGroupDetailsByID)
@@ -143,7 +159,7 @@ func DeviceGroupCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctxServices := dependencies.ServicesFromContext(r.Context())
 		if ID := chi.URLParam(r, "ID"); ID != "" {
-			_, err := strconv.Atoi(ID)
+			groupID, err := strconv.Atoi(ID)
 			ctxServices.Log = ctxServices.Log.WithField("deviceGroupID", ID)
 			ctxServices.Log.Debug("Retrieving device group")
 			if err != nil {
@@ -151,6 +167,10 @@ func DeviceGroupCtx(next http.Handler) http.Handler {
 				respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
 				return
 			}
+			if !validateDeviceGroupsRbac(w, r, deviceGroupsRbacAccessType(r), uint(groupID)) {
+				// logs and response handled by validateDeviceGroupsRbac
+				return
+			}
 
 			deviceGroup, err := ctxServices.DeviceGroupsService.GetDeviceGroupByID(ID)
 			if err != nil {
@@ -313,6 +333,14 @@ func GetAllDeviceGroups(w http.ResponseWriter, r *http.Request) {
 		// logs and response handled by readOrgID
 		return
 	}
+	allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, rbac.AccessTypeRead)
+	if err != nil {
+		// logs and response handled by handleDeviceGroupsRbac
+		return
+	}
+	if allowedGroupsIDs != nil {
+		tx = tx.Where("device_groups.id IN (?)", allowedGroupsIDs)
+	}
 
 	pagination := common.GetPagination(r)
 
@@ -354,6 +382,16 @@ func CreateDeviceGroup(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return
 		}
+		allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, rbac.AccessTypeWrite)
+		if err != nil {
+			// logs and response handled by handleDeviceGroupsRbac
+			return
+		}
+		// a user restricted to some device groups can only create device groups nested in them
+		if allowedGroupsIDs != nil && (deviceGroup.ParentID == nil || !containsInt(allowedGroupsIDs, *deviceGroup.ParentID)) {
+			respondWithAPIError(w, ctxServices.Log, errors.NewForbidden("access to parent device group is forbidden"))
+			return
+		}
 		ctxServices.Log.Debug("Creating a device group")
 
 		deviceGroup, err = ctxServices.DeviceGroupsService.CreateDeviceGroup(deviceGroup)
@@ -363,6 +401,8 @@ func CreateDeviceGroup(w http.ResponseWriter, r *http.Request) {
 			switch err.(type) {
 			case *services.DeviceGroupAlreadyExists:
 				apiError = errors.NewBadRequest(err.Error())
+			case *services.DeviceGroupNotFound:
+				apiError = errors.NewNotFound("parent device group not found")
 			default:
 				apiError := errors.NewInternalServerError()
 				apiError.SetTitle("failed updating device group")
@@ -419,13 +459,17 @@ func GetDeviceGroupDetailsByIDView(w http.ResponseWriter, r *http.Request) {
 	var deviceGroupDetails models.DeviceGroupDetailsView
 	deviceGroupDetails.DeviceGroup = deviceGroup
 	deviceGroupDetails.DeviceDetails.EnforceEdgeGroups = utility.EnforceEdgeGroups(orgID)
-	if int(len(deviceGroup.Devices)) == 0 {
+	devices, ok := getDeviceGroupDevices(w, r, deviceGroup)
+	if !ok {
+		return
+	}
+	if int(len(devices)) == 0 {
 		respondWithJSONBody(w, ctxServices.Log, &deviceGroupDetails)
 		return
 	}
 
-	devicesIDS := make([]uint, 0, len(deviceGroup.Devices))
-	for _, device := range deviceGroup.Devices {
+	devicesIDS := make([]uint, 0, len(devices))
+	for _, device := range devices {
 		devicesIDS = append(devicesIDS, device.ID)
 	}
 
@@ -467,6 +511,7 @@ func GetDeviceGroupDetailsByIDView(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param		 required_param query int false "device group ID"
+// @Param        include_descendants query bool false "whether the devices of the descendant groups are included"
 // @Success      200 {object} models.DeviceGroup
 // @Failure      400 {object} errors.BadRequest
 // @Failure      500 {object} errors.InternalServerError
@@ -474,10 +519,174 @@ func GetDeviceGroupDetailsByIDView(w http.ResponseWriter, r *http.Request) {
 func GetDeviceGroupByID(w http.ResponseWriter, r *http.Request) {
 	if deviceGroup := getContextDeviceGroup(w, r); deviceGroup != nil {
 		ctxServices := dependencies.ServicesFromContext(r.Context())
+		if r.URL.Query().Get("include_descendants") == "true" {
+			devices, ok := getDeviceGroupDevices(w, r, deviceGroup)
+			if !ok {
+				return
+			}
+			deviceGroup.Devices = devices
+		}
 		respondWithJSONBody(w, ctxServices.Log, deviceGroup)
 	}
 }
 
+// getDeviceGroupDevices returns the devices of a device group and of its descendant groups. The access to the device
+// group does not extend to the devices of its descendants, the user must have access to a device group of each device
+func getDeviceGroupDevices(w http.ResponseWriter, r *http.Request, deviceGroup *models.DeviceGroup) ([]models.Device, bool) {
+	if !deviceGroup.HasChildren {
+		return deviceGroup.Devices, true
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	devices, err := ctxServices.DeviceGroupsService.GetDeviceGroupDevices(deviceGroup.OrgID, deviceGroup.ID, true)
+	if err != nil {
+		ctxServices.Log.WithFields(log.Fields{"error": err.Error(), "device_group_id": deviceGroup.ID}).
+			Error("Error getting the devices of the device group descendants")
+		respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+		return nil, false
+	}
+	devicesUUID := make([]string, 0, len(devices))
+	for _, device := range devices {
+		devicesUUID = append(devicesUUID, device.UUID)
+	}
+	if !validateDevicesDeviceGroupsRbac(w, r, deviceGroup.OrgID, deviceGroupsRbacAccessType(r), devicesUUID) {
+		// logs and response handled by validateDevicesDeviceGroupsRbac
+		return nil, false
+	}
+	return devices, true
+}
+
+// SetDeviceGroupHierarchy Nests a device group under a parent group
+// @Summary      Nests a device group under a parent group
+// @Description  Nests a device group under a parent group, or makes it a root group when the parent is null. The parent can not be the group itself or one of its descendants. Nesting a group requires write access to the parent group, cascading the group access requires write access to all the group descendants.
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID   path int                            true "device group ID"
+// @Param        body body models.DeviceGroupHierarchyAPI true "request body"
+// @Success      200 {object} models.DeviceGroup
+// @Failure      400 {object} errors.BadRequest
+// @Failure      403 {object} errors.Forbidden
+// @Failure      404 {object} errors.NotFound
+// @Failure      500 {object} errors.InternalServerError
+// @Router       /device-groups/{ID}/hierarchy [put]
+func SetDeviceGroupHierarchy(w http.ResponseWriter, r *http.Request) {
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	ctxLog := ctxServices.Log.WithField("device_group_id", deviceGroup.ID)
+	var hierarchy models.DeviceGroupHierarchyAPI
+	if err := readRequestJSONBody(w, r, ctxLog, &hierarchy); err != nil {
+		return
+	}
+	// the access to the parent group and, when cascading, to the group extends to the group descendants
+	var extendedGroupsIDs []uint
+	if hierarchy.ParentID != nil {
+		extendedGroupsIDs = append(extendedGroupsIDs, *hierarchy.ParentID)
+	}
+	if hierarchy.CascadeRBAC {
+		descendantsIDs, err := ctxServices.DeviceGroupsService.GetDeviceGroupDescendantsIDs(deviceGroup.OrgID, deviceGroup.ID)
+		if err != nil {
+			ctxLog.WithField("error", err.Error()).Error("Error getting device group descendants")
+			respondWithAPIError(w, ctxLog, errors.NewInternalServerError())
+			return
+		}
+		extendedGroupsIDs = append(extendedGroupsIDs, descendantsIDs...)
+	}
+	if len(extendedGroupsIDs) > 0 && !validateDeviceGroupsRbac(w, r, rbac.AccessTypeWrite, extendedGroupsIDs...) {
+		// logs and response handled by validateDeviceGroupsRbac
+		return
+	}
+	updatedDeviceGroup, err := ctxServices.DeviceGroupsService.SetDeviceGroupHierarchy(
+		deviceGroup.OrgID, deviceGroup.ID, hierarchy.ParentID, hierarchy.CascadeRBAC)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceGroupHierarchyCycle:
+			apiError = errors.NewBadRequest(err.Error())
+		case *services.DeviceGroupNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			ctxLog.WithField("error", err.Error()).Error("Error setting device group hierarchy")
+			apiError = errors.NewInternalServerError()
+		}
+		respondWithAPIError(w, ctxLog, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxLog, updatedDeviceGroup)
+}
+
+// GetDeviceGroupDescendants Returns the descendant groups of a device group
+// @Summary      Returns the descendant groups of a device group
+// @Description  Returns the children of a device group, their children and so on
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID path int true "device group ID"
+// @Success      200 {object} []models.DeviceGroup
+// @Failure      400 {object} errors.BadRequest
+// @Failure      500 {object} errors.InternalServerError
+// @Router       /device-groups/{ID}/descendants [get]
+func GetDeviceGroupDescendants(w http.ResponseWriter, r *http.Request) {
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	ctxLog := ctxServices.Log.WithField("device_group_id", deviceGroup.ID)
+	descendantsIDs, err := ctxServices.DeviceGroupsService.GetDeviceGroupDescendantsIDs(deviceGroup.OrgID, deviceGroup.ID)
+	if err != nil {
+		ctxLog.WithField("error", err.Error()).Error("Error getting device group descendants")
+		respondWithAPIError(w, ctxLog, errors.NewInternalServerError())
+		return
+	}
+	descendants := []models.DeviceGroup{}
+	if len(descendantsIDs) > 0 {
+		if res := db.Org(deviceGroup.OrgID, "").Where("id IN (?)", descendantsIDs).Order("id").Find(&descendants); res.Error != nil {
+			ctxLog.WithField("error", res.Error.Error()).Error("Error getting device group descendants")
+			respondWithAPIError(w, ctxLog, errors.NewInternalServerError())
+			return
+		}
+	}
+	respondWithJSONBody(w, ctxLog, descendants)
+}
+
+// GetDeviceGroupConnectivity Returns the rhc connectivity of the devices of a device group
+// @Summary      Returns the connectivity of the devices of a device group
+// @Description  Returns the connected and disconnected devices count of the device group, and the time and percentage each device was connected over the last days, computed from their connectivity history
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID    path   int  true   "device group ID"
+// @Param        days  query  int  false  "field: the number of days of the period, between 1 and 365. Default is 30."
+// @Success      200 {object} models.DeviceGroupConnectivity
+// @Failure      400 {object} errors.BadRequest
+// @Failure      500 {object} errors.InternalServerError
+// @Router       /device-groups/{ID}/connectivity [get]
+func GetDeviceGroupConnectivity(w http.ResponseWriter, r *http.Request) {
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	ctxLog := ctxServices.Log.WithField("device_group_id", deviceGroup.ID)
+	days, err := readConnectivityDays(w, r, ctxLog)
+	if err != nil {
+		// logs and response handled by readConnectivityDays
+		return
+	}
+	connectivity, err := ctxServices.DeviceHistoryService.GetDeviceGroupConnectivity(deviceGroup.OrgID, deviceGroup.ID, days)
+	if err != nil {
+		ctxLog.WithField("error", err.Error()).Error("Error getting device group connectivity")
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed to get device group connectivity")
+		respondWithAPIError(w, ctxLog, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxLog, connectivity)
+}
+
 func getContextDeviceGroupDetails(w http.ResponseWriter, r *http.Request) *models.DeviceGroupDetails {
 	ctx := r.Context()
 	deviceGroupDetails, ok := ctx.Value(deviceGroupKey).(*models.DeviceGroupDetails)
@@ -594,6 +803,55 @@ func DeleteDeviceGroupByID(w http.ResponseWriter, r *http.Request) {
 	respondWithJSONBody(w, ctxLog, map[string]interface{}{"message": "Device group deleted"})
 }
 
+// MoveDeviceGroupsDevices moves devices from a device group to another
+// @Summary      Moves devices from a device group to another
+// @Description  Moves devices from a device group to another in a single transaction, the devices move to the inventory group of the target group as well when the inventory groups are enabled. Nothing is moved when an error occurs. The outcome of each device is returned, the devices not found in the source group are not moved.
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        body body models.DeviceGroupsMove true "request body"
+// @Success      200 {object} models.DeviceGroupsMoveResult
+// @Failure      400 {object} errors.BadRequest
+// @Failure      404 {object} errors.NotFound
+// @Failure      500 {object} errors.InternalServerError
+// @Router       /device-groups/move [post]
+func MoveDeviceGroupsDevices(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	var move models.DeviceGroupsMove
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &move); err != nil {
+		return
+	}
+	if err := move.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	if !validateDeviceGroupsRbac(w, r, rbac.AccessTypeWrite, move.FromGroupID, move.ToGroupID) {
+		// logs and response handled by validateDeviceGroupsRbac
+		return
+	}
+	result, err := ctxServices.DeviceGroupsService.MoveDeviceGroupsDevices(orgID, &move)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceGroupNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		case *services.DeviceGroupDynamicMembership:
+			apiError = errors.NewBadRequest(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed moving devices, no device was moved")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, result)
+}
+
 // createDeviceRequest validates request to create Device Group.
 func createDeviceRequest(w http.ResponseWriter, r *http.Request) (*models.DeviceGroup, error) {
 	ctxServices := dependencies.ServicesFromContext(r.Context())
@@ -669,7 +927,8 @@ func AddDeviceGroupDevices(w http.ResponseWriter, r *http.Request) {
 		ctxServices.Log.WithField("error", err.Error()).Error("Error when adding deviceGroup devices")
 		var apiError errors.APIError
 		switch err.(type) {
-		case *services.DeviceGroupDevicesNotSupplied, *services.DeviceGroupMandatoryFieldsUndefined:
+		case *services.DeviceGroupDevicesNotSupplied, *services.DeviceGroupMandatoryFieldsUndefined,
+			*services.DeviceGroupDynamicMembership:
 			apiError = errors.NewBadRequest(err.Error())
 		case *services.DeviceGroupOrgIDDevicesNotFound:
 			apiError = errors.NewNotFound(err.Error())
@@ -716,7 +975,8 @@ func DeleteDeviceGroupManyDevices(w http.ResponseWriter, r *http.Request) {
 		ctxServices.Log.WithField("error", err.Error()).Error("Error when removing deviceGroup devices")
 		var apiError errors.APIError
 		switch err.(type) {
-		case *services.DeviceGroupDevicesNotSupplied, *services.DeviceGroupMandatoryFieldsUndefined:
+		case *services.DeviceGroupDevicesNotSupplied, *services.DeviceGroupMandatoryFieldsUndefined,
+			*services.DeviceGroupDynamicMembership:
 			apiError = errors.NewBadRequest(err.Error())
 		case *services.DeviceGroupDevicesNotFound:
 			apiError = errors.NewNotFound(err.Error())
@@ -765,7 +1025,8 @@ func DeleteDeviceGroupOneDevice(w http.ResponseWriter, r *http.Request) {
 		ctxServices.Log.WithField("error", err.Error()).Error("Error when removing deviceGroup devices")
 		var apiError errors.APIError
 		switch err.(type) {
-		case *services.DeviceGroupDevicesNotSupplied, *services.DeviceGroupMandatoryFieldsUndefined:
+		case *services.DeviceGroupDevicesNotSupplied, *services.DeviceGroupMandatoryFieldsUndefined,
+			*services.DeviceGroupDynamicMembership:
 			apiError = errors.NewBadRequest(err.Error())
 		case *services.DeviceGroupDevicesNotFound:
 			apiError = errors.NewNotFound(err.Error())
@@ -832,7 +1093,15 @@ func UpdateAllDevicesFromGroup(w http.ResponseWriter, r *http.Request) {
 	var setOfImageSetID []uint
 	var setOfDeviceUUIDS []string
 
-	for _, d := range deviceGroup.Devices {
+	devices, ok := getDeviceGroupDevices(w, r, deviceGroup)
+	if !ok {
+		return
+	}
+	for _, d := range devices {
+		// the stale devices are excluded from the group update
+		if d.Staleness == models.DeviceStalenessStale {
+			continue
+		}
 		var img models.Image
 		err := db.DBx(r.Context()).Joins("Images").Find(&img,
 			"id = ?", d.ImageID)
@@ -848,6 +1117,10 @@ func UpdateAllDevicesFromGroup(w http.ResponseWriter, r *http.Request) {
 		setOfDeviceUUIDS = append(setOfDeviceUUIDS, d.UUID)
 
 	}
+	if len(setOfDeviceUUIDS) == 0 && len(devices) > 0 {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest("all the device group devices are stale"))
+		return
+	}
 
 	var devicesUpdate models.DevicesUpdate
 	devicesUpdate.DevicesUUID = setOfDeviceUUIDS
@@ -894,8 +1167,11 @@ func UpdateAllDevicesFromGroup(w http.ResponseWriter, r *http.Request) {
 	for _, update := range *updates {
 		update.OrgID = orgID
 		upd = append(upd, update)
-		ctxServices.Log.WithField("updateID", update.ID).Debug("Starting asynchronous update process")
-		ctxServices.UpdateService.CreateUpdateAsync(update.ID)
+		// updates awaiting approval are started once approved
+		if update.Status != models.UpdateStatusAwaitingApproval {
+			ctxServices.Log.WithField("updateID", update.ID).Debug("Starting asynchronous update process")
+			ctxServices.UpdateService.CreateUpdateAsync(update.ID)
+		}
 	}
 	if len(upd) == 0 {
 		respondWithAPIError(w, ctxServices.Log, errors.NewNotFound("devices not found"))
@@ -288,6 +288,98 @@ var _ = Describe("DeviceGroup routes", func() {
 				Expect(rr.Code).To(Equal(http.StatusOK))
 			})
 		})
+		When("the device group is dynamic", func() {
+			It("should return bad request", func() {
+				req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonDeviceBytes))
+				Expect(err).To(BeNil())
+				ctx := setContextDeviceGroup(req.Context(), &deviceGroup)
+				ctx = dependencies.ContextWithServices(ctx, edgeAPIServices)
+				rr := httptest.NewRecorder()
+
+				mockDeviceGroupsService.EXPECT().AddDeviceGroupDevices(orgID, deviceGroup.ID, gomock.Any()).
+					Return(nil, new(services.DeviceGroupDynamicMembership))
+
+				handler := http.HandlerFunc(AddDeviceGroupDevices)
+				handler.ServeHTTP(rr, req.WithContext(ctx))
+				Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+	Context("move devices between DeviceGroups", func() {
+		moveDevices := func(move models.DeviceGroupsMove) *httptest.ResponseRecorder {
+			body, err := json.Marshal(move)
+			Expect(err).To(BeNil())
+			req, err := http.NewRequest(http.MethodPost, "/move", bytes.NewBuffer(body))
+			Expect(err).To(BeNil())
+			ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(MoveDeviceGroupsDevices).ServeHTTP(rr, req.WithContext(ctx))
+			return rr
+		}
+		move := models.DeviceGroupsMove{FromGroupID: 1, ToGroupID: 2, DevicesUUID: []string{faker.UUIDHyphenated()}}
+
+		It("should move the devices", func() {
+			mockDeviceGroupsService.EXPECT().MoveDeviceGroupsDevices(common.DefaultOrgID, &move).Return(&models.DeviceGroupsMoveResult{
+				Moved: 1, Devices: []models.DeviceMoveResult{{DeviceUUID: move.DevicesUUID[0], Status: models.DeviceMoveStatusMoved}},
+			}, nil)
+			rr := moveDevices(move)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			var result models.DeviceGroupsMoveResult
+			Expect(json.NewDecoder(rr.Body).Decode(&result)).To(Succeed())
+			Expect(result.Moved).To(Equal(1))
+		})
+		It("should return bad request when the source and target groups are the same", func() {
+			rr := moveDevices(models.DeviceGroupsMove{FromGroupID: 1, ToGroupID: 1, DevicesUUID: move.DevicesUUID})
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("should return not found when a group does not exist", func() {
+			mockDeviceGroupsService.EXPECT().MoveDeviceGroupsDevices(common.DefaultOrgID, &move).Return(nil, new(services.DeviceGroupNotFound))
+			rr := moveDevices(move)
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+		It("should return internal server error when the move is rolled back", func() {
+			mockDeviceGroupsService.EXPECT().MoveDeviceGroupsDevices(common.DefaultOrgID, &move).Return(nil, errors.New("inventory is unavailable"))
+			rr := moveDevices(move)
+			Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+	Context("set DeviceGroup hierarchy", func() {
+		orgID := faker.UUIDHyphenated()
+		deviceGroup := models.DeviceGroup{Model: models.Model{ID: 2}, OrgID: orgID, Name: faker.UUIDHyphenated()}
+		parentID := uint(1)
+
+		setHierarchy := func(serviceErr error) *httptest.ResponseRecorder {
+			body, err := json.Marshal(models.DeviceGroupHierarchyAPI{ParentID: &parentID, CascadeRBAC: true})
+			Expect(err).To(BeNil())
+			req, err := http.NewRequest(http.MethodPut, "/hierarchy", bytes.NewBuffer(body))
+			Expect(err).To(BeNil())
+			ctx := setContextDeviceGroup(req.Context(), &deviceGroup)
+			ctx = dependencies.ContextWithServices(ctx, edgeAPIServices)
+			rr := httptest.NewRecorder()
+			nestedGroup := deviceGroup
+			nestedGroup.ParentID = &parentID
+			mockDeviceGroupsService.EXPECT().GetDeviceGroupDescendantsIDs(orgID, deviceGroup.ID).Return([]uint{3}, nil)
+			mockDeviceGroupsService.EXPECT().SetDeviceGroupHierarchy(orgID, deviceGroup.ID, &parentID, true).
+				Return(&nestedGroup, serviceErr)
+			http.HandlerFunc(SetDeviceGroupHierarchy).ServeHTTP(rr, req.WithContext(ctx))
+			return rr
+		}
+
+		It("should nest the device group under its parent", func() {
+			rr := setHierarchy(nil)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			var responseGroup models.DeviceGroup
+			Expect(json.NewDecoder(rr.Body).Decode(&responseGroup)).To(Succeed())
+			Expect(*responseGroup.ParentID).To(Equal(parentID))
+		})
+		It("should return bad request when the parent is a descendant of the group", func() {
+			rr := setHierarchy(new(services.DeviceGroupHierarchyCycle))
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("should return not found when the parent does not exist", func() {
+			rr := setHierarchy(new(services.DeviceGroupNotFound))
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
 	})
 	Context("create DeviceGroup", func() {
 		When("all is valid", func() {
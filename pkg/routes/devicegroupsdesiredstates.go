@@ -0,0 +1,167 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetDeviceGroupDesiredState returns the desired state of a device group
+// @Summary      Returns the desired state of a device group
+// @ID           GetDeviceGroupDesiredState
+// @Description  Returns the image set version the device group devices are pinned to
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Success      200 {object} models.DeviceGroupDesiredStateAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group desired state was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/desired-state [get]
+func GetDeviceGroupDesiredState(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	state, err := ctxServices.DesiredStateService.GetDeviceGroupDesiredState(deviceGroup.OrgID, deviceGroup.ID)
+	if err != nil {
+		respondDesiredStateError(w, ctxServices.Log, err, "failed getting device group desired state")
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, state)
+}
+
+// SetDeviceGroupDesiredState pins the devices of a device group to an image set version
+// @Summary      Pins the devices of a device group to an image set version
+// @ID           SetDeviceGroupDesiredState
+// @Description  Pins the device group devices to a successful version of an image set, the devices not running it drift from the desired state
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Param        body	body	models.SetDeviceGroupDesiredStateAPI	true	"request body"
+// @Success      200 {object} models.DeviceGroupDesiredStateAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "image-set or image-set version was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/desired-state [put]
+func SetDeviceGroupDesiredState(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	var state models.DeviceGroupDesiredState
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &state); err != nil {
+		return
+	}
+	if err := state.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	result, err := ctxServices.DesiredStateService.SetDeviceGroupDesiredState(deviceGroup.OrgID, deviceGroup.ID, &state)
+	if err != nil {
+		respondDesiredStateError(w, ctxServices.Log, err, "failed setting device group desired state")
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, result)
+}
+
+// DeleteDeviceGroupDesiredState unpins the devices of a device group
+// @Summary      Unpins the devices of a device group
+// @ID           DeleteDeviceGroupDesiredState
+// @Description  Deletes the desired state of a device group
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Success      200
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group desired state was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/desired-state [delete]
+func DeleteDeviceGroupDesiredState(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	if err := ctxServices.DesiredStateService.DeleteDeviceGroupDesiredState(deviceGroup.OrgID, deviceGroup.ID); err != nil {
+		respondDesiredStateError(w, ctxServices.Log, err, "failed deleting device group desired state")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetDeviceGroupDrift returns the drift of the devices of a device group from its desired state
+// @Summary      Returns the drift of the devices of a device group from its desired state
+// @ID           GetDeviceGroupDrift
+// @Description  Lists the device group devices with their drift status, IN_SYNC when running the desired commit, DRIFTING when running an older version or another image, AHEAD when running a newer version and UNKNOWN when running an unknown image
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Success      200 {object} models.DeviceGroupDrift
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group desired state was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/drift [get]
+func GetDeviceGroupDrift(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	drift, err := ctxServices.DesiredStateService.GetDeviceGroupDrift(deviceGroup.OrgID, deviceGroup.ID)
+	if err != nil {
+		respondDesiredStateError(w, ctxServices.Log, err, "failed getting device group drift")
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, drift)
+}
+
+// ReconcileDeviceGroup updates the drifting devices of a device group to its desired state
+// @Summary      Updates the drifting devices of a device group to its desired state
+// @ID           ReconcileDeviceGroup
+// @Description  Creates the update transactions converging the drifting devices to the desired state, the devices ahead of it, running an unknown image or running another image set are not updated and stay drifting
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Success      200 {object} []models.UpdateTransaction
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group desired state was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/reconcile [post]
+func ReconcileDeviceGroup(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	updates, err := ctxServices.DesiredStateService.ReconcileDeviceGroup(deviceGroup.OrgID, deviceGroup.ID)
+	if err != nil {
+		respondDesiredStateError(w, ctxServices.Log, err, "failed reconciling device group")
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, updates)
+}
+
+func respondDesiredStateError(w http.ResponseWriter, logger log.FieldLogger, err error, title string) {
+	logger.WithField("error", err.Error()).Error(title)
+	var apiError errors.APIError
+	switch err.(type) {
+	case *services.DesiredStateNotFound, *services.ImageSetNotFoundError, *services.ImageNotFoundError:
+		apiError = errors.NewNotFound(err.Error())
+	default:
+		apiError = errors.NewInternalServerError()
+		apiError.SetTitle(title)
+	}
+	respondWithAPIError(w, logger, apiError)
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestSetDeviceGroupDesiredState(t *testing.T) {
+	deviceGroup := models.DeviceGroup{OrgID: "0000000", Name: "group", Type: models.DeviceGroupTypeStatic}
+	deviceGroup.ID = 2048
+
+	tt := []struct {
+		name               string
+		state              models.DeviceGroupDesiredState
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should set the device group desired state",
+			state:              models.DeviceGroupDesiredState{ImageSetID: 1024, Version: 3},
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when the version is not defined",
+			state:              models.DeviceGroupDesiredState{ImageSetID: 1024},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return not found when the image set version does not exist",
+			state:              models.DeviceGroupDesiredState{ImageSetID: 1024, Version: 9},
+			callService:        true,
+			returnError:        new(services.ImageNotFoundError),
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, te := range tt {
+		body, err := json.Marshal(te.state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPut, "/", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDesiredStateService := mock_services.NewMockDesiredStateServiceInterface(ctrl)
+		if te.callService {
+			mockDesiredStateService.EXPECT().SetDeviceGroupDesiredState(deviceGroup.OrgID, deviceGroup.ID, gomock.Any()).DoAndReturn(
+				func(orgID string, deviceGroupID uint, state *models.DeviceGroupDesiredState) (*models.DeviceGroupDesiredState, error) {
+					if te.returnError != nil {
+						return nil, te.returnError
+					}
+					return state, nil
+				})
+		}
+		ctx := setContextDeviceGroup(req.Context(), &deviceGroup)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DesiredStateService: mockDesiredStateService,
+			Log:                 log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(SetDeviceGroupDesiredState)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
+func TestReconcileDeviceGroup(t *testing.T) {
+	deviceGroup := models.DeviceGroup{OrgID: "0000000", Name: "group", Type: models.DeviceGroupTypeStatic}
+	deviceGroup.ID = 2048
+
+	tt := []struct {
+		name               string
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should reconcile the device group", expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found when the group has no desired state", returnError: new(services.DesiredStateNotFound), expectedHTTPStatus: http.StatusNotFound},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodPost, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDesiredStateService := mock_services.NewMockDesiredStateServiceInterface(ctrl)
+		mockDesiredStateService.EXPECT().ReconcileDeviceGroup(deviceGroup.OrgID, deviceGroup.ID).Return([]models.UpdateTransaction{}, te.returnError)
+		ctx := setContextDeviceGroup(req.Context(), &deviceGroup)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DesiredStateService: mockDesiredStateService,
+			Log:                 log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(ReconcileDeviceGroup)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+	"gorm.io/gorm"
+)
+
+// deviceGroupsRbacAccessType returns the device groups access type needed by the request method
+func deviceGroupsRbacAccessType(r *http.Request) rbac.AccessType {
+	if r.Method == http.MethodGet {
+		return rbac.AccessTypeRead
+	}
+	return rbac.AccessTypeWrite
+}
+
+// handleDeviceGroupsRbac returns the ids of the device groups the user has accessType access to,
+// the ids are nil when the access is not restricted to some device groups.
+// The access to a device group with cascade_rbac defined extends to its descendants.
+func handleDeviceGroupsRbac(w http.ResponseWriter, r *http.Request, accessType rbac.AccessType) ([]uint, error) {
+	if !feature.DeviceGroupsRbac.IsEnabled() {
+		return nil, nil
+	}
+	contextServices := dependencies.ServicesFromContext(r.Context())
+	if userIdentity, err := common.GetIdentityFromContext(r.Context()); err != nil {
+		contextServices.Log.WithField("error", err.Error()).Error("error occurred when retrieving identity from context")
+		respondWithAPIError(w, contextServices.Log, errors.NewBadRequest("error retrieving identity"))
+		return nil, err
+	} else if userIdentity.Identity.Type != common.IdentityTypeUser {
+		return nil, nil
+	}
+	orgID := readOrgID(w, r, contextServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return nil, errors.NewBadRequest("could not read org id")
+	}
+	acl, err := contextServices.RbacService.GetAccessList(rbac.ApplicationEdge)
+	if err != nil {
+		contextServices.Log.WithField("error", err.Error()).Error("error occurred when getting rbac access list")
+		respondWithAPIError(w, contextServices.Log, errors.NewInternalServerError())
+		return nil, err
+	}
+	allowedAccess, groupsIDs, err := contextServices.RbacService.GetDeviceGroupsAccess(acl, rbac.ResourceTypeDeviceGroups, accessType)
+	if err != nil {
+		apiError := errors.NewServiceUnavailable(err.Error())
+		respondWithAPIError(w, contextServices.Log, apiError)
+		return nil, err
+	}
+	if !allowedAccess {
+		apiError := errors.NewForbidden("access to device groups is forbidden")
+		respondWithAPIError(w, contextServices.Log, apiError)
+		return nil, apiError
+	}
+	if groupsIDs == nil {
+		return nil, nil
+	}
+	groupsIDs, err = contextServices.DeviceGroupsService.ExpandDeviceGroupsAccess(orgID, groupsIDs)
+	if err != nil {
+		contextServices.Log.WithField("error", err.Error()).Error("error occurred when expanding device groups access")
+		respondWithAPIError(w, contextServices.Log, errors.NewInternalServerError())
+		return nil, err
+	}
+	return groupsIDs, nil
+}
+
+// validateDeviceGroupsRbac responds with a forbidden error and returns false when the user has no accessType access
+// to all the device groups
+func validateDeviceGroupsRbac(w http.ResponseWriter, r *http.Request, accessType rbac.AccessType, deviceGroupsIDs ...uint) bool {
+	allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, accessType)
+	if err != nil {
+		// logs and response handled by handleDeviceGroupsRbac
+		return false
+	}
+	if allowedGroupsIDs == nil {
+		return true
+	}
+	for _, deviceGroupID := range deviceGroupsIDs {
+		if !containsInt(allowedGroupsIDs, deviceGroupID) {
+			contextServices := dependencies.ServicesFromContext(r.Context())
+			contextServices.Log.WithField("deviceGroupID", deviceGroupID).Info("access to device group is forbidden")
+			respondWithAPIError(w, contextServices.Log, errors.NewForbidden("access to device group is forbidden"))
+			return false
+		}
+	}
+	return true
+}
+
+// deviceGroupsRbacDevicesFilter returns the filter of the devices belonging to the allowed device groups
+func deviceGroupsRbacDevicesFilter(allowedGroupsIDs []uint) *gorm.DB {
+	membersIDs := db.DB.Table("device_groups_devices").Select("device_id").Where("device_group_id IN (?)", allowedGroupsIDs)
+	return db.DB.Where("devices.id IN (?)", membersIDs)
+}
+
+// deviceGroupsRbacDeviceActionsFilter returns the filter of the device actions sent to the allowed device groups or to
+// no device group, and whose devices all belong to the allowed device groups
+func deviceGroupsRbacDeviceActionsFilter(allowedGroupsIDs []uint) *gorm.DB {
+	membersIDs := db.DB.Table("device_groups_devices").Select("device_id").Where("device_group_id IN (?)", allowedGroupsIDs)
+	forbiddenActionsIDs := db.DB.Table("deviceaction_dispatchrecords").Select("deviceaction_dispatchrecords.device_action_id").
+		Joins("JOIN dispatch_records ON dispatch_records.id = deviceaction_dispatchrecords.dispatch_record_id").
+		Where("dispatch_records.device_id NOT IN (?)", membersIDs)
+	return db.DB.Where("device_actions.device_group_id IS NULL OR device_actions.device_group_id IN (?)", allowedGroupsIDs).
+		Where("device_actions.id NOT IN (?)", forbiddenActionsIDs)
+}
+
+// validateDevicesDeviceGroupsRbac responds with a forbidden error and returns false when some devices do not belong
+// to a device group the user has accessType access to
+func validateDevicesDeviceGroupsRbac(w http.ResponseWriter, r *http.Request, orgID string, accessType rbac.AccessType, devicesUUID []string) bool {
+	allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, accessType)
+	if err != nil {
+		// logs and response handled by handleDeviceGroupsRbac
+		return false
+	}
+	if allowedGroupsIDs == nil {
+		return true
+	}
+	contextServices := dependencies.ServicesFromContext(r.Context())
+	var devicesCount int64
+	if res := db.Org(orgID, "").Model(&models.Device{}).Where("uuid IN (?)", devicesUUID).
+		Where(deviceGroupsRbacDevicesFilter(allowedGroupsIDs)).Count(&devicesCount); res.Error != nil {
+		contextServices.Log.WithField("error", res.Error.Error()).Error("failed to get allowed devices count")
+		respondWithAPIError(w, contextServices.Log, errors.NewInternalServerError())
+		return false
+	}
+	if devicesCount != int64(len(devicesUUID)) {
+		respondWithAPIError(w, contextServices.Log, errors.NewForbidden("access to some devices is forbidden"))
+		return false
+	}
+	return true
+}
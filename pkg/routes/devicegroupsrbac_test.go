@@ -0,0 +1,470 @@
+// FIXME: golangci-lint
+// nolint:revive
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	testHelpers "github.com/redhatinsights/edge-api/internal/testing"
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac"
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac/mock_rbac"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+)
+
+func TestDeviceGroupsRbac(t *testing.T) {
+	RegisterTestingT(t)
+	orgID := faker.UUIDHyphenated()
+
+	defer func() {
+		_ = os.Unsetenv(feature.DeviceGroupsRbac.EnvVar)
+		config.Get().Auth = false
+	}()
+	// enable authentication in config to use the identity type
+	config.Get().Auth = true
+	err := os.Setenv(feature.DeviceGroupsRbac.EnvVar, "true")
+	Expect(err).ToNot(HaveOccurred())
+
+	image := models.Image{Name: faker.Name(), OrgID: orgID}
+	err = db.DB.Create(&image).Error
+	Expect(err).ToNot(HaveOccurred())
+	devices := []models.Device{
+		{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID},
+		{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID},
+		{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID},
+	}
+	// the site group and the other group have a device each, the last device has no group
+	siteGroup := models.DeviceGroup{Name: faker.Name(), OrgID: orgID, Type: models.DeviceGroupTypeDefault, Devices: devices[:1]}
+	err = db.DB.Create(&siteGroup).Error
+	Expect(err).ToNot(HaveOccurred())
+	otherGroup := models.DeviceGroup{Name: faker.Name(), OrgID: orgID, Type: models.DeviceGroupTypeDefault, Devices: devices[1:2]}
+	err = db.DB.Create(&otherGroup).Error
+	Expect(err).ToNot(HaveOccurred())
+	err = db.DB.Create(&devices[2]).Error
+	Expect(err).ToNot(HaveOccurred())
+	// the site child group is not covered by the site group access, the site group access does not cascade
+	siteChildGroup := models.DeviceGroup{Name: faker.Name(), OrgID: orgID, Type: models.DeviceGroupTypeDefault, ParentID: &siteGroup.ID}
+	err = db.DB.Create(&siteChildGroup).Error
+	Expect(err).ToNot(HaveOccurred())
+	err = db.DB.Exec("INSERT INTO device_groups_devices (device_group_id, device_id) VALUES (?, ?)", siteChildGroup.ID, devices[1].ID).Error
+	Expect(err).ToNot(HaveOccurred())
+	otherUpdate := models.UpdateTransaction{OrgID: orgID, Status: models.UpdateStatusAwaitingApproval, Devices: devices[1:2]}
+	err = db.DB.Omit("Devices.*").Create(&otherUpdate).Error
+	Expect(err).ToNot(HaveOccurred())
+	siteAction := models.DeviceAction{OrgID: orgID, Type: models.DeviceActionTypeReboot, Status: models.DeviceActionStatusRunning,
+		DispatchRecords: []models.DispatchRecord{{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusCreated}}}
+	err = db.DB.Create(&siteAction).Error
+	Expect(err).ToNot(HaveOccurred())
+	otherAction := models.DeviceAction{OrgID: orgID, Type: models.DeviceActionTypeReboot, Status: models.DeviceActionStatusRunning,
+		DispatchRecords: []models.DispatchRecord{
+			{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusCreated},
+			{DeviceID: devices[2].ID, Status: models.DispatchRecordStatusCreated},
+		}}
+	err = db.DB.Create(&otherAction).Error
+	Expect(err).ToNot(HaveOccurred())
+
+	siteGroupID := strconv.Itoa(int(siteGroup.ID))
+	siteACL := rbac.AccessList{
+		rbac.Access{
+			ResourceDefinitions: []rbac.ResourceDefinition{
+				{Filter: rbac.ResourceDefinitionFilter{Key: rbac.DeviceGroupsFilterKey, Operation: "in", Value: []*string{&siteGroupID}}},
+			},
+			Permission: "edge:device-groups:*",
+		},
+	}
+
+	testCases := []struct {
+		Name                 string
+		HTTPMethod           string
+		URL                  string
+		BodyData             interface{}
+		IdentityType         string
+		AccessType           rbac.AccessType
+		ResultAllowedAccess  bool
+		ResultGroupsIDs      []uint
+		ExpectedHTTPStatus   int
+		ExpectedErrorMessage string
+		ExpectedDevices      []models.Device
+		ExpectedGroups       []models.DeviceGroup
+		ExpectedActions      []models.DeviceAction
+		RbacChecks           int // the number of rbac checks of the request, one when not defined
+	}{
+		{
+			Name:                "should return only the devices of the allowed device groups",
+			HTTPMethod:          http.MethodGet,
+			URL:                 "/devices/devicesview?sort_by=created_at",
+			IdentityType:        common.IdentityTypeUser,
+			AccessType:          rbac.AccessTypeRead,
+			ResultAllowedAccess: true,
+			ResultGroupsIDs:     []uint{siteGroup.ID},
+			ExpectedHTTPStatus:  http.StatusOK,
+			ExpectedDevices:     devices[:1],
+		},
+		{
+			Name:                "should return all the devices when access is not restricted",
+			HTTPMethod:          http.MethodGet,
+			URL:                 "/devices/devicesview?sort_by=created_at",
+			IdentityType:        common.IdentityTypeUser,
+			AccessType:          rbac.AccessTypeRead,
+			ResultAllowedAccess: true,
+			ExpectedHTTPStatus:  http.StatusOK,
+			ExpectedDevices:     devices,
+		},
+		{
+			Name:               "should not restrict the devices of a system identity",
+			HTTPMethod:         http.MethodGet,
+			URL:                "/devices/devicesview?sort_by=created_at",
+			IdentityType:       "System",
+			ExpectedHTTPStatus: http.StatusOK,
+			ExpectedDevices:    devices,
+		},
+		{
+			Name:                 "should not return the devices when device groups access is forbidden",
+			HTTPMethod:           http.MethodGet,
+			URL:                  "/devices/devicesview",
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeRead,
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to device groups is forbidden",
+		},
+		{
+			Name:                "should return the history of a device of the allowed device groups",
+			HTTPMethod:          http.MethodGet,
+			URL:                 fmt.Sprintf("/devices/%s/history", devices[0].UUID),
+			IdentityType:        common.IdentityTypeUser,
+			AccessType:          rbac.AccessTypeRead,
+			ResultAllowedAccess: true,
+			ResultGroupsIDs:     []uint{siteGroup.ID},
+			ExpectedHTTPStatus:  http.StatusOK,
+		},
+		{
+			Name:                 "should not return a device outside the allowed device groups",
+			HTTPMethod:           http.MethodGet,
+			URL:                  fmt.Sprintf("/devices/%s", devices[1].UUID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeRead,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+		},
+		{
+			Name:                 "should not return the history of a device without device group",
+			HTTPMethod:           http.MethodGet,
+			URL:                  fmt.Sprintf("/devices/%s/history", devices[2].UUID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeRead,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+		},
+		{
+			Name:                "should return only the allowed device groups",
+			HTTPMethod:          http.MethodGet,
+			URL:                 "/device-groups",
+			IdentityType:        common.IdentityTypeUser,
+			AccessType:          rbac.AccessTypeRead,
+			ResultAllowedAccess: true,
+			ResultGroupsIDs:     []uint{siteGroup.ID},
+			ExpectedHTTPStatus:  http.StatusOK,
+			ExpectedGroups:      []models.DeviceGroup{siteGroup},
+		},
+		{
+			Name:                "should return the allowed device group",
+			HTTPMethod:          http.MethodGet,
+			URL:                 fmt.Sprintf("/device-groups/%d", siteGroup.ID),
+			IdentityType:        common.IdentityTypeUser,
+			AccessType:          rbac.AccessTypeRead,
+			ResultAllowedAccess: true,
+			ResultGroupsIDs:     []uint{siteGroup.ID},
+			ExpectedHTTPStatus:  http.StatusOK,
+		},
+		{
+			Name:                 "should not return a device group not allowed",
+			HTTPMethod:           http.MethodGet,
+			URL:                  fmt.Sprintf("/device-groups/%d", otherGroup.ID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeRead,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to device group is forbidden",
+		},
+		{
+			Name:                 "should not update a device group not allowed",
+			HTTPMethod:           http.MethodPut,
+			URL:                  fmt.Sprintf("/device-groups/%d", otherGroup.ID),
+			BodyData:             &models.DeviceGroup{Name: faker.Name()},
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to device group is forbidden",
+		},
+		{
+			Name:                 "should not move devices to a device group not allowed",
+			HTTPMethod:           http.MethodPost,
+			URL:                  "/device-groups/move",
+			BodyData:             &models.DeviceGroupsMove{FromGroupID: siteGroup.ID, ToGroupID: otherGroup.ID, DevicesUUID: []string{devices[0].UUID}},
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to device group is forbidden",
+		},
+		{
+			Name:                 "should not create a device group outside the allowed device groups",
+			HTTPMethod:           http.MethodPost,
+			URL:                  "/device-groups",
+			BodyData:             &models.DeviceGroup{Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault},
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to parent device group is forbidden",
+		},
+		{
+			Name:                 "should not update the devices of the descendant groups not allowed",
+			HTTPMethod:           http.MethodPost,
+			URL:                  fmt.Sprintf("/device-groups/%d/updateDevices", siteGroup.ID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+			RbacChecks:           2,
+		},
+		{
+			Name:                 "should not approve an update of devices outside the allowed device groups",
+			HTTPMethod:           http.MethodPost,
+			URL:                  fmt.Sprintf("/updates/%d/approve", otherUpdate.ID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+		},
+		{
+			Name:                 "should not reject an update of devices outside the allowed device groups",
+			HTTPMethod:           http.MethodPost,
+			URL:                  fmt.Sprintf("/updates/%d/reject", otherUpdate.ID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+		},
+		{
+			Name:                 "should not retry an update of devices outside the allowed device groups",
+			HTTPMethod:           http.MethodPost,
+			URL:                  fmt.Sprintf("/updates/%d/retry-failed", otherUpdate.ID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+		},
+		{
+			Name:                 "should not nest a device group under a device group not allowed",
+			HTTPMethod:           http.MethodPut,
+			URL:                  fmt.Sprintf("/device-groups/%d/hierarchy", siteGroup.ID),
+			BodyData:             &models.DeviceGroupHierarchyAPI{ParentID: &otherGroup.ID},
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to device group is forbidden",
+			RbacChecks:           2,
+		},
+		{
+			Name:                 "should not cascade the access to device groups not allowed",
+			HTTPMethod:           http.MethodPut,
+			URL:                  fmt.Sprintf("/device-groups/%d/hierarchy", siteGroup.ID),
+			BodyData:             &models.DeviceGroupHierarchyAPI{CascadeRBAC: true},
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to device group is forbidden",
+			RbacChecks:           2,
+		},
+		{
+			Name:                "should return only the device actions of the devices of the allowed device groups",
+			HTTPMethod:          http.MethodGet,
+			URL:                 "/device-actions",
+			IdentityType:        common.IdentityTypeUser,
+			AccessType:          rbac.AccessTypeRead,
+			ResultAllowedAccess: true,
+			ResultGroupsIDs:     []uint{siteGroup.ID},
+			ExpectedHTTPStatus:  http.StatusOK,
+			ExpectedActions:     []models.DeviceAction{siteAction},
+		},
+		{
+			Name:                 "should not return a device action of devices outside the allowed device groups",
+			HTTPMethod:           http.MethodGet,
+			URL:                  fmt.Sprintf("/device-actions/%d", otherAction.ID),
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeRead,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to the device action devices is forbidden",
+		},
+		{
+			Name:                 "should not run an action on the devices of the descendant groups not allowed",
+			HTTPMethod:           http.MethodPost,
+			URL:                  "/device-actions",
+			BodyData:             &models.DeviceActionRequest{Type: models.DeviceActionTypeReboot, DeviceGroupID: &siteGroup.ID},
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+			RbacChecks:           2,
+		},
+		{
+			Name:                "should cascade the access when all the descendants are allowed",
+			HTTPMethod:          http.MethodPut,
+			URL:                 fmt.Sprintf("/device-groups/%d/hierarchy", siteGroup.ID),
+			BodyData:            &models.DeviceGroupHierarchyAPI{CascadeRBAC: true},
+			IdentityType:        common.IdentityTypeUser,
+			AccessType:          rbac.AccessTypeWrite,
+			ResultAllowedAccess: true,
+			ResultGroupsIDs:     []uint{siteGroup.ID, siteChildGroup.ID},
+			ExpectedHTTPStatus:  http.StatusOK,
+			RbacChecks:          2,
+		},
+		{
+			Name:                 "should not update devices outside the allowed device groups",
+			HTTPMethod:           http.MethodPost,
+			URL:                  "/updates",
+			BodyData:             &models.DevicesUpdate{DevicesUUID: []string{devices[0].UUID, devices[2].UUID}},
+			IdentityType:         common.IdentityTypeUser,
+			AccessType:           rbac.AccessTypeWrite,
+			ResultAllowedAccess:  true,
+			ResultGroupsIDs:      []uint{siteGroup.ID},
+			ExpectedHTTPStatus:   http.StatusForbidden,
+			ExpectedErrorMessage: "access to some devices is forbidden",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			RegisterTestingT(t)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRbacClient := mock_rbac.NewMockClientInterface(ctrl)
+			if testCase.IdentityType == common.IdentityTypeUser {
+				rbacChecks := testCase.RbacChecks
+				if rbacChecks == 0 {
+					rbacChecks = 1
+				}
+				mockRbacClient.EXPECT().GetAccessList(rbac.ApplicationEdge).Return(siteACL, nil).Times(rbacChecks)
+				mockRbacClient.EXPECT().GetDeviceGroupsAccess(siteACL, rbac.ResourceTypeDeviceGroups, testCase.AccessType).Return(
+					testCase.ResultAllowedAccess, testCase.ResultGroupsIDs, nil,
+				).Times(rbacChecks)
+			}
+
+			router := chi.NewRouter()
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					rLog := log.NewEntry(log.StandardLogger())
+					ctx := testHelpers.WithCustomIdentityType(r.Context(), orgID, testCase.IdentityType)
+					ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+						DeviceService:        services.NewDeviceService(ctx, rLog),
+						DeviceGroupsService:  services.NewDeviceGroupsService(ctx, rLog),
+						DeviceHistoryService: services.NewDeviceHistoryService(ctx, rLog),
+						DeviceActionsService: services.NewDeviceActionsService(ctx, rLog),
+						RbacService:          mockRbacClient,
+						Log:                  rLog,
+					})
+					next.ServeHTTP(w, r.WithContext(ctx))
+				})
+			})
+			router.Route("/devices", MakeDevicesRouter)
+			router.Route("/device-groups", MakeDeviceGroupsRouter)
+			router.Route("/updates", MakeUpdatesRouter)
+			router.Route("/device-actions", MakeDeviceActionsRouter)
+
+			var body io.Reader
+			if testCase.BodyData != nil {
+				jsonBodyData, err := json.Marshal(testCase.BodyData)
+				Expect(err).ToNot(HaveOccurred())
+				body = bytes.NewBuffer(jsonBodyData)
+			}
+			req, err := http.NewRequest(testCase.HTTPMethod, testCase.URL, body)
+			Expect(err).ToNot(HaveOccurred())
+
+			responseRecorder := httptest.NewRecorder()
+			router.ServeHTTP(responseRecorder, req)
+
+			Expect(responseRecorder.Code).To(Equal(testCase.ExpectedHTTPStatus))
+			respBody, err := io.ReadAll(responseRecorder.Body)
+			Expect(err).ToNot(HaveOccurred())
+			if testCase.ExpectedErrorMessage != "" {
+				Expect(string(respBody)).To(ContainSubstring(testCase.ExpectedErrorMessage))
+			}
+			if testCase.ExpectedDevices != nil {
+				var responseDevicesView models.DeviceViewListResponseAPI
+				err = json.Unmarshal(respBody, &responseDevicesView)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(responseDevicesView.Data.Devices)).To(Equal(len(testCase.ExpectedDevices)))
+				for ind, device := range responseDevicesView.Data.Devices {
+					Expect(device.DeviceUUID).To(Equal(testCase.ExpectedDevices[ind].UUID))
+				}
+			}
+			if testCase.ExpectedGroups != nil {
+				var responseGroups struct {
+					Data  []models.DeviceGroupListDetail `json:"data"`
+					Count int64                          `json:"count"`
+				}
+				err = json.Unmarshal(respBody, &responseGroups)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(responseGroups.Count).To(Equal(int64(len(testCase.ExpectedGroups))))
+				for ind, deviceGroup := range responseGroups.Data {
+					Expect(deviceGroup.DeviceGroup.ID).To(Equal(testCase.ExpectedGroups[ind].ID))
+				}
+			}
+			if testCase.ExpectedActions != nil {
+				var responseActions []models.DeviceAction
+				err = json.Unmarshal(respBody, &responseActions)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(responseActions)).To(Equal(len(testCase.ExpectedActions)))
+				for ind, action := range responseActions {
+					Expect(action.ID).To(Equal(testCase.ExpectedActions[ind].ID))
+				}
+			}
+		})
+	}
+}
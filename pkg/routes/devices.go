@@ -22,6 +22,7 @@ import (
 	"github.com/redhatinsights/edge-api/pkg/services"
 	"github.com/redhatinsights/edge-api/pkg/services/utility"
 	feature "github.com/redhatinsights/edge-api/unleash/features"
+	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -30,12 +31,18 @@ func MakeDevicesRouter(sub chi.Router) {
 	sub.With(ValidateQueryParams("devices")).With(ValidateGetAllDevicesFilterParams).Get("/", GetDevices)
 	sub.With(ValidateQueryParams("devicesview")).With(common.Paginate).With(ValidateGetDevicesViewFilterParams).Get("/devicesview", GetDevicesView)
 	sub.With(ValidateQueryParams("devicesview")).With(common.Paginate).With(ValidateGetDevicesViewFilterParams).Post("/devicesview", GetDevicesViewWithinDevices)
+	sub.With(ValidateQueryParams("devicesviewexport")).With(ValidateGetDevicesViewFilterParams).Get("/devicesview/export", ExportDevicesView)
+	sub.Get("/staleness", GetDevicesStalenessSettings)
+	sub.Put("/staleness", SetDevicesStalenessSettings)
 	sub.Route("/{DeviceUUID}", func(r chi.Router) {
 		r.Use(DeviceCtx)
 		r.Get("/dbinfo", GetDeviceDBInfo)
 		r.With(common.Paginate).With(ValidateDeviceUpdateImagesFilterParams).Get("/", GetDevice)
 		r.With(common.Paginate).Get("/updates", GetUpdateAvailableForDevice)
 		r.With(common.Paginate).Get("/image", GetDeviceImageInfo)
+		r.Get("/rollbacks", GetDeviceRollbacks)
+		r.With(common.Paginate).Get("/history", GetDeviceHistory)
+		r.Get("/connectivity", GetDeviceConnectivity)
 	})
 }
 
@@ -61,6 +68,17 @@ func DeviceCtx(next http.Handler) http.Handler {
 			respondWithAPIError(w, contextServices.Log, errors.NewBadRequest("DeviceUUID must be sent"))
 			return
 		}
+		contextServices := dependencies.ServicesFromContext(r.Context())
+		orgID := readOrgID(w, r, contextServices.Log)
+		if orgID == "" {
+			// logs and response handled by readOrgID
+			return
+		}
+		// the device is readable only through a device group the user has read access to
+		if !validateDevicesDeviceGroupsRbac(w, r, orgID, rbac.AccessTypeRead, []string{dc.DeviceUUID}) {
+			// logs and response handled by validateDevicesDeviceGroupsRbac
+			return
+		}
 		// TODO: Implement devices by tag
 		// dc.Tag = chi.URLParam(r, "Tag")
 		ctx := context.WithValue(r.Context(), deviceContextKey, dc)
@@ -68,7 +86,8 @@ func DeviceCtx(next http.Handler) http.Handler {
 	})
 }
 
-var devicesFilters = common.ComposeFilters(
+// devicesMatchFilters filters the devices by the request query params, without sorting them
+var devicesMatchFilters = common.ComposeFilters(
 	// Filter handler for "name"
 	common.ContainFilterHandler(&common.Filter{
 		QueryParam: "name",
@@ -99,9 +118,85 @@ var devicesFilters = common.ComposeFilters(
 		QueryParam: "groupUUID",
 		DBField:    "devices.group_uuid",
 	}),
+	// Filter handlers for the persisted inventory system profile
+	common.ContainFilterHandler(&common.Filter{
+		QueryParam: "arch",
+		DBField:    "devices.arch",
+	}),
+	common.ContainFilterHandler(&common.Filter{
+		QueryParam: "os_release",
+		DBField:    "devices.os_release",
+	}),
+	common.ContainFilterHandler(&common.Filter{
+		QueryParam: "os_kernel_version",
+		DBField:    "devices.os_kernel_version",
+	}),
+	common.ContainFilterHandler(&common.Filter{
+		QueryParam: "cpu_model",
+		DBField:    "devices.cpu_model",
+	}),
+	common.IntegerNumberFilterHandler(&common.Filter{
+		QueryParam: "number_of_cpus",
+		DBField:    "devices.number_of_cpus",
+	}),
+	common.IntegerNumberFilterHandler(&common.Filter{
+		QueryParam: "system_memory_bytes",
+		DBField:    "devices.system_memory_bytes",
+	}),
+	common.ContainFilterHandler(&common.Filter{
+		QueryParam: "host_type",
+		DBField:    "devices.host_type",
+	}),
+	// matches any network interface name, mac address or ip address
+	common.ContainFilterHandler(&common.Filter{
+		QueryParam: "network_interfaces",
+		DBField:    "devices.network_interfaces",
+	}),
+	// matches the devices having all the inventory tags
+	devicesTagsFilterHandler,
+	// matches any rpm-ostree deployment checksum, origin or version
+	common.ContainFilterHandler(&common.Filter{
+		QueryParam: "rpm_ostree_deployments",
+		DBField:    "devices.rpm_ostree_deployments",
+	}),
+	// matches the devices disconnected for more than the hours
+	devicesDisconnectedFilterHandler,
+)
+
+// devicesFilters filters the devices by the request query params and sorts them by the "sort_by" query param
+var devicesFilters = common.ComposeFilters(
+	devicesMatchFilters,
 	common.SortFilterHandler("devices", "name", "ASC"),
 )
 
+// devicesDisconnectedFilterHandler filters the devices disconnected from rhc for more than the hours of the
+// "disconnected_hours" query param
+func devicesDisconnectedFilterHandler(r *http.Request, tx *gorm.DB) *gorm.DB {
+	hours, err := strconv.Atoi(r.URL.Query().Get("disconnected_hours"))
+	if err != nil || hours < 0 {
+		return tx
+	}
+	return tx.Where(models.DevicesDisconnectedQuery, false, models.DeviceConnectivityEventTypes, time.Now().Add(-time.Duration(hours)*time.Hour))
+}
+
+// devicesTagsFilterHandler filters the devices having all the inventory tags of the repeatable "tag" query param,
+// a failing tags lookup is added to the query errors
+func devicesTagsFilterHandler(r *http.Request, tx *gorm.DB) *gorm.DB {
+	tags := r.URL.Query()["tag"]
+	if len(tags) == 0 {
+		return tx
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	taggedTx, err := ctxServices.DeviceService.FilterTaggedDevices(tx, tags)
+	if err != nil {
+		ctxServices.Log.WithFields(log.Fields{"error": err.Error(), "tags": tags}).Error("failed to filter the tagged devices")
+		tx = tx.Session(&gorm.Session{})
+		_ = tx.AddError(err)
+		return tx
+	}
+	return taggedTx
+}
+
 func ValidateDeviceUpdateImagesFilterParams(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctxServices := dependencies.ServicesFromContext(r.Context())
@@ -218,10 +313,17 @@ func ValidateGetDevicesViewFilterParams(next http.Handler) http.Handler {
 				errs = append(errs, validationError{Key: "update_available", Reason: fmt.Sprintf("%s is not a valid value for update_available. update_available must be boolean", val)})
 			}
 		}
-		// check for invalid image_id value
-		if val := r.URL.Query().Get("image_id"); val != "" {
-			if _, err := strconv.Atoi(val); err != nil {
-				errs = append(errs, validationError{Key: "image_id", Reason: fmt.Sprintf("%s is not a valid value for image_id. image_id must be integer", val)})
+		// check for invalid integer values
+		for _, key := range []string{"image_id", "number_of_cpus", "system_memory_bytes"} {
+			if val := r.URL.Query().Get(key); val != "" {
+				if _, err := strconv.Atoi(val); err != nil {
+					errs = append(errs, validationError{Key: key, Reason: fmt.Sprintf("%s is not a valid value for %s. %s must be integer", val, key, key)})
+				}
+			}
+		}
+		if val := r.URL.Query().Get("disconnected_hours"); val != "" {
+			if hours, err := strconv.Atoi(val); err != nil || hours < 0 {
+				errs = append(errs, validationError{Key: "disconnected_hours", Reason: fmt.Sprintf("%s is not a valid value for disconnected_hours. disconnected_hours must be a positive integer", val)})
 			}
 		}
 		if len(errs) == 0 {
@@ -476,12 +578,23 @@ func handleInventoryHostsRbac(w http.ResponseWriter, r *http.Request) (*gorm.DB,
 // @Tags         Devices (Systems)
 // @Accept       json
 // @Produce      json
-// @Param	 sort_by            query string	false "fields: name, uuid, update_available, image_id. To sort DESC use - before the fields."
+// @Param	 sort_by            query string	false "fields: name, uuid, update_available, image_id, arch, os_release, os_kernel_version, cpu_model, number_of_cpus, system_memory_bytes, host_type. To sort DESC use - before the fields."
 // @Param	 name               query string 	false "field: filter by name"
 // @Param	 update_available   query boolean	false "field: filter by update_available"
 // @Param	 uuid               query string	false "field: filter by uuid"
 // @Param	 created_at         query string	false "field: filter by creation date"
 // @Param	 image_id           query int   	false "field: filter by image id"
+// @Param	 arch               query string	false "field: filter by system profile arch"
+// @Param	 os_release         query string	false "field: filter by system profile os release"
+// @Param	 os_kernel_version  query string	false "field: filter by system profile kernel version"
+// @Param	 cpu_model          query string	false "field: filter by system profile cpu model"
+// @Param	 number_of_cpus     query int   	false "field: filter by system profile number of cpus"
+// @Param	 system_memory_bytes query int   	false "field: filter by system profile memory size in bytes"
+// @Param	 host_type          query string	false "field: filter by system profile host type"
+// @Param	 network_interfaces query string	false "field: filter by network interface name, mac address or ip address"
+// @Param	 tag                query string	false "field: filter by inventory tag in the namespace/key=value format, repeat to filter by all the tags"
+// @Param	 rpm_ostree_deployments query string	false "field: filter by rpm-ostree deployment checksum, origin or version"
+// @Param	 disconnected_hours query int   	false "field: filter the devices disconnected from rhc for more than the hours"
 // @Param	 limit              query int    	false "field: return number of devices until limit is reached. Default is 100."
 // @Param	 offset             query int    	false "field: return number of devices begining at the offset."
 // @Success      200  {object}  models.DeviceViewListResponseAPI
@@ -493,19 +606,12 @@ func GetDevicesView(w http.ResponseWriter, r *http.Request) {
 	if orgID == "" {
 		return
 	}
-	tx := devicesFilters(r, db.DB).Where("image_id > 0")
 	enforceEdgeGroups := utility.EnforceEdgeGroups(orgID)
-	if feature.EdgeParityInventoryRbac.IsEnabled() && feature.EdgeParityInventoryGroupsEnabled.IsEnabled() && !enforceEdgeGroups {
-		inventoryRbacFilter, err := handleInventoryHostsRbac(w, r)
-		if err != nil {
-			// logs and response handled by handleInventoryHostsRbac
-			return
-		}
-		if inventoryRbacFilter != nil {
-			tx = tx.Where(inventoryRbacFilter)
-		}
+	tx, err := devicesViewFilteredDB(w, r, devicesFilters, enforceEdgeGroups)
+	if err != nil {
+		// logs and response handled by devicesViewFilteredDB
+		return
 	}
-	tx = tx.Session(&gorm.Session{})
 	pagination := common.GetPagination(r)
 
 	devicesCount, err := contextServices.DeviceService.GetDevicesCount(tx)
@@ -524,6 +630,37 @@ func GetDevicesView(w http.ResponseWriter, r *http.Request) {
 	respondWithJSONBody(w, contextServices.Log, map[string]interface{}{"data": devicesViewList, "count": devicesCount})
 }
 
+// devicesViewFilteredDB returns the devices view query filtered by the filters and the user rbac access
+func devicesViewFilteredDB(w http.ResponseWriter, r *http.Request, filters common.FilterFunc, enforceEdgeGroups bool) (*gorm.DB, error) {
+	tx := filters(r, db.DB).Where("image_id > 0")
+	if tx.Error != nil {
+		// the error is logged by the failing filter
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed to filter the devices")
+		respondWithAPIError(w, dependencies.ServicesFromContext(r.Context()).Log, apiError)
+		return nil, tx.Error
+	}
+	if feature.EdgeParityInventoryRbac.IsEnabled() && feature.EdgeParityInventoryGroupsEnabled.IsEnabled() && !enforceEdgeGroups {
+		inventoryRbacFilter, err := handleInventoryHostsRbac(w, r)
+		if err != nil {
+			// logs and response handled by handleInventoryHostsRbac
+			return nil, err
+		}
+		if inventoryRbacFilter != nil {
+			tx = tx.Where(inventoryRbacFilter)
+		}
+	}
+	allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, rbac.AccessTypeRead)
+	if err != nil {
+		// logs and response handled by handleDeviceGroupsRbac
+		return nil, err
+	}
+	if allowedGroupsIDs != nil {
+		tx = tx.Where(deviceGroupsRbacDevicesFilter(allowedGroupsIDs))
+	}
+	return tx.Session(&gorm.Session{}), nil
+}
+
 // GetDevicesViewWithinDevices returns all data needed to display customers devices
 // @ID           GetDevicesViewWithinDevices
 // @Summary      Return all data of Devices.
@@ -532,12 +669,23 @@ func GetDevicesView(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param    body	body	models.FilterByDevicesAPI true	"request body"
-// @Param	 sort_by            query string	false "fields: name, uuid, update_available, image_id. To sort DESC use - before the fields."
+// @Param	 sort_by            query string	false "fields: name, uuid, update_available, image_id, arch, os_release, os_kernel_version, cpu_model, number_of_cpus, system_memory_bytes, host_type. To sort DESC use - before the fields."
 // @Param	 name               query string 	false "field: filter by name"
 // @Param	 update_available   query boolean	false "field: filter by update_available"
 // @Param	 uuid               query string	false "field: filter by uuid"
 // @Param	 created_at         query string	false "field: filter by creation date"
 // @Param	 image_id           query int   	false "field: filter by image id"
+// @Param	 arch               query string	false "field: filter by system profile arch"
+// @Param	 os_release         query string	false "field: filter by system profile os release"
+// @Param	 os_kernel_version  query string	false "field: filter by system profile kernel version"
+// @Param	 cpu_model          query string	false "field: filter by system profile cpu model"
+// @Param	 number_of_cpus     query int   	false "field: filter by system profile number of cpus"
+// @Param	 system_memory_bytes query int   	false "field: filter by system profile memory size in bytes"
+// @Param	 host_type          query string	false "field: filter by system profile host type"
+// @Param	 network_interfaces query string	false "field: filter by network interface name, mac address or ip address"
+// @Param	 tag                query string	false "field: filter by inventory tag in the namespace/key=value format, repeat to filter by all the tags"
+// @Param	 rpm_ostree_deployments query string	false "field: filter by rpm-ostree deployment checksum, origin or version"
+// @Param	 disconnected_hours query int   	false "field: filter the devices disconnected from rhc for more than the hours"
 // @Param	 limit              query int    	false "field: return number of devices until limit is reached. Default is 100."
 // @Param	 offset             query int    	false "field: return number of devices beginning at the offset."
 // @Success      200  {object}  models.DeviceViewListResponseAPI
@@ -577,6 +725,14 @@ func GetDevicesViewWithinDevices(w http.ResponseWriter, r *http.Request) {
 			tx = tx.Where(inventoryRbacFilter)
 		}
 	}
+	allowedGroupsIDs, err := handleDeviceGroupsRbac(w, r, rbac.AccessTypeRead)
+	if err != nil {
+		// logs and response handled by handleDeviceGroupsRbac
+		return
+	}
+	if allowedGroupsIDs != nil {
+		tx = tx.Where(deviceGroupsRbacDevicesFilter(allowedGroupsIDs))
+	}
 	tx = tx.Session(&gorm.Session{})
 	pagination := common.GetPagination(r)
 
@@ -595,3 +751,149 @@ func GetDevicesViewWithinDevices(w http.ResponseWriter, r *http.Request) {
 	devicesViewList.EnforceEdgeGroups = enforceEdgeGroups
 	respondWithJSONBody(w, contextServices.Log, map[string]interface{}{"data": devicesViewList, "count": devicesCount})
 }
+
+// GetDeviceRollbacks returns the update rollbacks of a device
+// @Summary      Get the update rollbacks of a device
+// @ID           GetDeviceRollbacks
+// @Description  Returns the updates the device rolled back to its previous deployment, usually after failing the greenboot health checks.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        DeviceUUID  path  string  true  "DeviceUUID"
+// @Success      200 {object} []models.UpdateRollbackAPI
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      404 {object} errors.NotFound	"The device was not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /devices/{DeviceUUID}/rollbacks [get]
+func GetDeviceRollbacks(w http.ResponseWriter, r *http.Request) {
+	contextServices := dependencies.ServicesFromContext(r.Context())
+	dc, ok := r.Context().Value(deviceContextKey).(DeviceContext)
+	if dc.DeviceUUID == "" || !ok {
+		return // Error set by DeviceCtx method
+	}
+	orgID := readOrgID(w, r, contextServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	rollbacks, err := contextServices.DeviceService.GetDeviceRollbacks(orgID, dc.DeviceUUID)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceNotFoundError:
+			apiError = errors.NewNotFound("Could not find device")
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed to get device rollbacks")
+		}
+		respondWithAPIError(w, contextServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, contextServices.Log, rollbacks)
+}
+
+// GetDeviceHistory returns the history timeline of a device
+// @Summary      Get the history timeline of a device
+// @ID           GetDeviceHistory
+// @Description  Returns the update attempts of the device merged with the image, group membership and connectivity changes, ordered from the most recent.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        DeviceUUID  path   string   true   "DeviceUUID"
+// @Param        limit       query  int      false  "field: return number of events until limit is reached. Default is 30."
+// @Param        offset      query  int      false  "field: return number of events beginning at the offset."
+// @Success      200 {object} models.DeviceHistoryAPI
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      404 {object} errors.NotFound	"The device was not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /devices/{DeviceUUID}/history [get]
+func GetDeviceHistory(w http.ResponseWriter, r *http.Request) {
+	contextServices := dependencies.ServicesFromContext(r.Context())
+	dc, ok := r.Context().Value(deviceContextKey).(DeviceContext)
+	if dc.DeviceUUID == "" || !ok {
+		return // Error set by DeviceCtx method
+	}
+	orgID := readOrgID(w, r, contextServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	pagination := common.GetPagination(r)
+	history, err := contextServices.DeviceHistoryService.GetDeviceHistory(orgID, dc.DeviceUUID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceNotFoundError:
+			apiError = errors.NewNotFound("Could not find device")
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed to get device history")
+		}
+		respondWithAPIError(w, contextServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, contextServices.Log, history)
+}
+
+// GetDeviceConnectivity returns the rhc connectivity of a device
+// @Summary      Get the connectivity of a device
+// @ID           GetDeviceConnectivity
+// @Description  Returns the current rhc connectivity of the device, since when, and the time and percentage it was connected over the last days, computed from its connectivity history.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        DeviceUUID  path   string   true   "DeviceUUID"
+// @Param        days        query  int      false  "field: the number of days of the period, between 1 and 365. Default is 30."
+// @Success      200 {object} models.DeviceConnectivity
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      404 {object} errors.NotFound	"The device was not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /devices/{DeviceUUID}/connectivity [get]
+func GetDeviceConnectivity(w http.ResponseWriter, r *http.Request) {
+	contextServices := dependencies.ServicesFromContext(r.Context())
+	dc, ok := r.Context().Value(deviceContextKey).(DeviceContext)
+	if dc.DeviceUUID == "" || !ok {
+		return // Error set by DeviceCtx method
+	}
+	orgID := readOrgID(w, r, contextServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	days, err := readConnectivityDays(w, r, contextServices.Log)
+	if err != nil {
+		// logs and response handled by readConnectivityDays
+		return
+	}
+	connectivity, err := contextServices.DeviceHistoryService.GetDeviceConnectivity(orgID, dc.DeviceUUID, days)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceNotFoundError:
+			apiError = errors.NewNotFound("Could not find device")
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed to get device connectivity")
+		}
+		respondWithAPIError(w, contextServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, contextServices.Log, connectivity)
+}
+
+// readConnectivityDays returns the days of the connectivity period of the "days" query param, 30 by default
+func readConnectivityDays(w http.ResponseWriter, r *http.Request, logger log.FieldLogger) (int, error) {
+	value := r.URL.Query().Get("days")
+	if value == "" {
+		return models.DeviceConnectivityDefaultDays, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err == nil {
+		err = models.ValidateDeviceConnectivityDays(days)
+	}
+	if err != nil {
+		respondWithAPIError(w, logger, errors.NewBadRequest(models.DeviceConnectivityDaysInvalidErrorMessage))
+		return 0, err
+	}
+	return days, nil
+}
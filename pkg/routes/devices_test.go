@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 	"time"
@@ -815,6 +816,13 @@ func TestValidateGetDevicesViewFilterParams(t *testing.T) {
 				{Key: "image_id", Reason: "123abc is not a valid value for image_id. image_id must be integer"},
 			},
 		},
+		{
+			name:   "invalid number_of_cpus",
+			params: "number_of_cpus=four",
+			expectedError: []validationError{
+				{Key: "number_of_cpus", Reason: "four is not a valid value for number_of_cpus. number_of_cpus must be integer"},
+			},
+		},
 	}
 
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
@@ -1517,6 +1525,186 @@ func TestGetDevicesViewFilteringByGroup(t *testing.T) {
 
 }
 
+func TestGetDevicesViewFilteringBySystemProfile(t *testing.T) {
+	RegisterTestingT(t)
+	orgID := faker.UUIDHyphenated()
+	defer func() {
+		config.Get().Auth = false
+	}()
+	// enable authentication in config to use the identity org
+	config.Get().Auth = true
+
+	image := models.Image{Name: faker.Name(), OrgID: orgID}
+	err := db.DB.Create(&image).Error
+	Expect(err).ToNot(HaveOccurred())
+	devices := []models.Device{
+		{
+			OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID, Name: "device-a",
+			SystemProfile: models.DeviceSystemProfile{
+				Arch: "x86_64", NumberOfCPUs: 4, Tags: []string{"site/store=042"},
+				NetworkInterfaces: []models.DeviceNetworkInterface{{Name: "eth0", IPv4Addresses: []string{"10.0.0.42"}}},
+			},
+		},
+		{
+			OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID, Name: "device-b",
+			SystemProfile: models.DeviceSystemProfile{Arch: "aarch64", NumberOfCPUs: 2, Tags: []string{"site/store=043"}},
+		},
+		{
+			OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID, Name: "device-c",
+			SystemProfile: models.DeviceSystemProfile{Arch: "x86_64", NumberOfCPUs: 8, Tags: []string{"site/store=043", "insights-client/env=prod"}},
+		},
+	}
+	err = db.DB.Create(&devices).Error
+	Expect(err).ToNot(HaveOccurred())
+
+	testCases := []struct {
+		Name            string
+		Params          string
+		ExpectedDevices []models.Device
+	}{
+		{Name: "should filter by arch", Params: "arch=x86_64", ExpectedDevices: []models.Device{devices[0], devices[2]}},
+		{Name: "should filter by number of cpus", Params: "number_of_cpus=2", ExpectedDevices: []models.Device{devices[1]}},
+		{Name: "should filter by tag", Params: "tag=site/store=043", ExpectedDevices: []models.Device{devices[1], devices[2]}},
+		{Name: "should filter by all the tags", Params: "tag=site/store=043&tag=insights-client/env=prod", ExpectedDevices: []models.Device{devices[2]}},
+		{Name: "should not filter by partial tag", Params: "tag=site/store=04", ExpectedDevices: []models.Device{}},
+		{Name: "should filter by ip address", Params: "network_interfaces=10.0.0.42", ExpectedDevices: []models.Device{devices[0]}},
+		{Name: "should sort by number of cpus", Params: "sort_by=-number_of_cpus", ExpectedDevices: []models.Device{devices[2], devices[0], devices[1]}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			RegisterTestingT(t)
+			router := chi.NewRouter()
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					rLog := log.NewEntry(log.StandardLogger())
+					ctx := testHelpers.WithCustomIdentity(r.Context(), orgID)
+					ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+						DeviceService: services.NewDeviceService(ctx, rLog),
+						Log:           rLog,
+					})
+					next.ServeHTTP(w, r.WithContext(ctx))
+				})
+			})
+			router.Route("/devices", MakeDevicesRouter)
+
+			req, err := http.NewRequest(http.MethodGet, "/devices/devicesview?"+testCase.Params, nil)
+			Expect(err).ToNot(HaveOccurred())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var responseDevicesView models.DeviceViewListResponseAPI
+			err = json.Unmarshal(rr.Body.Bytes(), &responseDevicesView)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responseDevicesView.Count).To(Equal(int64(len(testCase.ExpectedDevices))))
+			Expect(len(responseDevicesView.Data.Devices)).To(Equal(len(testCase.ExpectedDevices)))
+			for ind, device := range responseDevicesView.Data.Devices {
+				Expect(device.DeviceUUID).To(Equal(testCase.ExpectedDevices[ind].UUID))
+				Expect(device.Tags).To(Equal(testCase.ExpectedDevices[ind].SystemProfile.DeviceTags()))
+			}
+		})
+	}
+}
+
+func TestGetDevicesViewFilteringByInventoryTags(t *testing.T) {
+	RegisterTestingT(t)
+	orgID := faker.UUIDHyphenated()
+	tag := "site/store=042"
+	conf := config.Get()
+	defer func(auth bool, profileFields []string) {
+		conf.Auth = auth
+		conf.InventoryProfileFields = profileFields
+	}(conf.Auth, conf.InventoryProfileFields)
+	// enable authentication in config to use the identity org, and do not persist the devices tags
+	conf.Auth = true
+	conf.InventoryProfileFields = []string{models.DeviceSystemProfileFieldArch}
+
+	image := models.Image{Name: faker.Name(), OrgID: orgID}
+	err := db.DB.Create(&image).Error
+	Expect(err).ToNot(HaveOccurred())
+	devices := []models.Device{
+		{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID, Name: "device-a"},
+		{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID, Name: "device-b"},
+	}
+	err = db.DB.Create(&devices).Error
+	Expect(err).ToNot(HaveOccurred())
+
+	testCases := []struct {
+		Name            string
+		InventoryResult []inventory.Device
+		InventoryError  error
+		ExpectedStatus  int
+		ExpectedDevices []models.Device
+	}{
+		{
+			Name:            "should filter by the inventory tagged hosts",
+			InventoryResult: []inventory.Device{{ID: devices[1].UUID}},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedDevices: []models.Device{devices[1]},
+		},
+		{
+			Name:            "should not return devices when no inventory host has the tag",
+			InventoryResult: []inventory.Device{},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedDevices: []models.Device{},
+		},
+		{
+			Name:           "should fail when the inventory tagged hosts lookup fails",
+			InventoryError: errors.New("inventory error"),
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			RegisterTestingT(t)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockInventory := mock_inventory.NewMockClientInterface(ctrl)
+			mockInventory.EXPECT().ReturnDevicesByTag(url.QueryEscape(tag)).
+				Return(inventory.Response{Result: testCase.InventoryResult}, testCase.InventoryError).AnyTimes()
+
+			router := chi.NewRouter()
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					rLog := log.NewEntry(log.StandardLogger())
+					ctx := testHelpers.WithCustomIdentity(r.Context(), orgID)
+					ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+						DeviceService: &services.DeviceService{
+							UpdateService: services.NewUpdateService(ctx, rLog),
+							ImageService:  services.NewImageService(ctx, rLog),
+							Inventory:     mockInventory,
+							Service:       services.NewService(ctx, rLog),
+						},
+						Log: rLog,
+					})
+					next.ServeHTTP(w, r.WithContext(ctx))
+				})
+			})
+			router.Route("/devices", MakeDevicesRouter)
+
+			req, err := http.NewRequest(http.MethodGet, "/devices/devicesview?tag="+url.QueryEscape(tag), nil)
+			Expect(err).ToNot(HaveOccurred())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(testCase.ExpectedStatus))
+			if testCase.ExpectedStatus != http.StatusOK {
+				return
+			}
+
+			var responseDevicesView models.DeviceViewListResponseAPI
+			err = json.Unmarshal(rr.Body.Bytes(), &responseDevicesView)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responseDevicesView.Count).To(Equal(int64(len(testCase.ExpectedDevices))))
+			Expect(len(responseDevicesView.Data.Devices)).To(Equal(len(testCase.ExpectedDevices)))
+			for ind, device := range responseDevicesView.Data.Devices {
+				Expect(device.DeviceUUID).To(Equal(testCase.ExpectedDevices[ind].UUID))
+			}
+		})
+	}
+}
+
 func TestEnforceEdgeGroups(t *testing.T) {
 	RegisterTestingT(t)
 	conf := config.Get()
@@ -2078,3 +2266,109 @@ func TestDevicesViewInventoryHostsRbac(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDeviceRollbacks(t *testing.T) {
+	deviceUUID := faker.UUIDHyphenated()
+	rollbacks := []models.UpdateRollback{{OrgID: common.DefaultOrgID, UpdateTransactionID: 1200, DeviceUUID: deviceUUID}}
+
+	tt := []struct {
+		name               string
+		returnRollbacks    []models.UpdateRollback
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should return the device rollbacks", returnRollbacks: rollbacks, expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found when device does not exist", returnError: new(services.DeviceNotFoundError), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/devices/%s/rollbacks", deviceUUID), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceService := mock_services.NewMockDeviceServiceInterface(ctrl)
+		mockDeviceService.EXPECT().GetDeviceRollbacks(common.DefaultOrgID, deviceUUID).Return(te.returnRollbacks, te.returnError)
+		ctx := context.WithValue(req.Context(), deviceContextKey, DeviceContext{DeviceUUID: deviceUUID})
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceService: mockDeviceService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(GetDeviceRollbacks)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+			continue
+		}
+		if te.returnRollbacks != nil {
+			var responseRollbacks []models.UpdateRollback
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseRollbacks); err != nil {
+				t.Errorf("in %q: failed decoding response body: %s", te.name, err.Error())
+				continue
+			}
+			if len(responseRollbacks) != 1 || responseRollbacks[0].UpdateTransactionID != 1200 {
+				t.Errorf("in %q: wrong rollbacks: got %v", te.name, responseRollbacks)
+			}
+		}
+	}
+}
+
+func TestGetDeviceHistory(t *testing.T) {
+	deviceUUID := faker.UUIDHyphenated()
+	history := &models.DeviceHistory{
+		Count: 2,
+		Data: []models.DeviceHistoryEvent{
+			{Type: models.DeviceEventTypeUpdate, UpdateID: 1200, Status: models.DispatchRecordStatusComplete},
+			{Type: models.DeviceEventTypeGroupAdded, To: "group-1"},
+		},
+	}
+
+	tt := []struct {
+		name               string
+		returnHistory      *models.DeviceHistory
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should return the device history", returnHistory: history, expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found when device does not exist", returnError: new(services.DeviceNotFoundError), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/devices/%s/history", deviceUUID), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceHistoryService := mock_services.NewMockDeviceHistoryServiceInterface(ctrl)
+		mockDeviceHistoryService.EXPECT().GetDeviceHistory(common.DefaultOrgID, deviceUUID, 30, 0).Return(te.returnHistory, te.returnError)
+		ctx := context.WithValue(req.Context(), deviceContextKey, DeviceContext{DeviceUUID: deviceUUID})
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceHistoryService: mockDeviceHistoryService,
+			Log:                  log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(GetDeviceHistory)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+			continue
+		}
+		if te.returnHistory != nil {
+			var responseHistory models.DeviceHistory
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseHistory); err != nil {
+				t.Errorf("in %q: failed decoding response body: %s", te.name, err.Error())
+				continue
+			}
+			if responseHistory.Count != 2 || len(responseHistory.Data) != 2 || responseHistory.Data[0].UpdateID != 1200 {
+				t.Errorf("in %q: wrong history: got %v", te.name, responseHistory)
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package routes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	goErrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/utility"
+)
+
+const (
+	// DevicesViewExportFormatCSV is the csv devices view export format, the default
+	DevicesViewExportFormatCSV = "csv"
+	// DevicesViewExportFormatJSON is the json devices view export format
+	DevicesViewExportFormatJSON = "json"
+)
+
+// devicesViewExportCSVHeader is the header row of the csv devices view export
+var devicesViewExportCSVHeader = []string{
+	"DeviceUUID", "DeviceName", "ImageName", "ImageVersion", "Status", "UpdateAvailable",
+	"DispatcherStatus", "DispatcherReason", "GroupName", "DeviceGroups", "LastSeen",
+}
+
+// devicesViewExportWriter writes the exported devices view to the response, a batch at a time
+type devicesViewExportWriter interface {
+	// Begin writes the content headers and what precedes the devices
+	Begin() error
+	// Write writes a batch of devices
+	Write(devices []models.DeviceView) error
+	// End writes what follows the devices
+	End() error
+}
+
+// csvFormulaPrefixes are the first characters making a spreadsheet evaluate a cell as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvSafeCell returns the cell value quoted with a leading ' when a spreadsheet would evaluate it as a formula, as the
+// devices names and groups names are user input
+func csvSafeCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvDevicesViewExportWriter writes the devices view as a csv with a header row
+type csvDevicesViewExportWriter struct {
+	w   http.ResponseWriter
+	csv *csv.Writer
+}
+
+func (e *csvDevicesViewExportWriter) Begin() error {
+	e.csv = csv.NewWriter(e.w)
+	return e.csv.Write(devicesViewExportCSVHeader)
+}
+
+func (e *csvDevicesViewExportWriter) Write(devices []models.DeviceView) error {
+	for _, device := range devices {
+		groupsNames := make([]string, 0, len(device.DeviceGroups))
+		for _, group := range device.DeviceGroups {
+			groupsNames = append(groupsNames, group.Name)
+		}
+		var lastSeen string
+		if device.LastSeen.Valid {
+			lastSeen = device.LastSeen.Time.UTC().Format(time.RFC3339)
+		}
+		var imageVersion string
+		if device.ImageVersion != 0 {
+			imageVersion = strconv.Itoa(device.ImageVersion)
+		}
+		row := []string{
+			device.DeviceUUID, device.DeviceName, device.ImageName, imageVersion, device.Status,
+			strconv.FormatBool(device.UpdateAvailable), device.DispatcherStatus, device.DispatcherReason,
+			device.GroupName, strings.Join(groupsNames, ";"), lastSeen,
+		}
+		for i, cell := range row {
+			row[i] = csvSafeCell(cell)
+		}
+		if err := e.csv.Write(row); err != nil {
+			return err
+		}
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func (e *csvDevicesViewExportWriter) End() error {
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// jsonDevicesViewExportWriter writes the devices view as a json array
+type jsonDevicesViewExportWriter struct {
+	w     http.ResponseWriter
+	count int
+}
+
+func (e *jsonDevicesViewExportWriter) Begin() error {
+	_, err := e.w.Write([]byte("["))
+	return err
+}
+
+func (e *jsonDevicesViewExportWriter) Write(devices []models.DeviceView) error {
+	for _, device := range devices {
+		data, err := json.Marshal(device)
+		if err != nil {
+			return err
+		}
+		if e.count > 0 {
+			data = append([]byte(","), data...)
+		}
+		if _, err := e.w.Write(data); err != nil {
+			return err
+		}
+		e.count++
+	}
+	return nil
+}
+
+func (e *jsonDevicesViewExportWriter) End() error {
+	_, err := e.w.Write([]byte("]\n"))
+	return err
+}
+
+// ExportDevicesView streams all the devices view matching the filters as csv or json
+// @ID           ExportDevicesView
+// @Summary      Export all the devices view.
+// @Description  Streams all the devices matching the devices view filters as a csv or json attachment, with their image name and version, status, update availability, dispatcher status, groups and last seen time, in the devices creation order.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      text/csv
+// @Produce      json
+// @Param	 format             query string	false "field: the export format, csv or json. Default is csv."
+// @Param	 name               query string 	false "field: filter by name"
+// @Param	 update_available   query boolean	false "field: filter by update_available"
+// @Param	 uuid               query string	false "field: filter by uuid"
+// @Param	 created_at         query string	false "field: filter by creation date"
+// @Param	 image_id           query int   	false "field: filter by image id"
+// @Param	 arch               query string	false "field: filter by system profile arch"
+// @Param	 os_release         query string	false "field: filter by system profile os release"
+// @Param	 os_kernel_version  query string	false "field: filter by system profile kernel version"
+// @Param	 cpu_model          query string	false "field: filter by system profile cpu model"
+// @Param	 number_of_cpus     query int   	false "field: filter by system profile number of cpus"
+// @Param	 system_memory_bytes query int   	false "field: filter by system profile memory size in bytes"
+// @Param	 host_type          query string	false "field: filter by system profile host type"
+// @Param	 network_interfaces query string	false "field: filter by network interface name, mac address or ip address"
+// @Param	 tag                query string	false "field: filter by inventory tag in the namespace/key=value format, repeat to filter by all the tags"
+// @Param	 rpm_ostree_deployments query string	false "field: filter by rpm-ostree deployment checksum, origin or version"
+// @Param	 disconnected_hours query int   	false "field: filter the devices disconnected from rhc for more than the hours"
+// @Success      200  {array}  models.DeviceViewAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /devices/devicesview/export [get]
+func ExportDevicesView(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = DevicesViewExportFormatCSV
+	}
+	var exportWriter devicesViewExportWriter
+	var contentType string
+	switch format {
+	case DevicesViewExportFormatCSV:
+		exportWriter = &csvDevicesViewExportWriter{w: w}
+		contentType = "text/csv; charset=UTF-8"
+	case DevicesViewExportFormatJSON:
+		exportWriter = &jsonDevicesViewExportWriter{w: w}
+		contentType = "application/json; charset=UTF-8"
+	default:
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(
+			fmt.Sprintf("%s is not a valid value for format. format must be %s or %s", format, DevicesViewExportFormatCSV, DevicesViewExportFormatJSON)))
+		return
+	}
+	// the devices are exported in their id order, to read them by batches of the next ids
+	tx, err := devicesViewFilteredDB(w, r, devicesMatchFilters, utility.EnforceEdgeGroups(orgID))
+	if err != nil {
+		// logs and response handled by devicesViewFilteredDB
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	// the server write timeout does not apply to exports of large fleets
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !goErrors.Is(err, http.ErrNotSupported) {
+		ctxServices.Log.WithField("error", err.Error()).Error("failed to clear the devices export write deadline")
+	}
+	// the response starts with the first batch, so that an error before it is still reported with its status code
+	var started bool
+	begin := func() error {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"devices.%s\"", format))
+		w.WriteHeader(http.StatusOK)
+		return exportWriter.Begin()
+	}
+	err = ctxServices.DeviceService.ExportDevicesView(tx, services.DefaultDevicesViewExportBatchSize, func(devices []models.DeviceView) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		if err := exportWriter.Write(devices); err != nil {
+			return err
+		}
+		if err := controller.Flush(); err != nil && !goErrors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("failed to export the devices view")
+		if !started {
+			apiError := errors.NewInternalServerError()
+			apiError.SetTitle("failed exporting devices view")
+			respondWithAPIError(w, ctxServices.Log, apiError)
+		}
+		return
+	}
+	if !started {
+		if err := begin(); err != nil {
+			ctxServices.Log.WithField("error", err.Error()).Error("failed to export the devices view")
+			return
+		}
+	}
+	if err := exportWriter.End(); err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("failed to export the devices view")
+	}
+}
@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestExportDevicesView(t *testing.T) {
+	lastSeen := time.Date(2023, 5, 2, 10, 30, 0, 0, time.UTC)
+	batches := [][]models.DeviceView{
+		{
+			{
+				DeviceUUID: "device-1", DeviceName: "device-1", ImageName: "image", ImageVersion: 2,
+				Status: models.DeviceViewStatusRunning, UpdateAvailable: true, GroupName: "inventory-group",
+				DeviceGroups: []models.DeviceDeviceGroup{{ID: 1, Name: "group-1"}, {ID: 2, Name: "group-2"}},
+				LastSeen:     models.EdgeAPITime{Time: lastSeen, Valid: true},
+			},
+			{DeviceUUID: "device-2", DeviceName: "device-2", ImageName: "image", ImageVersion: 1, Status: models.DeviceViewStatusStale},
+		},
+		{
+			{DeviceUUID: "device-3", DeviceName: "device-3", ImageName: "image", ImageVersion: 1, Status: models.DeviceViewStatusUpdating,
+				DispatcherStatus: models.DispatchRecordStatusRunning},
+		},
+	}
+
+	tt := []struct {
+		name                string
+		format              string
+		callService         bool
+		returnError         error
+		expectedHTTPStatus  int
+		expectedContentType string
+	}{
+		{
+			name:                "should export the devices as csv by default",
+			callService:         true,
+			expectedHTTPStatus:  http.StatusOK,
+			expectedContentType: "text/csv; charset=UTF-8",
+		},
+		{
+			name:                "should export the devices as json",
+			format:              DevicesViewExportFormatJSON,
+			callService:         true,
+			expectedHTTPStatus:  http.StatusOK,
+			expectedContentType: "application/json; charset=UTF-8",
+		},
+		{
+			name:               "should return bad request when the format is unknown",
+			format:             "xlsx",
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return internal server error when the export fails before the first batch",
+			format:             DevicesViewExportFormatCSV,
+			callService:        true,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		t.Run(te.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/devicesview/export", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if te.format != "" {
+				query := req.URL.Query()
+				query.Set("format", te.format)
+				req.URL.RawQuery = query.Encode()
+			}
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockDeviceService := mock_services.NewMockDeviceServiceInterface(ctrl)
+			if te.callService {
+				mockDeviceService.EXPECT().ExportDevicesView(gomock.Any(), services.DefaultDevicesViewExportBatchSize, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ int, writeBatch func([]models.DeviceView) error) error {
+						if te.returnError != nil {
+							return te.returnError
+						}
+						for _, batch := range batches {
+							if err := writeBatch(batch); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+			}
+			ctx := dependencies.ContextWithServices(req.Context(), &dependencies.EdgeAPIServices{
+				DeviceService: mockDeviceService,
+				Log:           log.NewEntry(log.StandardLogger()),
+			})
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(ExportDevicesView)
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			if status := rr.Code; status != te.expectedHTTPStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, te.expectedHTTPStatus)
+			}
+			if te.expectedHTTPStatus != http.StatusOK {
+				return
+			}
+			if contentType := rr.Header().Get("Content-Type"); contentType != te.expectedContentType {
+				t.Errorf("handler returned wrong content type: got %q want %q", contentType, te.expectedContentType)
+			}
+
+			if te.format == DevicesViewExportFormatJSON {
+				var devices []models.DeviceView
+				if err := json.NewDecoder(rr.Body).Decode(&devices); err != nil {
+					t.Fatal(err)
+				}
+				if len(devices) != 3 || devices[0].ImageVersion != 2 || devices[2].DeviceUUID != "device-3" {
+					t.Errorf("handler returned wrong devices: got %+v", devices)
+				}
+				return
+			}
+			records, err := csv.NewReader(rr.Body).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 4 {
+				t.Fatalf("handler returned wrong number of csv rows: got %d want 4", len(records))
+			}
+			expectedRow := []string{"device-1", "device-1", "image", "2", models.DeviceViewStatusRunning, "true", "", "",
+				"inventory-group", "group-1;group-2", "2023-05-02T10:30:00Z"}
+			for i, value := range expectedRow {
+				if records[1][i] != value {
+					t.Errorf("handler returned wrong csv value for %s: got %q want %q", records[0][i], records[1][i], value)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVSafeCell(t *testing.T) {
+	tt := []struct {
+		value    string
+		expected string
+	}{
+		{value: "", expected: ""},
+		{value: "device-1", expected: "device-1"},
+		{value: "=HYPERLINK(\"http://example.com\")", expected: "'=HYPERLINK(\"http://example.com\")"},
+		{value: "+1", expected: "'+1"},
+		{value: "-1", expected: "'-1"},
+		{value: "@SUM(A1)", expected: "'@SUM(A1)"},
+		{value: "\t=1", expected: "'\t=1"},
+		{value: "\r=1", expected: "'\r=1"},
+		{value: "device=1", expected: "device=1"},
+	}
+	for _, te := range tt {
+		if cell := csvSafeCell(te.value); cell != te.expected {
+			t.Errorf("csvSafeCell(%q) returned %q want %q", te.value, cell, te.expected)
+		}
+	}
+}
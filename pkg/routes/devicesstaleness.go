@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// GetDevicesStalenessSettings returns the devices staleness thresholds of the org
+// @Summary      Returns the devices staleness thresholds
+// @ID           GetDevicesStalenessSettings
+// @Description  Returns the hours after which a device not seen becomes stale, then is culled. The defaults are returned when the org has not set its own.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} models.DeviceStalenessSettingsAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /devices/staleness [get]
+func GetDevicesStalenessSettings(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	settings, err := ctxServices.DeviceStalenessService.GetStalenessSettings(orgID)
+	if err != nil {
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed getting devices staleness settings")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, settings)
+}
+
+// SetDevicesStalenessSettings creates or replaces the devices staleness thresholds of the org
+// @Summary      Sets the devices staleness thresholds
+// @ID           SetDevicesStalenessSettings
+// @Description  Sets the hours after which a device not seen becomes stale and is excluded from the updates by default, and the hours after which it is removed from its groups and deleted. A device seen again is restored.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        body	body	models.SetDeviceStalenessSettingsAPI	true	"request body"
+// @Success      200 {object} models.DeviceStalenessSettingsAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /devices/staleness [put]
+func SetDevicesStalenessSettings(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	var settings models.DeviceStalenessSettings
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &settings); err != nil {
+		return
+	}
+	if err := settings.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	result, err := ctxServices.DeviceStalenessService.SetStalenessSettings(orgID, &settings)
+	if err != nil {
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed setting devices staleness settings")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, result)
+}
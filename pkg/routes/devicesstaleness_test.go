@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestGetDevicesStalenessSettings(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDeviceStalenessService := mock_services.NewMockDeviceStalenessServiceInterface(ctrl)
+	mockDeviceStalenessService.EXPECT().GetStalenessSettings(common.DefaultOrgID).Return(
+		&models.DeviceStalenessSettings{OrgID: common.DefaultOrgID, StaleHours: 26, CulledHours: 336}, nil)
+	ctx := dependencies.ContextWithServices(req.Context(), &dependencies.EdgeAPIServices{
+		DeviceStalenessService: mockDeviceStalenessService,
+		Log:                    log.NewEntry(log.StandardLogger()),
+	})
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(GetDevicesStalenessSettings)
+	handler.ServeHTTP(rr, req.WithContext(ctx))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var settings models.DeviceStalenessSettings
+	if err := json.NewDecoder(rr.Body).Decode(&settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.StaleHours != 26 || settings.CulledHours != 336 {
+		t.Errorf("handler returned wrong settings: got %d/%d want 26/336", settings.StaleHours, settings.CulledHours)
+	}
+}
+
+func TestSetDevicesStalenessSettings(t *testing.T) {
+	tt := []struct {
+		name               string
+		settings           models.DeviceStalenessSettings
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should set the devices staleness settings",
+			settings:           models.DeviceStalenessSettings{StaleHours: 24, CulledHours: 168},
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when the devices are culled before being stale",
+			settings:           models.DeviceStalenessSettings{StaleHours: 168, CulledHours: 24},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return internal server error",
+			settings:           models.DeviceStalenessSettings{StaleHours: 24, CulledHours: 168},
+			callService:        true,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		body, err := json.Marshal(te.settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPut, "/", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceStalenessService := mock_services.NewMockDeviceStalenessServiceInterface(ctrl)
+		if te.callService {
+			mockDeviceStalenessService.EXPECT().SetStalenessSettings(common.DefaultOrgID, gomock.Any()).DoAndReturn(
+				func(orgID string, settings *models.DeviceStalenessSettings) (*models.DeviceStalenessSettings, error) {
+					if te.returnError != nil {
+						return nil, te.returnError
+					}
+					return settings, nil
+				})
+		}
+		ctx := dependencies.ContextWithServices(req.Context(), &dependencies.EdgeAPIServices{
+			DeviceStalenessService: mockDeviceStalenessService,
+			Log:                    log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(SetDevicesStalenessSettings)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+// GetDeviceDesiredState returns the update manifest of the device pulling its updates
+// @Summary      Returns the desired state of a device
+// @ID           GetDeviceDesiredState
+// @Description  Returns the desired ref, remote url, commit hash, gpg settings and maintenance window of the device authenticated with its certificate. The desired state is driven by the pending update transaction of the device, then by the desired image of its device group.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} models.DeviceUpdateManifestAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The request is not authenticated with a device certificate."
+// @Failure      404 {object} errors.NotFound "The device was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device/desired-state [get]
+func GetDeviceDesiredState(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	subscriptionManagerID := readDeviceSubscriptionManagerID(w, r)
+	if subscriptionManagerID == "" {
+		// logs and response handled by readDeviceSubscriptionManagerID
+		return
+	}
+	manifest, err := ctxServices.DeviceService.GetDeviceUpdateManifest(orgID, subscriptionManagerID)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceNotFoundError:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed getting device desired state")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, manifest)
+}
+
+// SetDeviceUpdateResult records the result of an update transaction applied by the device pulling its updates
+// @Summary      Reports the result of a device update
+// @ID           SetDeviceUpdateResult
+// @Description  Reports whether the device authenticated with its certificate applied the update transaction of its desired state. The update transaction status is set from the result.
+// @Tags         Devices (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        body	body	models.DeviceUpdateResultAPI	true	"request body"
+// @Success      200
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      403 {object} errors.Forbidden "The request is not authenticated with a device certificate."
+// @Failure      404 {object} errors.NotFound "The device or update transaction was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device/update-result [post]
+func SetDeviceUpdateResult(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	subscriptionManagerID := readDeviceSubscriptionManagerID(w, r)
+	if subscriptionManagerID == "" {
+		// logs and response handled by readDeviceSubscriptionManagerID
+		return
+	}
+	var updateResult models.DeviceUpdateResult
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &updateResult); err != nil {
+		return
+	}
+	if err := updateResult.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	if err := ctxServices.DeviceService.SetDeviceUpdateResult(orgID, subscriptionManagerID, &updateResult); err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.DeviceNotFoundError, *services.UpdateNotFoundError:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed setting device update result")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	testHelpers "github.com/redhatinsights/edge-api/internal/testing"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestGetDeviceDesiredState(t *testing.T) {
+	orgID := faker.UUIDHyphenated()
+	subscriptionManagerID := faker.UUIDHyphenated()
+
+	initialAuth := config.Get().Auth
+	defer func() {
+		config.Get().Auth = initialAuth
+	}()
+	// enable authentication in config to use the identity type
+	config.Get().Auth = true
+
+	tt := []struct {
+		name               string
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should return the device desired state", expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found", returnError: new(services.DeviceNotFoundError), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodGet, "/desired-state", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceService := mock_services.NewMockDeviceServiceInterface(ctrl)
+		var manifest *models.DeviceUpdateManifest
+		if te.returnError == nil {
+			manifest = &models.DeviceUpdateManifest{DeviceUUID: faker.UUIDHyphenated(), UpToDate: true}
+		}
+		mockDeviceService.EXPECT().GetDeviceUpdateManifest(orgID, subscriptionManagerID).Return(manifest, te.returnError)
+		ctx := testHelpers.WithSystemIdentity(req.Context(), orgID, subscriptionManagerID)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceService: mockDeviceService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(GetDeviceDesiredState)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
+func TestSetDeviceUpdateResult(t *testing.T) {
+	orgID := faker.UUIDHyphenated()
+	subscriptionManagerID := faker.UUIDHyphenated()
+
+	initialAuth := config.Get().Auth
+	defer func() {
+		config.Get().Auth = initialAuth
+	}()
+	// enable authentication in config to use the identity type
+	config.Get().Auth = true
+
+	tt := []struct {
+		name               string
+		updateResult       models.DeviceUpdateResult
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should set the device update result",
+			updateResult:       models.DeviceUpdateResult{UpdateTransactionID: 1026, Status: models.DeviceUpdateResultStatusSuccess},
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when the status is unknown",
+			updateResult:       models.DeviceUpdateResult{UpdateTransactionID: 1026, Status: "DONE"},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return not found when the update is not of the device",
+			updateResult:       models.DeviceUpdateResult{UpdateTransactionID: 1026, Status: models.DeviceUpdateResultStatusFailure},
+			callService:        true,
+			returnError:        new(services.UpdateNotFoundError),
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, te := range tt {
+		body, err := json.Marshal(te.updateResult)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "/update-result", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeviceService := mock_services.NewMockDeviceServiceInterface(ctrl)
+		if te.callService {
+			mockDeviceService.EXPECT().SetDeviceUpdateResult(orgID, subscriptionManagerID, &te.updateResult).Return(te.returnError)
+		}
+		ctx := testHelpers.WithSystemIdentity(req.Context(), orgID, subscriptionManagerID)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			DeviceService: mockDeviceService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(SetDeviceUpdateResult)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"encoding/json"
+	goErrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatusEventEnd is the server-sent event sent when no more status changes are expected
+const StatusEventEnd = "end"
+
+const (
+	// defaultEventsStreamInterval is the status events polling interval in seconds used when the configured one is not positive
+	defaultEventsStreamInterval = 2
+	// defaultEventsStreamTimeout is the events stream timeout in seconds used when the configured one is not positive
+	defaultEventsStreamTimeout = 600
+)
+
+// GetImageStatusEvents streams the image, commit and installer status changes as server-sent events
+// @Summary      Stream the image build status changes
+// @ID           GetImageStatusEvents
+// @Description  Stream the image, commit and installer status changes as server-sent events until the image build completes
+// @Tags         Images
+// @Produce      text/event-stream
+// @Param        imageId	path	int	true	"Image Identifier"	example(1234)
+// @Success      200 {object} models.StatusEvent
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "The image was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /images/{imageId}/events [get]
+func GetImageStatusEvents(w http.ResponseWriter, r *http.Request) {
+	image := getImage(w, r)
+	if image == nil {
+		return
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	logger := ctxServices.Log.WithField("image_id", image.ID)
+	streamStatusEvents(w, r, logger, func() (*models.StatusEvents, error) {
+		return ctxServices.StatusEventsService.GetImageStatusEvents(image.OrgID, image.ID)
+	})
+}
+
+// GetUpdateStatusEvents streams the update and devices dispatch records status changes as server-sent events
+// @Summary      Stream the update status changes
+// @ID           GetUpdateStatusEvents
+// @Description  Stream the update and devices dispatch records status changes as server-sent events until the update completes
+// @Tags         Updates (Systems)
+// @Produce      text/event-stream
+// @Param        updateID	path	int	true	"a unique ID to identify the update"	example(1042)
+// @Success      200 {object} models.StatusEvent
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "The update was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /updates/{updateID}/events [get]
+func GetUpdateStatusEvents(w http.ResponseWriter, r *http.Request) {
+	update := getUpdate(w, r)
+	if update == nil {
+		return
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	logger := ctxServices.Log.WithField("update_id", update.ID)
+	streamStatusEvents(w, r, logger, func() (*models.StatusEvents, error) {
+		return ctxServices.StatusEventsService.GetUpdateStatusEvents(update.OrgID, update.ID)
+	})
+}
+
+// streamStatusEvents polls the status events and sends the changed ones to the client,
+// the stream ends when the status events are completed, the client disconnects or the stream times out
+func streamStatusEvents(w http.ResponseWriter, r *http.Request, logger log.FieldLogger, getStatusEvents func() (*models.StatusEvents, error)) {
+	statusEvents, err := getStatusEvents()
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.ImageNotFoundError, *services.UpdateNotFoundError:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed getting status events")
+		}
+		respondWithAPIError(w, logger, apiError)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	// the server write timeout does not apply to long-lived streams
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !goErrors.Is(err, http.ErrNotSupported) {
+		logger.WithField("error", err.Error()).Error("failed to clear the events stream write deadline")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	cfg := config.Get()
+	interval := cfg.EventsStreamInterval
+	if interval <= 0 {
+		interval = defaultEventsStreamInterval
+	}
+	streamTimeout := cfg.EventsStreamTimeout
+	if streamTimeout <= 0 {
+		streamTimeout = defaultEventsStreamTimeout
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	timeout := time.After(time.Duration(streamTimeout) * time.Second)
+
+	sent := make(map[string]models.StatusEvent)
+	for {
+		for _, event := range statusEvents.Events {
+			if previous, ok := sent[event.Key()]; ok && previous == event {
+				continue
+			}
+			if err := writeStatusEvent(w, event.Type, event); err != nil {
+				logger.WithField("error", err.Error()).Info("events stream client is gone")
+				return
+			}
+			sent[event.Key()] = event
+		}
+		if statusEvents.Completed {
+			_ = writeStatusEvent(w, StatusEventEnd, struct{}{})
+			_ = controller.Flush()
+			return
+		}
+		if err := controller.Flush(); err != nil {
+			logger.WithField("error", err.Error()).Error("failed to flush the events stream")
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timeout:
+			logger.Debug("events stream timed out")
+			return
+		case <-ticker.C:
+		}
+
+		if statusEvents, err = getStatusEvents(); err != nil {
+			logger.WithField("error", err.Error()).Error("failed to get status events")
+			return
+		}
+	}
+}
+
+// writeStatusEvent writes a server-sent event with json data
+func writeStatusEvent(w http.ResponseWriter, eventType string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, body)
+	return err
+}
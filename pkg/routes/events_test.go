@@ -0,0 +1,150 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestGetImageStatusEvents(t *testing.T) {
+	tt := []struct {
+		name               string
+		returnEvents       *models.StatusEvents
+		returnError        error
+		expectedHTTPStatus int
+		expectedBody       []string
+	}{
+		{
+			name: "should stream the image status events",
+			returnEvents: &models.StatusEvents{
+				Events: []models.StatusEvent{
+					{Type: models.StatusEventTypeImage, ID: testImage.ID, Status: models.ImageStatusSuccess},
+					{Type: models.StatusEventTypeCommit, ID: 1, Status: models.ImageStatusSuccess},
+				},
+				Completed: true,
+			},
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       []string{"event: image\n", "event: commit\n", "event: end\n"},
+		},
+		{name: "should return not found", returnError: new(services.ImageNotFoundError), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStatusEventsService := mock_services.NewMockStatusEventsServiceInterface(ctrl)
+		mockStatusEventsService.EXPECT().GetImageStatusEvents(testImage.OrgID, testImage.ID).Return(te.returnEvents, te.returnError)
+		ctx := context.WithValue(req.Context(), imageKey, &testImage)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			StatusEventsService: mockStatusEventsService,
+			Log:                 log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(GetImageStatusEvents)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+			continue
+		}
+		if te.returnEvents != nil {
+			if contentType := rr.Header().Get("Content-Type"); contentType != "text/event-stream" {
+				t.Errorf("in %q: wrong content type: got %q", te.name, contentType)
+			}
+			for _, expected := range te.expectedBody {
+				if !strings.Contains(rr.Body.String(), expected) {
+					t.Errorf("in %q: expected %q in body %q", te.name, expected, rr.Body.String())
+				}
+			}
+		}
+	}
+}
+
+func TestGetUpdateStatusEvents(t *testing.T) {
+	update := &testUpdates[0]
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStatusEventsService := mock_services.NewMockStatusEventsServiceInterface(ctrl)
+	mockStatusEventsService.EXPECT().GetUpdateStatusEvents(update.OrgID, update.ID).Return(&models.StatusEvents{
+		Events: []models.StatusEvent{
+			{Type: models.StatusEventTypeUpdate, ID: update.ID, Status: models.UpdateStatusSuccess},
+			{Type: models.StatusEventTypeDispatchRecord, ID: 1, Status: models.DispatchRecordStatusComplete, DeviceUUID: "device-uuid"},
+		},
+		Completed: true,
+	}, nil)
+	ctx := context.WithValue(req.Context(), UpdateContextKey, update)
+	ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+		StatusEventsService: mockStatusEventsService,
+		Log:                 log.NewEntry(log.StandardLogger()),
+	})
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(GetUpdateStatusEvents)
+	handler.ServeHTTP(rr, req.WithContext(ctx))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	for _, expected := range []string{"event: update\n", `"device_uuid":"device-uuid"`, "event: end\n"} {
+		if !strings.Contains(rr.Body.String(), expected) {
+			t.Errorf("expected %q in body %q", expected, rr.Body.String())
+		}
+	}
+}
+
+func TestStatusEventsStreamWithInvalidInterval(t *testing.T) {
+	cfg := config.Get()
+	initialInterval := cfg.EventsStreamInterval
+	cfg.EventsStreamInterval = 0
+	defer func() { cfg.EventsStreamInterval = initialInterval }()
+
+	update := &testUpdates[0]
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStatusEventsService := mock_services.NewMockStatusEventsServiceInterface(ctrl)
+	mockStatusEventsService.EXPECT().GetUpdateStatusEvents(update.OrgID, update.ID).Return(&models.StatusEvents{
+		Events: []models.StatusEvent{{Type: models.StatusEventTypeUpdate, ID: update.ID, Status: models.UpdateStatusBuilding}},
+	}, nil)
+	// the client is gone once the first events are sent
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+	ctx = context.WithValue(ctx, UpdateContextKey, update)
+	ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+		StatusEventsService: mockStatusEventsService,
+		Log:                 log.NewEntry(log.StandardLogger()),
+	})
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(GetUpdateStatusEvents)
+	handler.ServeHTTP(rr, req.WithContext(ctx))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), "event: update\n") {
+		t.Errorf("expected the update event in body %q", rr.Body.String())
+	}
+}
@@ -49,6 +49,7 @@ func MakeImagesRouter(sub chi.Router) {
 		r.Get("/", GetImageByID)
 		r.Get("/details", GetImageDetailsByID)
 		r.Get("/status", GetImageStatusByID)
+		r.Get("/events", GetImageStatusEvents)
 		r.Get("/repo", GetRepoForImage)
 		r.Get("/metadata", GetMetadataForImage)
 		r.Post("/installer", CreateInstallerForImage)
@@ -40,6 +40,13 @@ func MakeImageSetsRouter(sub chi.Router) {
 		r.With(validateFilterParams).With(common.Paginate).Get("/", GetImageSetsByID)
 		r.Delete("/", DeleteImageSet)
 		r.With(common.Paginate).Get("/devices", GetImageSetsDevicesByID)
+		r.Get("/hooks", GetImageSetUpdateHooks)
+		r.Post("/hooks", CreateImageSetUpdateHook)
+		r.Delete("/hooks/{hookID}", DeleteImageSetUpdateHook)
+		r.Route("/versions/{imageID}", func(rVersion chi.Router) {
+			rVersion.Use(ImageSetImageViewCtx)
+			rVersion.Get("/deltas", GetImageSetImageStaticDeltas)
+		})
 	})
 	sub.Route("/view/{imageSetID}", func(r chi.Router) {
 		r.Use(ImageSetViewCtx)
@@ -779,6 +786,47 @@ func GetImageSetImageView(w http.ResponseWriter, r *http.Request) {
 	respondWithJSONBody(w, ctxServices.Log, imageSetImageView)
 }
 
+// GetImageSetImageStaticDeltas returns the static deltas to an image-set version
+// @ID           GetImageSetImageStaticDeltas
+// @Summary      Return the static deltas to an image-set version.
+// @Description  Return the state, size and url of the static deltas generated to the image-set version commit, the static deltas from the commits most run by the devices are generated when the version build succeeds.
+// @Tags         Image-Sets
+// @Accept       json
+// @Produce      json
+// @Param 	 imageSetID path int true "the image set id"
+// @Param	 imageID     path int true "the image id"
+// @Success      200 {object} []models.StaticDeltaStateAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "The Image-Set or Image was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /image-sets/{imageSetID}/versions/{imageID}/deltas [get]
+func GetImageSetImageStaticDeltas(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	imageSet := getContextImageSet(w, r)
+	if imageSet == nil {
+		return
+	}
+	image := getContextImageSetImage(w, r)
+	if image == nil {
+		return
+	}
+
+	staticDeltas, err := ctxServices.StaticDeltaService.GetImageStaticDeltas(imageSet.OrgID, image.ID)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.ImageNotFoundError:
+			apiError = errors.NewNotFound("image-set has no image")
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed to get image static deltas")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, staticDeltas)
+}
+
 // DeleteImageSet deletes an imageset
 // @ID           DeleteImageSet
 // @Summary      Delete Image Set
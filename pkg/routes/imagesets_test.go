@@ -233,6 +233,54 @@ func TestGetImageSetsDevicesByID(t *testing.T) {
 	}
 }
 
+func TestGetImageSetImageStaticDeltas(t *testing.T) {
+	imageSet := &models.ImageSet{Model: models.Model{ID: 1}, OrgID: common.DefaultOrgID}
+	image := &models.Image{Model: models.Model{ID: 2}, OrgID: common.DefaultOrgID}
+	staticDeltas := []models.StaticDeltaState{
+		{OrgID: common.DefaultOrgID, Name: "from-to", FromCommit: "from", ToCommit: "to", Status: models.StaticDeltaStatusReady, Size: 1024},
+	}
+
+	tt := []struct {
+		name           string
+		staticDeltas   []models.StaticDeltaState
+		err            error
+		expectedStatus int
+	}{
+		{name: "should return the image static deltas", staticDeltas: staticDeltas, expectedStatus: http.StatusOK},
+		{name: "should return not found when the image does not exist", err: new(services.ImageNotFoundError), expectedStatus: http.StatusNotFound},
+		{name: "should return internal server error on unknown error", err: errors.NewInternalServerError(), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		t.Run(te.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockStaticDeltaService := mock_services.NewMockStaticDeltaServiceInterface(ctrl)
+			mockStaticDeltaService.EXPECT().GetImageStaticDeltas(common.DefaultOrgID, image.ID).Return(te.staticDeltas, te.err)
+
+			req, err := http.NewRequest("GET", "/deltas", nil)
+			assert.NoError(t, err)
+			ctx := context.WithValue(req.Context(), imageSetKey, imageSet)
+			ctx = context.WithValue(ctx, imageSetImageKey, image)
+			ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+				StaticDeltaService: mockStaticDeltaService,
+				Log:                log.NewEntry(log.StandardLogger()),
+			})
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(GetImageSetImageStaticDeltas).ServeHTTP(rr, req.WithContext(ctx))
+
+			assert.Equal(t, te.expectedStatus, rr.Code)
+			if te.expectedStatus == http.StatusOK {
+				var result []models.StaticDeltaStateAPI
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+				assert.Len(t, result, 1)
+				assert.Equal(t, "from", result[0].FromCommit)
+				assert.Equal(t, int64(1024), result[0].Size)
+			}
+		})
+	}
+}
+
 func TestGetAllImageSetsQueryParameters(t *testing.T) {
 	tt := []struct {
 		name          string
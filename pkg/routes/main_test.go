@@ -72,6 +72,16 @@ func setUp() {
 		&models.DispatchRecord{},
 		&models.ThirdPartyRepo{},
 		&models.DeviceGroup{},
+		&models.UpdateHook{},
+		&models.UpdateRollback{},
+		&models.DeviceEvent{},
+		&models.UpdatePolicy{},
+		&models.UpdatePolicyRun{},
+		&models.DeviceGroupDesiredState{},
+		&models.DeviceStalenessSettings{},
+		&models.UpdateApprovalSettings{},
+		&models.DeviceAction{},
+		&models.DeviceActionPlaybook{},
 	)
 	if err != nil {
 		panic(err)
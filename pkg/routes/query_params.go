@@ -19,12 +19,18 @@ func initalizeQueryParamsArray() map[string][]string {
 		m = make(map[string][]string)
 		m["device-groups"] = []string{"limit", "offset", "name", "created_at", "updated_at", "sort_by"}
 		m["devices"] = []string{"per_page", "page", "order_how", "hostname_or_id", "order_by"}
-		m["devicesview"] = []string{"limit", "offset", "name", "uuid", "update_available", "image_id", "sort_by", "created_at", "groupUUID"}
+		m["devicesview"] = []string{"limit", "offset", "name", "uuid", "update_available", "image_id", "sort_by", "created_at", "groupUUID",
+			"arch", "os_release", "os_kernel_version", "cpu_model", "number_of_cpus", "system_memory_bytes", "host_type",
+			"network_interfaces", "tag", "rpm_ostree_deployments", "disconnected_hours"}
+		m["devicesviewexport"] = []string{"format", "name", "uuid", "update_available", "image_id", "sort_by", "created_at", "groupUUID",
+			"arch", "os_release", "os_kernel_version", "cpu_model", "number_of_cpus", "system_memory_bytes", "host_type",
+			"network_interfaces", "tag", "rpm_ostree_deployments", "disconnected_hours"}
 		m["images"] = []string{"limit", "offset", "status", "name", "distribution", "created_at", "sort_by"}
 		m["image-sets"] = []string{"id", "limit", "offset", "status", "name", "version", "sort_by"}
 		m["thirdpartyrepo"] = []string{"limit", "offset", "name", "created_at", "updated_at", "imageID", "sort_by"}
-		m["updates"] = []string{"limit", "offset", "created_at", "updated_at", "status", "sort_by"}
+		m["updates"] = []string{"limit", "offset", "created_at", "updated_at", "status", "sort_by", "parent_id"}
 		m["imagesetimageview"] = []string{"limit", "offset", "version", "status", "sort_by"}
+		m["device-actions"] = []string{"limit", "offset"}
 	}
 	return m
 }
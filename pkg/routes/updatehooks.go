@@ -0,0 +1,230 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetImageSetUpdateHooks returns the update hooks of an image set
+// @Summary      Returns the update hooks of an image set
+// @ID           GetImageSetUpdateHooks
+// @Description  Returns the scripts that run on the image set devices before and after their updates
+// @Tags         Image-Sets
+// @Accept       json
+// @Produce      json
+// @Param        imageSetID	path	int	true	"Identifier of the ImageSet"
+// @Success      200 {object} []models.UpdateHookAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "image-set was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /image-sets/{imageSetID}/hooks [get]
+func GetImageSetUpdateHooks(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	imageSet := getContextImageSet(w, r)
+	if imageSet == nil {
+		return
+	}
+	hooks, err := ctxServices.UpdateHooksService.GetImageSetHooks(imageSet.OrgID, imageSet.ID)
+	if err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, hooks)
+}
+
+// CreateImageSetUpdateHook creates an update hook for an image set
+// @Summary      Creates an update hook for an image set
+// @ID           CreateImageSetUpdateHook
+// @Description  Creates a script that runs on the image set devices before the update or after the reboot on the update
+// @Tags         Image-Sets
+// @Accept       json
+// @Produce      json
+// @Param        imageSetID	path	int	true	"Identifier of the ImageSet"
+// @Param        body	body	models.CreateUpdateHookAPI	true	"request body"
+// @Success      200 {object} models.UpdateHookAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "image-set was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /image-sets/{imageSetID}/hooks [post]
+func CreateImageSetUpdateHook(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	imageSet := getContextImageSet(w, r)
+	if imageSet == nil {
+		return
+	}
+	var hook models.UpdateHook
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &hook); err != nil {
+		return
+	}
+	hook.ImageSetID = &imageSet.ID
+	hook.DeviceGroupID = nil
+	createUpdateHook(w, ctxServices, imageSet.OrgID, &hook)
+}
+
+// DeleteImageSetUpdateHook deletes an update hook of an image set
+// @Summary      Deletes an update hook of an image set
+// @ID           DeleteImageSetUpdateHook
+// @Description  Deletes an update hook of an image set
+// @Tags         Image-Sets
+// @Accept       json
+// @Produce      json
+// @Param        imageSetID	path	int	true	"Identifier of the ImageSet"
+// @Param        hookID	path	int	true	"Identifier of the update hook"
+// @Success      200
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "update hook was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /image-sets/{imageSetID}/hooks/{hookID} [delete]
+func DeleteImageSetUpdateHook(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	imageSet := getContextImageSet(w, r)
+	if imageSet == nil {
+		return
+	}
+	hookID := readUpdateHookID(w, r, ctxServices.Log)
+	if hookID == 0 {
+		return
+	}
+	err := ctxServices.UpdateHooksService.DeleteImageSetHook(imageSet.OrgID, imageSet.ID, hookID)
+	respondDeleteUpdateHook(w, ctxServices.Log, err)
+}
+
+// GetDeviceGroupUpdateHooks returns the update hooks of a device group
+// @Summary      Returns the update hooks of a device group
+// @ID           GetDeviceGroupUpdateHooks
+// @Description  Returns the scripts that run on the device group devices before and after their updates
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Success      200 {object} []models.UpdateHookAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/hooks [get]
+func GetDeviceGroupUpdateHooks(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	hooks, err := ctxServices.UpdateHooksService.GetDeviceGroupHooks(deviceGroup.OrgID, deviceGroup.ID)
+	if err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, hooks)
+}
+
+// CreateDeviceGroupUpdateHook creates an update hook for a device group
+// @Summary      Creates an update hook for a device group
+// @ID           CreateDeviceGroupUpdateHook
+// @Description  Creates a script that runs on the device group devices before the update or after the reboot on the update
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Param        body	body	models.CreateUpdateHookAPI	true	"request body"
+// @Success      200 {object} models.UpdateHookAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/hooks [post]
+func CreateDeviceGroupUpdateHook(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	var hook models.UpdateHook
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &hook); err != nil {
+		return
+	}
+	hook.DeviceGroupID = &deviceGroup.ID
+	hook.ImageSetID = nil
+	createUpdateHook(w, ctxServices, deviceGroup.OrgID, &hook)
+}
+
+// DeleteDeviceGroupUpdateHook deletes an update hook of a device group
+// @Summary      Deletes an update hook of a device group
+// @ID           DeleteDeviceGroupUpdateHook
+// @Description  Deletes an update hook of a device group
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Param        hookID	path	int	true	"Identifier of the update hook"
+// @Success      200
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "update hook was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/hooks/{hookID} [delete]
+func DeleteDeviceGroupUpdateHook(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	hookID := readUpdateHookID(w, r, ctxServices.Log)
+	if hookID == 0 {
+		return
+	}
+	err := ctxServices.UpdateHooksService.DeleteDeviceGroupHook(deviceGroup.OrgID, deviceGroup.ID, hookID)
+	respondDeleteUpdateHook(w, ctxServices.Log, err)
+}
+
+func createUpdateHook(w http.ResponseWriter, ctxServices *dependencies.EdgeAPIServices, orgID string, hook *models.UpdateHook) {
+	hook.ID = 0
+	hook.OrgID = orgID
+	if err := hook.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	hook, err := ctxServices.UpdateHooksService.CreateHook(orgID, hook)
+	if err != nil {
+		ctxServices.Log.WithField("error", err.Error()).Error("Error creating update hook")
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.ImageSetNotFoundError, *services.DeviceGroupNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed creating update hook")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, hook)
+}
+
+func readUpdateHookID(w http.ResponseWriter, r *http.Request, logger log.FieldLogger) uint {
+	hookID, err := strconv.Atoi(chi.URLParam(r, "hookID"))
+	if err != nil || hookID <= 0 {
+		respondWithAPIError(w, logger, errors.NewBadRequest("hook ID must be a positive integer"))
+		return 0
+	}
+	return uint(hookID)
+}
+
+func respondDeleteUpdateHook(w http.ResponseWriter, logger log.FieldLogger, err error) {
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.UpdateHookNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed deleting update hook")
+		}
+		respondWithAPIError(w, logger, apiError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
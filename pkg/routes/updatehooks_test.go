@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestCreateImageSetUpdateHook(t *testing.T) {
+	imageSet := models.ImageSet{OrgID: "0000000", Name: "image-set"}
+	imageSet.ID = 1024
+
+	tt := []struct {
+		name               string
+		hook               models.UpdateHook
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should create the image set hook",
+			hook:               models.UpdateHook{Name: "drain", Stage: models.UpdateHookStagePre, Script: "podman stop --all"},
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when the hook is invalid",
+			hook:               models.UpdateHook{Name: "drain", Stage: "during", Script: "podman stop --all"},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return internal server error",
+			hook:               models.UpdateHook{Name: "drain", Stage: models.UpdateHookStagePre, Script: "podman stop --all"},
+			callService:        true,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		body, err := json.Marshal(te.hook)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdateHooksService := mock_services.NewMockUpdateHooksServiceInterface(ctrl)
+		if te.callService {
+			mockUpdateHooksService.EXPECT().CreateHook(imageSet.OrgID, gomock.Any()).DoAndReturn(
+				func(orgID string, hook *models.UpdateHook) (*models.UpdateHook, error) {
+					if hook.ImageSetID == nil || *hook.ImageSetID != imageSet.ID || hook.DeviceGroupID != nil {
+						t.Errorf("in %q: hook does not belong to the image set: %v", te.name, hook)
+					}
+					if te.returnError != nil {
+						return nil, te.returnError
+					}
+					return hook, nil
+				})
+		}
+		ctx := context.WithValue(req.Context(), imageSetKey, &imageSet)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdateHooksService: mockUpdateHooksService,
+			Log:                log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(CreateImageSetUpdateHook)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
+func TestDeleteDeviceGroupUpdateHook(t *testing.T) {
+	deviceGroup := models.DeviceGroup{OrgID: "0000000", Name: "group", Type: models.DeviceGroupTypeStatic}
+	deviceGroup.ID = 2048
+
+	tt := []struct {
+		name               string
+		hookID             string
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should delete the device group hook", hookID: "10", callService: true, expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found", hookID: "10", callService: true, returnError: new(services.UpdateHookNotFound), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return bad request when hook id is invalid", hookID: "abc", expectedHTTPStatus: http.StatusBadRequest},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodDelete, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdateHooksService := mock_services.NewMockUpdateHooksServiceInterface(ctrl)
+		if te.callService {
+			mockUpdateHooksService.EXPECT().DeleteDeviceGroupHook(deviceGroup.OrgID, deviceGroup.ID, uint(10)).Return(te.returnError)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("hookID", te.hookID)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = setContextDeviceGroup(ctx, &deviceGroup)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdateHooksService: mockUpdateHooksService,
+			Log:                log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(DeleteDeviceGroupUpdateHook)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
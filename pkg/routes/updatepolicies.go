@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/errors"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetDeviceGroupUpdatePolicy returns the update policy of a device group
+// @Summary      Returns the update policy of a device group
+// @ID           GetDeviceGroupUpdatePolicy
+// @Description  Returns the policy updating automatically the device group devices to the latest successful version of an image set
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Success      200 {object} models.UpdatePolicyAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group update policy was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/update-policy [get]
+func GetDeviceGroupUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	policy, err := ctxServices.UpdatePolicyService.GetDeviceGroupUpdatePolicy(deviceGroup.OrgID, deviceGroup.ID)
+	if err != nil {
+		respondUpdatePolicyError(w, ctxServices.Log, err, "failed getting device group update policy")
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, policy)
+}
+
+// SetDeviceGroupUpdatePolicy creates or replaces the update policy of a device group
+// @Summary      Creates or replaces the update policy of a device group
+// @ID           SetDeviceGroupUpdatePolicy
+// @Description  Makes the device group devices update automatically to the latest successful version of an image set, as soon as the version succeeds, in the policy maintenance window or after a soak delay
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Param        body	body	models.SetUpdatePolicyAPI	true	"request body"
+// @Success      200 {object} models.UpdatePolicyAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "image-set was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/update-policy [put]
+func SetDeviceGroupUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	var policy models.UpdatePolicy
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &policy); err != nil {
+		return
+	}
+	if err := policy.ValidateRequest(); err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest(err.Error()))
+		return
+	}
+	result, err := ctxServices.UpdatePolicyService.SetDeviceGroupUpdatePolicy(deviceGroup.OrgID, deviceGroup.ID, &policy)
+	if err != nil {
+		respondUpdatePolicyError(w, ctxServices.Log, err, "failed setting device group update policy")
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, result)
+}
+
+// DeleteDeviceGroupUpdatePolicy deletes the update policy of a device group
+// @Summary      Deletes the update policy of a device group
+// @ID           DeleteDeviceGroupUpdatePolicy
+// @Description  Deletes the update policy of a device group and cancels its scheduled updates
+// @Tags         Device Groups
+// @Accept       json
+// @Produce      json
+// @Param        ID	path	int	true	"device group ID"
+// @Success      200
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      404 {object} errors.NotFound "device group update policy was not found."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /device-groups/{ID}/update-policy [delete]
+func DeleteDeviceGroupUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	deviceGroup := getContextDeviceGroup(w, r)
+	if deviceGroup == nil {
+		return
+	}
+	if err := ctxServices.UpdatePolicyService.DeleteDeviceGroupUpdatePolicy(deviceGroup.OrgID, deviceGroup.ID); err != nil {
+		respondUpdatePolicyError(w, ctxServices.Log, err, "failed deleting device group update policy")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func respondUpdatePolicyError(w http.ResponseWriter, logger log.FieldLogger, err error, title string) {
+	logger.WithField("error", err.Error()).Error(title)
+	var apiError errors.APIError
+	switch err.(type) {
+	case *services.UpdatePolicyNotFound, *services.ImageSetNotFoundError:
+		apiError = errors.NewNotFound(err.Error())
+	default:
+		apiError = errors.NewInternalServerError()
+		apiError.SetTitle(title)
+	}
+	respondWithAPIError(w, logger, apiError)
+}
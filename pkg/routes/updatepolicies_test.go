@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/dependencies"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+func TestSetDeviceGroupUpdatePolicy(t *testing.T) {
+	deviceGroup := models.DeviceGroup{OrgID: "0000000", Name: "group", Type: models.DeviceGroupTypeStatic}
+	deviceGroup.ID = 2048
+
+	tt := []struct {
+		name               string
+		policy             models.UpdatePolicy
+		callService        bool
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should set the device group update policy",
+			policy:             models.UpdatePolicy{ImageSetID: 1024, Schedule: models.UpdatePolicyScheduleWindow, WindowStart: "22:00", WindowEnd: "04:00"},
+			callService:        true,
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "should return bad request when the policy is invalid",
+			policy:             models.UpdatePolicy{ImageSetID: 1024, Schedule: models.UpdatePolicyScheduleSoak},
+			expectedHTTPStatus: http.StatusBadRequest,
+		},
+		{
+			name:               "should return not found when the image set does not exist",
+			policy:             models.UpdatePolicy{ImageSetID: 1024, Schedule: models.UpdatePolicyScheduleImmediate},
+			callService:        true,
+			returnError:        new(services.ImageSetNotFoundError),
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+		{
+			name:               "should return internal server error",
+			policy:             models.UpdatePolicy{ImageSetID: 1024, Schedule: models.UpdatePolicyScheduleImmediate},
+			callService:        true,
+			returnError:        errors.New("expected error"),
+			expectedHTTPStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, te := range tt {
+		body, err := json.Marshal(te.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPut, "/", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdatePolicyService := mock_services.NewMockUpdatePolicyServiceInterface(ctrl)
+		if te.callService {
+			mockUpdatePolicyService.EXPECT().SetDeviceGroupUpdatePolicy(deviceGroup.OrgID, deviceGroup.ID, gomock.Any()).DoAndReturn(
+				func(orgID string, deviceGroupID uint, policy *models.UpdatePolicy) (*models.UpdatePolicy, error) {
+					if te.returnError != nil {
+						return nil, te.returnError
+					}
+					return policy, nil
+				})
+		}
+		ctx := setContextDeviceGroup(req.Context(), &deviceGroup)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdatePolicyService: mockUpdatePolicyService,
+			Log:                 log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(SetDeviceGroupUpdatePolicy)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
+func TestDeleteDeviceGroupUpdatePolicy(t *testing.T) {
+	deviceGroup := models.DeviceGroup{OrgID: "0000000", Name: "group", Type: models.DeviceGroupTypeStatic}
+	deviceGroup.ID = 2048
+
+	tt := []struct {
+		name               string
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should delete the device group update policy", expectedHTTPStatus: http.StatusOK},
+		{name: "should return not found", returnError: new(services.UpdatePolicyNotFound), expectedHTTPStatus: http.StatusNotFound},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodDelete, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdatePolicyService := mock_services.NewMockUpdatePolicyServiceInterface(ctrl)
+		mockUpdatePolicyService.EXPECT().DeleteDeviceGroupUpdatePolicy(deviceGroup.OrgID, deviceGroup.ID).Return(te.returnError)
+		ctx := setContextDeviceGroup(req.Context(), &deviceGroup)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdatePolicyService: mockUpdatePolicyService,
+			Log:                 log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(DeleteDeviceGroupUpdatePolicy)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
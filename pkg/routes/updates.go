@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/redhatinsights/edge-api/pkg/clients/inventorygroups"
+	"github.com/redhatinsights/edge-api/pkg/clients/rbac"
 	"github.com/redhatinsights/edge-api/pkg/db"
 	"github.com/redhatinsights/edge-api/pkg/dependencies"
 	"github.com/redhatinsights/edge-api/pkg/errors"
@@ -28,11 +29,19 @@ func MakeUpdatesRouter(sub chi.Router) {
 	sub.With(ValidateQueryParams("updates")).With(common.Paginate).With(ValidateGetUpdatesFilterParams).Get("/", GetUpdates)
 	sub.Post("/", AddUpdate)
 	sub.Post("/validate", PostValidateUpdate)
+	sub.Post("/preview", PostPreviewUpdate)
+	sub.Get("/approval-settings", GetUpdateApprovalSettings)
+	sub.Put("/approval-settings", SetUpdateApprovalSettings)
 	sub.Route("/{updateID}", func(r chi.Router) {
 		r.Use(UpdateCtx)
 		r.Get("/", GetUpdateByID)
 		r.Get("/update-playbook.yml", GetUpdatePlaybook)
 		r.Get("/notify", SendNotificationForDevice) // TMP ROUTE TO SEND THE NOTIFICATION
+		r.Post("/retry-failed", RetryUpdateFailedDevices)
+		r.Get("/events", GetUpdateStatusEvents)
+		r.Get("/rollbacks", GetUpdateRollbacks)
+		r.Post("/approve", ApproveUpdate)
+		r.Post("/reject", RejectUpdate)
 	})
 	sub.Route("/inventory-groups/{GroupUUID}", func(r chi.Router) {
 		r.Use(InventoryGroupsCtx)
@@ -179,6 +188,7 @@ func GetUpdatePlaybook(w http.ResponseWriter, r *http.Request) {
 // @Param        status query string false "field: filter by status" example(BUILDING)
 // @Param        created_at query string false "field: filter by creation date" example(2023-05-03)
 // @Param        updated_at query string false "field: filter by update date" example(2023-05-04)
+// @Param        parent_id query int false "field: filter by the update retried by the updates" example(1025)
 // @Success      200 {object} []models.UpdateAPI	"List of devices updates"
 // @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed."
 // @Failure      500 {object} errors.InternalServerError	"There was an internal server error."
@@ -204,6 +214,42 @@ func GetUpdates(w http.ResponseWriter, r *http.Request) {
 	respondWithJSONBody(w, services.Log, &updates)
 }
 
+// setDevicesUpdateTaggedDevices sets the devices of the update to the org devices having all the inventory tags,
+// when the update targets tags instead of devices
+func setDevicesUpdateTaggedDevices(w http.ResponseWriter, r *http.Request, orgID string, devicesUpdate *models.DevicesUpdate) error {
+	if len(devicesUpdate.Tags) == 0 {
+		return nil
+	}
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	if len(devicesUpdate.DevicesUUID) > 0 {
+		apiError := errors.NewBadRequest("DeviceUUID and Tags can not be supplied together")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return apiError
+	}
+	var devicesUUID []string
+	query, err := ctxServices.DeviceService.FilterTaggedDevices(
+		db.Org(orgID, "").Model(&models.Device{}).Where("image_id > 0"), devicesUpdate.Tags,
+	)
+	if err == nil {
+		err = query.Order("uuid").Pluck("uuid", &devicesUUID).Error
+	}
+	if err != nil {
+		ctxServices.Log.WithFields(log.Fields{"error": err.Error(), "tags": devicesUpdate.Tags}).Error("failed to get tagged devices")
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed to get tagged devices")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return err
+	}
+	if len(devicesUUID) == 0 {
+		apiError := errors.NewNotFound("no devices found with the tags")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return apiError
+	}
+	ctxServices.Log.WithFields(log.Fields{"tags": devicesUpdate.Tags, "devicesCount": len(devicesUUID)}).Debug("update tags resolved to devices")
+	devicesUpdate.DevicesUUID = devicesUUID
+	return nil
+}
+
 func updateFromHTTP(w http.ResponseWriter, r *http.Request) *[]models.UpdateTransaction {
 	ctxServices := dependencies.ServicesFromContext(r.Context())
 	ctxServices.Log.Info("Update is being created")
@@ -217,9 +263,12 @@ func updateFromHTTP(w http.ResponseWriter, r *http.Request) *[]models.UpdateTran
 	}
 	ctxServices.Log.WithField("updateJSON", devicesUpdate).Debug("Update JSON received")
 
-	// TODO: Implement update by tag - Add validation per tag
+	if err := setDevicesUpdateTaggedDevices(w, r, orgID, &devicesUpdate); err != nil {
+		// logs and response handled by setDevicesUpdateTaggedDevices
+		return nil
+	}
 	if devicesUpdate.DevicesUUID == nil {
-		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest("DeviceUUID required."))
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest("DeviceUUID or Tags required."))
 		return nil
 	}
 
@@ -248,6 +297,27 @@ func updateFromHTTP(w http.ResponseWriter, r *http.Request) *[]models.UpdateTran
 		respondWithAPIError(w, ctxServices.Log, errors.NewNotFound("some devices where not found"))
 		return nil
 	}
+	if !validateDevicesDeviceGroupsRbac(w, r, orgID, rbac.AccessTypeWrite, devicesUUID) {
+		// logs and response handled by validateDevicesDeviceGroupsRbac
+		return nil
+	}
+	if !devicesUpdate.IncludeStale {
+		targetDevicesUUID, err := services.ExcludeStaleDevices(orgID, devicesUUID)
+		if err != nil {
+			ctxServices.Log.WithField("error", err.Error()).Error("failed to exclude stale devices")
+			respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+			return nil
+		}
+		if len(targetDevicesUUID) == 0 {
+			respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest("all the devices are stale, set IncludeStale to update them"))
+			return nil
+		}
+		if len(targetDevicesUUID) != len(devicesUUID) {
+			ctxServices.Log.WithField("staleDevicesCount", len(devicesUUID)-len(targetDevicesUUID)).Info("stale devices excluded from the update")
+		}
+		devicesUUID = targetDevicesUUID
+		devicesUpdate.DevicesUUID = targetDevicesUUID
+	}
 
 	// get the latest commit for devices
 	var commit *models.Commit
@@ -331,7 +401,7 @@ func updateFromHTTP(w http.ResponseWriter, r *http.Request) *[]models.UpdateTran
 // @Tags         Updates (Systems)
 // @Accept       json
 // @Produce      json
-// @Param        body	body	models.DevicesUpdateAPI	true	"devices uuids to update and optional target commit id"
+// @Param        body	body	models.DevicesUpdateAPI	true	"devices uuids or inventory tags to update and optional target commit id"
 // @Success      200 {object} models.UpdateAPI	"The created device update"
 // @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
 // @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
@@ -354,7 +424,8 @@ func AddUpdate(w http.ResponseWriter, r *http.Request) {
 		update.OrgID = orgID
 		upd = append(upd, update)
 		ctxServices.Log.WithField("updateID", update.ID).Info("UPGRADE: Starting asynchronous update process")
-		if update.Status != models.UpdateStatusDeviceDisconnected {
+		// updates awaiting approval are started once approved
+		if update.Status != models.UpdateStatusDeviceDisconnected && update.Status != models.UpdateStatusAwaitingApproval {
 			ctxServices.UpdateService.CreateUpdateAsync(update.ID)
 		}
 	}
@@ -403,6 +474,96 @@ func getUpdate(w http.ResponseWriter, r *http.Request) *models.UpdateTransaction
 	return update
 }
 
+// getWritableUpdate returns the update of the context when the user has write access to the device groups of all
+// the update devices
+func getWritableUpdate(w http.ResponseWriter, r *http.Request) *models.UpdateTransaction {
+	update := getUpdate(w, r)
+	if update == nil {
+		return nil
+	}
+	devicesUUID := make([]string, 0, len(update.Devices))
+	for _, device := range update.Devices {
+		devicesUUID = append(devicesUUID, device.UUID)
+	}
+	if !validateDevicesDeviceGroupsRbac(w, r, update.OrgID, rbac.AccessTypeWrite, devicesUUID) {
+		// logs and response handled by validateDevicesDeviceGroupsRbac
+		return nil
+	}
+	return update
+}
+
+// RetryUpdateFailedDevices retries an update for its failed devices only
+// @Summary      Retry an update for its failed devices
+// @ID           RetryUpdateFailedDevices
+// @Description  Creates a new update for the devices whose dispatch records failed or timed out. The new update reuses the update commit and repository and is linked to it by ParentID.
+// @Tags         Updates (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        updateID  path  int    true  "a unique ID to identify the update" example(1042)
+// @Success      200 {object} models.UpdateAPI	"The created retry update"
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      403 {object} errors.Forbidden	"The access to the update devices is forbidden"
+// @Failure      404 {object} errors.NotFound	"The requested update was not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /updates/{updateID}/retry-failed [post]
+func RetryUpdateFailedDevices(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	update := getWritableUpdate(w, r)
+	if update == nil {
+		return
+	}
+	retryUpdate, err := ctxServices.UpdateService.RetryUpdateFailedDevices(update.OrgID, update.ID)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.UpdateNotFoundError:
+			apiError = errors.NewNotFound(err.Error())
+		case *services.UpdateHasNoFailedDevices, *services.UpdateRepoNotAvailable:
+			apiError = errors.NewBadRequest(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed to retry update failed devices")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	if retryUpdate.Status != models.UpdateStatusAwaitingApproval {
+		ctxServices.Log.WithFields(log.Fields{"updateID": retryUpdate.ID, "parentID": update.ID}).Info("UPGRADE: Starting asynchronous update retry process")
+		ctxServices.UpdateService.CreateUpdateAsync(retryUpdate.ID)
+	}
+
+	respondWithJSONBody(w, ctxServices.Log, retryUpdate)
+}
+
+// GetUpdateRollbacks returns the devices rollbacks of an update
+// @Summary      Get the devices rollbacks of an update
+// @ID           GetUpdateRollbacks
+// @Description  Returns the devices that rolled back the update to their previous deployment, usually after failing the greenboot health checks.
+// @Tags         Updates (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        updateID  path  int    true  "a unique ID to identify the update" example(1042)
+// @Success      200 {object} []models.UpdateRollbackAPI
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      404 {object} errors.NotFound	"The requested update was not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /updates/{updateID}/rollbacks [get]
+func GetUpdateRollbacks(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	update := getUpdate(w, r)
+	if update == nil {
+		return
+	}
+	rollbacks, err := ctxServices.UpdateService.GetUpdateRollbacks(update.OrgID, update.ID)
+	if err != nil {
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed to get update rollbacks")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, rollbacks)
+}
+
 // SendNotificationForDevice TMP route to validate
 // @Summary      Send a notification for a device update
 // @ID           SendNotificationForDevice
@@ -497,9 +658,108 @@ var updateFilters = common.ComposeFilters(
 		QueryParam: "created_at",
 		DBField:    "update_transactions.created_at",
 	}),
+	// Filter handler for "parent_id"
+	common.IntegerNumberFilterHandler(&common.Filter{
+		QueryParam: "parent_id",
+		DBField:    "update_transactions.parent_id",
+	}),
 	common.SortFilterHandler("update_transactions", "created_at", "DESC"),
 )
 
+// PostPreviewUpdate returns a dry-run of a devices update
+// @Summary      Preview a devices update
+// @ID           PostPreviewUpdate
+// @Description  Returns the devices that would be included or excluded from an update and why, the packages difference from each devices current image to the target, the static deltas state and an estimate of the download size. Nothing is created or dispatched.
+// @Tags         Updates (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        body	body	models.DevicesUpdateAPI	true	"devices uuids or inventory tags to update and optional target commit id"
+// @Success      200 {object} models.UpdatePreviewAPI	"The update preview"
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      404 {object} errors.NotFound	"The devices or the target commit were not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /updates/preview [post]
+func PostPreviewUpdate(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		return
+	}
+	var devicesUpdate models.DevicesUpdate
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &devicesUpdate); err != nil {
+		return
+	}
+	if err := setDevicesUpdateTaggedDevices(w, r, orgID, &devicesUpdate); err != nil {
+		// logs and response handled by setDevicesUpdateTaggedDevices
+		return
+	}
+	if len(devicesUpdate.DevicesUUID) == 0 {
+		respondWithAPIError(w, ctxServices.Log, errors.NewBadRequest("DeviceUUID or Tags required."))
+		return
+	}
+
+	// remove any duplicates
+	devicesUUID := make([]string, 0, len(devicesUpdate.DevicesUUID))
+	devicesUUIDSMap := make(map[string]bool, len(devicesUpdate.DevicesUUID))
+	for _, deviceUUID := range devicesUpdate.DevicesUUID {
+		if _, ok := devicesUUIDSMap[deviceUUID]; !ok {
+			devicesUUID = append(devicesUUID, deviceUUID)
+			devicesUUIDSMap[deviceUUID] = true
+		}
+	}
+
+	var devicesCount int64
+	if result := db.Org(orgID, "").Model(&models.Device{}).Where("uuid IN (?)", devicesUUID).Count(&devicesCount); result.Error != nil {
+		ctxServices.Log.WithField("error", result.Error.Error()).Error("failed to get devices count")
+		respondWithAPIError(w, ctxServices.Log, errors.NewInternalServerError())
+		return
+	}
+	if int64(len(devicesUUID)) != devicesCount {
+		respondWithAPIError(w, ctxServices.Log, errors.NewNotFound("some devices where not found"))
+		return
+	}
+
+	// when no target commit is supplied preview the update to the latest commit of the devices
+	if devicesUpdate.CommitID == 0 {
+		commitID, err := ctxServices.DeviceService.GetLatestCommitFromDevices(orgID, devicesUUID)
+		if err != nil {
+			var apiError errors.APIError
+			switch err.(type) {
+			case *services.DeviceHasImageUndefined, *services.ImageHasNoImageSet, *services.DevicesHasMoreThanOneImageSet, *services.DeviceHasNoImageUpdate:
+				apiError = errors.NewBadRequest(err.Error())
+			default:
+				ctxServices.Log.WithField("error", err.Error()).Error("error when getting latest commit for devices")
+				apiError = errors.NewInternalServerError()
+				apiError.SetTitle("failed to get latest commit for devices")
+			}
+			respondWithAPIError(w, ctxServices.Log, apiError)
+			return
+		}
+		devicesUpdate.CommitID = commitID
+	}
+	commit, err := ctxServices.CommitService.GetCommitByID(devicesUpdate.CommitID, orgID)
+	if err != nil {
+		respondWithAPIError(w, ctxServices.Log, errors.NewNotFound(fmt.Sprintf("No commit found for CommitID %d", devicesUpdate.CommitID)))
+		return
+	}
+
+	preview, err := ctxServices.UpdateService.PreviewUpdate(orgID, devicesUUID, commit, devicesUpdate.IncludeStale)
+	if err != nil {
+		var apiError errors.APIError
+		switch err.(type) {
+		case *services.CommitImageNotFound:
+			apiError = errors.NewNotFound(err.Error())
+		default:
+			apiError = errors.NewInternalServerError()
+			apiError.SetTitle("failed to preview update")
+		}
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+
+	respondWithJSONBody(w, ctxServices.Log, preview)
+}
+
 // ValidateGetUpdatesFilterParams validate the query params that sent to /updates endpoint
 func ValidateGetUpdatesFilterParams(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -518,6 +778,12 @@ func ValidateGetUpdatesFilterParams(next http.Handler) http.Handler {
 				errs = append(errs, validationError{Key: "updated_at", Reason: err.Error()})
 			}
 		}
+		// "parent_id" validation
+		if val := r.URL.Query().Get("parent_id"); val != "" {
+			if _, err := strconv.Atoi(val); err != nil {
+				errs = append(errs, validationError{Key: "parent_id", Reason: fmt.Sprintf("%s is not a valid parent_id type, parent_id should be integer", val)})
+			}
+		}
 		// "sort_by" validation for "name", "created_at", "updated_at"
 		if val := r.URL.Query().Get("sort_by"); val != "" {
 			name := val
@@ -580,3 +846,140 @@ func GetInventoryGroupDevicesUpdateInfo(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	respondWithJSONBody(w, ctxServices.Log, inventoryGroupUpdateDevicesInfo)
 }
+
+// ApproveUpdate approves an update awaiting approval and starts it
+// @Summary      Approve an update awaiting approval
+// @ID           ApproveUpdate
+// @Description  Approves an update awaiting approval, the update is then built and dispatched to its devices. The update can not be approved by the user that created it.
+// @Tags         Updates (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        updateID  path  int    true  "a unique ID to identify the update" example(1042)
+// @Success      200 {object} models.UpdateAPI	"The approved update"
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      403 {object} errors.Forbidden	"The user is not allowed to approve the update or the access to the update devices is forbidden"
+// @Failure      404 {object} errors.NotFound	"The requested update was not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /updates/{updateID}/approve [post]
+func ApproveUpdate(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	update := getWritableUpdate(w, r)
+	if update == nil {
+		return
+	}
+	approvedUpdate, err := ctxServices.UpdateService.ApproveUpdate(update.OrgID, update.ID)
+	if err != nil {
+		respondWithAPIError(w, ctxServices.Log, updateReviewAPIError(err, "failed to approve update"))
+		return
+	}
+	ctxServices.Log.WithFields(log.Fields{"updateID": approvedUpdate.ID, "reviewedBy": approvedUpdate.ReviewedBy}).Info("UPGRADE: Starting asynchronous approved update process")
+	ctxServices.UpdateService.CreateUpdateAsync(approvedUpdate.ID)
+
+	respondWithJSONBody(w, ctxServices.Log, approvedUpdate)
+}
+
+// RejectUpdate rejects an update awaiting approval
+// @Summary      Reject an update awaiting approval
+// @ID           RejectUpdate
+// @Description  Rejects an update awaiting approval, the update is never built nor dispatched to its devices.
+// @Tags         Updates (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        updateID  path  int    true  "a unique ID to identify the update" example(1042)
+// @Success      200 {object} models.UpdateAPI	"The rejected update"
+// @Failure      400 {object} errors.BadRequest	"The request sent couldn't be processed"
+// @Failure      403 {object} errors.Forbidden	"The access to the update devices is forbidden"
+// @Failure      404 {object} errors.NotFound	"The requested update was not found"
+// @Failure      500 {object} errors.InternalServerError	"There was an internal server error"
+// @Router       /updates/{updateID}/reject [post]
+func RejectUpdate(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	update := getWritableUpdate(w, r)
+	if update == nil {
+		return
+	}
+	rejectedUpdate, err := ctxServices.UpdateService.RejectUpdate(update.OrgID, update.ID)
+	if err != nil {
+		respondWithAPIError(w, ctxServices.Log, updateReviewAPIError(err, "failed to reject update"))
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, rejectedUpdate)
+}
+
+// GetUpdateApprovalSettings returns the update approval settings of the org
+// @Summary      Returns the update approval settings
+// @ID           GetUpdateApprovalSettings
+// @Description  Returns whether the updates of the org await the approval of a second principal. The updates do not require approval when the org has not set its own settings.
+// @Tags         Updates (Systems)
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} models.UpdateApprovalSettingsAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /updates/approval-settings [get]
+func GetUpdateApprovalSettings(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	settings, err := ctxServices.UpdateService.GetUpdateApprovalSettings(orgID)
+	if err != nil {
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed getting update approval settings")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, settings)
+}
+
+// SetUpdateApprovalSettings creates or replaces the update approval settings of the org
+// @Summary      Sets the update approval settings
+// @ID           SetUpdateApprovalSettings
+// @Description  Sets whether the new updates of the org, including the retries of failed devices, await the approval of a principal other than their requester before being built and dispatched.
+// @Tags         Updates (Systems)
+// @Accept       json
+// @Produce      json
+// @Param        body	body	models.SetUpdateApprovalSettingsAPI	true	"request body"
+// @Success      200 {object} models.UpdateApprovalSettingsAPI
+// @Failure      400 {object} errors.BadRequest "The request sent couldn't be processed."
+// @Failure      500 {object} errors.InternalServerError "There was an internal server error."
+// @Router       /updates/approval-settings [put]
+func SetUpdateApprovalSettings(w http.ResponseWriter, r *http.Request) {
+	ctxServices := dependencies.ServicesFromContext(r.Context())
+	orgID := readOrgID(w, r, ctxServices.Log)
+	if orgID == "" {
+		// logs and response handled by readOrgID
+		return
+	}
+	var settings models.UpdateApprovalSettings
+	if err := readRequestJSONBody(w, r, ctxServices.Log, &settings); err != nil {
+		return
+	}
+	result, err := ctxServices.UpdateService.SetUpdateApprovalSettings(orgID, &settings)
+	if err != nil {
+		apiError := errors.NewInternalServerError()
+		apiError.SetTitle("failed setting update approval settings")
+		respondWithAPIError(w, ctxServices.Log, apiError)
+		return
+	}
+	respondWithJSONBody(w, ctxServices.Log, result)
+}
+
+// updateReviewAPIError returns the api error of an update approval or rejection error
+func updateReviewAPIError(err error, title string) errors.APIError {
+	var apiError errors.APIError
+	switch err.(type) {
+	case *services.UpdateNotFoundError:
+		apiError = errors.NewNotFound(err.Error())
+	case *services.UpdateNotAwaitingApproval:
+		apiError = errors.NewBadRequest(err.Error())
+	case *services.UpdateSelfApproval, *services.UpdateReviewerUndefined, *services.UpdateRequesterUndefined:
+		apiError = errors.NewForbidden(err.Error())
+	default:
+		apiError = errors.NewInternalServerError()
+		apiError.SetTitle(title)
+	}
+	return apiError
+}
@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -22,6 +23,8 @@ import (
 
 	"github.com/bxcodec/faker/v3"
 	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventory"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventory/mock_inventory"
 	"github.com/redhatinsights/edge-api/pkg/clients/inventorygroups"
 	"github.com/redhatinsights/edge-api/pkg/clients/inventorygroups/mock_inventorygroups"
 	"github.com/redhatinsights/edge-api/pkg/db"
@@ -115,6 +118,268 @@ func TestGetUpdatePlaybook(t *testing.T) {
 
 }
 
+func TestRetryUpdateFailedDevices(t *testing.T) {
+	update := &testUpdates[0]
+	parentID := update.ID
+	retryUpdate := models.UpdateTransaction{OrgID: update.OrgID, ParentID: &parentID, Status: models.UpdateStatusCreated}
+	retryUpdate.ID = update.ID + 1000
+	awaitingRetryUpdate := models.UpdateTransaction{OrgID: update.OrgID, ParentID: &parentID, Status: models.UpdateStatusAwaitingApproval}
+	awaitingRetryUpdate.ID = update.ID + 1001
+
+	tt := []struct {
+		name               string
+		returnUpdate       *models.UpdateTransaction
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should create retry update", returnUpdate: &retryUpdate, expectedHTTPStatus: http.StatusOK},
+		{name: "should not start retry update awaiting approval", returnUpdate: &awaitingRetryUpdate, expectedHTTPStatus: http.StatusOK},
+		{name: "should return bad request when no failed devices", returnError: new(services.UpdateHasNoFailedDevices), expectedHTTPStatus: http.StatusBadRequest},
+		{name: "should return bad request when repo not available", returnError: new(services.UpdateRepoNotAvailable), expectedHTTPStatus: http.StatusBadRequest},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodPost, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdateService := mock_services.NewMockUpdateServiceInterface(ctrl)
+		mockUpdateService.EXPECT().RetryUpdateFailedDevices(update.OrgID, update.ID).Return(te.returnUpdate, te.returnError)
+		if te.returnUpdate != nil && te.returnUpdate.Status != models.UpdateStatusAwaitingApproval {
+			mockUpdateService.EXPECT().CreateUpdateAsync(te.returnUpdate.ID)
+		}
+		ctx := context.WithValue(req.Context(), UpdateContextKey, update)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdateService: mockUpdateService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(RetryUpdateFailedDevices)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+			continue
+		}
+		if te.returnUpdate != nil {
+			var responseUpdate models.UpdateTransaction
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseUpdate); err != nil {
+				t.Errorf("in %q: failed decoding response body: %s", te.name, err.Error())
+				continue
+			}
+			if responseUpdate.ParentID == nil || *responseUpdate.ParentID != update.ID {
+				t.Errorf("in %q: wrong parent update: got %v want %v", te.name, responseUpdate.ParentID, update.ID)
+			}
+		}
+	}
+}
+
+func TestGetUpdateRollbacks(t *testing.T) {
+	update := &testUpdates[0]
+
+	tt := []struct {
+		name               string
+		returnRollbacks    []models.UpdateRollback
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{
+			name:               "should return the update rollbacks",
+			returnRollbacks:    []models.UpdateRollback{{OrgID: update.OrgID, UpdateTransactionID: update.ID, DeviceUUID: faker.UUIDHyphenated()}},
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdateService := mock_services.NewMockUpdateServiceInterface(ctrl)
+		mockUpdateService.EXPECT().GetUpdateRollbacks(update.OrgID, update.ID).Return(te.returnRollbacks, te.returnError)
+		ctx := context.WithValue(req.Context(), UpdateContextKey, update)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdateService: mockUpdateService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(GetUpdateRollbacks)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+			continue
+		}
+		if te.returnRollbacks != nil {
+			var responseRollbacks []models.UpdateRollback
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseRollbacks); err != nil {
+				t.Errorf("in %q: failed decoding response body: %s", te.name, err.Error())
+				continue
+			}
+			if len(responseRollbacks) != 1 || responseRollbacks[0].UpdateTransactionID != update.ID {
+				t.Errorf("in %q: wrong rollbacks: got %v", te.name, responseRollbacks)
+			}
+		}
+	}
+}
+
+func TestSetUpdateApprovalSettings(t *testing.T) {
+	tt := []struct {
+		name               string
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should set the update approval settings", expectedHTTPStatus: http.StatusOK},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		settings := models.UpdateApprovalSettings{RequireApproval: true}
+		body, err := json.Marshal(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPut, "/", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdateService := mock_services.NewMockUpdateServiceInterface(ctrl)
+		var returnSettings *models.UpdateApprovalSettings
+		if te.returnError == nil {
+			returnSettings = &models.UpdateApprovalSettings{OrgID: common.DefaultOrgID, RequireApproval: true}
+		}
+		mockUpdateService.EXPECT().SetUpdateApprovalSettings(common.DefaultOrgID, &settings).Return(returnSettings, te.returnError)
+		ctx := dependencies.ContextWithServices(req.Context(), &dependencies.EdgeAPIServices{
+			UpdateService: mockUpdateService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(SetUpdateApprovalSettings)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+			continue
+		}
+		if te.returnError == nil {
+			var responseSettings models.UpdateApprovalSettings
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseSettings); err != nil {
+				t.Errorf("in %q: failed decoding response body: %s", te.name, err.Error())
+				continue
+			}
+			if !responseSettings.RequireApproval {
+				t.Errorf("in %q: handler returned wrong settings: got %v want true", te.name, responseSettings.RequireApproval)
+			}
+		}
+	}
+}
+
+func TestApproveUpdate(t *testing.T) {
+	update := &testUpdates[0]
+	approvedUpdate := models.UpdateTransaction{OrgID: update.OrgID, Status: models.UpdateStatusCreated, ReviewedBy: faker.Username()}
+	approvedUpdate.ID = update.ID
+
+	tt := []struct {
+		name               string
+		returnUpdate       *models.UpdateTransaction
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should approve and start the update", returnUpdate: &approvedUpdate, expectedHTTPStatus: http.StatusOK},
+		{name: "should return forbidden on self approval", returnError: new(services.UpdateSelfApproval), expectedHTTPStatus: http.StatusForbidden},
+		{name: "should return forbidden when the requester is unknown", returnError: new(services.UpdateRequesterUndefined), expectedHTTPStatus: http.StatusForbidden},
+		{name: "should return bad request when not awaiting approval", returnError: new(services.UpdateNotAwaitingApproval), expectedHTTPStatus: http.StatusBadRequest},
+		{name: "should return not found when update does not exist", returnError: new(services.UpdateNotFoundError), expectedHTTPStatus: http.StatusNotFound},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodPost, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdateService := mock_services.NewMockUpdateServiceInterface(ctrl)
+		mockUpdateService.EXPECT().ApproveUpdate(update.OrgID, update.ID).Return(te.returnUpdate, te.returnError)
+		if te.returnUpdate != nil {
+			mockUpdateService.EXPECT().CreateUpdateAsync(te.returnUpdate.ID)
+		}
+		ctx := context.WithValue(req.Context(), UpdateContextKey, update)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdateService: mockUpdateService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(ApproveUpdate)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+			continue
+		}
+		if te.returnUpdate != nil {
+			var responseUpdate models.UpdateTransaction
+			if err := json.Unmarshal(rr.Body.Bytes(), &responseUpdate); err != nil {
+				t.Errorf("in %q: failed decoding response body: %s", te.name, err.Error())
+				continue
+			}
+			if responseUpdate.ReviewedBy != approvedUpdate.ReviewedBy {
+				t.Errorf("in %q: wrong reviewer: got %v want %v", te.name, responseUpdate.ReviewedBy, approvedUpdate.ReviewedBy)
+			}
+		}
+	}
+}
+
+func TestRejectUpdate(t *testing.T) {
+	update := &testUpdates[0]
+	rejectedUpdate := models.UpdateTransaction{OrgID: update.OrgID, Status: models.UpdateStatusRejected}
+	rejectedUpdate.ID = update.ID
+
+	tt := []struct {
+		name               string
+		returnUpdate       *models.UpdateTransaction
+		returnError        error
+		expectedHTTPStatus int
+	}{
+		{name: "should reject the update", returnUpdate: &rejectedUpdate, expectedHTTPStatus: http.StatusOK},
+		{name: "should return bad request when not awaiting approval", returnError: new(services.UpdateNotAwaitingApproval), expectedHTTPStatus: http.StatusBadRequest},
+		{name: "should return internal server error", returnError: errors.New("expected error"), expectedHTTPStatus: http.StatusInternalServerError},
+	}
+
+	for _, te := range tt {
+		req, err := http.NewRequest(http.MethodPost, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUpdateService := mock_services.NewMockUpdateServiceInterface(ctrl)
+		// a rejected update is never started
+		mockUpdateService.EXPECT().RejectUpdate(update.OrgID, update.ID).Return(te.returnUpdate, te.returnError)
+		ctx := context.WithValue(req.Context(), UpdateContextKey, update)
+		ctx = dependencies.ContextWithServices(ctx, &dependencies.EdgeAPIServices{
+			UpdateService: mockUpdateService,
+			Log:           log.NewEntry(log.StandardLogger()),
+		})
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(RejectUpdate)
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if status := rr.Code; status != te.expectedHTTPStatus {
+			t.Errorf("in %q: handler returned wrong status code: got %v want %v", te.name, status, te.expectedHTTPStatus)
+		}
+	}
+}
+
 var _ = Describe("Update routes", func() {
 	var edgeAPIServices *dependencies.EdgeAPIServices
 	orgID := faker.UUIDHyphenated()
@@ -463,6 +728,197 @@ var _ = Describe("Update routes", func() {
 				Expect(responseRecorder.Code).To(Equal(http.StatusOK))
 			})
 		})
+
+		When("updating devices by tags", func() {
+			tag := fmt.Sprintf("site/store=%s", faker.UUIDHyphenated())
+			taggedDevice := models.Device{
+				OrgID:         orgID,
+				UUID:          faker.UUIDHyphenated(),
+				ImageID:       image.ID,
+				SystemProfile: models.DeviceSystemProfile{Tags: []string{tag, "insights-client/env=prod"}},
+			}
+			db.DB.Create(&taggedDevice)
+			otherTaggedDevice := models.Device{
+				OrgID:         orgID,
+				UUID:          faker.UUIDHyphenated(),
+				ImageID:       image.ID,
+				SystemProfile: models.DeviceSystemProfile{Tags: []string{tag}},
+			}
+			db.DB.Create(&otherTaggedDevice)
+
+			It("should update the devices having all the tags", func() {
+				updateData, err := json.Marshal(models.DevicesUpdate{Tags: []string{tag, "insights-client/env=prod"}})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				updateTransactions := []models.UpdateTransaction{{OrgID: orgID, Devices: []models.Device{taggedDevice}}}
+				mockUpdateService.EXPECT().BuildUpdateTransactions(ctx, gomock.Any(), orgID, gomock.Any()).DoAndReturn(
+					func(_ context.Context, devicesUpdate *models.DevicesUpdate, _ string, _ *models.Commit) (*[]models.UpdateTransaction, error) {
+						Expect(devicesUpdate.DevicesUUID).To(Equal([]string{taggedDevice.UUID}))
+						return &updateTransactions, nil
+					})
+				mockUpdateService.EXPECT().CreateUpdateAsync(updateTransactions[0].ID)
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(AddUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			})
+
+			It("should update the inventory tagged devices when the tags are not persisted", func() {
+				conf := config.Get()
+				defer func(profileFields []string) {
+					conf.InventoryProfileFields = profileFields
+				}(conf.InventoryProfileFields)
+				conf.InventoryProfileFields = []string{models.DeviceSystemProfileFieldArch}
+				// only otherTaggedDevice has the tag in inventory
+				mockInventory := mock_inventory.NewMockClientInterface(ctrl)
+				mockInventory.EXPECT().ReturnDevicesByTag(url.QueryEscape(tag)).
+					Return(inventory.Response{Result: []inventory.Device{{ID: otherTaggedDevice.UUID}}}, nil)
+				logger := log.NewEntry(log.StandardLogger())
+				edgeAPIServices.DeviceService = &services.DeviceService{
+					Inventory: mockInventory,
+					Service:   services.NewService(context.Background(), logger),
+				}
+
+				updateData, err := json.Marshal(models.DevicesUpdate{Tags: []string{tag}})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				updateTransactions := []models.UpdateTransaction{{OrgID: orgID, Devices: []models.Device{otherTaggedDevice}}}
+				mockUpdateService.EXPECT().BuildUpdateTransactions(ctx, gomock.Any(), orgID, gomock.Any()).DoAndReturn(
+					func(_ context.Context, devicesUpdate *models.DevicesUpdate, _ string, _ *models.Commit) (*[]models.UpdateTransaction, error) {
+						Expect(devicesUpdate.DevicesUUID).To(Equal([]string{otherTaggedDevice.UUID}))
+						return &updateTransactions, nil
+					})
+				mockUpdateService.EXPECT().CreateUpdateAsync(updateTransactions[0].ID)
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(AddUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			})
+
+			It("should return not found when no devices have the tags", func() {
+				updateData, err := json.Marshal(models.DevicesUpdate{Tags: []string{"site/store=does-not-exist"}})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(AddUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("should return bad request when devices and tags are supplied", func() {
+				updateData, err := json.Marshal(models.DevicesUpdate{DevicesUUID: []string{taggedDevice.UUID}, Tags: []string{tag}})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(AddUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+		When("previewing an update", func() {
+			It("should return bad request when devices are not supplied", func() {
+				updateData, err := json.Marshal(models.DevicesUpdate{CommitID: updateCommit.ID})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/preview", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(PostPreviewUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("should return not found when devices does not exist", func() {
+				updateData, err := json.Marshal(models.DevicesUpdate{CommitID: updateCommit.ID, DevicesUUID: []string{device.UUID, "does-not-exists"}})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/preview", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(PostPreviewUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("should return the update preview", func() {
+				updateData, err := json.Marshal(models.DevicesUpdate{CommitID: updateCommit.ID, DevicesUUID: []string{device.UUID, device3.UUID}})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/preview", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				preview := models.UpdatePreview{
+					CommitID:        updateCommit.ID,
+					ImageID:         updateImage.ID,
+					IncludedDevices: []models.UpdatePreviewDevice{{UUID: device.UUID, ImageID: image.ID}},
+					ExcludedDevices: []models.UpdatePreviewDevice{
+						{UUID: device3.UUID, ImageID: imageWithImageSetID.ID, Reason: models.UpdatePreviewReasonDifferentImageSet},
+					},
+				}
+				mockUpdateService.EXPECT().PreviewUpdate(orgID, []string{device.UUID, device3.UUID}, gomock.Any(), false).Return(&preview, nil)
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(PostPreviewUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+				var responsePreview models.UpdatePreview
+				err = json.Unmarshal(responseRecorder.Body.Bytes(), &responsePreview)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(responsePreview.CommitID).To(Equal(updateCommit.ID))
+				Expect(len(responsePreview.IncludedDevices)).To(Equal(1))
+				Expect(len(responsePreview.ExcludedDevices)).To(Equal(1))
+				Expect(responsePreview.ExcludedDevices[0].Reason).To(Equal(models.UpdatePreviewReasonDifferentImageSet))
+			})
+
+			It("should return not found when the commit has no image", func() {
+				updateData, err := json.Marshal(models.DevicesUpdate{CommitID: updateCommit.ID, DevicesUUID: []string{device.UUID}})
+				Expect(err).To(BeNil())
+				req, err := http.NewRequest(http.MethodPost, "/preview", bytes.NewBuffer(updateData))
+				Expect(err).To(BeNil())
+				ctx := dependencies.ContextWithServices(req.Context(), edgeAPIServices)
+				req = req.WithContext(ctx)
+
+				mockUpdateService.EXPECT().PreviewUpdate(orgID, []string{device.UUID}, gomock.Any(), false).Return(nil, new(services.CommitImageNotFound))
+
+				responseRecorder := httptest.NewRecorder()
+				handler := http.HandlerFunc(PostPreviewUpdate)
+				handler.ServeHTTP(responseRecorder, req)
+
+				Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			})
+		})
 		When("CommitID provided by user does not belong to same ImageSet as that of Device Image", func() {
 			It("should not allow to update with commitID belonging to different ImageSet", func() {
 
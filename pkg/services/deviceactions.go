@@ -0,0 +1,369 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/template"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/clients/playbookdispatcher"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services/playbooksigner"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// deviceActionTemplateName is the template of the curated device actions playbook
+const deviceActionTemplateName = "template_playbook_dispatcher_device_action_payload.yml"
+
+// DeviceActionsServiceInterface defines the interface that helps handle the actions dispatched to the devices
+type DeviceActionsServiceInterface interface {
+	GetDeviceActions(orgID string, limit int, offset int, tx *gorm.DB) ([]models.DeviceAction, error)
+	GetDeviceAction(orgID string, actionID uint) (*models.DeviceAction, error)
+	CreateDeviceAction(orgID string, request *models.DeviceActionRequest) (*models.DeviceAction, error)
+	GetDeviceActionPlaybook(action *models.DeviceAction) (io.ReadCloser, error)
+	GetPlaybooks(orgID string) ([]models.DeviceActionPlaybook, error)
+	CreatePlaybook(orgID string, playbook *models.DeviceActionPlaybook) (*models.DeviceActionPlaybook, error)
+	DeletePlaybook(orgID string, playbookID uint) error
+}
+
+// NewDeviceActionsService gives an instance of the main implementation of DeviceActionsServiceInterface
+func NewDeviceActionsService(ctx context.Context, log log.FieldLogger) DeviceActionsServiceInterface {
+	return &DeviceActionsService{
+		Service:             Service{ctx: ctx, log: log.WithField("service", "device-actions")},
+		FilesService:        NewFilesService(log),
+		PlaybookClient:      playbookdispatcher.InitClient(ctx, log),
+		DeviceGroupsService: NewDeviceGroupsService(ctx, log),
+	}
+}
+
+// DeviceActionsService is the main implementation of a DeviceActionsServiceInterface
+type DeviceActionsService struct {
+	Service
+	FilesService        FilesService
+	PlaybookClient      playbookdispatcher.ClientInterface
+	DeviceGroupsService DeviceGroupsServiceInterface
+}
+
+// GetDeviceActions returns the device actions of the org matching tx, the latest first
+func (s *DeviceActionsService) GetDeviceActions(orgID string, limit int, offset int, tx *gorm.DB) ([]models.DeviceAction, error) {
+	if tx == nil {
+		tx = db.DB
+	}
+	actions := make([]models.DeviceAction, 0)
+	if result := db.OrgDB(orgID, tx, "device_actions").Preload("DispatchRecords").Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).Find(&actions); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting device actions")
+		return nil, result.Error
+	}
+	return actions, nil
+}
+
+// GetDeviceAction returns a device action of the org
+func (s *DeviceActionsService) GetDeviceAction(orgID string, actionID uint) (*models.DeviceAction, error) {
+	var action models.DeviceAction
+	if result := db.Org(orgID, "").Preload("DispatchRecords").First(&action, actionID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DeviceActionNotFound)
+		}
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting device action")
+		return nil, result.Error
+	}
+	return &action, nil
+}
+
+// CreateDeviceAction creates a device action and dispatches its signed playbook to the devices of the request,
+// or to all the devices of its device group
+func (s *DeviceActionsService) CreateDeviceAction(orgID string, request *models.DeviceActionRequest) (*models.DeviceAction, error) {
+	if err := request.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	devices, err := s.getDeviceActionDevices(orgID, request)
+	if err != nil {
+		return nil, err
+	}
+	playbook, err := s.renderDeviceActionPlaybook(orgID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	action := &models.DeviceAction{
+		OrgID:         orgID,
+		Type:          request.Type,
+		Unit:          request.Unit,
+		PlaybookID:    request.PlaybookID,
+		DeviceGroupID: request.DeviceGroupID,
+		RequestedBy:   common.GetParsedIdentityPrincipal(s.ctx),
+		Status:        models.DeviceActionStatusCreated,
+	}
+	if result := db.DB.Create(action); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while creating device action")
+		return nil, result.Error
+	}
+	logger := s.log.WithFields(log.Fields{"deviceActionID": action.ID, "type": action.Type})
+
+	playbookURL, err := s.writeDeviceActionPlaybook(action, playbook)
+	if err != nil {
+		action.Status = models.DeviceActionStatusError
+		db.DB.Model(action).Update("status", action.Status)
+		return nil, err
+	}
+
+	for _, device := range devices {
+		dispatchRecord := models.DispatchRecord{
+			DeviceID:    device.ID,
+			PlaybookURL: playbookURL,
+			Status:      models.DispatchRecordStatusError,
+			Reason:      models.UpdateReasonFailure,
+		}
+		if device.RHCClientID == "" {
+			dispatchRecord.Reason = models.DeviceActionReasonNotConnected
+			action.DispatchRecords = append(action.DispatchRecords, dispatchRecord)
+			continue
+		}
+		responses, err := s.PlaybookClient.ExecuteDispatcher(playbookdispatcher.DispatcherPayload{
+			Recipient:    device.RHCClientID,
+			PlaybookURL:  playbookURL,
+			OrgID:        orgID,
+			PlaybookName: "Edge-management",
+			Principal:    action.RequestedBy,
+		})
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err.Error(), "deviceUUID": device.UUID}).Error("Error on playbook-dispatcher execution")
+		}
+		for _, response := range responses {
+			if response.StatusCode == http.StatusCreated {
+				dispatchRecord.Status = models.DispatchRecordStatusCreated
+				dispatchRecord.Reason = ""
+				dispatchRecord.PlaybookDispatcherID = response.PlaybookDispatcherID
+			}
+		}
+		action.DispatchRecords = append(action.DispatchRecords, dispatchRecord)
+	}
+
+	action.Status = deviceActionStatus(action.DispatchRecords)
+	if result := db.DB.Omit("DispatchRecords.Device").Save(action); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while saving device action dispatch records")
+		return nil, result.Error
+	}
+	logger.WithField("status", action.Status).Info("device action dispatched")
+	return action, nil
+}
+
+// getDeviceActionDevices returns the devices the action is requested for. As an update of a device group, the action
+// of a device group is sent to the devices of its descendant groups too.
+func (s *DeviceActionsService) getDeviceActionDevices(orgID string, request *models.DeviceActionRequest) ([]models.Device, error) {
+	var devices []models.Device
+	if request.DeviceGroupID != nil {
+		var deviceGroup models.DeviceGroup
+		if result := db.Org(orgID, "").First(&deviceGroup, *request.DeviceGroupID); result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil, new(DeviceGroupNotFound)
+			}
+			return nil, result.Error
+		}
+		groupDevices, err := s.DeviceGroupsService.GetDeviceGroupDevices(orgID, deviceGroup.ID, true)
+		if err != nil {
+			return nil, err
+		}
+		devices = groupDevices
+	} else {
+		if result := db.Org(orgID, "").Where("uuid IN (?)", request.DevicesUUID).Find(&devices); result.Error != nil {
+			return nil, result.Error
+		}
+		devicesUUID := make(map[string]bool, len(request.DevicesUUID))
+		for _, deviceUUID := range request.DevicesUUID {
+			devicesUUID[deviceUUID] = true
+		}
+		if len(devices) != len(devicesUUID) {
+			return nil, new(DeviceNotFoundError)
+		}
+	}
+	if len(devices) == 0 {
+		return nil, new(DeviceActionHasNoDevices)
+	}
+	return devices, nil
+}
+
+// renderDeviceActionPlaybook returns the signed playbook of the action, the org playbook or the curated action template
+func (s *DeviceActionsService) renderDeviceActionPlaybook(orgID string, request *models.DeviceActionRequest) ([]byte, error) {
+	var playbook []byte
+	if request.Type == models.DeviceActionTypeRunPlaybook {
+		var orgPlaybook models.DeviceActionPlaybook
+		if result := db.Org(orgID, "").First(&orgPlaybook, *request.PlaybookID); result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil, new(DeviceActionPlaybookNotFound)
+			}
+			return nil, result.Error
+		}
+		playbook = []byte(orgPlaybook.Playbook)
+	} else {
+		templateContents, err := template.New(deviceActionTemplateName).Delims("@@", "@@").
+			ParseFiles(config.Get().TemplatesPath + deviceActionTemplateName)
+		if err != nil {
+			s.log.WithField("error", err.Error()).Error("Error parsing device action playbook template")
+			return nil, err
+		}
+		var rendered bytes.Buffer
+		if err := templateContents.Execute(&rendered, request); err != nil {
+			s.log.WithField("error", err.Error()).Error("Error executing device action playbook template")
+			return nil, err
+		}
+		playbook = rendered.Bytes()
+	}
+
+	// unlike the update playbook, the device action playbooks have no static signature
+	signer, err := playbooksigner.NewSignerFromConfig()
+	if err != nil {
+		s.log.WithField("error", err.Error()).Error("Error loading playbook signing key")
+		return nil, err
+	}
+	signed, err := signer.SignPlaybook(playbook)
+	if err != nil {
+		s.log.WithField("error", err.Error()).Error("Error signing device action playbook")
+		return nil, err
+	}
+	return signed, nil
+}
+
+// deviceActionPlaybookPath returns the storage path of the playbook of a device action
+func deviceActionPlaybookPath(action *models.DeviceAction) string {
+	return fmt.Sprintf("%s/playbooks/playbook_dispatcher_device_action_%s_%d.yml", action.OrgID, action.OrgID, action.ID)
+}
+
+// writeDeviceActionPlaybook uploads the playbook of a device action and returns the url playbook dispatcher gets it from
+func (s *DeviceActionsService) writeDeviceActionPlaybook(action *models.DeviceAction, playbook []byte) (string, error) {
+	dirpath := fmt.Sprintf("/tmp/v2/%s", action.OrgID)
+	if err := os.MkdirAll(dirpath, 0770); err != nil {
+		s.log.WithField("error", err.Error()).Errorf("Error creating folder: %s", dirpath)
+		return "", err
+	}
+	tmpfilepath := fmt.Sprintf("%s/playbook_dispatcher_device_action_%s_%d.yml", dirpath, action.OrgID, action.ID)
+	if err := os.WriteFile(tmpfilepath, playbook, 0640); err != nil {
+		s.log.WithField("error", err.Error()).Errorf("Error creating file: %s", tmpfilepath)
+		return "", err
+	}
+	defer func() {
+		if err := os.Remove(tmpfilepath); err != nil {
+			s.log.WithField("error", err.Error()).Error("Error deleting temp file")
+		}
+	}()
+	if _, err := s.FilesService.GetUploader().UploadFile(tmpfilepath, deviceActionPlaybookPath(action)); err != nil {
+		s.log.WithField("error", err.Error()).Error("Error uploading device action playbook")
+		return "", err
+	}
+	return fmt.Sprintf("%s/api/edge/v1/device-actions/%d/playbook.yml", config.Get().EdgeAPIBaseURL, action.ID), nil
+}
+
+// GetDeviceActionPlaybook returns the signed playbook dispatched by a device action
+func (s *DeviceActionsService) GetDeviceActionPlaybook(action *models.DeviceAction) (io.ReadCloser, error) {
+	return s.FilesService.GetFile(deviceActionPlaybookPath(action))
+}
+
+// GetPlaybooks returns the playbooks uploaded by the org to run on its devices
+func (s *DeviceActionsService) GetPlaybooks(orgID string) ([]models.DeviceActionPlaybook, error) {
+	playbooks := make([]models.DeviceActionPlaybook, 0)
+	if result := db.Org(orgID, "").Order("name ASC, id ASC").Find(&playbooks); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting device action playbooks")
+		return nil, result.Error
+	}
+	return playbooks, nil
+}
+
+// CreatePlaybook validates and creates a playbook of the org to run on its devices
+func (s *DeviceActionsService) CreatePlaybook(orgID string, playbook *models.DeviceActionPlaybook) (*models.DeviceActionPlaybook, error) {
+	playbook.OrgID = orgID
+	if err := playbook.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	if result := db.DB.Create(playbook); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while creating device action playbook")
+		return nil, result.Error
+	}
+	return playbook, nil
+}
+
+// DeletePlaybook deletes a playbook of the org, the device actions that ran it are kept
+func (s *DeviceActionsService) DeletePlaybook(orgID string, playbookID uint) error {
+	result := db.Org(orgID, "").Delete(&models.DeviceActionPlaybook{}, playbookID)
+	if result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while deleting device action playbook")
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return new(DeviceActionPlaybookNotFound)
+	}
+	return nil
+}
+
+// deviceActionStatus returns the status of a device action from the status of its dispatch records
+func deviceActionStatus(dispatchRecords []models.DispatchRecord) string {
+	status := models.DeviceActionStatusSuccess
+	for _, dispatchRecord := range dispatchRecords {
+		switch dispatchRecord.Status {
+		case models.DispatchRecordStatusCreated, models.DispatchRecordStatusPending, models.DispatchRecordStatusRunning:
+			return models.DeviceActionStatusRunning
+		case models.DispatchRecordStatusError:
+			status = models.DeviceActionStatusError
+		}
+	}
+	if len(dispatchRecords) == 0 {
+		return models.DeviceActionStatusError
+	}
+	return status
+}
+
+// processDeviceActionRunEvent updates the dispatch record and the status of a device action from a playbook
+// dispatcher run event, it returns false when the run is not of a device action playbook
+func processDeviceActionRunEvent(logger log.FieldLogger, playbookDispatcherID string, playbookStatus string) (bool, error) {
+	var action models.DeviceAction
+	result := db.DB.Preload("DispatchRecords").
+		Joins("JOIN deviceaction_dispatchrecords ON device_actions.id = deviceaction_dispatchrecords.device_action_id").
+		Joins("JOIN dispatch_records ON dispatch_records.id = deviceaction_dispatchrecords.dispatch_record_id").
+		Where("dispatch_records.playbook_dispatcher_id = ?", playbookDispatcherID).First(&action)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting device action")
+		return false, result.Error
+	}
+
+	for i := range action.DispatchRecords {
+		dispatchRecord := &action.DispatchRecords[i]
+		if dispatchRecord.PlaybookDispatcherID != playbookDispatcherID {
+			continue
+		}
+		switch playbookStatus {
+		case PlaybookStatusRunning:
+			dispatchRecord.Status = models.DispatchRecordStatusRunning
+		case PlaybookStatusSuccess:
+			dispatchRecord.Status = models.DispatchRecordStatusComplete
+		case PlaybookStatusTimeout:
+			dispatchRecord.Status = models.DispatchRecordStatusError
+			dispatchRecord.Reason = models.UpdateReasonTimeout
+		default:
+			dispatchRecord.Status = models.DispatchRecordStatusError
+			dispatchRecord.Reason = models.UpdateReasonFailure
+		}
+		if result := db.DB.Omit("Device").Save(dispatchRecord); result.Error != nil {
+			logger.WithField("error", result.Error.Error()).Error("error occurred while saving device action dispatch record")
+			return true, result.Error
+		}
+	}
+
+	action.Status = deviceActionStatus(action.DispatchRecords)
+	if result := db.DB.Model(&action).Update("status", action.Status); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while saving device action status")
+		return true, result.Error
+	}
+	logger.WithFields(log.Fields{"deviceActionID": action.ID, "status": action.Status}).Info("device action status updated")
+	return true, nil
+}
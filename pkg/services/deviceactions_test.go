@@ -0,0 +1,295 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/clients/playbookdispatcher"
+	"github.com/redhatinsights/edge-api/pkg/clients/playbookdispatcher/mock_playbookdispatcher"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+	"github.com/redhatinsights/edge-api/pkg/services/playbooksigner"
+)
+
+var _ = Describe("DeviceActionsService", func() {
+	var ctrl *gomock.Controller
+	var mockFilesService *mock_services.MockFilesService
+	var mockUploader *mock_services.MockUploader
+	var mockPlaybookClient *mock_playbookdispatcher.MockClientInterface
+	var deviceActionsService *services.DeviceActionsService
+	var cfg *config.EdgeConfig
+	var signingKey *openpgp.Entity
+	var orgID string
+
+	f, _ := os.Getwd()
+	templatesPath := fmt.Sprintf("%s/../templates/", filepath.Dir(f))
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockFilesService = mock_services.NewMockFilesService(ctrl)
+		mockUploader = mock_services.NewMockUploader(ctrl)
+		mockPlaybookClient = mock_playbookdispatcher.NewMockClientInterface(ctrl)
+		deviceActionsService = &services.DeviceActionsService{
+			Service:             services.NewService(context.Background(), log.WithField("service", "device-actions")),
+			FilesService:        mockFilesService,
+			PlaybookClient:      mockPlaybookClient,
+			DeviceGroupsService: services.NewDeviceGroupsService(context.Background(), log.NewEntry(log.StandardLogger())),
+		}
+		orgID = faker.UUIDHyphenated()
+
+		var err error
+		signingKey, err = openpgp.NewEntity("edge-api", "", "edge-api@example.com", nil)
+		Expect(err).ToNot(HaveOccurred())
+		var keyRing bytes.Buffer
+		writer, err := armor.Encode(&keyRing, openpgp.PrivateKeyType, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signingKey.SerializePrivate(writer, nil)).To(Succeed())
+		Expect(writer.Close()).To(Succeed())
+
+		cfg = config.Get()
+		cfg.PlaybookSigningKey = keyRing.String()
+		cfg.TemplatesPath = templatesPath
+	})
+	AfterEach(func() {
+		cfg.PlaybookSigningKey = ""
+		ctrl.Finish()
+	})
+
+	Context("CreateDeviceAction", func() {
+		var connectedDevice, disconnectedDevice models.Device
+		BeforeEach(func() {
+			connectedDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), RHCClientID: faker.UUIDHyphenated()}
+			disconnectedDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&connectedDevice).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Create(&disconnectedDevice).Error).ToNot(HaveOccurred())
+		})
+
+		It("should dispatch the signed restart playbook to the connected devices", func() {
+			playbookDispatcherID := faker.UUIDHyphenated()
+			mockUploader.EXPECT().UploadFile(gomock.Any(), gomock.Any()).DoAndReturn(func(source, destination string) (string, error) {
+				Expect(destination).To(HavePrefix(fmt.Sprintf("%s/playbooks/playbook_dispatcher_device_action_%s_", orgID, orgID)))
+				actual, err := os.ReadFile(source)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(actual)).To(ContainSubstring(`systemd_unit: "podman-auto-update.service"`))
+				Expect(playbooksigner.VerifyPlaybook(actual, openpgp.EntityList{signingKey})).To(Succeed())
+				return "url", nil
+			})
+			mockFilesService.EXPECT().GetUploader().Return(mockUploader)
+			mockPlaybookClient.EXPECT().ExecuteDispatcher(gomock.Any()).DoAndReturn(func(payload playbookdispatcher.DispatcherPayload) ([]playbookdispatcher.Response, error) {
+				Expect(payload.Recipient).To(Equal(connectedDevice.RHCClientID))
+				Expect(payload.OrgID).To(Equal(orgID))
+				Expect(payload.PlaybookURL).To(HaveSuffix("/playbook.yml"))
+				return []playbookdispatcher.Response{{StatusCode: http.StatusCreated, PlaybookDispatcherID: playbookDispatcherID}}, nil
+			})
+
+			action, err := deviceActionsService.CreateDeviceAction(orgID, &models.DeviceActionRequest{
+				Type:        models.DeviceActionTypeRestartService,
+				Unit:        "podman-auto-update.service",
+				DevicesUUID: []string{connectedDevice.UUID, disconnectedDevice.UUID},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action.Status).To(Equal(models.DeviceActionStatusRunning))
+			Expect(action.DispatchRecords).To(HaveLen(2))
+
+			savedAction, err := deviceActionsService.GetDeviceAction(orgID, action.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(savedAction.DispatchRecords).To(HaveLen(2))
+			for _, dispatchRecord := range savedAction.DispatchRecords {
+				if dispatchRecord.DeviceID == connectedDevice.ID {
+					Expect(dispatchRecord.Status).To(Equal(models.DispatchRecordStatusCreated))
+					Expect(dispatchRecord.PlaybookDispatcherID).To(Equal(playbookDispatcherID))
+				} else {
+					Expect(dispatchRecord.Status).To(Equal(models.DispatchRecordStatusError))
+					Expect(dispatchRecord.Reason).To(Equal(models.DeviceActionReasonNotConnected))
+				}
+			}
+		})
+
+		It("should dispatch the org playbook to all the devices of a device group", func() {
+			deviceGroup := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault,
+				Devices: []models.Device{connectedDevice}}
+			Expect(db.DB.Omit("Devices.*").Create(&deviceGroup).Error).ToNot(HaveOccurred())
+			playbook, err := deviceActionsService.CreatePlaybook(orgID, &models.DeviceActionPlaybook{
+				Name:     "prune-images",
+				Playbook: "- hosts: localhost\n  vars: {}\n  tasks:\n    - name: prune\n      command: podman image prune -f\n",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockUploader.EXPECT().UploadFile(gomock.Any(), gomock.Any()).DoAndReturn(func(source, destination string) (string, error) {
+				actual, err := os.ReadFile(source)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(actual)).To(ContainSubstring("podman image prune -f"))
+				Expect(playbooksigner.VerifyPlaybook(actual, openpgp.EntityList{signingKey})).To(Succeed())
+				return "url", nil
+			})
+			mockFilesService.EXPECT().GetUploader().Return(mockUploader)
+			mockPlaybookClient.EXPECT().ExecuteDispatcher(gomock.Any()).Return(nil, errors.New("expected error"))
+
+			action, err := deviceActionsService.CreateDeviceAction(orgID, &models.DeviceActionRequest{
+				Type:          models.DeviceActionTypeRunPlaybook,
+				PlaybookID:    &playbook.ID,
+				DeviceGroupID: &deviceGroup.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action.Status).To(Equal(models.DeviceActionStatusError))
+			Expect(action.DispatchRecords).To(HaveLen(1))
+			Expect(action.DispatchRecords[0].Reason).To(Equal(models.UpdateReasonFailure))
+		})
+
+		It("should dispatch the action of a device group to the devices of its descendant groups", func() {
+			deviceGroup := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault,
+				Devices: []models.Device{connectedDevice}}
+			Expect(db.DB.Omit("Devices.*").Create(&deviceGroup).Error).ToNot(HaveOccurred())
+			childGroup := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault,
+				ParentID: &deviceGroup.ID, Devices: []models.Device{disconnectedDevice}}
+			Expect(db.DB.Omit("Devices.*").Create(&childGroup).Error).ToNot(HaveOccurred())
+
+			mockUploader.EXPECT().UploadFile(gomock.Any(), gomock.Any()).Return("url", nil)
+			mockFilesService.EXPECT().GetUploader().Return(mockUploader)
+			mockPlaybookClient.EXPECT().ExecuteDispatcher(gomock.Any()).Return(
+				[]playbookdispatcher.Response{{StatusCode: http.StatusCreated, PlaybookDispatcherID: faker.UUIDHyphenated()}}, nil)
+
+			action, err := deviceActionsService.CreateDeviceAction(orgID, &models.DeviceActionRequest{
+				Type:          models.DeviceActionTypeReboot,
+				DeviceGroupID: &deviceGroup.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			devicesIDs := make([]uint, 0, len(action.DispatchRecords))
+			for _, dispatchRecord := range action.DispatchRecords {
+				devicesIDs = append(devicesIDs, dispatchRecord.DeviceID)
+			}
+			Expect(devicesIDs).To(ConsistOf(connectedDevice.ID, disconnectedDevice.ID))
+		})
+
+		It("should return device not found when a device is not of the org", func() {
+			_, err := deviceActionsService.CreateDeviceAction(orgID, &models.DeviceActionRequest{
+				Type:        models.DeviceActionTypeReboot,
+				DevicesUUID: []string{connectedDevice.UUID, faker.UUIDHyphenated()},
+			})
+			Expect(err).To(MatchError(new(services.DeviceNotFoundError)))
+		})
+
+		It("should return playbook not found when the org playbook does not exist", func() {
+			playbookID := uint(99999999)
+			_, err := deviceActionsService.CreateDeviceAction(orgID, &models.DeviceActionRequest{
+				Type:        models.DeviceActionTypeRunPlaybook,
+				PlaybookID:  &playbookID,
+				DevicesUUID: []string{connectedDevice.UUID},
+			})
+			Expect(err).To(MatchError(new(services.DeviceActionPlaybookNotFound)))
+		})
+
+		It("should return an error when the device group has no devices", func() {
+			deviceGroup := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault}
+			Expect(db.DB.Create(&deviceGroup).Error).ToNot(HaveOccurred())
+			_, err := deviceActionsService.CreateDeviceAction(orgID, &models.DeviceActionRequest{
+				Type:          models.DeviceActionTypeCollectLogs,
+				DeviceGroupID: &deviceGroup.ID,
+			})
+			Expect(err).To(MatchError(new(services.DeviceActionHasNoDevices)))
+		})
+
+		It("should fail when no signing key is configured", func() {
+			cfg.PlaybookSigningKey = ""
+			_, err := deviceActionsService.CreateDeviceAction(orgID, &models.DeviceActionRequest{
+				Type:        models.DeviceActionTypeRefreshSubscription,
+				DevicesUUID: []string{connectedDevice.UUID},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("playbook dispatcher run events", func() {
+		var action models.DeviceAction
+		var running, other models.DispatchRecord
+		var updateService *services.UpdateService
+		BeforeEach(func() {
+			device := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+			running = models.DispatchRecord{DeviceID: device.ID, PlaybookDispatcherID: faker.UUIDHyphenated(), Status: models.DispatchRecordStatusCreated}
+			other = models.DispatchRecord{DeviceID: device.ID, PlaybookDispatcherID: faker.UUIDHyphenated(), Status: models.DispatchRecordStatusComplete}
+			action = models.DeviceAction{OrgID: orgID, Type: models.DeviceActionTypeReboot, Status: models.DeviceActionStatusRunning,
+				DispatchRecords: []models.DispatchRecord{running, other}}
+			Expect(db.DB.Omit("DispatchRecords.Device").Create(&action).Error).ToNot(HaveOccurred())
+			running = action.DispatchRecords[0]
+			updateService = &services.UpdateService{
+				Service: services.NewService(context.Background(), log.WithField("service", "update")),
+			}
+		})
+
+		It("should complete the device action when its last run succeeds", func() {
+			message, err := json.Marshal(&services.PlaybookDispatcherEvent{Payload: services.PlaybookDispatcherEventPayload{
+				ID: running.PlaybookDispatcherID, Status: services.PlaybookStatusSuccess, OrgID: orgID,
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updateService.ProcessPlaybookDispatcherRunEvent(message)).To(Succeed())
+
+			Expect(db.DB.First(&running, running.ID).Error).ToNot(HaveOccurred())
+			Expect(running.Status).To(Equal(models.DispatchRecordStatusComplete))
+			Expect(db.DB.First(&action, action.ID).Error).ToNot(HaveOccurred())
+			Expect(action.Status).To(Equal(models.DeviceActionStatusSuccess))
+		})
+
+		It("should set the device action in error when a run times out", func() {
+			message, err := json.Marshal(&services.PlaybookDispatcherEvent{Payload: services.PlaybookDispatcherEventPayload{
+				ID: running.PlaybookDispatcherID, Status: services.PlaybookStatusTimeout, OrgID: orgID,
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updateService.ProcessPlaybookDispatcherRunEvent(message)).To(Succeed())
+
+			Expect(db.DB.First(&running, running.ID).Error).ToNot(HaveOccurred())
+			Expect(running.Status).To(Equal(models.DispatchRecordStatusError))
+			Expect(running.Reason).To(Equal(models.UpdateReasonTimeout))
+			Expect(db.DB.First(&action, action.ID).Error).ToNot(HaveOccurred())
+			Expect(action.Status).To(Equal(models.DeviceActionStatusError))
+		})
+	})
+
+	Context("playbooks", func() {
+		It("should create, list and delete the org playbooks", func() {
+			playbook, err := deviceActionsService.CreatePlaybook(orgID, &models.DeviceActionPlaybook{
+				Name:     "prune-images",
+				Playbook: "- hosts: localhost\n  vars: {}\n  tasks: []\n",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(playbook.OrgID).To(Equal(orgID))
+
+			playbooks, err := deviceActionsService.GetPlaybooks(orgID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(playbooks).To(HaveLen(1))
+
+			otherPlaybooks, err := deviceActionsService.GetPlaybooks(faker.UUIDHyphenated())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(otherPlaybooks).To(BeEmpty())
+
+			Expect(deviceActionsService.DeletePlaybook(faker.UUIDHyphenated(), playbook.ID)).To(MatchError(new(services.DeviceActionPlaybookNotFound)))
+			Expect(deviceActionsService.DeletePlaybook(orgID, playbook.ID)).To(Succeed())
+			Expect(deviceActionsService.DeletePlaybook(orgID, playbook.ID)).To(MatchError(new(services.DeviceActionPlaybookNotFound)))
+		})
+
+		It("should refuse a playbook without vars to hold its signature", func() {
+			_, err := deviceActionsService.CreatePlaybook(orgID, &models.DeviceActionPlaybook{
+				Name:     "prune-images",
+				Playbook: "- hosts: localhost\n  tasks: []\n",
+			})
+			Expect(err).To(MatchError(models.DeviceActionPlaybookInvalidErrorMessage))
+		})
+	})
+})
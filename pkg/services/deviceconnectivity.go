@@ -0,0 +1,254 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/redhatinsights/edge-api/config"
+	kafkacommon "github.com/redhatinsights/edge-api/pkg/common/kafka"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// GetDeviceConnectivity returns the rhc connectivity of a device over the last days
+func (s *DeviceHistoryService) GetDeviceConnectivity(orgID string, deviceUUID string, days int) (*models.DeviceConnectivity, error) {
+	if err := models.ValidateDeviceConnectivityDays(days); err != nil {
+		return nil, err
+	}
+	var device models.Device
+	if result := db.Org(orgID, "").Where("uuid = ?", deviceUUID).First(&device); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DeviceNotFoundError)
+		}
+		s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while getting device")
+		return nil, result.Error
+	}
+	connectivity, err := s.devicesConnectivity([]models.Device{device}, days)
+	if err != nil {
+		return nil, err
+	}
+	return &connectivity[0], nil
+}
+
+// GetDeviceGroupConnectivity returns the rhc connectivity of the devices of a device group over the last days
+func (s *DeviceHistoryService) GetDeviceGroupConnectivity(orgID string, deviceGroupID uint, days int) (*models.DeviceGroupConnectivity, error) {
+	if err := models.ValidateDeviceConnectivityDays(days); err != nil {
+		return nil, err
+	}
+	var deviceGroup models.DeviceGroup
+	if result := db.Org(orgID, "").Preload("Devices").First(&deviceGroup, deviceGroupID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DeviceGroupNotFound)
+		}
+		s.log.WithFields(log.Fields{"device_group_id": deviceGroupID, "error": result.Error.Error()}).Error("error occurred while getting device group")
+		return nil, result.Error
+	}
+	devicesConnectivity, err := s.devicesConnectivity(deviceGroup.Devices, days)
+	if err != nil {
+		return nil, err
+	}
+
+	connectivity := models.DeviceGroupConnectivity{
+		DeviceGroupID:   deviceGroup.ID,
+		DeviceGroupName: deviceGroup.Name,
+		DevicesCount:    len(devicesConnectivity),
+		Devices:         devicesConnectivity,
+	}
+	for _, deviceConnectivity := range devicesConnectivity {
+		if deviceConnectivity.Connected {
+			connectivity.ConnectedCount++
+		}
+		connectivity.ConnectedPercentage += deviceConnectivity.ConnectedPercentage
+	}
+	connectivity.DisconnectedCount = connectivity.DevicesCount - connectivity.ConnectedCount
+	if connectivity.DevicesCount > 0 {
+		connectivity.ConnectedPercentage /= float64(connectivity.DevicesCount)
+	}
+	return &connectivity, nil
+}
+
+// devicesConnectivity computes the connectivity of the devices over the last days from their connectivity events
+func (s *DeviceHistoryService) devicesConnectivity(devices []models.Device, days int) ([]models.DeviceConnectivity, error) {
+	connectivity := make([]models.DeviceConnectivity, 0, len(devices))
+	if len(devices) == 0 {
+		return connectivity, nil
+	}
+	now := time.Now()
+	start := now.AddDate(0, 0, -days)
+	devicesIDs := make([]uint, 0, len(devices))
+	for _, device := range devices {
+		devicesIDs = append(devicesIDs, device.ID)
+	}
+
+	var events []models.DeviceEvent
+	if result := db.DB.Where("device_id IN (?) AND type IN (?) AND created_at > ?", devicesIDs, models.DeviceConnectivityEventTypes, start).
+		Order("created_at ASC, id ASC").Find(&events); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting devices connectivity events")
+		return nil, result.Error
+	}
+	// the last event of each device before the period gives its connectivity at the start of the period
+	var previousEvents []models.DeviceEvent
+	if result := db.DB.Where("id IN (?)", db.DB.Model(&models.DeviceEvent{}).Select("MAX(id)").
+		Where("device_id IN (?) AND type IN (?) AND created_at <= ?", devicesIDs, models.DeviceConnectivityEventTypes, start).
+		Group("device_id")).Find(&previousEvents); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting devices previous connectivity events")
+		return nil, result.Error
+	}
+
+	devicesEvents := make(map[uint][]models.DeviceEvent, len(devices))
+	for _, event := range events {
+		devicesEvents[event.DeviceID] = append(devicesEvents[event.DeviceID], event)
+	}
+	devicesPreviousEvent := make(map[uint]*models.DeviceEvent, len(previousEvents))
+	for i := range previousEvents {
+		devicesPreviousEvent[previousEvents[i].DeviceID] = &previousEvents[i]
+	}
+	for i := range devices {
+		device := &devices[i]
+		connectivity = append(connectivity,
+			models.NewDeviceConnectivity(device, start, now, devicesPreviousEvent[device.ID], devicesEvents[device.ID]))
+	}
+	return connectivity, nil
+}
+
+// setDevicesConnectivity records the rhc connectivity of devices seen by the inventory or the playbook dispatcher,
+// the connectivity changes are added to the devices history and may alert on their device groups
+func setDevicesConnectivity(logger log.FieldLogger, connected bool, devices ...*models.Device) {
+	eventType := models.DeviceEventTypeDisconnected
+	if connected {
+		eventType = models.DeviceEventTypeConnected
+	}
+	var changedDevicesIDs []uint
+	var events []models.DeviceEvent
+	for _, device := range devices {
+		if device.Connected == connected {
+			continue
+		}
+		device.Connected = connected
+		changedDevicesIDs = append(changedDevicesIDs, device.ID)
+		events = append(events, models.DeviceEvent{OrgID: device.OrgID, DeviceID: device.ID, Type: eventType})
+	}
+	if len(changedDevicesIDs) == 0 {
+		return
+	}
+	if result := db.DB.Model(&models.Device{}).Where("id IN (?)", changedDevicesIDs).Update("connected", connected); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while updating devices connectivity")
+		return
+	}
+	createDeviceEvents(logger, events...)
+	if !connected {
+		alertDisconnectedDeviceGroups(logger, changedDevicesIDs)
+	}
+}
+
+// deviceGroupDisconnections is the count of the devices of a device group by connectivity
+type deviceGroupDisconnections struct {
+	DeviceGroupID          uint
+	DevicesCount           int
+	DisconnectedCount      int
+	NewlyDisconnectedCount int
+}
+
+// alertDisconnectedDeviceGroups notifies the device groups of the newly disconnected devices where the disconnected
+// devices reached the configured alert percentage
+func alertDisconnectedDeviceGroups(logger log.FieldLogger, disconnectedDevicesIDs []uint) {
+	alertPercent := config.Get().DisconnectedAlertPercent
+	if alertPercent <= 0 {
+		return
+	}
+	var groupsDisconnections []deviceGroupDisconnections
+	if result := db.DB.Table("device_groups_devices").
+		Select("device_groups_devices.device_group_id, COUNT(*) AS devices_count, "+
+			"SUM(CASE WHEN devices.connected = ? THEN 1 ELSE 0 END) AS disconnected_count, "+
+			"SUM(CASE WHEN devices.id IN (?) THEN 1 ELSE 0 END) AS newly_disconnected_count", false, disconnectedDevicesIDs).
+		Joins("JOIN devices ON devices.id = device_groups_devices.device_id AND devices.deleted_at IS NULL").
+		Where("device_groups_devices.device_group_id IN (?)", db.DB.Table("device_groups_devices").
+			Select("device_group_id").Where("device_id IN (?)", disconnectedDevicesIDs)).
+		Group("device_groups_devices.device_group_id").Scan(&groupsDisconnections); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while counting device groups disconnected devices")
+		return
+	}
+	for _, disconnections := range groupsDisconnections {
+		if !models.DeviceGroupDisconnectedAlert(disconnections.DevicesCount, disconnections.DisconnectedCount,
+			disconnections.NewlyDisconnectedCount, alertPercent) {
+			continue
+		}
+		var deviceGroup models.DeviceGroup
+		if result := db.DB.First(&deviceGroup, disconnections.DeviceGroupID); result.Error != nil {
+			logger.WithFields(log.Fields{"device_group_id": disconnections.DeviceGroupID, "error": result.Error.Error()}).
+				Error("error occurred while getting disconnected device group")
+			continue
+		}
+		groupLogger := logger.WithFields(log.Fields{
+			"org_id": deviceGroup.OrgID, "device_group_id": deviceGroup.ID,
+			"devices": disconnections.DevicesCount, "disconnected": disconnections.DisconnectedCount,
+		})
+		groupLogger.Warning("device group devices disconnected")
+		if _, err := SendDeviceGroupDisconnectedNotification(kafkacommon.NewProducerService(), kafkacommon.NewTopicService(),
+			&deviceGroup, disconnections.DevicesCount, disconnections.DisconnectedCount); err != nil {
+			groupLogger.WithField("error", err.Error()).Error("error occurred while sending device group disconnected notification")
+		}
+	}
+}
+
+// SendDeviceGroupDisconnectedNotification notifies that too many devices of a device group are disconnected
+func SendDeviceGroupDisconnectedNotification(producerService kafkacommon.ProducerServiceInterface, topicService kafkacommon.TopicServiceInterface,
+	deviceGroup *models.DeviceGroup, devicesCount int, disconnectedCount int) (ImageNotification, error) {
+	// the notification template of notifications-backend service needs the device group name as ID
+	type NotificationPayLoad struct {
+		ID                string `json:"ID"`
+		DevicesCount      int    `json:"DevicesCount"`
+		DisconnectedCount int    `json:"DisconnectedCount"`
+	}
+	payload, err := json.Marshal(NotificationPayLoad{ID: deviceGroup.Name, DevicesCount: devicesCount, DisconnectedCount: disconnectedCount})
+	if err != nil {
+		return ImageNotification{}, err
+	}
+	notify := ImageNotification{
+		Version:     NotificationConfigVersion,
+		Bundle:      NotificationConfigBundle,
+		Application: NotificationConfigApplication,
+		EventType:   NotificationConfigEventTypeDeviceGroupDisconnected,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Account:     deviceGroup.Account,
+		OrgID:       deviceGroup.OrgID,
+		Context:     fmt.Sprintf(`{"DeviceGroupID":"%v"}`, deviceGroup.ID),
+		Events:      []EventNotification{{Metadata: make(map[string]string), Payload: string(payload)}},
+		Recipients:  []RecipientNotification{{IgnoreUserPreferences: false, OnlyAdmins: false, Users: []string{NotificationConfigUser}}},
+	}
+	recordValue, err := json.Marshal(notify)
+	if err != nil {
+		return notify, err
+	}
+
+	p := producerService.GetProducerInstance()
+	if p == nil {
+		return notify, new(KafkaProducerInstanceUndefined)
+	}
+	topic, err := topicService.GetTopic(NotificationTopic)
+	if err != nil {
+		return notify, err
+	}
+	if err := p.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte("DeviceGroupDisconnected"),
+		Value:          recordValue,
+	}, nil); err != nil {
+		return notify, err
+	}
+	return notify, nil
+}
+
+// processPlaybookRunConnectivity records the connectivity of the device of a playbook dispatcher run
+func processPlaybookRunConnectivity(logger log.FieldLogger, playbookDispatcherID string, playbookStatus string) {
+	var dispatchRecord models.DispatchRecord
+	if result := db.DB.Where("playbook_dispatcher_id = ?", playbookDispatcherID).Preload("Device").
+		First(&dispatchRecord); result.Error != nil || dispatchRecord.Device == nil {
+		return
+	}
+	setDevicesConnectivity(logger, playbookStatus != PlaybookStatusTimeout, dispatchRecord.Device)
+}
@@ -0,0 +1,115 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+var _ = Describe("DeviceConnectivity", func() {
+	var service services.DeviceHistoryServiceInterface
+	var orgID string
+	var connectedDevice, disconnectedDevice models.Device
+	var deviceGroup models.DeviceGroup
+
+	at := func(hours int) models.EdgeAPITime {
+		return models.EdgeAPITime{Time: time.Now().Add(time.Duration(hours) * time.Hour), Valid: true}
+	}
+
+	BeforeEach(func() {
+		service = services.NewDeviceHistoryService(context.Background(), log.NewEntry(log.StandardLogger()))
+		orgID = faker.UUIDHyphenated()
+		connectedDevice = models.Device{Model: models.Model{CreatedAt: at(-100)}, OrgID: orgID, UUID: faker.UUIDHyphenated(), Connected: true}
+		disconnectedDevice = models.Device{Model: models.Model{CreatedAt: at(-100)}, OrgID: orgID, UUID: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&connectedDevice).Error).ToNot(HaveOccurred())
+		Expect(db.DB.Create(&disconnectedDevice).Error).ToNot(HaveOccurred())
+		// the devices connected default is true
+		Expect(db.DB.Model(&disconnectedDevice).Update("connected", false).Error).ToNot(HaveOccurred())
+
+		events := []models.DeviceEvent{
+			{CreatedAt: at(-50), OrgID: orgID, DeviceID: connectedDevice.ID, Type: models.DeviceEventTypeConnected},
+			{CreatedAt: at(-12), OrgID: orgID, DeviceID: connectedDevice.ID, Type: models.DeviceEventTypeDisconnected},
+			{CreatedAt: at(-6), OrgID: orgID, DeviceID: connectedDevice.ID, Type: models.DeviceEventTypeConnected},
+			{CreatedAt: at(-36), OrgID: orgID, DeviceID: disconnectedDevice.ID, Type: models.DeviceEventTypeDisconnected},
+		}
+		Expect(db.DB.Create(&events).Error).ToNot(HaveOccurred())
+
+		deviceGroup = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault,
+			Devices: []models.Device{connectedDevice, disconnectedDevice}}
+		Expect(db.DB.Omit("Devices.*").Create(&deviceGroup).Error).ToNot(HaveOccurred())
+	})
+
+	Context("GetDeviceConnectivity", func() {
+		It("should return the device connectivity over the period", func() {
+			connectivity, err := service.GetDeviceConnectivity(orgID, connectedDevice.UUID, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connectivity.Connected).To(BeTrue())
+			Expect(connectivity.Since.Time).To(BeTemporally("~", at(-6).Time, time.Second))
+			Expect(connectivity.ConnectedPercentage).To(BeNumerically("~", 75, 0.1))
+			Expect(connectivity.ConnectedSeconds).To(BeNumerically("~", 18*3600, 2))
+			Expect(connectivity.Disconnections).To(Equal(1))
+		})
+
+		It("should return device not found when the device is not of the org", func() {
+			_, err := service.GetDeviceConnectivity(faker.UUIDHyphenated(), connectedDevice.UUID, 1)
+			Expect(err).To(MatchError(new(services.DeviceNotFoundError)))
+		})
+
+		It("should refuse an invalid period", func() {
+			_, err := service.GetDeviceConnectivity(orgID, connectedDevice.UUID, 0)
+			Expect(err).To(MatchError(models.DeviceConnectivityDaysInvalidErrorMessage))
+		})
+	})
+
+	Context("GetDeviceGroupConnectivity", func() {
+		It("should return the connectivity of the device group devices", func() {
+			connectivity, err := service.GetDeviceGroupConnectivity(orgID, deviceGroup.ID, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connectivity.DevicesCount).To(Equal(2))
+			Expect(connectivity.ConnectedCount).To(Equal(1))
+			Expect(connectivity.DisconnectedCount).To(Equal(1))
+			Expect(connectivity.ConnectedPercentage).To(BeNumerically("~", 37.5, 0.1))
+			Expect(connectivity.Devices).To(HaveLen(2))
+		})
+
+		It("should return device group not found when the group is not of the org", func() {
+			_, err := service.GetDeviceGroupConnectivity(faker.UUIDHyphenated(), deviceGroup.ID, 1)
+			Expect(err).To(MatchError(new(services.DeviceGroupNotFound)))
+		})
+	})
+
+	Context("playbook dispatcher run events", func() {
+		It("should record the disconnection of the device of a timed out run", func() {
+			dispatchRecord := models.DispatchRecord{DeviceID: connectedDevice.ID, PlaybookDispatcherID: faker.UUIDHyphenated(),
+				Status: models.DispatchRecordStatusCreated}
+			action := models.DeviceAction{OrgID: orgID, Type: models.DeviceActionTypeReboot, Status: models.DeviceActionStatusRunning,
+				DispatchRecords: []models.DispatchRecord{dispatchRecord}}
+			Expect(db.DB.Omit("DispatchRecords.Device").Create(&action).Error).ToNot(HaveOccurred())
+			updateService := &services.UpdateService{
+				Service: services.NewService(context.Background(), log.WithField("service", "update")),
+			}
+			message, err := json.Marshal(&services.PlaybookDispatcherEvent{Payload: services.PlaybookDispatcherEventPayload{
+				ID: dispatchRecord.PlaybookDispatcherID, Status: services.PlaybookStatusTimeout, OrgID: orgID,
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updateService.ProcessPlaybookDispatcherRunEvent(message)).To(Succeed())
+
+			var device models.Device
+			Expect(db.DB.First(&device, connectedDevice.ID).Error).ToNot(HaveOccurred())
+			Expect(device.Connected).To(BeFalse())
+			connectivity, err := service.GetDeviceConnectivity(orgID, connectedDevice.UUID, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connectivity.Connected).To(BeFalse())
+			Expect(connectivity.Disconnections).To(Equal(2))
+		})
+	})
+})
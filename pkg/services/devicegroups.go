@@ -6,11 +6,15 @@ import (
 	"context"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/redhatinsights/edge-api/pkg/clients/inventory"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventorygroups"
 	"github.com/redhatinsights/edge-api/pkg/db"
 	"github.com/redhatinsights/edge-api/pkg/models"
 	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services/utility"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -38,21 +42,28 @@ type DeviceGroupsServiceInterface interface {
 	DeleteDeviceGroupDevices(orgID string, deviceGroupID uint, devices []models.Device) (*[]models.Device, error)
 	GetDeviceImageInfo(setOfImages map[int]models.DeviceImageInfo, orgID string) error
 	DeviceGroupNameExists(orgID string, name string) (bool, error)
+	SetDeviceGroupHierarchy(orgID string, deviceGroupID uint, parentID *uint, cascadeRBAC bool) (*models.DeviceGroup, error)
+	GetDeviceGroupDescendantsIDs(orgID string, deviceGroupID uint) ([]uint, error)
+	GetDeviceGroupDevices(orgID string, deviceGroupID uint, includeDescendants bool) ([]models.Device, error)
+	ExpandDeviceGroupsAccess(orgID string, deviceGroupsIDs []uint) ([]uint, error)
+	MoveDeviceGroupsDevices(orgID string, move *models.DeviceGroupsMove) (*models.DeviceGroupsMoveResult, error)
 }
 
 // DeviceGroupsService is the main implementation of a DeviceGroupsServiceInterface
 type DeviceGroupsService struct {
 	Service
-	DeviceService DeviceServiceInterface
-	UpdateService UpdateServiceInterface
+	DeviceService         DeviceServiceInterface
+	UpdateService         UpdateServiceInterface
+	InventoryGroupsClient inventorygroups.ClientInterface
 }
 
 // NewDeviceGroupsService return an instance of the main implementation of a DeviceGroupsServiceInterface
 func NewDeviceGroupsService(ctx context.Context, log log.FieldLogger) DeviceGroupsServiceInterface {
 	return &DeviceGroupsService{
-		Service:       Service{ctx: ctx, log: log.WithField("service", "device-groups")},
-		DeviceService: NewDeviceService(ctx, log),
-		UpdateService: NewUpdateService(ctx, log),
+		Service:               Service{ctx: ctx, log: log.WithField("service", "device-groups")},
+		DeviceService:         NewDeviceService(ctx, log),
+		UpdateService:         NewUpdateService(ctx, log),
+		InventoryGroupsClient: inventorygroups.InitClient(ctx, log),
 	}
 }
 
@@ -102,6 +113,22 @@ func (s *DeviceGroupsService) DeleteDeviceGroupByID(ID string) error {
 		sLog.WithField("error", result.Error.Error()).Error("Error deleting device group")
 		return result.Error
 	}
+	// the children of the group move up to its parent
+	if res := db.DB.Model(&models.DeviceGroup{}).Where("parent_id = ?", deviceGroup.ID).
+		Update("parent_id", deviceGroup.ParentID); res.Error != nil {
+		sLog.WithField("error", res.Error.Error()).Error("Error moving device group children")
+	}
+	// the update policy and the desired state of the group are deleted along with it
+	if err := NewUpdatePolicyService(s.ctx, s.log).DeleteDeviceGroupUpdatePolicy(deviceGroup.OrgID, deviceGroup.ID); err != nil {
+		if _, ok := err.(*UpdatePolicyNotFound); !ok {
+			sLog.WithField("error", err.Error()).Error("Error deleting device group update policy")
+		}
+	}
+	if err := NewDesiredStateService(s.ctx, s.log).DeleteDeviceGroupDesiredState(deviceGroup.OrgID, deviceGroup.ID); err != nil {
+		if _, ok := err.(*DesiredStateNotFound); !ok {
+			sLog.WithField("error", err.Error()).Error("Error deleting device group desired state")
+		}
+	}
 	return nil
 }
 
@@ -146,6 +173,12 @@ func (s *DeviceGroupsService) GetDeviceGroups(orgID string, limit int, offset in
 		return nil, res.Error
 	}
 
+	driftCounts, err := getDeviceGroupsDriftCounts(orgID, deviceGroups)
+	if err != nil {
+		s.log.WithField("error", err.Error()).Error("Error getting device groups drift counts")
+		return nil, err
+	}
+
 	// Concat info
 	var deviceGroupListDetail []models.DeviceGroupListDetail
 	for _, group := range deviceGroups {
@@ -169,7 +202,7 @@ func (s *DeviceGroupsService) GetDeviceGroups(orgID string, limit int, offset in
 		}
 		deviceGroupListDetail = append(deviceGroupListDetail,
 			models.DeviceGroupListDetail{DeviceGroup: group,
-				DeviceImageInfo: &info})
+				DeviceImageInfo: &info, Drift: driftCounts[group.ID]})
 	}
 
 	return &deviceGroupListDetail, nil
@@ -251,11 +284,26 @@ func (s *DeviceGroupsService) CreateDeviceGroup(deviceGroup *models.DeviceGroup)
 		Type:  string(static),
 		OrgID: deviceGroup.OrgID,
 	}
+	if deviceGroup.ParentID != nil {
+		if res := db.Org(deviceGroup.OrgID, "").First(&models.DeviceGroup{}, *deviceGroup.ParentID); res.Error != nil {
+			return nil, new(DeviceGroupNotFound)
+		}
+		group.ParentID = deviceGroup.ParentID
+		group.CascadeRBAC = deviceGroup.CascadeRBAC
+	}
+	if deviceGroup.Type == string(dynamic) {
+		group.Type = string(dynamic)
+		group.Rule = deviceGroup.Rule
+		group.Principal = common.GetParsedIdentityPrincipal(s.ctx)
+	}
 	result := db.DB.Create(&group)
 	if result.Error != nil {
 		s.log.WithField("error", result.Error.Error()).Error("Error creating device group")
 		return nil, result.Error
 	}
+	if group.Type == string(dynamic) {
+		s.evaluateDynamicDeviceGroup(group)
+	}
 
 	return group, nil
 }
@@ -279,6 +327,11 @@ func (s *DeviceGroupsService) GetDeviceGroupByID(ID string) (*models.DeviceGroup
 	if err != nil {
 		s.log.WithField("error", err.Error()).Error("Error validating device group update")
 	}
+	var childrenCount int64
+	if res := db.Org(orgID, "").Model(&models.DeviceGroup{}).Where("parent_id = ?", deviceGroup.ID).Count(&childrenCount); res.Error != nil {
+		s.log.WithField("error", res.Error.Error()).Error("Error counting device group children")
+	}
+	deviceGroup.HasChildren = childrenCount > 0
 
 	return &deviceGroup, nil
 }
@@ -329,7 +382,8 @@ func (s *DeviceGroupsService) UpdateDeviceGroup(deviceGroup *models.DeviceGroup,
 	if err != nil {
 		s.log.WithField("error", err.Error()).Error("Error retrieving device group")
 	}
-	if groupDetails.Name != "" {
+	// the name is only checked when it changes, a dynamic group rule can be updated alone
+	if groupDetails.Name != "" && groupDetails.Name != deviceGroup.Name {
 		groupDetails.Name = deviceGroup.Name
 		deviceGroupExists, err := s.DeviceGroupNameExists(groupDetails.OrgID, groupDetails.Name)
 		if err != nil {
@@ -341,10 +395,18 @@ func (s *DeviceGroupsService) UpdateDeviceGroup(deviceGroup *models.DeviceGroup,
 		}
 	}
 
+	if groupDetails.Type == string(dynamic) && deviceGroup.Rule != "" {
+		groupDetails.Rule = deviceGroup.Rule
+		groupDetails.Principal = common.GetParsedIdentityPrincipal(s.ctx)
+	}
+
 	result := db.DB.Omit("Devices").Save(&groupDetails)
 	if result.Error != nil {
 		return result.Error
 	}
+	if groupDetails.Type == string(dynamic) {
+		s.evaluateDynamicDeviceGroup(groupDetails)
+	}
 
 	return nil
 }
@@ -391,6 +453,9 @@ func (s *DeviceGroupsService) AddDeviceGroupDevices(orgID string, deviceGroupID
 	if res := db.Org(orgID, "").First(&deviceGroup, deviceGroupID); res.Error != nil {
 		return nil, res.Error
 	}
+	if deviceGroup.Type == models.DeviceGroupTypeDynamic {
+		return nil, new(DeviceGroupDynamicMembership)
+	}
 
 	// get the device ids needed to be added to device group and remove duplicates and undefined
 	mapDeviceIDS := make(map[uint]bool, len(devices))
@@ -419,6 +484,12 @@ func (s *DeviceGroupsService) AddDeviceGroupDevices(orgID string, deviceGroupID
 		return nil, err
 	}
 
+	events := make([]models.DeviceEvent, 0, len(devicesToAdd))
+	for _, device := range devicesToAdd {
+		events = append(events, models.DeviceEvent{OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupAdded, To: deviceGroup.Name})
+	}
+	createDeviceEvents(s.log, events...)
+
 	return &devicesToAdd, nil
 }
 
@@ -438,6 +509,9 @@ func (s *DeviceGroupsService) DeleteDeviceGroupDevices(orgID string, deviceGroup
 	if res := db.Org(orgID, "").First(&deviceGroup, deviceGroupID); res.Error != nil {
 		return nil, res.Error
 	}
+	if deviceGroup.Type == models.DeviceGroupTypeDynamic {
+		return nil, new(DeviceGroupDynamicMembership)
+	}
 
 	// get the device ids needed to be deleted from device group, remove duplicates and undefined
 	mapDeviceIDS := make(map[uint]bool, len(devices))
@@ -466,5 +540,293 @@ func (s *DeviceGroupsService) DeleteDeviceGroupDevices(orgID string, deviceGroup
 		return nil, err
 	}
 
+	events := make([]models.DeviceEvent, 0, len(devicesToRemove))
+	for _, device := range devicesToRemove {
+		events = append(events, models.DeviceEvent{OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupRemoved, From: deviceGroup.Name})
+	}
+	createDeviceEvents(s.log, events...)
+
 	return &devicesToRemove, nil
 }
+
+// evaluateDynamicDeviceGroup sets the members of a dynamic device group once created or updated, the membership
+// is kept current by the dynamic device groups job afterwards
+func (s *DeviceGroupsService) evaluateDynamicDeviceGroup(deviceGroup *models.DeviceGroup) {
+	if _, err := NewDynamicDeviceGroupsService(s.ctx, s.log).EvaluateDeviceGroup(deviceGroup.OrgID, deviceGroup.ID); err != nil {
+		s.log.WithFields(log.Fields{"error": err.Error(), "device_group_id": deviceGroup.ID}).Error("Error evaluating dynamic device group")
+	}
+}
+
+// SetDeviceGroupHierarchy nests a device group under a parent group, or makes it a root group when parentID is nil.
+// The parent can not be the group itself or one of its descendants
+func (s *DeviceGroupsService) SetDeviceGroupHierarchy(orgID string, deviceGroupID uint, parentID *uint, cascadeRBAC bool) (*models.DeviceGroup, error) {
+	var deviceGroup models.DeviceGroup
+	if res := db.Org(orgID, "").First(&deviceGroup, deviceGroupID); res.Error != nil {
+		return nil, new(DeviceGroupNotFound)
+	}
+	if parentID != nil {
+		if *parentID == deviceGroup.ID {
+			return nil, new(DeviceGroupHierarchyCycle)
+		}
+		if res := db.Org(orgID, "").First(&models.DeviceGroup{}, *parentID); res.Error != nil {
+			return nil, new(DeviceGroupNotFound)
+		}
+		descendantsIDs, err := s.GetDeviceGroupDescendantsIDs(orgID, deviceGroup.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, descendantID := range descendantsIDs {
+			if descendantID == *parentID {
+				return nil, new(DeviceGroupHierarchyCycle)
+			}
+		}
+	}
+	deviceGroup.ParentID = parentID
+	deviceGroup.CascadeRBAC = cascadeRBAC
+	if res := db.DB.Model(&deviceGroup).Select("parent_id", "cascade_rbac").Updates(&deviceGroup); res.Error != nil {
+		s.log.WithField("error", res.Error.Error()).Error("Error setting device group hierarchy")
+		return nil, res.Error
+	}
+	s.log.WithFields(log.Fields{"device_group_id": deviceGroup.ID, "parent_id": parentID}).Info("Device group hierarchy set")
+
+	return &deviceGroup, nil
+}
+
+// GetDeviceGroupDescendantsIDs returns the ids of the children of a device group, of their children and so on
+func (s *DeviceGroupsService) GetDeviceGroupDescendantsIDs(orgID string, deviceGroupID uint) ([]uint, error) {
+	return s.getDescendantsIDs(orgID, []uint{deviceGroupID})
+}
+
+func (s *DeviceGroupsService) getDescendantsIDs(orgID string, deviceGroupsIDs []uint) ([]uint, error) {
+	visited := make(map[uint]bool, len(deviceGroupsIDs))
+	for _, ID := range deviceGroupsIDs {
+		visited[ID] = true
+	}
+	descendantsIDs := []uint{}
+	parentsIDs := deviceGroupsIDs
+	for len(parentsIDs) > 0 {
+		var childrenIDs []uint
+		if res := db.Org(orgID, "").Model(&models.DeviceGroup{}).Where("parent_id IN (?)", parentsIDs).
+			Pluck("id", &childrenIDs); res.Error != nil {
+			s.log.WithField("error", res.Error.Error()).Error("Error getting device group children")
+			return nil, res.Error
+		}
+		parentsIDs = nil
+		for _, childID := range childrenIDs {
+			// the visited groups guard against a cycle left in the database
+			if !visited[childID] {
+				visited[childID] = true
+				descendantsIDs = append(descendantsIDs, childID)
+				parentsIDs = append(parentsIDs, childID)
+			}
+		}
+	}
+	return descendantsIDs, nil
+}
+
+// GetDeviceGroupDevices returns the devices of a device group, optionally with the devices of its descendant groups
+func (s *DeviceGroupsService) GetDeviceGroupDevices(orgID string, deviceGroupID uint, includeDescendants bool) ([]models.Device, error) {
+	deviceGroupsIDs := []uint{deviceGroupID}
+	if includeDescendants {
+		descendantsIDs, err := s.GetDeviceGroupDescendantsIDs(orgID, deviceGroupID)
+		if err != nil {
+			return nil, err
+		}
+		deviceGroupsIDs = append(deviceGroupsIDs, descendantsIDs...)
+	}
+	var devices []models.Device
+	membersIDs := db.DB.Table("device_groups_devices").Select("device_id").Where("device_group_id IN (?)", deviceGroupsIDs)
+	if res := db.Org(orgID, "").Where("id IN (?)", membersIDs).Order("id").Find(&devices); res.Error != nil {
+		s.log.WithField("error", res.Error.Error()).Error("Error getting device group devices")
+		return nil, res.Error
+	}
+	return devices, nil
+}
+
+// ExpandDeviceGroupsAccess returns the ids of the device groups covered by the RBAC permissions on the given groups,
+// the permissions on a group cascading to its descendants cover them as well
+func (s *DeviceGroupsService) ExpandDeviceGroupsAccess(orgID string, deviceGroupsIDs []uint) ([]uint, error) {
+	if len(deviceGroupsIDs) == 0 {
+		return deviceGroupsIDs, nil
+	}
+	var cascadingIDs []uint
+	if res := db.Org(orgID, "").Model(&models.DeviceGroup{}).Where("id IN (?) AND cascade_rbac = ?", deviceGroupsIDs, true).
+		Pluck("id", &cascadingIDs); res.Error != nil {
+		s.log.WithField("error", res.Error.Error()).Error("Error getting cascading device groups")
+		return nil, res.Error
+	}
+	coveredIDs := append([]uint{}, deviceGroupsIDs...)
+	if len(cascadingIDs) == 0 {
+		return coveredIDs, nil
+	}
+	descendantsIDs, err := s.getDescendantsIDs(orgID, cascadingIDs)
+	if err != nil {
+		return nil, err
+	}
+	covered := make(map[uint]bool, len(coveredIDs))
+	for _, ID := range coveredIDs {
+		covered[ID] = true
+	}
+	for _, ID := range descendantsIDs {
+		if !covered[ID] {
+			covered[ID] = true
+			coveredIDs = append(coveredIDs, ID)
+		}
+	}
+	return coveredIDs, nil
+}
+
+// MoveDeviceGroupsDevices moves devices from a device group to another in a single transaction. When the inventory
+// groups are enabled for the organization and one of the groups is an inventory group, the devices move between the
+// inventory groups as well, they leave their inventory group when the target group is not an inventory group.
+// The transaction is rolled back when inventory fails. The devices not found in the source group are left as they are
+func (s *DeviceGroupsService) MoveDeviceGroupsDevices(orgID string, move *models.DeviceGroupsMove) (*models.DeviceGroupsMoveResult, error) {
+	if err := move.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "from_group_id": move.FromGroupID, "to_group_id": move.ToGroupID})
+	var fromGroup, toGroup models.DeviceGroup
+	if res := db.Org(orgID, "").First(&fromGroup, move.FromGroupID); res.Error != nil {
+		return nil, new(DeviceGroupNotFound)
+	}
+	if res := db.Org(orgID, "").First(&toGroup, move.ToGroupID); res.Error != nil {
+		return nil, new(DeviceGroupNotFound)
+	}
+	if fromGroup.Type == models.DeviceGroupTypeDynamic || toGroup.Type == models.DeviceGroupTypeDynamic {
+		return nil, new(DeviceGroupDynamicMembership)
+	}
+
+	var devices []models.Device
+	if res := db.Org(orgID, "").Where("uuid IN (?)", move.DevicesUUID).Find(&devices); res.Error != nil {
+		return nil, res.Error
+	}
+	devicesByUUID := make(map[string]models.Device, len(devices))
+	for _, device := range devices {
+		devicesByUUID[device.UUID] = device
+	}
+
+	syncInventory := feature.EdgeParityInventoryGroupsEnabled.IsEnabled() && !utility.EnforceEdgeGroups(orgID) &&
+		(fromGroup.UUID != "" || toGroup.UUID != "")
+	var result *models.DeviceGroupsMoveResult
+	var devicesToMove []models.Device
+	var devicesUUIDToMove []string
+	var inventoryRemoved, inventoryAdded bool
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// the source membership rows stay locked until the move ends, a concurrent move of the same devices waits for it
+		var sourceDevicesIDs []uint
+		if res := tx.Table("device_groups_devices").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("device_group_id = ? AND device_id IN (?)", fromGroup.ID, devicesIDs(devices)).
+			Pluck("device_id", &sourceDevicesIDs); res.Error != nil {
+			return res.Error
+		}
+		inSourceGroup := make(map[uint]bool, len(sourceDevicesIDs))
+		for _, ID := range sourceDevicesIDs {
+			inSourceGroup[ID] = true
+		}
+
+		result = &models.DeviceGroupsMoveResult{Devices: make([]models.DeviceMoveResult, 0, len(move.DevicesUUID))}
+		requested := make(map[string]bool, len(move.DevicesUUID))
+		for _, deviceUUID := range move.DevicesUUID {
+			if requested[deviceUUID] {
+				continue
+			}
+			requested[deviceUUID] = true
+			device, ok := devicesByUUID[deviceUUID]
+			switch {
+			case !ok:
+				result.Devices = append(result.Devices, models.DeviceMoveResult{DeviceUUID: deviceUUID, Status: models.DeviceMoveStatusNotFound})
+			case !inSourceGroup[device.ID]:
+				result.Devices = append(result.Devices,
+					models.DeviceMoveResult{DeviceUUID: deviceUUID, DeviceID: device.ID, Status: models.DeviceMoveStatusNotInSourceGroup})
+			default:
+				result.Devices = append(result.Devices, models.DeviceMoveResult{DeviceUUID: deviceUUID, DeviceID: device.ID, Status: models.DeviceMoveStatusMoved})
+				devicesToMove = append(devicesToMove, device)
+				devicesUUIDToMove = append(devicesUUIDToMove, device.UUID)
+			}
+		}
+		if len(devicesToMove) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&fromGroup).Association("Devices").Delete(devicesToMove); err != nil {
+			return err
+		}
+		// the devices already in the target group are not added twice
+		if err := tx.Model(&toGroup).Omit("Devices.*").Association("Devices").Append(devicesToMove); err != nil {
+			return err
+		}
+		if !syncInventory {
+			return nil
+		}
+		// the devices leave their inventory group when the target group is not an inventory group
+		inventoryGroup := map[string]interface{}{"group_uuid": "", "group_name": ""}
+		if toGroup.UUID != "" {
+			inventoryGroup = map[string]interface{}{"group_uuid": toGroup.UUID, "group_name": toGroup.Name}
+		}
+		if res := tx.Model(&models.Device{}).Where("id IN (?)", devicesIDs(devicesToMove)).Updates(inventoryGroup); res.Error != nil {
+			return res.Error
+		}
+		// inventory is the last step, the transaction is rolled back when it fails. A host belongs to a single
+		// inventory group, it leaves the source group before joining the target group
+		if fromGroup.UUID != "" {
+			if err := s.InventoryGroupsClient.RemoveHostsFromGroup(fromGroup.UUID, devicesUUIDToMove); err != nil {
+				return err
+			}
+			inventoryRemoved = true
+		}
+		if toGroup.UUID != "" {
+			if _, err := s.InventoryGroupsClient.AddHostsToGroup(toGroup.UUID, devicesUUIDToMove); err != nil {
+				return err
+			}
+			inventoryAdded = true
+		}
+		return nil
+	})
+	if err != nil {
+		// move back the inventory hosts to their source group
+		s.restoreInventoryHostsGroup(logger, &fromGroup, &toGroup, devicesUUIDToMove, inventoryRemoved, inventoryAdded)
+		logger.WithField("error", err.Error()).Error("Error moving devices between device groups, the move was rolled back")
+		return nil, err
+	}
+	if len(devicesToMove) == 0 {
+		return result, nil
+	}
+	result.Moved = len(devicesToMove)
+
+	events := make([]models.DeviceEvent, 0, 2*len(devicesToMove))
+	for _, device := range devicesToMove {
+		events = append(events,
+			models.DeviceEvent{OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupRemoved, From: fromGroup.Name},
+			models.DeviceEvent{OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupAdded, To: toGroup.Name})
+	}
+	createDeviceEvents(s.log, events...)
+	logger.WithFields(log.Fields{"moved": result.Moved, "inventory": syncInventory}).Info("Devices moved between device groups")
+
+	return result, nil
+}
+
+// restoreInventoryHostsGroup undoes the inventory steps of a rolled back move, the hosts added to the target inventory
+// group leave it and the hosts removed from the source inventory group join it back
+func (s *DeviceGroupsService) restoreInventoryHostsGroup(logger *log.Entry, fromGroup *models.DeviceGroup, toGroup *models.DeviceGroup,
+	devicesUUID []string, removed bool, added bool) {
+	if added {
+		if err := s.InventoryGroupsClient.RemoveHostsFromGroup(toGroup.UUID, devicesUUID); err != nil {
+			logger.WithFields(log.Fields{"error": err.Error(), "devices_uuid": devicesUUID}).
+				Error("Error removing the inventory hosts from the target group")
+		}
+	}
+	if removed {
+		if _, err := s.InventoryGroupsClient.AddHostsToGroup(fromGroup.UUID, devicesUUID); err != nil {
+			logger.WithFields(log.Fields{"error": err.Error(), "devices_uuid": devicesUUID}).
+				Error("Error moving back the inventory hosts to the source group")
+		}
+	}
+}
+
+func devicesIDs(devices []models.Device) []uint {
+	IDs := make([]uint, 0, len(devices))
+	for _, device := range devices {
+		IDs = append(IDs, device.ID)
+	}
+	return IDs
+}
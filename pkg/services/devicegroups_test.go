@@ -4,14 +4,20 @@ package services_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventorygroups"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventorygroups/mock_inventorygroups"
 	"github.com/redhatinsights/edge-api/pkg/models"
 	"github.com/redhatinsights/edge-api/pkg/routes/common"
 	"github.com/redhatinsights/edge-api/pkg/services"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
 
 	log "github.com/sirupsen/logrus"
 
@@ -532,4 +538,242 @@ var _ = Describe("DeviceGroupsService basic functions", func() {
 			})
 		})
 	})
+	Context("nested device groups", func() {
+		var orgID string
+		var region, site, line models.DeviceGroup
+		var regionDevice, lineDevice models.Device
+
+		BeforeEach(func() {
+			var err error
+			orgID, err = common.GetOrgIDFromContext(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			regionDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: faker.Name()}
+			lineDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: faker.Name()}
+			region = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault, Devices: []models.Device{regionDevice}}
+			Expect(db.DB.Create(&region).Error).ToNot(HaveOccurred())
+			regionDevice = region.Devices[0]
+			site = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault, ParentID: &region.ID}
+			Expect(db.DB.Create(&site).Error).ToNot(HaveOccurred())
+			line = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault, ParentID: &site.ID,
+				Devices: []models.Device{lineDevice}}
+			Expect(db.DB.Create(&line).Error).ToNot(HaveOccurred())
+			lineDevice = line.Devices[0]
+		})
+
+		It("should return the descendants of a group", func() {
+			descendantsIDs, err := deviceGroupsService.GetDeviceGroupDescendantsIDs(orgID, region.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(descendantsIDs).To(Equal([]uint{site.ID, line.ID}))
+
+			group, err := deviceGroupsService.GetDeviceGroupByID(fmt.Sprint(site.ID))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(group.HasChildren).To(BeTrue())
+			group, err = deviceGroupsService.GetDeviceGroupByID(fmt.Sprint(line.ID))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(group.HasChildren).To(BeFalse())
+		})
+
+		It("should return the devices of a group with the devices of its descendants", func() {
+			devices, err := deviceGroupsService.GetDeviceGroupDevices(orgID, region.ID, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(devices).To(HaveLen(1))
+			Expect(devices[0].ID).To(Equal(regionDevice.ID))
+
+			devices, err = deviceGroupsService.GetDeviceGroupDevices(orgID, region.ID, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(devices).To(HaveLen(2))
+			Expect([]uint{devices[0].ID, devices[1].ID}).To(Equal([]uint{regionDevice.ID, lineDevice.ID}))
+		})
+
+		It("should not nest a group under one of its descendants", func() {
+			_, err := deviceGroupsService.SetDeviceGroupHierarchy(orgID, region.ID, &line.ID, false)
+			Expect(err).To(MatchError(new(services.DeviceGroupHierarchyCycle)))
+			_, err = deviceGroupsService.SetDeviceGroupHierarchy(orgID, region.ID, &region.ID, false)
+			Expect(err).To(MatchError(new(services.DeviceGroupHierarchyCycle)))
+
+			group, err := deviceGroupsService.SetDeviceGroupHierarchy(orgID, line.ID, &region.ID, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*group.ParentID).To(Equal(region.ID))
+			Expect(group.CascadeRBAC).To(BeTrue())
+			group, err = deviceGroupsService.SetDeviceGroupHierarchy(orgID, line.ID, nil, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(group.ParentID).To(BeNil())
+		})
+
+		It("should not nest a group under a group of another org", func() {
+			otherGroup := models.DeviceGroup{OrgID: faker.UUIDHyphenated(), Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDefault}
+			Expect(db.DB.Create(&otherGroup).Error).ToNot(HaveOccurred())
+			_, err := deviceGroupsService.SetDeviceGroupHierarchy(orgID, site.ID, &otherGroup.ID, false)
+			Expect(err).To(MatchError(new(services.DeviceGroupNotFound)))
+		})
+
+		It("should cover the descendants of the groups cascading their permissions", func() {
+			coveredIDs, err := deviceGroupsService.ExpandDeviceGroupsAccess(orgID, []uint{region.ID})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(coveredIDs).To(Equal([]uint{region.ID}))
+
+			Expect(db.DB.Model(&models.DeviceGroup{}).Where("id = ?", region.ID).Update("cascade_rbac", true).Error).ToNot(HaveOccurred())
+			coveredIDs, err = deviceGroupsService.ExpandDeviceGroupsAccess(orgID, []uint{region.ID})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(coveredIDs).To(Equal([]uint{region.ID, site.ID, line.ID}))
+		})
+	})
+	Context("moving devices between device groups", func() {
+		var orgID string
+		var fromGroup, toGroup models.DeviceGroup
+		var movedDevice, otherDevice models.Device
+
+		groupDevicesUUID := func(group models.DeviceGroup) []string {
+			var devices []models.Device
+			Expect(db.DB.Model(&group).Association("Devices").Find(&devices)).To(Succeed())
+			devicesUUID := make([]string, 0, len(devices))
+			for _, device := range devices {
+				devicesUUID = append(devicesUUID, device.UUID)
+			}
+			return devicesUUID
+		}
+
+		BeforeEach(func() {
+			var err error
+			orgID, err = common.GetOrgIDFromContext(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			fromGroup = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic,
+				Devices: []models.Device{{OrgID: orgID, UUID: faker.UUIDHyphenated()}}}
+			Expect(db.DB.Create(&fromGroup).Error).ToNot(HaveOccurred())
+			movedDevice = fromGroup.Devices[0]
+			toGroup = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic, UUID: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&toGroup).Error).ToNot(HaveOccurred())
+			otherDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&otherDevice).Error).ToNot(HaveOccurred())
+		})
+
+		It("should move the devices of the source group and report the others", func() {
+			unknownUUID := faker.UUIDHyphenated()
+			result, err := deviceGroupsService.MoveDeviceGroupsDevices(orgID, &models.DeviceGroupsMove{
+				FromGroupID: fromGroup.ID, ToGroupID: toGroup.ID, DevicesUUID: []string{movedDevice.UUID, otherDevice.UUID, unknownUUID},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Moved).To(Equal(1))
+			Expect(result.Devices).To(Equal([]models.DeviceMoveResult{
+				{DeviceUUID: movedDevice.UUID, DeviceID: movedDevice.ID, Status: models.DeviceMoveStatusMoved},
+				{DeviceUUID: otherDevice.UUID, DeviceID: otherDevice.ID, Status: models.DeviceMoveStatusNotInSourceGroup},
+				{DeviceUUID: unknownUUID, Status: models.DeviceMoveStatusNotFound},
+			}))
+			Expect(groupDevicesUUID(fromGroup)).To(BeEmpty())
+			Expect(groupDevicesUUID(toGroup)).To(Equal([]string{movedDevice.UUID}))
+		})
+
+		When("the inventory groups are enabled", func() {
+			var ctrl *gomock.Controller
+			var mockInventoryGroupsClient *mock_inventorygroups.MockClientInterface
+			var inventoryDeviceGroupsService services.DeviceGroupsServiceInterface
+
+			BeforeEach(func() {
+				ctrl = gomock.NewController(GinkgoT())
+				mockInventoryGroupsClient = mock_inventorygroups.NewMockClientInterface(ctrl)
+				inventoryDeviceGroupsService = &services.DeviceGroupsService{
+					Service:               services.NewService(ctx, log.NewEntry(log.StandardLogger())),
+					InventoryGroupsClient: mockInventoryGroupsClient,
+				}
+				os.Setenv(feature.EdgeParityInventoryGroupsEnabled.EnvVar, "true")
+			})
+			AfterEach(func() {
+				os.Unsetenv(feature.EdgeParityInventoryGroupsEnabled.EnvVar)
+				ctrl.Finish()
+			})
+
+			It("should move the devices to the target inventory group", func() {
+				mockInventoryGroupsClient.EXPECT().AddHostsToGroup(toGroup.UUID, []string{movedDevice.UUID}).
+					Return(&inventorygroups.Group{ID: toGroup.UUID}, nil)
+				result, err := inventoryDeviceGroupsService.MoveDeviceGroupsDevices(orgID, &models.DeviceGroupsMove{
+					FromGroupID: fromGroup.ID, ToGroupID: toGroup.ID, DevicesUUID: []string{movedDevice.UUID},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Moved).To(Equal(1))
+				var device models.Device
+				Expect(db.DB.First(&device, movedDevice.ID).Error).ToNot(HaveOccurred())
+				Expect(device.GroupUUID).To(Equal(toGroup.UUID))
+				Expect(device.GroupName).To(Equal(toGroup.Name))
+			})
+
+			It("should not move the devices in inventory when the organization enforces edge groups", func() {
+				os.Setenv(feature.EnforceEdgeGroups.EnvVar, "true")
+				defer os.Unsetenv(feature.EnforceEdgeGroups.EnvVar)
+				result, err := inventoryDeviceGroupsService.MoveDeviceGroupsDevices(orgID, &models.DeviceGroupsMove{
+					FromGroupID: fromGroup.ID, ToGroupID: toGroup.ID, DevicesUUID: []string{movedDevice.UUID},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Moved).To(Equal(1))
+				Expect(groupDevicesUUID(toGroup)).To(Equal([]string{movedDevice.UUID}))
+				var device models.Device
+				Expect(db.DB.First(&device, movedDevice.ID).Error).ToNot(HaveOccurred())
+				Expect(device.GroupUUID).To(BeEmpty())
+			})
+
+			It("should move the devices from the source inventory group to the target inventory group", func() {
+				fromGroup.UUID = faker.UUIDHyphenated()
+				Expect(db.DB.Model(&models.DeviceGroup{}).Where("id = ?", fromGroup.ID).Update("uuid", fromGroup.UUID).Error).ToNot(HaveOccurred())
+				gomock.InOrder(
+					mockInventoryGroupsClient.EXPECT().RemoveHostsFromGroup(fromGroup.UUID, []string{movedDevice.UUID}).Return(nil),
+					mockInventoryGroupsClient.EXPECT().AddHostsToGroup(toGroup.UUID, []string{movedDevice.UUID}).
+						Return(&inventorygroups.Group{ID: toGroup.UUID}, nil),
+				)
+				result, err := inventoryDeviceGroupsService.MoveDeviceGroupsDevices(orgID, &models.DeviceGroupsMove{
+					FromGroupID: fromGroup.ID, ToGroupID: toGroup.ID, DevicesUUID: []string{movedDevice.UUID},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Moved).To(Equal(1))
+			})
+
+			It("should remove the devices from their inventory group when the target group is not an inventory group", func() {
+				fromGroup.UUID = faker.UUIDHyphenated()
+				Expect(db.DB.Model(&models.DeviceGroup{}).Where("id = ?", fromGroup.ID).Update("uuid", fromGroup.UUID).Error).ToNot(HaveOccurred())
+				Expect(db.DB.Model(&models.Device{}).Where("id = ?", movedDevice.ID).
+					Updates(map[string]interface{}{"group_uuid": fromGroup.UUID, "group_name": fromGroup.Name}).Error).ToNot(HaveOccurred())
+				Expect(db.DB.Model(&models.DeviceGroup{}).Where("id = ?", toGroup.ID).Update("uuid", "").Error).ToNot(HaveOccurred())
+				mockInventoryGroupsClient.EXPECT().RemoveHostsFromGroup(fromGroup.UUID, []string{movedDevice.UUID}).Return(nil)
+				result, err := inventoryDeviceGroupsService.MoveDeviceGroupsDevices(orgID, &models.DeviceGroupsMove{
+					FromGroupID: fromGroup.ID, ToGroupID: toGroup.ID, DevicesUUID: []string{movedDevice.UUID},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Moved).To(Equal(1))
+				var device models.Device
+				Expect(db.DB.First(&device, movedDevice.ID).Error).ToNot(HaveOccurred())
+				Expect(device.GroupUUID).To(BeEmpty())
+				Expect(device.GroupName).To(BeEmpty())
+			})
+
+			It("should move back the devices to the source inventory group when the target inventory group fails", func() {
+				fromGroup.UUID = faker.UUIDHyphenated()
+				Expect(db.DB.Model(&models.DeviceGroup{}).Where("id = ?", fromGroup.ID).Update("uuid", fromGroup.UUID).Error).ToNot(HaveOccurred())
+				gomock.InOrder(
+					mockInventoryGroupsClient.EXPECT().RemoveHostsFromGroup(fromGroup.UUID, []string{movedDevice.UUID}).Return(nil),
+					mockInventoryGroupsClient.EXPECT().AddHostsToGroup(toGroup.UUID, []string{movedDevice.UUID}).
+						Return(nil, errors.New("inventory is unavailable")),
+					mockInventoryGroupsClient.EXPECT().AddHostsToGroup(fromGroup.UUID, []string{movedDevice.UUID}).
+						Return(&inventorygroups.Group{ID: fromGroup.UUID}, nil),
+				)
+				_, err := inventoryDeviceGroupsService.MoveDeviceGroupsDevices(orgID, &models.DeviceGroupsMove{
+					FromGroupID: fromGroup.ID, ToGroupID: toGroup.ID, DevicesUUID: []string{movedDevice.UUID},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(groupDevicesUUID(fromGroup)).To(Equal([]string{movedDevice.UUID}))
+				Expect(groupDevicesUUID(toGroup)).To(BeEmpty())
+			})
+
+			It("should roll back the move when inventory fails", func() {
+				mockInventoryGroupsClient.EXPECT().AddHostsToGroup(toGroup.UUID, []string{movedDevice.UUID}).
+					Return(nil, errors.New("inventory is unavailable"))
+				_, err := inventoryDeviceGroupsService.MoveDeviceGroupsDevices(orgID, &models.DeviceGroupsMove{
+					FromGroupID: fromGroup.ID, ToGroupID: toGroup.ID, DevicesUUID: []string{movedDevice.UUID},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(groupDevicesUUID(fromGroup)).To(Equal([]string{movedDevice.UUID}))
+				Expect(groupDevicesUUID(toGroup)).To(BeEmpty())
+				var device models.Device
+				Expect(db.DB.First(&device, movedDevice.ID).Error).ToNot(HaveOccurred())
+				Expect(device.GroupUUID).To(BeEmpty())
+			})
+		})
+	})
 })
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DesiredStateServiceInterface defines the interface that helps handling the desired states of the device groups
+type DesiredStateServiceInterface interface {
+	GetDeviceGroupDesiredState(orgID string, deviceGroupID uint) (*models.DeviceGroupDesiredState, error)
+	SetDeviceGroupDesiredState(orgID string, deviceGroupID uint, state *models.DeviceGroupDesiredState) (*models.DeviceGroupDesiredState, error)
+	DeleteDeviceGroupDesiredState(orgID string, deviceGroupID uint) error
+	GetDeviceGroupDrift(orgID string, deviceGroupID uint) (*models.DeviceGroupDrift, error)
+	ReconcileDeviceGroup(orgID string, deviceGroupID uint) ([]models.UpdateTransaction, error)
+}
+
+// NewDesiredStateService gives an instance of the main implementation of DesiredStateServiceInterface
+func NewDesiredStateService(ctx context.Context, log log.FieldLogger) DesiredStateServiceInterface {
+	return &DesiredStateService{
+		Service:              Service{ctx: ctx, log: log.WithField("service", "desired-state")},
+		UpdateServiceFactory: NewUpdateService,
+	}
+}
+
+// DesiredStateService is the main implementation of a DesiredStateServiceInterface
+type DesiredStateService struct {
+	Service
+	// UpdateServiceFactory gives the update service creating the update transactions reconciling the devices
+	UpdateServiceFactory func(ctx context.Context, log log.FieldLogger) UpdateServiceInterface
+}
+
+// GetDeviceGroupDesiredState returns the desired state of a device group
+func (s *DesiredStateService) GetDeviceGroupDesiredState(orgID string, deviceGroupID uint) (*models.DeviceGroupDesiredState, error) {
+	var state models.DeviceGroupDesiredState
+	if result := db.Org(orgID, "").Where("device_group_id = ?", deviceGroupID).First(&state); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DesiredStateNotFound)
+		}
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "device_group_id": deviceGroupID}).Error("error getting desired state")
+		return nil, result.Error
+	}
+	return &state, nil
+}
+
+// SetDeviceGroupDesiredState pins the devices of a device group to a successful version of an image set
+func (s *DesiredStateService) SetDeviceGroupDesiredState(orgID string, deviceGroupID uint, state *models.DeviceGroupDesiredState) (*models.DeviceGroupDesiredState, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "device_group_id": deviceGroupID, "image_set_id": state.ImageSetID})
+	if err := state.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	var imageSet models.ImageSet
+	if result := db.Org(orgID, "").First(&imageSet, state.ImageSetID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageSetNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error getting image-set")
+		return nil, result.Error
+	}
+	var image models.Image
+	if result := db.Org(orgID, "").Where("image_set_id = ? AND version = ? AND status = ?",
+		state.ImageSetID, state.Version, models.ImageStatusSuccess).First(&image); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error getting image-set version")
+		return nil, result.Error
+	}
+
+	// the upsert keeps a single desired state when the state of a group is set concurrently
+	desired := models.DeviceGroupDesiredState{
+		OrgID: orgID, DeviceGroupID: deviceGroupID, ImageSetID: state.ImageSetID, Version: state.Version, ImageID: image.ID,
+	}
+	if result := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "device_group_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"image_set_id", "version", "image_id", "updated_at"}),
+	}).Create(&desired); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error saving desired state")
+		return nil, result.Error
+	}
+	existing, err := s.GetDeviceGroupDesiredState(orgID, deviceGroupID)
+	if err != nil {
+		return nil, err
+	}
+	logger.WithField("version", existing.Version).Info("device group desired state set")
+
+	return existing, nil
+}
+
+// DeleteDeviceGroupDesiredState unpins the devices of a device group
+func (s *DesiredStateService) DeleteDeviceGroupDesiredState(orgID string, deviceGroupID uint) error {
+	state, err := s.GetDeviceGroupDesiredState(orgID, deviceGroupID)
+	if err != nil {
+		return err
+	}
+	// a deleted state is not kept, the device group has at most one desired state
+	if result := db.DB.Unscoped().Delete(state); result.Error != nil {
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "device_group_id": deviceGroupID}).Error("error deleting desired state")
+		return result.Error
+	}
+	return nil
+}
+
+// GetDeviceGroupDrift returns the drift of the devices of a device group from its desired state
+func (s *DesiredStateService) GetDeviceGroupDrift(orgID string, deviceGroupID uint) (*models.DeviceGroupDrift, error) {
+	state, err := s.GetDeviceGroupDesiredState(orgID, deviceGroupID)
+	if err != nil {
+		return nil, err
+	}
+	var devices []models.Device
+	membersIDs := db.DB.Table("device_groups_devices").Select("device_id").Where("device_group_id = ?", deviceGroupID)
+	if result := db.Org(orgID, "").Where("id IN (?)", membersIDs).Order("id").Find(&devices); result.Error != nil {
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "device_group_id": deviceGroupID}).Error("error getting device group devices")
+		return nil, result.Error
+	}
+	desiredImagesIDs := make(map[uint]uint, len(devices))
+	for _, device := range devices {
+		desiredImagesIDs[device.ID] = state.ImageID
+	}
+	images, err := getDriftImages(orgID, devices, desiredImagesIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &models.DeviceGroupDrift{DesiredState: state, Devices: make([]models.DeviceDrift, 0, len(devices))}
+	desiredImage, ok := images[state.ImageID]
+	if !ok {
+		return nil, new(ImageNotFoundError)
+	}
+	if desiredImage.Commit != nil {
+		drift.CommitHash = desiredImage.Commit.OSTreeCommit
+	}
+	for i := range devices {
+		deviceImage := images[devices[i].ImageID]
+		deviceDrift := models.DeviceDrift{
+			DeviceID:    devices[i].ID,
+			DeviceUUID:  devices[i].UUID,
+			DeviceName:  devices[i].Name,
+			ImageID:     devices[i].ImageID,
+			CurrentHash: devices[i].CurrentHash,
+			Status:      models.DeviceDriftStatus(&devices[i], deviceImage, desiredImage),
+		}
+		if deviceImage != nil {
+			deviceDrift.ImageVersion = deviceImage.Version
+		}
+		drift.Counts.Add(deviceDrift.Status)
+		drift.Devices = append(drift.Devices, deviceDrift)
+	}
+	return drift, nil
+}
+
+// ReconcileDeviceGroup creates the update transactions converging the drifting devices of a device group
+// to its desired state, the devices ahead of the desired state or running an unknown image are left as they are.
+// The devices running another image set are not moved to the desired image set, they are left drifting
+func (s *DesiredStateService) ReconcileDeviceGroup(orgID string, deviceGroupID uint) ([]models.UpdateTransaction, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "device_group_id": deviceGroupID})
+	drift, err := s.GetDeviceGroupDrift(orgID, deviceGroupID)
+	if err != nil {
+		return nil, err
+	}
+	var devicesUUID []string
+	for _, device := range drift.Devices {
+		if device.Status == models.DeviceDriftStatusDrifting {
+			devicesUUID = append(devicesUUID, device.DeviceUUID)
+		}
+	}
+	devicesUUID, err = s.excludeOtherImageSetsDevices(orgID, drift.DesiredState.ImageSetID, devicesUUID)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("error excluding the devices running other image sets")
+		return nil, err
+	}
+	// the stale devices are not reconciled
+	devicesUUID, err = ExcludeStaleDevices(orgID, devicesUUID)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("error excluding stale devices")
+		return nil, err
+	}
+	if len(devicesUUID) == 0 {
+		return []models.UpdateTransaction{}, nil
+	}
+	var image models.Image
+	if result := db.Org(orgID, "").Preload("Commit").First(&image, drift.DesiredState.ImageID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageNotFoundError)
+		}
+		return nil, result.Error
+	}
+
+	updateService := s.UpdateServiceFactory(s.ctx, s.log)
+	updates, err := updateService.BuildUpdateTransactions(s.ctx,
+		&models.DevicesUpdate{CommitID: image.CommitID, DevicesUUID: devicesUUID}, orgID, image.Commit)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("error building the reconcile update transactions")
+		return nil, err
+	}
+	for _, update := range *updates {
+		// updates awaiting approval are started once approved
+		if update.Status != models.UpdateStatusDeviceDisconnected && update.Status != models.UpdateStatusAwaitingApproval {
+			updateService.CreateUpdateAsync(update.ID)
+		}
+	}
+	logger.WithField("updates_count", len(*updates)).Info("device group reconciled to its desired state")
+	return *updates, nil
+}
+
+// excludeOtherImageSetsDevices returns the devices running a version of the image set, the others are logged
+func (s *DesiredStateService) excludeOtherImageSetsDevices(orgID string, imageSetID uint, devicesUUID []string) ([]string, error) {
+	if len(devicesUUID) == 0 {
+		return devicesUUID, nil
+	}
+	var imageSetDevicesUUID []string
+	if result := db.Org(orgID, "devices").Model(&models.Device{}).
+		Joins("JOIN images ON images.id = devices.image_id").
+		Where("devices.uuid IN (?) AND images.image_set_id = ?", devicesUUID, imageSetID).
+		Pluck("devices.uuid", &imageSetDevicesUUID); result.Error != nil {
+		return nil, result.Error
+	}
+	if skipped := len(devicesUUID) - len(imageSetDevicesUUID); skipped > 0 {
+		s.log.WithFields(log.Fields{"org_id": orgID, "image_set_id": imageSetID, "devices_count": skipped}).
+			Warning("drifting devices running another image set are not reconciled")
+	}
+	return imageSetDevicesUUID, nil
+}
+
+// getDriftImages returns the images the devices run and their desired images with their commits, by image id
+func getDriftImages(orgID string, devices []models.Device, desiredImagesIDs map[uint]uint) (map[uint]*models.Image, error) {
+	imagesIDs := make([]uint, 0, len(devices)+1)
+	for _, device := range devices {
+		if device.ImageID != 0 {
+			imagesIDs = append(imagesIDs, device.ImageID)
+		}
+	}
+	for _, imageID := range desiredImagesIDs {
+		imagesIDs = append(imagesIDs, imageID)
+	}
+	images := make(map[uint]*models.Image)
+	if len(imagesIDs) == 0 {
+		return images, nil
+	}
+	var imagesList []models.Image
+	if result := db.Org(orgID, "").Preload("Commit").Where("id IN (?)", imagesIDs).Find(&imagesList); result.Error != nil {
+		return nil, result.Error
+	}
+	for i := range imagesList {
+		images[imagesList[i].ID] = &imagesList[i]
+	}
+	return images, nil
+}
+
+// getDeviceGroupsDesiredImages returns the desired image ids of the device groups that have a desired state, by group id
+func getDeviceGroupsDesiredImages(orgID string, deviceGroupsIDs []uint) (map[uint]uint, error) {
+	desiredImagesIDs := make(map[uint]uint)
+	if len(deviceGroupsIDs) == 0 {
+		return desiredImagesIDs, nil
+	}
+	var states []models.DeviceGroupDesiredState
+	if result := db.Org(orgID, "").Where("device_group_id IN (?)", deviceGroupsIDs).Find(&states); result.Error != nil {
+		return nil, result.Error
+	}
+	for _, state := range states {
+		desiredImagesIDs[state.DeviceGroupID] = state.ImageID
+	}
+	return desiredImagesIDs, nil
+}
+
+// getDeviceGroupsDriftCounts returns the drift counts of the device groups that have a desired state, by group id.
+// The groups devices must be loaded
+func getDeviceGroupsDriftCounts(orgID string, deviceGroups []models.DeviceGroup) (map[uint]*models.DeviceDriftCounts, error) {
+	deviceGroupsIDs := make([]uint, 0, len(deviceGroups))
+	for _, group := range deviceGroups {
+		deviceGroupsIDs = append(deviceGroupsIDs, group.ID)
+	}
+	groupsDesiredImages, err := getDeviceGroupsDesiredImages(orgID, deviceGroupsIDs)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]*models.DeviceDriftCounts, len(groupsDesiredImages))
+	if len(groupsDesiredImages) == 0 {
+		return counts, nil
+	}
+	var devices []models.Device
+	for _, group := range deviceGroups {
+		if _, ok := groupsDesiredImages[group.ID]; ok {
+			devices = append(devices, group.Devices...)
+		}
+	}
+	images, err := getDriftImages(orgID, devices, groupsDesiredImages)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range deviceGroups {
+		desiredImage, ok := images[groupsDesiredImages[group.ID]]
+		if !ok {
+			continue
+		}
+		groupCounts := &models.DeviceDriftCounts{}
+		for i := range group.Devices {
+			groupCounts.Add(models.DeviceDriftStatus(&group.Devices[i], images[group.Devices[i].ImageID], desiredImage))
+		}
+		counts[group.ID] = groupCounts
+	}
+	return counts, nil
+}
+
+// getDevicesDriftStatus returns the drift status of the devices that belong to a device group with a desired state,
+// by device id. When a device belongs to several of them, the group with the lowest id prevails.
+// The devices groups must be loaded
+func getDevicesDriftStatus(orgID string, devices []models.Device) (map[uint]string, error) {
+	var deviceGroupsIDs []uint
+	for _, device := range devices {
+		for _, group := range device.DevicesGroups {
+			deviceGroupsIDs = append(deviceGroupsIDs, group.ID)
+		}
+	}
+	statuses := make(map[uint]string)
+	groupsDesiredImages, err := getDeviceGroupsDesiredImages(orgID, deviceGroupsIDs)
+	if err != nil || len(groupsDesiredImages) == 0 {
+		return statuses, err
+	}
+	var pinnedDevices []models.Device
+	devicesDesiredImages := make(map[uint]uint)
+	for _, device := range devices {
+		var pinnedGroupID uint
+		for _, group := range device.DevicesGroups {
+			if _, ok := groupsDesiredImages[group.ID]; ok && (pinnedGroupID == 0 || group.ID < pinnedGroupID) {
+				pinnedGroupID = group.ID
+			}
+		}
+		if pinnedGroupID != 0 {
+			pinnedDevices = append(pinnedDevices, device)
+			devicesDesiredImages[device.ID] = groupsDesiredImages[pinnedGroupID]
+		}
+	}
+	images, err := getDriftImages(orgID, pinnedDevices, devicesDesiredImages)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pinnedDevices {
+		if desiredImage, ok := images[devicesDesiredImages[pinnedDevices[i].ID]]; ok {
+			statuses[pinnedDevices[i].ID] = models.DeviceDriftStatus(&pinnedDevices[i], images[pinnedDevices[i].ImageID], desiredImage)
+		}
+	}
+	return statuses, nil
+}
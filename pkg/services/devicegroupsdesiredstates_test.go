@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"context"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+var _ = Describe("DesiredStateService", func() {
+	var ctrl *gomock.Controller
+	var mockUpdateService *mock_services.MockUpdateServiceInterface
+	var service services.DesiredStateServiceInterface
+	var orgID string
+	var imageSet models.ImageSet
+	var deviceGroup models.DeviceGroup
+	var images []models.Image
+	var drifting, inSync, ahead, unknown models.Device
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockUpdateService = mock_services.NewMockUpdateServiceInterface(ctrl)
+		service = &services.DesiredStateService{
+			Service: services.NewService(context.Background(), log.WithField("service", "desired-state")),
+			UpdateServiceFactory: func(_ context.Context, _ log.FieldLogger) services.UpdateServiceInterface {
+				return mockUpdateService
+			},
+		}
+
+		orgID = faker.UUIDHyphenated()
+		imageSet = models.ImageSet{OrgID: orgID, Name: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+		images = make([]models.Image, 3)
+		for i := range images {
+			images[i] = models.Image{
+				OrgID: orgID, Name: imageSet.Name, ImageSetID: &imageSet.ID, Version: i + 1, Status: models.ImageStatusSuccess,
+				Commit: &models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDDigit()},
+			}
+			Expect(db.DB.Create(&images[i]).Error).ToNot(HaveOccurred())
+		}
+		deviceGroup = models.DeviceGroup{
+			OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic,
+			Devices: []models.Device{
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: images[0].ID},
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: images[1].ID},
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: images[2].ID},
+				{OrgID: orgID, UUID: faker.UUIDHyphenated()},
+			},
+		}
+		Expect(db.DB.Create(&deviceGroup).Error).ToNot(HaveOccurred())
+		drifting, inSync, ahead, unknown = deviceGroup.Devices[0], deviceGroup.Devices[1], deviceGroup.Devices[2], deviceGroup.Devices[3]
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("SetDeviceGroupDesiredState", func() {
+		It("should pin the device group to the image set version", func() {
+			state, err := service.SetDeviceGroupDesiredState(orgID, deviceGroup.ID, &models.DeviceGroupDesiredState{ImageSetID: imageSet.ID, Version: 2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.ImageID).To(Equal(images[1].ID))
+
+			state, err = service.SetDeviceGroupDesiredState(orgID, deviceGroup.ID, &models.DeviceGroupDesiredState{ImageSetID: imageSet.ID, Version: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.ImageID).To(Equal(images[0].ID))
+			var count int64
+			Expect(db.DB.Model(&models.DeviceGroupDesiredState{}).Where("device_group_id = ?", deviceGroup.ID).Count(&count).Error).ToNot(HaveOccurred())
+			Expect(count).To(Equal(int64(1)))
+		})
+
+		It("should pin again the device group once unpinned", func() {
+			_, err := service.SetDeviceGroupDesiredState(orgID, deviceGroup.ID, &models.DeviceGroupDesiredState{ImageSetID: imageSet.ID, Version: 2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.DeleteDeviceGroupDesiredState(orgID, deviceGroup.ID)).To(Succeed())
+
+			state, err := service.SetDeviceGroupDesiredState(orgID, deviceGroup.ID, &models.DeviceGroupDesiredState{ImageSetID: imageSet.ID, Version: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.ImageID).To(Equal(images[0].ID))
+		})
+
+		It("should not pin the device group to a version that does not exist", func() {
+			_, err := service.SetDeviceGroupDesiredState(orgID, deviceGroup.ID, &models.DeviceGroupDesiredState{ImageSetID: imageSet.ID, Version: 9})
+			Expect(err).To(MatchError(new(services.ImageNotFoundError)))
+		})
+	})
+
+	When("the device group has a desired state", func() {
+		BeforeEach(func() {
+			_, err := service.SetDeviceGroupDesiredState(orgID, deviceGroup.ID, &models.DeviceGroupDesiredState{ImageSetID: imageSet.ID, Version: 2})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should report the drift of the devices", func() {
+			drift, err := service.GetDeviceGroupDrift(orgID, deviceGroup.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(drift.CommitHash).To(Equal(images[1].Commit.OSTreeCommit))
+			Expect(drift.Counts).To(Equal(models.DeviceDriftCounts{InSync: 1, Drifting: 1, Ahead: 1, Unknown: 1}))
+			statuses := make(map[uint]string)
+			for _, device := range drift.Devices {
+				statuses[device.DeviceID] = device.Status
+			}
+			Expect(statuses).To(Equal(map[uint]string{
+				drifting.ID: models.DeviceDriftStatusDrifting, inSync.ID: models.DeviceDriftStatusInSync,
+				ahead.ID: models.DeviceDriftStatusAhead, unknown.ID: models.DeviceDriftStatusUnknown,
+			}))
+		})
+
+		It("should update the drifting devices of the image set only", func() {
+			otherImageSet := models.ImageSet{OrgID: orgID, Name: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&otherImageSet).Error).ToNot(HaveOccurred())
+			otherImage := models.Image{OrgID: orgID, Name: otherImageSet.Name, ImageSetID: &otherImageSet.ID, Version: 1, Status: models.ImageStatusSuccess}
+			Expect(db.DB.Create(&otherImage).Error).ToNot(HaveOccurred())
+			otherDevice := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: otherImage.ID}
+			Expect(db.DB.Create(&otherDevice).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Exec("INSERT INTO device_groups_devices (device_group_id, device_id) VALUES (?, ?)", deviceGroup.ID, otherDevice.ID).Error).
+				ToNot(HaveOccurred())
+
+			drift, err := service.GetDeviceGroupDrift(orgID, deviceGroup.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(drift.Counts.Drifting).To(Equal(2))
+
+			mockUpdateService.EXPECT().BuildUpdateTransactions(gomock.Any(), gomock.Any(), orgID, gomock.Any()).DoAndReturn(
+				func(_ context.Context, devicesUpdate *models.DevicesUpdate, _ string, commit *models.Commit) (*[]models.UpdateTransaction, error) {
+					Expect(devicesUpdate.DevicesUUID).To(Equal([]string{drifting.UUID}))
+					Expect(commit.ID).To(Equal(images[1].CommitID))
+					return &[]models.UpdateTransaction{{Model: models.Model{ID: 1}, Status: models.UpdateStatusCreated}}, nil
+				})
+			mockUpdateService.EXPECT().CreateUpdateAsync(uint(1))
+
+			updates, err := service.ReconcileDeviceGroup(orgID, deviceGroup.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updates).To(HaveLen(1))
+		})
+
+		It("should add the drift to the device groups list and the devices view", func() {
+			deviceGroupsService := services.NewDeviceGroupsService(context.Background(), log.NewEntry(log.StandardLogger()))
+			groups, err := deviceGroupsService.GetDeviceGroups(orgID, 10, 0, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*groups).To(HaveLen(1))
+			Expect((*groups)[0].Drift).To(Equal(&models.DeviceDriftCounts{InSync: 1, Drifting: 1, Ahead: 1, Unknown: 1}))
+
+			var devices []models.Device
+			Expect(db.DB.Preload("DevicesGroups").Where("id IN (?)", []uint{drifting.ID, ahead.ID}).Order("id").Find(&devices).Error).ToNot(HaveOccurred())
+			view, err := services.ReturnDevicesView(devices, orgID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(view[0].DriftStatus).To(Equal(models.DeviceDriftStatusDrifting))
+			Expect(view[1].DriftStatus).To(Equal(models.DeviceDriftStatusAhead))
+		})
+	})
+})
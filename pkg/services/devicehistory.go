@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DeviceHistoryServiceInterface defines the interface that helps handle the history timeline of a device
+type DeviceHistoryServiceInterface interface {
+	GetDeviceHistory(orgID string, deviceUUID string, limit int, offset int) (*models.DeviceHistory, error)
+	GetDeviceConnectivity(orgID string, deviceUUID string, days int) (*models.DeviceConnectivity, error)
+	GetDeviceGroupConnectivity(orgID string, deviceGroupID uint, days int) (*models.DeviceGroupConnectivity, error)
+}
+
+// NewDeviceHistoryService gives an instance of the main implementation of DeviceHistoryServiceInterface
+func NewDeviceHistoryService(ctx context.Context, log log.FieldLogger) DeviceHistoryServiceInterface {
+	return &DeviceHistoryService{
+		Service: Service{ctx: ctx, log: log.WithField("service", "device-history")},
+	}
+}
+
+// DeviceHistoryService is the main implementation of a DeviceHistoryServiceInterface
+type DeviceHistoryService struct {
+	Service
+}
+
+// GetDeviceHistory returns a page of the device history timeline, merging the device update attempts and the device events
+func (s *DeviceHistoryService) GetDeviceHistory(orgID string, deviceUUID string, limit int, offset int) (*models.DeviceHistory, error) {
+	var device models.Device
+	if result := db.Org(orgID, "").Where("uuid = ?", deviceUUID).First(&device); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DeviceNotFoundError)
+		}
+		s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while getting device")
+		return nil, result.Error
+	}
+
+	updatesQuery := db.Org(orgID, "update_transactions").Model(&models.UpdateTransaction{}).
+		Joins("JOIN updatetransaction_devices ON updatetransaction_devices.update_transaction_id = update_transactions.id").
+		Where("updatetransaction_devices.device_id = ?", device.ID)
+	var updatesCount int64
+	if result := updatesQuery.Count(&updatesCount); result.Error != nil {
+		s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while counting device updates")
+		return nil, result.Error
+	}
+	var eventsCount int64
+	if result := db.Org(orgID, "").Model(&models.DeviceEvent{}).Where("device_id = ?", device.ID).Count(&eventsCount); result.Error != nil {
+		s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while counting device events")
+		return nil, result.Error
+	}
+	history := models.DeviceHistory{Count: updatesCount + eventsCount, Data: []models.DeviceHistoryEvent{}}
+	if int64(offset) >= history.Count {
+		return &history, nil
+	}
+	if limit <= 0 {
+		limit = int(history.Count) - offset
+	}
+
+	// the page is selected in a single timeline of the update transactions and the device events ids
+	var entries []struct {
+		Source string
+		ID     uint
+	}
+	if result := db.DB.Raw(`SELECT 'update' AS source, update_transactions.id AS id, update_transactions.created_at AS event_time
+		FROM update_transactions JOIN updatetransaction_devices ON updatetransaction_devices.update_transaction_id = update_transactions.id
+		WHERE update_transactions.org_id = ? AND update_transactions.deleted_at IS NULL AND updatetransaction_devices.device_id = ?
+		UNION ALL
+		SELECT 'event' AS source, device_events.id AS id, device_events.created_at AS event_time
+		FROM device_events WHERE device_events.org_id = ? AND device_events.deleted_at IS NULL AND device_events.device_id = ?
+		ORDER BY event_time DESC, source DESC, id DESC LIMIT ? OFFSET ?`,
+		orgID, device.ID, orgID, device.ID, limit, offset).Scan(&entries); result.Error != nil {
+		s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while getting device history page")
+		return nil, result.Error
+	}
+	var updatesIDs, eventsIDs []uint
+	for _, entry := range entries {
+		if entry.Source == "update" {
+			updatesIDs = append(updatesIDs, entry.ID)
+		} else {
+			eventsIDs = append(eventsIDs, entry.ID)
+		}
+	}
+
+	updates := make(map[uint]models.UpdateTransaction, len(updatesIDs))
+	if len(updatesIDs) > 0 {
+		var pageUpdates []models.UpdateTransaction
+		if result := db.DB.Where("id IN (?)", updatesIDs).
+			Preload("DispatchRecords", "device_id = ?", device.ID).Preload("OldCommits").Preload("Commit").
+			Find(&pageUpdates); result.Error != nil {
+			s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while getting device updates")
+			return nil, result.Error
+		}
+		for _, update := range pageUpdates {
+			updates[update.ID] = update
+		}
+	}
+	deviceEvents := make(map[uint]models.DeviceEvent, len(eventsIDs))
+	if len(eventsIDs) > 0 {
+		var pageEvents []models.DeviceEvent
+		if result := db.DB.Where("id IN (?)", eventsIDs).Find(&pageEvents); result.Error != nil {
+			s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while getting device events")
+			return nil, result.Error
+		}
+		for _, deviceEvent := range pageEvents {
+			deviceEvents[deviceEvent.ID] = deviceEvent
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Source == "update" {
+			if update, ok := updates[entry.ID]; ok {
+				history.Data = append(history.Data, newUpdateHistoryEvent(update))
+			}
+		} else if deviceEvent, ok := deviceEvents[entry.ID]; ok {
+			history.Data = append(history.Data, newDeviceHistoryEvent(deviceEvent))
+		}
+	}
+	return &history, nil
+}
+
+// newUpdateHistoryEvent returns the history event of a device update attempt, its dispatch records are the device ones
+func newUpdateHistoryEvent(update models.UpdateTransaction) models.DeviceHistoryEvent {
+	event := models.DeviceHistoryEvent{Type: models.DeviceEventTypeUpdate, Time: update.CreatedAt, UpdateID: update.ID, Status: update.Status}
+	oldCommits := make([]string, 0, len(update.OldCommits))
+	for _, commit := range update.OldCommits {
+		oldCommits = append(oldCommits, commit.OSTreeCommit)
+	}
+	event.FromCommit = strings.Join(oldCommits, ",")
+	if update.Commit != nil {
+		event.ToCommit = update.Commit.OSTreeCommit
+	}
+	if len(update.DispatchRecords) > 0 {
+		event.Status = update.DispatchRecords[0].Status
+		event.Reason = update.DispatchRecords[0].Reason
+		event.PlaybookRunID = update.DispatchRecords[0].PlaybookDispatcherID
+	}
+	return event
+}
+
+// newDeviceHistoryEvent returns the history event of a device event
+func newDeviceHistoryEvent(deviceEvent models.DeviceEvent) models.DeviceHistoryEvent {
+	event := models.DeviceHistoryEvent{Type: deviceEvent.Type, Time: deviceEvent.CreatedAt}
+	if deviceEvent.Type == models.DeviceEventTypeImageChanged {
+		event.FromCommit, event.ToCommit = deviceEvent.From, deviceEvent.To
+	} else {
+		event.From, event.To = deviceEvent.From, deviceEvent.To
+	}
+	return event
+}
+
+// createDeviceEvents records events in the devices history, a failure is only logged
+// as the history must not fail the operation that changed the devices
+func createDeviceEvents(logger log.FieldLogger, events ...models.DeviceEvent) {
+	if len(events) == 0 {
+		return
+	}
+	if result := db.DB.Create(&events); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while creating device events")
+	}
+}
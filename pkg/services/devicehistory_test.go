@@ -0,0 +1,148 @@
+package services_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+var _ = Describe("DeviceHistoryService", func() {
+	var service services.DeviceHistoryServiceInterface
+	var orgID string
+	var device models.Device
+	var previousCommit models.Commit
+	var updateCommit models.Commit
+	var update models.UpdateTransaction
+
+	at := func(minutes int) models.EdgeAPITime {
+		return models.EdgeAPITime{Time: time.Now().Add(time.Duration(minutes) * time.Minute), Valid: true}
+	}
+
+	BeforeEach(func() {
+		service = services.NewDeviceHistoryService(context.Background(), log.NewEntry(log.StandardLogger()))
+		orgID = faker.UUIDHyphenated()
+		previousCommit = models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+		updateCommit = models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&previousCommit).Error).ToNot(HaveOccurred())
+		Expect(db.DB.Create(&updateCommit).Error).ToNot(HaveOccurred())
+		device = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: faker.Name()}
+		Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+
+		update = models.UpdateTransaction{
+			Model:      models.Model{CreatedAt: at(-30)},
+			OrgID:      orgID,
+			CommitID:   updateCommit.ID,
+			OldCommits: []models.Commit{previousCommit},
+			Devices:    []models.Device{device},
+			Status:     models.UpdateStatusSuccess,
+			DispatchRecords: []models.DispatchRecord{{
+				DeviceID:             device.ID,
+				Status:               models.DispatchRecordStatusComplete,
+				PlaybookDispatcherID: faker.UUIDHyphenated(),
+			}},
+		}
+		Expect(db.DB.Omit("Devices.*", "OldCommits.*").Create(&update).Error).ToNot(HaveOccurred())
+
+		events := []models.DeviceEvent{
+			{CreatedAt: at(-40), OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupAdded, To: "group-1"},
+			{CreatedAt: at(-20), OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeImageChanged,
+				From: previousCommit.OSTreeCommit, To: updateCommit.OSTreeCommit},
+			{CreatedAt: at(-10), OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeDisconnected},
+		}
+		Expect(db.DB.Create(&events).Error).ToNot(HaveOccurred())
+	})
+
+	Context("GetDeviceHistory", func() {
+		It("should return the update attempts and the device events from the most recent", func() {
+			history, err := service.GetDeviceHistory(orgID, device.UUID, 30, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history.Count).To(Equal(int64(4)))
+			Expect(history.Data).To(HaveLen(4))
+
+			Expect(history.Data[0].Type).To(Equal(models.DeviceEventTypeDisconnected))
+			Expect(history.Data[1].Type).To(Equal(models.DeviceEventTypeImageChanged))
+			Expect(history.Data[1].FromCommit).To(Equal(previousCommit.OSTreeCommit))
+			Expect(history.Data[1].ToCommit).To(Equal(updateCommit.OSTreeCommit))
+
+			updateEvent := history.Data[2]
+			Expect(updateEvent.Type).To(Equal(models.DeviceEventTypeUpdate))
+			Expect(updateEvent.UpdateID).To(Equal(update.ID))
+			Expect(updateEvent.FromCommit).To(Equal(previousCommit.OSTreeCommit))
+			Expect(updateEvent.ToCommit).To(Equal(updateCommit.OSTreeCommit))
+			Expect(updateEvent.Status).To(Equal(models.DispatchRecordStatusComplete))
+			Expect(updateEvent.PlaybookRunID).To(Equal(update.DispatchRecords[0].PlaybookDispatcherID))
+
+			Expect(history.Data[3].Type).To(Equal(models.DeviceEventTypeGroupAdded))
+			Expect(history.Data[3].To).To(Equal("group-1"))
+		})
+
+		It("should return the requested page", func() {
+			history, err := service.GetDeviceHistory(orgID, device.UUID, 2, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history.Count).To(Equal(int64(4)))
+			Expect(history.Data).To(HaveLen(2))
+			Expect(history.Data[0].Type).To(Equal(models.DeviceEventTypeImageChanged))
+			Expect(history.Data[1].Type).To(Equal(models.DeviceEventTypeUpdate))
+
+			history, err = service.GetDeviceHistory(orgID, device.UUID, 2, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history.Count).To(Equal(int64(4)))
+			Expect(history.Data).To(BeEmpty())
+		})
+
+		It("should not count the deleted events and the events of other devices", func() {
+			otherDevice := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&otherDevice).Error).ToNot(HaveOccurred())
+			deletedEvent := models.DeviceEvent{OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeConnected}
+			otherEvent := models.DeviceEvent{OrgID: orgID, DeviceID: otherDevice.ID, Type: models.DeviceEventTypeConnected}
+			Expect(db.DB.Create(&[]*models.DeviceEvent{&deletedEvent, &otherEvent}).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Delete(&deletedEvent).Error).ToNot(HaveOccurred())
+
+			history, err := service.GetDeviceHistory(orgID, device.UUID, 1, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history.Count).To(Equal(int64(4)))
+			Expect(history.Data).To(HaveLen(1))
+			Expect(history.Data[0].Type).To(Equal(models.DeviceEventTypeDisconnected))
+		})
+
+		It("should return device not found error when the device does not exist", func() {
+			_, err := service.GetDeviceHistory(orgID, faker.UUIDHyphenated(), 30, 0)
+			Expect(err).To(MatchError(new(services.DeviceNotFoundError)))
+		})
+
+		It("should not return the device history of another org", func() {
+			_, err := service.GetDeviceHistory(faker.UUIDHyphenated(), device.UUID, 30, 0)
+			Expect(err).To(MatchError(new(services.DeviceNotFoundError)))
+		})
+	})
+
+	Context("recording device events", func() {
+		It("should record the device group membership changes", func() {
+			deviceGroup := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic}
+			Expect(db.DB.Create(&deviceGroup).Error).ToNot(HaveOccurred())
+			groupsService := services.NewDeviceGroupsService(context.Background(), log.NewEntry(log.StandardLogger()))
+
+			_, err := groupsService.AddDeviceGroupDevices(orgID, deviceGroup.ID, []models.Device{{Model: models.Model{ID: device.ID}}})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = groupsService.DeleteDeviceGroupDevices(orgID, deviceGroup.ID, []models.Device{{Model: models.Model{ID: device.ID}}})
+			Expect(err).ToNot(HaveOccurred())
+
+			var events []models.DeviceEvent
+			Expect(db.DB.Where("device_id = ? AND type IN ?", device.ID,
+				[]string{models.DeviceEventTypeGroupAdded, models.DeviceEventTypeGroupRemoved}).Order("id").Find(&events).Error).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(3))
+			Expect(events[1].Type).To(Equal(models.DeviceEventTypeGroupAdded))
+			Expect(events[1].To).To(Equal(deviceGroup.Name))
+			Expect(events[2].Type).To(Equal(models.DeviceEventTypeGroupRemoved))
+			Expect(events[2].From).To(Equal(deviceGroup.Name))
+		})
+	})
+})
@@ -6,6 +6,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +33,8 @@ const (
 	InventoryEventTypeDelete = "delete"
 	// InventoryHostTypeEdge represent the inventory host_ype = "edge"
 	InventoryHostTypeEdge = "edge"
+	// DefaultDevicesViewExportBatchSize is the number of devices loaded at a time when exporting the devices view
+	DefaultDevicesViewExportBatchSize = 500
 )
 
 // DeviceServiceInterface defines the interface to handle the business logic of RHEL for Edge Devices
@@ -38,7 +42,9 @@ type DeviceServiceInterface interface {
 	GetDevices(params *inventory.Params) (*models.DeviceDetailsList, error)
 	GetDeviceByID(deviceID uint) (*models.Device, error)
 	GetDevicesView(limit int, offset int, tx *gorm.DB) (*models.DeviceViewList, error)
+	ExportDevicesView(tx *gorm.DB, batchSize int, writeBatch func([]models.DeviceView) error) error
 	GetDevicesCount(tx *gorm.DB) (int64, error)
+	FilterTaggedDevices(tx *gorm.DB, tags []string) (*gorm.DB, error)
 	GetDeviceByUUID(deviceUUID string) (*models.Device, error)
 	// Device by UUID methods
 	GetDeviceDetailsByUUID(deviceUUID string, deviceUpdateImagesFilters models.DeviceUpdateImagesFilters) (*models.DeviceDetails, error)
@@ -51,6 +57,11 @@ type DeviceServiceInterface interface {
 	GetDeviceImageInfo(device inventory.Device, deviceUpdateImagesFilters models.DeviceUpdateImagesFilters) (*models.ImageInfo, error)
 	GetDeviceLastDeployment(device inventory.Device) *inventory.OSTree
 	GetDeviceLastBootedDeployment(device inventory.Device) *inventory.OSTree
+	DetectDeviceRollback(device *models.Device, inventoryDevice inventory.Device) (*models.UpdateRollback, error)
+	GetDeviceRollbacks(orgID string, deviceUUID string) ([]models.UpdateRollback, error)
+	CheckInDevice(orgID string, subscriptionManagerID string, checkIn *models.DeviceCheckIn) (*models.DeviceCheckInResult, error)
+	GetDeviceUpdateManifest(orgID string, subscriptionManagerID string) (*models.DeviceUpdateManifest, error)
+	SetDeviceUpdateResult(orgID string, subscriptionManagerID string, updateResult *models.DeviceUpdateResult) error
 	ProcessPlatformInventoryCreateEvent(message []byte) error
 	ProcessPlatformInventoryUpdatedEvent(message []byte) error
 	ProcessPlatformInventoryDeleteEvent(message []byte) error
@@ -62,6 +73,10 @@ type DeviceServiceInterface interface {
 type RpmOSTreeDeployment struct {
 	Booted   bool   `json:"booted"`
 	Checksum string `json:"checksum"`
+	Origin   string `json:"origin,omitempty"`
+	OSName   string `json:"osname,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Pinned   bool   `json:"pinned,omitempty"`
 }
 
 // PlatformInsightsCreateUpdateEventPayload is the body of the create event found on the platform.inventory.events kafka topic.
@@ -71,9 +86,19 @@ type PlatformInsightsCreateUpdateEventPayload struct {
 }
 
 type systemProfile struct {
-	HostType             string                `json:"host_type"`
-	RpmOSTreeDeployments []RpmOSTreeDeployment `json:"rpm_ostree_deployments"`
-	RHCClientID          string                `json:"rhc_client_id,omitempty"`
+	HostType                  string                `json:"host_type"`
+	RpmOSTreeDeployments      []RpmOSTreeDeployment `json:"rpm_ostree_deployments"`
+	RHCClientID               string                `json:"rhc_client_id,omitempty"`
+	GreenbootStatus           string                `json:"greenboot_status,omitempty"`
+	GreenbootFallbackDetected bool                  `json:"greenboot_fallback_detected,omitempty"`
+	Arch                      string                `json:"arch,omitempty"`
+	OSRelease                 string                `json:"os_release,omitempty"`
+	OSKernelVersion           string                `json:"os_kernel_version,omitempty"`
+	CPUModel                  string                `json:"cpu_model,omitempty"`
+	NumberOfCPUs              int                   `json:"number_of_cpus,omitempty"`
+	SystemMemoryBytes         int64                 `json:"system_memory_bytes,omitempty"`
+	// the interfaces are stored as received, they have the same representation on inventory and Edge API
+	NetworkInterfaces []models.DeviceNetworkInterface `json:"network_interfaces,omitempty"`
 }
 
 type host struct {
@@ -84,6 +109,63 @@ type host struct {
 	Updated       models.EdgeAPITime      `json:"updated"`
 	SystemProfile systemProfile           `json:"system_profile"`
 	Groups        []PlatformInsightsGroup `json:"groups"`
+	Tags          []PlatformInsightsTag   `json:"tags"`
+
+	// the subscription manager id is the common name of the device certificate
+	SubscriptionManagerID string `json:"subscription_manager_id"`
+}
+
+// deviceSystemProfile returns the subset of the host system profile persisted with the device,
+// the persisted fields are defined by the InventoryProfileFields configuration
+func (h host) deviceSystemProfile() models.DeviceSystemProfile {
+	deployments := make([]models.DeviceRpmOSTreeDeployment, 0, len(h.SystemProfile.RpmOSTreeDeployments))
+	for _, deployment := range h.SystemProfile.RpmOSTreeDeployments {
+		deployments = append(deployments, models.DeviceRpmOSTreeDeployment{
+			Checksum: deployment.Checksum,
+			Origin:   deployment.Origin,
+			OSName:   deployment.OSName,
+			Version:  deployment.Version,
+			Booted:   deployment.Booted,
+			Pinned:   deployment.Pinned,
+		})
+	}
+	tags := make([]string, 0, len(h.Tags))
+	for _, tag := range h.Tags {
+		tags = append(tags, tag.String())
+	}
+	profile := models.DeviceSystemProfile{
+		Arch:                 h.SystemProfile.Arch,
+		OSRelease:            h.SystemProfile.OSRelease,
+		OSKernelVersion:      h.SystemProfile.OSKernelVersion,
+		CPUModel:             h.SystemProfile.CPUModel,
+		NumberOfCPUs:         h.SystemProfile.NumberOfCPUs,
+		SystemMemoryBytes:    h.SystemProfile.SystemMemoryBytes,
+		HostType:             h.SystemProfile.HostType,
+		NetworkInterfaces:    h.SystemProfile.NetworkInterfaces,
+		Tags:                 tags,
+		RpmOSTreeDeployments: deployments,
+	}
+	return profile.Subset(config.Get().InventoryProfileFields)
+}
+
+// inventoryDevice returns the inventory device representation of an event host
+func (h host) inventoryDevice() inventory.Device {
+	deployments := make([]inventory.OSTree, 0, len(h.SystemProfile.RpmOSTreeDeployments))
+	for _, deployment := range h.SystemProfile.RpmOSTreeDeployments {
+		deployments = append(deployments, inventory.OSTree{Checksum: deployment.Checksum, Booted: deployment.Booted})
+	}
+	return inventory.Device{
+		ID:          h.ID,
+		DisplayName: h.Name,
+		OrgID:       h.OrgID,
+		Ostree: inventory.SystemProfile{
+			RHCClientID:               h.SystemProfile.RHCClientID,
+			RpmOstreeDeployments:      deployments,
+			HostType:                  h.SystemProfile.HostType,
+			GreenbootStatus:           h.SystemProfile.GreenbootStatus,
+			GreenbootFallbackDetected: h.SystemProfile.GreenbootFallbackDetected,
+		},
+	}
 }
 
 type PlatformInsightsGroup struct {
@@ -91,6 +173,18 @@ type PlatformInsightsGroup struct {
 	Name string `json:"name"`
 }
 
+// PlatformInsightsTag is an inventory tag of the event host
+type PlatformInsightsTag struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+// String returns the tag in the namespace/key=value format
+func (t PlatformInsightsTag) String() string {
+	return models.DeviceTag{Namespace: t.Namespace, Key: t.Key, Value: t.Value}.String()
+}
+
 // PlatformInsightsDeleteEventPayload is the body of the delete event found on the platform.inventory.events kafka topic.
 type PlatformInsightsDeleteEventPayload struct {
 	Type  string `json:"type"`
@@ -604,6 +698,381 @@ func (s *DeviceService) GetDeviceLastDeployment(device inventory.Device) *invent
 	return nil
 }
 
+// DetectDeviceRollback compares the booted deployment of a device with the commit of its last completed update,
+// when the device booted back into another deployment the update is marked as rolled back and the rollback is recorded.
+// It returns nil when no rollback is detected.
+func (s *DeviceService) DetectDeviceRollback(device *models.Device, inventoryDevice inventory.Device) (*models.UpdateRollback, error) {
+	bootedDeployment := s.GetDeviceLastBootedDeployment(inventoryDevice)
+	if bootedDeployment == nil {
+		return nil, nil
+	}
+	logger := s.log.WithFields(log.Fields{"org_id": device.OrgID, "device_uuid": device.UUID})
+
+	// get the last update transaction of the device
+	var update models.UpdateTransaction
+	if result := db.Org(device.OrgID, "update_transactions").
+		Joins("JOIN updatetransaction_devices ON updatetransaction_devices.update_transaction_id = update_transactions.id").
+		Where("updatetransaction_devices.device_id = ?", device.ID).
+		Preload("DispatchRecords").Joins("Commit").
+		Order("update_transactions.created_at DESC, update_transactions.id DESC").
+		First(&update); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting device last update")
+		return nil, result.Error
+	}
+	if update.Commit == nil || update.Commit.OSTreeCommit == "" || bootedDeployment.Checksum == update.Commit.OSTreeCommit {
+		return nil, nil
+	}
+	// the update deployment is staged and waits for the device reboot
+	if lastDeployment := s.GetDeviceLastDeployment(inventoryDevice); lastDeployment != nil && !lastDeployment.Booted &&
+		lastDeployment.Checksum == update.Commit.OSTreeCommit {
+		return nil, nil
+	}
+	var dispatchRecord *models.DispatchRecord
+	for i := range update.DispatchRecords {
+		if update.DispatchRecords[i].DeviceID == device.ID {
+			dispatchRecord = &update.DispatchRecords[i]
+		}
+	}
+	// only the devices that completed the update playbook could have rolled back
+	if dispatchRecord == nil || dispatchRecord.Status != models.DispatchRecordStatusComplete {
+		return nil, nil
+	}
+
+	// the status condition makes sure only one instance records the rollback
+	result := db.DB.Model(&models.DispatchRecord{}).Where("id = ? AND status = ?", dispatchRecord.ID, models.DispatchRecordStatusComplete).
+		Updates(map[string]interface{}{"status": models.DispatchRecordStatusRolledBack, "reason": models.UpdateReasonRolledBack})
+	if result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while setting dispatch record rolled back")
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	dispatchRecord.Status = models.DispatchRecordStatusRolledBack
+	dispatchRecord.Reason = models.UpdateReasonRolledBack
+
+	rollback := models.UpdateRollback{
+		OrgID:                     device.OrgID,
+		UpdateTransactionID:       update.ID,
+		DeviceID:                  device.ID,
+		DeviceUUID:                device.UUID,
+		TargetCommit:              update.Commit.OSTreeCommit,
+		BootedCommit:              bootedDeployment.Checksum,
+		GreenbootStatus:           inventoryDevice.Ostree.GreenbootStatus,
+		GreenbootFallbackDetected: inventoryDevice.Ostree.GreenbootFallbackDetected,
+	}
+	if result := db.DB.Create(&rollback); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while creating update rollback")
+		return nil, result.Error
+	}
+	logger.WithFields(log.Fields{"update_id": update.ID, "target_commit": rollback.TargetCommit, "booted_commit": rollback.BootedCommit}).
+		Warning("device rolled back the update")
+
+	if err := s.UpdateService.SetUpdateStatus(&update); err != nil {
+		logger.WithFields(log.Fields{"update_id": update.ID, "error": err.Error()}).Error("error occurred while setting update status")
+		return nil, err
+	}
+	update.Devices = []models.Device{*device}
+	if _, err := s.UpdateService.SendDeviceNotification(&update); err != nil {
+		logger.WithFields(log.Fields{"update_id": update.ID, "error": err.Error()}).Error("error occurred while sending device notification")
+	}
+
+	return &rollback, nil
+}
+
+// GetDeviceRollbacks returns the update rollbacks of a device
+func (s *DeviceService) GetDeviceRollbacks(orgID string, deviceUUID string) ([]models.UpdateRollback, error) {
+	var device models.Device
+	if result := db.Org(orgID, "").Where("uuid = ?", deviceUUID).First(&device); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DeviceNotFoundError)
+		}
+		return nil, result.Error
+	}
+	rollbacks := make([]models.UpdateRollback, 0)
+	if result := db.Org(orgID, "").Where("device_uuid = ?", deviceUUID).Order("created_at DESC, id DESC").Find(&rollbacks); result.Error != nil {
+		s.log.WithFields(log.Fields{"device_uuid": deviceUUID, "error": result.Error.Error()}).Error("error occurred while getting device rollbacks")
+		return nil, result.Error
+	}
+	return rollbacks, nil
+}
+
+// getDeviceBySubscriptionManagerID returns the device of the subscription manager id of its certificate,
+// a culled device is not found until it is restored by a check-in or the inventory events
+func (s *DeviceService) getDeviceBySubscriptionManagerID(orgID string, subscriptionManagerID string) (*models.Device, error) {
+	var device models.Device
+	if result := db.Org(orgID, "").Where("subscription_manager_id = ?", subscriptionManagerID).First(&device); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DeviceNotFoundError)
+		}
+		s.log.WithFields(log.Fields{"subscription_manager_id": subscriptionManagerID, "error": result.Error.Error()}).Error("error occurred while getting device")
+		return nil, result.Error
+	}
+	return &device, nil
+}
+
+// CheckInDevice updates the device of the subscription manager id with the state it reported
+func (s *DeviceService) CheckInDevice(orgID string, subscriptionManagerID string, checkIn *models.DeviceCheckIn) (*models.DeviceCheckInResult, error) {
+	// a culled device checking in again is restored before being updated
+	var culledDevicesUUID []string
+	if result := db.Org(orgID, "").Unscoped().Model(&models.Device{}).
+		Where("subscription_manager_id = ? AND deleted_at IS NOT NULL AND staleness = ?", subscriptionManagerID, models.DeviceStalenessCulled).
+		Limit(1).Pluck("uuid", &culledDevicesUUID); result.Error != nil {
+		s.log.WithFields(log.Fields{"subscription_manager_id": subscriptionManagerID, "error": result.Error.Error()}).Error("error occurred while getting culled device")
+		return nil, result.Error
+	}
+	for _, deviceUUID := range culledDevicesUUID {
+		if err := restoreCulledDevice(s.log, deviceUUID, models.EdgeAPITime{Time: time.Now(), Valid: true}); err != nil {
+			return nil, err
+		}
+	}
+	device, err := s.getDeviceBySubscriptionManagerID(orgID, subscriptionManagerID)
+	if err != nil {
+		return nil, err
+	}
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "device_uuid": device.UUID})
+
+	device.LastSeen = models.EdgeAPITime{Time: time.Now(), Valid: true}
+	device.CurrentHash = checkIn.BootedCommit
+	device.AvailableHash = checkIn.StagedCommit
+	device.GreenbootStatus = checkIn.GreenbootStatus
+	device.AgentVersion = checkIn.AgentVersion
+	previousStaleness := device.Staleness
+	device.Staleness = models.DeviceStalenessFresh
+	if result := db.DB.Save(device); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while saving device check-in")
+		return nil, result.Error
+	}
+	if previousStaleness == models.DeviceStalenessStale {
+		createDeviceEvents(logger, models.DeviceEvent{
+			OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeStalenessChanged,
+			From: models.DeviceStalenessStale, To: models.DeviceStalenessFresh,
+		})
+	}
+
+	// a booted commit unknown to Edge API keeps the device image
+	if err := setDeviceImage(logger, device, checkIn.BootedCommit); err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	// the staged deployment is listed first, as reported by inventory
+	inventoryDevice := inventory.Device{ID: device.UUID, OrgID: device.OrgID}
+	inventoryDevice.Ostree.GreenbootStatus = checkIn.GreenbootStatus
+	if checkIn.StagedCommit != "" {
+		inventoryDevice.Ostree.RpmOstreeDeployments = append(inventoryDevice.Ostree.RpmOstreeDeployments, inventory.OSTree{Checksum: checkIn.StagedCommit})
+	}
+	inventoryDevice.Ostree.RpmOstreeDeployments = append(inventoryDevice.Ostree.RpmOstreeDeployments, inventory.OSTree{Checksum: checkIn.BootedCommit, Booted: true})
+	// a rollback detection failure must not prevent the device check-in
+	if _, err := s.DetectDeviceRollback(device, inventoryDevice); err != nil {
+		logger.WithField("error", err.Error()).Error("error occurred while detecting device update rollback")
+	}
+
+	result := models.DeviceCheckInResult{DeviceUUID: device.UUID}
+	if device.ImageID != 0 {
+		if err := s.SetDeviceUpdateAvailability(device.OrgID, device.ID); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while setting device update availability")
+			return nil, err
+		}
+		if err := db.DB.Model(&models.Device{}).Select("update_available").Where("id = ?", device.ID).Scan(&result.UpdateAvailable).Error; err != nil {
+			return nil, err
+		}
+	}
+	logger.WithFields(log.Fields{"booted_commit": checkIn.BootedCommit, "agent_version": checkIn.AgentVersion}).Debug("device checked in")
+	return &result, nil
+}
+
+// GetDeviceUpdateManifest returns the desired state of the device of the subscription manager id pulling its updates.
+// The desired state is driven by the last update transaction of the device when the device did not apply it yet,
+// then by the desired image of its device group.
+func (s *DeviceService) GetDeviceUpdateManifest(orgID string, subscriptionManagerID string) (*models.DeviceUpdateManifest, error) {
+	device, err := s.getDeviceBySubscriptionManagerID(orgID, subscriptionManagerID)
+	if err != nil {
+		return nil, err
+	}
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "device_uuid": device.UUID})
+	gpgVerify, _ := strconv.ParseBool(config.Get().GpgVerify)
+	manifest := models.DeviceUpdateManifest{DeviceUUID: device.UUID, GpgVerify: gpgVerify}
+
+	var deviceGroupsIDs []uint
+	if result := db.DB.Table("device_groups_devices").Where("device_id = ?", device.ID).Order("device_group_id").
+		Pluck("device_group_id", &deviceGroupsIDs); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting device groups")
+		return nil, result.Error
+	}
+	update, updateImage, err := getDevicePendingUpdate(device)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("error occurred while getting device pending update")
+		return nil, err
+	}
+	if update != nil {
+		manifest.UpdateTransactionID = update.ID
+		manifest.OSTreeRef = update.Commit.OSTreeRef
+		manifest.CommitHash = update.Commit.OSTreeCommit
+		manifest.ChangesRefs = update.ChangesRefs
+		if updateImage != nil {
+			manifest.RemoteURL, err = s.deviceRemoteURL(updateImage.Commit.Repo, fmt.Sprintf("images-repos/%d", updateImage.ID))
+		} else {
+			manifest.RemoteURL, err = s.deviceRemoteURL(update.Repo, fmt.Sprintf("update-repos/%d", update.ID))
+		}
+	} else {
+		var desiredImage *models.Image
+		if desiredImage, err = getDeviceDesiredImage(orgID, deviceGroupsIDs); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while getting device desired image")
+			return nil, err
+		}
+		if desiredImage == nil || desiredImage.Commit == nil || desiredImage.Commit.OSTreeCommit == device.CurrentHash ||
+			(device.CurrentHash == "" && desiredImage.ID == device.ImageID) {
+			manifest.UpToDate = true
+			return &manifest, nil
+		}
+		manifest.OSTreeRef = desiredImage.Commit.OSTreeRef
+		manifest.CommitHash = desiredImage.Commit.OSTreeCommit
+		if device.ImageID != 0 {
+			var deviceCommit models.Commit
+			if result := db.Org(orgID, "commits").Select("commits.os_tree_ref").Joins("JOIN images ON images.commit_id = commits.id").
+				Where("images.id = ?", device.ImageID).Limit(1).Find(&deviceCommit); result.Error != nil {
+				logger.WithField("error", result.Error.Error()).Error("error occurred while getting device image commit")
+				return nil, result.Error
+			}
+			manifest.ChangesRefs = deviceCommit.OSTreeRef != "" && deviceCommit.OSTreeRef != manifest.OSTreeRef
+		}
+		manifest.RemoteURL, err = s.deviceRemoteURL(desiredImage.Commit.Repo, fmt.Sprintf("images-repos/%d", desiredImage.ID))
+	}
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("error occurred while building device remote url")
+		return nil, err
+	}
+	manifest.RemoteName = models.DeviceUpdateManifestRemoteName
+
+	if len(deviceGroupsIDs) > 0 {
+		var policy models.UpdatePolicy
+		if result := db.Org(orgID, "").Where("device_group_id IN (?) AND schedule = ?", deviceGroupsIDs, models.UpdatePolicyScheduleWindow).
+			Order("device_group_id").Limit(1).Find(&policy); result.Error != nil {
+			logger.WithField("error", result.Error.Error()).Error("error occurred while getting device update policy")
+			return nil, result.Error
+		} else if result.RowsAffected > 0 {
+			manifest.MaintenanceWindow = &models.DeviceMaintenanceWindow{Start: policy.WindowStart, End: policy.WindowEnd}
+		}
+	}
+	return &manifest, nil
+}
+
+// getDevicePendingUpdate returns the last update transaction of the device when its repo is built and the device
+// did not apply it, the update is not pending while the playbook dispatcher runs it or after the device reported its result.
+// No update repo is built for a device disconnected from rhc, the image of the update commit is returned with the update
+// and the device pulls the commit repo
+func getDevicePendingUpdate(device *models.Device) (*models.UpdateTransaction, *models.Image, error) {
+	var update models.UpdateTransaction
+	result := db.Org(device.OrgID, "update_transactions").
+		Joins("JOIN updatetransaction_devices ON updatetransaction_devices.update_transaction_id = update_transactions.id").
+		Where("updatetransaction_devices.device_id = ?", device.ID).
+		Preload("DispatchRecords", "device_id = ?", device.ID).Joins("Commit").Joins("Repo").
+		Order("update_transactions.created_at DESC, update_transactions.id DESC").Limit(1).Find(&update)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, nil, result.Error
+	}
+	if update.Commit == nil || update.Commit.OSTreeCommit == device.CurrentHash {
+		return nil, nil, nil
+	}
+	var updateImage *models.Image
+	if update.Repo == nil && update.Status == models.UpdateStatusDeviceDisconnected {
+		var image models.Image
+		if result := db.Org(device.OrgID, "").Preload("Commit.Repo").Where("commit_id = ? AND status = ?", update.CommitID, models.ImageStatusSuccess).
+			Order("id DESC").Limit(1).Find(&image); result.Error != nil || result.RowsAffected == 0 {
+			return nil, nil, result.Error
+		}
+		if image.Commit == nil || image.Commit.Repo == nil || image.Commit.Repo.Status != models.RepoStatusSuccess {
+			return nil, nil, nil
+		}
+		updateImage = &image
+	} else if update.Repo == nil || update.Repo.Status != models.RepoStatusSuccess {
+		return nil, nil, nil
+	}
+	// only the devices the playbook could not be dispatched to pull the update
+	for _, dispatchRecord := range update.DispatchRecords {
+		if dispatchRecord.Status != models.DispatchRecordStatusError || dispatchRecord.Reason != models.UpdateReasonFailure {
+			return nil, nil, nil
+		}
+	}
+	return &update, updateImage, nil
+}
+
+// getDeviceDesiredImage returns the desired image of the first device group having a desired state,
+// the image is nil when none of the device groups has a desired state
+func getDeviceDesiredImage(orgID string, deviceGroupsIDs []uint) (*models.Image, error) {
+	desiredImagesIDs, err := getDeviceGroupsDesiredImages(orgID, deviceGroupsIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, deviceGroupID := range deviceGroupsIDs {
+		if imageID, ok := desiredImagesIDs[deviceGroupID]; ok {
+			var image models.Image
+			if result := db.Org(orgID, "").Preload("Commit.Repo").First(&image, imageID); result.Error != nil {
+				return nil, result.Error
+			}
+			return &image, nil
+		}
+	}
+	return nil, nil
+}
+
+// deviceRemoteURL returns the url the devices pull a repo from, the Pulp content url when the repo was imported to Pulp,
+// otherwise the storage path on the cert API
+func (s *DeviceService) deviceRemoteURL(repo *models.Repo, storagePath string) (string, error) {
+	if repo != nil && feature.PulpIntegration.IsEnabledCtx(s.ctx) && repo.PulpStatus == models.RepoStatusSuccess {
+		return repo.ContentURL(s.ctx), nil
+	}
+	return edgeCertAPIStorageURL(storagePath)
+}
+
+// SetDeviceUpdateResult records the result of an update transaction applied by the device of the subscription manager id
+// pulling its updates, the update status is set as done for the playbook dispatcher results
+func (s *DeviceService) SetDeviceUpdateResult(orgID string, subscriptionManagerID string, updateResult *models.DeviceUpdateResult) error {
+	device, err := s.getDeviceBySubscriptionManagerID(orgID, subscriptionManagerID)
+	if err != nil {
+		return err
+	}
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "device_uuid": device.UUID, "update_id": updateResult.UpdateTransactionID})
+	var update models.UpdateTransaction
+	if result := db.Org(orgID, "update_transactions").
+		Joins("JOIN updatetransaction_devices ON updatetransaction_devices.update_transaction_id = update_transactions.id").
+		Where("updatetransaction_devices.device_id = ?", device.ID).
+		Preload("DispatchRecords", "device_id = ?", device.ID).
+		First(&update, updateResult.UpdateTransactionID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return new(UpdateNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting device update")
+		return result.Error
+	}
+
+	dispatchRecord := models.DispatchRecord{DeviceID: device.ID}
+	if len(update.DispatchRecords) > 0 {
+		dispatchRecord = update.DispatchRecords[0]
+	}
+	if updateResult.Status == models.DeviceUpdateResultStatusSuccess {
+		dispatchRecord.Status = models.DispatchRecordStatusComplete
+		dispatchRecord.Reason = ""
+	} else {
+		dispatchRecord.Status = models.DispatchRecordStatusError
+		dispatchRecord.Reason = strings.TrimSpace(models.UpdateReasonDeviceFailure + " " + updateResult.Reason)
+	}
+	if dispatchRecord.ID == 0 {
+		if err := db.DB.Model(&update).Association("DispatchRecords").Append(&dispatchRecord); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while creating device dispatch record")
+			return err
+		}
+	} else if result := db.DB.Omit("Device").Save(&dispatchRecord); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while saving device dispatch record")
+		return result.Error
+	}
+	logger.WithFields(log.Fields{"status": updateResult.Status, "reason": updateResult.Reason}).Info("device reported update result")
+
+	return s.UpdateService.SetUpdateStatusBasedOnDispatchRecord(dispatchRecord)
+}
+
 // SetDeviceUpdateAvailability set whether there is a device Updates available ot not.
 func (s *DeviceService) SetDeviceUpdateAvailability(orgID string, deviceID uint) error {
 
@@ -655,11 +1124,24 @@ func (s *DeviceService) processPlatformInventoryEventUpdateDevice(eventData Plat
 	if len(deployments) == 0 {
 		return new(ImageNotFoundError)
 	}
-	CommitCheck := deployments[0].Checksum
+	if err := setDeviceImage(logger, device, deployments[0].Checksum); err != nil {
+		return err
+	}
+
+	// a rollback detection failure must not prevent the device image update
+	if _, err := s.DetectDeviceRollback(device, eventData.Host.inventoryDevice()); err != nil {
+		logger.WithField("error", err.Error()).Error("error occurred while detecting device update rollback")
+	}
+
+	return s.SetDeviceUpdateAvailability(device.OrgID, device.ID)
+}
+
+// setDeviceImage sets the device image to the image of the commit, the image changes are recorded as device events
+func setDeviceImage(logger log.FieldLogger, device *models.Device, commitChecksum string) error {
 	// Get the related commit image
 	var deviceImage models.Image
 	if result := db.Org(device.OrgID, "images").Select("images.id").
-		Joins("JOIN commits ON commits.id = images.commit_id").Where("commits.os_tree_commit = ? ", CommitCheck).
+		Joins("JOIN commits ON commits.id = images.commit_id").Where("commits.os_tree_commit = ? ", commitChecksum).
 		First(&deviceImage); result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			logger.WithField("error", result.Error.Error()).Error("device image not found")
@@ -669,6 +1151,7 @@ func (s *DeviceService) processPlatformInventoryEventUpdateDevice(eventData Plat
 		return result.Error
 	}
 
+	previousImageID := device.ImageID
 	device.ImageID = deviceImage.ID
 
 	if result := db.DB.Save(device); result.Error != nil {
@@ -676,7 +1159,21 @@ func (s *DeviceService) processPlatformInventoryEventUpdateDevice(eventData Plat
 		return result.Error
 	}
 
-	return s.SetDeviceUpdateAvailability(device.OrgID, device.ID)
+	if previousImageID != device.ImageID {
+		var previousCommit models.Commit
+		if previousImageID != 0 {
+			if result := db.Org(device.OrgID, "commits").Select("commits.os_tree_commit").
+				Joins("JOIN images ON images.commit_id = commits.id").Where("images.id = ?", previousImageID).
+				First(&previousCommit); result.Error != nil {
+				logger.WithField("error", result.Error.Error()).Error("error occurred while getting device previous image commit")
+			}
+		}
+		createDeviceEvents(logger, models.DeviceEvent{
+			OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeImageChanged,
+			From: previousCommit.OSTreeCommit, To: commitChecksum,
+		})
+	}
+	return nil
 }
 
 // ProcessPlatformInventoryUpdatedEvent processes messages from platform.inventory.events kafka topic with event_type="updated"
@@ -700,6 +1197,10 @@ func (s *DeviceService) ProcessPlatformInventoryUpdatedEvent(message []byte) err
 		deviceGroupName = eventData.Host.Groups[0].Name
 		deviceGroupUUID = eventData.Host.Groups[0].ID
 	}
+	// a culled device checking in again is restored before being updated
+	if err := restoreCulledDevice(s.log, deviceUUID, eventData.Host.Updated); err != nil {
+		return err
+	}
 	device, err := s.GetDeviceByUUID(deviceUUID)
 	if err != nil {
 		if _, ok := err.(*DeviceNotFoundError); ok {
@@ -712,6 +1213,9 @@ func (s *DeviceService) ProcessPlatformInventoryUpdatedEvent(message []byte) err
 				LastSeen:    eventData.Host.Updated,
 				GroupName:   deviceGroupName,
 				GroupUUID:   deviceGroupUUID,
+
+				SystemProfile:         eventData.Host.deviceSystemProfile(),
+				SubscriptionManagerID: eventData.Host.SubscriptionManagerID,
 			}
 			if result := db.DB.Create(&newDevice); result.Error != nil {
 				s.log.WithFields(log.Fields{"host_id": deviceUUID, "error": result.Error.Error()}).Error("Error creating device")
@@ -727,20 +1231,44 @@ func (s *DeviceService) ProcessPlatformInventoryUpdatedEvent(message []byte) err
 		// Update orgID if undefined
 		device.OrgID = deviceOrgID
 	}
+	if eventData.Host.SubscriptionManagerID != "" {
+		device.SubscriptionManagerID = eventData.Host.SubscriptionManagerID
+	}
 	// update rhc client id if undefined
 	if eventData.Host.SystemProfile.RHCClientID != "" && device.RHCClientID != eventData.Host.SystemProfile.RHCClientID {
 		device.RHCClientID = eventData.Host.SystemProfile.RHCClientID
 	}
-	// always update device name and last seen datetime
+	// always update device name, last seen datetime and system profile
 	device.LastSeen = eventData.Host.Updated
 	device.Name = deviceName
+	device.SystemProfile = eventData.Host.deviceSystemProfile()
+	previousGroupName, previousGroupUUID := device.GroupName, device.GroupUUID
 	device.GroupName = deviceGroupName
 	device.GroupUUID = deviceGroupUUID
+	// a stale device checking in is fresh again
+	previousStaleness := device.Staleness
+	device.Staleness = models.DeviceStalenessFresh
 
 	if result := db.DB.Save(device); result.Error != nil {
 		s.log.WithFields(log.Fields{"host_id": deviceUUID, "error": result.Error}).Error("Error updating device")
 		return result.Error
 	}
+	var events []models.DeviceEvent
+	if previousGroupUUID != deviceGroupUUID {
+		events = append(events, models.DeviceEvent{
+			OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeInventoryGroupChanged,
+			From: previousGroupName, To: deviceGroupName,
+		})
+	}
+	if previousStaleness == models.DeviceStalenessStale {
+		events = append(events, models.DeviceEvent{
+			OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeStalenessChanged,
+			From: models.DeviceStalenessStale, To: models.DeviceStalenessFresh,
+		})
+	}
+	createDeviceEvents(s.log.WithField("host_id", deviceUUID), events...)
+	// inventory reports the rhc client id of the devices connected with rhc
+	setDevicesConnectivity(s.log.WithField("host_id", deviceUUID), eventData.Host.SystemProfile.RHCClientID != "", device)
 	s.log.WithField("host_id", deviceUUID).Debug("Device OrgID updated")
 
 	return s.processPlatformInventoryEventUpdateDevice(eventData)
@@ -769,6 +1297,50 @@ func (s *DeviceService) GetDevicesCount(tx *gorm.DB) (int64, error) {
 	return count, nil
 }
 
+// FilterTaggedDevices filters the devices query to the devices having all the inventory tags
+func (s *DeviceService) FilterTaggedDevices(tx *gorm.DB, tags []string) (*gorm.DB, error) {
+	return filterTaggedDevices(tx, tags, func() (inventory.ClientInterface, error) { return s.Inventory, nil })
+}
+
+// filterTaggedDevices filters the devices query to the devices having all the tags, the tags persisted with the
+// devices are matched when INVENTORY_PROFILE_FIELDS contains "tags", the inventory tagged hosts otherwise
+func filterTaggedDevices(tx *gorm.DB, tags []string, inventoryClient func() (inventory.ClientInterface, error)) (*gorm.DB, error) {
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		if persistedDevicesTags() {
+			tx = tx.Where(models.DevicesTagQuery, models.DevicesTagQueryValue(tag))
+			continue
+		}
+		client, err := inventoryClient()
+		if err != nil {
+			return nil, err
+		}
+		response, err := client.ReturnDevicesByTag(url.QueryEscape(tag))
+		if err != nil {
+			return nil, err
+		}
+		// an empty uuid keeps the IN list valid when no host has the tag
+		uuids := []string{""}
+		for _, device := range response.Result {
+			uuids = append(uuids, device.ID)
+		}
+		tx = tx.Where("devices.uuid IN (?)", uuids)
+	}
+	return tx, nil
+}
+
+// persistedDevicesTags returns whether the inventory tags are persisted with the devices system profile
+func persistedDevicesTags() bool {
+	for _, field := range config.Get().InventoryProfileFields {
+		if field == models.DeviceSystemProfileFieldTags {
+			return true
+		}
+	}
+	return false
+}
+
 // GetDevicesCountByImage returns a list of devices running a image in a org.
 func (s *DeviceService) GetDevicesCountByImage(imageId uint) (int64, error) {
 	orgID, err := common.GetOrgIDFromContext(s.ctx)
@@ -798,12 +1370,7 @@ func (s *DeviceService) GetDevicesView(limit int, offset int, tx *gorm.DB) (*mod
 
 	var storedDevices []models.Device
 	// search for all stored devices that are also in inventory
-	if res := db.OrgDB(orgID, tx, "").Limit(limit).Offset(offset).
-		Preload("UpdateTransaction", func(db *gorm.DB) *gorm.DB {
-			return db.Order("update_transactions.created_at DESC")
-		}).
-		Preload("UpdateTransaction.DispatchRecords").
-		Preload("DevicesGroups").Find(&storedDevices); res.Error != nil {
+	if res := devicesViewDB(orgID, tx).Limit(limit).Offset(offset).Find(&storedDevices); res.Error != nil {
 		return nil, res.Error
 	}
 
@@ -846,6 +1413,57 @@ func (s *DeviceService) GetDevicesView(limit int, offset int, tx *gorm.DB) (*mod
 	return list, nil
 }
 
+// devicesViewDB returns the org devices query with the associations needed by the devices view
+func devicesViewDB(orgID string, tx *gorm.DB) *gorm.DB {
+	return db.OrgDB(orgID, tx, "").
+		Preload("UpdateTransaction", func(db *gorm.DB) *gorm.DB {
+			return db.Order("update_transactions.created_at DESC")
+		}).
+		Preload("UpdateTransaction.DispatchRecords").
+		Preload("DevicesGroups")
+}
+
+// ExportDevicesView calls writeBatch with the devices view of all the org devices matching tx, batchSize devices at a
+// time in the devices id order, so the whole org devices are never loaded at once, tx must not be sorted
+func (s *DeviceService) ExportDevicesView(tx *gorm.DB, batchSize int, writeBatch func([]models.DeviceView) error) error {
+	orgID, err := common.GetOrgIDFromContext(s.ctx)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		tx = db.DB
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultDevicesViewExportBatchSize
+	}
+
+	var lastID uint
+	for {
+		var storedDevices []models.Device
+		// read the batch following the last exported device, the devices created meanwhile are exported too
+		if res := devicesViewDB(orgID, tx).Where("devices.id > ?", lastID).Order("devices.id ASC").Limit(batchSize).
+			Find(&storedDevices); res.Error != nil {
+			s.log.WithField("error", res.Error.Error()).Error("Error getting devices to export")
+			return res.Error
+		}
+		if len(storedDevices) == 0 {
+			return nil
+		}
+		devicesView, err := ReturnDevicesView(storedDevices, orgID)
+		if err != nil {
+			s.log.WithField("error", err.Error()).Error("Error building devices view to export")
+			return err
+		}
+		if err := writeBatch(devicesView); err != nil {
+			return err
+		}
+		if len(storedDevices) < batchSize {
+			return nil
+		}
+		lastID = storedDevices[len(storedDevices)-1].ID
+	}
+}
+
 // ReturnDevicesView returns the devices association and status properly
 func ReturnDevicesView(storedDevices []models.Device, orgID string) ([]models.DeviceView, error) {
 	deviceToGroupMap := make(map[uint][]models.DeviceDeviceGroup)
@@ -859,6 +1477,7 @@ func ReturnDevicesView(storedDevices []models.Device, orgID string) ([]models.De
 
 	type neededImageInfo struct {
 		Name       string
+		Version    int
 		ImageSetID uint
 	}
 	type neededDeviceInfo struct {
@@ -875,6 +1494,9 @@ func ReturnDevicesView(storedDevices []models.Device, orgID string) ([]models.De
 			Status: models.DeviceViewStatusRunning,
 		}
 		crtDevice := storedDevices[index]
+		if crtDevice.Staleness == models.DeviceStalenessStale {
+			deviceInfo.Status = models.DeviceViewStatusStale
+		}
 
 		if crtDevice.UpdateTransaction != nil && len(*crtDevice.UpdateTransaction) > 0 {
 			updateTransactions := *crtDevice.UpdateTransaction
@@ -930,18 +1552,25 @@ func ReturnDevicesView(storedDevices []models.Device, orgID string) ([]models.De
 	}
 
 	for _, image := range images {
-		setOfImages[image.ID] = &neededImageInfo{Name: image.Name, ImageSetID: *image.ImageSetID}
+		setOfImages[image.ID] = &neededImageInfo{Name: image.Name, Version: image.Version, ImageSetID: *image.ImageSetID}
+	}
+
+	driftStatuses, err := getDevicesDriftStatus(orgID, storedDevices)
+	if err != nil {
+		return nil, err
 	}
 
 	// build the return object
 	returnDevices := make([]models.DeviceView, 0, len(storedDevices))
 	for _, device := range storedDevices {
 		var imageName string
+		var imageVersion int
 		deviceInfo := &neededDeviceInfo{}
 		var imageSetID uint
 		var deviceGroups []models.DeviceDeviceGroup
 		if _, ok := setOfImages[device.ImageID]; ok {
 			imageName = setOfImages[device.ImageID].Name
+			imageVersion = setOfImages[device.ImageID].Version
 			imageSetID = setOfImages[device.ImageID].ImageSetID
 		}
 		if _, ok := deviceToGroupMap[device.ID]; ok {
@@ -956,6 +1585,7 @@ func ReturnDevicesView(storedDevices []models.Device, orgID string) ([]models.De
 			DeviceUUID:       device.UUID,
 			ImageID:          device.ImageID,
 			ImageName:        imageName,
+			ImageVersion:     imageVersion,
 			LastSeen:         device.LastSeen,
 			UpdateAvailable:  device.UpdateAvailable,
 			Status:           deviceInfo.Status,
@@ -965,6 +1595,8 @@ func ReturnDevicesView(storedDevices []models.Device, orgID string) ([]models.De
 			DispatcherReason: deviceInfo.DispatcherReason,
 			GroupName:        device.GroupName,
 			GroupUUID:        device.GroupUUID,
+			DriftStatus:      driftStatuses[device.ID],
+			Tags:             device.SystemProfile.DeviceTags(),
 		}
 		returnDevices = append(returnDevices, currentDeviceView)
 	}
@@ -1059,8 +1691,15 @@ func (s *DeviceService) platformInventoryCreateEventHelper(e PlatformInsightsCre
 		LastSeen:    e.Host.Updated,
 		GroupName:   deviceGroupName,
 		GroupUUID:   deviceGroupUUID,
+
+		SystemProfile:         e.Host.deviceSystemProfile(),
+		SubscriptionManagerID: e.Host.SubscriptionManagerID,
 	}
 
+	// a culled device created again in inventory is restored
+	if err := restoreCulledDevice(s.log, newDevice.UUID, newDevice.LastSeen); err != nil {
+		return err
+	}
 	// We should not create a new device if UUID already exists
 	result := db.DB.Where(&models.Device{UUID: newDevice.UUID}).FirstOrCreate(&newDevice)
 
@@ -17,6 +17,7 @@ import (
 
 	"github.com/bxcodec/faker/v3"
 	"github.com/golang/mock/gomock"
+	"github.com/redhatinsights/edge-api/config"
 	"github.com/redhatinsights/edge-api/pkg/clients/inventory"
 	"github.com/redhatinsights/edge-api/pkg/clients/inventory/mock_inventory"
 	"github.com/redhatinsights/edge-api/pkg/common/seeder"
@@ -714,6 +715,84 @@ var _ = Describe("DfseviceService", func() {
 			Expect(savedDevice.GroupName).To(Equal(event.Host.Groups[0].Name))
 		})
 
+		It("should record the rhc connectivity changes reported by inventory", func() {
+			device := models.Device{UUID: faker.UUIDHyphenated(), OrgID: orgID, RHCClientID: faker.UUIDHyphenated(), Connected: true}
+			Expect(db.DB.Create(&device).Error).To(BeNil())
+
+			event := new(services.PlatformInsightsCreateUpdateEventPayload)
+			event.Type = services.InventoryEventTypeUpdated
+			event.Host.ID = device.UUID
+			event.Host.OrgID = orgID
+			event.Host.Name = faker.UUIDHyphenated()
+			event.Host.Updated = models.EdgeAPITime(sql.NullTime{Time: time.Now().UTC(), Valid: true})
+			event.Host.SystemProfile.HostType = services.InventoryHostTypeEdge
+			event.Host.SystemProfile.RpmOSTreeDeployments = []services.RpmOSTreeDeployment{{Booted: true, Checksum: commit.OSTreeCommit}}
+			message, err := json.Marshal(event)
+			Expect(err).To(BeNil())
+			Expect(deviceService.ProcessPlatformInventoryUpdatedEvent(message)).To(Succeed())
+
+			event.Host.SystemProfile.RHCClientID = device.RHCClientID
+			message, err = json.Marshal(event)
+			Expect(err).To(BeNil())
+			Expect(deviceService.ProcessPlatformInventoryUpdatedEvent(message)).To(Succeed())
+			// an unchanged connectivity is not recorded again
+			Expect(deviceService.ProcessPlatformInventoryUpdatedEvent(message)).To(Succeed())
+
+			var savedDevice models.Device
+			Expect(db.DB.First(&savedDevice, device.ID).Error).To(BeNil())
+			Expect(savedDevice.Connected).To(BeTrue())
+			var events []models.DeviceEvent
+			Expect(db.DB.Where("device_id = ? AND type IN (?)", device.ID, models.DeviceConnectivityEventTypes).
+				Order("id").Find(&events).Error).To(BeNil())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Type).To(Equal(models.DeviceEventTypeDisconnected))
+			Expect(events[1].Type).To(Equal(models.DeviceEventTypeConnected))
+		})
+
+		It("should persist the configured subset of the system profile", func() {
+			initialProfileFields := config.Get().InventoryProfileFields
+			defer func() { config.Get().InventoryProfileFields = initialProfileFields }()
+			config.Get().InventoryProfileFields = []string{
+				models.DeviceSystemProfileFieldArch, models.DeviceSystemProfileFieldCPU, models.DeviceSystemProfileFieldNetworkInterfaces,
+				models.DeviceSystemProfileFieldTags, models.DeviceSystemProfileFieldRpmOSTreeDeployments,
+			}
+
+			event := new(services.PlatformInsightsCreateUpdateEventPayload)
+			event.Type = services.InventoryEventTypeUpdated
+			event.Host.ID = faker.UUIDHyphenated()
+			event.Host.OrgID = orgID
+			event.Host.Name = faker.UUIDHyphenated()
+			event.Host.SystemProfile.HostType = services.InventoryHostTypeEdge
+			event.Host.SystemProfile.RpmOSTreeDeployments = []services.RpmOSTreeDeployment{{Booted: true, Checksum: commit.OSTreeCommit, Version: "9.2"}}
+			event.Host.SystemProfile.Arch = "x86_64"
+			event.Host.SystemProfile.OSRelease = "9.2"
+			event.Host.SystemProfile.CPUModel = "Intel(R) Xeon(R)"
+			event.Host.SystemProfile.NumberOfCPUs = 4
+			event.Host.SystemProfile.SystemMemoryBytes = 8589934592
+			event.Host.SystemProfile.NetworkInterfaces = []models.DeviceNetworkInterface{{Name: "eth0", IPv4Addresses: []string{"10.0.0.42"}}}
+			event.Host.Tags = []services.PlatformInsightsTag{{Namespace: "site", Key: "store", Value: "042"}, {Namespace: "insights-client", Key: "kiosk"}}
+			message, err := json.Marshal(event)
+			Expect(err).To(BeNil())
+
+			err = deviceService.ProcessPlatformInventoryUpdatedEvent(message)
+			Expect(err).To(BeNil())
+
+			var savedDevice models.Device
+			res := db.DB.Where("uuid = ?", event.Host.ID).First(&savedDevice)
+			Expect(res.Error).To(BeNil())
+			profile := savedDevice.SystemProfile
+			Expect(profile.Arch).To(Equal("x86_64"))
+			Expect(profile.CPUModel).To(Equal("Intel(R) Xeon(R)"))
+			Expect(profile.NumberOfCPUs).To(Equal(4))
+			Expect(profile.NetworkInterfaces).To(Equal(event.Host.SystemProfile.NetworkInterfaces))
+			Expect(profile.Tags).To(Equal([]string{"site/store=042", "insights-client/kiosk"}))
+			Expect(profile.RpmOSTreeDeployments).To(Equal([]models.DeviceRpmOSTreeDeployment{{Checksum: commit.OSTreeCommit, Version: "9.2", Booted: true}}))
+			// the fields not configured are not persisted
+			Expect(profile.OSRelease).To(BeEmpty())
+			Expect(profile.SystemMemoryBytes).To(BeZero())
+			Expect(profile.HostType).To(BeEmpty())
+		})
+
 		Context("device update availability", func() {
 			device := models.Device{
 				UUID:            faker.UUIDHyphenated(),
@@ -1335,6 +1414,63 @@ var _ = Describe("DfseviceService", func() {
 			})
 		})
 	})
+	Context("ExportDevicesView", func() {
+		var image *models.Image
+		var devicesName string
+		var devices []models.Device
+		BeforeEach(func() {
+			image, _ = seeder.Images().Create()
+			devicesName = faker.UUIDHyphenated()
+			devices = make([]models.Device, 0, 3)
+			for i := 0; i < 3; i++ {
+				device := models.Device{OrgID: common.DefaultOrgID, ImageID: image.ID, UUID: faker.UUIDHyphenated(), Name: devicesName}
+				Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+				devices = append(devices, device)
+			}
+		})
+
+		It("should export all the devices matching the filter by batches", func() {
+			var batches [][]models.DeviceView
+			tx := db.DB.Where("devices.name = ?", devicesName)
+			err := deviceService.ExportDevicesView(tx, 2, func(devicesView []models.DeviceView) error {
+				batches = append(batches, devicesView)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(batches)).To(Equal(2))
+			Expect(len(batches[0])).To(Equal(2))
+			Expect(len(batches[1])).To(Equal(1))
+			exportedUUIDs := []string{batches[0][0].DeviceUUID, batches[0][1].DeviceUUID, batches[1][0].DeviceUUID}
+			// the devices are exported in their id order
+			Expect(exportedUUIDs).To(Equal([]string{devices[0].UUID, devices[1].UUID, devices[2].UUID}))
+			for _, deviceView := range append(batches[0], batches[1]...) {
+				Expect(deviceView.ImageName).To(Equal(image.Name))
+				Expect(deviceView.ImageVersion).To(Equal(image.Version))
+			}
+		})
+
+		It("should not call the writer when no device matches the filter", func() {
+			tx := db.DB.Where("devices.name = ?", faker.UUIDHyphenated())
+			err := deviceService.ExportDevicesView(tx, 2, func(devicesView []models.DeviceView) error {
+				Fail("the writer should not be called")
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should stop and return the writer error", func() {
+			expectedError := errors.New("expected writer error")
+			var calls int
+			tx := db.DB.Where("devices.name = ?", devicesName)
+			err := deviceService.ExportDevicesView(tx, 1, func(devicesView []models.DeviceView) error {
+				calls++
+				return expectedError
+			})
+			Expect(err).To(MatchError(expectedError))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
 	Context("Get CommitID from Device Image", func() {
 		It("should return zero images", func() {
 			device := models.Device{
@@ -2109,4 +2245,406 @@ var _ = Describe("DfseviceService", func() {
 			Expect(len((*imageInfo.UpdatesAvailable))).To(Equal(2))
 		})
 	})
+
+	Context("DetectDeviceRollback", func() {
+		var mockUpdateService *mock_services.MockUpdateServiceInterface
+		var device models.Device
+		var previousCommit models.Commit
+		var update models.UpdateTransaction
+
+		BeforeEach(func() {
+			mockUpdateService = mock_services.NewMockUpdateServiceInterface(ctrl)
+			deviceService.UpdateService = mockUpdateService
+
+			previousCommit = models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+			updateCommit := models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&previousCommit).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Create(&updateCommit).Error).ToNot(HaveOccurred())
+			imageSet := models.ImageSet{Name: faker.UUIDHyphenated(), OrgID: orgID}
+			Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+			images := []models.Image{
+				{OrgID: orgID, CommitID: previousCommit.ID, ImageSetID: &imageSet.ID, Status: models.ImageStatusSuccess},
+				{OrgID: orgID, CommitID: updateCommit.ID, ImageSetID: &imageSet.ID, Status: models.ImageStatusSuccess},
+			}
+			Expect(db.DB.Create(&images).Error).ToNot(HaveOccurred())
+
+			device = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: faker.Name(), ImageID: images[1].ID}
+			Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+			update = models.UpdateTransaction{
+				OrgID:           orgID,
+				CommitID:        updateCommit.ID,
+				Devices:         []models.Device{device},
+				Status:          models.UpdateStatusSuccess,
+				DispatchRecords: []models.DispatchRecord{{DeviceID: device.ID, Status: models.DispatchRecordStatusComplete}},
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+			update.Commit = &updateCommit
+		})
+
+		It("should record the rollback when the device booted its previous deployment", func() {
+			inventoryDevice := inventory.Device{ID: device.UUID, Ostree: inventory.SystemProfile{
+				RpmOstreeDeployments: []inventory.OSTree{
+					{Checksum: previousCommit.OSTreeCommit, Booted: true},
+					{Checksum: update.Commit.OSTreeCommit, Booted: false},
+				},
+				GreenbootStatus:           "red",
+				GreenbootFallbackDetected: true,
+			}}
+			mockUpdateService.EXPECT().SetUpdateStatus(gomock.Any()).DoAndReturn(func(u *models.UpdateTransaction) error {
+				Expect(u.ID).To(Equal(update.ID))
+				Expect(u.DispatchRecords[0].Status).To(Equal(models.DispatchRecordStatusRolledBack))
+				return nil
+			})
+			mockUpdateService.EXPECT().SendDeviceNotification(gomock.Any()).Return(services.ImageNotification{}, nil)
+
+			rollback, err := deviceService.DetectDeviceRollback(&device, inventoryDevice)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback).ToNot(BeNil())
+			Expect(rollback.UpdateTransactionID).To(Equal(update.ID))
+			Expect(rollback.TargetCommit).To(Equal(update.Commit.OSTreeCommit))
+			Expect(rollback.BootedCommit).To(Equal(previousCommit.OSTreeCommit))
+			Expect(rollback.GreenbootStatus).To(Equal("red"))
+			Expect(rollback.GreenbootFallbackDetected).To(BeTrue())
+
+			var dispatchRecord models.DispatchRecord
+			Expect(db.DB.First(&dispatchRecord, update.DispatchRecords[0].ID).Error).ToNot(HaveOccurred())
+			Expect(dispatchRecord.Status).To(Equal(models.DispatchRecordStatusRolledBack))
+			Expect(dispatchRecord.Reason).To(Equal(models.UpdateReasonRolledBack))
+
+			// the rollback is recorded only once
+			rollback, err = deviceService.DetectDeviceRollback(&device, inventoryDevice)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback).To(BeNil())
+
+			rollbacks, err := deviceService.GetDeviceRollbacks(orgID, device.UUID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollbacks).To(HaveLen(1))
+			Expect(rollbacks[0].UpdateTransactionID).To(Equal(update.ID))
+		})
+
+		It("should not detect a rollback when the device booted the update commit", func() {
+			inventoryDevice := inventory.Device{ID: device.UUID, Ostree: inventory.SystemProfile{
+				RpmOstreeDeployments: []inventory.OSTree{{Checksum: update.Commit.OSTreeCommit, Booted: true}},
+			}}
+			rollback, err := deviceService.DetectDeviceRollback(&device, inventoryDevice)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback).To(BeNil())
+		})
+
+		It("should not detect a rollback when the update deployment waits for the device reboot", func() {
+			inventoryDevice := inventory.Device{ID: device.UUID, Ostree: inventory.SystemProfile{
+				RpmOstreeDeployments: []inventory.OSTree{
+					{Checksum: update.Commit.OSTreeCommit, Booted: false},
+					{Checksum: previousCommit.OSTreeCommit, Booted: true},
+				},
+			}}
+			rollback, err := deviceService.DetectDeviceRollback(&device, inventoryDevice)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback).To(BeNil())
+		})
+
+		It("should not detect a rollback when the update playbook did not complete", func() {
+			Expect(db.DB.Model(&models.DispatchRecord{}).Where("id = ?", update.DispatchRecords[0].ID).
+				Update("status", models.DispatchRecordStatusRunning).Error).ToNot(HaveOccurred())
+			inventoryDevice := inventory.Device{ID: device.UUID, Ostree: inventory.SystemProfile{
+				RpmOstreeDeployments: []inventory.OSTree{{Checksum: previousCommit.OSTreeCommit, Booted: true}},
+			}}
+			rollback, err := deviceService.DetectDeviceRollback(&device, inventoryDevice)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollback).To(BeNil())
+		})
+
+		It("should detect the rollback from the inventory updated event", func() {
+			mockUpdateService.EXPECT().SetUpdateStatus(gomock.Any()).Return(nil)
+			mockUpdateService.EXPECT().SendDeviceNotification(gomock.Any()).Return(services.ImageNotification{}, nil)
+			event := new(services.PlatformInsightsCreateUpdateEventPayload)
+			event.Type = services.InventoryEventTypeUpdated
+			event.Host.ID = device.UUID
+			event.Host.OrgID = orgID
+			event.Host.Name = device.Name
+			event.Host.Updated = models.EdgeAPITime(sql.NullTime{Time: time.Now().UTC(), Valid: true})
+			event.Host.SystemProfile.HostType = services.InventoryHostTypeEdge
+			event.Host.SystemProfile.RpmOSTreeDeployments = []services.RpmOSTreeDeployment{
+				{Booted: true, Checksum: previousCommit.OSTreeCommit},
+				{Booted: false, Checksum: update.Commit.OSTreeCommit},
+			}
+			event.Host.SystemProfile.GreenbootStatus = "red"
+			message, err := json.Marshal(event)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deviceService.ProcessPlatformInventoryUpdatedEvent(message)).To(Succeed())
+
+			rollbacks, err := deviceService.GetDeviceRollbacks(orgID, device.UUID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollbacks).To(HaveLen(1))
+			Expect(rollbacks[0].GreenbootStatus).To(Equal("red"))
+		})
+
+		It("should return device not found error when getting the rollbacks of an unknown device", func() {
+			_, err := deviceService.GetDeviceRollbacks(orgID, faker.UUIDHyphenated())
+			Expect(err).To(MatchError(new(services.DeviceNotFoundError)))
+		})
+	})
+
+	Context("CheckInDevice", func() {
+		var device models.Device
+		var bootedCommit, stagedCommit models.Commit
+		var bootedImage models.Image
+
+		BeforeEach(func() {
+			bootedCommit = models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+			stagedCommit = models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&bootedCommit).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Create(&stagedCommit).Error).ToNot(HaveOccurred())
+			imageSet := models.ImageSet{Name: faker.UUIDHyphenated(), OrgID: orgID}
+			Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+			bootedImage = models.Image{OrgID: orgID, CommitID: bootedCommit.ID, ImageSetID: &imageSet.ID, Status: models.ImageStatusSuccess}
+			Expect(db.DB.Create(&bootedImage).Error).ToNot(HaveOccurred())
+			stagedImage := models.Image{OrgID: orgID, CommitID: stagedCommit.ID, ImageSetID: &imageSet.ID, Status: models.ImageStatusSuccess}
+			stagedImage.CreatedAt = models.EdgeAPITime(sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true})
+			Expect(db.DB.Create(&stagedImage).Error).ToNot(HaveOccurred())
+
+			device = models.Device{
+				OrgID: orgID, UUID: faker.UUIDHyphenated(), SubscriptionManagerID: faker.UUIDHyphenated(),
+				Staleness: models.DeviceStalenessStale,
+			}
+			Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+		})
+
+		It("should update the device with the reported state", func() {
+			checkIn := models.DeviceCheckIn{
+				BootedCommit: bootedCommit.OSTreeCommit, StagedCommit: stagedCommit.OSTreeCommit,
+				GreenbootStatus: models.DeviceGreenbootStatusGreen, AgentVersion: "0.2.8",
+			}
+			result, err := deviceService.CheckInDevice(orgID, device.SubscriptionManagerID, &checkIn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.DeviceUUID).To(Equal(device.UUID))
+			Expect(result.UpdateAvailable).To(BeTrue())
+
+			var savedDevice models.Device
+			Expect(db.DB.First(&savedDevice, device.ID).Error).ToNot(HaveOccurred())
+			Expect(savedDevice.LastSeen.Time).To(BeTemporally("~", time.Now(), time.Minute))
+			Expect(savedDevice.CurrentHash).To(Equal(bootedCommit.OSTreeCommit))
+			Expect(savedDevice.AvailableHash).To(Equal(stagedCommit.OSTreeCommit))
+			Expect(savedDevice.GreenbootStatus).To(Equal(models.DeviceGreenbootStatusGreen))
+			Expect(savedDevice.AgentVersion).To(Equal("0.2.8"))
+			Expect(savedDevice.Staleness).To(Equal(models.DeviceStalenessFresh))
+			Expect(savedDevice.ImageID).To(Equal(bootedImage.ID))
+
+			var events []models.DeviceEvent
+			Expect(db.DB.Where("device_id = ?", device.ID).Order("id").Find(&events).Error).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Type).To(Equal(models.DeviceEventTypeStalenessChanged))
+			Expect(events[1].Type).To(Equal(models.DeviceEventTypeImageChanged))
+			Expect(events[1].To).To(Equal(bootedCommit.OSTreeCommit))
+		})
+
+		It("should keep the device image when the booted commit is unknown", func() {
+			checkIn := models.DeviceCheckIn{BootedCommit: faker.UUIDHyphenated()}
+			result, err := deviceService.CheckInDevice(orgID, device.SubscriptionManagerID, &checkIn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.UpdateAvailable).To(BeFalse())
+
+			var savedDevice models.Device
+			Expect(db.DB.First(&savedDevice, device.ID).Error).ToNot(HaveOccurred())
+			Expect(savedDevice.CurrentHash).To(Equal(checkIn.BootedCommit))
+			Expect(savedDevice.ImageID).To(BeZero())
+		})
+
+		It("should restore a culled device checking in again", func() {
+			group := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic}
+			Expect(db.DB.Create(&group).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Model(&device).Select("staleness", "culled_device_groups_ids").
+				Updates(models.Device{Staleness: models.DeviceStalenessCulled, CulledDeviceGroupsIDs: []uint{group.ID}}).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Delete(&device).Error).ToNot(HaveOccurred())
+
+			checkIn := models.DeviceCheckIn{BootedCommit: bootedCommit.OSTreeCommit}
+			result, err := deviceService.CheckInDevice(orgID, device.SubscriptionManagerID, &checkIn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.DeviceUUID).To(Equal(device.UUID))
+
+			var savedDevice models.Device
+			Expect(db.DB.Preload("DevicesGroups").First(&savedDevice, device.ID).Error).ToNot(HaveOccurred())
+			Expect(savedDevice.Staleness).To(Equal(models.DeviceStalenessFresh))
+			Expect(savedDevice.CurrentHash).To(Equal(bootedCommit.OSTreeCommit))
+			Expect(savedDevice.DevicesGroups).To(HaveLen(1))
+			Expect(savedDevice.DevicesGroups[0].ID).To(Equal(group.ID))
+			var events []models.DeviceEvent
+			Expect(db.DB.Where("device_id = ? AND type = ?", device.ID, models.DeviceEventTypeStalenessChanged).
+				Find(&events).Error).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].From).To(Equal(models.DeviceStalenessCulled))
+			Expect(events[0].To).To(Equal(models.DeviceStalenessFresh))
+		})
+
+		It("should return device not found error", func() {
+			checkIn := models.DeviceCheckIn{BootedCommit: bootedCommit.OSTreeCommit}
+			_, err := deviceService.CheckInDevice(orgID, faker.UUIDHyphenated(), &checkIn)
+			Expect(err).To(MatchError(new(services.DeviceNotFoundError)))
+			_, err = deviceService.CheckInDevice(faker.UUIDHyphenated(), device.SubscriptionManagerID, &checkIn)
+			Expect(err).To(MatchError(new(services.DeviceNotFoundError)))
+		})
+	})
+
+	Context("device pull updates", func() {
+		var mockUpdateService *mock_services.MockUpdateServiceInterface
+		var device models.Device
+		var deviceGroup models.DeviceGroup
+		var desiredImage models.Image
+		var desiredCommit models.Commit
+
+		BeforeEach(func() {
+			mockUpdateService = mock_services.NewMockUpdateServiceInterface(ctrl)
+			deviceService.UpdateService = mockUpdateService
+
+			currentCommit := models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated(), OSTreeRef: "rhel/8/x86_64/edge"}
+			desiredCommit = models.Commit{
+				OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated(), OSTreeRef: "rhel/9/x86_64/edge",
+				Repo: &models.Repo{URL: faker.URL(), Status: models.RepoStatusSuccess},
+			}
+			Expect(db.DB.Create(&currentCommit).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Create(&desiredCommit).Error).ToNot(HaveOccurred())
+			imageSet := models.ImageSet{Name: faker.UUIDHyphenated(), OrgID: orgID}
+			Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+			currentImage := models.Image{OrgID: orgID, CommitID: currentCommit.ID, ImageSetID: &imageSet.ID, Status: models.ImageStatusSuccess, Version: 1}
+			Expect(db.DB.Create(&currentImage).Error).ToNot(HaveOccurred())
+			desiredImage = models.Image{OrgID: orgID, CommitID: desiredCommit.ID, ImageSetID: &imageSet.ID, Status: models.ImageStatusSuccess, Version: 2}
+			Expect(db.DB.Create(&desiredImage).Error).ToNot(HaveOccurred())
+
+			device = models.Device{
+				OrgID: orgID, UUID: faker.UUIDHyphenated(), SubscriptionManagerID: faker.UUIDHyphenated(),
+				ImageID: currentImage.ID, CurrentHash: currentCommit.OSTreeCommit,
+			}
+			Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+			deviceGroup = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic, Devices: []models.Device{device}}
+			Expect(db.DB.Omit("Devices.*").Create(&deviceGroup).Error).ToNot(HaveOccurred())
+		})
+
+		createUpdate := func(dispatchRecords ...models.DispatchRecord) models.UpdateTransaction {
+			update := models.UpdateTransaction{
+				OrgID:           orgID,
+				CommitID:        desiredCommit.ID,
+				RepoID:          desiredCommit.RepoID,
+				Devices:         []models.Device{device},
+				Status:          models.UpdateStatusError,
+				DispatchRecords: dispatchRecords,
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+			return update
+		}
+
+		It("should return the device is up to date without desired state", func() {
+			manifest, err := deviceService.GetDeviceUpdateManifest(orgID, device.SubscriptionManagerID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.DeviceUUID).To(Equal(device.UUID))
+			Expect(manifest.UpToDate).To(BeTrue())
+			Expect(manifest.CommitHash).To(BeEmpty())
+		})
+
+		It("should return the desired image of the device group", func() {
+			Expect(db.DB.Create(&models.DeviceGroupDesiredState{
+				OrgID: orgID, DeviceGroupID: deviceGroup.ID, ImageSetID: *desiredImage.ImageSetID, Version: 2, ImageID: desiredImage.ID,
+			}).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Create(&models.UpdatePolicy{
+				OrgID: orgID, DeviceGroupID: deviceGroup.ID, ImageSetID: *desiredImage.ImageSetID,
+				Schedule: models.UpdatePolicyScheduleWindow, WindowStart: "22:00", WindowEnd: "04:00",
+			}).Error).ToNot(HaveOccurred())
+
+			manifest, err := deviceService.GetDeviceUpdateManifest(orgID, device.SubscriptionManagerID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.UpToDate).To(BeFalse())
+			Expect(manifest.UpdateTransactionID).To(BeZero())
+			Expect(manifest.CommitHash).To(Equal(desiredCommit.OSTreeCommit))
+			Expect(manifest.OSTreeRef).To(Equal(desiredCommit.OSTreeRef))
+			Expect(manifest.ChangesRefs).To(BeTrue())
+			Expect(manifest.RemoteName).To(Equal(models.DeviceUpdateManifestRemoteName))
+			Expect(manifest.RemoteURL).To(Equal(fmt.Sprintf("%s/api/edge/v1/storage/images-repos/%d", config.Get().EdgeCertAPIBaseURL, desiredImage.ID)))
+			Expect(manifest.MaintenanceWindow).To(Equal(&models.DeviceMaintenanceWindow{Start: "22:00", End: "04:00"}))
+
+			// the device running the desired commit is up to date
+			Expect(db.DB.Model(&device).Update("current_hash", desiredCommit.OSTreeCommit).Error).ToNot(HaveOccurred())
+			manifest, err = deviceService.GetDeviceUpdateManifest(orgID, device.SubscriptionManagerID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.UpToDate).To(BeTrue())
+		})
+
+		It("should return the update the playbook could not be dispatched for", func() {
+			update := createUpdate(models.DispatchRecord{DeviceID: device.ID, Status: models.DispatchRecordStatusError, Reason: models.UpdateReasonFailure})
+
+			manifest, err := deviceService.GetDeviceUpdateManifest(orgID, device.SubscriptionManagerID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.UpToDate).To(BeFalse())
+			Expect(manifest.UpdateTransactionID).To(Equal(update.ID))
+			Expect(manifest.CommitHash).To(Equal(desiredCommit.OSTreeCommit))
+			Expect(manifest.RemoteURL).To(Equal(fmt.Sprintf("%s/api/edge/v1/storage/update-repos/%d", config.Get().EdgeCertAPIBaseURL, update.ID)))
+		})
+
+		It("should return the update of a disconnected device from the commit repo", func() {
+			update := models.UpdateTransaction{
+				OrgID: orgID, CommitID: desiredCommit.ID, Devices: []models.Device{device}, Status: models.UpdateStatusDeviceDisconnected,
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+
+			manifest, err := deviceService.GetDeviceUpdateManifest(orgID, device.SubscriptionManagerID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.UpToDate).To(BeFalse())
+			Expect(manifest.UpdateTransactionID).To(Equal(update.ID))
+			Expect(manifest.CommitHash).To(Equal(desiredCommit.OSTreeCommit))
+			Expect(manifest.RemoteURL).To(Equal(fmt.Sprintf("%s/api/edge/v1/storage/images-repos/%d", config.Get().EdgeCertAPIBaseURL, desiredImage.ID)))
+		})
+
+		It("should not return the update the playbook dispatcher runs", func() {
+			createUpdate(models.DispatchRecord{DeviceID: device.ID, Status: models.DispatchRecordStatusRunning})
+
+			manifest, err := deviceService.GetDeviceUpdateManifest(orgID, device.SubscriptionManagerID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.UpToDate).To(BeTrue())
+		})
+
+		It("should record the update result reported by the device", func() {
+			update := createUpdate(models.DispatchRecord{DeviceID: device.ID, Status: models.DispatchRecordStatusError, Reason: models.UpdateReasonFailure})
+			mockUpdateService.EXPECT().SetUpdateStatusBasedOnDispatchRecord(gomock.Any()).DoAndReturn(func(record models.DispatchRecord) error {
+				Expect(record.ID).To(Equal(update.DispatchRecords[0].ID))
+				return nil
+			})
+
+			Expect(deviceService.SetDeviceUpdateResult(orgID, device.SubscriptionManagerID, &models.DeviceUpdateResult{
+				UpdateTransactionID: update.ID, Status: models.DeviceUpdateResultStatusFailure, Reason: "no space left on device",
+			})).To(Succeed())
+
+			var dispatchRecord models.DispatchRecord
+			Expect(db.DB.First(&dispatchRecord, update.DispatchRecords[0].ID).Error).ToNot(HaveOccurred())
+			Expect(dispatchRecord.Status).To(Equal(models.DispatchRecordStatusError))
+			Expect(dispatchRecord.Reason).To(Equal(models.UpdateReasonDeviceFailure + " no space left on device"))
+
+			// the update failed on the device is not pulled again
+			manifest, err := deviceService.GetDeviceUpdateManifest(orgID, device.SubscriptionManagerID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.UpToDate).To(BeTrue())
+		})
+
+		It("should create the device dispatch record of the update result", func() {
+			update := createUpdate()
+			mockUpdateService.EXPECT().SetUpdateStatusBasedOnDispatchRecord(gomock.Any()).Return(nil)
+
+			Expect(deviceService.SetDeviceUpdateResult(orgID, device.SubscriptionManagerID, &models.DeviceUpdateResult{
+				UpdateTransactionID: update.ID, Status: models.DeviceUpdateResultStatusSuccess,
+			})).To(Succeed())
+
+			var savedUpdate models.UpdateTransaction
+			Expect(db.DB.Preload("DispatchRecords").First(&savedUpdate, update.ID).Error).ToNot(HaveOccurred())
+			Expect(savedUpdate.DispatchRecords).To(HaveLen(1))
+			Expect(savedUpdate.DispatchRecords[0].DeviceID).To(Equal(device.ID))
+			Expect(savedUpdate.DispatchRecords[0].Status).To(Equal(models.DispatchRecordStatusComplete))
+		})
+
+		It("should return update not found error when the update is not of the device", func() {
+			update := models.UpdateTransaction{OrgID: orgID, CommitID: desiredCommit.ID}
+			Expect(db.DB.Create(&update).Error).ToNot(HaveOccurred())
+			err := deviceService.SetDeviceUpdateResult(orgID, device.SubscriptionManagerID, &models.DeviceUpdateResult{
+				UpdateTransactionID: update.ID, Status: models.DeviceUpdateResultStatusSuccess,
+			})
+			Expect(err).To(MatchError(new(services.UpdateNotFoundError)))
+		})
+	})
 })
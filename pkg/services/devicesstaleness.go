@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/jobs"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DeviceStalenessServiceInterface defines the interface that helps handling the devices staleness lifecycle
+type DeviceStalenessServiceInterface interface {
+	GetStalenessSettings(orgID string) (*models.DeviceStalenessSettings, error)
+	SetStalenessSettings(orgID string, settings *models.DeviceStalenessSettings) (*models.DeviceStalenessSettings, error)
+	UpdateDevicesStaleness() error
+}
+
+// NewDeviceStalenessService gives an instance of the main implementation of DeviceStalenessServiceInterface
+func NewDeviceStalenessService(ctx context.Context, log log.FieldLogger) DeviceStalenessServiceInterface {
+	return &DeviceStalenessService{
+		Service: Service{ctx: ctx, log: log.WithField("service", "device-staleness")},
+	}
+}
+
+// DeviceStalenessService is the main implementation of a DeviceStalenessServiceInterface
+type DeviceStalenessService struct {
+	Service
+}
+
+// DeviceStalenessLockKey is the db advisory lock key of the device staleness job
+const DeviceStalenessLockKey int64 = 4502
+
+// DeviceStalenessJob is the job moving the devices not seen from fresh to stale to culled
+type DeviceStalenessJob struct{}
+
+// DeviceStalenessJobHandler moves the devices not seen from fresh to stale to culled
+func DeviceStalenessJobHandler(ctx context.Context, _ *jobs.Job) {
+	if !feature.DeviceStalenessCulling.IsEnabled() {
+		log.WithContext(ctx).Warning("device staleness culling feature flag is disabled")
+		return
+	}
+	s := NewDeviceStalenessService(ctx, log.StandardLogger().WithContext(ctx))
+	// every replica schedules the job, only the one holding the lock culls and restores the devices
+	locked, err := db.WithAdvisoryLock(ctx, DeviceStalenessLockKey, func() {
+		if err := s.UpdateDevicesStaleness(); err != nil {
+			log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when updating devices staleness")
+		}
+	})
+	if err != nil {
+		log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when locking the devices staleness update")
+	} else if !locked {
+		log.WithContext(ctx).Info("devices staleness update is already running on another replica")
+	}
+}
+
+func init() {
+	jobs.RegisterHandlers("DeviceStalenessJob", DeviceStalenessJobHandler, jobs.IgnoredJobHandler)
+}
+
+// ScheduleDeviceStalenessJob updates periodically the devices staleness until the context is done
+func ScheduleDeviceStalenessJob(ctx context.Context) {
+	interval := config.Get().DeviceStalenessInterval
+	if interval <= 0 {
+		log.WithContext(ctx).Info("device staleness job is disabled")
+		return
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if feature.JobQueue.IsEnabledCtx(ctx) {
+			if err := jobs.NewAndEnqueue(ctx, "DeviceStalenessJob", &DeviceStalenessJob{}); err != nil {
+				log.WithContext(ctx).WithField("error", err.Error()).Error("Failed enqueueing job")
+			}
+		} else {
+			DeviceStalenessJobHandler(ctx, nil)
+		}
+	}
+}
+
+// defaultStalenessSettings returns the configured staleness thresholds of the orgs without their own settings
+func defaultStalenessSettings(orgID string) *models.DeviceStalenessSettings {
+	cfg := config.Get()
+	return &models.DeviceStalenessSettings{OrgID: orgID, StaleHours: cfg.DeviceStaleHours, CulledHours: cfg.DeviceCulledHours}
+}
+
+// GetStalenessSettings returns the devices staleness thresholds of an org, the configured defaults when the org has none
+func (s *DeviceStalenessService) GetStalenessSettings(orgID string) (*models.DeviceStalenessSettings, error) {
+	var settings models.DeviceStalenessSettings
+	if result := db.Org(orgID, "").First(&settings); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return defaultStalenessSettings(orgID), nil
+		}
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "org_id": orgID}).Error("error getting device staleness settings")
+		return nil, result.Error
+	}
+	return &settings, nil
+}
+
+// SetStalenessSettings creates or replaces the devices staleness thresholds of an org
+func (s *DeviceStalenessService) SetStalenessSettings(orgID string, settings *models.DeviceStalenessSettings) (*models.DeviceStalenessSettings, error) {
+	logger := s.log.WithField("org_id", orgID)
+	if err := settings.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	existing, err := s.GetStalenessSettings(orgID)
+	if err != nil {
+		return nil, err
+	}
+	existing.StaleHours = settings.StaleHours
+	existing.CulledHours = settings.CulledHours
+	if result := db.DB.Save(existing); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error saving device staleness settings")
+		return nil, result.Error
+	}
+	logger.WithFields(log.Fields{"stale_hours": existing.StaleHours, "culled_hours": existing.CulledHours}).Info("device staleness settings set")
+	return existing, nil
+}
+
+// UpdateDevicesStaleness marks stale the devices of all the orgs not seen within the stale threshold of their org,
+// and culls the devices not seen within the culled threshold
+func (s *DeviceStalenessService) UpdateDevicesStaleness() error {
+	var orgsIDs []string
+	if result := db.DB.Model(&models.Device{}).Distinct("org_id").Where("last_seen IS NOT NULL").
+		Order("org_id").Pluck("org_id", &orgsIDs); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error getting devices orgs")
+		return result.Error
+	}
+	var settings []models.DeviceStalenessSettings
+	if result := db.DB.Find(&settings); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error getting device staleness settings")
+		return result.Error
+	}
+	orgsSettings := make(map[string]*models.DeviceStalenessSettings, len(settings))
+	for i := range settings {
+		orgsSettings[settings[i].OrgID] = &settings[i]
+	}
+	now := time.Now()
+	for _, orgID := range orgsIDs {
+		orgSettings, ok := orgsSettings[orgID]
+		if !ok {
+			orgSettings = defaultStalenessSettings(orgID)
+		}
+		if err := s.updateOrgDevicesStaleness(orgSettings, now); err != nil {
+			s.log.WithFields(log.Fields{"error": err.Error(), "org_id": orgID}).Error("error updating org devices staleness")
+		}
+	}
+	return nil
+}
+
+// updateOrgDevicesStaleness moves the devices of an org to the staleness of the time they were last seen
+func (s *DeviceStalenessService) updateOrgDevicesStaleness(settings *models.DeviceStalenessSettings, now time.Time) error {
+	logger := s.log.WithField("org_id", settings.OrgID)
+	var devices []models.Device
+	staleTime := now.Add(-time.Duration(settings.StaleHours) * time.Hour)
+	if result := db.Org(settings.OrgID, "").Preload("DevicesGroups").
+		Where("last_seen < ? AND staleness <> ?", staleTime, models.DeviceStalenessCulled).Order("id").Find(&devices); result.Error != nil {
+		return result.Error
+	}
+	var staleDevicesIDs []uint
+	var events []models.DeviceEvent
+	for i := range devices {
+		device := &devices[i]
+		staleness := settings.Staleness(device.LastSeen.Time, now)
+		// culling the device updates its staleness and groups, keep them for the events
+		fromStaleness, groups := device.Staleness, append([]models.DeviceGroup(nil), device.DevicesGroups...)
+		switch {
+		case staleness == models.DeviceStalenessCulled:
+			if err := cullDevice(device); err != nil {
+				logger.WithFields(log.Fields{"error": err.Error(), "device_uuid": device.UUID}).Error("error culling device")
+				continue
+			}
+			for _, group := range groups {
+				events = append(events, models.DeviceEvent{
+					OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupRemoved, From: group.Name,
+				})
+			}
+		case staleness == models.DeviceStalenessStale && device.Staleness != models.DeviceStalenessStale:
+			staleDevicesIDs = append(staleDevicesIDs, device.ID)
+		default:
+			continue
+		}
+		events = append(events, models.DeviceEvent{
+			OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeStalenessChanged, From: fromStaleness, To: staleness,
+		})
+	}
+	if len(staleDevicesIDs) > 0 {
+		if result := db.DB.Model(&models.Device{}).Where("id IN (?)", staleDevicesIDs).
+			Update("staleness", models.DeviceStalenessStale); result.Error != nil {
+			return result.Error
+		}
+	}
+	createDeviceEvents(logger, events...)
+	if len(events) > 0 {
+		logger.WithField("events", len(events)).Info("devices staleness changed")
+	}
+	return nil
+}
+
+// cullDevice removes a device from its groups and deletes it, the groups are kept to add the device back to them
+// when it is seen again
+func cullDevice(device *models.Device) error {
+	groupsIDs := make([]uint, 0, len(device.DevicesGroups))
+	for _, group := range device.DevicesGroups {
+		groupsIDs = append(groupsIDs, group.ID)
+	}
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if len(device.DevicesGroups) > 0 {
+			if err := tx.Model(device).Association("DevicesGroups").Delete(device.DevicesGroups); err != nil {
+				return err
+			}
+		}
+		if result := tx.Model(device).Select("staleness", "culled_device_groups_ids").
+			Updates(models.Device{Staleness: models.DeviceStalenessCulled, CulledDeviceGroupsIDs: groupsIDs}); result.Error != nil {
+			return result.Error
+		}
+		return tx.Delete(device).Error
+	})
+}
+
+// restoreCulledDevice restores a culled device seen again and adds it back to the groups it was removed from,
+// nothing is done when the device was not culled
+func restoreCulledDevice(logger log.FieldLogger, deviceUUID string, lastSeen models.EdgeAPITime) error {
+	var device models.Device
+	if result := db.DB.Unscoped().Where("uuid = ? AND deleted_at IS NOT NULL AND staleness = ?", deviceUUID, models.DeviceStalenessCulled).
+		Limit(1).Find(&device); result.Error != nil {
+		logger.WithFields(log.Fields{"error": result.Error.Error(), "host_id": deviceUUID}).Error("error getting culled device")
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return nil
+	}
+	var groups []models.DeviceGroup
+	if len(device.CulledDeviceGroupsIDs) > 0 {
+		if result := db.Org(device.OrgID, "").Where("id IN (?)", device.CulledDeviceGroupsIDs).Find(&groups); result.Error != nil {
+			logger.WithFields(log.Fields{"error": result.Error.Error(), "host_id": deviceUUID}).Error("error getting culled device groups")
+			return result.Error
+		}
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Unscoped().Model(&device).Select("deleted_at", "staleness", "culled_device_groups_ids", "last_seen").
+			Updates(map[string]interface{}{
+				"deleted_at": nil, "staleness": models.DeviceStalenessFresh, "culled_device_groups_ids": nil, "last_seen": lastSeen,
+			}); result.Error != nil {
+			return result.Error
+		}
+		if len(groups) > 0 {
+			return tx.Model(&device).Omit("DevicesGroups.*").Association("DevicesGroups").Append(groups)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err.Error(), "host_id": deviceUUID}).Error("error restoring culled device")
+		return err
+	}
+
+	events := []models.DeviceEvent{{
+		OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeStalenessChanged,
+		From: models.DeviceStalenessCulled, To: models.DeviceStalenessFresh,
+	}}
+	for _, group := range groups {
+		events = append(events, models.DeviceEvent{OrgID: device.OrgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupAdded, To: group.Name})
+	}
+	createDeviceEvents(logger, events...)
+	logger.WithFields(log.Fields{"host_id": deviceUUID, "groups": len(groups)}).Info("culled device restored")
+	return nil
+}
+
+// ExcludeStaleDevices returns the devices uuids without the stale devices, the stale devices are excluded from the
+// updates unless explicitly included
+func ExcludeStaleDevices(orgID string, devicesUUID []string) ([]string, error) {
+	if len(devicesUUID) == 0 {
+		return devicesUUID, nil
+	}
+	var staleDevicesUUID []string
+	if result := db.Org(orgID, "").Model(&models.Device{}).Where("uuid IN (?) AND staleness = ?", devicesUUID, models.DeviceStalenessStale).
+		Pluck("uuid", &staleDevicesUUID); result.Error != nil {
+		return nil, result.Error
+	}
+	if len(staleDevicesUUID) == 0 {
+		return devicesUUID, nil
+	}
+	stale := make(map[string]bool, len(staleDevicesUUID))
+	for _, deviceUUID := range staleDevicesUUID {
+		stale[deviceUUID] = true
+	}
+	targets := make([]string, 0, len(devicesUUID)-len(staleDevicesUUID))
+	for _, deviceUUID := range devicesUUID {
+		if !stale[deviceUUID] {
+			targets = append(targets, deviceUUID)
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,171 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+var _ = Describe("DeviceStalenessService", func() {
+	var service services.DeviceStalenessServiceInterface
+	var orgID string
+
+	lastSeen := func(hours int) models.EdgeAPITime {
+		return models.EdgeAPITime{Time: time.Now().Add(-time.Duration(hours) * time.Hour), Valid: true}
+	}
+
+	deviceEvents := func(device models.Device, eventType string) []models.DeviceEvent {
+		var events []models.DeviceEvent
+		Expect(db.DB.Where("device_id = ? AND type = ?", device.ID, eventType).Order("id").Find(&events).Error).ToNot(HaveOccurred())
+		return events
+	}
+
+	BeforeEach(func() {
+		service = services.NewDeviceStalenessService(context.Background(), log.NewEntry(log.StandardLogger()))
+		orgID = faker.UUIDHyphenated()
+	})
+
+	Context("staleness settings", func() {
+		It("should return the configured defaults when the org has no settings", func() {
+			settings, err := service.GetStalenessSettings(orgID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.OrgID).To(Equal(orgID))
+			Expect(settings.StaleHours).To(Equal(config.Get().DeviceStaleHours))
+			Expect(settings.CulledHours).To(Equal(config.Get().DeviceCulledHours))
+		})
+
+		It("should set and replace the org settings", func() {
+			_, err := service.SetStalenessSettings(orgID, &models.DeviceStalenessSettings{StaleHours: 2, CulledHours: 48})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = service.SetStalenessSettings(orgID, &models.DeviceStalenessSettings{StaleHours: 4, CulledHours: 96})
+			Expect(err).ToNot(HaveOccurred())
+
+			settings, err := service.GetStalenessSettings(orgID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.ID).ToNot(BeZero())
+			Expect(settings.StaleHours).To(Equal(4))
+			Expect(settings.CulledHours).To(Equal(96))
+			var count int64
+			Expect(db.DB.Model(&models.DeviceStalenessSettings{}).Where("org_id = ?", orgID).Count(&count).Error).ToNot(HaveOccurred())
+			Expect(count).To(Equal(int64(1)))
+		})
+
+		It("should not set invalid settings", func() {
+			_, err := service.SetStalenessSettings(orgID, &models.DeviceStalenessSettings{StaleHours: 48, CulledHours: 24})
+			Expect(err).To(MatchError(models.DeviceStalenessSettingsInvalidErrorMessage))
+		})
+	})
+
+	Context("UpdateDevicesStaleness", func() {
+		var freshDevice, staleDevice, culledDevice models.Device
+		var group models.DeviceGroup
+
+		BeforeEach(func() {
+			_, err := service.SetStalenessSettings(orgID, &models.DeviceStalenessSettings{StaleHours: 2, CulledHours: 48})
+			Expect(err).ToNot(HaveOccurred())
+			freshDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), LastSeen: lastSeen(1)}
+			staleDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), LastSeen: lastSeen(3)}
+			Expect(db.DB.Create(&freshDevice).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Create(&staleDevice).Error).ToNot(HaveOccurred())
+			culledDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), LastSeen: lastSeen(50)}
+			group = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic, Devices: []models.Device{culledDevice}}
+			Expect(db.DB.Create(&group).Error).ToNot(HaveOccurred())
+			culledDevice = group.Devices[0]
+		})
+
+		It("should mark stale then cull the devices not seen following the org settings", func() {
+			Expect(service.UpdateDevicesStaleness()).To(Succeed())
+
+			var fresh, stale models.Device
+			Expect(db.DB.First(&fresh, freshDevice.ID).Error).ToNot(HaveOccurred())
+			Expect(fresh.Staleness).To(Equal(models.DeviceStalenessFresh))
+			Expect(db.DB.First(&stale, staleDevice.ID).Error).ToNot(HaveOccurred())
+			Expect(stale.Staleness).To(Equal(models.DeviceStalenessStale))
+			events := deviceEvents(staleDevice, models.DeviceEventTypeStalenessChanged)
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].From).To(Equal(models.DeviceStalenessFresh))
+			Expect(events[0].To).To(Equal(models.DeviceStalenessStale))
+
+			Expect(db.DB.First(&models.Device{}, culledDevice.ID).Error).To(HaveOccurred())
+			var culled models.Device
+			Expect(db.DB.Unscoped().First(&culled, culledDevice.ID).Error).ToNot(HaveOccurred())
+			Expect(culled.Staleness).To(Equal(models.DeviceStalenessCulled))
+			Expect(culled.CulledDeviceGroupsIDs).To(Equal([]uint{group.ID}))
+			Expect(db.DB.Model(&group).Association("Devices").Count()).To(BeZero())
+			Expect(deviceEvents(culledDevice, models.DeviceEventTypeGroupRemoved)).To(HaveLen(1))
+			events = deviceEvents(culledDevice, models.DeviceEventTypeStalenessChanged)
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].To).To(Equal(models.DeviceStalenessCulled))
+
+			// the stale devices are not marked again
+			Expect(service.UpdateDevicesStaleness()).To(Succeed())
+			Expect(deviceEvents(staleDevice, models.DeviceEventTypeStalenessChanged)).To(HaveLen(1))
+		})
+
+		It("should restore the devices seen again", func() {
+			Expect(service.UpdateDevicesStaleness()).To(Succeed())
+			deviceService := services.NewDeviceService(context.Background(), log.NewEntry(log.StandardLogger()))
+			commit := models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&commit).Error).ToNot(HaveOccurred())
+			image := models.Image{OrgID: orgID, CommitID: commit.ID, Status: models.ImageStatusSuccess}
+			Expect(db.DB.Create(&image).Error).ToNot(HaveOccurred())
+
+			for _, device := range []models.Device{staleDevice, culledDevice} {
+				event := new(services.PlatformInsightsCreateUpdateEventPayload)
+				event.Type = services.InventoryEventTypeUpdated
+				event.Host.ID = device.UUID
+				event.Host.OrgID = orgID
+				event.Host.Name = faker.UUIDHyphenated()
+				event.Host.Updated = lastSeen(0)
+				event.Host.SystemProfile.HostType = services.InventoryHostTypeEdge
+				event.Host.SystemProfile.RpmOSTreeDeployments = []services.RpmOSTreeDeployment{{Booted: true, Checksum: commit.OSTreeCommit}}
+				message, err := json.Marshal(event)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deviceService.ProcessPlatformInventoryUpdatedEvent(message)).To(Succeed())
+
+				var savedDevice models.Device
+				Expect(db.DB.First(&savedDevice, device.ID).Error).ToNot(HaveOccurred())
+				Expect(savedDevice.Staleness).To(Equal(models.DeviceStalenessFresh))
+				events := deviceEvents(device, models.DeviceEventTypeStalenessChanged)
+				Expect(events).To(HaveLen(2))
+				Expect(events[1].To).To(Equal(models.DeviceStalenessFresh))
+			}
+
+			var restored models.Device
+			Expect(db.DB.Preload("DevicesGroups").First(&restored, culledDevice.ID).Error).ToNot(HaveOccurred())
+			Expect(restored.CulledDeviceGroupsIDs).To(BeEmpty())
+			Expect(restored.DevicesGroups).To(HaveLen(1))
+			Expect(restored.DevicesGroups[0].ID).To(Equal(group.ID))
+			Expect(deviceEvents(culledDevice, models.DeviceEventTypeGroupAdded)).To(HaveLen(1))
+
+			// the restored devices are seen within the thresholds
+			Expect(service.UpdateDevicesStaleness()).To(Succeed())
+			Expect(db.DB.First(&restored, culledDevice.ID).Error).ToNot(HaveOccurred())
+			Expect(restored.Staleness).To(Equal(models.DeviceStalenessFresh))
+		})
+	})
+
+	Context("ExcludeStaleDevices", func() {
+		It("should exclude the stale devices", func() {
+			devices := []models.Device{
+				{OrgID: orgID, UUID: faker.UUIDHyphenated()},
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), Staleness: models.DeviceStalenessStale},
+			}
+			Expect(db.DB.Create(&devices).Error).ToNot(HaveOccurred())
+
+			devicesUUID, err := services.ExcludeStaleDevices(orgID, []string{devices[0].UUID, devices[1].UUID})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(devicesUUID).To(Equal([]string{devices[0].UUID}))
+		})
+	})
+})
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventory"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/jobs"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DynamicDeviceGroupsServiceInterface defines the interface that helps keeping the dynamic device groups membership current
+type DynamicDeviceGroupsServiceInterface interface {
+	EvaluateDeviceGroup(orgID string, deviceGroupID uint) (*DeviceGroupEvaluation, error)
+	EvaluateDynamicDeviceGroups() error
+}
+
+// NewDynamicDeviceGroupsService gives an instance of the main implementation of DynamicDeviceGroupsServiceInterface
+func NewDynamicDeviceGroupsService(ctx context.Context, log log.FieldLogger) DynamicDeviceGroupsServiceInterface {
+	return &DynamicDeviceGroupsService{
+		Service:                Service{ctx: ctx, log: log.WithField("service", "dynamic-device-groups")},
+		InventoryClientFactory: inventory.InitClient,
+	}
+}
+
+// DynamicDeviceGroupsService is the main implementation of a DynamicDeviceGroupsServiceInterface
+type DynamicDeviceGroupsService struct {
+	Service
+	// InventoryClientFactory gives the inventory client querying the devices tags on behalf of the group rule principal
+	InventoryClientFactory func(ctx context.Context, log log.FieldLogger) inventory.ClientInterface
+}
+
+// DeviceGroupEvaluation is the outcome of the evaluation of a dynamic device group rule
+type DeviceGroupEvaluation struct {
+	Added   int
+	Removed int
+}
+
+// DynamicDeviceGroupsJob is the job evaluating the rules of all the dynamic device groups
+type DynamicDeviceGroupsJob struct{}
+
+// DynamicDeviceGroupsJobHandler evaluates the rules of all the dynamic device groups
+func DynamicDeviceGroupsJobHandler(ctx context.Context, _ *jobs.Job) {
+	s := NewDynamicDeviceGroupsService(ctx, log.StandardLogger().WithContext(ctx))
+	if err := s.EvaluateDynamicDeviceGroups(); err != nil {
+		log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when evaluating dynamic device groups")
+	}
+}
+
+func init() {
+	jobs.RegisterHandlers("DynamicDeviceGroupsJob", DynamicDeviceGroupsJobHandler, jobs.IgnoredJobHandler)
+}
+
+// ScheduleDynamicDeviceGroupsJob evaluates periodically the rules of all the dynamic device groups until the context is done
+func ScheduleDynamicDeviceGroupsJob(ctx context.Context) {
+	interval := config.Get().DynamicGroupsInterval
+	if interval <= 0 {
+		log.WithContext(ctx).Info("dynamic device groups job is disabled")
+		return
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if feature.JobQueue.IsEnabledCtx(ctx) {
+			if err := jobs.NewAndEnqueue(ctx, "DynamicDeviceGroupsJob", &DynamicDeviceGroupsJob{}); err != nil {
+				log.WithContext(ctx).WithField("error", err.Error()).Error("Failed enqueueing job")
+			}
+		} else {
+			DynamicDeviceGroupsJobHandler(ctx, nil)
+		}
+	}
+}
+
+// EvaluateDynamicDeviceGroups evaluates the rules of the dynamic device groups of all the orgs
+func (s *DynamicDeviceGroupsService) EvaluateDynamicDeviceGroups() error {
+	var groups []models.DeviceGroup
+	if result := db.DB.Select("id", "org_id").Where("type = ?", models.DeviceGroupTypeDynamic).
+		Order("id").Find(&groups); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error getting dynamic device groups")
+		return result.Error
+	}
+	for _, group := range groups {
+		if _, err := s.EvaluateDeviceGroup(group.OrgID, group.ID); err != nil {
+			s.log.WithFields(log.Fields{"error": err.Error(), "org_id": group.OrgID, "device_group_id": group.ID}).
+				Error("error evaluating dynamic device group")
+		}
+	}
+	return nil
+}
+
+// EvaluateDeviceGroup sets the members of a dynamic device group to the devices matching its rule
+func (s *DynamicDeviceGroupsService) EvaluateDeviceGroup(orgID string, deviceGroupID uint) (*DeviceGroupEvaluation, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "device_group_id": deviceGroupID})
+	var group models.DeviceGroup
+	if result := db.Org(orgID, "").Preload("Devices").First(&group, deviceGroupID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(DeviceGroupNotFound)
+		}
+		return nil, result.Error
+	}
+	if group.Type != models.DeviceGroupTypeDynamic {
+		return nil, new(DeviceGroupNotDynamic)
+	}
+	rule, err := models.ParseDeviceGroupRule(group.Rule)
+	if err != nil {
+		return nil, err
+	}
+	matching, err := s.findMatchingDevices(&group, rule)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("error finding the devices matching the group rule")
+		return nil, err
+	}
+
+	members := make(map[uint]bool, len(group.Devices))
+	for _, device := range group.Devices {
+		members[device.ID] = true
+	}
+	matchingIDs := make(map[uint]bool, len(matching))
+	var devicesToAdd, devicesToRemove []models.Device
+	for _, device := range matching {
+		matchingIDs[device.ID] = true
+		if !members[device.ID] {
+			devicesToAdd = append(devicesToAdd, device)
+		}
+	}
+	for _, device := range group.Devices {
+		if !matchingIDs[device.ID] {
+			devicesToRemove = append(devicesToRemove, device)
+		}
+	}
+
+	events := make([]models.DeviceEvent, 0, len(devicesToAdd)+len(devicesToRemove))
+	if len(devicesToAdd) > 0 {
+		if err := db.DB.Model(&group).Omit("Devices.*").Association("Devices").Append(devicesToAdd); err != nil {
+			return nil, err
+		}
+		for _, device := range devicesToAdd {
+			events = append(events, models.DeviceEvent{OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupAdded, To: group.Name})
+		}
+	}
+	if len(devicesToRemove) > 0 {
+		if err := db.DB.Model(&group).Association("Devices").Delete(devicesToRemove); err != nil {
+			return nil, err
+		}
+		for _, device := range devicesToRemove {
+			events = append(events, models.DeviceEvent{OrgID: orgID, DeviceID: device.ID, Type: models.DeviceEventTypeGroupRemoved, From: group.Name})
+		}
+	}
+	createDeviceEvents(s.log, events...)
+
+	evaluation := &DeviceGroupEvaluation{Added: len(devicesToAdd), Removed: len(devicesToRemove)}
+	if len(events) > 0 {
+		logger.WithFields(log.Fields{"added": evaluation.Added, "removed": evaluation.Removed}).Info("dynamic device group membership changed")
+	}
+	return evaluation, nil
+}
+
+// ruleNamePattern turns a rule name pattern into a LIKE pattern, only * is a wildcard
+var ruleNamePattern = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "*", "%")
+
+// findMatchingDevices returns the org devices matching all the rule conditions, the tag conditions are matched against
+// the inventory and the other conditions against the edge devices
+func (s *DynamicDeviceGroupsService) findMatchingDevices(group *models.DeviceGroup, rule *models.DeviceGroupRule) ([]models.Device, error) {
+	query := db.Org(group.OrgID, "devices").Model(&models.Device{}).
+		Joins("LEFT JOIN images ON images.id = devices.image_id")
+	for _, condition := range rule.Conditions {
+		switch condition.Attribute {
+		case models.DeviceGroupRuleAttributeImageSet:
+			query = query.Where(fmt.Sprintf("images.image_set_id %s ?", condition.Operator), condition.IntValue())
+		case models.DeviceGroupRuleAttributeImageVersion:
+			query = query.Where(fmt.Sprintf("images.version %s ?", condition.Operator), condition.IntValue())
+		case models.DeviceGroupRuleAttributeName:
+			if condition.Operator == "~" {
+				query = query.Where(`devices.name LIKE ? ESCAPE '\'`, ruleNamePattern.Replace(condition.Value))
+			} else {
+				query = query.Where(fmt.Sprintf("devices.name %s ?", condition.Operator), condition.Value)
+			}
+		case models.DeviceGroupRuleAttributeConnected:
+			query = query.Where("devices.connected = ?", condition.BoolValue())
+		case models.DeviceGroupRuleAttributeUpdateAvailable:
+			query = query.Where("devices.update_available = ?", condition.BoolValue())
+		case models.DeviceGroupRuleAttributeLastSeenHours:
+			// the more hours since last seen, the older the last seen time
+			operators := map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<="}
+			lastSeen := time.Now().Add(-time.Duration(condition.IntValue()) * time.Hour)
+			query = query.Where(fmt.Sprintf("devices.last_seen %s ?", operators[condition.Operator]), lastSeen)
+		case models.DeviceGroupRuleAttributeTag:
+			var err error
+			query, err = filterTaggedDevices(query, []string{condition.Value}, func() (inventory.ClientInterface, error) {
+				return s.principalInventoryClient(group)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	var devices []models.Device
+	if result := query.Select("devices.*").Find(&devices); result.Error != nil {
+		return nil, result.Error
+	}
+	return devices, nil
+}
+
+// principalInventoryClient returns the inventory client acting on behalf of the group rule principal
+func (s *DynamicDeviceGroupsService) principalInventoryClient(group *models.DeviceGroup) (inventory.ClientInterface, error) {
+	ctx, err := s.contextWithPrincipal(group.OrgID, group.Principal)
+	if err != nil {
+		return nil, err
+	}
+	return s.InventoryClientFactory(ctx, s.log), nil
+}
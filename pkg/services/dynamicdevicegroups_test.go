@@ -0,0 +1,201 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventory"
+	"github.com/redhatinsights/edge-api/pkg/clients/inventory/mock_inventory"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+var _ = Describe("DynamicDeviceGroupsService", func() {
+	var ctrl *gomock.Controller
+	var mockInventoryClient *mock_inventory.MockClientInterface
+	var service services.DynamicDeviceGroupsServiceInterface
+	var orgID string
+	var imageSet models.ImageSet
+	var storeDevice, disconnectedDevice, depotDevice models.Device
+
+	createGroup := func(rule string) models.DeviceGroup {
+		group := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDynamic, Rule: rule}
+		Expect(db.DB.Create(&group).Error).ToNot(HaveOccurred())
+		return group
+	}
+
+	groupDevicesIDs := func(group models.DeviceGroup) []uint {
+		var devices []models.Device
+		Expect(db.DB.Model(&group).Association("Devices").Find(&devices)).To(Succeed())
+		ids := make([]uint, 0, len(devices))
+		for _, device := range devices {
+			ids = append(ids, device.ID)
+		}
+		return ids
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockInventoryClient = mock_inventory.NewMockClientInterface(ctrl)
+		service = &services.DynamicDeviceGroupsService{
+			Service: services.NewService(context.Background(), log.WithField("service", "dynamic-device-groups")),
+			InventoryClientFactory: func(_ context.Context, _ log.FieldLogger) inventory.ClientInterface {
+				return mockInventoryClient
+			},
+		}
+
+		orgID = faker.UUIDHyphenated()
+		imageSet = models.ImageSet{OrgID: orgID, Name: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+		images := make([]models.Image, 2)
+		for i := range images {
+			images[i] = models.Image{OrgID: orgID, Name: imageSet.Name, ImageSetID: &imageSet.ID, Version: i + 1, Status: models.ImageStatusSuccess}
+			Expect(db.DB.Create(&images[i]).Error).ToNot(HaveOccurred())
+		}
+		now := models.EdgeAPITime{Time: time.Now(), Valid: true}
+		storeDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: "store-1", ImageID: images[0].ID, Connected: true, LastSeen: now, UpdateAvailable: true}
+		disconnectedDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: "store-2", ImageID: images[1].ID,
+			LastSeen: models.EdgeAPITime{Time: time.Now().Add(-48 * time.Hour), Valid: true}}
+		depotDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: "depot-1", ImageID: images[0].ID, Connected: true, LastSeen: now}
+		for _, device := range []*models.Device{&storeDevice, &disconnectedDevice, &depotDevice} {
+			Expect(db.DB.Create(device).Error).ToNot(HaveOccurred())
+		}
+		// gorm does not save the false value of a field with a default
+		Expect(db.DB.Model(&disconnectedDevice).Update("connected", false).Error).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("EvaluateDeviceGroup", func() {
+		It("should add the devices matching the rule", func() {
+			group := createGroup(fmt.Sprintf(`image_set = %d and image_version < 2 and name ~ "store-*"`, imageSet.ID))
+
+			evaluation, err := service.EvaluateDeviceGroup(orgID, group.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(evaluation.Added).To(Equal(1))
+			Expect(evaluation.Removed).To(Equal(0))
+			Expect(groupDevicesIDs(group)).To(Equal([]uint{storeDevice.ID}))
+
+			var events []models.DeviceEvent
+			Expect(db.DB.Where("device_id = ? AND type = ?", storeDevice.ID, models.DeviceEventTypeGroupAdded).Find(&events).Error).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].To).To(Equal(group.Name))
+		})
+
+		It("should remove the devices no longer matching the rule", func() {
+			group := createGroup("connected = true and update_available = true")
+			_, err := service.EvaluateDeviceGroup(orgID, group.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(groupDevicesIDs(group)).To(Equal([]uint{storeDevice.ID}))
+
+			Expect(db.DB.Model(&group).Update("rule", "last_seen_hours > 24").Error).ToNot(HaveOccurred())
+			evaluation, err := service.EvaluateDeviceGroup(orgID, group.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(evaluation.Added).To(Equal(1))
+			Expect(evaluation.Removed).To(Equal(1))
+			Expect(groupDevicesIDs(group)).To(Equal([]uint{disconnectedDevice.ID}))
+		})
+
+		It("should match the devices persisted tags", func() {
+			Expect(db.DB.Model(&depotDevice).Update("tags", `["site/city=paris"]`).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Model(&disconnectedDevice).Update("tags", `["site/city=paris"]`).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Model(&storeDevice).Update("tags", `["site/city=paris-2"]`).Error).ToNot(HaveOccurred())
+			group := createGroup(`tag = "site/city=paris" and connected = true`)
+
+			_, err := service.EvaluateDeviceGroup(orgID, group.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(groupDevicesIDs(group)).To(Equal([]uint{depotDevice.ID}))
+		})
+
+		It("should match the devices tags in the inventory on behalf of the rule principal when the tags are not persisted", func() {
+			initialProfileFields := config.Get().InventoryProfileFields
+			defer func() {
+				config.Get().InventoryProfileFields = initialProfileFields
+			}()
+			config.Get().InventoryProfileFields = []string{models.DeviceSystemProfileFieldArch}
+			group := createGroup(`tag = "site/city=paris" and connected = true`)
+			Expect(db.DB.Model(&group).Update("principal", "admin").Error).ToNot(HaveOccurred())
+			service = &services.DynamicDeviceGroupsService{
+				Service: services.NewService(context.Background(), log.WithField("service", "dynamic-device-groups")),
+				InventoryClientFactory: func(ctx context.Context, _ log.FieldLogger) inventory.ClientInterface {
+					Expect(identity.GetIdentity(ctx).Identity.OrgID).To(Equal(orgID))
+					Expect(identity.GetIdentity(ctx).Identity.ServiceAccount.Username).To(Equal("admin"))
+					return mockInventoryClient
+				},
+			}
+			mockInventoryClient.EXPECT().ReturnDevicesByTag(url.QueryEscape("site/city=paris")).Return(inventory.Response{
+				Total: 2, Count: 2, Result: []inventory.Device{{ID: depotDevice.UUID}, {ID: disconnectedDevice.UUID}},
+			}, nil)
+
+			_, err := service.EvaluateDeviceGroup(orgID, group.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(groupDevicesIDs(group)).To(Equal([]uint{depotDevice.ID}))
+		})
+
+		It("should not query the inventory tags when the rule principal is unknown", func() {
+			initialProfileFields := config.Get().InventoryProfileFields
+			defer func() {
+				config.Get().InventoryProfileFields = initialProfileFields
+			}()
+			config.Get().InventoryProfileFields = []string{models.DeviceSystemProfileFieldArch}
+			group := createGroup(`tag = "site/city=paris"`)
+
+			_, err := service.EvaluateDeviceGroup(orgID, group.ID)
+			Expect(err).To(MatchError(new(services.PrincipalUndefined)))
+			Expect(groupDevicesIDs(group)).To(BeEmpty())
+		})
+
+		It("should match only the * wildcard of a name pattern", func() {
+			for _, rule := range []string{`name ~ "store_*"`, `name ~ "%"`, `name ~ "depot\\*"`} {
+				group := createGroup(rule)
+				_, err := service.EvaluateDeviceGroup(orgID, group.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(groupDevicesIDs(group)).To(BeEmpty(), rule)
+			}
+		})
+
+		It("should not evaluate a static group", func() {
+			group := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic}
+			Expect(db.DB.Create(&group).Error).ToNot(HaveOccurred())
+			_, err := service.EvaluateDeviceGroup(orgID, group.ID)
+			Expect(err).To(MatchError(new(services.DeviceGroupNotDynamic)))
+		})
+	})
+
+	Context("DeviceGroupsService", func() {
+		var deviceGroupsService services.DeviceGroupsServiceInterface
+
+		BeforeEach(func() {
+			ctx := identity.WithIdentity(context.Background(), identity.XRHID{Identity: identity.Identity{OrgID: orgID}})
+			deviceGroupsService = services.NewDeviceGroupsService(ctx, log.NewEntry(log.StandardLogger()))
+		})
+
+		It("should set the members of a dynamic group on creation", func() {
+			group, err := deviceGroupsService.CreateDeviceGroup(&models.DeviceGroup{
+				OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeDynamic, Rule: `name = "depot-1"`,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(group.Type).To(Equal(models.DeviceGroupTypeDynamic))
+			Expect(groupDevicesIDs(*group)).To(Equal([]uint{depotDevice.ID}))
+		})
+
+		It("should not add devices to a dynamic group by hand", func() {
+			group := createGroup("connected = true")
+			_, err := deviceGroupsService.AddDeviceGroupDevices(orgID, group.ID, []models.Device{storeDevice})
+			Expect(err).To(MatchError(new(services.DeviceGroupDynamicMembership)))
+		})
+	})
+})
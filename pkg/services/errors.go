@@ -38,6 +38,9 @@ const DeviceGroupDeviceNotSuppliedMsg = "device-group device must be supplied"
 const DeviceGroupAlreadyExistsMsg = "device group already exists"
 const DeviceGroupAccountOrNameUndefinedMsg = "device group account or name are undefined"
 const DeviceGroupMandatoryFieldsUndefinedMsg = "device group mandatory field are undefined"
+const DeviceGroupNotDynamicMsg = "device group is not dynamic"
+const DeviceGroupHierarchyCycleMsg = "device group can not be nested under itself or one of its descendants"
+const DeviceGroupDynamicMembershipMsg = "devices of a dynamic device group are selected by its rule and can not be added or removed"
 const DeviceHasImageUndefinedMsg = "device has image undefined"
 const DeviceHasNoImageUpdateMsg = "device has no image update"
 const DevicesHasMoreThanOneImageSetMsg = "device has more than one image-set"
@@ -54,6 +57,21 @@ const KafkaAllBrokersDownMsg = "Cannot connect to any Kafka brokers"
 const DBCommitErrorMsg = "Error searching for ImageSet of Device Images"
 const KafkaProducerInstanceUndefinedMsg = "kafka producer instance is undefined"
 const ParsingISODateErrorMsg = "error occurred while parsing string for ISO date"
+const UpdateHasNoFailedDevicesMsg = "update has no failed devices to retry"
+const UpdateRepoNotAvailableMsg = "update repository is not available"
+const UpdateHookNotFoundMsg = "update hook was not found"
+const UpdateHooksRequirePlaybookSigningMsg = "update hooks require dynamic playbook signing"
+const UpdateNotAwaitingApprovalMsg = "update is not awaiting approval"
+const UpdateSelfApprovalMsg = "update can not be approved by the principal that created it"
+const UpdateReviewerUndefinedMsg = "update reviewer principal is undefined"
+const UpdateRequesterUndefinedMsg = "update requester principal is unknown, the update can not be approved"
+const UpdateNotApprovedMsg = "update is not approved"
+const UpdatePolicyNotFoundMsg = "device group update policy was not found"
+const DesiredStateNotFoundMsg = "device group desired state was not found"
+const DeviceActionNotFoundMsg = "device action was not found"
+const DeviceActionPlaybookNotFoundMsg = "device action playbook was not found"
+const DeviceActionHasNoDevicesMsg = "device action has no devices to run on"
+const PrincipalUndefinedMsg = "the principal acting on behalf of the org is unknown"
 
 // DeviceNotFoundError indicates the device was not found
 type DeviceNotFoundError struct{}
@@ -309,6 +327,27 @@ func (e *DeviceGroupMandatoryFieldsUndefined) Error() string {
 	return DeviceGroupMandatoryFieldsUndefinedMsg
 }
 
+// DeviceGroupNotDynamic indicates that the device group is not dynamic and has no rule to evaluate
+type DeviceGroupNotDynamic struct{}
+
+func (e *DeviceGroupNotDynamic) Error() string {
+	return DeviceGroupNotDynamicMsg
+}
+
+// DeviceGroupHierarchyCycle indicates that the parent of a device group is the group itself or one of its descendants
+type DeviceGroupHierarchyCycle struct{}
+
+func (e *DeviceGroupHierarchyCycle) Error() string {
+	return DeviceGroupHierarchyCycleMsg
+}
+
+// DeviceGroupDynamicMembership indicates that devices were added to or removed from a dynamic device group by hand
+type DeviceGroupDynamicMembership struct{}
+
+func (e *DeviceGroupDynamicMembership) Error() string {
+	return DeviceGroupDynamicMembershipMsg
+}
+
 // DeviceHasImageUndefined indicates that device record has image not defined
 type DeviceHasImageUndefined struct{}
 
@@ -419,3 +458,108 @@ type ParsingISODateError struct{}
 func (e *ParsingISODateError) Error() string {
 	return ParsingISODateErrorMsg
 }
+
+// UpdateHasNoFailedDevices occurs when retrying an update that has no failed dispatch records
+type UpdateHasNoFailedDevices struct{}
+
+func (e *UpdateHasNoFailedDevices) Error() string {
+	return UpdateHasNoFailedDevicesMsg
+}
+
+// UpdateRepoNotAvailable occurs when retrying an update that has no successfully built repository
+type UpdateRepoNotAvailable struct{}
+
+func (e *UpdateRepoNotAvailable) Error() string {
+	return UpdateRepoNotAvailableMsg
+}
+
+// UpdateHookNotFound indicates the update hook was not found
+type UpdateHookNotFound struct{}
+
+func (e *UpdateHookNotFound) Error() string {
+	return UpdateHookNotFoundMsg
+}
+
+// UpdateHooksRequirePlaybookSigning occurs when an update with hooks is rendered while dynamic playbook signing is disabled
+type UpdateHooksRequirePlaybookSigning struct{}
+
+func (e *UpdateHooksRequirePlaybookSigning) Error() string {
+	return UpdateHooksRequirePlaybookSigningMsg
+}
+
+// UpdateNotAwaitingApproval occurs when approving or rejecting an update that is not awaiting approval
+type UpdateNotAwaitingApproval struct{}
+
+func (e *UpdateNotAwaitingApproval) Error() string {
+	return UpdateNotAwaitingApprovalMsg
+}
+
+// UpdateSelfApproval occurs when the principal that created an update tries to approve it
+type UpdateSelfApproval struct{}
+
+func (e *UpdateSelfApproval) Error() string {
+	return UpdateSelfApprovalMsg
+}
+
+// UpdateReviewerUndefined occurs when approving or rejecting an update without an identity principal
+type UpdateReviewerUndefined struct{}
+
+func (e *UpdateReviewerUndefined) Error() string {
+	return UpdateReviewerUndefinedMsg
+}
+
+// UpdateRequesterUndefined occurs when approving an update created by an unknown principal
+type UpdateRequesterUndefined struct{}
+
+func (e *UpdateRequesterUndefined) Error() string {
+	return UpdateRequesterUndefinedMsg
+}
+
+// UpdateNotApproved occurs when building an update that is awaiting approval or was rejected
+type UpdateNotApproved struct{}
+
+func (e *UpdateNotApproved) Error() string {
+	return UpdateNotApprovedMsg
+}
+
+// UpdatePolicyNotFound occurs when the device group has no update policy
+type UpdatePolicyNotFound struct{}
+
+func (e *UpdatePolicyNotFound) Error() string {
+	return UpdatePolicyNotFoundMsg
+}
+
+// DesiredStateNotFound occurs when the device group has no desired state
+type DesiredStateNotFound struct{}
+
+func (e *DesiredStateNotFound) Error() string {
+	return DesiredStateNotFoundMsg
+}
+
+// DeviceActionNotFound indicates the device action was not found
+type DeviceActionNotFound struct{}
+
+func (e *DeviceActionNotFound) Error() string {
+	return DeviceActionNotFoundMsg
+}
+
+// DeviceActionPlaybookNotFound indicates the device action playbook was not found
+type DeviceActionPlaybookNotFound struct{}
+
+func (e *DeviceActionPlaybookNotFound) Error() string {
+	return DeviceActionPlaybookNotFoundMsg
+}
+
+// DeviceActionHasNoDevices occurs when a device action is sent to a device group without devices
+type DeviceActionHasNoDevices struct{}
+
+func (e *DeviceActionHasNoDevices) Error() string {
+	return DeviceActionHasNoDevicesMsg
+}
+
+// PrincipalUndefined occurs when the background processing has no principal to act on behalf of
+type PrincipalUndefined struct{}
+
+func (e *PrincipalUndefined) Error() string {
+	return PrincipalUndefinedMsg
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/redhatinsights/edge-api/config"
@@ -44,6 +45,7 @@ func (s *BasicFileService) GetDownloader() files.Downloader {
 type FilesService interface {
 	GetFile(path string) (io.ReadCloser, error)
 	GetSignedURL(path string) (string, error)
+	DeleteFolder(path string) error
 	GetExtractor() files.Extractor
 	GetUploader() files.Uploader
 	GetDownloader() files.Downloader
@@ -92,6 +94,11 @@ func (s *LocalFilesService) GetSignedURL(path string) (string, error) {
 	return path, nil
 }
 
+// DeleteFolder deletes a local folder and its content
+func (s *LocalFilesService) DeleteFolder(path string) error {
+	return os.RemoveAll(filepath.Clean("/tmp/" + path))
+}
+
 // NewS3FilesServices return a new FilesService with s3 client
 func NewS3FilesServices(client files.S3ClientInterface, basicFileService BasicFileService) FilesService {
 	return &S3FilesService{
@@ -129,3 +136,8 @@ func (s *S3FilesService) GetSignedURL(path string) (string, error) {
 	cfg := config.Get()
 	return s.Client.GetSignedURL(cfg.BucketName, path, defaultURLSignatureExpiry*time.Minute)
 }
+
+// DeleteFolder deletes all the files of an aws s3 bucket folder
+func (s *S3FilesService) DeleteFolder(path string) error {
+	return s.Client.DeleteFolder(s.Bucket, strings.TrimPrefix(path, "/"))
+}
@@ -50,6 +50,7 @@ type S3ClientInterface interface {
 	GetObject(bucket string, key string) (output *s3.GetObjectOutput, err error)
 	PutObject(file io.ReadSeeker, bucket string, key string, acl string) (*s3.PutObjectOutput, error)
 	DeleteObject(bucket string, key string) (*s3.DeleteObjectOutput, error)
+	DeleteFolder(bucket string, folderKey string) error
 	Download(file io.WriterAt, bucket string, key string) (n int64, err error)
 	Upload(file io.Reader, bucket string, key string, acl string) (*s3manager.UploadOutput, error)
 	GetSignedURL(bucket string, key string, expire time.Duration) (string, error)
@@ -90,6 +91,11 @@ func (s3Client *S3Client) DeleteObject(bucket string, key string) (*s3.DeleteObj
 	})
 }
 
+// DeleteFolder deletes all the objects of a folder in S3 bucket
+func (s3Client *S3Client) DeleteFolder(bucket string, folderKey string) error {
+	return s3Client.FolderDeleter.Delete(bucket, folderKey)
+}
+
 // Download downloads an object in S3 and writes the payload into file
 func (s3Client *S3Client) Download(file io.WriterAt, bucket string, key string) (int64, error) {
 	return s3Client.Downloader.Download(
@@ -117,6 +117,23 @@ var _ = Describe("File Service Test", func() {
 				ctrl.Finish()
 			})
 
+			Context("DeleteFolder", func() {
+				It("should delete the folder without the leading separator", func() {
+					s3Client.EXPECT().DeleteFolder(cfg.BucketName, "v2/org/static-deltas/1/repo").Return(nil)
+
+					err := s3FilesService.DeleteFolder("/v2/org/static-deltas/1/repo")
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("should return error when client fail", func() {
+					expectedError := errors.New("s3 DeleteFolder error")
+					s3Client.EXPECT().DeleteFolder(cfg.BucketName, "v2/org/static-deltas/1/repo").Return(expectedError)
+
+					err := s3FilesService.DeleteFolder("v2/org/static-deltas/1/repo")
+					Expect(err).To(MatchError(expectedError))
+				})
+			})
+
 			Context("GetSignedURL", func() {
 				It("should GetSignedURL successfully", func() {
 					resourcePath := faker.URL()
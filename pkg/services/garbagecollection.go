@@ -0,0 +1,494 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/jobs"
+	"github.com/redhatinsights/edge-api/pkg/metrics"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services/repostore"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// values of the garbage collection metrics labels
+const (
+	gcTypeStaticDelta = "static_delta"
+	gcTypeUpdateRepo  = "update_repo"
+	gcResultDeleted   = "deleted"
+	gcResultDryRun    = "dry_run"
+	gcResultFailed    = "failed"
+)
+
+// GarbageCollectionServiceInterface defines the interface that helps collecting the static deltas and update repos no longer referenced
+type GarbageCollectionServiceInterface interface {
+	CollectGarbage(orgID string, dryRun bool) (*GarbageCollectionResult, error)
+}
+
+// NewGarbageCollectionService gives an instance of the main implementation of GarbageCollectionServiceInterface
+func NewGarbageCollectionService(ctx context.Context, log log.FieldLogger) GarbageCollectionServiceInterface {
+	return &GarbageCollectionService{
+		Service:         Service{ctx: ctx, log: log.WithField("service", "garbage-collection")},
+		FilesService:    NewFilesService(log),
+		PulpRepoDeleter: repostore.PulpRepoDelete,
+	}
+}
+
+// GarbageCollectionService is the main implementation of a GarbageCollectionServiceInterface
+type GarbageCollectionService struct {
+	Service
+	FilesService    FilesService
+	PulpRepoDeleter func(ctx context.Context, orgID string, pulpID string) error
+}
+
+// GarbageCollectionResult is the outcome of a garbage collection, in dry-run mode it counts what would have been collected
+type GarbageCollectionResult struct {
+	DryRun            bool
+	StaticDeltasCount int
+	UpdateReposCount  int
+	Bytes             int64
+	FailedCount       int
+}
+
+// gcReferences holds what references the static deltas and the update repos, the keys are prefixed with the org id
+type gcReferences struct {
+	latestCommits    map[string]bool // ostree commits of the latest image-set versions
+	latestCommitIDs  map[string]bool // ids of the commits of the latest image-set versions
+	runningCommits   map[string]bool // ostree commits run by the devices
+	recentDeltaNames map[string]bool // static delta names used by recent or in progress update transactions
+}
+
+func gcKey(orgID string, value interface{}) string {
+	return fmt.Sprintf("%s/%v", orgID, value)
+}
+
+// gcOrg returns the db scope of an org, or of all the orgs when orgID is empty
+func gcOrg(orgID string, table string) *gorm.DB {
+	if orgID == "" {
+		return db.DB
+	}
+	return db.Org(orgID, table)
+}
+
+// GarbageCollectionLockKey is the db advisory lock key of the garbage collection job
+const GarbageCollectionLockKey int64 = 4501
+
+// GarbageCollectionJob is the job collecting the static deltas and update repos no longer referenced
+type GarbageCollectionJob struct {
+	DryRun bool
+}
+
+// GarbageCollectionJobHandler collects the static deltas and update repos no longer referenced
+func GarbageCollectionJobHandler(ctx context.Context, job *jobs.Job) {
+	if !feature.GarbageCollection.IsEnabled() {
+		log.WithContext(ctx).Warning("garbage collection feature flag is disabled")
+		return
+	}
+	dryRun := config.Get().GCDryRun
+	if job != nil {
+		dryRun = job.Args.(*GarbageCollectionJob).DryRun
+	}
+	s := NewGarbageCollectionService(ctx, log.StandardLogger().WithContext(ctx))
+	// every replica schedules the job, only the one holding the lock collects the garbage
+	locked, err := db.WithAdvisoryLock(ctx, GarbageCollectionLockKey, func() {
+		if _, err := s.CollectGarbage("", dryRun); err != nil {
+			log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when collecting garbage")
+		}
+	})
+	if err != nil {
+		log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when locking the garbage collection")
+	} else if !locked {
+		log.WithContext(ctx).Info("garbage collection is already running on another replica")
+	}
+}
+
+func init() {
+	jobs.RegisterHandlers("GarbageCollectionJob", GarbageCollectionJobHandler, jobs.IgnoredJobHandler)
+}
+
+// ScheduleGarbageCollectionJob collects periodically the static deltas and update repos no longer referenced until the context is done
+func ScheduleGarbageCollectionJob(ctx context.Context) {
+	interval := config.Get().GCInterval
+	if interval <= 0 {
+		log.WithContext(ctx).Info("garbage collection job is disabled")
+		return
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if feature.JobQueue.IsEnabledCtx(ctx) {
+			if err := jobs.NewAndEnqueue(ctx, "GarbageCollectionJob", &GarbageCollectionJob{DryRun: config.Get().GCDryRun}); err != nil {
+				log.WithContext(ctx).WithField("error", err.Error()).Error("Failed enqueueing job")
+			}
+		} else {
+			GarbageCollectionJobHandler(ctx, nil)
+		}
+	}
+}
+
+// CollectGarbage deletes the static deltas and the update repos storage no longer referenced. A static delta is referenced
+// when it updates to one of the latest versions of an image-set, when it updates from a commit still running on devices
+// or when a recent update transaction uses it. An update repo is referenced when one of the update transactions using it,
+// such as the retries of an update, is recent or still in progress, or updates to one of the latest versions.
+// In dry-run mode nothing is deleted.
+// An empty orgID collects the garbage of all the orgs.
+func (s *GarbageCollectionService) CollectGarbage(orgID string, dryRun bool) (*GarbageCollectionResult, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "dry_run": dryRun})
+	result := &GarbageCollectionResult{DryRun: dryRun}
+	since := time.Now().AddDate(0, 0, -config.Get().GCKeepDays)
+	refs, err := s.getReferences(orgID, since)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.collectStaticDeltas(orgID, refs, result); err != nil {
+		return nil, err
+	}
+	if err := s.collectUpdateRepos(orgID, refs, since, result); err != nil {
+		return nil, err
+	}
+	logger.WithFields(log.Fields{
+		"static_deltas_count": result.StaticDeltasCount,
+		"update_repos_count":  result.UpdateReposCount,
+		"bytes":               result.Bytes,
+		"failed_count":        result.FailedCount,
+	}).Info("garbage collection finished")
+
+	return result, nil
+}
+
+// getReferences collects what references the static deltas and the update repos
+func (s *GarbageCollectionService) getReferences(orgID string, since time.Time) (*gcReferences, error) {
+	refs := &gcReferences{
+		latestCommits:    map[string]bool{},
+		latestCommitIDs:  map[string]bool{},
+		runningCommits:   map[string]bool{},
+		recentDeltaNames: map[string]bool{},
+	}
+
+	var latestCommits []struct {
+		OrgID        string
+		CommitID     uint
+		OSTreeCommit string
+	}
+	if err := db.DB.Raw(`SELECT org_id, commit_id, os_tree_commit FROM (
+		SELECT images.org_id, images.commit_id, commits.os_tree_commit,
+			ROW_NUMBER() OVER (PARTITION BY images.image_set_id ORDER BY images.version DESC, images.id DESC) AS version_rank
+		FROM images JOIN commits ON commits.id = images.commit_id
+		WHERE images.status = ? AND images.deleted_at IS NULL AND (? = '' OR images.org_id = ?)) latest_images
+		WHERE version_rank <= ?`, models.ImageStatusSuccess, orgID, orgID, config.Get().GCKeepVersions).
+		Scan(&latestCommits).Error; err != nil {
+		s.log.WithField("error", err.Error()).Error("error occurred while getting the latest versions commits")
+		return nil, err
+	}
+	for _, commit := range latestCommits {
+		refs.latestCommits[gcKey(commit.OrgID, commit.OSTreeCommit)] = true
+		refs.latestCommitIDs[gcKey(commit.OrgID, commit.CommitID)] = true
+	}
+
+	var runningCommits []struct {
+		OrgID        string
+		OSTreeCommit string
+	}
+	if err := gcOrg(orgID, "devices").Table("devices").Distinct("devices.org_id", "commits.os_tree_commit").
+		Joins("JOIN images ON images.id = devices.image_id").
+		Joins("JOIN commits ON commits.id = images.commit_id").
+		Where("devices.deleted_at IS NULL").
+		Scan(&runningCommits).Error; err != nil {
+		s.log.WithField("error", err.Error()).Error("error occurred while getting the devices commits")
+		return nil, err
+	}
+	for _, commit := range runningCommits {
+		refs.runningCommits[gcKey(commit.OrgID, commit.OSTreeCommit)] = true
+	}
+
+	var recentDeltas []struct {
+		OrgID      string
+		FromCommit string
+		ToCommit   string
+	}
+	if err := gcOrg(orgID, "update_transactions").Table("update_transactions").
+		Select("update_transactions.org_id, old_commits.os_tree_commit AS from_commit, commits.os_tree_commit AS to_commit").
+		Joins("JOIN commits ON commits.id = update_transactions.commit_id").
+		Joins("JOIN updatetransaction_commits ON updatetransaction_commits.update_transaction_id = update_transactions.id").
+		Joins("JOIN commits old_commits ON old_commits.id = updatetransaction_commits.commit_id").
+		Where("update_transactions.deleted_at IS NULL").
+		Where("update_transactions.created_at >= ? OR update_transactions.status IN ?", since, gcUpdateStatusesInProgress).
+		Scan(&recentDeltas).Error; err != nil {
+		s.log.WithField("error", err.Error()).Error("error occurred while getting the recent updates commits")
+		return nil, err
+	}
+	for _, delta := range recentDeltas {
+		refs.recentDeltaNames[gcKey(delta.OrgID, models.GetStaticDeltaName(delta.FromCommit, delta.ToCommit))] = true
+	}
+
+	return refs, nil
+}
+
+// gcUpdateStatusesInProgress are the status of the update transactions that may still use their repo
+var gcUpdateStatusesInProgress = []string{models.UpdateStatusCreated, models.UpdateStatusBuilding, models.UpdateStatusAwaitingApproval}
+
+// gcStaticDeltaStatusesInProgress are the status of the static deltas being generated
+var gcStaticDeltaStatusesInProgress = []string{
+	models.StaticDeltaStatusDownloading, models.StaticDeltaStatusGenerating, models.StaticDeltaStatusUploading,
+}
+
+// staticDeltaReferences returns how many references a static delta has
+func (refs *gcReferences) staticDeltaReferences(state *models.StaticDeltaState) int {
+	fromCommit, toCommit := state.FromCommit, state.ToCommit
+	if fromCommit == "" || toCommit == "" {
+		// the static deltas generated before the commits were recorded are only known by their name
+		commits := strings.Split(state.Name, "-")
+		if len(commits) != 2 {
+			// do not guess the commits of a name that cannot be split
+			return 1
+		}
+		fromCommit, toCommit = commits[0], commits[1]
+	}
+	count := 0
+	for _, referenced := range []bool{
+		refs.latestCommits[gcKey(state.OrgID, toCommit)],
+		refs.runningCommits[gcKey(state.OrgID, fromCommit)],
+		refs.recentDeltaNames[gcKey(state.OrgID, state.Name)],
+	} {
+		if referenced {
+			count++
+		}
+	}
+	return count
+}
+
+// collectStaticDeltas deletes the static deltas with no reference, a failing static delta does not stop the collection
+func (s *GarbageCollectionService) collectStaticDeltas(orgID string, refs *gcReferences, result *GarbageCollectionResult) error {
+	batchSize := config.Get().CleanupBatchSize
+	var lastID uint
+	for {
+		var states []models.StaticDeltaState
+		if err := gcOrg(orgID, "").Where("id > ? AND status NOT IN ?", lastID, gcStaticDeltaStatusesInProgress).
+			Order("id ASC").Limit(batchSize).Find(&states).Error; err != nil {
+			s.log.WithField("error", err.Error()).Error("error occurred while getting static deltas")
+			return err
+		}
+		if len(states) == 0 {
+			return nil
+		}
+		lastID = states[len(states)-1].ID
+
+		for i := range states {
+			state := &states[i]
+			if refs.staticDeltaReferences(state) > 0 {
+				continue
+			}
+			logger := s.log.WithFields(log.Fields{"org_id": state.OrgID, "static_delta": state.Name, "size": state.Size, "dry_run": result.DryRun})
+			outcome := gcResultDryRun
+			if !result.DryRun {
+				outcome = gcResultDeleted
+				if err := s.deleteStaticDelta(logger, state); err != nil {
+					outcome = gcResultFailed
+				}
+			}
+			metrics.GarbageCollectedCount.WithLabelValues(gcTypeStaticDelta, outcome).Inc()
+			metrics.GarbageCollectedBytes.WithLabelValues(outcome).Add(float64(state.Size))
+			if outcome == gcResultFailed {
+				result.FailedCount++
+				continue
+			}
+			result.StaticDeltasCount++
+			result.Bytes += state.Size
+			logger.Info("static delta garbage collected")
+		}
+	}
+}
+
+func (s *GarbageCollectionService) deleteStaticDelta(logger log.FieldLogger, state *models.StaticDeltaState) error {
+	if state.URL != "" {
+		path, err := urlPath(state.URL)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while parsing static delta url")
+			return err
+		}
+		if err := s.FilesService.DeleteFolder(path); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while deleting static delta folder")
+			return err
+		}
+	}
+	return state.Delete(logger)
+}
+
+// updateRepoCandidate is an update repo with the update transactions using it, the retries of an update reuse its repo
+type updateRepoCandidate struct {
+	RepoID          uint
+	RepoURL         string
+	RepoPulpID      string
+	StaticDeltaRepo bool
+	Updates         []updateRepoUpdate `gorm:"-"`
+}
+
+// updateRepoUpdate is an update transaction using an update repo
+type updateRepoUpdate struct {
+	RepoID            uint
+	UpdateID          uint
+	OrgID             string
+	CommitID          uint
+	Status            string
+	CreatedAt         time.Time
+	CommitRepoURL     string
+	CommitRepoPulpID  string
+	CommitRepoPulpURL string
+}
+
+// collectUpdateRepos deletes the storage of the update repos with no reference, a failing update repo does not stop the collection
+func (s *GarbageCollectionService) collectUpdateRepos(orgID string, refs *gcReferences, since time.Time, result *GarbageCollectionResult) error {
+	batchSize := config.Get().CleanupBatchSize
+	var lastID uint
+	for {
+		var candidates []updateRepoCandidate
+		if err := db.DB.Table("repos").
+			Select(`repos.id AS repo_id, repos.url AS repo_url, repos.pulp_id AS repo_pulp_id, EXISTS (SELECT 1 FROM static_delta_states
+				WHERE static_delta_states.url = repos.url AND static_delta_states.deleted_at IS NULL) AS static_delta_repo`).
+			Where("repos.id > ? AND repos.deleted_at IS NULL AND repos.status <> ?", lastID, models.RepoStatusStorageCleaned).
+			Where("repos.id IN (?)", gcOrg(orgID, "update_transactions").Table("update_transactions").
+				Select("update_transactions.repo_id").Where("update_transactions.deleted_at IS NULL")).
+			Order("repos.id ASC").Limit(batchSize).Scan(&candidates).Error; err != nil {
+			s.log.WithField("error", err.Error()).Error("error occurred while getting update repos")
+			return err
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+		lastID = candidates[len(candidates)-1].RepoID
+
+		reposIDs := make([]uint, 0, len(candidates))
+		for _, candidate := range candidates {
+			reposIDs = append(reposIDs, candidate.RepoID)
+		}
+		var updates []updateRepoUpdate
+		if err := db.DB.Table("update_transactions").
+			Select(`update_transactions.repo_id, update_transactions.id AS update_id, update_transactions.org_id,
+				update_transactions.commit_id, update_transactions.status, update_transactions.created_at,
+				commit_repos.url AS commit_repo_url, commit_repos.pulp_id AS commit_repo_pulp_id, commit_repos.pulp_url AS commit_repo_pulp_url`).
+			Joins("JOIN commits ON commits.id = update_transactions.commit_id").
+			Joins("LEFT JOIN repos commit_repos ON commit_repos.id = commits.repo_id").
+			Where("update_transactions.repo_id IN (?) AND update_transactions.deleted_at IS NULL", reposIDs).
+			Scan(&updates).Error; err != nil {
+			s.log.WithField("error", err.Error()).Error("error occurred while getting update repos update transactions")
+			return err
+		}
+		reposUpdates := make(map[uint][]updateRepoUpdate, len(candidates))
+		for _, update := range updates {
+			reposUpdates[update.RepoID] = append(reposUpdates[update.RepoID], update)
+		}
+
+		for i := range candidates {
+			candidate := &candidates[i]
+			candidate.Updates = reposUpdates[candidate.RepoID]
+			if len(candidate.Updates) == 0 {
+				continue
+			}
+			ownsFolder, ownsPulpRepo := candidate.ownStorage()
+			if (!ownsFolder && !ownsPulpRepo) || refs.updateRepoReferences(candidate, since) > 0 {
+				continue
+			}
+			logger := s.log.WithFields(log.Fields{
+				"org_id": candidate.Updates[0].OrgID, "repo_id": candidate.RepoID, "updates_count": len(candidate.Updates), "dry_run": result.DryRun,
+			})
+			outcome := gcResultDryRun
+			if !result.DryRun {
+				outcome = gcResultDeleted
+				if err := s.deleteUpdateRepo(logger, candidate, ownsFolder, ownsPulpRepo); err != nil {
+					outcome = gcResultFailed
+				}
+			}
+			metrics.GarbageCollectedCount.WithLabelValues(gcTypeUpdateRepo, outcome).Inc()
+			if outcome == gcResultFailed {
+				result.FailedCount++
+				continue
+			}
+			result.UpdateReposCount++
+			logger.Info("update repo garbage collected")
+		}
+	}
+}
+
+// ownStorage returns whether the update repo has its own storage folder and its own Pulp repo, the update repos
+// pointing to an update commit repo or to a static delta repo share its storage and must not be deleted
+func (c *updateRepoCandidate) ownStorage() (bool, bool) {
+	repoPath, err := urlPath(c.RepoURL)
+	ownsFolder := err == nil && repoPath != "" && repoPath != "/" && !c.StaticDeltaRepo
+	ownsPulpRepo := c.RepoPulpID != ""
+	for _, update := range c.Updates {
+		commitRepoPath, _ := urlPath(update.CommitRepoURL)
+		commitRepoPulpPath, _ := urlPath(update.CommitRepoPulpURL)
+		if repoPath == commitRepoPath || repoPath == commitRepoPulpPath {
+			ownsFolder = false
+		}
+		if c.RepoPulpID == update.CommitRepoPulpID {
+			ownsPulpRepo = false
+		}
+	}
+	return ownsFolder, ownsPulpRepo
+}
+
+// updateRepoReferences returns how many references an update repo has, each update transaction using the repo
+// references it when it is recent, still in progress or updates to one of the latest versions
+func (refs *gcReferences) updateRepoReferences(candidate *updateRepoCandidate, since time.Time) int {
+	count := 0
+	for _, update := range candidate.Updates {
+		if !update.CreatedAt.Before(since) {
+			count++
+		}
+		for _, status := range gcUpdateStatusesInProgress {
+			if update.Status == status {
+				count++
+			}
+		}
+		if refs.latestCommitIDs[gcKey(update.OrgID, update.CommitID)] {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *GarbageCollectionService) deleteUpdateRepo(logger log.FieldLogger, candidate *updateRepoCandidate, ownsFolder bool, ownsPulpRepo bool) error {
+	values := map[string]interface{}{"status": models.RepoStatusStorageCleaned}
+	if ownsFolder {
+		path, _ := urlPath(candidate.RepoURL)
+		if err := s.FilesService.DeleteFolder(path); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while deleting update repo folder")
+			return err
+		}
+		values["url"] = ""
+	}
+	if ownsPulpRepo {
+		if err := s.PulpRepoDeleter(s.ctx, candidate.Updates[0].OrgID, candidate.RepoPulpID); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while deleting update repo pulp repository")
+			return err
+		}
+		values["pulp_id"], values["pulp_url"], values["pulp_status"] = "", "", models.RepoStatusStorageCleaned
+	}
+	if err := db.DB.Model(&models.Repo{}).Where("id = ?", candidate.RepoID).Updates(values).Error; err != nil {
+		logger.WithField("error", err.Error()).Error("error occurred while updating update repo status to cleaned")
+		return err
+	}
+	return nil
+}
+
+// urlPath returns the path of an url
+func urlPath(rawURL string) (string, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return parsedURL.Path, nil
+}
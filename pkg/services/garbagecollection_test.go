@@ -0,0 +1,211 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+var _ = Describe("GarbageCollectionService", func() {
+	var ctrl *gomock.Controller
+	var mockFilesService *mock_services.MockFilesService
+	var service services.GarbageCollectionServiceInterface
+	var deletedPulpRepos []string
+	var keepVersions, keepDays int
+	var orgID string
+	var commits []models.Commit
+	var unreferencedDelta, legacyDelta models.StaticDeltaState
+	var keptDeltas []models.StaticDeltaState
+	var unreferencedUpdate, sharedRepoUpdate, staticDeltaRepoUpdate, latestVersionUpdate, recentUpdate models.UpdateTransaction
+
+	oldDate := models.EdgeAPITime{Time: time.Now().AddDate(0, 0, -60), Valid: true}
+
+	createDelta := func(from, to models.Commit, url string, size int64) models.StaticDeltaState {
+		state := models.StaticDeltaState{
+			OrgID: orgID, Name: models.GetStaticDeltaName(from.OSTreeCommit, to.OSTreeCommit),
+			FromCommit: from.OSTreeCommit, ToCommit: to.OSTreeCommit, Status: models.StaticDeltaStatusReady, URL: url, Size: size,
+		}
+		Expect(db.DB.Create(&state).Error).ToNot(HaveOccurred())
+		return state
+	}
+
+	createUpdate := func(commit, oldCommit models.Commit, repo models.Repo, createdAt models.EdgeAPITime) models.UpdateTransaction {
+		repo.Status = models.RepoStatusSuccess
+		update := models.UpdateTransaction{
+			Model:      models.Model{CreatedAt: createdAt},
+			OrgID:      orgID,
+			CommitID:   commit.ID,
+			OldCommits: []models.Commit{oldCommit},
+			Repo:       &repo,
+			Status:     models.UpdateStatusSuccess,
+		}
+		Expect(db.DB.Omit("OldCommits.*").Create(&update).Error).ToNot(HaveOccurred())
+		return update
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockFilesService = mock_services.NewMockFilesService(ctrl)
+		deletedPulpRepos = []string{}
+		service = &services.GarbageCollectionService{
+			Service:      services.NewService(context.Background(), log.WithField("service", "garbage-collection")),
+			FilesService: mockFilesService,
+			PulpRepoDeleter: func(_ context.Context, _ string, pulpID string) error {
+				deletedPulpRepos = append(deletedPulpRepos, pulpID)
+				return nil
+			},
+		}
+		keepVersions, keepDays = config.Get().GCKeepVersions, config.Get().GCKeepDays
+		config.Get().GCKeepVersions, config.Get().GCKeepDays = 1, 30
+
+		orgID = faker.UUIDHyphenated()
+		imageSet := models.ImageSet{OrgID: orgID, Name: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+		// commits[0..2] are the image-set versions, commits[3] and commits[4] are only used by a recent update
+		commits = make([]models.Commit, 5)
+		for i := range commits {
+			commits[i] = models.Commit{
+				OrgID:        orgID,
+				OSTreeCommit: faker.UUIDDigit(),
+				Repo:         &models.Repo{URL: "https://repos.example.com/" + faker.UUIDDigit() + "/repo", Status: models.RepoStatusSuccess},
+			}
+			Expect(db.DB.Create(&commits[i]).Error).ToNot(HaveOccurred())
+		}
+		var images []models.Image
+		for i, commit := range commits[:3] {
+			image := models.Image{OrgID: orgID, Name: imageSet.Name, ImageSetID: &imageSet.ID, CommitID: commit.ID, Version: i + 1, Status: models.ImageStatusSuccess}
+			Expect(db.DB.Create(&image).Error).ToNot(HaveOccurred())
+			images = append(images, image)
+		}
+		// a device still runs the second version
+		Expect(db.DB.Create(&models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: images[1].ID}).Error).ToNot(HaveOccurred())
+
+		unreferencedDelta = createDelta(commits[0], commits[1], "https://repos.example.com/v2/org/static-deltas/1/repo", 100)
+		legacyDelta = models.StaticDeltaState{OrgID: orgID, Name: models.GetStaticDeltaName(faker.UUIDDigit(), faker.UUIDDigit()), Status: models.StaticDeltaStatusError}
+		Expect(db.DB.Create(&legacyDelta).Error).ToNot(HaveOccurred())
+		keptDeltas = []models.StaticDeltaState{
+			// to the latest version
+			createDelta(commits[0], commits[2], "https://repos.example.com/v2/org/static-deltas/2/repo", 100),
+			// from a commit running on a device
+			createDelta(commits[1], commits[0], "https://repos.example.com/v2/org/static-deltas/3/repo", 100),
+			// used by a recent update
+			createDelta(commits[3], commits[4], "https://repos.example.com/v2/org/static-deltas/4/repo", 100),
+		}
+		inProgressDelta := models.StaticDeltaState{OrgID: orgID, Name: faker.UUIDDigit(), Status: models.StaticDeltaStatusGenerating}
+		Expect(db.DB.Create(&inProgressDelta).Error).ToNot(HaveOccurred())
+		keptDeltas = append(keptDeltas, inProgressDelta)
+
+		unreferencedUpdate = createUpdate(commits[1], commits[0],
+			models.Repo{URL: "https://repos.example.com/v2/org/updates/1/repo", PulpID: faker.UUIDHyphenated()}, oldDate)
+		sharedRepoUpdate = createUpdate(commits[1], commits[0], models.Repo{URL: commits[1].Repo.URL}, oldDate)
+		staticDeltaRepoUpdate = createUpdate(commits[0], commits[1], models.Repo{URL: keptDeltas[1].URL}, oldDate)
+		latestVersionUpdate = createUpdate(commits[2], commits[1], models.Repo{URL: "https://repos.example.com/v2/org/updates/2/repo"}, oldDate)
+		recentUpdate = createUpdate(commits[4], commits[3], models.Repo{URL: "https://repos.example.com/v2/org/updates/3/repo"},
+			models.EdgeAPITime{Time: time.Now(), Valid: true})
+	})
+
+	AfterEach(func() {
+		config.Get().GCKeepVersions, config.Get().GCKeepDays = keepVersions, keepDays
+		ctrl.Finish()
+	})
+
+	expectRepoStatus := func(update models.UpdateTransaction, status string) {
+		var repo models.Repo
+		Expect(db.DB.First(&repo, *update.RepoID).Error).ToNot(HaveOccurred())
+		Expect(repo.Status).To(Equal(status))
+	}
+
+	Context("CollectGarbage", func() {
+		It("should delete the static deltas and update repos with no reference", func() {
+			mockFilesService.EXPECT().DeleteFolder("/v2/org/static-deltas/1/repo").Return(nil)
+			mockFilesService.EXPECT().DeleteFolder("/v2/org/updates/1/repo").Return(nil)
+
+			result, err := service.CollectGarbage(orgID, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.StaticDeltasCount).To(Equal(2))
+			Expect(result.UpdateReposCount).To(Equal(1))
+			Expect(result.Bytes).To(Equal(int64(100)))
+			Expect(result.FailedCount).To(Equal(0))
+
+			var states []models.StaticDeltaState
+			Expect(db.DB.Where("org_id = ?", orgID).Order("id").Find(&states).Error).ToNot(HaveOccurred())
+			Expect(states).To(HaveLen(len(keptDeltas)))
+			for i := range keptDeltas {
+				Expect(states[i].ID).To(Equal(keptDeltas[i].ID))
+			}
+
+			Expect(deletedPulpRepos).To(Equal([]string{unreferencedUpdate.Repo.PulpID}))
+			var repo models.Repo
+			Expect(db.DB.First(&repo, *unreferencedUpdate.RepoID).Error).ToNot(HaveOccurred())
+			Expect(repo.Status).To(Equal(models.RepoStatusStorageCleaned))
+			Expect(repo.URL).To(BeEmpty())
+			Expect(repo.PulpID).To(BeEmpty())
+			expectRepoStatus(sharedRepoUpdate, models.RepoStatusSuccess)
+			expectRepoStatus(staticDeltaRepoUpdate, models.RepoStatusSuccess)
+			expectRepoStatus(latestVersionUpdate, models.RepoStatusSuccess)
+			expectRepoStatus(recentUpdate, models.RepoStatusSuccess)
+		})
+
+		It("should keep the update repo while a retry of its update uses it", func() {
+			retryUpdate := models.UpdateTransaction{
+				OrgID:    orgID,
+				CommitID: commits[1].ID,
+				RepoID:   unreferencedUpdate.RepoID,
+				ParentID: &unreferencedUpdate.ID,
+				Status:   models.UpdateStatusBuilding,
+			}
+			Expect(db.DB.Create(&retryUpdate).Error).ToNot(HaveOccurred())
+			mockFilesService.EXPECT().DeleteFolder("/v2/org/static-deltas/1/repo").Return(nil)
+
+			result, err := service.CollectGarbage(orgID, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.UpdateReposCount).To(Equal(0))
+			Expect(deletedPulpRepos).To(BeEmpty())
+			expectRepoStatus(unreferencedUpdate, models.RepoStatusSuccess)
+		})
+
+		It("should only count what would be collected in dry-run mode", func() {
+			result, err := service.CollectGarbage(orgID, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.DryRun).To(BeTrue())
+			Expect(result.StaticDeltasCount).To(Equal(2))
+			Expect(result.UpdateReposCount).To(Equal(1))
+			Expect(result.Bytes).To(Equal(int64(100)))
+
+			var count int64
+			Expect(db.DB.Model(&models.StaticDeltaState{}).Where("org_id = ?", orgID).Count(&count).Error).ToNot(HaveOccurred())
+			Expect(count).To(Equal(int64(len(keptDeltas) + 2)))
+			Expect(deletedPulpRepos).To(BeEmpty())
+			expectRepoStatus(unreferencedUpdate, models.RepoStatusSuccess)
+		})
+
+		It("should continue collecting when a deletion fails", func() {
+			mockFilesService.EXPECT().DeleteFolder("/v2/org/static-deltas/1/repo").Return(errors.New("expected error"))
+			mockFilesService.EXPECT().DeleteFolder("/v2/org/updates/1/repo").Return(nil)
+
+			result, err := service.CollectGarbage(orgID, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.StaticDeltasCount).To(Equal(1))
+			Expect(result.UpdateReposCount).To(Equal(1))
+			Expect(result.Bytes).To(Equal(int64(0)))
+			Expect(result.FailedCount).To(Equal(1))
+
+			var state models.StaticDeltaState
+			Expect(db.DB.First(&state, unreferencedDelta.ID).Error).ToNot(HaveOccurred())
+			Expect(db.DB.First(&state, legacyDelta.ID).Error).To(HaveOccurred())
+			expectRepoStatus(unreferencedUpdate, models.RepoStatusStorageCleaned)
+		})
+	})
+})
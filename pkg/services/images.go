@@ -778,6 +778,14 @@ func (s *ImageService) SetFinalImageStatus(i *models.Image) {
 		if err := s.SetDevicesUpdateAvailabilityFromImageSet(i.OrgID, *i.ImageSetID); err != nil {
 			s.log.WithField("error", err.Error()).Error("Error while setting devices update availability flag")
 		}
+		if feature.StaticDeltaPregeneration.IsEnabledCtx(s.ctx) {
+			NewStaticDeltaService(s.ctx, s.log).PreGenerateStaticDeltasAsync(i.OrgID, i.ID)
+		}
+		if feature.UpdatePolicies.IsEnabledCtx(s.ctx) {
+			if _, err := NewUpdatePolicyService(s.ctx, s.log).ScheduleImageUpdatePolicies(i.OrgID, i.ID); err != nil {
+				s.log.WithField("error", err.Error()).Error("Error while scheduling the device groups update policies")
+			}
+		}
 	}
 }
 
@@ -45,6 +45,16 @@ func setupTestDB() {
 		&models.DispatchRecord{},
 		&models.DeviceGroup{},
 		&models.StaticDeltaState{},
+		&models.UpdateHook{},
+		&models.UpdateRollback{},
+		&models.DeviceEvent{},
+		&models.UpdatePolicy{},
+		&models.UpdatePolicyRun{},
+		&models.DeviceGroupDesiredState{},
+		&models.DeviceStalenessSettings{},
+		&models.UpdateApprovalSettings{},
+		&models.DeviceAction{},
+		&models.DeviceActionPlaybook{},
 	)
 	if err != nil {
 		panic(err)
@@ -284,6 +284,20 @@ func (m *MockS3ClientInterface) EXPECT() *MockS3ClientInterfaceMockRecorder {
 	return m.recorder
 }
 
+// DeleteFolder mocks base method.
+func (m *MockS3ClientInterface) DeleteFolder(bucket, folderKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFolder", bucket, folderKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFolder indicates an expected call of DeleteFolder.
+func (mr *MockS3ClientInterfaceMockRecorder) DeleteFolder(bucket, folderKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFolder", reflect.TypeOf((*MockS3ClientInterface)(nil).DeleteFolder), bucket, folderKey)
+}
+
 // DeleteObject mocks base method.
 func (m *MockS3ClientInterface) DeleteObject(bucket, key string) (*s3.DeleteObjectOutput, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,141 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/deviceactions.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	io "io"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+	gorm "gorm.io/gorm"
+)
+
+// MockDeviceActionsServiceInterface is a mock of DeviceActionsServiceInterface interface.
+type MockDeviceActionsServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceActionsServiceInterfaceMockRecorder
+}
+
+// MockDeviceActionsServiceInterfaceMockRecorder is the mock recorder for MockDeviceActionsServiceInterface.
+type MockDeviceActionsServiceInterfaceMockRecorder struct {
+	mock *MockDeviceActionsServiceInterface
+}
+
+// NewMockDeviceActionsServiceInterface creates a new mock instance.
+func NewMockDeviceActionsServiceInterface(ctrl *gomock.Controller) *MockDeviceActionsServiceInterface {
+	mock := &MockDeviceActionsServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockDeviceActionsServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceActionsServiceInterface) EXPECT() *MockDeviceActionsServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateDeviceAction mocks base method.
+func (m *MockDeviceActionsServiceInterface) CreateDeviceAction(orgID string, request *models.DeviceActionRequest) (*models.DeviceAction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeviceAction", orgID, request)
+	ret0, _ := ret[0].(*models.DeviceAction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDeviceAction indicates an expected call of CreateDeviceAction.
+func (mr *MockDeviceActionsServiceInterfaceMockRecorder) CreateDeviceAction(orgID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeviceAction", reflect.TypeOf((*MockDeviceActionsServiceInterface)(nil).CreateDeviceAction), orgID, request)
+}
+
+// CreatePlaybook mocks base method.
+func (m *MockDeviceActionsServiceInterface) CreatePlaybook(orgID string, playbook *models.DeviceActionPlaybook) (*models.DeviceActionPlaybook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePlaybook", orgID, playbook)
+	ret0, _ := ret[0].(*models.DeviceActionPlaybook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePlaybook indicates an expected call of CreatePlaybook.
+func (mr *MockDeviceActionsServiceInterfaceMockRecorder) CreatePlaybook(orgID, playbook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePlaybook", reflect.TypeOf((*MockDeviceActionsServiceInterface)(nil).CreatePlaybook), orgID, playbook)
+}
+
+// DeletePlaybook mocks base method.
+func (m *MockDeviceActionsServiceInterface) DeletePlaybook(orgID string, playbookID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePlaybook", orgID, playbookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePlaybook indicates an expected call of DeletePlaybook.
+func (mr *MockDeviceActionsServiceInterfaceMockRecorder) DeletePlaybook(orgID, playbookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePlaybook", reflect.TypeOf((*MockDeviceActionsServiceInterface)(nil).DeletePlaybook), orgID, playbookID)
+}
+
+// GetDeviceAction mocks base method.
+func (m *MockDeviceActionsServiceInterface) GetDeviceAction(orgID string, actionID uint) (*models.DeviceAction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceAction", orgID, actionID)
+	ret0, _ := ret[0].(*models.DeviceAction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceAction indicates an expected call of GetDeviceAction.
+func (mr *MockDeviceActionsServiceInterfaceMockRecorder) GetDeviceAction(orgID, actionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceAction", reflect.TypeOf((*MockDeviceActionsServiceInterface)(nil).GetDeviceAction), orgID, actionID)
+}
+
+// GetDeviceActionPlaybook mocks base method.
+func (m *MockDeviceActionsServiceInterface) GetDeviceActionPlaybook(action *models.DeviceAction) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceActionPlaybook", action)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceActionPlaybook indicates an expected call of GetDeviceActionPlaybook.
+func (mr *MockDeviceActionsServiceInterfaceMockRecorder) GetDeviceActionPlaybook(action interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceActionPlaybook", reflect.TypeOf((*MockDeviceActionsServiceInterface)(nil).GetDeviceActionPlaybook), action)
+}
+
+// GetDeviceActions mocks base method.
+func (m *MockDeviceActionsServiceInterface) GetDeviceActions(orgID string, limit, offset int, tx *gorm.DB) ([]models.DeviceAction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceActions", orgID, limit, offset, tx)
+	ret0, _ := ret[0].([]models.DeviceAction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceActions indicates an expected call of GetDeviceActions.
+func (mr *MockDeviceActionsServiceInterfaceMockRecorder) GetDeviceActions(orgID, limit, offset, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceActions", reflect.TypeOf((*MockDeviceActionsServiceInterface)(nil).GetDeviceActions), orgID, limit, offset, tx)
+}
+
+// GetPlaybooks mocks base method.
+func (m *MockDeviceActionsServiceInterface) GetPlaybooks(orgID string) ([]models.DeviceActionPlaybook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlaybooks", orgID)
+	ret0, _ := ret[0].([]models.DeviceActionPlaybook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlaybooks indicates an expected call of GetPlaybooks.
+func (mr *MockDeviceActionsServiceInterfaceMockRecorder) GetPlaybooks(orgID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlaybooks", reflect.TypeOf((*MockDeviceActionsServiceInterface)(nil).GetPlaybooks), orgID)
+}
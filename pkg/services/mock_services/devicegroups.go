@@ -109,6 +109,21 @@ func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) DeviceGroupNameExists(or
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeviceGroupNameExists", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).DeviceGroupNameExists), orgID, name)
 }
 
+// ExpandDeviceGroupsAccess mocks base method.
+func (m *MockDeviceGroupsServiceInterface) ExpandDeviceGroupsAccess(orgID string, deviceGroupsIDs []uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpandDeviceGroupsAccess", orgID, deviceGroupsIDs)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpandDeviceGroupsAccess indicates an expected call of ExpandDeviceGroupsAccess.
+func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) ExpandDeviceGroupsAccess(orgID, deviceGroupsIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpandDeviceGroupsAccess", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).ExpandDeviceGroupsAccess), orgID, deviceGroupsIDs)
+}
+
 // GetDeviceGroupByID mocks base method.
 func (m *MockDeviceGroupsServiceInterface) GetDeviceGroupByID(ID string) (*models.DeviceGroup, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +139,21 @@ func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) GetDeviceGroupByID(ID in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupByID", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).GetDeviceGroupByID), ID)
 }
 
+// GetDeviceGroupDescendantsIDs mocks base method.
+func (m *MockDeviceGroupsServiceInterface) GetDeviceGroupDescendantsIDs(orgID string, deviceGroupID uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupDescendantsIDs", orgID, deviceGroupID)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceGroupDescendantsIDs indicates an expected call of GetDeviceGroupDescendantsIDs.
+func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) GetDeviceGroupDescendantsIDs(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupDescendantsIDs", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).GetDeviceGroupDescendantsIDs), orgID, deviceGroupID)
+}
+
 // GetDeviceGroupDetailsByID mocks base method.
 func (m *MockDeviceGroupsServiceInterface) GetDeviceGroupDetailsByID(ID string) (*models.DeviceGroupDetails, error) {
 	m.ctrl.T.Helper()
@@ -154,6 +184,21 @@ func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) GetDeviceGroupDeviceByID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupDeviceByID", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).GetDeviceGroupDeviceByID), orgID, deviceGroupID, deviceID)
 }
 
+// GetDeviceGroupDevices mocks base method.
+func (m *MockDeviceGroupsServiceInterface) GetDeviceGroupDevices(orgID string, deviceGroupID uint, includeDescendants bool) ([]models.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupDevices", orgID, deviceGroupID, includeDescendants)
+	ret0, _ := ret[0].([]models.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceGroupDevices indicates an expected call of GetDeviceGroupDevices.
+func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) GetDeviceGroupDevices(orgID, deviceGroupID, includeDescendants interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupDevices", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).GetDeviceGroupDevices), orgID, deviceGroupID, includeDescendants)
+}
+
 // GetDeviceGroups mocks base method.
 func (m *MockDeviceGroupsServiceInterface) GetDeviceGroups(orgID string, limit, offset int, tx *gorm.DB) (*[]models.DeviceGroupListDetail, error) {
 	m.ctrl.T.Helper()
@@ -198,6 +243,36 @@ func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) GetDeviceImageInfo(setOf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceImageInfo", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).GetDeviceImageInfo), setOfImages, orgID)
 }
 
+// MoveDeviceGroupsDevices mocks base method.
+func (m *MockDeviceGroupsServiceInterface) MoveDeviceGroupsDevices(orgID string, move *models.DeviceGroupsMove) (*models.DeviceGroupsMoveResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveDeviceGroupsDevices", orgID, move)
+	ret0, _ := ret[0].(*models.DeviceGroupsMoveResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveDeviceGroupsDevices indicates an expected call of MoveDeviceGroupsDevices.
+func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) MoveDeviceGroupsDevices(orgID, move interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveDeviceGroupsDevices", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).MoveDeviceGroupsDevices), orgID, move)
+}
+
+// SetDeviceGroupHierarchy mocks base method.
+func (m *MockDeviceGroupsServiceInterface) SetDeviceGroupHierarchy(orgID string, deviceGroupID uint, parentID *uint, cascadeRBAC bool) (*models.DeviceGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeviceGroupHierarchy", orgID, deviceGroupID, parentID, cascadeRBAC)
+	ret0, _ := ret[0].(*models.DeviceGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetDeviceGroupHierarchy indicates an expected call of SetDeviceGroupHierarchy.
+func (mr *MockDeviceGroupsServiceInterfaceMockRecorder) SetDeviceGroupHierarchy(orgID, deviceGroupID, parentID, cascadeRBAC interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeviceGroupHierarchy", reflect.TypeOf((*MockDeviceGroupsServiceInterface)(nil).SetDeviceGroupHierarchy), orgID, deviceGroupID, parentID, cascadeRBAC)
+}
+
 // UpdateDeviceGroup mocks base method.
 func (m *MockDeviceGroupsServiceInterface) UpdateDeviceGroup(deviceGroup *models.DeviceGroup, orgID, ID string) error {
 	m.ctrl.T.Helper()
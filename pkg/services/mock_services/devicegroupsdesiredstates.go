@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/devicegroupsdesiredstates.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// MockDesiredStateServiceInterface is a mock of DesiredStateServiceInterface interface.
+type MockDesiredStateServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDesiredStateServiceInterfaceMockRecorder
+}
+
+// MockDesiredStateServiceInterfaceMockRecorder is the mock recorder for MockDesiredStateServiceInterface.
+type MockDesiredStateServiceInterfaceMockRecorder struct {
+	mock *MockDesiredStateServiceInterface
+}
+
+// NewMockDesiredStateServiceInterface creates a new mock instance.
+func NewMockDesiredStateServiceInterface(ctrl *gomock.Controller) *MockDesiredStateServiceInterface {
+	mock := &MockDesiredStateServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockDesiredStateServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDesiredStateServiceInterface) EXPECT() *MockDesiredStateServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DeleteDeviceGroupDesiredState mocks base method.
+func (m *MockDesiredStateServiceInterface) DeleteDeviceGroupDesiredState(orgID string, deviceGroupID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceGroupDesiredState", orgID, deviceGroupID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeviceGroupDesiredState indicates an expected call of DeleteDeviceGroupDesiredState.
+func (mr *MockDesiredStateServiceInterfaceMockRecorder) DeleteDeviceGroupDesiredState(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceGroupDesiredState", reflect.TypeOf((*MockDesiredStateServiceInterface)(nil).DeleteDeviceGroupDesiredState), orgID, deviceGroupID)
+}
+
+// GetDeviceGroupDesiredState mocks base method.
+func (m *MockDesiredStateServiceInterface) GetDeviceGroupDesiredState(orgID string, deviceGroupID uint) (*models.DeviceGroupDesiredState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupDesiredState", orgID, deviceGroupID)
+	ret0, _ := ret[0].(*models.DeviceGroupDesiredState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceGroupDesiredState indicates an expected call of GetDeviceGroupDesiredState.
+func (mr *MockDesiredStateServiceInterfaceMockRecorder) GetDeviceGroupDesiredState(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupDesiredState", reflect.TypeOf((*MockDesiredStateServiceInterface)(nil).GetDeviceGroupDesiredState), orgID, deviceGroupID)
+}
+
+// GetDeviceGroupDrift mocks base method.
+func (m *MockDesiredStateServiceInterface) GetDeviceGroupDrift(orgID string, deviceGroupID uint) (*models.DeviceGroupDrift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupDrift", orgID, deviceGroupID)
+	ret0, _ := ret[0].(*models.DeviceGroupDrift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceGroupDrift indicates an expected call of GetDeviceGroupDrift.
+func (mr *MockDesiredStateServiceInterfaceMockRecorder) GetDeviceGroupDrift(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupDrift", reflect.TypeOf((*MockDesiredStateServiceInterface)(nil).GetDeviceGroupDrift), orgID, deviceGroupID)
+}
+
+// ReconcileDeviceGroup mocks base method.
+func (m *MockDesiredStateServiceInterface) ReconcileDeviceGroup(orgID string, deviceGroupID uint) ([]models.UpdateTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileDeviceGroup", orgID, deviceGroupID)
+	ret0, _ := ret[0].([]models.UpdateTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileDeviceGroup indicates an expected call of ReconcileDeviceGroup.
+func (mr *MockDesiredStateServiceInterfaceMockRecorder) ReconcileDeviceGroup(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileDeviceGroup", reflect.TypeOf((*MockDesiredStateServiceInterface)(nil).ReconcileDeviceGroup), orgID, deviceGroupID)
+}
+
+// SetDeviceGroupDesiredState mocks base method.
+func (m *MockDesiredStateServiceInterface) SetDeviceGroupDesiredState(orgID string, deviceGroupID uint, state *models.DeviceGroupDesiredState) (*models.DeviceGroupDesiredState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeviceGroupDesiredState", orgID, deviceGroupID, state)
+	ret0, _ := ret[0].(*models.DeviceGroupDesiredState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetDeviceGroupDesiredState indicates an expected call of SetDeviceGroupDesiredState.
+func (mr *MockDesiredStateServiceInterfaceMockRecorder) SetDeviceGroupDesiredState(orgID, deviceGroupID, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeviceGroupDesiredState", reflect.TypeOf((*MockDesiredStateServiceInterface)(nil).SetDeviceGroupDesiredState), orgID, deviceGroupID, state)
+}
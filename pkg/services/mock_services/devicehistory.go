@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/devicehistory.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// MockDeviceHistoryServiceInterface is a mock of DeviceHistoryServiceInterface interface.
+type MockDeviceHistoryServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceHistoryServiceInterfaceMockRecorder
+}
+
+// MockDeviceHistoryServiceInterfaceMockRecorder is the mock recorder for MockDeviceHistoryServiceInterface.
+type MockDeviceHistoryServiceInterfaceMockRecorder struct {
+	mock *MockDeviceHistoryServiceInterface
+}
+
+// NewMockDeviceHistoryServiceInterface creates a new mock instance.
+func NewMockDeviceHistoryServiceInterface(ctrl *gomock.Controller) *MockDeviceHistoryServiceInterface {
+	mock := &MockDeviceHistoryServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockDeviceHistoryServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceHistoryServiceInterface) EXPECT() *MockDeviceHistoryServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetDeviceConnectivity mocks base method.
+func (m *MockDeviceHistoryServiceInterface) GetDeviceConnectivity(orgID, deviceUUID string, days int) (*models.DeviceConnectivity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceConnectivity", orgID, deviceUUID, days)
+	ret0, _ := ret[0].(*models.DeviceConnectivity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceConnectivity indicates an expected call of GetDeviceConnectivity.
+func (mr *MockDeviceHistoryServiceInterfaceMockRecorder) GetDeviceConnectivity(orgID, deviceUUID, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceConnectivity", reflect.TypeOf((*MockDeviceHistoryServiceInterface)(nil).GetDeviceConnectivity), orgID, deviceUUID, days)
+}
+
+// GetDeviceGroupConnectivity mocks base method.
+func (m *MockDeviceHistoryServiceInterface) GetDeviceGroupConnectivity(orgID string, deviceGroupID uint, days int) (*models.DeviceGroupConnectivity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupConnectivity", orgID, deviceGroupID, days)
+	ret0, _ := ret[0].(*models.DeviceGroupConnectivity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceGroupConnectivity indicates an expected call of GetDeviceGroupConnectivity.
+func (mr *MockDeviceHistoryServiceInterfaceMockRecorder) GetDeviceGroupConnectivity(orgID, deviceGroupID, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupConnectivity", reflect.TypeOf((*MockDeviceHistoryServiceInterface)(nil).GetDeviceGroupConnectivity), orgID, deviceGroupID, days)
+}
+
+// GetDeviceHistory mocks base method.
+func (m *MockDeviceHistoryServiceInterface) GetDeviceHistory(orgID, deviceUUID string, limit, offset int) (*models.DeviceHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceHistory", orgID, deviceUUID, limit, offset)
+	ret0, _ := ret[0].(*models.DeviceHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceHistory indicates an expected call of GetDeviceHistory.
+func (mr *MockDeviceHistoryServiceInterfaceMockRecorder) GetDeviceHistory(orgID, deviceUUID, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceHistory", reflect.TypeOf((*MockDeviceHistoryServiceInterface)(nil).GetDeviceHistory), orgID, deviceUUID, limit, offset)
+}
@@ -36,6 +36,65 @@ func (m *MockDeviceServiceInterface) EXPECT() *MockDeviceServiceInterfaceMockRec
 	return m.recorder
 }
 
+// CheckInDevice mocks base method.
+func (m *MockDeviceServiceInterface) CheckInDevice(orgID, subscriptionManagerID string, checkIn *models.DeviceCheckIn) (*models.DeviceCheckInResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckInDevice", orgID, subscriptionManagerID, checkIn)
+	ret0, _ := ret[0].(*models.DeviceCheckInResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckInDevice indicates an expected call of CheckInDevice.
+func (mr *MockDeviceServiceInterfaceMockRecorder) CheckInDevice(orgID, subscriptionManagerID, checkIn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckInDevice", reflect.TypeOf((*MockDeviceServiceInterface)(nil).CheckInDevice), orgID, subscriptionManagerID, checkIn)
+}
+
+// DetectDeviceRollback mocks base method.
+func (m *MockDeviceServiceInterface) DetectDeviceRollback(device *models.Device, inventoryDevice inventory.Device) (*models.UpdateRollback, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetectDeviceRollback", device, inventoryDevice)
+	ret0, _ := ret[0].(*models.UpdateRollback)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DetectDeviceRollback indicates an expected call of DetectDeviceRollback.
+func (mr *MockDeviceServiceInterfaceMockRecorder) DetectDeviceRollback(device, inventoryDevice interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectDeviceRollback", reflect.TypeOf((*MockDeviceServiceInterface)(nil).DetectDeviceRollback), device, inventoryDevice)
+}
+
+// ExportDevicesView mocks base method.
+func (m *MockDeviceServiceInterface) ExportDevicesView(tx *gorm.DB, batchSize int, writeBatch func([]models.DeviceView) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportDevicesView", tx, batchSize, writeBatch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportDevicesView indicates an expected call of ExportDevicesView.
+func (mr *MockDeviceServiceInterfaceMockRecorder) ExportDevicesView(tx, batchSize, writeBatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportDevicesView", reflect.TypeOf((*MockDeviceServiceInterface)(nil).ExportDevicesView), tx, batchSize, writeBatch)
+}
+
+// FilterTaggedDevices mocks base method.
+func (m *MockDeviceServiceInterface) FilterTaggedDevices(tx *gorm.DB, tags []string) (*gorm.DB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterTaggedDevices", tx, tags)
+	ret0, _ := ret[0].(*gorm.DB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterTaggedDevices indicates an expected call of FilterTaggedDevices.
+func (mr *MockDeviceServiceInterfaceMockRecorder) FilterTaggedDevices(tx, tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterTaggedDevices", reflect.TypeOf((*MockDeviceServiceInterface)(nil).FilterTaggedDevices), tx, tags)
+}
+
 // GetDeviceByID mocks base method.
 func (m *MockDeviceServiceInterface) GetDeviceByID(deviceID uint) (*models.Device, error) {
 	m.ctrl.T.Helper()
@@ -154,6 +213,36 @@ func (mr *MockDeviceServiceInterfaceMockRecorder) GetDeviceLastDeployment(device
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceLastDeployment", reflect.TypeOf((*MockDeviceServiceInterface)(nil).GetDeviceLastDeployment), device)
 }
 
+// GetDeviceRollbacks mocks base method.
+func (m *MockDeviceServiceInterface) GetDeviceRollbacks(orgID, deviceUUID string) ([]models.UpdateRollback, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceRollbacks", orgID, deviceUUID)
+	ret0, _ := ret[0].([]models.UpdateRollback)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceRollbacks indicates an expected call of GetDeviceRollbacks.
+func (mr *MockDeviceServiceInterfaceMockRecorder) GetDeviceRollbacks(orgID, deviceUUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceRollbacks", reflect.TypeOf((*MockDeviceServiceInterface)(nil).GetDeviceRollbacks), orgID, deviceUUID)
+}
+
+// GetDeviceUpdateManifest mocks base method.
+func (m *MockDeviceServiceInterface) GetDeviceUpdateManifest(orgID, subscriptionManagerID string) (*models.DeviceUpdateManifest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceUpdateManifest", orgID, subscriptionManagerID)
+	ret0, _ := ret[0].(*models.DeviceUpdateManifest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceUpdateManifest indicates an expected call of GetDeviceUpdateManifest.
+func (mr *MockDeviceServiceInterfaceMockRecorder) GetDeviceUpdateManifest(orgID, subscriptionManagerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceUpdateManifest", reflect.TypeOf((*MockDeviceServiceInterface)(nil).GetDeviceUpdateManifest), orgID, subscriptionManagerID)
+}
+
 // GetDevices mocks base method.
 func (m *MockDeviceServiceInterface) GetDevices(params *inventory.Params) (*models.DeviceDetailsList, error) {
 	m.ctrl.T.Helper()
@@ -288,6 +377,20 @@ func (mr *MockDeviceServiceInterfaceMockRecorder) ProcessPlatformInventoryUpdate
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPlatformInventoryUpdatedEvent", reflect.TypeOf((*MockDeviceServiceInterface)(nil).ProcessPlatformInventoryUpdatedEvent), message)
 }
 
+// SetDeviceUpdateResult mocks base method.
+func (m *MockDeviceServiceInterface) SetDeviceUpdateResult(orgID, subscriptionManagerID string, updateResult *models.DeviceUpdateResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeviceUpdateResult", orgID, subscriptionManagerID, updateResult)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDeviceUpdateResult indicates an expected call of SetDeviceUpdateResult.
+func (mr *MockDeviceServiceInterfaceMockRecorder) SetDeviceUpdateResult(orgID, subscriptionManagerID, updateResult interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeviceUpdateResult", reflect.TypeOf((*MockDeviceServiceInterface)(nil).SetDeviceUpdateResult), orgID, subscriptionManagerID, updateResult)
+}
+
 // SyncDevicesWithInventory mocks base method.
 func (m *MockDeviceServiceInterface) SyncDevicesWithInventory(orgID string) {
 	m.ctrl.T.Helper()
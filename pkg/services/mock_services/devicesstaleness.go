@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/devicesstaleness.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// MockDeviceStalenessServiceInterface is a mock of DeviceStalenessServiceInterface interface.
+type MockDeviceStalenessServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceStalenessServiceInterfaceMockRecorder
+}
+
+// MockDeviceStalenessServiceInterfaceMockRecorder is the mock recorder for MockDeviceStalenessServiceInterface.
+type MockDeviceStalenessServiceInterfaceMockRecorder struct {
+	mock *MockDeviceStalenessServiceInterface
+}
+
+// NewMockDeviceStalenessServiceInterface creates a new mock instance.
+func NewMockDeviceStalenessServiceInterface(ctrl *gomock.Controller) *MockDeviceStalenessServiceInterface {
+	mock := &MockDeviceStalenessServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockDeviceStalenessServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceStalenessServiceInterface) EXPECT() *MockDeviceStalenessServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetStalenessSettings mocks base method.
+func (m *MockDeviceStalenessServiceInterface) GetStalenessSettings(orgID string) (*models.DeviceStalenessSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStalenessSettings", orgID)
+	ret0, _ := ret[0].(*models.DeviceStalenessSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStalenessSettings indicates an expected call of GetStalenessSettings.
+func (mr *MockDeviceStalenessServiceInterfaceMockRecorder) GetStalenessSettings(orgID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStalenessSettings", reflect.TypeOf((*MockDeviceStalenessServiceInterface)(nil).GetStalenessSettings), orgID)
+}
+
+// SetStalenessSettings mocks base method.
+func (m *MockDeviceStalenessServiceInterface) SetStalenessSettings(orgID string, settings *models.DeviceStalenessSettings) (*models.DeviceStalenessSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStalenessSettings", orgID, settings)
+	ret0, _ := ret[0].(*models.DeviceStalenessSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetStalenessSettings indicates an expected call of SetStalenessSettings.
+func (mr *MockDeviceStalenessServiceInterfaceMockRecorder) SetStalenessSettings(orgID, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStalenessSettings", reflect.TypeOf((*MockDeviceStalenessServiceInterface)(nil).SetStalenessSettings), orgID, settings)
+}
+
+// UpdateDevicesStaleness mocks base method.
+func (m *MockDeviceStalenessServiceInterface) UpdateDevicesStaleness() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDevicesStaleness")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDevicesStaleness indicates an expected call of UpdateDevicesStaleness.
+func (mr *MockDeviceStalenessServiceInterfaceMockRecorder) UpdateDevicesStaleness() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDevicesStaleness", reflect.TypeOf((*MockDeviceStalenessServiceInterface)(nil).UpdateDevicesStaleness))
+}
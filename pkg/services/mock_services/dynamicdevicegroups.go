@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/dynamicdevicegroups.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	services "github.com/redhatinsights/edge-api/pkg/services"
+)
+
+// MockDynamicDeviceGroupsServiceInterface is a mock of DynamicDeviceGroupsServiceInterface interface.
+type MockDynamicDeviceGroupsServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDynamicDeviceGroupsServiceInterfaceMockRecorder
+}
+
+// MockDynamicDeviceGroupsServiceInterfaceMockRecorder is the mock recorder for MockDynamicDeviceGroupsServiceInterface.
+type MockDynamicDeviceGroupsServiceInterfaceMockRecorder struct {
+	mock *MockDynamicDeviceGroupsServiceInterface
+}
+
+// NewMockDynamicDeviceGroupsServiceInterface creates a new mock instance.
+func NewMockDynamicDeviceGroupsServiceInterface(ctrl *gomock.Controller) *MockDynamicDeviceGroupsServiceInterface {
+	mock := &MockDynamicDeviceGroupsServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockDynamicDeviceGroupsServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDynamicDeviceGroupsServiceInterface) EXPECT() *MockDynamicDeviceGroupsServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// EvaluateDeviceGroup mocks base method.
+func (m *MockDynamicDeviceGroupsServiceInterface) EvaluateDeviceGroup(orgID string, deviceGroupID uint) (*services.DeviceGroupEvaluation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateDeviceGroup", orgID, deviceGroupID)
+	ret0, _ := ret[0].(*services.DeviceGroupEvaluation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateDeviceGroup indicates an expected call of EvaluateDeviceGroup.
+func (mr *MockDynamicDeviceGroupsServiceInterfaceMockRecorder) EvaluateDeviceGroup(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateDeviceGroup", reflect.TypeOf((*MockDynamicDeviceGroupsServiceInterface)(nil).EvaluateDeviceGroup), orgID, deviceGroupID)
+}
+
+// EvaluateDynamicDeviceGroups mocks base method.
+func (m *MockDynamicDeviceGroupsServiceInterface) EvaluateDynamicDeviceGroups() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateDynamicDeviceGroups")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EvaluateDynamicDeviceGroups indicates an expected call of EvaluateDynamicDeviceGroups.
+func (mr *MockDynamicDeviceGroupsServiceInterfaceMockRecorder) EvaluateDynamicDeviceGroups() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateDynamicDeviceGroups", reflect.TypeOf((*MockDynamicDeviceGroupsServiceInterface)(nil).EvaluateDynamicDeviceGroups))
+}
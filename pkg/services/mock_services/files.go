@@ -35,6 +35,20 @@ func (m *MockFilesService) EXPECT() *MockFilesServiceMockRecorder {
 	return m.recorder
 }
 
+// DeleteFolder mocks base method.
+func (m *MockFilesService) DeleteFolder(path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFolder", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFolder indicates an expected call of DeleteFolder.
+func (mr *MockFilesServiceMockRecorder) DeleteFolder(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFolder", reflect.TypeOf((*MockFilesService)(nil).DeleteFolder), path)
+}
+
 // GetDownloader mocks base method.
 func (m *MockFilesService) GetDownloader() files.Downloader {
 	m.ctrl.T.Helper()
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/garbagecollection.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	services "github.com/redhatinsights/edge-api/pkg/services"
+)
+
+// MockGarbageCollectionServiceInterface is a mock of GarbageCollectionServiceInterface interface.
+type MockGarbageCollectionServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockGarbageCollectionServiceInterfaceMockRecorder
+}
+
+// MockGarbageCollectionServiceInterfaceMockRecorder is the mock recorder for MockGarbageCollectionServiceInterface.
+type MockGarbageCollectionServiceInterfaceMockRecorder struct {
+	mock *MockGarbageCollectionServiceInterface
+}
+
+// NewMockGarbageCollectionServiceInterface creates a new mock instance.
+func NewMockGarbageCollectionServiceInterface(ctrl *gomock.Controller) *MockGarbageCollectionServiceInterface {
+	mock := &MockGarbageCollectionServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockGarbageCollectionServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGarbageCollectionServiceInterface) EXPECT() *MockGarbageCollectionServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CollectGarbage mocks base method.
+func (m *MockGarbageCollectionServiceInterface) CollectGarbage(dryRun bool) (*services.GarbageCollectionResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CollectGarbage", dryRun)
+	ret0, _ := ret[0].(*services.GarbageCollectionResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CollectGarbage indicates an expected call of CollectGarbage.
+func (mr *MockGarbageCollectionServiceInterfaceMockRecorder) CollectGarbage(dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectGarbage", reflect.TypeOf((*MockGarbageCollectionServiceInterface)(nil).CollectGarbage), dryRun)
+}
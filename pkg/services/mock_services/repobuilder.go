@@ -35,6 +35,21 @@ func (m *MockRepoBuilderInterface) EXPECT() *MockRepoBuilderInterfaceMockRecorde
 	return m.recorder
 }
 
+// BuildStaticDelta mocks base method.
+func (m *MockRepoBuilderInterface) BuildStaticDelta(ctx context.Context, orgID string, fromCommit, toCommit *models.Commit) (*models.StaticDeltaState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildStaticDelta", ctx, orgID, fromCommit, toCommit)
+	ret0, _ := ret[0].(*models.StaticDeltaState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildStaticDelta indicates an expected call of BuildStaticDelta.
+func (mr *MockRepoBuilderInterfaceMockRecorder) BuildStaticDelta(ctx, orgID, fromCommit, toCommit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildStaticDelta", reflect.TypeOf((*MockRepoBuilderInterface)(nil).BuildStaticDelta), ctx, orgID, fromCommit, toCommit)
+}
+
 // BuildUpdateRepo mocks base method.
 func (m *MockRepoBuilderInterface) BuildUpdateRepo(ctx context.Context, id uint) (*models.UpdateTransaction, error) {
 	m.ctrl.T.Helper()
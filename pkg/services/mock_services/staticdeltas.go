@@ -0,0 +1,77 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/staticdeltas.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// MockStaticDeltaServiceInterface is a mock of StaticDeltaServiceInterface interface.
+type MockStaticDeltaServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockStaticDeltaServiceInterfaceMockRecorder
+}
+
+// MockStaticDeltaServiceInterfaceMockRecorder is the mock recorder for MockStaticDeltaServiceInterface.
+type MockStaticDeltaServiceInterfaceMockRecorder struct {
+	mock *MockStaticDeltaServiceInterface
+}
+
+// NewMockStaticDeltaServiceInterface creates a new mock instance.
+func NewMockStaticDeltaServiceInterface(ctrl *gomock.Controller) *MockStaticDeltaServiceInterface {
+	mock := &MockStaticDeltaServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockStaticDeltaServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStaticDeltaServiceInterface) EXPECT() *MockStaticDeltaServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetImageStaticDeltas mocks base method.
+func (m *MockStaticDeltaServiceInterface) GetImageStaticDeltas(orgID string, imageID uint) ([]models.StaticDeltaState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageStaticDeltas", orgID, imageID)
+	ret0, _ := ret[0].([]models.StaticDeltaState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImageStaticDeltas indicates an expected call of GetImageStaticDeltas.
+func (mr *MockStaticDeltaServiceInterfaceMockRecorder) GetImageStaticDeltas(orgID, imageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageStaticDeltas", reflect.TypeOf((*MockStaticDeltaServiceInterface)(nil).GetImageStaticDeltas), orgID, imageID)
+}
+
+// PreGenerateStaticDeltas mocks base method.
+func (m *MockStaticDeltaServiceInterface) PreGenerateStaticDeltas(orgID string, imageID uint) ([]models.StaticDeltaState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreGenerateStaticDeltas", orgID, imageID)
+	ret0, _ := ret[0].([]models.StaticDeltaState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreGenerateStaticDeltas indicates an expected call of PreGenerateStaticDeltas.
+func (mr *MockStaticDeltaServiceInterfaceMockRecorder) PreGenerateStaticDeltas(orgID, imageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreGenerateStaticDeltas", reflect.TypeOf((*MockStaticDeltaServiceInterface)(nil).PreGenerateStaticDeltas), orgID, imageID)
+}
+
+// PreGenerateStaticDeltasAsync mocks base method.
+func (m *MockStaticDeltaServiceInterface) PreGenerateStaticDeltasAsync(orgID string, imageID uint) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PreGenerateStaticDeltasAsync", orgID, imageID)
+}
+
+// PreGenerateStaticDeltasAsync indicates an expected call of PreGenerateStaticDeltasAsync.
+func (mr *MockStaticDeltaServiceInterfaceMockRecorder) PreGenerateStaticDeltasAsync(orgID, imageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreGenerateStaticDeltasAsync", reflect.TypeOf((*MockStaticDeltaServiceInterface)(nil).PreGenerateStaticDeltasAsync), orgID, imageID)
+}
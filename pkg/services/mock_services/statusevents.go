@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/statusevents.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// MockStatusEventsServiceInterface is a mock of StatusEventsServiceInterface interface.
+type MockStatusEventsServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatusEventsServiceInterfaceMockRecorder
+}
+
+// MockStatusEventsServiceInterfaceMockRecorder is the mock recorder for MockStatusEventsServiceInterface.
+type MockStatusEventsServiceInterfaceMockRecorder struct {
+	mock *MockStatusEventsServiceInterface
+}
+
+// NewMockStatusEventsServiceInterface creates a new mock instance.
+func NewMockStatusEventsServiceInterface(ctrl *gomock.Controller) *MockStatusEventsServiceInterface {
+	mock := &MockStatusEventsServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockStatusEventsServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatusEventsServiceInterface) EXPECT() *MockStatusEventsServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetImageStatusEvents mocks base method.
+func (m *MockStatusEventsServiceInterface) GetImageStatusEvents(orgID string, imageID uint) (*models.StatusEvents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageStatusEvents", orgID, imageID)
+	ret0, _ := ret[0].(*models.StatusEvents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImageStatusEvents indicates an expected call of GetImageStatusEvents.
+func (mr *MockStatusEventsServiceInterfaceMockRecorder) GetImageStatusEvents(orgID, imageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageStatusEvents", reflect.TypeOf((*MockStatusEventsServiceInterface)(nil).GetImageStatusEvents), orgID, imageID)
+}
+
+// GetUpdateStatusEvents mocks base method.
+func (m *MockStatusEventsServiceInterface) GetUpdateStatusEvents(orgID string, updateID uint) (*models.StatusEvents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpdateStatusEvents", orgID, updateID)
+	ret0, _ := ret[0].(*models.StatusEvents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpdateStatusEvents indicates an expected call of GetUpdateStatusEvents.
+func (mr *MockStatusEventsServiceInterfaceMockRecorder) GetUpdateStatusEvents(orgID, updateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdateStatusEvents", reflect.TypeOf((*MockStatusEventsServiceInterface)(nil).GetUpdateStatusEvents), orgID, updateID)
+}
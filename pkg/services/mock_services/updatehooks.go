@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/updatehooks.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// MockUpdateHooksServiceInterface is a mock of UpdateHooksServiceInterface interface.
+type MockUpdateHooksServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUpdateHooksServiceInterfaceMockRecorder
+}
+
+// MockUpdateHooksServiceInterfaceMockRecorder is the mock recorder for MockUpdateHooksServiceInterface.
+type MockUpdateHooksServiceInterfaceMockRecorder struct {
+	mock *MockUpdateHooksServiceInterface
+}
+
+// NewMockUpdateHooksServiceInterface creates a new mock instance.
+func NewMockUpdateHooksServiceInterface(ctrl *gomock.Controller) *MockUpdateHooksServiceInterface {
+	mock := &MockUpdateHooksServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockUpdateHooksServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUpdateHooksServiceInterface) EXPECT() *MockUpdateHooksServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateHook mocks base method.
+func (m *MockUpdateHooksServiceInterface) CreateHook(orgID string, hook *models.UpdateHook) (*models.UpdateHook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHook", orgID, hook)
+	ret0, _ := ret[0].(*models.UpdateHook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateHook indicates an expected call of CreateHook.
+func (mr *MockUpdateHooksServiceInterfaceMockRecorder) CreateHook(orgID, hook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHook", reflect.TypeOf((*MockUpdateHooksServiceInterface)(nil).CreateHook), orgID, hook)
+}
+
+// DeleteDeviceGroupHook mocks base method.
+func (m *MockUpdateHooksServiceInterface) DeleteDeviceGroupHook(orgID string, deviceGroupID, hookID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceGroupHook", orgID, deviceGroupID, hookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeviceGroupHook indicates an expected call of DeleteDeviceGroupHook.
+func (mr *MockUpdateHooksServiceInterfaceMockRecorder) DeleteDeviceGroupHook(orgID, deviceGroupID, hookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceGroupHook", reflect.TypeOf((*MockUpdateHooksServiceInterface)(nil).DeleteDeviceGroupHook), orgID, deviceGroupID, hookID)
+}
+
+// DeleteImageSetHook mocks base method.
+func (m *MockUpdateHooksServiceInterface) DeleteImageSetHook(orgID string, imageSetID, hookID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteImageSetHook", orgID, imageSetID, hookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteImageSetHook indicates an expected call of DeleteImageSetHook.
+func (mr *MockUpdateHooksServiceInterfaceMockRecorder) DeleteImageSetHook(orgID, imageSetID, hookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteImageSetHook", reflect.TypeOf((*MockUpdateHooksServiceInterface)(nil).DeleteImageSetHook), orgID, imageSetID, hookID)
+}
+
+// GetDeviceGroupHooks mocks base method.
+func (m *MockUpdateHooksServiceInterface) GetDeviceGroupHooks(orgID string, deviceGroupID uint) ([]models.UpdateHook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupHooks", orgID, deviceGroupID)
+	ret0, _ := ret[0].([]models.UpdateHook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceGroupHooks indicates an expected call of GetDeviceGroupHooks.
+func (mr *MockUpdateHooksServiceInterfaceMockRecorder) GetDeviceGroupHooks(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupHooks", reflect.TypeOf((*MockUpdateHooksServiceInterface)(nil).GetDeviceGroupHooks), orgID, deviceGroupID)
+}
+
+// GetImageSetHooks mocks base method.
+func (m *MockUpdateHooksServiceInterface) GetImageSetHooks(orgID string, imageSetID uint) ([]models.UpdateHook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageSetHooks", orgID, imageSetID)
+	ret0, _ := ret[0].([]models.UpdateHook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImageSetHooks indicates an expected call of GetImageSetHooks.
+func (mr *MockUpdateHooksServiceInterfaceMockRecorder) GetImageSetHooks(orgID, imageSetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageSetHooks", reflect.TypeOf((*MockUpdateHooksServiceInterface)(nil).GetImageSetHooks), orgID, imageSetID)
+}
+
+// GetUpdateTransactionHooks mocks base method.
+func (m *MockUpdateHooksServiceInterface) GetUpdateTransactionHooks(update *models.UpdateTransaction) ([]models.UpdateHook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpdateTransactionHooks", update)
+	ret0, _ := ret[0].([]models.UpdateHook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpdateTransactionHooks indicates an expected call of GetUpdateTransactionHooks.
+func (mr *MockUpdateHooksServiceInterfaceMockRecorder) GetUpdateTransactionHooks(update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdateTransactionHooks", reflect.TypeOf((*MockUpdateHooksServiceInterface)(nil).GetUpdateTransactionHooks), update)
+}
@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/updatepolicies.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/redhatinsights/edge-api/pkg/models"
+)
+
+// MockUpdatePolicyServiceInterface is a mock of UpdatePolicyServiceInterface interface.
+type MockUpdatePolicyServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUpdatePolicyServiceInterfaceMockRecorder
+}
+
+// MockUpdatePolicyServiceInterfaceMockRecorder is the mock recorder for MockUpdatePolicyServiceInterface.
+type MockUpdatePolicyServiceInterfaceMockRecorder struct {
+	mock *MockUpdatePolicyServiceInterface
+}
+
+// NewMockUpdatePolicyServiceInterface creates a new mock instance.
+func NewMockUpdatePolicyServiceInterface(ctrl *gomock.Controller) *MockUpdatePolicyServiceInterface {
+	mock := &MockUpdatePolicyServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockUpdatePolicyServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUpdatePolicyServiceInterface) EXPECT() *MockUpdatePolicyServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DeleteDeviceGroupUpdatePolicy mocks base method.
+func (m *MockUpdatePolicyServiceInterface) DeleteDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceGroupUpdatePolicy", orgID, deviceGroupID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeviceGroupUpdatePolicy indicates an expected call of DeleteDeviceGroupUpdatePolicy.
+func (mr *MockUpdatePolicyServiceInterfaceMockRecorder) DeleteDeviceGroupUpdatePolicy(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceGroupUpdatePolicy", reflect.TypeOf((*MockUpdatePolicyServiceInterface)(nil).DeleteDeviceGroupUpdatePolicy), orgID, deviceGroupID)
+}
+
+// GetDeviceGroupUpdatePolicy mocks base method.
+func (m *MockUpdatePolicyServiceInterface) GetDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint) (*models.UpdatePolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceGroupUpdatePolicy", orgID, deviceGroupID)
+	ret0, _ := ret[0].(*models.UpdatePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceGroupUpdatePolicy indicates an expected call of GetDeviceGroupUpdatePolicy.
+func (mr *MockUpdatePolicyServiceInterfaceMockRecorder) GetDeviceGroupUpdatePolicy(orgID, deviceGroupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceGroupUpdatePolicy", reflect.TypeOf((*MockUpdatePolicyServiceInterface)(nil).GetDeviceGroupUpdatePolicy), orgID, deviceGroupID)
+}
+
+// RunDueUpdatePolicies mocks base method.
+func (m *MockUpdatePolicyServiceInterface) RunDueUpdatePolicies() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDueUpdatePolicies")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunDueUpdatePolicies indicates an expected call of RunDueUpdatePolicies.
+func (mr *MockUpdatePolicyServiceInterfaceMockRecorder) RunDueUpdatePolicies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDueUpdatePolicies", reflect.TypeOf((*MockUpdatePolicyServiceInterface)(nil).RunDueUpdatePolicies))
+}
+
+// ScheduleImageUpdatePolicies mocks base method.
+func (m *MockUpdatePolicyServiceInterface) ScheduleImageUpdatePolicies(orgID string, imageID uint) ([]models.UpdatePolicyRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleImageUpdatePolicies", orgID, imageID)
+	ret0, _ := ret[0].([]models.UpdatePolicyRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleImageUpdatePolicies indicates an expected call of ScheduleImageUpdatePolicies.
+func (mr *MockUpdatePolicyServiceInterfaceMockRecorder) ScheduleImageUpdatePolicies(orgID, imageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleImageUpdatePolicies", reflect.TypeOf((*MockUpdatePolicyServiceInterface)(nil).ScheduleImageUpdatePolicies), orgID, imageID)
+}
+
+// SetDeviceGroupUpdatePolicy mocks base method.
+func (m *MockUpdatePolicyServiceInterface) SetDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint, policy *models.UpdatePolicy) (*models.UpdatePolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeviceGroupUpdatePolicy", orgID, deviceGroupID, policy)
+	ret0, _ := ret[0].(*models.UpdatePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetDeviceGroupUpdatePolicy indicates an expected call of SetDeviceGroupUpdatePolicy.
+func (mr *MockUpdatePolicyServiceInterfaceMockRecorder) SetDeviceGroupUpdatePolicy(orgID, deviceGroupID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeviceGroupUpdatePolicy", reflect.TypeOf((*MockUpdatePolicyServiceInterface)(nil).SetDeviceGroupUpdatePolicy), orgID, deviceGroupID, policy)
+}
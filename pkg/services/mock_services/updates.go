@@ -37,6 +37,21 @@ func (m *MockUpdateServiceInterface) EXPECT() *MockUpdateServiceInterfaceMockRec
 	return m.recorder
 }
 
+// ApproveUpdate mocks base method.
+func (m *MockUpdateServiceInterface) ApproveUpdate(orgID string, updateID uint) (*models.UpdateTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveUpdate", orgID, updateID)
+	ret0, _ := ret[0].(*models.UpdateTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveUpdate indicates an expected call of ApproveUpdate.
+func (mr *MockUpdateServiceInterfaceMockRecorder) ApproveUpdate(orgID, updateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveUpdate", reflect.TypeOf((*MockUpdateServiceInterface)(nil).ApproveUpdate), orgID, updateID)
+}
+
 // BuildUpdateRepo mocks base method.
 func (m *MockUpdateServiceInterface) BuildUpdateRepo(ctx context.Context, orgID string, updateID uint) (*models.UpdateTransaction, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +109,21 @@ func (mr *MockUpdateServiceInterfaceMockRecorder) CreateUpdateAsync(id interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpdateAsync", reflect.TypeOf((*MockUpdateServiceInterface)(nil).CreateUpdateAsync), id)
 }
 
+// GetUpdateApprovalSettings mocks base method.
+func (m *MockUpdateServiceInterface) GetUpdateApprovalSettings(orgID string) (*models.UpdateApprovalSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpdateApprovalSettings", orgID)
+	ret0, _ := ret[0].(*models.UpdateApprovalSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpdateApprovalSettings indicates an expected call of GetUpdateApprovalSettings.
+func (mr *MockUpdateServiceInterfaceMockRecorder) GetUpdateApprovalSettings(orgID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdateApprovalSettings", reflect.TypeOf((*MockUpdateServiceInterface)(nil).GetUpdateApprovalSettings), orgID)
+}
+
 // GetUpdatePlaybook mocks base method.
 func (m *MockUpdateServiceInterface) GetUpdatePlaybook(update *models.UpdateTransaction) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +139,21 @@ func (mr *MockUpdateServiceInterfaceMockRecorder) GetUpdatePlaybook(update inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdatePlaybook", reflect.TypeOf((*MockUpdateServiceInterface)(nil).GetUpdatePlaybook), update)
 }
 
+// GetUpdateRollbacks mocks base method.
+func (m *MockUpdateServiceInterface) GetUpdateRollbacks(orgID string, updateID uint) ([]models.UpdateRollback, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpdateRollbacks", orgID, updateID)
+	ret0, _ := ret[0].([]models.UpdateRollback)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpdateRollbacks indicates an expected call of GetUpdateRollbacks.
+func (mr *MockUpdateServiceInterfaceMockRecorder) GetUpdateRollbacks(orgID, updateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdateRollbacks", reflect.TypeOf((*MockUpdateServiceInterface)(nil).GetUpdateRollbacks), orgID, updateID)
+}
+
 // GetUpdateTransactionsForDevice mocks base method.
 func (m *MockUpdateServiceInterface) GetUpdateTransactionsForDevice(device *models.Device) (*[]models.UpdateTransaction, error) {
 	m.ctrl.T.Helper()
@@ -139,6 +184,21 @@ func (mr *MockUpdateServiceInterfaceMockRecorder) InventoryGroupDevicesUpdateInf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InventoryGroupDevicesUpdateInfo", reflect.TypeOf((*MockUpdateServiceInterface)(nil).InventoryGroupDevicesUpdateInfo), orgID, inventoryGroupUUID)
 }
 
+// PreviewUpdate mocks base method.
+func (m *MockUpdateServiceInterface) PreviewUpdate(orgID string, devicesUUID []string, commit *models.Commit, includeStale bool) (*models.UpdatePreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewUpdate", orgID, devicesUUID, commit, includeStale)
+	ret0, _ := ret[0].(*models.UpdatePreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewUpdate indicates an expected call of PreviewUpdate.
+func (mr *MockUpdateServiceInterfaceMockRecorder) PreviewUpdate(orgID, devicesUUID, commit, includeStale interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewUpdate", reflect.TypeOf((*MockUpdateServiceInterface)(nil).PreviewUpdate), orgID, devicesUUID, commit, includeStale)
+}
+
 // ProcessPlaybookDispatcherRunEvent mocks base method.
 func (m *MockUpdateServiceInterface) ProcessPlaybookDispatcherRunEvent(message []byte) error {
 	m.ctrl.T.Helper()
@@ -153,6 +213,36 @@ func (mr *MockUpdateServiceInterfaceMockRecorder) ProcessPlaybookDispatcherRunEv
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPlaybookDispatcherRunEvent", reflect.TypeOf((*MockUpdateServiceInterface)(nil).ProcessPlaybookDispatcherRunEvent), message)
 }
 
+// RejectUpdate mocks base method.
+func (m *MockUpdateServiceInterface) RejectUpdate(orgID string, updateID uint) (*models.UpdateTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectUpdate", orgID, updateID)
+	ret0, _ := ret[0].(*models.UpdateTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectUpdate indicates an expected call of RejectUpdate.
+func (mr *MockUpdateServiceInterfaceMockRecorder) RejectUpdate(orgID, updateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectUpdate", reflect.TypeOf((*MockUpdateServiceInterface)(nil).RejectUpdate), orgID, updateID)
+}
+
+// RetryUpdateFailedDevices mocks base method.
+func (m *MockUpdateServiceInterface) RetryUpdateFailedDevices(orgID string, updateID uint) (*models.UpdateTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryUpdateFailedDevices", orgID, updateID)
+	ret0, _ := ret[0].(*models.UpdateTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryUpdateFailedDevices indicates an expected call of RetryUpdateFailedDevices.
+func (mr *MockUpdateServiceInterfaceMockRecorder) RetryUpdateFailedDevices(orgID, updateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryUpdateFailedDevices", reflect.TypeOf((*MockUpdateServiceInterface)(nil).RetryUpdateFailedDevices), orgID, updateID)
+}
+
 // SendDeviceNotification mocks base method.
 func (m *MockUpdateServiceInterface) SendDeviceNotification(update *models.UpdateTransaction) (services.ImageNotification, error) {
 	m.ctrl.T.Helper()
@@ -168,6 +258,35 @@ func (mr *MockUpdateServiceInterfaceMockRecorder) SendDeviceNotification(update
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendDeviceNotification", reflect.TypeOf((*MockUpdateServiceInterface)(nil).SendDeviceNotification), update)
 }
 
+// SetUnresponsiveDispatchRecords mocks base method.
+func (m *MockUpdateServiceInterface) SetUnresponsiveDispatchRecords() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUnresponsiveDispatchRecords")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUnresponsiveDispatchRecords indicates an expected call of SetUnresponsiveDispatchRecords.
+func (mr *MockUpdateServiceInterfaceMockRecorder) SetUnresponsiveDispatchRecords() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUnresponsiveDispatchRecords", reflect.TypeOf((*MockUpdateServiceInterface)(nil).SetUnresponsiveDispatchRecords))
+}
+
+// SetUpdateApprovalSettings mocks base method.
+func (m *MockUpdateServiceInterface) SetUpdateApprovalSettings(orgID string, settings *models.UpdateApprovalSettings) (*models.UpdateApprovalSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUpdateApprovalSettings", orgID, settings)
+	ret0, _ := ret[0].(*models.UpdateApprovalSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUpdateApprovalSettings indicates an expected call of SetUpdateApprovalSettings.
+func (mr *MockUpdateServiceInterfaceMockRecorder) SetUpdateApprovalSettings(orgID, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUpdateApprovalSettings", reflect.TypeOf((*MockUpdateServiceInterface)(nil).SetUpdateApprovalSettings), orgID, settings)
+}
+
 // SetUpdateStatus mocks base method.
 func (m *MockUpdateServiceInterface) SetUpdateStatus(update *models.UpdateTransaction) error {
 	m.ctrl.T.Helper()
@@ -42,6 +42,8 @@ const (
 	NotificationConfigEventTypeImage = "image-creation"
 	// NotificationConfigEventTypeDevice to be used
 	NotificationConfigEventTypeDevice = "update-devices"
+	// NotificationConfigEventTypeDeviceGroupDisconnected to be used
+	NotificationConfigEventTypeDeviceGroupDisconnected = "device-group-disconnected"
 	// NotificationConfigUser to be used
 	NotificationConfigUser = "fleet-management"
 )
@@ -0,0 +1,15 @@
+// FIXME: golangci-lint
+// nolint:revive,typecheck
+package playbooksigner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPlaybookSignerSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Playbook Signer Suite")
+}
@@ -0,0 +1,130 @@
+package playbooksigner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// serializePlay returns the canonical representation of a play as computed by the insights playbook verifier,
+// the play is loaded as ordered mappings and written with the python representation of its values
+func serializePlay(play *yaml.Node) (string, error) {
+	var builder strings.Builder
+	if err := serializeNode(&builder, play); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func serializeNode(builder *strings.Builder, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return serializeNode(builder, node.Alias)
+	case yaml.MappingNode:
+		builder.WriteString("ordereddict([")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString("('" + node.Content[i].Value + "', ")
+			if err := serializeNode(builder, node.Content[i+1]); err != nil {
+				return err
+			}
+			builder.WriteString(")")
+		}
+		builder.WriteString("])")
+	case yaml.SequenceNode:
+		builder.WriteString("[")
+		for i, item := range node.Content {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			if err := serializeNode(builder, item); err != nil {
+				return err
+			}
+		}
+		builder.WriteString("]")
+	case yaml.ScalarNode:
+		return serializeScalar(builder, node)
+	default:
+		return fmt.Errorf("unexpected yaml node kind %d at line %d", node.Kind, node.Line)
+	}
+	return nil
+}
+
+func serializeScalar(builder *strings.Builder, node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!null":
+		builder.WriteString("None")
+	case "!!bool":
+		var value bool
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		if value {
+			builder.WriteString("True")
+		} else {
+			builder.WriteString("False")
+		}
+	case "!!int":
+		var value int64
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		builder.WriteString(strconv.FormatInt(value, 10))
+	case "!!float":
+		var value float64
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		repr := strconv.FormatFloat(value, 'f', -1, 64)
+		if !strings.ContainsAny(repr, ".naN") {
+			repr += ".0"
+		}
+		builder.WriteString(repr)
+	default:
+		builder.WriteString(pythonStringRepr(node.Value))
+	}
+	return nil
+}
+
+// pythonStringRepr returns the python repr of a string
+func pythonStringRepr(value string) string {
+	quote := '\''
+	if strings.ContainsRune(value, '\'') && !strings.ContainsRune(value, '"') {
+		quote = '"'
+	}
+	var builder strings.Builder
+	builder.WriteRune(quote)
+	for _, r := range value {
+		switch {
+		case r == quote || r == '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(r)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			builder.WriteString(fmt.Sprintf(`\x%02x`, r))
+		case r > 0x7f && !unicode.IsPrint(r):
+			switch {
+			case r <= 0xff:
+				builder.WriteString(fmt.Sprintf(`\x%02x`, r))
+			case r <= 0xffff:
+				builder.WriteString(fmt.Sprintf(`\u%04x`, r))
+			default:
+				builder.WriteString(fmt.Sprintf(`\U%08x`, r))
+			}
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	builder.WriteRune(quote)
+	return builder.String()
+}
@@ -0,0 +1,293 @@
+// Package playbooksigner signs the playbooks sent to the devices in the format verified by rhc-worker-playbook
+package playbooksigner
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/redhatinsights/edge-api/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// SignatureVar is the play variable holding the signature
+	SignatureVar = "insights_signature"
+	// SignatureExcludeVar is the play variable holding the comma separated paths of the variables not signed
+	SignatureExcludeVar = "insights_signature_exclude"
+	// signatureLineLength is the wrapping length of the signature block
+	signatureLineLength = 76
+)
+
+// ErrNoSigningKey is returned when no playbook signing key is configured
+var ErrNoSigningKey = errors.New("no playbook signing key configured")
+
+// ErrSigningKeyNotFound is returned when the configured signing key id is not in the signing key ring
+var ErrSigningKeyNotFound = errors.New("playbook signing key not found in key ring")
+
+// ErrPlaybookNotSigned is returned when a play does not have a signature
+var ErrPlaybookNotSigned = errors.New("playbook play is not signed")
+
+// Signer signs playbooks with the private key of a key ring
+type Signer struct {
+	keyRing openpgp.EntityList
+	entity  *openpgp.Entity
+}
+
+// NewSigner returns a signer using the private key identified by keyID in the armored key ring,
+// keyID may be empty when the key ring has a single private key.
+// The key ring may hold the current and next keys, to rotate the signing key, add the next key to the key ring,
+// publish its public key to the devices and then change keyID
+func NewSigner(armoredKeyRing []byte, keyID string, passphrase string) (*Signer, error) {
+	keyRing, err := ReadKeyRing(armoredKeyRing)
+	if err != nil {
+		return nil, err
+	}
+	var entity *openpgp.Entity
+	for _, candidate := range keyRing {
+		if candidate.PrivateKey == nil {
+			continue
+		}
+		if keyID == "" {
+			if entity != nil {
+				return nil, errors.New("playbook signing key ring has several private keys and no signing key id configured")
+			}
+			entity = candidate
+		} else if matchKeyID(candidate, keyID) {
+			entity = candidate
+			break
+		}
+	}
+	if entity == nil {
+		return nil, ErrSigningKeyNotFound
+	}
+	if entity.PrivateKey.Encrypted {
+		if err := entity.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt playbook signing key: %w", err)
+		}
+	}
+	return &Signer{keyRing: keyRing, entity: entity}, nil
+}
+
+// NewSignerFromConfig returns a signer using the signing key configured either directly or as a file path,
+// the key is read on each call so that a rotated key file is used without restarting the service
+func NewSignerFromConfig() (*Signer, error) {
+	cfg := config.Get()
+	armoredKeyRing := []byte(cfg.PlaybookSigningKey)
+	if len(armoredKeyRing) == 0 && cfg.PlaybookSigningKeyPath != "" {
+		content, err := os.ReadFile(cfg.PlaybookSigningKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read playbook signing key file: %w", err)
+		}
+		armoredKeyRing = content
+	}
+	if len(armoredKeyRing) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	return NewSigner(armoredKeyRing, cfg.PlaybookSigningKeyID, cfg.PlaybookSigningPassphrase)
+}
+
+// ReadKeyRing reads an armored key ring
+func ReadKeyRing(armoredKeyRing []byte) (openpgp.EntityList, error) {
+	keyRing, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armoredKeyRing))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playbook signing key ring: %w", err)
+	}
+	return keyRing, nil
+}
+
+func matchKeyID(entity *openpgp.Entity, keyID string) bool {
+	keyID = strings.ToUpper(strings.TrimPrefix(strings.ReplaceAll(keyID, " ", ""), "0x"))
+	fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+	return keyID != "" && strings.HasSuffix(fingerprint, keyID)
+}
+
+// KeyID returns the id of the signing key
+func (s *Signer) KeyID() string {
+	return s.entity.PrimaryKey.KeyIdString()
+}
+
+// KeyRing returns the key ring of the signer, used to verify playbooks signed by any of its keys
+func (s *Signer) KeyRing() openpgp.EntityList {
+	return s.keyRing
+}
+
+// SignPlaybook signs all the plays of a playbook, all the variables of the plays are signed,
+// the exclusion list of each play is reset to the signature variable
+func (s *Signer) SignPlaybook(playbook []byte) ([]byte, error) {
+	document, plays, err := readPlays(playbook)
+	if err != nil {
+		return nil, err
+	}
+	for _, play := range plays {
+		vars := mappingValue(play, "vars")
+		if vars == nil || vars.Kind != yaml.MappingNode {
+			return nil, errors.New("playbook play has no vars to hold the signature")
+		}
+		setMappingValue(vars, SignatureExcludeVar, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "/vars/" + SignatureVar})
+		digest, err := playDigest(play)
+		if err != nil {
+			return nil, err
+		}
+		var signature bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&signature, s.entity, bytes.NewReader(digest), &packet.Config{DefaultHash: crypto.SHA256}); err != nil {
+			return nil, fmt.Errorf("failed to sign playbook: %w", err)
+		}
+		setMappingValue(vars, SignatureVar, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!binary",
+			Style: yaml.LiteralStyle,
+			Value: wrapSignature(base64.StdEncoding.EncodeToString([]byte(base64.StdEncoding.EncodeToString(signature.Bytes())))),
+		})
+	}
+
+	var signed bytes.Buffer
+	encoder := yaml.NewEncoder(&signed)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return signed.Bytes(), nil
+}
+
+// VerifyPlaybook verifies the signatures of all the plays of a playbook against a key ring
+func VerifyPlaybook(playbook []byte, keyRing openpgp.EntityList) error {
+	_, plays, err := readPlays(playbook)
+	if err != nil {
+		return err
+	}
+	for _, play := range plays {
+		signatureNode := mappingValue(mappingValue(play, "vars"), SignatureVar)
+		if signatureNode == nil {
+			return ErrPlaybookNotSigned
+		}
+		// the binary value of the signature is the base64 encoded armored signature
+		var encodedSignature string
+		if err := signatureNode.Decode(&encodedSignature); err != nil {
+			return fmt.Errorf("failed to decode playbook signature: %w", err)
+		}
+		signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+		if err != nil {
+			return fmt.Errorf("failed to decode playbook signature: %w", err)
+		}
+		digest, err := playDigest(play)
+		if err != nil {
+			return err
+		}
+		if _, err := openpgp.CheckArmoredDetachedSignature(keyRing, bytes.NewReader(digest), bytes.NewReader(signature), nil); err != nil {
+			return fmt.Errorf("playbook signature verification failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// readPlays parses a playbook and returns its document node and its plays
+func readPlays(playbook []byte) (*yaml.Node, []*yaml.Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(playbook, &document); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse playbook: %w", err)
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) != 1 || document.Content[0].Kind != yaml.SequenceNode {
+		return nil, nil, errors.New("playbook is not a list of plays")
+	}
+	plays := document.Content[0].Content
+	for _, play := range plays {
+		if play.Kind != yaml.MappingNode {
+			return nil, nil, errors.New("playbook play is not a mapping")
+		}
+	}
+	return &document, plays, nil
+}
+
+// playDigest returns the sha256 digest of the play without its excluded variables, as signed by the signer
+func playDigest(play *yaml.Node) ([]byte, error) {
+	unsigned, err := excludeVars(play)
+	if err != nil {
+		return nil, err
+	}
+	serialized, err := serializePlay(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(serialized))
+	return digest[:], nil
+}
+
+// excludeVars returns a copy of the play without the variables listed in its exclusion list
+func excludeVars(play *yaml.Node) (*yaml.Node, error) {
+	vars := mappingValue(play, "vars")
+	excludeNode := mappingValue(vars, SignatureExcludeVar)
+	if excludeNode == nil {
+		return play, nil
+	}
+	excluded := make(map[string]bool)
+	for _, path := range strings.Split(excludeNode.Value, ",") {
+		name := strings.TrimPrefix(strings.TrimSpace(path), "/vars/")
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("playbook signature exclusion %q is not a top level variable", path)
+		}
+		excluded[name] = true
+	}
+
+	filteredVars := *vars
+	filteredVars.Content = make([]*yaml.Node, 0, len(vars.Content))
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		if !excluded[vars.Content[i].Value] {
+			filteredVars.Content = append(filteredVars.Content, vars.Content[i], vars.Content[i+1])
+		}
+	}
+	filteredPlay := *play
+	filteredPlay.Content = make([]*yaml.Node, len(play.Content))
+	copy(filteredPlay.Content, play.Content)
+	for i := 0; i+1 < len(filteredPlay.Content); i += 2 {
+		if filteredPlay.Content[i].Value == "vars" {
+			filteredPlay.Content[i+1] = &filteredVars
+		}
+	}
+	return &filteredPlay, nil
+}
+
+// mappingValue returns the value node of a key of a mapping node, nil when not found
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value node of a key of a mapping node, the key is appended when not found
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// wrapSignature wraps the encoded signature in lines of signatureLineLength characters
+func wrapSignature(encoded string) string {
+	var builder strings.Builder
+	for len(encoded) > signatureLineLength {
+		builder.WriteString(encoded[:signatureLineLength] + "\n")
+		encoded = encoded[signatureLineLength:]
+	}
+	builder.WriteString(encoded + "\n")
+	return builder.String()
+}
@@ -0,0 +1,190 @@
+// FIXME: golangci-lint
+// nolint:revive
+package playbooksigner_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/services/playbooksigner"
+)
+
+const playbook = `# update playbook
+- name: Run the update
+  become: true
+  hosts: localhost
+  vars:
+    update_number: "1000"
+    repo_url: "https://cert.console.redhat.com/api/edge/v1/storage/update-repos/1000"
+    insights_signature_exclude: "/vars/insights_signature,/vars/update_number,/vars/repo_url"
+    insights_signature: !!binary |
+      c3RhdGljIHNpZ25hdHVyZQ==
+  tasks:
+  - name: run rpmostree update
+    ansible.builtin.shell: rpm-ostree upgrade --allow-downgrade
+    register: rpmostree_upgrade_out
+    failed_when: 'rpmostree_upgrade_out.rc != 0'
+`
+
+func newArmoredKeyRing(entities ...*openpgp.Entity) []byte {
+	var keyRing bytes.Buffer
+	writer, err := armor.Encode(&keyRing, openpgp.PrivateKeyType, nil)
+	Expect(err).ToNot(HaveOccurred())
+	for _, entity := range entities {
+		Expect(entity.SerializePrivate(writer, nil)).To(Succeed())
+	}
+	Expect(writer.Close()).To(Succeed())
+	return keyRing.Bytes()
+}
+
+func newEntity(name string) *openpgp.Entity {
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	Expect(err).ToNot(HaveOccurred())
+	return entity
+}
+
+var _ = Describe("Playbook signer", func() {
+	var currentKey *openpgp.Entity
+	var nextKey *openpgp.Entity
+
+	BeforeEach(func() {
+		if currentKey == nil {
+			currentKey = newEntity("current")
+			nextKey = newEntity("next")
+		}
+	})
+
+	Context("NewSigner", func() {
+		It("should use the single private key of the key ring", func() {
+			signer, err := playbooksigner.NewSigner(newArmoredKeyRing(currentKey), "", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signer.KeyID()).To(Equal(currentKey.PrimaryKey.KeyIdString()))
+		})
+
+		It("should select the signing key by id when the key ring has several keys", func() {
+			signer, err := playbooksigner.NewSigner(newArmoredKeyRing(currentKey, nextKey), nextKey.PrimaryKey.KeyIdString(), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signer.KeyID()).To(Equal(nextKey.PrimaryKey.KeyIdString()))
+			Expect(signer.KeyRing()).To(HaveLen(2))
+		})
+
+		It("should require a key id when the key ring has several keys", func() {
+			_, err := playbooksigner.NewSigner(newArmoredKeyRing(currentKey, nextKey), "", "")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when the key id is not in the key ring", func() {
+			_, err := playbooksigner.NewSigner(newArmoredKeyRing(currentKey), nextKey.PrimaryKey.KeyIdString(), "")
+			Expect(err).To(MatchError(playbooksigner.ErrSigningKeyNotFound))
+		})
+
+		It("should return an error when the key ring is not valid", func() {
+			_, err := playbooksigner.NewSigner([]byte("not a key ring"), "", "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("NewSignerFromConfig", func() {
+		var cfg *config.EdgeConfig
+		var initialKey, initialKeyPath, initialKeyID string
+
+		BeforeEach(func() {
+			cfg = config.Get()
+			initialKey, initialKeyPath, initialKeyID = cfg.PlaybookSigningKey, cfg.PlaybookSigningKeyPath, cfg.PlaybookSigningKeyID
+		})
+
+		AfterEach(func() {
+			cfg.PlaybookSigningKey, cfg.PlaybookSigningKeyPath, cfg.PlaybookSigningKeyID = initialKey, initialKeyPath, initialKeyID
+		})
+
+		It("should return an error when no key is configured", func() {
+			cfg.PlaybookSigningKey, cfg.PlaybookSigningKeyPath = "", ""
+			_, err := playbooksigner.NewSignerFromConfig()
+			Expect(err).To(MatchError(playbooksigner.ErrNoSigningKey))
+		})
+
+		It("should load the key from config", func() {
+			cfg.PlaybookSigningKey, cfg.PlaybookSigningKeyPath, cfg.PlaybookSigningKeyID = string(newArmoredKeyRing(currentKey)), "", ""
+			signer, err := playbooksigner.NewSignerFromConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signer.KeyID()).To(Equal(currentKey.PrimaryKey.KeyIdString()))
+		})
+
+		It("should load the rotated key from the key file", func() {
+			keyFile, err := os.CreateTemp("", "playbook-signing-key")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(keyFile.Name())
+			cfg.PlaybookSigningKey, cfg.PlaybookSigningKeyPath, cfg.PlaybookSigningKeyID = "", keyFile.Name(), ""
+
+			Expect(os.WriteFile(keyFile.Name(), newArmoredKeyRing(currentKey), 0600)).To(Succeed())
+			signer, err := playbooksigner.NewSignerFromConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signer.KeyID()).To(Equal(currentKey.PrimaryKey.KeyIdString()))
+
+			Expect(os.WriteFile(keyFile.Name(), newArmoredKeyRing(currentKey, nextKey), 0600)).To(Succeed())
+			cfg.PlaybookSigningKeyID = nextKey.PrimaryKey.KeyIdString()
+			signer, err = playbooksigner.NewSignerFromConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signer.KeyID()).To(Equal(nextKey.PrimaryKey.KeyIdString()))
+		})
+	})
+
+	Context("SignPlaybook", func() {
+		var signer *playbooksigner.Signer
+
+		BeforeEach(func() {
+			var err error
+			signer, err = playbooksigner.NewSigner(newArmoredKeyRing(currentKey, nextKey), currentKey.PrimaryKey.KeyIdString(), "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should sign the playbook and verify with the signing key", func() {
+			signed, err := signer.SignPlaybook([]byte(playbook))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(signed)).To(ContainSubstring(`insights_signature_exclude: /vars/insights_signature` + "\n"))
+			Expect(string(signed)).To(ContainSubstring("insights_signature: !!binary |\n"))
+			Expect(string(signed)).ToNot(ContainSubstring("c3RhdGljIHNpZ25hdHVyZQ=="))
+
+			Expect(playbooksigner.VerifyPlaybook(signed, openpgp.EntityList{currentKey})).To(Succeed())
+			Expect(playbooksigner.VerifyPlaybook(signed, signer.KeyRing())).To(Succeed())
+			Expect(playbooksigner.VerifyPlaybook(signed, openpgp.EntityList{nextKey})).ToNot(Succeed())
+		})
+
+		It("should sign the variables that were excluded from the static signature", func() {
+			signed, err := signer.SignPlaybook([]byte(playbook))
+			Expect(err).ToNot(HaveOccurred())
+
+			tampered := strings.Replace(string(signed), "update-repos/1000", "update-repos/1001", 1)
+			Expect(tampered).ToNot(Equal(string(signed)))
+			Expect(playbooksigner.VerifyPlaybook([]byte(tampered), signer.KeyRing())).ToNot(Succeed())
+		})
+
+		It("should return an error when the playbook is not a list of plays", func() {
+			_, err := signer.SignPlaybook([]byte("name: not a playbook"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when the play has no vars", func() {
+			_, err := signer.SignPlaybook([]byte("- name: no vars\n  hosts: localhost\n"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("VerifyPlaybook", func() {
+		It("should return an error when the play is not signed", func() {
+			err := playbooksigner.VerifyPlaybook([]byte("- name: no signature\n  vars:\n    number: 1\n"), openpgp.EntityList{currentKey})
+			Expect(err).To(MatchError(playbooksigner.ErrPlaybookNotSigned))
+		})
+
+		It("should not verify the static signature", func() {
+			Expect(playbooksigner.VerifyPlaybook([]byte(playbook), openpgp.EntityList{currentKey})).ToNot(Succeed())
+		})
+	})
+})
@@ -3,7 +3,6 @@ package services_test
 import (
 	"context"
 
-	"github.com/bxcodec/faker/v3"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo" // nolint: revive
 	. "github.com/onsi/gomega" // nolint: revive
@@ -52,8 +51,9 @@ var _ = Describe("Repo Service Test", func() {
 		})
 		When("is not valid with repo id that doesnt exist", func() {
 			It("repo id doesnt create and verify that repo id doesnt exist in db", func() {
-				id := faker.ID
-				uid := uint(id[0])
+				var lastRepo models.Repo
+				Expect(db.DB.Unscoped().Order("id DESC").Limit(1).Find(&lastRepo).Error).ToNot(HaveOccurred())
+				uid := lastRepo.ID + 1000
 				repo, err := repoService.GetRepoByID(&uid)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("record not found"))
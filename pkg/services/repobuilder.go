@@ -36,6 +36,7 @@ type RepoBuilderInterface interface {
 	CommitTarUpload(c *models.Commit, tarFileName string) error
 	CommitTarDelete(tarFileName string) error
 	RepoPullLocalStaticDeltas(u *models.Commit, o *models.Commit, uprepo string, oldrepo string) error
+	BuildStaticDelta(ctx context.Context, orgID string, fromCommit *models.Commit, toCommit *models.Commit) (*models.StaticDeltaState, error)
 }
 
 // RepoBuilder is the implementation of a RepoBuilderInterface
@@ -99,6 +100,17 @@ func (rb *RepoBuilder) BuildUpdateRepo(ctx context.Context, id uint) (*models.Up
 	update.Repo.URL = updateCommit.Repo.ContentURL(ctx)
 	rb.log.WithField("update_transaction", update).Info("UPGRADE: point update to commit repo")
 
+	// a ready static delta repo holds the update commit too, the devices running its from commit
+	// get the static delta and the other devices pull the update commit objects
+	staticDeltaURL, err := rb.updateStaticDeltaURL(update)
+	if err != nil {
+		return nil, err
+	}
+	if staticDeltaURL != "" {
+		update.Repo.URL = staticDeltaURL
+		rb.log.WithField("static_delta_url", staticDeltaURL).Info("UPGRADE: point update to static delta repo")
+	}
+
 	rb.log.WithField("repo", update.Repo.DistributionURL(ctx)).Info("Update repo URL")
 	update.Repo.Status = models.RepoStatusSuccess
 	if err := db.DB.Omit("Devices.*").Save(&update).Error; err != nil {
@@ -111,6 +123,40 @@ func (rb *RepoBuilder) BuildUpdateRepo(ctx context.Context, id uint) (*models.Up
 	return update, nil
 }
 
+// updateStaticDeltaURL returns the url of the ready static delta repo to the update commit from the commit run by
+// the most devices of the update, it is empty when there is none
+func (rb *RepoBuilder) updateStaticDeltaURL(update *models.UpdateTransaction) (string, error) {
+	if update.Commit.OSTreeCommit == "" || len(update.OldCommits) == 0 {
+		return "", nil
+	}
+	fromCommits := make([]string, 0, len(update.OldCommits))
+	for _, commit := range update.OldCommits {
+		fromCommits = append(fromCommits, commit.OSTreeCommit)
+	}
+	var staticDeltas []struct {
+		URL          string
+		DevicesCount int
+	}
+	if result := db.DB.Table("static_delta_states").
+		Select("static_delta_states.url, COUNT(DISTINCT updatetransaction_devices.device_id) AS devices_count").
+		Joins("LEFT JOIN commits ON commits.os_tree_commit = static_delta_states.from_commit AND commits.org_id = static_delta_states.org_id").
+		Joins("LEFT JOIN images ON images.commit_id = commits.id").
+		Joins("LEFT JOIN devices ON devices.image_id = images.id AND devices.deleted_at IS NULL").
+		Joins("LEFT JOIN updatetransaction_devices ON updatetransaction_devices.device_id = devices.id AND updatetransaction_devices.update_transaction_id = ?", update.ID).
+		Where("static_delta_states.org_id = ? AND static_delta_states.deleted_at IS NULL", update.OrgID).
+		Where("static_delta_states.status = ? AND static_delta_states.url <> ''", models.StaticDeltaStatusReady).
+		Where("static_delta_states.to_commit = ? AND static_delta_states.from_commit IN ?", update.Commit.OSTreeCommit, fromCommits).
+		Group("static_delta_states.url").Order("devices_count DESC, static_delta_states.url").Limit(1).
+		Scan(&staticDeltas); result.Error != nil {
+		rb.log.WithField("error", result.Error.Error()).Error("error occurred while getting update static deltas")
+		return "", result.Error
+	}
+	if len(staticDeltas) == 0 {
+		return "", nil
+	}
+	return staticDeltas[0].URL, nil
+}
+
 // StoreRepo requests Pulp to create/update an ostree repo from an IB commit
 func (rb *RepoBuilder) StoreRepo(ctx context.Context, imagesetID uint, repo *models.Repo) (*models.Repo, error) {
 	var cmt models.Commit
@@ -448,6 +494,106 @@ func (rb *RepoBuilder) RepoPullLocalStaticDeltas(u *models.Commit, o *models.Com
 	return nil
 }
 
+// BuildStaticDelta generates the static delta between two commits and uploads the repo holding it,
+// the progress of the generation is recorded in the static delta state
+func (rb *RepoBuilder) BuildStaticDelta(ctx context.Context, orgID string, fromCommit *models.Commit, toCommit *models.Commit) (*models.StaticDeltaState, error) {
+	if fromCommit == nil || toCommit == nil {
+		rb.log.Error("nil pointer to models.Commit provided")
+		return nil, errors.New("invalid Commit Provided: nil pointer")
+	}
+	logger := rb.log.WithFields(log.Fields{"from_commit": fromCommit.OSTreeCommit, "to_commit": toCommit.OSTreeCommit})
+	state := &models.StaticDeltaState{OrgID: orgID, Name: models.GetStaticDeltaName(fromCommit.OSTreeCommit, toCommit.OSTreeCommit)}
+	state, err := state.Query(logger)
+	if err != nil {
+		return nil, err
+	}
+	state.FromCommit, state.ToCommit, state.URL, state.Size = fromCommit.OSTreeCommit, toCommit.OSTreeCommit, "", 0
+	setStatus := func(status string) error {
+		state.Status = status
+		return state.Save(logger)
+	}
+	// fail records the error status of the static delta and returns the error that caused it
+	fail := func(err error) (*models.StaticDeltaState, error) {
+		logger.WithField("error", err.Error()).Error("error occurred while building static delta")
+		if saveErr := setStatus(models.StaticDeltaStatusError); saveErr != nil {
+			return nil, saveErr
+		}
+		return state, err
+	}
+
+	if err := setStatus(models.StaticDeltaStatusDownloading); err != nil {
+		return nil, err
+	}
+	path := filepath.Clean(filepath.Join(config.Get().RepoTempPath, "static-deltas", strconv.FormatUint(uint64(state.ID), 10)))
+	defer func() {
+		if err := os.RemoveAll(path); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while removing static delta directory")
+		}
+	}()
+	// the to commit repo is extracted into path/repo, the from commit repo into path/from/repo
+	fromPath := filepath.Join(path, "from")
+	for _, commitRepo := range []struct {
+		commit *models.Commit
+		dest   string
+	}{{toCommit, path}, {fromCommit, fromPath}} {
+		tarFileName, err := rb.CommitTarDownload(commitRepo.commit, commitRepo.dest)
+		if err != nil {
+			return fail(err)
+		}
+		if err := rb.CommitTarExtract(commitRepo.commit, tarFileName, commitRepo.dest); err != nil {
+			return fail(err)
+		}
+		if err := rb.CommitTarDelete(tarFileName); err != nil {
+			logger.WithField("error", err.Error()).Error("error occurred while deleting commit tarfile")
+		}
+	}
+
+	if err := setStatus(models.StaticDeltaStatusGenerating); err != nil {
+		return nil, err
+	}
+	repoPath := filepath.Join(path, "repo")
+	if err := rb.RepoPullLocalStaticDeltas(toCommit, fromCommit, repoPath, filepath.Join(fromPath, "repo")); err != nil {
+		return fail(err)
+	}
+	size, err := directorySize(filepath.Join(repoPath, "deltas"))
+	if err != nil {
+		return fail(err)
+	}
+
+	if err := setStatus(models.StaticDeltaStatusUploading); err != nil {
+		return nil, err
+	}
+	// the static delta repo is served to the devices as the repo of the updates from its from commit
+	repoURL, err := rb.FilesService.GetUploader().UploadRepo(repoPath, fmt.Sprintf("v2/%s/static-deltas/%d", orgID, state.ID), "public-read")
+	if err != nil {
+		return fail(err)
+	}
+
+	state.URL = repoURL
+	state.Size = size
+	if err := setStatus(models.StaticDeltaStatusReady); err != nil {
+		return nil, err
+	}
+	logger.WithFields(log.Fields{"size": size, "url": repoURL}).Info("static delta generated")
+
+	return state, nil
+}
+
+// directorySize returns the size in bytes of the files of a directory
+func directorySize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
 // RepoRevParse Handle the RevParse separate since we need the stdout parsed
 func RepoRevParse(path string, ref string) (string, error) {
 	cmd := BuildCommand("ostree", "rev-parse", "--repo", path, ref)
@@ -554,6 +554,62 @@ var _ = Describe("RepoBuilder Service Test", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("repo unavailable"))
 		})
+
+		Context("when the update has old commits", func() {
+			var updateCommit models.Commit
+			var staticDeltaURL string
+
+			BeforeEach(func() {
+				updateCommit = models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDDigit(),
+					Repo: &models.Repo{URL: "https://repos.example.com/commit/repo", Status: models.RepoStatusSuccess}}
+				Expect(db.DB.Create(&updateCommit).Error).ToNot(HaveOccurred())
+				oldCommits := []models.Commit{{OrgID: orgID, OSTreeCommit: faker.UUIDDigit()}, {OrgID: orgID, OSTreeCommit: faker.UUIDDigit()}}
+				Expect(db.DB.Create(&oldCommits).Error).ToNot(HaveOccurred())
+				var devices []models.Device
+				// two devices run the second old commit and one device runs the first one
+				for _, commit := range []models.Commit{oldCommits[0], oldCommits[1], oldCommits[1]} {
+					image := models.Image{OrgID: orgID, Name: faker.UUIDHyphenated(), CommitID: commit.ID}
+					Expect(db.DB.Create(&image).Error).ToNot(HaveOccurred())
+					device := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: image.ID}
+					Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+					devices = append(devices, device)
+				}
+				update = &models.UpdateTransaction{
+					OrgID:      orgID,
+					CommitID:   updateCommit.ID,
+					Repo:       &models.Repo{},
+					OldCommits: oldCommits,
+					Devices:    devices,
+				}
+				Expect(db.DB.Omit("OldCommits.*", "Devices.*").Create(update).Error).ToNot(HaveOccurred())
+
+				staticDeltaURL = "https://repos.example.com/v2/" + orgID + "/static-deltas/2/repo"
+				for i, commit := range oldCommits {
+					state := models.StaticDeltaState{
+						OrgID: orgID, Name: models.GetStaticDeltaName(commit.OSTreeCommit, updateCommit.OSTreeCommit),
+						FromCommit: commit.OSTreeCommit, ToCommit: updateCommit.OSTreeCommit, Status: models.StaticDeltaStatusReady,
+						URL: fmt.Sprintf("https://repos.example.com/v2/%s/static-deltas/%d/repo", orgID, i+1),
+					}
+					Expect(db.DB.Create(&state).Error).ToNot(HaveOccurred())
+				}
+			})
+
+			It("should point the update to the static delta repo of the most devices", func() {
+				result, err := services.NewRepoBuilder(ctx, log.NewEntry(log.StandardLogger())).BuildUpdateRepo(ctx, update.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Repo.URL).To(Equal(staticDeltaURL))
+				Expect(result.Repo.Status).To(Equal(models.RepoStatusSuccess))
+			})
+
+			It("should point the update to the commit repo when no static delta is ready", func() {
+				Expect(db.DB.Model(&models.StaticDeltaState{}).Where("org_id = ?", orgID).
+					Update("status", models.StaticDeltaStatusGenerating).Error).ToNot(HaveOccurred())
+
+				result, err := services.NewRepoBuilder(ctx, log.NewEntry(log.StandardLogger())).BuildUpdateRepo(ctx, update.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Repo.URL).To(Equal(updateCommit.Repo.URL))
+			})
+		})
 	})
 })
 
@@ -95,6 +95,25 @@ func PulpRepoStore(ctx context.Context, orgID string, imagesetID uint,
 	return pulpID, distBaseURL, nil
 }
 
+// PulpRepoDelete deletes the Pulp OSTree repository of an org
+func PulpRepoDelete(ctx context.Context, orgID string, pulpID string) error {
+	id, err := uuid.Parse(pulpID)
+	if err != nil {
+		return err
+	}
+	pserv, err := domainService(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := pserv.RepositoriesDelete(ctx, id); err != nil {
+		log.WithContext(ctx).WithFields(log.Fields{"pulp_id": pulpID, "error": err.Error()}).Error("Error deleting pulp ostree repository")
+		return err
+	}
+	log.WithContext(ctx).WithField("pulp_id", pulpID).Info("Pulp OSTree Repo deleted")
+
+	return nil
+}
+
 // looks up a domain, creates one if it does not exist, and returns a service using that domain
 func domainService(ctx context.Context, orgID string) (*pulp.PulpService, error) {
 	name := fmt.Sprintf("em%sd", orgID)
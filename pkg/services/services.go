@@ -4,7 +4,10 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -26,3 +29,23 @@ func NewService(ctx context.Context, log log.FieldLogger) Service {
 func (s *Service) SetLog(log log.FieldLogger) {
 	s.log = log
 }
+
+// contextWithPrincipal returns the service context with a service identity of the org acting on behalf of a principal,
+// used by the background processing configured by the principal. The identities of the principals are never stored
+func (s *Service) contextWithPrincipal(orgID string, principal string) (context.Context, error) {
+	if orgID == "" || principal == "" {
+		return nil, new(PrincipalUndefined)
+	}
+	id := identity.XRHID{Identity: identity.Identity{
+		OrgID:          orgID,
+		Type:           "ServiceAccount",
+		Internal:       identity.Internal{OrgID: orgID},
+		ServiceAccount: &identity.ServiceAccount{Username: principal},
+	}}
+	rawIdentity, err := json.Marshal(id)
+	if err != nil {
+		return nil, err
+	}
+	ctx := identity.WithIdentity(s.ctx, id)
+	return identity.WithRawIdentity(ctx, base64.StdEncoding.EncodeToString(rawIdentity)), nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/jobs"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// StaticDeltaServiceInterface defines the interface that helps handle the static deltas of the image versions
+type StaticDeltaServiceInterface interface {
+	PreGenerateStaticDeltas(orgID string, imageID uint) ([]models.StaticDeltaState, error)
+	PreGenerateStaticDeltasAsync(orgID string, imageID uint)
+	GetImageStaticDeltas(orgID string, imageID uint) ([]models.StaticDeltaState, error)
+}
+
+// NewStaticDeltaService gives an instance of the main implementation of StaticDeltaServiceInterface
+func NewStaticDeltaService(ctx context.Context, log log.FieldLogger) StaticDeltaServiceInterface {
+	return &StaticDeltaService{
+		Service:     Service{ctx: ctx, log: log.WithField("service", "static-delta")},
+		RepoBuilder: NewRepoBuilder(ctx, log),
+	}
+}
+
+// StaticDeltaService is the main implementation of a StaticDeltaServiceInterface
+type StaticDeltaService struct {
+	Service
+	RepoBuilder RepoBuilderInterface
+}
+
+// PreGenerateStaticDeltasJob is the job generating the static deltas of a new image version
+type PreGenerateStaticDeltasJob struct {
+	OrgID   string
+	ImageID uint
+}
+
+// PreGenerateStaticDeltasJobHandler generates the static deltas of a new image version
+func PreGenerateStaticDeltasJobHandler(ctx context.Context, job *jobs.Job) {
+	s := NewStaticDeltaService(ctx, log.StandardLogger().WithContext(ctx))
+	args := job.Args.(*PreGenerateStaticDeltasJob)
+	if _, err := s.PreGenerateStaticDeltas(args.OrgID, args.ImageID); err != nil {
+		log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when pre-generating static deltas")
+	}
+}
+
+func init() {
+	jobs.RegisterHandlers("PreGenerateStaticDeltasJob", PreGenerateStaticDeltasJobHandler, jobs.IgnoredJobHandler)
+}
+
+// PreGenerateStaticDeltasAsync generates asynchronously the static deltas of a new image version
+func (s *StaticDeltaService) PreGenerateStaticDeltasAsync(orgID string, imageID uint) {
+	if feature.JobQueue.IsEnabledCtx(s.ctx) {
+		err := jobs.NewAndEnqueue(s.ctx, "PreGenerateStaticDeltasJob", &PreGenerateStaticDeltasJob{OrgID: orgID, ImageID: imageID})
+		if err != nil {
+			log.WithContext(s.ctx).WithField("error", err.Error()).Error("Failed enqueueing job")
+		}
+	} else {
+		go func() {
+			if _, err := s.PreGenerateStaticDeltas(orgID, imageID); err != nil {
+				s.log.WithField("error", err.Error()).Error("error occurred when pre-generating static deltas")
+			}
+		}()
+	}
+}
+
+// PreGenerateStaticDeltas generates the static deltas to the image commit from the commits most devices of the image set run,
+// so that the first update to the image version does not wait for them. The static deltas already generated or being
+// generated are not generated again, a failing static delta does not prevent the others from being generated
+func (s *StaticDeltaService) PreGenerateStaticDeltas(orgID string, imageID uint) ([]models.StaticDeltaState, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "image_id": imageID})
+	count := config.Get().StaticDeltaPregenCount
+	if count <= 0 {
+		logger.Info("static deltas pre-generation is disabled")
+		return []models.StaticDeltaState{}, nil
+	}
+
+	var image models.Image
+	if result := db.Org(orgID, "images").Joins("Commit").First(&image, imageID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting image")
+		return nil, result.Error
+	}
+	if image.Commit == nil || image.ImageSetID == nil {
+		return []models.StaticDeltaState{}, nil
+	}
+
+	// the commits of the image set most run by the devices, excluding the new image commit
+	var fromCommits []models.Commit
+	if result := db.Org(orgID, "commits").Select("commits.*").
+		Joins("JOIN images ON images.commit_id = commits.id").
+		Joins("JOIN devices ON devices.image_id = images.id AND devices.deleted_at IS NULL").
+		Where("images.image_set_id = ? AND commits.id <> ? AND commits.os_tree_commit <> ?",
+			*image.ImageSetID, image.Commit.ID, image.Commit.OSTreeCommit).
+		Group("commits.id").Order("COUNT(devices.id) DESC, commits.id DESC").Limit(count).
+		Find(&fromCommits); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting devices commits")
+		return nil, result.Error
+	}
+
+	states := make([]models.StaticDeltaState, 0, len(fromCommits))
+	for _, fromCommit := range fromCommits {
+		fromCommit := fromCommit
+		state := &models.StaticDeltaState{OrgID: orgID, Name: models.GetStaticDeltaName(fromCommit.OSTreeCommit, image.Commit.OSTreeCommit)}
+		state, err := state.Query(logger)
+		if err != nil {
+			return nil, err
+		}
+		if state.Status != models.StaticDeltaStatusNotFound && state.Status != models.StaticDeltaStatusError {
+			states = append(states, *state)
+			continue
+		}
+		state, err = s.RepoBuilder.BuildStaticDelta(s.ctx, orgID, &fromCommit, image.Commit)
+		if err != nil {
+			logger.WithFields(log.Fields{"from_commit": fromCommit.OSTreeCommit, "error": err.Error()}).Error("error occurred while generating static delta")
+		}
+		if state != nil {
+			states = append(states, *state)
+		}
+	}
+	logger.WithField("static_deltas_count", len(states)).Info("static deltas pre-generated")
+
+	return states, nil
+}
+
+// GetImageStaticDeltas returns the static deltas to the image commit
+func (s *StaticDeltaService) GetImageStaticDeltas(orgID string, imageID uint) ([]models.StaticDeltaState, error) {
+	var image models.Image
+	if result := db.Org(orgID, "images").Joins("Commit").First(&image, imageID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageNotFoundError)
+		}
+		s.log.WithFields(log.Fields{"image_id": imageID, "error": result.Error.Error()}).Error("error occurred while getting image")
+		return nil, result.Error
+	}
+	states := []models.StaticDeltaState{}
+	if image.Commit == nil || image.Commit.OSTreeCommit == "" {
+		return states, nil
+	}
+	if result := db.Org(orgID, "").Where("to_commit = ?", image.Commit.OSTreeCommit).
+		Order("created_at DESC").Find(&states); result.Error != nil {
+		s.log.WithFields(log.Fields{"image_id": imageID, "error": result.Error.Error()}).Error("error occurred while getting image static deltas")
+		return nil, result.Error
+	}
+	return states, nil
+}
@@ -0,0 +1,161 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+var _ = Describe("StaticDeltaService", func() {
+	var ctrl *gomock.Controller
+	var mockRepoBuilder *mock_services.MockRepoBuilderInterface
+	var service services.StaticDeltaServiceInterface
+	var orgID string
+	var imageSet models.ImageSet
+	var image models.Image
+	var pregenCount int
+
+	createImage := func(devicesCount int) models.Image {
+		commit := models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+		versionImage := models.Image{OrgID: orgID, Name: imageSet.Name, ImageSetID: &imageSet.ID, Commit: &commit, Status: models.ImageStatusSuccess}
+		Expect(db.DB.Create(&versionImage).Error).ToNot(HaveOccurred())
+		for i := 0; i < devicesCount; i++ {
+			device := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: versionImage.ID}
+			Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+		}
+		return versionImage
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockRepoBuilder = mock_services.NewMockRepoBuilderInterface(ctrl)
+		service = &services.StaticDeltaService{
+			Service:     services.NewService(context.Background(), log.WithField("service", "static-delta")),
+			RepoBuilder: mockRepoBuilder,
+		}
+		orgID = faker.UUIDHyphenated()
+		imageSet = models.ImageSet{OrgID: orgID, Name: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+		pregenCount = config.Get().StaticDeltaPregenCount
+		config.Get().StaticDeltaPregenCount = 2
+	})
+
+	AfterEach(func() {
+		config.Get().StaticDeltaPregenCount = pregenCount
+		ctrl.Finish()
+	})
+
+	Context("PreGenerateStaticDeltas", func() {
+		var mostRunImage models.Image
+		var secondRunImage models.Image
+
+		BeforeEach(func() {
+			mostRunImage = createImage(3)
+			createImage(1)
+			secondRunImage = createImage(2)
+			image = createImage(0)
+		})
+
+		It("should generate the static deltas from the commits most run by the devices", func() {
+			for _, fromImage := range []models.Image{mostRunImage, secondRunImage} {
+				fromCommit := fromImage.Commit.OSTreeCommit
+				mockRepoBuilder.EXPECT().BuildStaticDelta(gomock.Any(), orgID, gomock.AssignableToTypeOf(&models.Commit{}), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, from *models.Commit, to *models.Commit) (*models.StaticDeltaState, error) {
+						Expect(from.OSTreeCommit).To(Equal(fromCommit))
+						Expect(to.OSTreeCommit).To(Equal(image.Commit.OSTreeCommit))
+						return &models.StaticDeltaState{OrgID: orgID, FromCommit: from.OSTreeCommit, ToCommit: to.OSTreeCommit, Status: models.StaticDeltaStatusReady}, nil
+					})
+			}
+
+			states, err := service.PreGenerateStaticDeltas(orgID, image.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(states).To(HaveLen(2))
+			Expect(states[0].FromCommit).To(Equal(mostRunImage.Commit.OSTreeCommit))
+			Expect(states[1].FromCommit).To(Equal(secondRunImage.Commit.OSTreeCommit))
+		})
+
+		It("should not generate again the static deltas already generated", func() {
+			readyState := models.StaticDeltaState{
+				OrgID:      orgID,
+				Name:       models.GetStaticDeltaName(mostRunImage.Commit.OSTreeCommit, image.Commit.OSTreeCommit),
+				FromCommit: mostRunImage.Commit.OSTreeCommit,
+				ToCommit:   image.Commit.OSTreeCommit,
+				Status:     models.StaticDeltaStatusReady,
+			}
+			Expect(db.DB.Create(&readyState).Error).ToNot(HaveOccurred())
+			mockRepoBuilder.EXPECT().BuildStaticDelta(gomock.Any(), orgID, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ string, from *models.Commit, to *models.Commit) (*models.StaticDeltaState, error) {
+					Expect(from.OSTreeCommit).To(Equal(secondRunImage.Commit.OSTreeCommit))
+					return &models.StaticDeltaState{OrgID: orgID, FromCommit: from.OSTreeCommit, Status: models.StaticDeltaStatusReady}, nil
+				})
+
+			states, err := service.PreGenerateStaticDeltas(orgID, image.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(states).To(HaveLen(2))
+			Expect(states[0].ID).To(Equal(readyState.ID))
+		})
+
+		It("should continue generating the static deltas when one fails", func() {
+			gomock.InOrder(
+				mockRepoBuilder.EXPECT().BuildStaticDelta(gomock.Any(), orgID, gomock.Any(), gomock.Any()).
+					Return(&models.StaticDeltaState{OrgID: orgID, Status: models.StaticDeltaStatusError}, errors.New("expected error")),
+				mockRepoBuilder.EXPECT().BuildStaticDelta(gomock.Any(), orgID, gomock.Any(), gomock.Any()).
+					Return(&models.StaticDeltaState{OrgID: orgID, Status: models.StaticDeltaStatusReady}, nil),
+			)
+
+			states, err := service.PreGenerateStaticDeltas(orgID, image.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(states).To(HaveLen(2))
+			Expect(states[0].Status).To(Equal(models.StaticDeltaStatusError))
+			Expect(states[1].Status).To(Equal(models.StaticDeltaStatusReady))
+		})
+
+		It("should not generate static deltas when the pre-generation count is zero", func() {
+			config.Get().StaticDeltaPregenCount = 0
+			states, err := service.PreGenerateStaticDeltas(orgID, image.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(states).To(BeEmpty())
+		})
+
+		It("should return image not found error when the image does not exist", func() {
+			_, err := service.PreGenerateStaticDeltas(faker.UUIDHyphenated(), image.ID)
+			Expect(err).To(MatchError(new(services.ImageNotFoundError)))
+		})
+	})
+
+	Context("GetImageStaticDeltas", func() {
+		BeforeEach(func() {
+			image = createImage(0)
+		})
+
+		It("should return the static deltas to the image commit", func() {
+			states := []models.StaticDeltaState{
+				{OrgID: orgID, Name: faker.UUIDHyphenated(), ToCommit: image.Commit.OSTreeCommit, Status: models.StaticDeltaStatusReady},
+				{OrgID: orgID, Name: faker.UUIDHyphenated(), ToCommit: faker.UUIDHyphenated(), Status: models.StaticDeltaStatusReady},
+				{OrgID: faker.UUIDHyphenated(), Name: faker.UUIDHyphenated(), ToCommit: image.Commit.OSTreeCommit, Status: models.StaticDeltaStatusReady},
+			}
+			Expect(db.DB.Create(&states).Error).ToNot(HaveOccurred())
+
+			imageStates, err := service.GetImageStaticDeltas(orgID, image.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageStates).To(HaveLen(1))
+			Expect(imageStates[0].ID).To(Equal(states[0].ID))
+		})
+
+		It("should return image not found error when the image does not exist", func() {
+			_, err := service.GetImageStaticDeltas(orgID, image.ID+1000)
+			Expect(err).To(MatchError(new(services.ImageNotFoundError)))
+		})
+	})
+})
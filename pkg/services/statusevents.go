@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// StatusEventsServiceInterface defines the interface that helps handle the images and updates status events
+type StatusEventsServiceInterface interface {
+	GetImageStatusEvents(orgID string, imageID uint) (*models.StatusEvents, error)
+	GetUpdateStatusEvents(orgID string, updateID uint) (*models.StatusEvents, error)
+}
+
+// NewStatusEventsService gives an instance of the main implementation of StatusEventsServiceInterface
+func NewStatusEventsService(ctx context.Context, log log.FieldLogger) StatusEventsServiceInterface {
+	return &StatusEventsService{
+		Service: Service{ctx: ctx, log: log.WithField("service", "status-events")},
+	}
+}
+
+// StatusEventsService is the main implementation of a StatusEventsServiceInterface
+// The status events are read from the database, this way all the replicas see the same changes
+// whichever replica has processed them
+type StatusEventsService struct {
+	Service
+}
+
+// GetImageStatusEvents returns the current status events of the image, commit and installer stages of an image
+func (s *StatusEventsService) GetImageStatusEvents(orgID string, imageID uint) (*models.StatusEvents, error) {
+	var image models.Image
+	if result := db.Org(orgID, "images").Joins("Commit").Joins("Installer").First(&image, imageID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageNotFoundError)
+		}
+		s.log.WithFields(log.Fields{"image_id": imageID, "error": result.Error.Error()}).Error("error occurred while getting image status")
+		return nil, result.Error
+	}
+
+	statusEvents := models.StatusEvents{
+		Events: []models.StatusEvent{{Type: models.StatusEventTypeImage, ID: image.ID, Status: image.Status}},
+	}
+	if image.Commit != nil {
+		statusEvents.Events = append(statusEvents.Events,
+			models.StatusEvent{Type: models.StatusEventTypeCommit, ID: image.Commit.ID, Status: image.Commit.Status})
+	}
+	if image.Installer != nil {
+		statusEvents.Events = append(statusEvents.Events,
+			models.StatusEvent{Type: models.StatusEventTypeInstaller, ID: image.Installer.ID, Status: image.Installer.Status})
+	}
+	statusEvents.Completed = image.Status == models.ImageStatusSuccess || image.Status == models.ImageStatusError
+
+	return &statusEvents, nil
+}
+
+// GetUpdateStatusEvents returns the current status events of an update and of its devices dispatch records
+func (s *StatusEventsService) GetUpdateStatusEvents(orgID string, updateID uint) (*models.StatusEvents, error) {
+	var update models.UpdateTransaction
+	if result := db.Org(orgID, "update_transactions").Preload("DispatchRecords.Device").First(&update, updateID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(UpdateNotFoundError)
+		}
+		s.log.WithFields(log.Fields{"update_id": updateID, "error": result.Error.Error()}).Error("error occurred while getting update status")
+		return nil, result.Error
+	}
+
+	statusEvents := models.StatusEvents{
+		Events: []models.StatusEvent{{Type: models.StatusEventTypeUpdate, ID: update.ID, Status: update.Status}},
+	}
+	completed := update.Status != models.UpdateStatusCreated && update.Status != models.UpdateStatusBuilding &&
+		update.Status != models.UpdateStatusAwaitingApproval
+	for _, dispatchRecord := range update.DispatchRecords {
+		statusEvent := models.StatusEvent{
+			Type:   models.StatusEventTypeDispatchRecord,
+			ID:     dispatchRecord.ID,
+			Status: dispatchRecord.Status,
+			Reason: dispatchRecord.Reason,
+		}
+		if dispatchRecord.Device != nil {
+			statusEvent.DeviceUUID = dispatchRecord.Device.UUID
+		}
+		statusEvents.Events = append(statusEvents.Events, statusEvent)
+		switch dispatchRecord.Status {
+		case models.DispatchRecordStatusCreated, models.DispatchRecordStatusPending, models.DispatchRecordStatusRunning:
+			completed = false
+		}
+	}
+	statusEvents.Completed = completed
+
+	return &statusEvents, nil
+}
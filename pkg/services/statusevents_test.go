@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"context"
+
+	"github.com/bxcodec/faker/v3"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+var _ = Describe("StatusEventsService", func() {
+	var service services.StatusEventsServiceInterface
+	orgID := faker.UUIDHyphenated()
+
+	BeforeEach(func() {
+		service = services.NewStatusEventsService(context.Background(), log.NewEntry(log.StandardLogger()))
+	})
+
+	Context("GetImageStatusEvents", func() {
+		It("should return the image, commit and installer status events", func() {
+			image := models.Image{
+				OrgID:     orgID,
+				Name:      faker.Name(),
+				Status:    models.ImageStatusBuilding,
+				Commit:    &models.Commit{OrgID: orgID, Status: models.ImageStatusSuccess},
+				Installer: &models.Installer{OrgID: orgID, Status: models.ImageStatusBuilding},
+			}
+			Expect(db.DB.Create(&image).Error).ToNot(HaveOccurred())
+
+			statusEvents, err := service.GetImageStatusEvents(orgID, image.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statusEvents.Completed).To(BeFalse())
+			Expect(statusEvents.Events).To(ConsistOf(
+				models.StatusEvent{Type: models.StatusEventTypeImage, ID: image.ID, Status: models.ImageStatusBuilding},
+				models.StatusEvent{Type: models.StatusEventTypeCommit, ID: image.Commit.ID, Status: models.ImageStatusSuccess},
+				models.StatusEvent{Type: models.StatusEventTypeInstaller, ID: image.Installer.ID, Status: models.ImageStatusBuilding},
+			))
+
+			Expect(db.DB.Model(&image).Update("status", models.ImageStatusSuccess).Error).ToNot(HaveOccurred())
+			statusEvents, err = service.GetImageStatusEvents(orgID, image.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statusEvents.Completed).To(BeTrue())
+		})
+
+		It("should return image not found for another org", func() {
+			image := models.Image{OrgID: orgID, Name: faker.Name(), Status: models.ImageStatusBuilding}
+			Expect(db.DB.Create(&image).Error).ToNot(HaveOccurred())
+
+			_, err := service.GetImageStatusEvents(faker.UUIDHyphenated(), image.ID)
+			Expect(err).To(MatchError(new(services.ImageNotFoundError)))
+		})
+	})
+
+	Context("GetUpdateStatusEvents", func() {
+		It("should return the update and dispatch records status events", func() {
+			device := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+			dispatchRecord := models.DispatchRecord{DeviceID: device.ID, Status: models.DispatchRecordStatusRunning}
+			update := models.UpdateTransaction{
+				OrgID:           orgID,
+				Status:          models.UpdateStatusSuccess,
+				DispatchRecords: []models.DispatchRecord{dispatchRecord},
+			}
+			Expect(db.DB.Create(&update).Error).ToNot(HaveOccurred())
+
+			statusEvents, err := service.GetUpdateStatusEvents(orgID, update.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statusEvents.Completed).To(BeFalse())
+			Expect(statusEvents.Events).To(ConsistOf(
+				models.StatusEvent{Type: models.StatusEventTypeUpdate, ID: update.ID, Status: models.UpdateStatusSuccess},
+				models.StatusEvent{
+					Type:       models.StatusEventTypeDispatchRecord,
+					ID:         update.DispatchRecords[0].ID,
+					Status:     models.DispatchRecordStatusRunning,
+					DeviceUUID: device.UUID,
+				},
+			))
+
+			Expect(db.DB.Model(&update.DispatchRecords[0]).Update("status", models.DispatchRecordStatusComplete).Error).ToNot(HaveOccurred())
+			statusEvents, err = service.GetUpdateStatusEvents(orgID, update.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statusEvents.Completed).To(BeTrue())
+		})
+
+		It("should return update not found for another org", func() {
+			update := models.UpdateTransaction{OrgID: orgID, Status: models.UpdateStatusBuilding}
+			Expect(db.DB.Create(&update).Error).ToNot(HaveOccurred())
+
+			_, err := service.GetUpdateStatusEvents(faker.UUIDHyphenated(), update.ID)
+			Expect(err).To(MatchError(new(services.UpdateNotFoundError)))
+		})
+	})
+})
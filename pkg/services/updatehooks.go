@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UpdateHooksServiceInterface defines the interface that helps handle the update hooks of image sets and device groups
+type UpdateHooksServiceInterface interface {
+	GetImageSetHooks(orgID string, imageSetID uint) ([]models.UpdateHook, error)
+	GetDeviceGroupHooks(orgID string, deviceGroupID uint) ([]models.UpdateHook, error)
+	CreateHook(orgID string, hook *models.UpdateHook) (*models.UpdateHook, error)
+	DeleteImageSetHook(orgID string, imageSetID uint, hookID uint) error
+	DeleteDeviceGroupHook(orgID string, deviceGroupID uint, hookID uint) error
+	GetUpdateTransactionHooks(update *models.UpdateTransaction) ([]models.UpdateHook, error)
+}
+
+// NewUpdateHooksService gives an instance of the main implementation of UpdateHooksServiceInterface
+func NewUpdateHooksService(ctx context.Context, log log.FieldLogger) UpdateHooksServiceInterface {
+	return &UpdateHooksService{
+		Service: Service{ctx: ctx, log: log.WithField("service", "update-hooks")},
+	}
+}
+
+// UpdateHooksService is the main implementation of a UpdateHooksServiceInterface
+type UpdateHooksService struct {
+	Service
+}
+
+// GetImageSetHooks returns the update hooks of an image set
+func (s *UpdateHooksService) GetImageSetHooks(orgID string, imageSetID uint) ([]models.UpdateHook, error) {
+	return s.getHooks(db.Org(orgID, "").Where("image_set_id = ?", imageSetID))
+}
+
+// GetDeviceGroupHooks returns the update hooks of a device group
+func (s *UpdateHooksService) GetDeviceGroupHooks(orgID string, deviceGroupID uint) ([]models.UpdateHook, error) {
+	return s.getHooks(db.Org(orgID, "").Where("device_group_id = ?", deviceGroupID))
+}
+
+func (s *UpdateHooksService) getHooks(query *gorm.DB) ([]models.UpdateHook, error) {
+	hooks := make([]models.UpdateHook, 0)
+	if result := query.Order("position ASC, id ASC").Find(&hooks); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting update hooks")
+		return nil, result.Error
+	}
+	return hooks, nil
+}
+
+// CreateHook validates and creates an update hook for an image set or a device group of the org
+func (s *UpdateHooksService) CreateHook(orgID string, hook *models.UpdateHook) (*models.UpdateHook, error) {
+	hook.OrgID = orgID
+	if err := hook.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	if hook.ImageSetID != nil {
+		var imageSet models.ImageSet
+		if result := db.Org(orgID, "").First(&imageSet, *hook.ImageSetID); result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil, new(ImageSetNotFoundError)
+			}
+			return nil, result.Error
+		}
+	}
+	if hook.DeviceGroupID != nil {
+		var deviceGroup models.DeviceGroup
+		if result := db.Org(orgID, "").First(&deviceGroup, *hook.DeviceGroupID); result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil, new(DeviceGroupNotFound)
+			}
+			return nil, result.Error
+		}
+	}
+	if result := db.DB.Create(hook); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while creating update hook")
+		return nil, result.Error
+	}
+	s.log.WithFields(log.Fields{"hook_id": hook.ID, "stage": hook.Stage}).Info("update hook created")
+	return hook, nil
+}
+
+// DeleteImageSetHook deletes an update hook of an image set
+func (s *UpdateHooksService) DeleteImageSetHook(orgID string, imageSetID uint, hookID uint) error {
+	return s.deleteHook(db.Org(orgID, "").Where("image_set_id = ?", imageSetID), hookID)
+}
+
+// DeleteDeviceGroupHook deletes an update hook of a device group
+func (s *UpdateHooksService) DeleteDeviceGroupHook(orgID string, deviceGroupID uint, hookID uint) error {
+	return s.deleteHook(db.Org(orgID, "").Where("device_group_id = ?", deviceGroupID), hookID)
+}
+
+func (s *UpdateHooksService) deleteHook(query *gorm.DB, hookID uint) error {
+	result := query.Delete(&models.UpdateHook{}, hookID)
+	if result.Error != nil {
+		s.log.WithFields(log.Fields{"hook_id": hookID, "error": result.Error.Error()}).Error("error occurred while deleting update hook")
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return new(UpdateHookNotFound)
+	}
+	s.log.WithField("hook_id", hookID).Info("update hook deleted")
+	return nil
+}
+
+// GetUpdateTransactionHooks returns the hooks to run for an update transaction,
+// the hooks of the image set of the update commit come first, followed by the hooks of the devices groups
+func (s *UpdateHooksService) GetUpdateTransactionHooks(update *models.UpdateTransaction) ([]models.UpdateHook, error) {
+	var imageSetIDs []uint
+	if result := db.Org(update.OrgID, "").Model(&models.Image{}).Where("commit_id = ? AND image_set_id IS NOT NULL", update.CommitID).
+		Distinct().Pluck("image_set_id", &imageSetIDs); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting update image set")
+		return nil, result.Error
+	}
+	hooks, err := s.getHooks(db.Org(update.OrgID, "").Where("image_set_id IN (?)", imageSetIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	devicesIDs := make([]uint, 0, len(update.Devices))
+	for _, device := range update.Devices {
+		devicesIDs = append(devicesIDs, device.ID)
+	}
+	var deviceGroupIDs []uint
+	if result := db.DB.Table("device_groups_devices").Where("device_id IN (?)", devicesIDs).
+		Distinct().Pluck("device_group_id", &deviceGroupIDs); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting update devices groups")
+		return nil, result.Error
+	}
+	groupsHooks, err := s.getHooks(db.Org(update.OrgID, "").Where("device_group_id IN (?)", deviceGroupIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(hooks, groupsHooks...), nil
+}
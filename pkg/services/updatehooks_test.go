@@ -0,0 +1,117 @@
+package services_test
+
+import (
+	"context"
+
+	"github.com/bxcodec/faker/v3"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+)
+
+var _ = Describe("UpdateHooksService", func() {
+	var service services.UpdateHooksServiceInterface
+	var orgID string
+	var imageSet models.ImageSet
+	var deviceGroup models.DeviceGroup
+
+	BeforeEach(func() {
+		service = services.NewUpdateHooksService(context.Background(), log.NewEntry(log.StandardLogger()))
+		orgID = faker.UUIDHyphenated()
+		imageSet = models.ImageSet{OrgID: orgID, Name: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+		deviceGroup = models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic}
+		Expect(db.DB.Create(&deviceGroup).Error).ToNot(HaveOccurred())
+	})
+
+	Context("CreateHook", func() {
+		It("should create and return the image set hooks in position order", func() {
+			second, err := service.CreateHook(orgID, &models.UpdateHook{
+				Name: "smoke-test", Stage: models.UpdateHookStagePost, Script: "true", Position: 2, ImageSetID: &imageSet.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			first, err := service.CreateHook(orgID, &models.UpdateHook{
+				Name: "drain", Stage: models.UpdateHookStagePre, Script: "true", Position: 1, ImageSetID: &imageSet.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			hooks, err := service.GetImageSetHooks(orgID, imageSet.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hooks).To(HaveLen(2))
+			Expect(hooks[0].ID).To(Equal(first.ID))
+			Expect(hooks[1].ID).To(Equal(second.ID))
+
+			hooks, err = service.GetImageSetHooks(faker.UUIDHyphenated(), imageSet.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hooks).To(BeEmpty())
+		})
+
+		It("should not create an invalid hook", func() {
+			_, err := service.CreateHook(orgID, &models.UpdateHook{Name: "drain", Stage: "during", Script: "true", ImageSetID: &imageSet.ID})
+			Expect(err).To(MatchError(models.UpdateHookStageInvalidErrorMessage))
+		})
+
+		It("should not create a hook for a device group of another org", func() {
+			_, err := service.CreateHook(faker.UUIDHyphenated(), &models.UpdateHook{
+				Name: "drain", Stage: models.UpdateHookStagePre, Script: "true", DeviceGroupID: &deviceGroup.ID,
+			})
+			Expect(err).To(MatchError(new(services.DeviceGroupNotFound)))
+		})
+	})
+
+	Context("DeleteDeviceGroupHook", func() {
+		It("should delete the device group hook", func() {
+			hook, err := service.CreateHook(orgID, &models.UpdateHook{
+				Name: "drain", Stage: models.UpdateHookStagePre, Script: "true", DeviceGroupID: &deviceGroup.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = service.DeleteImageSetHook(orgID, imageSet.ID, hook.ID)
+			Expect(err).To(MatchError(new(services.UpdateHookNotFound)))
+
+			err = service.DeleteDeviceGroupHook(orgID, deviceGroup.ID, hook.ID)
+			Expect(err).ToNot(HaveOccurred())
+			hooks, err := service.GetDeviceGroupHooks(orgID, deviceGroup.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hooks).To(BeEmpty())
+		})
+	})
+
+	Context("GetUpdateTransactionHooks", func() {
+		It("should return the image set hooks then the devices groups hooks", func() {
+			commit := models.Commit{OrgID: orgID}
+			Expect(db.DB.Create(&commit).Error).ToNot(HaveOccurred())
+			image := models.Image{OrgID: orgID, Name: imageSet.Name, CommitID: commit.ID, ImageSetID: &imageSet.ID}
+			Expect(db.DB.Create(&image).Error).ToNot(HaveOccurred())
+			device := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&device).Error).ToNot(HaveOccurred())
+			Expect(db.DB.Model(&deviceGroup).Omit("Devices.*").Association("Devices").Append(&device)).ToNot(HaveOccurred())
+			otherGroup := models.DeviceGroup{OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic}
+			Expect(db.DB.Create(&otherGroup).Error).ToNot(HaveOccurred())
+
+			groupHook, err := service.CreateHook(orgID, &models.UpdateHook{
+				Name: "group", Stage: models.UpdateHookStagePre, Script: "true", DeviceGroupID: &deviceGroup.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			imageSetHook, err := service.CreateHook(orgID, &models.UpdateHook{
+				Name: "image-set", Stage: models.UpdateHookStagePost, Script: "true", Position: 5, ImageSetID: &imageSet.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = service.CreateHook(orgID, &models.UpdateHook{
+				Name: "other-group", Stage: models.UpdateHookStagePre, Script: "true", DeviceGroupID: &otherGroup.ID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			update := models.UpdateTransaction{OrgID: orgID, CommitID: commit.ID, Devices: []models.Device{device}}
+			hooks, err := service.GetUpdateTransactionHooks(&update)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hooks).To(HaveLen(2))
+			Expect(hooks[0].ID).To(Equal(imageSetHook.ID))
+			Expect(hooks[1].ID).To(Equal(groupHook.ID))
+		})
+	})
+})
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/redhatinsights/edge-api/config"
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/jobs"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UpdatePolicyServiceInterface defines the interface that helps handling the device groups update policies
+type UpdatePolicyServiceInterface interface {
+	GetDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint) (*models.UpdatePolicy, error)
+	SetDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint, policy *models.UpdatePolicy) (*models.UpdatePolicy, error)
+	DeleteDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint) error
+	ScheduleImageUpdatePolicies(orgID string, imageID uint) ([]models.UpdatePolicyRun, error)
+	RunDueUpdatePolicies() error
+}
+
+// NewUpdatePolicyService gives an instance of the main implementation of UpdatePolicyServiceInterface
+func NewUpdatePolicyService(ctx context.Context, log log.FieldLogger) UpdatePolicyServiceInterface {
+	return &UpdatePolicyService{
+		Service:              Service{ctx: ctx, log: log.WithField("service", "update-policy")},
+		UpdateServiceFactory: NewUpdateService,
+	}
+}
+
+// UpdatePolicyService is the main implementation of a UpdatePolicyServiceInterface
+type UpdatePolicyService struct {
+	Service
+	// UpdateServiceFactory gives the update service creating the update transactions on behalf of the policy principal
+	UpdateServiceFactory func(ctx context.Context, log log.FieldLogger) UpdateServiceInterface
+}
+
+// UpdatePoliciesJob is the job running the update policies runs that are due
+type UpdatePoliciesJob struct{}
+
+// UpdatePoliciesJobHandler runs the update policies runs that are due
+func UpdatePoliciesJobHandler(ctx context.Context, _ *jobs.Job) {
+	if !feature.UpdatePolicies.IsEnabled() {
+		log.WithContext(ctx).Warning("update policies feature flag is disabled")
+		return
+	}
+	s := NewUpdatePolicyService(ctx, log.StandardLogger().WithContext(ctx))
+	if err := s.RunDueUpdatePolicies(); err != nil {
+		log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when running update policies")
+	}
+}
+
+func init() {
+	jobs.RegisterHandlers("UpdatePoliciesJob", UpdatePoliciesJobHandler, jobs.IgnoredJobHandler)
+}
+
+// ScheduleUpdatePoliciesJob runs periodically the update policies runs that are due until the context is done
+func ScheduleUpdatePoliciesJob(ctx context.Context) {
+	interval := config.Get().UpdatePolicyInterval
+	if interval <= 0 {
+		log.WithContext(ctx).Info("update policies job is disabled")
+		return
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if feature.JobQueue.IsEnabledCtx(ctx) {
+			if err := jobs.NewAndEnqueue(ctx, "UpdatePoliciesJob", &UpdatePoliciesJob{}); err != nil {
+				log.WithContext(ctx).WithField("error", err.Error()).Error("Failed enqueueing job")
+			}
+		} else {
+			UpdatePoliciesJobHandler(ctx, nil)
+		}
+	}
+}
+
+// GetDeviceGroupUpdatePolicy returns the update policy of a device group
+func (s *UpdatePolicyService) GetDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint) (*models.UpdatePolicy, error) {
+	var policy models.UpdatePolicy
+	if result := db.Org(orgID, "").Where("device_group_id = ?", deviceGroupID).First(&policy); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(UpdatePolicyNotFound)
+		}
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "device_group_id": deviceGroupID}).Error("error getting update policy")
+		return nil, result.Error
+	}
+	return &policy, nil
+}
+
+// SetDeviceGroupUpdatePolicy creates or replaces the update policy of a device group. The update transactions of the policy
+// are created on behalf of the principal setting it
+func (s *UpdatePolicyService) SetDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint, policy *models.UpdatePolicy) (*models.UpdatePolicy, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "device_group_id": deviceGroupID, "image_set_id": policy.ImageSetID})
+	if err := policy.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	var imageSet models.ImageSet
+	if result := db.Org(orgID, "").First(&imageSet, policy.ImageSetID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageSetNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error getting image-set")
+		return nil, result.Error
+	}
+
+	existing, err := s.GetDeviceGroupUpdatePolicy(orgID, deviceGroupID)
+	if err != nil {
+		if _, ok := err.(*UpdatePolicyNotFound); !ok {
+			return nil, err
+		}
+		existing = &models.UpdatePolicy{OrgID: orgID, DeviceGroupID: deviceGroupID}
+	}
+	existing.ImageSetID = policy.ImageSetID
+	existing.Schedule = policy.Schedule
+	existing.WindowStart = policy.WindowStart
+	existing.WindowEnd = policy.WindowEnd
+	existing.SoakHours = policy.SoakHours
+	existing.Principal = common.GetParsedIdentityPrincipal(s.ctx)
+	if result := db.DB.Save(existing); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error saving update policy")
+		return nil, result.Error
+	}
+	// the scheduled runs follow the previous policy
+	if err := s.supersedeScheduledRuns(existing.ID, "the update policy changed"); err != nil {
+		logger.WithField("error", err.Error()).Error("error superseding update policy runs")
+		return nil, err
+	}
+	logger.WithField("schedule", existing.Schedule).Info("device group update policy set")
+
+	return existing, nil
+}
+
+// DeleteDeviceGroupUpdatePolicy deletes the update policy of a device group and cancels its scheduled runs
+func (s *UpdatePolicyService) DeleteDeviceGroupUpdatePolicy(orgID string, deviceGroupID uint) error {
+	policy, err := s.GetDeviceGroupUpdatePolicy(orgID, deviceGroupID)
+	if err != nil {
+		return err
+	}
+	if result := db.DB.Delete(policy); result.Error != nil {
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "device_group_id": deviceGroupID}).Error("error deleting update policy")
+		return result.Error
+	}
+	return s.supersedeScheduledRuns(policy.ID, "the update policy was deleted")
+}
+
+func (s *UpdatePolicyService) supersedeScheduledRuns(policyID uint, reason string) error {
+	return db.DB.Model(&models.UpdatePolicyRun{}).
+		Where("policy_id = ? AND status = ?", policyID, models.UpdatePolicyRunStatusScheduled).
+		Updates(map[string]interface{}{"status": models.UpdatePolicyRunStatusSuperseded, "reason": reason}).Error
+}
+
+// ScheduleImageUpdatePolicies schedules the runs of the update policies of the image set of a successful image version.
+// A run replaces the scheduled runs of the same policy, the runs due immediately are run before returning
+func (s *UpdatePolicyService) ScheduleImageUpdatePolicies(orgID string, imageID uint) ([]models.UpdatePolicyRun, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "image_id": imageID})
+	var image models.Image
+	if result := db.Org(orgID, "").First(&image, imageID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error getting image")
+		return nil, result.Error
+	}
+	if image.ImageSetID == nil || image.Status != models.ImageStatusSuccess {
+		return nil, nil
+	}
+
+	var policies []models.UpdatePolicy
+	if result := db.Org(orgID, "").Where("image_set_id = ?", *image.ImageSetID).Find(&policies); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error getting update policies")
+		return nil, result.Error
+	}
+	now := time.Now().UTC()
+	runs := make([]models.UpdatePolicyRun, 0, len(policies))
+	for _, policy := range policies {
+		if err := s.supersedeScheduledRuns(policy.ID, "a newer image set version succeeded"); err != nil {
+			logger.WithField("error", err.Error()).Error("error superseding update policy runs")
+			return nil, err
+		}
+		run := models.UpdatePolicyRun{
+			OrgID:       orgID,
+			PolicyID:    policy.ID,
+			ImageID:     image.ID,
+			Status:      models.UpdatePolicyRunStatusScheduled,
+			ScheduledAt: policy.NextRun(now),
+		}
+		if result := db.DB.Create(&run); result.Error != nil {
+			logger.WithField("error", result.Error.Error()).Error("error creating update policy run")
+			return nil, result.Error
+		}
+		logger.WithFields(log.Fields{"policy_id": policy.ID, "scheduled_at": run.ScheduledAt}).Info("update policy run scheduled")
+		runs = append(runs, run)
+	}
+
+	for i := range runs {
+		if runs[i].ScheduledAt.After(now) {
+			continue
+		}
+		if err := s.runUpdatePolicy(&runs[i]); err != nil {
+			logger.WithFields(log.Fields{"error": err.Error(), "policy_run_id": runs[i].ID}).Error("error running update policy")
+		}
+	}
+
+	return runs, nil
+}
+
+// RunDueUpdatePolicies runs the scheduled update policies runs of all the orgs that are due
+func (s *UpdatePolicyService) RunDueUpdatePolicies() error {
+	var runs []models.UpdatePolicyRun
+	if result := db.DB.Where("status = ? AND scheduled_at <= ?", models.UpdatePolicyRunStatusScheduled, time.Now().UTC()).
+		Order("scheduled_at").Find(&runs); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error getting due update policy runs")
+		return result.Error
+	}
+	for i := range runs {
+		if err := s.runUpdatePolicy(&runs[i]); err != nil {
+			s.log.WithFields(log.Fields{"error": err.Error(), "policy_run_id": runs[i].ID}).Error("error running update policy")
+		}
+	}
+	return nil
+}
+
+// runUpdatePolicy creates the update transactions of the group devices running an older version of the image set.
+// The run is claimed first so that it is run only once when several replicas run the due runs
+func (s *UpdatePolicyService) runUpdatePolicy(run *models.UpdatePolicyRun) error {
+	logger := s.log.WithFields(log.Fields{"org_id": run.OrgID, "policy_id": run.PolicyID, "policy_run_id": run.ID})
+	result := db.DB.Model(&models.UpdatePolicyRun{}).
+		Where("id = ? AND status = ?", run.ID, models.UpdatePolicyRunStatusScheduled).
+		Update("status", models.UpdatePolicyRunStatusRunning)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logger.Debug("update policy run already claimed")
+		return nil
+	}
+	run.Status = models.UpdatePolicyRunStatusRunning
+
+	updates, err := s.createPolicyUpdates(run)
+	if err != nil {
+		s.finishRun(run, models.UpdatePolicyRunStatusError, err.Error())
+		return err
+	}
+	if run.Status == models.UpdatePolicyRunStatusScheduled {
+		// rescheduled to the next maintenance window
+		return nil
+	}
+	logger.WithField("updates_count", len(updates)).Info("update policy run done")
+	s.finishRun(run, models.UpdatePolicyRunStatusDone, "")
+	return nil
+}
+
+func (s *UpdatePolicyService) finishRun(run *models.UpdatePolicyRun, status string, reason string) {
+	run.Status = status
+	run.Reason = reason
+	if result := db.DB.Model(run).Select("status", "reason").Updates(run); result.Error != nil {
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "policy_run_id": run.ID}).Error("error saving update policy run")
+	}
+}
+
+func (s *UpdatePolicyService) createPolicyUpdates(run *models.UpdatePolicyRun) ([]models.UpdateTransaction, error) {
+	var policy models.UpdatePolicy
+	if result := db.Org(run.OrgID, "").First(&policy, run.PolicyID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(UpdatePolicyNotFound)
+		}
+		return nil, result.Error
+	}
+	now := time.Now().UTC()
+	if policy.Schedule == models.UpdatePolicyScheduleWindow {
+		// the run is late, e.g. when the job did not run during the window
+		if next := policy.NextWindowStart(now); !next.Equal(now) {
+			run.Status = models.UpdatePolicyRunStatusScheduled
+			run.ScheduledAt = next
+			return nil, db.DB.Model(run).Select("status", "scheduled_at").Updates(run).Error
+		}
+	}
+
+	var image models.Image
+	if result := db.Org(run.OrgID, "").Preload("Commit").First(&image, run.ImageID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(ImageNotFoundError)
+		}
+		return nil, result.Error
+	}
+	var devicesUUID []string
+	if result := db.Org(run.OrgID, "devices").Model(&models.Device{}).
+		Joins("JOIN device_groups_devices ON device_groups_devices.device_id = devices.id").
+		Joins("JOIN images ON images.id = devices.image_id").
+		Where("device_groups_devices.device_group_id = ? AND images.image_set_id = ? AND images.version < ?",
+			policy.DeviceGroupID, policy.ImageSetID, image.Version).
+		// the stale devices are not updated automatically
+		Where("devices.staleness <> ?", models.DeviceStalenessStale).
+		Pluck("devices.uuid", &devicesUUID); result.Error != nil {
+		return nil, result.Error
+	}
+	if len(devicesUUID) == 0 {
+		return nil, nil
+	}
+
+	ctx, err := s.contextWithPrincipal(policy.OrgID, policy.Principal)
+	if err != nil {
+		return nil, err
+	}
+	updateService := s.UpdateServiceFactory(ctx, s.log)
+	updates, err := updateService.BuildUpdateTransactions(ctx,
+		&models.DevicesUpdate{CommitID: image.CommitID, DevicesUUID: devicesUUID}, run.OrgID, image.Commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, update := range *updates {
+		if result := db.DB.Model(&models.UpdateTransaction{}).Where("id = ?", update.ID).
+			Update("update_policy_id", policy.ID); result.Error != nil {
+			return nil, result.Error
+		}
+		// updates awaiting approval are started once approved
+		if update.Status != models.UpdateStatusDeviceDisconnected && update.Status != models.UpdateStatusAwaitingApproval {
+			updateService.CreateUpdateAsync(update.ID)
+		}
+	}
+	return *updates, nil
+}
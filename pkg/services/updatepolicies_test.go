@@ -0,0 +1,196 @@
+package services_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo" // nolint: revive
+	. "github.com/onsi/gomega" // nolint: revive
+	"github.com/redhatinsights/platform-go-middlewares/v2/identity"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhatinsights/edge-api/pkg/db"
+	"github.com/redhatinsights/edge-api/pkg/models"
+	"github.com/redhatinsights/edge-api/pkg/services"
+	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+)
+
+var _ = Describe("UpdatePolicyService", func() {
+	var ctrl *gomock.Controller
+	var mockUpdateService *mock_services.MockUpdateServiceInterface
+	var service services.UpdatePolicyServiceInterface
+	var updateServiceCtx context.Context
+	var orgID string
+	var imageSet models.ImageSet
+	var deviceGroup models.DeviceGroup
+	var images []models.Image
+	var outdatedDevice models.Device
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockUpdateService = mock_services.NewMockUpdateServiceInterface(ctrl)
+		orgID = faker.UUIDHyphenated()
+		rawIdentity, err := json.Marshal(identity.XRHID{Identity: identity.Identity{OrgID: orgID, User: &identity.User{Username: "admin"}}})
+		Expect(err).ToNot(HaveOccurred())
+		ctx := identity.WithRawIdentity(context.Background(), base64.StdEncoding.EncodeToString(rawIdentity))
+		service = &services.UpdatePolicyService{
+			Service: services.NewService(ctx, log.WithField("service", "update-policy")),
+			UpdateServiceFactory: func(ctx context.Context, _ log.FieldLogger) services.UpdateServiceInterface {
+				updateServiceCtx = ctx
+				return mockUpdateService
+			},
+		}
+
+		imageSet = models.ImageSet{OrgID: orgID, Name: faker.UUIDHyphenated()}
+		Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+		images = make([]models.Image, 2)
+		for i := range images {
+			images[i] = models.Image{
+				OrgID: orgID, Name: imageSet.Name, ImageSetID: &imageSet.ID, Version: i + 1, Status: models.ImageStatusSuccess,
+				Commit: &models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDDigit()},
+			}
+			Expect(db.DB.Create(&images[i]).Error).ToNot(HaveOccurred())
+		}
+		outdatedDevice = models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: images[0].ID}
+		upToDateDevice := models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: images[1].ID}
+		deviceGroup = models.DeviceGroup{
+			OrgID: orgID, Name: faker.UUIDHyphenated(), Type: models.DeviceGroupTypeStatic,
+			Devices: []models.Device{outdatedDevice, upToDateDevice},
+		}
+		Expect(db.DB.Create(&deviceGroup).Error).ToNot(HaveOccurred())
+		outdatedDevice = deviceGroup.Devices[0]
+		// a device out of the group is not updated
+		Expect(db.DB.Create(&models.Device{OrgID: orgID, UUID: faker.UUIDHyphenated(), ImageID: images[0].ID}).Error).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("SetDeviceGroupUpdatePolicy", func() {
+		It("should create and then replace the device group update policy", func() {
+			policy, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID, Schedule: models.UpdatePolicyScheduleImmediate})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.OrgID).To(Equal(orgID))
+			Expect(policy.Principal).ToNot(BeEmpty())
+
+			replaced, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID, Schedule: models.UpdatePolicyScheduleSoak, SoakHours: 24})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replaced.ID).To(Equal(policy.ID))
+
+			current, err := service.GetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current.Schedule).To(Equal(models.UpdatePolicyScheduleSoak))
+			Expect(current.SoakHours).To(Equal(24))
+		})
+
+		It("should return image set not found", func() {
+			_, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID + 1000, Schedule: models.UpdatePolicyScheduleImmediate})
+			Expect(err).To(MatchError(new(services.ImageSetNotFoundError)))
+		})
+
+		It("should return not found once the policy is deleted", func() {
+			_, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID, Schedule: models.UpdatePolicyScheduleImmediate})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.DeleteDeviceGroupUpdatePolicy(orgID, deviceGroup.ID)).To(Succeed())
+			_, err = service.GetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID)
+			Expect(err).To(MatchError(new(services.UpdatePolicyNotFound)))
+		})
+	})
+
+	Context("ScheduleImageUpdatePolicies", func() {
+		It("should update the outdated group devices immediately", func() {
+			policy, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID, Schedule: models.UpdatePolicyScheduleImmediate})
+			Expect(err).ToNot(HaveOccurred())
+			var update models.UpdateTransaction
+			mockUpdateService.EXPECT().BuildUpdateTransactions(gomock.Any(), gomock.Any(), orgID, gomock.Any()).DoAndReturn(
+				func(_ context.Context, devicesUpdate *models.DevicesUpdate, orgID string, commit *models.Commit) (*[]models.UpdateTransaction, error) {
+					Expect(devicesUpdate.CommitID).To(Equal(images[1].CommitID))
+					Expect(devicesUpdate.DevicesUUID).To(Equal([]string{outdatedDevice.UUID}))
+					Expect(commit.ID).To(Equal(images[1].CommitID))
+					update = models.UpdateTransaction{OrgID: orgID, CommitID: commit.ID, Status: models.UpdateStatusCreated}
+					Expect(db.DB.Create(&update).Error).ToNot(HaveOccurred())
+					return &[]models.UpdateTransaction{update}, nil
+				})
+			mockUpdateService.EXPECT().CreateUpdateAsync(gomock.Any()).Do(func(id uint) {
+				Expect(id).To(Equal(update.ID))
+			})
+
+			runs, err := service.ScheduleImageUpdatePolicies(orgID, images[1].ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(HaveLen(1))
+			updateIdentity := identity.GetIdentity(updateServiceCtx).Identity
+			Expect(updateIdentity.OrgID).To(Equal(orgID))
+			Expect(updateIdentity.ServiceAccount.Username).To(Equal(policy.Principal))
+
+			var run models.UpdatePolicyRun
+			Expect(db.DB.First(&run, runs[0].ID).Error).ToNot(HaveOccurred())
+			Expect(run.Status).To(Equal(models.UpdatePolicyRunStatusDone))
+			Expect(db.DB.First(&update, update.ID).Error).ToNot(HaveOccurred())
+			Expect(update.UpdatePolicyID).ToNot(BeNil())
+			Expect(*update.UpdatePolicyID).To(Equal(policy.ID))
+		})
+
+		It("should fail the run when the principal of the policy is unknown", func() {
+			policy, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID, Schedule: models.UpdatePolicyScheduleImmediate})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.DB.Model(policy).Update("principal", "").Error).ToNot(HaveOccurred())
+
+			runs, err := service.ScheduleImageUpdatePolicies(orgID, images[1].ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(HaveLen(1))
+
+			var run models.UpdatePolicyRun
+			Expect(db.DB.First(&run, runs[0].ID).Error).ToNot(HaveOccurred())
+			Expect(run.Status).To(Equal(models.UpdatePolicyRunStatusError))
+			Expect(run.Reason).To(Equal(services.PrincipalUndefinedMsg))
+		})
+
+		It("should schedule the soak policy run and supersede it with a newer version", func() {
+			_, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID, Schedule: models.UpdatePolicyScheduleSoak, SoakHours: 24})
+			Expect(err).ToNot(HaveOccurred())
+
+			runs, err := service.ScheduleImageUpdatePolicies(orgID, images[0].ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Status).To(Equal(models.UpdatePolicyRunStatusScheduled))
+			Expect(runs[0].ScheduledAt).To(BeTemporally(">", time.Now().Add(23*time.Hour)))
+
+			newRuns, err := service.ScheduleImageUpdatePolicies(orgID, images[1].ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(newRuns).To(HaveLen(1))
+			var run models.UpdatePolicyRun
+			Expect(db.DB.First(&run, runs[0].ID).Error).ToNot(HaveOccurred())
+			Expect(run.Status).To(Equal(models.UpdatePolicyRunStatusSuperseded))
+		})
+	})
+
+	Context("RunDueUpdatePolicies", func() {
+		It("should run the scheduled runs once due", func() {
+			_, err := service.SetDeviceGroupUpdatePolicy(orgID, deviceGroup.ID,
+				&models.UpdatePolicy{ImageSetID: imageSet.ID, Schedule: models.UpdatePolicyScheduleSoak, SoakHours: 24})
+			Expect(err).ToNot(HaveOccurred())
+			runs, err := service.ScheduleImageUpdatePolicies(orgID, images[1].ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.DB.Model(&runs[0]).Update("scheduled_at", time.Now().Add(-time.Minute)).Error).ToNot(HaveOccurred())
+			mockUpdateService.EXPECT().BuildUpdateTransactions(gomock.Any(), gomock.Any(), orgID, gomock.Any()).
+				Return(&[]models.UpdateTransaction{}, nil)
+
+			Expect(service.RunDueUpdatePolicies()).To(Succeed())
+			var run models.UpdatePolicyRun
+			Expect(db.DB.First(&run, runs[0].ID).Error).ToNot(HaveOccurred())
+			Expect(run.Status).To(Equal(models.UpdatePolicyRunStatusDone))
+		})
+	})
+})
@@ -2,6 +2,7 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -25,6 +26,7 @@ import (
 	"github.com/redhatinsights/edge-api/pkg/jobs"
 	"github.com/redhatinsights/edge-api/pkg/models"
 	"github.com/redhatinsights/edge-api/pkg/routes/common"
+	"github.com/redhatinsights/edge-api/pkg/services/playbooksigner"
 	feature "github.com/redhatinsights/edge-api/unleash/features"
 
 	"github.com/redhatinsights/edge-api/config"
@@ -51,6 +53,14 @@ type UpdateServiceInterface interface {
 	ValidateUpdateSelection(orgID string, imageIds []uint) (bool, error) // nolint:revive
 	ValidateUpdateDeviceGroup(orgID string, deviceGroupID uint) (bool, error)
 	InventoryGroupDevicesUpdateInfo(orgID string, inventoryGroupUUID string) (*models.InventoryGroupDevicesUpdateInfo, error)
+	PreviewUpdate(orgID string, devicesUUID []string, commit *models.Commit, includeStale bool) (*models.UpdatePreview, error)
+	RetryUpdateFailedDevices(orgID string, updateID uint) (*models.UpdateTransaction, error)
+	SetUnresponsiveDispatchRecords() error
+	GetUpdateRollbacks(orgID string, updateID uint) ([]models.UpdateRollback, error)
+	ApproveUpdate(orgID string, updateID uint) (*models.UpdateTransaction, error)
+	RejectUpdate(orgID string, updateID uint) (*models.UpdateTransaction, error)
+	GetUpdateApprovalSettings(orgID string) (*models.UpdateApprovalSettings, error)
+	SetUpdateApprovalSettings(orgID string, settings *models.UpdateApprovalSettings) (*models.UpdateApprovalSettings, error)
 }
 
 // NewUpdateService gives an instance of the main implementation of a UpdateServiceInterface
@@ -93,6 +103,9 @@ type playbooks struct {
 	RepoContentURL       string
 	RemoteOstreeUpdate   string
 	OSTreeRef            string
+	OSTreeCommit         string
+	PreUpdateHooks       string
+	PostUpdateHooks      string
 }
 
 // TemplateRemoteInfo the values to playbook
@@ -104,6 +117,15 @@ type TemplateRemoteInfo struct {
 	UpdateTransactionID uint
 	RemoteOstreeUpdate  string
 	OSTreeRef           string
+	OSTreeCommit        string
+	PreUpdateHooks      []models.UpdateHook
+	PostUpdateHooks     []models.UpdateHook
+}
+
+// playbookHook is an update hook as rendered in the playbook variables
+type playbookHook struct {
+	Name   string `json:"name"`
+	Script string `json:"script"`
 }
 
 // PlaybookDispatcherEventPayload belongs to PlaybookDispatcherEvent
@@ -130,6 +152,9 @@ type PlaybookDispatcherEvent struct {
 	Payload   PlaybookDispatcherEventPayload `json:"payload"`
 }
 
+// UpdatePreviewPackageDownloadSize is the average size in bytes used to estimate the download of an added or upgraded package
+const UpdatePreviewPackageDownloadSize int64 = 1024 * 1024
+
 type CreateUpdateAsyncJob struct {
 	UpdateID uint
 }
@@ -140,8 +165,44 @@ func CreateUpdateAsyncJobHandler(ctx context.Context, job *jobs.Job) {
 	s.createUpdate(ctx, args.UpdateID)
 }
 
+type UnresponsiveDispatchRecordsJob struct {
+}
+
+func UnresponsiveDispatchRecordsJobHandler(ctx context.Context, _ *jobs.Job) {
+	s := NewUpdateService(ctx, log.StandardLogger().WithContext(ctx))
+	if err := s.SetUnresponsiveDispatchRecords(); err != nil {
+		log.WithContext(ctx).WithField("error", err.Error()).Error("error occurred when setting unresponsive dispatch records")
+	}
+}
+
 func init() {
 	jobs.RegisterHandlers("CreateUpdateAsyncJob", CreateUpdateAsyncJobHandler, jobs.IgnoredJobHandler)
+	jobs.RegisterHandlers("UnresponsiveDispatchRecordsJob", UnresponsiveDispatchRecordsJobHandler, jobs.IgnoredJobHandler)
+}
+
+// ScheduleUnresponsiveDispatchRecordsJob looks periodically for unresponsive dispatch records until the context is done
+func ScheduleUnresponsiveDispatchRecordsJob(ctx context.Context) {
+	interval := config.Get().DispatchRecordInterval
+	if interval <= 0 {
+		log.WithContext(ctx).Info("unresponsive dispatch records job is disabled")
+		return
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if feature.JobQueue.IsEnabledCtx(ctx) {
+			if err := jobs.NewAndEnqueue(ctx, "UnresponsiveDispatchRecordsJob", &UnresponsiveDispatchRecordsJob{}); err != nil {
+				log.WithContext(ctx).WithField("error", err.Error()).Error("Failed enqueueing job")
+			}
+		} else {
+			UnresponsiveDispatchRecordsJobHandler(ctx, nil)
+		}
+	}
 }
 
 // CreateUpdateAsync is the function that creates an update transaction asynchronously
@@ -214,6 +275,10 @@ func (s *UpdateService) CreateUpdate(ctx context.Context, id uint) (*models.Upda
 		}
 		return nil, result.Error
 	}
+	if update.Status == models.UpdateStatusAwaitingApproval || update.Status == models.UpdateStatusRejected {
+		s.log.WithField("status", update.Status).Error("update is not approved")
+		return nil, new(UpdateNotApproved)
+	}
 
 	update.Status = models.UpdateStatusBuilding
 	if result := db.DB.Model(&models.UpdateTransaction{}).Where("ID=?", id).Update("Status", update.Status); result.Error != nil {
@@ -221,13 +286,18 @@ func (s *UpdateService) CreateUpdate(ctx context.Context, id uint) (*models.Upda
 		return nil, result.Error
 	}
 
-	update, err = s.BuildUpdateRepo(ctx, orgID, id)
-	if err != nil {
-		s.log.WithField("error", err.Error()).Error("error when building update repo")
-		return nil, err
-	}
+	if update.ParentID != nil && update.Repo != nil && update.Repo.Status == models.RepoStatusSuccess {
+		// a retry of failed devices reuses the repo already built for the parent update
+		s.log.WithField("parent_id", *update.ParentID).Info("UPGRADE: reusing parent update repo")
+	} else {
+		update, err = s.BuildUpdateRepo(ctx, orgID, id)
+		if err != nil {
+			s.log.WithField("error", err.Error()).Error("error when building update repo")
+			return nil, err
+		}
 
-	s.log.WithField("update_transaction", update).Info("UPGRADE: update repo built")
+		s.log.WithField("update_transaction", update).Info("UPGRADE: update repo built")
+	}
 
 	// below code wil be refactored in its own function when WriteTemplateRequested event will be implemented
 
@@ -247,6 +317,20 @@ func (s *UpdateService) CreateUpdate(ctx context.Context, id uint) (*models.Upda
 	go s.SetUpdateErrorStatusWhenInterrupted(intctx, *update, sigint, intcancel)
 
 	remoteInfo := NewTemplateRemoteInfo(ctx, update)
+	hooks, err := NewUpdateHooksService(ctx, s.log).GetUpdateTransactionHooks(update)
+	if err != nil {
+		update.Status = models.UpdateStatusError
+		db.DB.Save(update)
+		s.log.WithField("error", err.Error()).Error("Error getting update hooks")
+		return nil, err
+	}
+	for _, hook := range hooks {
+		if hook.Stage == models.UpdateHookStagePre {
+			remoteInfo.PreUpdateHooks = append(remoteInfo.PreUpdateHooks, hook)
+		} else {
+			remoteInfo.PostUpdateHooks = append(remoteInfo.PostUpdateHooks, hook)
+		}
+	}
 
 	playbookURL, err := s.WriteTemplate(remoteInfo, update.OrgID)
 
@@ -305,7 +389,6 @@ func (s *UpdateService) CreateUpdate(ctx context.Context, id uint) (*models.Upda
 
 		for _, excPlaybook := range exc {
 			if excPlaybook.StatusCode == http.StatusCreated {
-				device.Connected = true
 				dispatchRecord := &models.DispatchRecord{
 					Device:               &device,
 					DeviceID:             device.ID,
@@ -315,7 +398,6 @@ func (s *UpdateService) CreateUpdate(ctx context.Context, id uint) (*models.Upda
 				}
 				dispatchRecords = append(dispatchRecords, *dispatchRecord)
 			} else {
-				device.Connected = false
 				dispatchRecord := &models.DispatchRecord{
 					Device:      &device,
 					DeviceID:    device.ID,
@@ -325,7 +407,8 @@ func (s *UpdateService) CreateUpdate(ctx context.Context, id uint) (*models.Upda
 				}
 				dispatchRecords = append(dispatchRecords, *dispatchRecord)
 			}
-			db.DB.Save(&device)
+			// the connectivity is the only device field changed by the dispatch, it is saved with its history event
+			setDevicesConnectivity(s.log, excPlaybook.StatusCode == http.StatusCreated, &device)
 		}
 		update.DispatchRecords = dispatchRecords
 		err = s.SetUpdateStatus(update)
@@ -357,6 +440,7 @@ func NewTemplateRemoteInfo(ctx context.Context, update *models.UpdateTransaction
 		UpdateTransactionID: update.ID,
 		GpgVerify:           config.Get().GpgVerify,
 		OSTreeRef:           update.Commit.OSTreeRef,
+		OSTreeCommit:        update.Commit.OSTreeCommit,
 		RemoteOstreeUpdate:  fmt.Sprint(update.ChangesRefs),
 	}
 }
@@ -444,6 +528,15 @@ func (s *UpdateService) getPlaybookURL(updateID uint) string {
 	return fmt.Sprintf("%s/api/edge/v1/updates/%d/update-playbook.yml", cfg.EdgeAPIBaseURL, updateID)
 }
 
+// edgeCertAPIStorageURL returns the url of a storage path on the cert API, requested by the devices with their certificate
+func edgeCertAPIStorageURL(path string) (string, error) {
+	edgeCertAPIBaseURL, err := url.Parse(config.Get().EdgeCertAPIBaseURL)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s://%s/api/edge/v1/storage/%s", edgeCertAPIBaseURL.Scheme, edgeCertAPIBaseURL.Host, path), nil
+}
+
 // WriteTemplate is the function that writes the template to a file
 func (s *UpdateService) WriteTemplate(templateInfo TemplateRemoteInfo, orgID string) (string, error) {
 	cfg := config.Get()
@@ -455,12 +548,11 @@ func (s *UpdateService) WriteTemplate(templateInfo TemplateRemoteInfo, orgID str
 		return "", err
 	}
 
-	edgeCertAPIBaseURL, err := url.Parse(cfg.EdgeCertAPIBaseURL)
+	repoURL, err := edgeCertAPIStorageURL(fmt.Sprintf("update-repos/%d", templateInfo.UpdateTransactionID))
 	if err != nil {
 		s.log.WithFields(log.Fields{"error": err.Error(), "url": cfg.EdgeCertAPIBaseURL}).Error("error while parsing config edge cert api url")
 		return "", err
 	}
-	repoURL := fmt.Sprintf("%s://%s/api/edge/v1/storage/update-repos/%d", edgeCertAPIBaseURL.Scheme, edgeCertAPIBaseURL.Host, templateInfo.UpdateTransactionID)
 
 	// override the repo URL with the Pulp Distribution URL
 	if feature.PulpIntegration.IsEnabledCtx(s.ctx) {
@@ -478,8 +570,24 @@ func (s *UpdateService) WriteTemplate(templateInfo TemplateRemoteInfo, orgID str
 		RepoContentURL:      repoURL,
 		RemoteOstreeUpdate:  templateInfo.RemoteOstreeUpdate,
 		OSTreeRef:           templateInfo.OSTreeRef,
+		OSTreeCommit:        templateInfo.OSTreeCommit,
 		GoTemplateGpgVerify: templateInfo.GpgVerify,
 	}
+	// the hooks tasks are not part of the static playbook signature, the playbooks running hooks must be signed on render
+	dynamicSigning := feature.DynamicPlaybookSigning.IsEnabledCtx(s.ctx)
+	if (len(templateInfo.PreUpdateHooks) > 0 || len(templateInfo.PostUpdateHooks) > 0) && !dynamicSigning {
+		s.log.Error("Update hooks can not run without dynamic playbook signing")
+		return "", new(UpdateHooksRequirePlaybookSigning)
+	}
+	// the hooks are rendered as json variables, the hooks variables and tasks are left out of the playbook without hooks
+	if templateData.PreUpdateHooks, err = playbookHooks(templateInfo.PreUpdateHooks); err != nil {
+		s.log.WithField("error", err.Error()).Error("Error marshalling pre-update hooks")
+		return "", err
+	}
+	if templateData.PostUpdateHooks, err = playbookHooks(templateInfo.PostUpdateHooks); err != nil {
+		s.log.WithField("error", err.Error()).Error("Error marshalling post-update hooks")
+		return "", err
+	}
 
 	// TODO change the same time as line 231
 	// TODO: (holloway) what is line 231 at the time the above TODO was added?
@@ -492,15 +600,28 @@ func (s *UpdateService) WriteTemplate(templateInfo TemplateRemoteInfo, orgID str
 		s.log.WithField("error", err.Error()).Errorf("Error creating folder: %s", dirpath)
 		return "", err
 	}
-	// create the tmpfile with the full path
-	f, err := os.Create(tmpfilepath)
+	var playbook bytes.Buffer
+	err = templateContents.Execute(&playbook, templateData)
 	if err != nil {
-		s.log.WithField("error", err.Error()).Errorf("Error creating file: %s", tmpfilepath)
+		s.log.WithField("error", err.Error()).Error("Error executing template")
 		return "", err
 	}
-	err = templateContents.Execute(f, templateData)
-	if err != nil {
-		s.log.WithField("error", err.Error()).Error("Error executing template")
+	playbookContent := playbook.Bytes()
+	if dynamicSigning {
+		signer, err := playbooksigner.NewSignerFromConfig()
+		if err != nil {
+			s.log.WithField("error", err.Error()).Error("Error loading playbook signing key")
+			return "", err
+		}
+		if playbookContent, err = signer.SignPlaybook(playbookContent); err != nil {
+			s.log.WithField("error", err.Error()).Error("Error signing playbook")
+			return "", err
+		}
+		s.log.WithField("keyID", signer.KeyID()).Debug("Playbook signed")
+	}
+	// create the tmpfile with the full path
+	if err := os.WriteFile(tmpfilepath, playbookContent, 0640); err != nil {
+		s.log.WithField("error", err.Error()).Errorf("Error creating file: %s", tmpfilepath)
 		return "", err
 	}
 
@@ -568,6 +689,12 @@ func (s *UpdateService) ProcessPlaybookDispatcherRunEvent(message []byte) error
 		"PlaybookDispatcherID": e.Payload.ID,
 		"Status":               e.Payload.Status,
 	})
+	// the device answered the run unless it timed out
+	processPlaybookRunConnectivity(s.log, e.Payload.ID, e.Payload.Status)
+	// the runs of the device actions playbooks only update the device actions
+	if isDeviceAction, err := processDeviceActionRunEvent(s.log, e.Payload.ID, e.Payload.Status); err != nil || isDeviceAction {
+		return err
+	}
 	if e.Payload.Status == PlaybookStatusRunning {
 		s.log.WithField("playbook_dispatcher_id", e.Payload.ID).Debug("Playbook is running - waiting for next messages")
 		return nil
@@ -647,6 +774,12 @@ func (s *UpdateService) SetUpdateStatus(update *models.UpdateTransaction) error
 			update.Status = models.UpdateStatusError
 			break
 		}
+		if d.Status == models.DispatchRecordStatusUnresponsive {
+			update.Status = models.UpdateStatusDeviceUnresponsive
+		}
+		if d.Status == models.DispatchRecordStatusRolledBack && update.Status != models.UpdateStatusDeviceUnresponsive {
+			update.Status = models.UpdateStatusRolledBack
+		}
 	}
 	if allSuccess {
 		update.Status = models.UpdateStatusSuccess
@@ -657,6 +790,90 @@ func (s *UpdateService) SetUpdateStatus(update *models.UpdateTransaction) error
 	return result.Error
 }
 
+// GetUpdateRollbacks returns the devices rollbacks of an update
+func (s *UpdateService) GetUpdateRollbacks(orgID string, updateID uint) ([]models.UpdateRollback, error) {
+	rollbacks := make([]models.UpdateRollback, 0)
+	if result := db.Org(orgID, "").Where("update_transaction_id = ?", updateID).Order("created_at DESC, id DESC").Find(&rollbacks); result.Error != nil {
+		s.log.WithFields(log.Fields{"update_id": updateID, "error": result.Error.Error()}).Error("error occurred while getting update rollbacks")
+		return nil, result.Error
+	}
+	return rollbacks, nil
+}
+
+// playbookHooks returns the json representation of the hooks to render in the playbook, it is empty without hooks
+func playbookHooks(hooks []models.UpdateHook) (string, error) {
+	if len(hooks) == 0 {
+		return "", nil
+	}
+	renderedHooks := make([]playbookHook, 0, len(hooks))
+	for _, hook := range hooks {
+		renderedHooks = append(renderedHooks, playbookHook{Name: hook.Name, Script: hook.Script})
+	}
+	data, err := json.Marshal(renderedHooks)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SetUnresponsiveDispatchRecords marks the dispatch records stuck in pending or running status past the configured deadline as unresponsive,
+// then sets the status of their update transactions and sends the device notification
+func (s *UpdateService) SetUnresponsiveDispatchRecords() error {
+	stuckStatuses := []string{models.DispatchRecordStatusPending, models.DispatchRecordStatusRunning}
+	deadline := time.Now().Add(-time.Duration(config.Get().DispatchRecordTimeout) * time.Minute)
+	var dispatchRecords []models.DispatchRecord
+	if result := db.DB.Where("status IN (?) AND updated_at < ?", stuckStatuses, deadline).Find(&dispatchRecords); result.Error != nil {
+		s.log.WithField("error", result.Error.Error()).Error("error occurred while getting stuck dispatch records")
+		return result.Error
+	}
+
+	updatesIDs := make([]uint, 0, len(dispatchRecords))
+	updatesSet := make(map[uint]bool, len(dispatchRecords))
+	for _, dispatchRecord := range dispatchRecords {
+		// the status condition makes sure only one instance marks a dispatch record as unresponsive
+		result := db.DB.Model(&models.DispatchRecord{}).Where("id = ? AND status IN (?)", dispatchRecord.ID, stuckStatuses).
+			Updates(map[string]interface{}{"status": models.DispatchRecordStatusUnresponsive, "reason": models.UpdateReasonTimeout})
+		if result.Error != nil {
+			s.log.WithFields(log.Fields{"dispatch_record_id": dispatchRecord.ID, "error": result.Error.Error()}).Error("error occurred while setting dispatch record unresponsive")
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		s.log.WithFields(log.Fields{"dispatch_record_id": dispatchRecord.ID, "device_id": dispatchRecord.DeviceID}).Info("dispatch record set unresponsive")
+
+		var updateIDs []uint
+		if result := db.DB.Table("updatetransaction_dispatchrecords").Where("dispatch_record_id = ?", dispatchRecord.ID).
+			Pluck("update_transaction_id", &updateIDs); result.Error != nil {
+			s.log.WithFields(log.Fields{"dispatch_record_id": dispatchRecord.ID, "error": result.Error.Error()}).Error("error occurred while getting dispatch record update")
+			return result.Error
+		}
+		for _, updateID := range updateIDs {
+			if !updatesSet[updateID] {
+				updatesSet[updateID] = true
+				updatesIDs = append(updatesIDs, updateID)
+			}
+		}
+	}
+
+	for _, updateID := range updatesIDs {
+		var update models.UpdateTransaction
+		if result := db.DB.Preload("DispatchRecords").Preload("Devices").First(&update, updateID); result.Error != nil {
+			s.log.WithFields(log.Fields{"update_id": updateID, "error": result.Error.Error()}).Error("error occurred while getting update")
+			return result.Error
+		}
+		if err := s.SetUpdateStatus(&update); err != nil {
+			s.log.WithFields(log.Fields{"update_id": updateID, "error": err.Error()}).Error("error occurred while setting update status")
+			return err
+		}
+		if _, err := s.SendDeviceNotification(&update); err != nil {
+			s.log.WithFields(log.Fields{"update_id": updateID, "error": err.Error()}).Error("error occurred while sending device notification")
+		}
+	}
+
+	return nil
+}
+
 // SendDeviceNotification connects to platform.notifications.ingress on image topic
 func (s *UpdateService) SendDeviceNotification(i *models.UpdateTransaction) (ImageNotification, error) {
 	s.log.WithField("message", i).Info("SendImageNotification::Starts")
@@ -862,6 +1079,277 @@ func (s *UpdateService) InventoryGroupDevicesUpdateInfo(orgID string, inventoryG
 	return &inventoryGroupDevicesInfo, nil
 }
 
+// PreviewUpdate returns what an update of the given devices to the commit would do, without creating anything.
+// The stale devices are excluded unless includeStale is set
+func (s *UpdateService) PreviewUpdate(orgID string, devicesUUID []string, commit *models.Commit, includeStale bool) (*models.UpdatePreview, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "commit_id": commit.ID})
+
+	var targetImage models.Image
+	if result := db.Org(orgID, "").Preload("Commit.InstalledPackages").Where("commit_id = ?", commit.ID).First(&targetImage); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(CommitImageNotFound)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting update target image")
+		return nil, result.Error
+	}
+
+	var devices []models.Device
+	if result := db.Org(orgID, "").Where("uuid IN (?)", devicesUUID).Order("id ASC").Find(&devices); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting update devices")
+		return nil, result.Error
+	}
+
+	imagesIDs := make([]uint, 0, len(devices))
+	for _, device := range devices {
+		if device.ImageID != 0 {
+			imagesIDs = append(imagesIDs, device.ImageID)
+		}
+	}
+	var images []models.Image
+	if len(imagesIDs) > 0 {
+		if result := db.Org(orgID, "").Preload("Commit.InstalledPackages").Where("id IN (?)", imagesIDs).Find(&images); result.Error != nil {
+			logger.WithField("error", result.Error.Error()).Error("error occurred while getting devices images")
+			return nil, result.Error
+		}
+	}
+	imagesMap := make(map[uint]models.Image, len(images))
+	for _, image := range images {
+		imagesMap[image.ID] = image
+	}
+
+	preview := models.UpdatePreview{
+		CommitID:        commit.ID,
+		ImageID:         targetImage.ID,
+		IncludedDevices: []models.UpdatePreviewDevice{},
+		ExcludedDevices: []models.UpdatePreviewDevice{},
+		Images:          []models.UpdatePreviewImage{},
+	}
+	// keep the images preview in the order the devices reference them
+	imagesPreviewIndex := make(map[uint]int)
+	for _, device := range devices {
+		previewDevice := models.UpdatePreviewDevice{UUID: device.UUID, Name: device.Name, ImageID: device.ImageID}
+		image, ok := imagesMap[device.ImageID]
+		switch {
+		case !ok:
+			previewDevice.Reason = models.UpdatePreviewReasonImageUndefined
+		case image.ImageSetID == nil || targetImage.ImageSetID == nil || *image.ImageSetID != *targetImage.ImageSetID:
+			previewDevice.Reason = models.UpdatePreviewReasonDifferentImageSet
+		case image.ID == targetImage.ID || (device.CurrentHash != "" && device.CurrentHash == commit.OSTreeCommit):
+			previewDevice.Reason = models.UpdatePreviewReasonAlreadyOnTarget
+		case !device.Connected:
+			previewDevice.Reason = models.UpdatePreviewReasonDisconnected
+		case device.Staleness == models.DeviceStalenessStale && !includeStale:
+			previewDevice.Reason = models.UpdatePreviewReasonStale
+		}
+		if previewDevice.Reason != "" {
+			preview.ExcludedDevices = append(preview.ExcludedDevices, previewDevice)
+			continue
+		}
+		preview.IncludedDevices = append(preview.IncludedDevices, previewDevice)
+
+		index, ok := imagesPreviewIndex[image.ID]
+		if !ok {
+			imagePreview, err := s.previewUpdateImage(orgID, image, targetImage, commit)
+			if err != nil {
+				return nil, err
+			}
+			preview.Images = append(preview.Images, *imagePreview)
+			index = len(preview.Images) - 1
+			imagesPreviewIndex[image.ID] = index
+		}
+		preview.Images[index].DevicesCount++
+		preview.EstimatedDownloadSize += preview.Images[index].EstimatedDownloadSize
+	}
+
+	return &preview, nil
+}
+
+func (s *UpdateService) previewUpdateImage(orgID string, image models.Image, targetImage models.Image, commit *models.Commit) (*models.UpdatePreviewImage, error) {
+	imagePreview := models.UpdatePreviewImage{
+		ImageID: image.ID,
+		Version: image.Version,
+	}
+	if image.Commit != nil && targetImage.Commit != nil {
+		imagePreview.OSTreeCommit = image.Commit.OSTreeCommit
+		imagePreview.PackageDiff = GetDiffOnUpdate(image, targetImage)
+	}
+	staticDelta := models.StaticDeltaState{
+		OrgID: orgID,
+		Name:  models.GetStaticDeltaName(imagePreview.OSTreeCommit, commit.OSTreeCommit),
+	}
+	staticDeltaState, err := staticDelta.Query(s.log)
+	if err != nil {
+		return nil, err
+	}
+	imagePreview.StaticDeltaStatus = staticDeltaState.Status
+	imagePreview.StaticDeltaExists = staticDeltaState.Status == models.StaticDeltaStatusReady
+	downloadPackages := len(imagePreview.PackageDiff.Added) + len(imagePreview.PackageDiff.Upgraded)
+	imagePreview.EstimatedDownloadSize = int64(downloadPackages) * UpdatePreviewPackageDownloadSize
+
+	return &imagePreview, nil
+}
+
+// RetryUpdateFailedDevices creates a child update transaction for the devices whose dispatch records failed or timed out,
+// the child update reuses the parent update commit and repo
+func (s *UpdateService) RetryUpdateFailedDevices(orgID string, updateID uint) (*models.UpdateTransaction, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "update_id": updateID})
+
+	var update models.UpdateTransaction
+	if result := db.Org(orgID, "update_transactions").Preload("DispatchRecords").Preload("OldCommits").
+		Joins("Commit").Joins("Repo").First(&update, updateID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(UpdateNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting update transaction")
+		return nil, result.Error
+	}
+	if update.Repo == nil || update.Repo.Status != models.RepoStatusSuccess {
+		return nil, new(UpdateRepoNotAvailable)
+	}
+
+	failedDevicesIDs := make([]uint, 0, len(update.DispatchRecords))
+	for _, dispatchRecord := range update.DispatchRecords {
+		if dispatchRecord.Status == models.DispatchRecordStatusError || dispatchRecord.Status == models.DispatchRecordStatusUnresponsive {
+			failedDevicesIDs = append(failedDevicesIDs, dispatchRecord.DeviceID)
+		}
+	}
+	if len(failedDevicesIDs) == 0 {
+		return nil, new(UpdateHasNoFailedDevices)
+	}
+	var devices []models.Device
+	if result := db.Org(orgID, "").Where("id IN (?)", failedDevicesIDs).Find(&devices); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting update failed devices")
+		return nil, result.Error
+	}
+	if len(devices) == 0 {
+		return nil, new(UpdateHasNoFailedDevices)
+	}
+	status, err := s.newUpdateStatus(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	retryUpdate := models.UpdateTransaction{
+		OrgID:           orgID,
+		Account:         update.Account,
+		CommitID:        update.CommitID,
+		Commit:          update.Commit,
+		OldCommits:      update.OldCommits,
+		Devices:         devices,
+		Status:          status,
+		RepoID:          update.RepoID,
+		Repo:            update.Repo,
+		ChangesRefs:     update.ChangesRefs,
+		DispatchRecords: []models.DispatchRecord{},
+		ParentID:        &update.ID,
+		RequestedBy:     common.GetParsedIdentityPrincipal(s.ctx),
+	}
+	if result := db.DB.Omit("Devices.*", "Commit", "Repo", "OldCommits.*").Create(&retryUpdate); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while creating retry update transaction")
+		return nil, result.Error
+	}
+	logger.WithFields(log.Fields{"retry_update_id": retryUpdate.ID, "devices_count": len(devices)}).Info("update failed devices retry created")
+
+	return &retryUpdate, nil
+}
+
+// newUpdateStatus returns the status of a new update, the update awaits approval when the org requires
+// a second person to approve its updates
+func (s *UpdateService) newUpdateStatus(orgID string) (string, error) {
+	settings, err := s.GetUpdateApprovalSettings(orgID)
+	if err != nil {
+		return "", err
+	}
+	if settings.RequireApproval {
+		return models.UpdateStatusAwaitingApproval, nil
+	}
+	return models.UpdateStatusCreated, nil
+}
+
+// GetUpdateApprovalSettings returns the update approval settings of an org, the updates do not require approval
+// when the org has no settings
+func (s *UpdateService) GetUpdateApprovalSettings(orgID string) (*models.UpdateApprovalSettings, error) {
+	var settings models.UpdateApprovalSettings
+	if result := db.Org(orgID, "").First(&settings); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return &models.UpdateApprovalSettings{OrgID: orgID}, nil
+		}
+		s.log.WithFields(log.Fields{"error": result.Error.Error(), "org_id": orgID}).Error("error getting update approval settings")
+		return nil, result.Error
+	}
+	return &settings, nil
+}
+
+// SetUpdateApprovalSettings creates or replaces the update approval settings of an org
+func (s *UpdateService) SetUpdateApprovalSettings(orgID string, settings *models.UpdateApprovalSettings) (*models.UpdateApprovalSettings, error) {
+	logger := s.log.WithField("org_id", orgID)
+	existing, err := s.GetUpdateApprovalSettings(orgID)
+	if err != nil {
+		return nil, err
+	}
+	existing.RequireApproval = settings.RequireApproval
+	if result := db.DB.Save(existing); result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error saving update approval settings")
+		return nil, result.Error
+	}
+	logger.WithField("require_approval", existing.RequireApproval).Info("update approval settings set")
+	return existing, nil
+}
+
+// ApproveUpdate approves an update awaiting approval, the update can then be built and dispatched.
+// The update can not be approved by the principal that created it, nor when that principal is unknown
+func (s *UpdateService) ApproveUpdate(orgID string, updateID uint) (*models.UpdateTransaction, error) {
+	return s.reviewUpdate(orgID, updateID, models.UpdateStatusCreated)
+}
+
+// RejectUpdate rejects an update awaiting approval, the update is never built nor dispatched
+func (s *UpdateService) RejectUpdate(orgID string, updateID uint) (*models.UpdateTransaction, error) {
+	return s.reviewUpdate(orgID, updateID, models.UpdateStatusRejected)
+}
+
+func (s *UpdateService) reviewUpdate(orgID string, updateID uint, status string) (*models.UpdateTransaction, error) {
+	logger := s.log.WithFields(log.Fields{"org_id": orgID, "update_id": updateID, "status": status})
+	principal := common.GetParsedIdentityPrincipal(s.ctx)
+	if principal == "" {
+		return nil, new(UpdateReviewerUndefined)
+	}
+
+	var update models.UpdateTransaction
+	if result := db.Org(orgID, "").First(&update, updateID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, new(UpdateNotFoundError)
+		}
+		logger.WithField("error", result.Error.Error()).Error("error occurred while getting update transaction")
+		return nil, result.Error
+	}
+	if update.Status != models.UpdateStatusAwaitingApproval {
+		return nil, new(UpdateNotAwaitingApproval)
+	}
+	if status == models.UpdateStatusCreated && update.RequestedBy == "" {
+		return nil, new(UpdateRequesterUndefined)
+	}
+	if status == models.UpdateStatusCreated && update.RequestedBy == principal {
+		return nil, new(UpdateSelfApproval)
+	}
+
+	// the update is reviewed only once, even when several reviewers act at the same time
+	result := db.DB.Model(&models.UpdateTransaction{}).
+		Where("id = ? AND status = ?", update.ID, models.UpdateStatusAwaitingApproval).
+		Updates(map[string]interface{}{"status": status, "reviewed_by": principal})
+	if result.Error != nil {
+		logger.WithField("error", result.Error.Error()).Error("error occurred while reviewing update transaction")
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, new(UpdateNotAwaitingApproval)
+	}
+	update.Status = status
+	update.ReviewedBy = principal
+	logger.WithField("reviewed_by", principal).Info("update transaction reviewed")
+
+	return &update, nil
+}
+
 // BuildUpdateTransactions creates the update transaction to be sent to Playbook Dispatcher
 func (s *UpdateService) BuildUpdateTransactions(ctx context.Context, devicesUpdate *models.DevicesUpdate,
 	orgID string, commit *models.Commit) (*[]models.UpdateTransaction, error) {
@@ -885,13 +1373,18 @@ func (s *UpdateService) BuildUpdateTransactions(ctx context.Context, devicesUpda
 
 	s.log.WithField("inventoryDevice", inv).Debug("Device retrieved from inventoryResponse")
 
+	status, err := s.newUpdateStatus(orgID)
+	if err != nil {
+		return nil, err
+	}
 	// Create the models.UpdateTransaction for each device
 	var updates []models.UpdateTransaction
 	for _, inventoryResponse := range ii {
 		update := models.UpdateTransaction{
-			OrgID:    orgID,
-			CommitID: devicesUpdate.CommitID,
-			Status:   models.UpdateStatusCreated,
+			OrgID:       orgID,
+			CommitID:    devicesUpdate.CommitID,
+			Status:      status,
+			RequestedBy: common.GetParsedIdentityPrincipal(s.ctx),
 		}
 
 		// Add the Commit ID passed in via JSON to the update
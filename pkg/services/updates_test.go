@@ -11,7 +11,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/bxcodec/faker/v3"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo" // nolint: revive
@@ -29,6 +33,8 @@ import (
 	"github.com/redhatinsights/edge-api/pkg/routes/common"
 	"github.com/redhatinsights/edge-api/pkg/services"
 	"github.com/redhatinsights/edge-api/pkg/services/mock_services"
+	"github.com/redhatinsights/edge-api/pkg/services/playbooksigner"
+	feature "github.com/redhatinsights/edge-api/unleash/features"
 
 	"github.com/redhatinsights/edge-api/config"
 )
@@ -317,6 +323,20 @@ var _ = Describe("UpdateService Basic functions", func() {
 				db.DB.Omit("Devices.*").Create(&update)
 			})
 
+			When("when the update is not approved", func() {
+				It("should not build the update awaiting approval", func() {
+					Expect(db.DB.Model(&models.UpdateTransaction{}).Where("id = ?", update.ID).Update("status", models.UpdateStatusAwaitingApproval).Error).ToNot(HaveOccurred())
+					mockRepoBuilder.EXPECT().BuildUpdateRepo(gomock.Any(), gomock.Any()).Times(0)
+
+					_, err := updateService.CreateUpdate(ctx, update.ID)
+					Expect(err).To(MatchError(new(services.UpdateNotApproved)))
+
+					var savedUpdate models.UpdateTransaction
+					Expect(db.DB.First(&savedUpdate, update.ID).Error).ToNot(HaveOccurred())
+					Expect(savedUpdate.Status).To(Equal(models.UpdateStatusAwaitingApproval))
+				})
+			})
+
 			When("when build repo fail", func() {
 				It("should return error when can't build repo", func() {
 					expectedError := errors.New("error building repo")
@@ -383,6 +403,7 @@ var _ = Describe("UpdateService Basic functions", func() {
 
 			When("when playbook dispatcher respond with an error", func() {
 				It("should create dispatcher records with status error and reason failure", func() {
+					Expect(db.DB.Model(&device).Update("connected", true).Error).ToNot(HaveOccurred())
 
 					fname := fmt.Sprintf("playbook_dispatcher_update_%s_%d.yml", update.OrgID, update.ID)
 					tmpfilepath := fmt.Sprintf("/tmp/v2/%s/%s", update.OrgID, fname)
@@ -424,6 +445,15 @@ var _ = Describe("UpdateService Basic functions", func() {
 
 					Expect(len(updateTransaction.Devices)).Should(Equal(1))
 					Expect(updateTransaction.Devices[0].ID).Should(Equal(device.ID))
+
+					// the device connectivity change is saved with its history event
+					var savedDevice models.Device
+					Expect(db.DB.First(&savedDevice, device.ID).Error).ToNot(HaveOccurred())
+					Expect(savedDevice.Connected).To(BeFalse())
+					var events []models.DeviceEvent
+					Expect(db.DB.Where("device_id = ?", device.ID).Find(&events).Error).ToNot(HaveOccurred())
+					Expect(len(events)).To(Equal(1))
+					Expect(events[0].Type).To(Equal(models.DeviceEventTypeDisconnected))
 				})
 			})
 
@@ -641,6 +671,104 @@ var _ = Describe("UpdateService Basic functions", func() {
 			})
 		})
 
+		Context("when there are update hooks", func() {
+			It("should refuse to render the hooks without dynamic playbook signing", func() {
+				t := services.TemplateRemoteInfo{
+					UpdateTransactionID: 1001,
+					RemoteName:          "remote-name",
+					RemoteOstreeUpdate:  "false",
+					OSTreeRef:           "rhel/8/x86_64/edge",
+					GpgVerify:           "false",
+					PreUpdateHooks:      []models.UpdateHook{{Name: "drain", Stage: models.UpdateHookStagePre, Script: "podman stop --all"}},
+				}
+				updateService := &services.UpdateService{
+					Service: services.NewService(context.Background(), log.WithField("service", "update")),
+				}
+
+				_, err := updateService.WriteTemplate(t, orgID)
+				Expect(err).To(MatchError(new(services.UpdateHooksRequirePlaybookSigning)))
+			})
+		})
+
+		Context("when dynamic playbook signing is enabled", func() {
+			var cfg *config.EdgeConfig
+			var signingKey *openpgp.Entity
+			BeforeEach(func() {
+				var err error
+				signingKey, err = openpgp.NewEntity("edge-api", "", "edge-api@example.com", nil)
+				Expect(err).ToNot(HaveOccurred())
+				var keyRing bytes.Buffer
+				writer, err := armor.Encode(&keyRing, openpgp.PrivateKeyType, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(signingKey.SerializePrivate(writer, nil)).To(Succeed())
+				Expect(writer.Close()).To(Succeed())
+
+				cfg = config.Get()
+				cfg.PlaybookSigningKey = keyRing.String()
+				os.Setenv(feature.DynamicPlaybookSigning.EnvVar, "true")
+			})
+			AfterEach(func() {
+				cfg.PlaybookSigningKey = ""
+				os.Unsetenv(feature.DynamicPlaybookSigning.EnvVar)
+			})
+
+			It("should sign the playbook with the configured key", func() {
+				t := services.TemplateRemoteInfo{
+					UpdateTransactionID: 1002,
+					RemoteName:          "remote-name",
+					RemoteOstreeUpdate:  "false",
+					OSTreeRef:           "rhel/8/x86_64/edge",
+					GpgVerify:           "false",
+					OSTreeCommit:        "target-checksum",
+					PreUpdateHooks:      []models.UpdateHook{{Name: "drain", Stage: models.UpdateHookStagePre, Script: "podman stop --all"}},
+					PostUpdateHooks:     []models.UpdateHook{{Name: "smoke-test", Stage: models.UpdateHookStagePost, Script: "curl -f http://localhost/health"}},
+				}
+				fname := fmt.Sprintf("playbook_dispatcher_update_%s_%d.yml", orgID, t.UpdateTransactionID)
+				tmpfilepath := fmt.Sprintf("/tmp/v2/%s/%s", orgID, fname)
+
+				ctrl := gomock.NewController(GinkgoT())
+				defer ctrl.Finish()
+				mockFilesService := mock_services.NewMockFilesService(ctrl)
+				updateService := &services.UpdateService{
+					Service:      services.NewService(context.Background(), log.WithField("service", "update")),
+					FilesService: mockFilesService,
+				}
+				mockUploader := mock_services.NewMockUploader(ctrl)
+				mockUploader.EXPECT().UploadFile(tmpfilepath, fmt.Sprintf("%s/playbooks/%s", orgID, fname)).Do(func(x, y string) {
+					actual, err := os.ReadFile(x)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(actual)).To(ContainSubstring("insights_signature_exclude: /vars/insights_signature\n"))
+					Expect(string(actual)).To(ContainSubstring("run pre-update hooks"))
+					Expect(string(actual)).To(ContainSubstring(`if [ "$booted_checksum" != "target-checksum" ]; then`))
+					Expect(playbooksigner.VerifyPlaybook(actual, openpgp.EntityList{signingKey})).To(Succeed())
+
+					tampered := strings.Replace(string(actual), "http://localhost/health", "http://example.com/payload", 1)
+					Expect(playbooksigner.VerifyPlaybook([]byte(tampered), openpgp.EntityList{signingKey})).ToNot(Succeed())
+				}).Return("url", nil)
+				mockFilesService.EXPECT().GetUploader().Return(mockUploader)
+
+				_, err := updateService.WriteTemplate(t, orgID)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should fail when no signing key is configured", func() {
+				cfg.PlaybookSigningKey = ""
+				t := services.TemplateRemoteInfo{
+					UpdateTransactionID: 1003,
+					RemoteName:          "remote-name",
+					RemoteOstreeUpdate:  "false",
+					OSTreeRef:           "rhel/8/x86_64/edge",
+					GpgVerify:           "false",
+				}
+				updateService := &services.UpdateService{
+					Service: services.NewService(context.Background(), log.WithField("service", "update")),
+				}
+
+				_, err := updateService.WriteTemplate(t, orgID)
+				Expect(err).To(MatchError(playbooksigner.ErrNoSigningKey))
+			})
+		})
+
 		Context("when upload works", func() {
 			var cfg *config.EdgeConfig
 			BeforeEach(func() {
@@ -814,6 +942,45 @@ var _ = Describe("UpdateService Basic functions", func() {
 				Expect(u.Status).To(Equal(models.UpdateStatusSuccess))
 			})
 		})
+		Context("when one of the dispatch records has rolled back", func() {
+			It("should set the update status as rolled back", func() {
+				u := &models.UpdateTransaction{
+					OrgID: faker.UUIDHyphenated(),
+					DispatchRecords: []models.DispatchRecord{
+						{PlaybookDispatcherID: faker.UUIDHyphenated(), Status: models.DispatchRecordStatusComplete},
+						{PlaybookDispatcherID: faker.UUIDHyphenated(), Status: models.DispatchRecordStatusRolledBack},
+					},
+					Status: models.UpdateStatusSuccess,
+				}
+				Expect(db.DB.Create(u).Error).ToNot(HaveOccurred())
+				err := updateService.SetUpdateStatus(u)
+				Expect(err).ToNot(HaveOccurred())
+				db.DB.First(&u, u.ID)
+				Expect(u.Status).To(Equal(models.UpdateStatusRolledBack))
+			})
+		})
+	})
+
+	Describe("get update rollbacks", func() {
+		It("should return the rollbacks of the update only", func() {
+			orgID := faker.UUIDHyphenated()
+			updateService := services.NewUpdateService(context.Background(), log.NewEntry(log.StandardLogger()))
+			rollbacks := []models.UpdateRollback{
+				{OrgID: orgID, UpdateTransactionID: 1100, DeviceUUID: faker.UUIDHyphenated()},
+				{OrgID: orgID, UpdateTransactionID: 1100, DeviceUUID: faker.UUIDHyphenated()},
+				{OrgID: orgID, UpdateTransactionID: 1101, DeviceUUID: faker.UUIDHyphenated()},
+				{OrgID: faker.UUIDHyphenated(), UpdateTransactionID: 1100, DeviceUUID: faker.UUIDHyphenated()},
+			}
+			Expect(db.DB.Create(&rollbacks).Error).ToNot(HaveOccurred())
+
+			updateRollbacks, err := updateService.GetUpdateRollbacks(orgID, 1100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updateRollbacks).To(HaveLen(2))
+			for _, rollback := range updateRollbacks {
+				Expect(rollback.OrgID).To(Equal(orgID))
+				Expect(rollback.UpdateTransactionID).To(Equal(uint(1100)))
+			}
+		})
 	})
 
 	Describe("Update Devices From Update Transaction", func() {
@@ -1739,4 +1906,450 @@ var _ = Describe("UpdateService Basic functions", func() {
 			})
 		})
 	})
+
+	Describe("update preview", func() {
+		var orgID string
+		var imageSet models.ImageSet
+		var otherImageSet models.ImageSet
+		var commits []models.Commit
+		var images []models.Image
+		var otherImage models.Image
+		var devices []models.Device
+		var updateService services.UpdateServiceInterface
+
+		BeforeEach(func() {
+			if orgID != "" {
+				// setup only once
+				return
+			}
+			updateService = services.NewUpdateService(context.Background(), log.WithField("service", "update"))
+			orgID = faker.UUIDHyphenated()
+
+			packages := []models.InstalledPackage{
+				{Name: "vim", Version: "1.0"},
+				{Name: "vim", Version: "2.0"},
+				{Name: "git", Version: "1.0"},
+			}
+			commits = []models.Commit{
+				{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated(), InstalledPackages: packages[:1]},
+				{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated(), InstalledPackages: packages[1:]},
+				{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()},
+			}
+			Expect(db.DB.Create(&commits).Error).ToNot(HaveOccurred())
+
+			imageSet = models.ImageSet{Name: faker.Name(), OrgID: orgID}
+			Expect(db.DB.Create(&imageSet).Error).ToNot(HaveOccurred())
+			otherImageSet = models.ImageSet{Name: faker.Name(), OrgID: orgID}
+			Expect(db.DB.Create(&otherImageSet).Error).ToNot(HaveOccurred())
+			images = []models.Image{
+				{Name: imageSet.Name, OrgID: orgID, ImageSetID: &imageSet.ID, CommitID: commits[0].ID, Version: 1},
+				{Name: imageSet.Name, OrgID: orgID, ImageSetID: &imageSet.ID, CommitID: commits[1].ID, Version: 2},
+			}
+			Expect(db.DB.Create(&images).Error).ToNot(HaveOccurred())
+			otherImage = models.Image{Name: otherImageSet.Name, OrgID: orgID, ImageSetID: &otherImageSet.ID, CommitID: commits[2].ID, Version: 1}
+			Expect(db.DB.Create(&otherImage).Error).ToNot(HaveOccurred())
+
+			devices = []models.Device{
+				{OrgID: orgID, Name: faker.Name(), UUID: faker.UUIDHyphenated(), ImageID: images[0].ID},
+				{OrgID: orgID, Name: faker.Name(), UUID: faker.UUIDHyphenated(), ImageID: images[0].ID},
+				{OrgID: orgID, Name: faker.Name(), UUID: faker.UUIDHyphenated(), ImageID: images[1].ID},
+				{OrgID: orgID, Name: faker.Name(), UUID: faker.UUIDHyphenated(), ImageID: otherImage.ID},
+				{OrgID: orgID, Name: faker.Name(), UUID: faker.UUIDHyphenated(), ImageID: images[0].ID},
+				{OrgID: orgID, Name: faker.Name(), UUID: faker.UUIDHyphenated()},
+			}
+			Expect(db.DB.Create(&devices).Error).ToNot(HaveOccurred())
+			// connected has a database default value, set it explicitly
+			Expect(db.DB.Model(&devices[4]).Update("connected", false).Error).ToNot(HaveOccurred())
+
+			staticDelta := models.StaticDeltaState{
+				OrgID:  orgID,
+				Name:   models.GetStaticDeltaName(commits[0].OSTreeCommit, commits[1].OSTreeCommit),
+				Status: models.StaticDeltaStatusReady,
+			}
+			Expect(db.DB.Create(&staticDelta).Error).ToNot(HaveOccurred())
+		})
+
+		It("should return the included and excluded devices with reasons", func() {
+			devicesUUID := make([]string, 0, len(devices))
+			for _, device := range devices {
+				devicesUUID = append(devicesUUID, device.UUID)
+			}
+			preview, err := updateService.PreviewUpdate(orgID, devicesUUID, &commits[1], false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(preview.CommitID).To(Equal(commits[1].ID))
+			Expect(preview.ImageID).To(Equal(images[1].ID))
+
+			Expect(len(preview.IncludedDevices)).To(Equal(2))
+			Expect(preview.IncludedDevices[0].UUID).To(Equal(devices[0].UUID))
+			Expect(preview.IncludedDevices[1].UUID).To(Equal(devices[1].UUID))
+
+			excludedReasons := make(map[string]string)
+			for _, device := range preview.ExcludedDevices {
+				excludedReasons[device.UUID] = device.Reason
+			}
+			Expect(excludedReasons).To(Equal(map[string]string{
+				devices[2].UUID: models.UpdatePreviewReasonAlreadyOnTarget,
+				devices[3].UUID: models.UpdatePreviewReasonDifferentImageSet,
+				devices[4].UUID: models.UpdatePreviewReasonDisconnected,
+				devices[5].UUID: models.UpdatePreviewReasonImageUndefined,
+			}))
+
+			Expect(len(preview.Images)).To(Equal(1))
+			imagePreview := preview.Images[0]
+			Expect(imagePreview.ImageID).To(Equal(images[0].ID))
+			Expect(imagePreview.OSTreeCommit).To(Equal(commits[0].OSTreeCommit))
+			Expect(imagePreview.DevicesCount).To(Equal(2))
+			Expect(len(imagePreview.PackageDiff.Added)).To(Equal(1))
+			Expect(imagePreview.PackageDiff.Added[0].Name).To(Equal("git"))
+			Expect(len(imagePreview.PackageDiff.Upgraded)).To(Equal(1))
+			Expect(imagePreview.PackageDiff.Upgraded[0].Name).To(Equal("vim"))
+			Expect(imagePreview.StaticDeltaExists).To(BeTrue())
+			Expect(imagePreview.StaticDeltaStatus).To(Equal(models.StaticDeltaStatusReady))
+			Expect(imagePreview.EstimatedDownloadSize).To(Equal(2 * services.UpdatePreviewPackageDownloadSize))
+			Expect(preview.EstimatedDownloadSize).To(Equal(2 * imagePreview.EstimatedDownloadSize))
+		})
+
+		It("should exclude the stale devices unless included", func() {
+			Expect(db.DB.Model(&devices[1]).Update("staleness", models.DeviceStalenessStale).Error).ToNot(HaveOccurred())
+			devicesUUID := []string{devices[0].UUID, devices[1].UUID}
+
+			preview, err := updateService.PreviewUpdate(orgID, devicesUUID, &commits[1], false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(preview.IncludedDevices)).To(Equal(1))
+			Expect(preview.IncludedDevices[0].UUID).To(Equal(devices[0].UUID))
+			Expect(len(preview.ExcludedDevices)).To(Equal(1))
+			Expect(preview.ExcludedDevices[0].UUID).To(Equal(devices[1].UUID))
+			Expect(preview.ExcludedDevices[0].Reason).To(Equal(models.UpdatePreviewReasonStale))
+
+			preview, err = updateService.PreviewUpdate(orgID, devicesUUID, &commits[1], true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(preview.IncludedDevices)).To(Equal(2))
+			Expect(preview.ExcludedDevices).To(BeEmpty())
+		})
+
+		It("should report static delta not found", func() {
+			preview, err := updateService.PreviewUpdate(orgID, []string{devices[2].UUID}, &commits[0], false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(preview.IncludedDevices)).To(Equal(1))
+			Expect(len(preview.Images)).To(Equal(1))
+			Expect(preview.Images[0].StaticDeltaExists).To(BeFalse())
+			Expect(preview.Images[0].StaticDeltaStatus).To(Equal(models.StaticDeltaStatusNotFound))
+			Expect(preview.Images[0].EstimatedDownloadSize).To(Equal(int64(0)))
+		})
+
+		It("should return error when the commit has no image", func() {
+			commit := models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&commit).Error).ToNot(HaveOccurred())
+			_, err := updateService.PreviewUpdate(orgID, []string{devices[0].UUID}, &commit, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(new(services.CommitImageNotFound)))
+		})
+	})
+
+	Describe("retry update failed devices", func() {
+		var orgID string
+		var commit models.Commit
+		var repo models.Repo
+		var devices []models.Device
+		var updateService services.UpdateServiceInterface
+
+		BeforeEach(func() {
+			if orgID != "" {
+				// setup only once
+				return
+			}
+			updateService = services.NewUpdateService(context.Background(), log.WithField("service", "update"))
+			orgID = faker.UUIDHyphenated()
+			commit = models.Commit{OrgID: orgID, OSTreeCommit: faker.UUIDHyphenated()}
+			Expect(db.DB.Create(&commit).Error).ToNot(HaveOccurred())
+			repo = models.Repo{Status: models.RepoStatusSuccess, URL: faker.URL()}
+			Expect(db.DB.Create(&repo).Error).ToNot(HaveOccurred())
+			devices = []models.Device{
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), RHCClientID: faker.UUIDHyphenated()},
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), RHCClientID: faker.UUIDHyphenated()},
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), RHCClientID: faker.UUIDHyphenated()},
+			}
+			Expect(db.DB.Create(&devices).Error).ToNot(HaveOccurred())
+		})
+
+		It("should create a child update for the failed devices only", func() {
+			update := models.UpdateTransaction{
+				OrgID:    orgID,
+				CommitID: commit.ID,
+				RepoID:   &repo.ID,
+				Devices:  devices,
+				Status:   models.UpdateStatusError,
+				DispatchRecords: []models.DispatchRecord{
+					{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusComplete},
+					{DeviceID: devices[1].ID, Status: models.DispatchRecordStatusError, Reason: models.UpdateReasonFailure},
+					{DeviceID: devices[2].ID, Status: models.DispatchRecordStatusError, Reason: models.UpdateReasonTimeout},
+				},
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+
+			retryUpdate, err := updateService.RetryUpdateFailedDevices(orgID, update.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(retryUpdate.ID).ToNot(Equal(update.ID))
+			Expect(*retryUpdate.ParentID).To(Equal(update.ID))
+			Expect(retryUpdate.Status).To(Equal(models.UpdateStatusCreated))
+
+			var savedRetryUpdate models.UpdateTransaction
+			err = db.DB.Preload("Devices").Preload("DispatchRecords").First(&savedRetryUpdate, retryUpdate.ID).Error
+			Expect(err).ToNot(HaveOccurred())
+			Expect(savedRetryUpdate.CommitID).To(Equal(commit.ID))
+			Expect(*savedRetryUpdate.RepoID).To(Equal(repo.ID))
+			Expect(*savedRetryUpdate.ParentID).To(Equal(update.ID))
+			Expect(len(savedRetryUpdate.DispatchRecords)).To(Equal(0))
+			Expect(len(savedRetryUpdate.Devices)).To(Equal(2))
+			devicesIDs := []uint{savedRetryUpdate.Devices[0].ID, savedRetryUpdate.Devices[1].ID}
+			Expect(devicesIDs).To(ConsistOf(devices[1].ID, devices[2].ID))
+		})
+
+		It("should create the retry update awaiting approval when the org requires update approval", func() {
+			_, err := updateService.SetUpdateApprovalSettings(orgID, &models.UpdateApprovalSettings{RequireApproval: true})
+			Expect(err).ToNot(HaveOccurred())
+			update := models.UpdateTransaction{
+				OrgID:    orgID,
+				CommitID: commit.ID,
+				RepoID:   &repo.ID,
+				Devices:  devices[:1],
+				Status:   models.UpdateStatusError,
+				DispatchRecords: []models.DispatchRecord{
+					{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusError},
+				},
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+
+			retryUpdate, err := updateService.RetryUpdateFailedDevices(orgID, update.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(retryUpdate.Status).To(Equal(models.UpdateStatusAwaitingApproval))
+			Expect(retryUpdate.RequestedBy).To(Equal(common.DefaultPrincipal))
+		})
+
+		It("should return error when the update has no failed devices", func() {
+			update := models.UpdateTransaction{
+				OrgID:    orgID,
+				CommitID: commit.ID,
+				RepoID:   &repo.ID,
+				Devices:  devices[:1],
+				Status:   models.UpdateStatusSuccess,
+				DispatchRecords: []models.DispatchRecord{
+					{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusComplete},
+				},
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+
+			_, err := updateService.RetryUpdateFailedDevices(orgID, update.ID)
+			Expect(err).To(MatchError(new(services.UpdateHasNoFailedDevices)))
+		})
+
+		It("should return error when the update repo is not available", func() {
+			errorRepo := models.Repo{Status: models.RepoStatusError}
+			Expect(db.DB.Create(&errorRepo).Error).ToNot(HaveOccurred())
+			update := models.UpdateTransaction{
+				OrgID:    orgID,
+				CommitID: commit.ID,
+				RepoID:   &errorRepo.ID,
+				Devices:  devices[:1],
+				Status:   models.UpdateStatusError,
+				DispatchRecords: []models.DispatchRecord{
+					{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusError},
+				},
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+
+			_, err := updateService.RetryUpdateFailedDevices(orgID, update.ID)
+			Expect(err).To(MatchError(new(services.UpdateRepoNotAvailable)))
+		})
+
+		It("should return error when the update does not exist", func() {
+			_, err := updateService.RetryUpdateFailedDevices(orgID, 9999999)
+			Expect(err).To(MatchError(new(services.UpdateNotFoundError)))
+		})
+	})
+
+	Describe("update approval", func() {
+		var orgID string
+		var updateService services.UpdateServiceInterface
+
+		BeforeEach(func() {
+			updateService = services.NewUpdateService(context.Background(), log.WithField("service", "update"))
+			orgID = faker.UUIDHyphenated()
+		})
+
+		createUpdate := func(status string, requestedBy string) models.UpdateTransaction {
+			update := models.UpdateTransaction{OrgID: orgID, Status: status, RequestedBy: requestedBy}
+			Expect(db.DB.Create(&update).Error).ToNot(HaveOccurred())
+			return update
+		}
+
+		It("should approve the update requested by another principal", func() {
+			update := createUpdate(models.UpdateStatusAwaitingApproval, faker.Username())
+
+			approvedUpdate, err := updateService.ApproveUpdate(orgID, update.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(approvedUpdate.Status).To(Equal(models.UpdateStatusCreated))
+			Expect(approvedUpdate.ReviewedBy).To(Equal(common.DefaultPrincipal))
+
+			var savedUpdate models.UpdateTransaction
+			Expect(db.DB.First(&savedUpdate, update.ID).Error).ToNot(HaveOccurred())
+			Expect(savedUpdate.Status).To(Equal(models.UpdateStatusCreated))
+			Expect(savedUpdate.ReviewedBy).To(Equal(common.DefaultPrincipal))
+		})
+
+		It("should not approve the update requested by the same principal", func() {
+			update := createUpdate(models.UpdateStatusAwaitingApproval, common.DefaultPrincipal)
+
+			_, err := updateService.ApproveUpdate(orgID, update.ID)
+			Expect(err).To(MatchError(new(services.UpdateSelfApproval)))
+
+			var savedUpdate models.UpdateTransaction
+			Expect(db.DB.First(&savedUpdate, update.ID).Error).ToNot(HaveOccurred())
+			Expect(savedUpdate.Status).To(Equal(models.UpdateStatusAwaitingApproval))
+		})
+
+		It("should not approve the update requested by an unknown principal", func() {
+			update := createUpdate(models.UpdateStatusAwaitingApproval, "")
+
+			_, err := updateService.ApproveUpdate(orgID, update.ID)
+			Expect(err).To(MatchError(new(services.UpdateRequesterUndefined)))
+
+			var savedUpdate models.UpdateTransaction
+			Expect(db.DB.First(&savedUpdate, update.ID).Error).ToNot(HaveOccurred())
+			Expect(savedUpdate.Status).To(Equal(models.UpdateStatusAwaitingApproval))
+		})
+
+		It("should set the update approval settings of the org only", func() {
+			settings, err := updateService.GetUpdateApprovalSettings(orgID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.RequireApproval).To(BeFalse())
+
+			settings, err = updateService.SetUpdateApprovalSettings(orgID, &models.UpdateApprovalSettings{RequireApproval: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.OrgID).To(Equal(orgID))
+			Expect(settings.RequireApproval).To(BeTrue())
+			settings, err = updateService.GetUpdateApprovalSettings(orgID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.RequireApproval).To(BeTrue())
+
+			otherSettings, err := updateService.GetUpdateApprovalSettings(faker.UUIDHyphenated())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(otherSettings.RequireApproval).To(BeFalse())
+
+			settings, err = updateService.SetUpdateApprovalSettings(orgID, &models.UpdateApprovalSettings{RequireApproval: false})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.RequireApproval).To(BeFalse())
+			var count int64
+			Expect(db.DB.Model(&models.UpdateApprovalSettings{}).Where("org_id = ?", orgID).Count(&count).Error).ToNot(HaveOccurred())
+			Expect(count).To(Equal(int64(1)))
+		})
+
+		It("should reject the update, even by the principal that requested it", func() {
+			update := createUpdate(models.UpdateStatusAwaitingApproval, common.DefaultPrincipal)
+
+			rejectedUpdate, err := updateService.RejectUpdate(orgID, update.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rejectedUpdate.Status).To(Equal(models.UpdateStatusRejected))
+
+			_, err = updateService.ApproveUpdate(orgID, update.ID)
+			Expect(err).To(MatchError(new(services.UpdateNotAwaitingApproval)))
+		})
+
+		It("should not review an update that is not awaiting approval", func() {
+			update := createUpdate(models.UpdateStatusCreated, faker.Username())
+
+			_, err := updateService.ApproveUpdate(orgID, update.ID)
+			Expect(err).To(MatchError(new(services.UpdateNotAwaitingApproval)))
+			_, err = updateService.RejectUpdate(orgID, update.ID)
+			Expect(err).To(MatchError(new(services.UpdateNotAwaitingApproval)))
+		})
+
+		It("should return error when the update does not exist", func() {
+			createUpdate(models.UpdateStatusAwaitingApproval, faker.Username())
+			_, err := updateService.ApproveUpdate(faker.UUIDHyphenated(), 9999999)
+			Expect(err).To(MatchError(new(services.UpdateNotFoundError)))
+		})
+	})
+
+	Describe("set unresponsive dispatch records", func() {
+		var ctrl *gomock.Controller
+		var mockProducerService *mock_kafkacommon.MockProducerServiceInterface
+		var mockProducer *mock_kafkacommon.MockProducer
+		var mockTopicService *mock_kafkacommon.MockTopicServiceInterface
+		var updateService services.UpdateServiceInterface
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			mockProducerService = mock_kafkacommon.NewMockProducerServiceInterface(ctrl)
+			mockProducer = mock_kafkacommon.NewMockProducer(ctrl)
+			mockTopicService = mock_kafkacommon.NewMockTopicServiceInterface(ctrl)
+			updateService = &services.UpdateService{
+				Service:         services.NewService(context.Background(), log.WithField("service", "update")),
+				ProducerService: mockProducerService,
+				TopicService:    mockTopicService,
+			}
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should set the stuck dispatch records unresponsive and update the update status", func() {
+			orgID := faker.UUIDHyphenated()
+			devices := []models.Device{
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: faker.Name()},
+				{OrgID: orgID, UUID: faker.UUIDHyphenated(), Name: faker.Name()},
+			}
+			Expect(db.DB.Create(&devices).Error).ToNot(HaveOccurred())
+			update := models.UpdateTransaction{
+				OrgID:   orgID,
+				Devices: devices,
+				Status:  models.UpdateStatusBuilding,
+				DispatchRecords: []models.DispatchRecord{
+					{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusRunning},
+					{DeviceID: devices[1].ID, Status: models.DispatchRecordStatusComplete},
+				},
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&update).Error).ToNot(HaveOccurred())
+			pastDeadline := time.Now().Add(-time.Duration(config.Get().DispatchRecordTimeout+1) * time.Minute)
+			Expect(db.DB.Model(&models.DispatchRecord{}).Where("id IN (?)", []uint{update.DispatchRecords[0].ID, update.DispatchRecords[1].ID}).
+				UpdateColumn("updated_at", pastDeadline).Error).ToNot(HaveOccurred())
+			// a recent running dispatch record is not stuck
+			recentUpdate := models.UpdateTransaction{
+				OrgID:           orgID,
+				Devices:         devices[:1],
+				Status:          models.UpdateStatusBuilding,
+				DispatchRecords: []models.DispatchRecord{{DeviceID: devices[0].ID, Status: models.DispatchRecordStatusPending}},
+			}
+			Expect(db.DB.Omit("Devices.*").Create(&recentUpdate).Error).ToNot(HaveOccurred())
+
+			mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(nil)
+			mockProducerService.EXPECT().GetProducerInstance().Return(mockProducer)
+			mockTopicService.EXPECT().GetTopic(services.NotificationTopic).Return(services.NotificationTopic, nil)
+
+			err := updateService.SetUnresponsiveDispatchRecords()
+			Expect(err).ToNot(HaveOccurred())
+
+			var dispatchRecords []models.DispatchRecord
+			Expect(db.DB.Order("id").Find(&dispatchRecords, []uint{
+				update.DispatchRecords[0].ID, update.DispatchRecords[1].ID, recentUpdate.DispatchRecords[0].ID,
+			}).Error).ToNot(HaveOccurred())
+			Expect(dispatchRecords).To(HaveLen(3))
+			Expect(dispatchRecords[0].Status).To(Equal(models.DispatchRecordStatusUnresponsive))
+			Expect(dispatchRecords[0].Reason).To(Equal(models.UpdateReasonTimeout))
+			Expect(dispatchRecords[1].Status).To(Equal(models.DispatchRecordStatusComplete))
+			Expect(dispatchRecords[2].Status).To(Equal(models.DispatchRecordStatusPending))
+
+			var savedUpdate models.UpdateTransaction
+			Expect(db.DB.First(&savedUpdate, update.ID).Error).ToNot(HaveOccurred())
+			Expect(savedUpdate.Status).To(Equal(models.UpdateStatusDeviceUnresponsive))
+			var savedRecentUpdate models.UpdateTransaction
+			Expect(db.DB.First(&savedRecentUpdate, recentUpdate.ID).Error).ToNot(HaveOccurred())
+			Expect(savedRecentUpdate.Status).To(Equal(models.UpdateStatusBuilding))
+
+			// the unresponsive dispatch records are not processed again
+			err = updateService.SetUnresponsiveDispatchRecords()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
 })
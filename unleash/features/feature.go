@@ -78,11 +78,17 @@ var CleanUPDevices = &Flag{Name: "edge-management.cleanup_devices", EnvVar: "FEA
 // CleanUPOrphanCommits is a feature flag to use for cleanup orphan commits
 var CleanUPOrphanCommits = &Flag{Name: "edge-management.cleanup_orphan_commits", EnvVar: "FEATURE_CLEANUP_ORPHAN_COMMITS"}
 
+// GarbageCollection is a feature flag to use for the garbage collection of unreferenced static deltas and update repos
+var GarbageCollection = &Flag{Name: "edge-management.garbage_collection", EnvVar: "FEATURE_GARBAGE_COLLECTION"}
+
 // STATIC DELTA FLAGS
 
 // HideCreateGroup toggles creation of static deltas
 var HideCreateGroup = &Flag{Name: "edge-management.hide-create-group", EnvVar: "FEATURE_HIDE_CREATE_GROUP"}
 
+// StaticDeltaPregeneration toggles the generation of static deltas from the fleet commits when an image version succeeds
+var StaticDeltaPregeneration = &Flag{Name: "edge-management.static_delta_pregeneration", EnvVar: "FEATURE_STATIC_DELTA_PREGENERATION"}
+
 // EdgeParityGroupsMigration toggles edge parity groups migration
 var EdgeParityGroupsMigration = &Flag{Name: "edgeParity.groups-migration", EnvVar: "FEATURE_EDGE_PARITY_GROUPS_MIGRATION"}
 
@@ -95,6 +101,12 @@ var EdgeParityInventoryGroupsEnabled = &Flag{Name: "edgeParity.inventory-groups-
 // EdgeParityInventoryRbac is a feature flag for inventory rbac usage
 var EdgeParityInventoryRbac = &Flag{Name: "edgeParity.inventory-rbac", EnvVar: "FEATURE_INVENTORY_RBAC"}
 
+// DeviceGroupsRbac is a feature flag to restrict the device groups a user can see and manage to its rbac resource definitions
+var DeviceGroupsRbac = &Flag{Name: "edge-management.device_groups_rbac", EnvVar: "FEATURE_DEVICE_GROUPS_RBAC"}
+
+// DeviceStalenessCulling is a feature flag to query unleash whether the devices not seen are marked stale then culled
+var DeviceStalenessCulling = &Flag{Name: "edge-management.device_staleness_culling", EnvVar: "FEATURE_DEVICE_STALENESS_CULLING"}
+
 // DB LOGGING FLAGS
 
 // SilentGormLogging toggles noisy logging from Gorm (using for tests during development on slow machines/connections
@@ -111,6 +123,16 @@ var PulpIntegrationDisableAWSRepoStore = &Flag{Name: "edge-management.pulp_integ
 // PulpIntegrationUpdateViaPulp uses the Pulp Distribution URL for image and system updates
 var PulpIntegrationUpdateViaPulp = &Flag{Name: "edge-management.pulp_integration_updateviapulp", EnvVar: "FEATURE_PULP_INTEGRATION_UPDATEVIAPULP"}
 
+// UPDATE FLAGS
+
+// UpdatePolicies is a feature flag to query unleash whether the device groups update automatically following their update policy
+var UpdatePolicies = &Flag{Name: "edge-management.update_policies", EnvVar: "FEATURE_UPDATE_POLICIES"}
+
+// PLAYBOOK FLAGS
+
+// DynamicPlaybookSigning signs the update playbooks with the configured signing key instead of the static template signature
+var DynamicPlaybookSigning = &Flag{Name: "edge-management.dynamic_playbook_signing", EnvVar: "FEATURE_DYNAMIC_PLAYBOOK_SIGNING"}
+
 // (ADD FEATURE FLAGS ABOVE)
 // FEATURE FLAG CHECK CODE
 